@@ -18,6 +18,89 @@ type Config struct {
 	Log      LogConfig      `mapstructure:"log"`
 	K8s      K8sConfig      `mapstructure:"k8s"`
 	Grafana  GrafanaConfig  `mapstructure:"grafana"`
+
+	ConfigHistory  ConfigHistoryConfig  `mapstructure:"config_history"`
+	ExternalSecret ExternalSecretConfig `mapstructure:"external_secret"`
+	LogExport      LogExportConfig      `mapstructure:"log_export"`
+	Notification   NotificationConfig   `mapstructure:"notification"`
+	AlertHistory   AlertHistoryConfig   `mapstructure:"alert_history"`
+	QueryConsole   QueryConsoleConfig   `mapstructure:"query_console"`
+	Cost           CostConfig           `mapstructure:"cost"`
+
+	CapacityForecast CapacityForecastConfig `mapstructure:"capacity_forecast"`
+	HealthDiagnosis  HealthDiagnosisConfig  `mapstructure:"health_diagnosis"`
+	CertificateScan  CertificateScanConfig  `mapstructure:"certificate_scan"`
+}
+
+// ConfigHistoryConfig ConfigMap/Secret 版本历史配置
+type ConfigHistoryConfig struct {
+	EncryptionKey   string `mapstructure:"encryption_key"`   // Secret 快照加密密钥，为空时使用 JWT 密钥
+	ObserveInformer bool   `mapstructure:"observe_informer"` // 是否记录 Informer 观察到的外部修改
+	MaxVersions     int    `mapstructure:"max_versions"`     // 每个对象保留的版本数
+}
+
+// ExternalSecretConfig 外部密钥同步配置
+type ExternalSecretConfig struct {
+	FileRootDir string `mapstructure:"file_root_dir"` // 文件密钥源允许读取的根目录，为空时不允许使用文件密钥源
+}
+
+// LogExportConfig 日志导出配置
+type LogExportConfig struct {
+	Dir            string `mapstructure:"dir"`              // 异步导出文件目录
+	RetentionHours int    `mapstructure:"retention_hours"`  // 导出文件保留时长
+	MaxSyncEntries int    `mapstructure:"max_sync_entries"` // 同步流式导出的最大条数，超过时需使用异步任务
+	MaxJobEntries  int    `mapstructure:"max_job_entries"`  // 单个异步导出任务的最大条数
+	Workers        int    `mapstructure:"workers"`          // 并发执行的导出任务数
+}
+
+// NotificationConfig 通知中心配置
+type NotificationConfig struct {
+	MaxAttempts                int `mapstructure:"max_attempts"`                  // 单条通知的最大发送次数
+	RetryBaseSeconds           int `mapstructure:"retry_base_seconds"`            // 首次重试间隔，之后每次翻倍（最长 30 分钟）
+	HealthCheckIntervalSeconds int `mapstructure:"health_check_interval_seconds"` // 集群健康探测周期，0 表示不探测
+	HealthScoreDrop            int `mapstructure:"health_score_drop"`             // 健康评分较上次诊断下降超过该值时通知
+}
+
+// AlertHistoryConfig 告警历史配置
+type AlertHistoryConfig struct {
+	PollIntervalSeconds int    `mapstructure:"poll_interval_seconds"` // 拉取 Alertmanager 告警的周期，0 表示只通过 webhook 接收
+	WebhookToken        string `mapstructure:"webhook_token"`         // Alertmanager webhook 的 Bearer Token，为空时不开放 webhook 接收
+	TeamLabel           string `mapstructure:"team_label"`            // 用于按团队统计的告警标签
+	RetentionDays       int    `mapstructure:"retention_days"`        // 告警历史保留天数
+}
+
+// QueryConsoleConfig PromQL 查询控制台配置
+type QueryConsoleConfig struct {
+	RateLimitPerMinute int `mapstructure:"rate_limit_per_minute"` // 每个用户每分钟的查询与补全请求数，0 表示不限流
+	Burst              int `mapstructure:"burst"`                 // 允许的突发请求数
+}
+
+// CostConfig 成本分摊配置
+type CostConfig struct {
+	SnapshotIntervalSeconds int    `mapstructure:"snapshot_interval_seconds"` // 未配置 Prometheus 的集群快照采集周期
+	Currency                string `mapstructure:"currency"`                  // 集群未设置单价时的默认币种
+	TeamLabel               string `mapstructure:"team_label"`                // 集群未设置单价时用于团队归属的命名空间标签
+}
+
+// CapacityForecastConfig 容量预测配置
+type CapacityForecastConfig struct {
+	Threshold     float64 `mapstructure:"threshold"`       // 预测达到该百分比的时间
+	LookbackDays  int     `mapstructure:"lookback_days"`   // 拟合使用的历史天数
+	HorizonDays   int     `mapstructure:"horizon_days"`    // 向后预测的最长天数
+	NodePoolLabel string  `mapstructure:"node_pool_label"` // 节点池标签，为空时只预测集群整体
+}
+
+// HealthDiagnosisConfig 定时健康诊断配置
+type HealthDiagnosisConfig struct {
+	IntervalMinutes int `mapstructure:"interval_minutes"` // 定时诊断周期，0 表示不定时诊断（手动诊断仍会记录历史）
+	RetentionDays   int `mapstructure:"retention_days"`   // 诊断历史保留天数
+}
+
+// CertificateScanConfig 证书过期扫描配置
+type CertificateScanConfig struct {
+	IntervalHours int `mapstructure:"interval_hours"` // 定时扫描周期，0 表示不定时扫描（仍可手动扫描）
+	WarningDays   int `mapstructure:"warning_days"`   // 剩余天数不超过该值时发送告警通知
+	CriticalDays  int `mapstructure:"critical_days"`  // 剩余天数不超过该值时发送严重通知
 }
 
 // GrafanaConfig Grafana 配置
@@ -93,6 +176,18 @@ func Load() *Config {
 	_ = viper.BindEnv("jwt.secret", "JWT_SECRET")
 	_ = viper.BindEnv("jwt.expire_time", "JWT_EXPIRE_TIME")
 
+	// 绑定版本历史环境变量
+	_ = viper.BindEnv("config_history.encryption_key", "CONFIG_HISTORY_ENCRYPTION_KEY")
+
+	// 绑定外部密钥环境变量
+	_ = viper.BindEnv("external_secret.file_root_dir", "EXTERNAL_SECRET_FILE_ROOT_DIR")
+
+	// 绑定日志导出环境变量
+	_ = viper.BindEnv("log_export.dir", "LOG_EXPORT_DIR")
+
+	// 绑定告警历史环境变量
+	_ = viper.BindEnv("alert_history.webhook_token", "ALERT_HISTORY_WEBHOOK_TOKEN")
+
 	// 绑定日志环境变量
 	_ = viper.BindEnv("log.level", "LOG_LEVEL")
 
@@ -195,4 +290,50 @@ func setDefaults() {
 	viper.SetDefault("grafana.url", "http://localhost:3000")
 	viper.SetDefault("grafana.api_key", "")
 	viper.SetDefault("grafana.api_key_file", "") // 支持从文件读取 API Key
+
+	// 版本历史默认配置
+	viper.SetDefault("config_history.observe_informer", false)
+	viper.SetDefault("config_history.max_versions", 50)
+
+	// 日志导出默认配置
+	viper.SetDefault("log_export.dir", "./data/log-exports")
+	viper.SetDefault("log_export.retention_hours", 72)
+	viper.SetDefault("log_export.max_sync_entries", 100000)
+	viper.SetDefault("log_export.max_job_entries", 5000000)
+	viper.SetDefault("log_export.workers", 2)
+
+	// 通知中心默认配置
+	viper.SetDefault("notification.max_attempts", 5)
+	viper.SetDefault("notification.retry_base_seconds", 30)
+	viper.SetDefault("notification.health_check_interval_seconds", 60)
+	viper.SetDefault("notification.health_score_drop", 10)
+
+	// 告警历史默认配置
+	viper.SetDefault("alert_history.poll_interval_seconds", 60)
+	viper.SetDefault("alert_history.team_label", "team")
+	viper.SetDefault("alert_history.retention_days", 90)
+
+	// 查询控制台默认配置
+	viper.SetDefault("query_console.rate_limit_per_minute", 60)
+	viper.SetDefault("query_console.burst", 20)
+
+	// 成本分摊默认配置
+	viper.SetDefault("cost.snapshot_interval_seconds", 600)
+	viper.SetDefault("cost.currency", "CNY")
+	viper.SetDefault("cost.team_label", "team")
+
+	// 容量预测默认配置
+	viper.SetDefault("capacity_forecast.threshold", 85)
+	viper.SetDefault("capacity_forecast.lookback_days", 30)
+	viper.SetDefault("capacity_forecast.horizon_days", 90)
+	viper.SetDefault("capacity_forecast.node_pool_label", "")
+
+	// 定时健康诊断默认配置
+	viper.SetDefault("health_diagnosis.interval_minutes", 60)
+	viper.SetDefault("health_diagnosis.retention_days", 90)
+
+	// 证书过期扫描默认配置
+	viper.SetDefault("certificate_scan.interval_hours", 12)
+	viper.SetDefault("certificate_scan.warning_days", 30)
+	viper.SetDefault("certificate_scan.critical_days", 7)
 }
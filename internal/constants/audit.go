@@ -2,21 +2,22 @@ package constants
 
 // 操作模块定义
 const (
-	ModuleAuth       = "auth"       // 认证：登录、登出、密码修改
-	ModuleCluster    = "cluster"    // 集群：导入、删除、配置
-	ModuleNode       = "node"       // 节点：cordon、uncordon、drain
-	ModulePod        = "pod"        // Pod：删除
-	ModuleWorkload   = "workload"   // 工作负载：deployment/sts/ds/job/cronjob
-	ModuleConfig     = "config"     // 配置：configmap、secret
-	ModuleNetwork    = "network"    // 网络：service、ingress
-	ModuleStorage    = "storage"    // 存储：pvc、pv、storageclass
-	ModuleNamespace  = "namespace"  // 命名空间
-	ModulePermission = "permission" // 权限：用户组、集群权限
-	ModuleSystem     = "system"     // 系统：LDAP、SSH配置
-	ModuleMonitoring = "monitoring" // 监控：Prometheus、Grafana配置
-	ModuleAlert      = "alert"      // 告警：AlertManager、静默规则
-	ModuleArgoCD     = "argocd"     // GitOps：ArgoCD应用
-	ModuleUnknown    = "unknown"    // 未知模块
+	ModuleAuth         = "auth"         // 认证：登录、登出、密码修改
+	ModuleCluster      = "cluster"      // 集群：导入、删除、配置
+	ModuleNode         = "node"         // 节点：cordon、uncordon、drain、标签/污点/注解、维护窗口
+	ModulePod          = "pod"          // Pod：删除
+	ModuleWorkload     = "workload"     // 工作负载：deployment/sts/ds/job/cronjob
+	ModuleConfig       = "config"       // 配置：configmap、secret
+	ModuleNetwork      = "network"      // 网络：service、ingress
+	ModuleStorage      = "storage"      // 存储：pvc、pv、storageclass
+	ModuleNamespace    = "namespace"    // 命名空间
+	ModulePermission   = "permission"   // 权限：用户组、集群权限
+	ModuleSystem       = "system"       // 系统：LDAP、SSH配置
+	ModuleMonitoring   = "monitoring"   // 监控：Prometheus、Grafana配置
+	ModuleAlert        = "alert"        // 告警：AlertManager、静默规则
+	ModuleArgoCD       = "argocd"       // GitOps：ArgoCD应用
+	ModuleNotification = "notification" // 通知中心：通知渠道、订阅
+	ModuleUnknown      = "unknown"      // 未知模块
 )
 
 // 操作动作定义
@@ -43,6 +44,20 @@ const (
 	ActionUncordon = "uncordon"
 	ActionDrain    = "drain"
 
+	// 批量与预览操作
+	ActionBulkUpdate = "bulk_update"
+	ActionPreview    = "preview"
+
+	// Secret 明文查看
+	ActionReveal = "reveal"
+
+	// ConfigMap/Secret 历史版本恢复
+	ActionRestore = "restore"
+
+	// 维护窗口操作
+	ActionCancel  = "cancel"
+	ActionConfirm = "confirm"
+
 	// ArgoCD 操作
 	ActionSync = "sync"
 
@@ -51,25 +66,43 @@ const (
 
 	// 导入操作
 	ActionImport = "import"
+
+	// 导出与下载
+	ActionExport   = "export"
+	ActionDownload = "download"
+
+	// 通知重新发送
+	ActionRetry = "retry"
+
+	// 告警确认与指派
+	ActionAcknowledge = "acknowledge"
+	ActionAssign      = "assign"
+
+	// 静默延长
+	ActionExtend = "extend"
+
+	// PromQL 查询
+	ActionQuery = "query"
 )
 
 // ModuleNames 模块中文名称映射
 var ModuleNames = map[string]string{
-	ModuleAuth:       "认证管理",
-	ModuleCluster:    "集群管理",
-	ModuleNode:       "节点管理",
-	ModulePod:        "Pod管理",
-	ModuleWorkload:   "工作负载",
-	ModuleConfig:     "配置管理",
-	ModuleNetwork:    "网络管理",
-	ModuleStorage:    "存储管理",
-	ModuleNamespace:  "命名空间",
-	ModulePermission: "权限管理",
-	ModuleSystem:     "系统设置",
-	ModuleMonitoring: "监控配置",
-	ModuleAlert:      "告警管理",
-	ModuleArgoCD:     "GitOps",
-	ModuleUnknown:    "未知",
+	ModuleAuth:         "认证管理",
+	ModuleCluster:      "集群管理",
+	ModuleNode:         "节点管理",
+	ModulePod:          "Pod管理",
+	ModuleWorkload:     "工作负载",
+	ModuleConfig:       "配置管理",
+	ModuleNetwork:      "网络管理",
+	ModuleStorage:      "存储管理",
+	ModuleNamespace:    "命名空间",
+	ModulePermission:   "权限管理",
+	ModuleSystem:       "系统设置",
+	ModuleMonitoring:   "监控配置",
+	ModuleAlert:        "告警管理",
+	ModuleArgoCD:       "GitOps",
+	ModuleNotification: "通知中心",
+	ModuleUnknown:      "未知",
 }
 
 // ActionNames 操作中文名称映射
@@ -88,7 +121,20 @@ var ActionNames = map[string]string{
 	ActionCordon:         "禁止调度",
 	ActionUncordon:       "允许调度",
 	ActionDrain:          "驱逐节点",
+	ActionBulkUpdate:     "批量更新",
+	ActionPreview:        "预览",
+	ActionReveal:         "查看明文",
+	ActionRestore:        "恢复版本",
+	ActionCancel:         "取消",
+	ActionConfirm:        "确认完成",
 	ActionSync:           "同步",
 	ActionTest:           "测试",
 	ActionImport:         "导入",
+	ActionExport:         "导出",
+	ActionDownload:       "下载",
+	ActionRetry:          "重试",
+	ActionAcknowledge:    "确认告警",
+	ActionAssign:         "指派",
+	ActionExtend:         "延长",
+	ActionQuery:          "查询",
 }
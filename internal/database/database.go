@@ -86,6 +86,11 @@ func Init(cfg config.DatabaseConfig) (*gorm.DB, error) {
 
 // autoMigrate 自动迁移数据库表
 func autoMigrate(db *gorm.DB) error {
+	// 创建唯一索引前清理历史数据中的重复版本号
+	if err := dedupeConfigVersions(db); err != nil {
+		return err
+	}
+
 	// 禁用外键约束检查
 	db.Exec("SET FOREIGN_KEY_CHECKS = 0")
 
@@ -101,12 +106,36 @@ func autoMigrate(db *gorm.DB) error {
 		&models.TerminalSession{},
 		&models.TerminalCommand{},
 		&models.AuditLog{},
-		&models.OperationLog{},      // 操作审计日志表（新增）
-		&models.SystemSetting{},     // 系统设置表
-		&models.ArgoCDConfig{},      // ArgoCD 配置表
-		&models.UserGroup{},         // 用户组表
-		&models.UserGroupMember{},   // 用户组成员关联表
-		&models.ClusterPermission{}, // 集群权限表
+		&models.OperationLog{},             // 操作审计日志表（新增）
+		&models.SystemSetting{},            // 系统设置表
+		&models.ArgoCDConfig{},             // ArgoCD 配置表
+		&models.UserGroup{},                // 用户组表
+		&models.UserGroupMember{},          // 用户组成员关联表
+		&models.ClusterPermission{},        // 集群权限表
+		&models.MaintenanceWindow{},        // 节点维护窗口表
+		&models.MaintenanceNodeTask{},      // 维护窗口节点任务表
+		&models.SecretSource{},             // 外部密钥源表
+		&models.ExternalSecret{},           // 外部密钥同步表
+		&models.ConfigVersion{},            // ConfigMap/Secret 版本历史表
+		&models.LogParseRule{},             // 日志解析规则表
+		&models.LogExportJob{},             // 日志导出任务表
+		&models.LogAlertRule{},             // 日志告警规则表
+		&models.NotificationChannel{},      // 通知渠道表
+		&models.NotificationSubscription{}, // 通知订阅表
+		&models.NotificationEvent{},        // 通知事件表
+		&models.NotificationDelivery{},     // 通知投递记录表
+		&models.AlertRecord{},              // 告警历史记录表
+		&models.AlertHistoryEvent{},        // 告警生命周期事件表
+		&models.SilenceTemplate{},          // 静默模板表
+		&models.RecurringSilence{},         // 周期静默表
+		&models.SavedQuery{},               // 查询控制台保存查询表
+		&models.CostPricing{},              // 集群资源单价表
+		&models.CostDailyRollup{},          // 成本日汇总表
+		&models.SLO{},                      // SLO 定义表
+		&models.HealthDiagnosisRecord{},    // 健康诊断历史表
+		&models.DiagnosisRuleSetting{},     // 诊断规则集群配置表
+		&models.CustomDiagnosisRule{},      // 自定义诊断规则表
+		&models.ClusterCertificate{},       // 集群证书清单表
 	)
 
 	// 重新启用外键约束检查
@@ -123,6 +152,24 @@ func autoMigrate(db *gorm.DB) error {
 	return err
 }
 
+// dedupeConfigVersions 删除同一对象下重复的版本号（保留最早的记录），仅在唯一索引创建前执行
+func dedupeConfigVersions(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&models.ConfigVersion{}) || migrator.HasIndex(&models.ConfigVersion{}, "idx_config_version_unique") {
+		return nil
+	}
+	result := db.Exec(`DELETE v1 FROM config_versions v1 JOIN config_versions v2
+		ON v1.cluster_id = v2.cluster_id AND v1.kind = v2.kind AND v1.namespace = v2.namespace
+		AND v1.name = v2.name AND v1.version = v2.version AND v1.id > v2.id`)
+	if result.Error != nil {
+		return fmt.Errorf("清理重复配置版本失败: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		logger.Info("已清理重复的配置版本: %d 条", result.RowsAffected)
+	}
+	return nil
+}
+
 // createDefaultPermissions 创建默认权限配置
 func createDefaultPermissions(db *gorm.DB) {
 	// 检查是否已有权限配置
@@ -233,6 +233,10 @@ func (h *AlertHandler) GetAlerts(c *gin.Context) {
 	if alertname := c.Query("alertname"); alertname != "" {
 		filter["alertname"] = alertname
 	}
+	// source=kubepolaris-log 仅查看日志告警
+	if source := c.Query("source"); source != "" {
+		filter["source"] = source
+	}
 
 	// 获取告警列表
 	alerts, err := h.alertManagerService.GetAlerts(c.Request.Context(), config, filter)
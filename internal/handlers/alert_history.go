@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+	"github.com/clay-wangzhi/KubePolaris/internal/services"
+	"github.com/clay-wangzhi/KubePolaris/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AlertHistoryHandler 告警历史处理器
+type AlertHistoryHandler struct {
+	alertHistoryService *services.AlertHistoryService
+	webhookToken        string
+}
+
+// NewAlertHistoryHandler 创建告警历史处理器，webhookToken 为空时不接收 Alertmanager webhook
+func NewAlertHistoryHandler(alertHistoryService *services.AlertHistoryService, webhookToken string) *AlertHistoryHandler {
+	return &AlertHistoryHandler{alertHistoryService: alertHistoryService, webhookToken: webhookToken}
+}
+
+// ListAlerts 分页检索集群告警历史
+func (h *AlertHistoryHandler) ListAlerts(c *gin.Context) {
+	var query models.AlertHistoryQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+	query.ClusterID = parseClusterID(c.Param("clusterID"))
+
+	result, err := h.alertHistoryService.List(&query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": result})
+}
+
+// GetAlert 获取告警记录及生命周期事件
+func (h *AlertHistoryHandler) GetAlert(c *gin.Context) {
+	id, ok := parseUintParam(c, "alertId")
+	if !ok {
+		return
+	}
+	record, err := h.alertHistoryService.Get(parseClusterID(c.Param("clusterID")), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": record})
+}
+
+// AcknowledgeAlert 确认告警
+func (h *AlertHistoryHandler) AcknowledgeAlert(c *gin.Context) {
+	id, ok := parseUintParam(c, "alertId")
+	if !ok {
+		return
+	}
+	var req models.AlertAcknowledgeRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误: " + err.Error(), "data": nil})
+			return
+		}
+	}
+
+	record, err := h.alertHistoryService.Acknowledge(parseClusterID(c.Param("clusterID")), id, c.GetString("username"), req.Note)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.Set("audit_resource_name", record.AlertName)
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "确认成功", "data": record})
+}
+
+// AssignAlert 指派告警处理人
+func (h *AlertHistoryHandler) AssignAlert(c *gin.Context) {
+	id, ok := parseUintParam(c, "alertId")
+	if !ok {
+		return
+	}
+	var req models.AlertAssignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+
+	record, err := h.alertHistoryService.Assign(parseClusterID(c.Param("clusterID")), id, req.AssigneeID, c.GetString("username"), req.Note)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.Set("audit_resource_name", record.AlertName)
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "指派成功", "data": record})
+}
+
+// GetNoiseRanking 告警规则噪音排名；days 默认 7，limit 默认 20
+func (h *AlertHistoryHandler) GetNoiseRanking(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	items, err := h.alertHistoryService.NoiseRanking(parseClusterID(c.Param("clusterID")), alertHistorySince(c, 7), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": items})
+}
+
+// GetMetrics MTTA / MTTR 统计：groupBy=cluster|team，clusterIds 逗号分隔（为空表示所有集群），days 默认 30
+func (h *AlertHistoryHandler) GetMetrics(c *gin.Context) {
+	var clusterIDs []uint
+	for _, part := range strings.Split(c.Query("clusterIds"), ",") {
+		if id := parseClusterID(strings.TrimSpace(part)); id != 0 {
+			clusterIDs = append(clusterIDs, id)
+		}
+	}
+	if c.Param("clusterID") != "" {
+		clusterIDs = []uint{parseClusterID(c.Param("clusterID"))}
+	}
+
+	items, err := h.alertHistoryService.Metrics(c.DefaultQuery("groupBy", "cluster"), clusterIDs, alertHistorySince(c, 30))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": items})
+}
+
+// ReceiveWebhook 接收 Alertmanager webhook 推送（webhook_configs 中使用 Bearer Token 认证）
+func (h *AlertHistoryHandler) ReceiveWebhook(c *gin.Context) {
+	if h.webhookToken == "" {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "未开放 Alertmanager webhook 接收", "data": nil})
+		return
+	}
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(h.webhookToken)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "无效的 webhook token", "data": nil})
+		return
+	}
+	clusterID := parseClusterID(c.Param("clusterID"))
+	if clusterID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的集群ID", "data": nil})
+		return
+	}
+
+	var payload models.AlertmanagerWebhookPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+	count, err := h.alertHistoryService.IngestWebhook(clusterID, &payload)
+	if err != nil {
+		logger.Error("处理 Alertmanager webhook 失败", "cluster_id", clusterID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "接收成功", "data": gin.H{"alerts": count}})
+}
+
+// alertHistorySince 解析 days 查询参数（最长 365 天）得到统计起点
+func alertHistorySince(c *gin.Context, defaultDays int) time.Time {
+	days, err := strconv.Atoi(c.Query("days"))
+	if err != nil || days <= 0 {
+		days = defaultDays
+	}
+	if days > 365 {
+		days = 365
+	}
+	return time.Now().AddDate(0, 0, -days)
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+	"github.com/clay-wangzhi/KubePolaris/internal/services"
+	"github.com/clay-wangzhi/KubePolaris/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AlertManagerRoutingHandler Alertmanager 路由、接收器与抑制规则编辑处理器
+type AlertManagerRoutingHandler struct {
+	clusterService *services.ClusterService
+	routingService *services.AlertManagerRoutingService
+}
+
+// NewAlertManagerRoutingHandler 创建 Alertmanager 路由编辑处理器
+func NewAlertManagerRoutingHandler(clusterService *services.ClusterService, routingService *services.AlertManagerRoutingService) *AlertManagerRoutingHandler {
+	return &AlertManagerRoutingHandler{
+		clusterService: clusterService,
+		routingService: routingService,
+	}
+}
+
+// getCluster 获取路径中的集群
+func (h *AlertManagerRoutingHandler) getCluster(c *gin.Context) (*models.Cluster, bool) {
+	cluster, err := h.clusterService.GetCluster(parseClusterID(c.Param("clusterID")))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "集群不存在", "data": nil})
+		return nil, false
+	}
+	return cluster, true
+}
+
+// ListSources 获取可编辑的路由配置来源（alertmanager.yaml Secret 与 AlertmanagerConfig）
+func (h *AlertManagerRoutingHandler) ListSources(c *gin.Context) {
+	cluster, ok := h.getCluster(c)
+	if !ok {
+		return
+	}
+	sources, err := h.routingService.ListSources(c.Request.Context(), cluster)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": sources})
+}
+
+// GetRouting 获取路由配置，来源通过 kind / namespace / name / key 查询参数指定
+func (h *AlertManagerRoutingHandler) GetRouting(c *gin.Context) {
+	cluster, ok := h.getCluster(c)
+	if !ok {
+		return
+	}
+	source := models.AlertManagerRoutingSource{
+		Kind:      c.DefaultQuery("kind", models.AlertManagerRoutingSourceSecret),
+		Namespace: c.Query("namespace"),
+		Name:      c.Query("name"),
+		Key:       c.Query("key"),
+	}
+	doc, err := h.routingService.GetRouting(c.Request.Context(), cluster, source)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": doc})
+}
+
+// SaveRouting 校验并保存路由配置
+func (h *AlertManagerRoutingHandler) SaveRouting(c *gin.Context) {
+	cluster, ok := h.getCluster(c)
+	if !ok {
+		return
+	}
+	var req models.AlertManagerRoutingSaveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+	c.Set("audit_resource_name", req.Source.Namespace+"/"+req.Source.Name)
+
+	doc, validation, err := h.routingService.SaveRouting(c.Request.Context(), cluster, &req, c.GetString("username"))
+	if err != nil {
+		logger.Error("保存 Alertmanager 路由配置失败", "cluster", cluster.Name, "source", req.Source.Name, "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": validation})
+		return
+	}
+	if !validation.Valid {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "配置校验失败", "data": validation})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "保存成功", "data": gin.H{"document": doc, "warnings": validation.Warnings}})
+}
+
+// ValidateRouting 校验路由配置或完整的 alertmanager.yaml（不保存）
+func (h *AlertManagerRoutingHandler) ValidateRouting(c *gin.Context) {
+	cluster, ok := h.getCluster(c)
+	if !ok {
+		return
+	}
+	var req models.AlertManagerRoutingSaveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+	validation, err := h.routingService.ValidateRouting(c.Request.Context(), cluster, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "校验完成", "data": validation})
+}
+
+// SimulateRouting 模拟一组标签会被路由到哪些接收器
+func (h *AlertManagerRoutingHandler) SimulateRouting(c *gin.Context) {
+	cluster, ok := h.getCluster(c)
+	if !ok {
+		return
+	}
+	var req models.AlertManagerRoutingSimulateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+	result, err := h.routingService.Simulate(c.Request.Context(), cluster, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": result})
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+	"github.com/clay-wangzhi/KubePolaris/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CertificateHandler 集群证书清单处理器
+type CertificateHandler struct {
+	clusterService     *services.ClusterService
+	certificateService *services.CertificateService
+}
+
+// NewCertificateHandler 创建证书清单处理器
+func NewCertificateHandler(clusterService *services.ClusterService, certificateService *services.CertificateService) *CertificateHandler {
+	return &CertificateHandler{
+		clusterService:     clusterService,
+		certificateService: certificateService,
+	}
+}
+
+// ListCertificates 获取集群证书清单
+func (h *CertificateHandler) ListCertificates(c *gin.Context) {
+	var query models.CertificateQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "参数错误: " + err.Error(), "data": nil})
+		return
+	}
+	certs, err := h.certificateService.List(parseClusterID(c.Param("clusterID")), &query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": certs})
+}
+
+// ScanCertificates 立即扫描集群证书并更新证书清单
+func (h *CertificateHandler) ScanCertificates(c *gin.Context) {
+	cluster, err := h.clusterService.GetCluster(parseClusterID(c.Param("clusterID")))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "集群不存在", "data": nil})
+		return
+	}
+	result, err := h.certificateService.Scan(c.Request.Context(), cluster)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "扫描证书失败: " + err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "扫描完成", "data": result})
+}
+
+// ListExpiringCertificates 获取全部集群中即将过期（含已过期）的证书
+func (h *CertificateHandler) ListExpiringCertificates(c *gin.Context) {
+	var query models.ExpiringCertificateQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "参数错误: " + err.Error(), "data": nil})
+		return
+	}
+	certs, err := h.certificateService.Expiring(&query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": certs})
+}
@@ -197,6 +197,7 @@ func (h *ClusterHandler) ImportCluster(c *gin.Context) {
 		SATokenEnc:         req.Token,      // TODO: 需要加密存储
 		CAEnc:              req.CaCert,     // TODO: 需要加密存储
 		Version:            clusterInfo.Version,
+		CertExpireAt:       clusterInfo.CertExpireAt,
 		Status:             clusterInfo.Status,
 		Labels:             "{}",
 		MonitoringConfig:   "{}", // 初始化为空 JSON 对象，避免 MySQL JSON 字段报错
@@ -734,10 +735,11 @@ func (h *ClusterHandler) TestConnection(c *gin.Context) {
 	}
 
 	testResult := gin.H{
-		"version":    clusterInfo.Version,
-		"nodeCount":  clusterInfo.NodeCount,
-		"readyNodes": clusterInfo.ReadyNodes,
-		"status":     clusterInfo.Status,
+		"version":      clusterInfo.Version,
+		"nodeCount":    clusterInfo.NodeCount,
+		"readyNodes":   clusterInfo.ReadyNodes,
+		"status":       clusterInfo.Status,
+		"certExpireAt": clusterInfo.CertExpireAt,
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/services"
+	"github.com/clay-wangzhi/KubePolaris/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConfigVersionHandler ConfigMap/Secret 版本历史处理器
+type ConfigVersionHandler struct {
+	clusterSvc *services.ClusterService
+	versionSvc *services.ConfigVersionService
+}
+
+// NewConfigVersionHandler 创建版本历史处理器
+func NewConfigVersionHandler(clusterSvc *services.ClusterService, versionSvc *services.ConfigVersionService) *ConfigVersionHandler {
+	return &ConfigVersionHandler{clusterSvc: clusterSvc, versionSvc: versionSvc}
+}
+
+// ListConfigMapVersions 获取ConfigMap版本列表
+func (h *ConfigVersionHandler) ListConfigMapVersions(c *gin.Context) {
+	h.listVersions(c, services.ConfigKindConfigMap)
+}
+
+// ListSecretVersions 获取Secret版本列表
+func (h *ConfigVersionHandler) ListSecretVersions(c *gin.Context) {
+	h.listVersions(c, services.ConfigKindSecret)
+}
+
+// DiffConfigMapVersions 比较ConfigMap两个版本
+func (h *ConfigVersionHandler) DiffConfigMapVersions(c *gin.Context) {
+	h.diffVersions(c, services.ConfigKindConfigMap)
+}
+
+// DiffSecretVersions 比较Secret两个版本（只返回键名变化）
+func (h *ConfigVersionHandler) DiffSecretVersions(c *gin.Context) {
+	h.diffVersions(c, services.ConfigKindSecret)
+}
+
+// RestoreConfigMap 将ConfigMap恢复到指定版本
+func (h *ConfigVersionHandler) RestoreConfigMap(c *gin.Context) {
+	h.restore(c, services.ConfigKindConfigMap)
+}
+
+// RestoreSecret 将Secret恢复到指定版本
+func (h *ConfigVersionHandler) RestoreSecret(c *gin.Context) {
+	h.restore(c, services.ConfigKindSecret)
+}
+
+// GetConfigMapConsumers 获取引用ConfigMap的工作负载
+func (h *ConfigVersionHandler) GetConfigMapConsumers(c *gin.Context) {
+	h.consumers(c, services.ConfigKindConfigMap)
+}
+
+// GetSecretConsumers 获取引用Secret的工作负载
+func (h *ConfigVersionHandler) GetSecretConsumers(c *gin.Context) {
+	h.consumers(c, services.ConfigKindSecret)
+}
+
+func (h *ConfigVersionHandler) listVersions(c *gin.Context, kind string) {
+	versions, err := h.versionSvc.ListVersions(parseClusterID(c.Param("clusterID")), kind, c.Param("namespace"), c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": versions})
+}
+
+func (h *ConfigVersionHandler) diffVersions(c *gin.Context, kind string) {
+	from, err := strconv.Atoi(c.Query("from"))
+	if err != nil || from <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的起始版本: " + c.Query("from"), "data": nil})
+		return
+	}
+	to, _ := strconv.Atoi(c.DefaultQuery("to", "0"))
+
+	diff, err := h.versionSvc.Diff(parseClusterID(c.Param("clusterID")), kind, c.Param("namespace"), c.Param("name"), from, to)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": diff})
+}
+
+func (h *ConfigVersionHandler) restore(c *gin.Context, kind string) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的版本号: " + c.Param("version"), "data": nil})
+		return
+	}
+
+	cluster, err := h.clusterSvc.GetCluster(parseClusterID(c.Param("clusterID")))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "集群不存在", "data": nil})
+		return
+	}
+	c.Set("cluster_name", cluster.Name)
+	c.Set("audit_resource_name", fmt.Sprintf("%s@v%d", c.Param("name"), version))
+
+	k8sClient, err := services.NewK8sClientForCluster(cluster)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "创建K8s客户端失败: " + err.Error(), "data": nil})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	namespace, name, username := c.Param("namespace"), c.Param("name"), c.GetString("username")
+	var resourceVersion string
+	if kind == services.ConfigKindSecret {
+		result, restoreErr := h.versionSvc.RestoreSecret(ctx, k8sClient.GetClientset(), cluster.ID, namespace, name, version, username)
+		err = restoreErr
+		if result != nil {
+			resourceVersion = result.ResourceVersion
+		}
+	} else {
+		result, restoreErr := h.versionSvc.RestoreConfigMap(ctx, k8sClient.GetClientset(), cluster.ID, namespace, name, version, username)
+		err = restoreErr
+		if result != nil {
+			resourceVersion = result.ResourceVersion
+		}
+	}
+	if err != nil {
+		logger.Error("恢复历史版本失败", "kind", kind, "namespace", namespace, "name", name, "version", version, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error(), "data": nil})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "恢复成功",
+		"data": gin.H{
+			"name":            name,
+			"namespace":       namespace,
+			"version":         version,
+			"resourceVersion": resourceVersion,
+		},
+	})
+}
+
+func (h *ConfigVersionHandler) consumers(c *gin.Context, kind string) {
+	cluster, err := h.clusterSvc.GetCluster(parseClusterID(c.Param("clusterID")))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "集群不存在", "data": nil})
+		return
+	}
+
+	k8sClient, err := services.NewK8sClientForCluster(cluster)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "创建K8s客户端失败: " + err.Error(), "data": nil})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	consumers, err := services.FindConsumers(ctx, k8sClient.GetClientset(), kind, c.Param("namespace"), c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": consumers})
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/clay-wangzhi/KubePolaris/internal/config"
 	"github.com/clay-wangzhi/KubePolaris/internal/k8s"
 	"github.com/clay-wangzhi/KubePolaris/internal/middleware"
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
 	"github.com/clay-wangzhi/KubePolaris/internal/services"
 	"github.com/clay-wangzhi/KubePolaris/pkg/logger"
 )
@@ -26,14 +27,16 @@ type ConfigMapHandler struct {
 	cfg        *config.Config
 	clusterSvc *services.ClusterService
 	k8sMgr     *k8s.ClusterInformerManager
+	versionSvc *services.ConfigVersionService
 }
 
-func NewConfigMapHandler(db *gorm.DB, cfg *config.Config, clusterSvc *services.ClusterService, k8sMgr *k8s.ClusterInformerManager) *ConfigMapHandler {
+func NewConfigMapHandler(db *gorm.DB, cfg *config.Config, clusterSvc *services.ClusterService, k8sMgr *k8s.ClusterInformerManager, versionSvc *services.ConfigVersionService) *ConfigMapHandler {
 	return &ConfigMapHandler{
 		db:         db,
 		cfg:        cfg,
 		clusterSvc: clusterSvc,
 		k8sMgr:     k8sMgr,
+		versionSvc: versionSvc,
 	}
 }
 
@@ -351,6 +354,11 @@ func (h *ConfigMapHandler) DeleteConfigMap(c *gin.Context) {
 
 	clientset := k8sClient.GetClientset()
 
+	// 删除前保存最后一个版本，便于误删后恢复
+	if existing, getErr := clientset.CoreV1().ConfigMaps(namespace).Get(context.Background(), name, metav1.GetOptions{}); getErr == nil {
+		h.versionSvc.RecordConfigMap(cluster.ID, nil, existing, models.ConfigVersionSourceKubePolaris, c.GetString("username"))
+	}
+
 	// 删除ConfigMap
 	err = clientset.CoreV1().ConfigMaps(namespace).Delete(context.Background(), name, metav1.DeleteOptions{})
 	if err != nil {
@@ -427,6 +435,7 @@ func (h *ConfigMapHandler) CreateConfigMap(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("创建ConfigMap失败: %v", err)})
 		return
 	}
+	h.versionSvc.RecordConfigMap(cluster.ID, nil, created, models.ConfigVersionSourceKubePolaris, c.GetString("username"))
 
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
@@ -491,6 +500,8 @@ func (h *ConfigMapHandler) UpdateConfigMap(c *gin.Context) {
 		return
 	}
 
+	before := configMap.DeepCopy()
+
 	// 更新ConfigMap
 	configMap.Labels = req.Labels
 	configMap.Annotations = req.Annotations
@@ -502,6 +513,7 @@ func (h *ConfigMapHandler) UpdateConfigMap(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("更新ConfigMap失败: %v", err)})
 		return
 	}
+	h.versionSvc.RecordConfigMap(cluster.ID, before, updated, models.ConfigVersionSourceKubePolaris, c.GetString("username"))
 
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
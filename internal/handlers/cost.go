@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/middleware"
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+	"github.com/clay-wangzhi/KubePolaris/internal/services"
+	"github.com/clay-wangzhi/KubePolaris/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CostHandler 成本分摊处理器（单价配置、分摊查询与 CSV 导出）
+type CostHandler struct {
+	costService *services.CostService
+}
+
+// NewCostHandler 创建成本分摊处理器
+func NewCostHandler(costService *services.CostService) *CostHandler {
+	return &CostHandler{costService: costService}
+}
+
+// GetPricing 获取集群单价
+func (h *CostHandler) GetPricing(c *gin.Context) {
+	clusterID, ok := parseUintParam(c, "clusterID")
+	if !ok {
+		return
+	}
+	pricing, err := h.costService.GetPricing(clusterID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": pricing})
+}
+
+// UpdatePricing 更新集群单价
+func (h *CostHandler) UpdatePricing(c *gin.Context) {
+	clusterID, ok := parseUintParam(c, "clusterID")
+	if !ok {
+		return
+	}
+	var req models.CostPricingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+	pricing, err := h.costService.UpdatePricing(clusterID, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "保存成功", "data": pricing})
+}
+
+// GetAllocation 查询成本分摊，参数 start、end（2006-01-02，默认最近 30 天）、groupBy（namespace/workload/team）、namespace、team
+func (h *CostHandler) GetAllocation(c *gin.Context) {
+	report, ok := h.allocation(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": report})
+}
+
+// ExportAllocation 以 CSV 导出成本分摊，参数同 GetAllocation
+func (h *CostHandler) ExportAllocation(c *gin.Context) {
+	report, ok := h.allocation(c)
+	if !ok {
+		return
+	}
+	filename := fmt.Sprintf("cost-%s-%s-%s-%s.csv", c.Param("clusterID"), report.GroupBy, report.StartDate, report.EndDate)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := services.WriteCostCSV(c.Writer, report); err != nil {
+		logger.Error("导出成本分摊失败", "error", err)
+	}
+}
+
+// Rollup 按 Prometheus 历史数据重新汇总某一天的成本（如修改单价后），参数 date（2006-01-02）
+func (h *CostHandler) Rollup(c *gin.Context) {
+	clusterID, ok := parseUintParam(c, "clusterID")
+	if !ok {
+		return
+	}
+	var req struct {
+		Date string `json:"date" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+	day, err := time.ParseInLocation("2006-01-02", req.Date, time.Local)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的日期: " + req.Date, "data": nil})
+		return
+	}
+	c.Set("audit_resource_name", req.Date)
+
+	rows, err := h.costService.RollupDay(c.Request.Context(), clusterID, day)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "汇总完成", "data": gin.H{"date": req.Date, "rows": rows}})
+}
+
+// allocation 解析查询条件并按命名空间权限查询分摊结果，失败时直接写出响应
+func (h *CostHandler) allocation(c *gin.Context) (*models.CostAllocationReport, bool) {
+	clusterID, ok := parseUintParam(c, "clusterID")
+	if !ok {
+		return nil, false
+	}
+	var query models.CostAllocationQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误: " + err.Error(), "data": nil})
+		return nil, false
+	}
+	if _, hasAccess := middleware.CheckNamespacePermission(c, query.Namespace); !hasAccess {
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "message": fmt.Sprintf("无权访问命名空间: %s", query.Namespace), "data": nil})
+		return nil, false
+	}
+
+	// 只有部分命名空间权限的用户，团队等汇总也只统计其可见的命名空间
+	var namespaces []string
+	if _, hasAll := middleware.GetAllowedNamespaces(c); !hasAll {
+		all, err := h.costService.Namespaces(clusterID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "查询成本数据失败: " + err.Error(), "data": nil})
+			return nil, false
+		}
+		namespaces = middleware.FilterNamespaces(c, all)
+	}
+
+	report, err := h.costService.Allocation(clusterID, &query, namespaces)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return nil, false
+	}
+	return report, true
+}
@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/middleware"
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+	"github.com/clay-wangzhi/KubePolaris/internal/services"
+	"github.com/clay-wangzhi/KubePolaris/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExternalSecretHandler 外部密钥源与外部密钥处理器
+type ExternalSecretHandler struct {
+	externalSecretService *services.ExternalSecretService
+}
+
+// NewExternalSecretHandler 创建外部密钥处理器
+func NewExternalSecretHandler(externalSecretService *services.ExternalSecretService) *ExternalSecretHandler {
+	return &ExternalSecretHandler{externalSecretService: externalSecretService}
+}
+
+// ListSources 获取密钥源列表
+func (h *ExternalSecretHandler) ListSources(c *gin.Context) {
+	clusterID := parseClusterID(c.Param("clusterID"))
+	sources, err := h.externalSecretService.ListSources(clusterID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": sources})
+}
+
+// CreateSource 创建密钥源
+func (h *ExternalSecretHandler) CreateSource(c *gin.Context) {
+	h.saveSource(c, 0)
+}
+
+// UpdateSource 更新密钥源
+func (h *ExternalSecretHandler) UpdateSource(c *gin.Context) {
+	sourceID, ok := parseUintParam(c, "sourceId")
+	if !ok {
+		return
+	}
+	h.saveSource(c, sourceID)
+}
+
+func (h *ExternalSecretHandler) saveSource(c *gin.Context, sourceID uint) {
+	clusterID := parseClusterID(c.Param("clusterID"))
+	var req models.SecretSourceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+
+	source, err := h.externalSecretService.SaveSource(clusterID, sourceID, &req, c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "保存成功", "data": source})
+}
+
+// DeleteSource 删除密钥源
+func (h *ExternalSecretHandler) DeleteSource(c *gin.Context) {
+	sourceID, ok := parseUintParam(c, "sourceId")
+	if !ok {
+		return
+	}
+	if err := h.externalSecretService.DeleteSource(parseClusterID(c.Param("clusterID")), sourceID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "删除成功", "data": nil})
+}
+
+// TestSource 测试密钥源，读取指定路径并返回键名
+func (h *ExternalSecretHandler) TestSource(c *gin.Context) {
+	sourceID, ok := parseUintParam(c, "sourceId")
+	if !ok {
+		return
+	}
+	var req struct {
+		Path string `json:"path" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+
+	keys, err := h.externalSecretService.TestSource(c.Request.Context(), parseClusterID(c.Param("clusterID")), sourceID, req.Path)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "密钥源测试失败: " + err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "连接成功", "data": gin.H{"keys": keys}})
+}
+
+// ListExternalSecrets 获取外部密钥列表
+func (h *ExternalSecretHandler) ListExternalSecrets(c *gin.Context) {
+	items, err := h.externalSecretService.ListExternalSecrets(parseClusterID(c.Param("clusterID")), c.Query("namespace"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error(), "data": nil})
+		return
+	}
+	// 只返回用户有权限的命名空间中的外部密钥
+	if _, hasAll := middleware.GetAllowedNamespaces(c); !hasAll {
+		visible := make([]models.ExternalSecret, 0, len(items))
+		for _, item := range items {
+			if middleware.HasNamespaceAccess(c, item.Namespace) {
+				visible = append(visible, item)
+			}
+		}
+		items = visible
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": items})
+}
+
+// GetExternalSecret 获取外部密钥详情
+func (h *ExternalSecretHandler) GetExternalSecret(c *gin.Context) {
+	item, ok := h.getExternalSecret(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": item})
+}
+
+// CreateExternalSecret 创建外部密钥并立即同步
+func (h *ExternalSecretHandler) CreateExternalSecret(c *gin.Context) {
+	h.saveExternalSecret(c, 0)
+}
+
+// UpdateExternalSecret 更新外部密钥并立即同步
+func (h *ExternalSecretHandler) UpdateExternalSecret(c *gin.Context) {
+	item, ok := h.getExternalSecret(c)
+	if !ok {
+		return
+	}
+	h.saveExternalSecret(c, item.ID)
+}
+
+func (h *ExternalSecretHandler) saveExternalSecret(c *gin.Context, id uint) {
+	clusterID := parseClusterID(c.Param("clusterID"))
+	var req models.ExternalSecretRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+	// 外部密钥会在目标命名空间中创建并持续同步 Secret
+	if _, hasAccess := middleware.CheckNamespacePermission(c, req.Namespace); !hasAccess {
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "message": fmt.Sprintf("无权访问命名空间: %s", req.Namespace), "data": nil})
+		return
+	}
+
+	item, err := h.externalSecretService.SaveExternalSecret(c.Request.Context(), clusterID, id, &req, c.GetUint("user_id"), c.GetString("username"))
+	if err != nil {
+		logger.Error("保存外部密钥失败", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "保存成功", "data": item})
+}
+
+// DeleteExternalSecret 删除外部密钥（保留集群内 Secret）
+func (h *ExternalSecretHandler) DeleteExternalSecret(c *gin.Context) {
+	item, ok := h.getExternalSecret(c)
+	if !ok {
+		return
+	}
+	if err := h.externalSecretService.DeleteExternalSecret(item.ClusterID, item.ID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "删除成功", "data": nil})
+}
+
+// SyncExternalSecret 立即同步外部密钥
+func (h *ExternalSecretHandler) SyncExternalSecret(c *gin.Context) {
+	item, ok := h.getExternalSecret(c)
+	if !ok {
+		return
+	}
+
+	drift, err := h.externalSecretService.Sync(c.Request.Context(), item, services.ExternalSecretTriggerManual, c.GetString("username"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "同步失败: " + err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "同步成功", "data": drift})
+}
+
+// GetExternalSecretDrift 检查外部密钥与集群内 Secret 的差异
+func (h *ExternalSecretHandler) GetExternalSecretDrift(c *gin.Context) {
+	item, ok := h.getExternalSecret(c)
+	if !ok {
+		return
+	}
+
+	drift, err := h.externalSecretService.CheckDrift(c.Request.Context(), item)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "检查差异失败: " + err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": drift})
+}
+
+// getExternalSecret 获取路径中的外部密钥并校验其命名空间权限
+func (h *ExternalSecretHandler) getExternalSecret(c *gin.Context) (*models.ExternalSecret, bool) {
+	id, ok := parseUintParam(c, "id")
+	if !ok {
+		return nil, false
+	}
+	item, err := h.externalSecretService.GetExternalSecret(parseClusterID(c.Param("clusterID")), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": err.Error(), "data": nil})
+		return nil, false
+	}
+	if _, hasAccess := middleware.CheckNamespacePermission(c, item.Namespace); !hasAccess {
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "message": fmt.Sprintf("无权访问命名空间: %s", item.Namespace), "data": nil})
+		return nil, false
+	}
+	return item, true
+}
+
+// parseUintParam 解析路径中的数字 ID，失败时写入 400 响应
+func parseUintParam(c *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的ID: " + c.Param(name), "data": nil})
+		return 0, false
+	}
+	return uint(id), true
+}
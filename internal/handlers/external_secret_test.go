@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+)
+
+// ExternalSecretHandlerTestSuite 定义外部密钥处理器测试套件
+type ExternalSecretHandlerTestSuite struct {
+	suite.Suite
+	router *gin.Engine
+}
+
+// SetupTest 每个测试前的设置
+func (s *ExternalSecretHandlerTestSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewExternalSecretHandler(nil)
+	s.router = gin.New()
+	// 模拟 ClusterAccessRequired 写入的开发权限，仅可访问 app-* 命名空间
+	s.router.Use(func(c *gin.Context) {
+		c.Set("cluster_permission", &models.ClusterPermission{PermissionType: models.PermissionTypeDev, Namespaces: `["app-*"]`})
+		c.Next()
+	})
+	s.router.POST("/api/clusters/:clusterID/external-secrets", handler.CreateExternalSecret)
+}
+
+// TestCreateExternalSecret_NamespaceForbidden 测试无目标命名空间权限时拒绝创建
+func (s *ExternalSecretHandlerTestSuite) TestCreateExternalSecret_NamespaceForbidden() {
+	body := `{"namespace":"kube-system","secret_name":"db","source_id":1,"path":"app/db"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/clusters/1/external-secrets", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+
+	assert.Equal(s.T(), http.StatusForbidden, w.Code)
+	assert.Contains(s.T(), w.Body.String(), "kube-system")
+}
+
+// TestExternalSecretHandlerSuite 运行外部密钥处理器测试套件
+func TestExternalSecretHandlerSuite(t *testing.T) {
+	suite.Run(t, new(ExternalSecretHandlerTestSuite))
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+	"github.com/clay-wangzhi/KubePolaris/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LogAlertHandler 日志告警规则处理器
+type LogAlertHandler struct {
+	logAlertService *services.LogAlertService
+}
+
+// NewLogAlertHandler 创建日志告警规则处理器
+func NewLogAlertHandler(logAlertService *services.LogAlertService) *LogAlertHandler {
+	return &LogAlertHandler{logAlertService: logAlertService}
+}
+
+// ListRules 获取日志告警规则列表
+func (h *LogAlertHandler) ListRules(c *gin.Context) {
+	rules, err := h.logAlertService.ListRules(parseClusterID(c.Param("clusterID")))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": rules})
+}
+
+// GetRule 获取日志告警规则详情（含当前窗口计数）
+func (h *LogAlertHandler) GetRule(c *gin.Context) {
+	ruleID, ok := parseUintParam(c, "ruleId")
+	if !ok {
+		return
+	}
+	rule, err := h.logAlertService.GetRule(parseClusterID(c.Param("clusterID")), ruleID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": rule})
+}
+
+// CreateRule 创建日志告警规则
+func (h *LogAlertHandler) CreateRule(c *gin.Context) {
+	h.saveRule(c, 0)
+}
+
+// UpdateRule 更新日志告警规则
+func (h *LogAlertHandler) UpdateRule(c *gin.Context) {
+	ruleID, ok := parseUintParam(c, "ruleId")
+	if !ok {
+		return
+	}
+	h.saveRule(c, ruleID)
+}
+
+func (h *LogAlertHandler) saveRule(c *gin.Context, ruleID uint) {
+	var req models.LogAlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+
+	rule, err := h.logAlertService.SaveRule(parseClusterID(c.Param("clusterID")), ruleID, &req, c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.Set("audit_resource_name", rule.Name)
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "保存成功", "data": rule})
+}
+
+// DeleteRule 删除日志告警规则
+func (h *LogAlertHandler) DeleteRule(c *gin.Context) {
+	ruleID, ok := parseUintParam(c, "ruleId")
+	if !ok {
+		return
+	}
+	if err := h.logAlertService.DeleteRule(parseClusterID(c.Param("clusterID")), ruleID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "删除成功", "data": nil})
+}
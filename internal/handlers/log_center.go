@@ -2,8 +2,10 @@ package handlers
 
 import (
 	"bufio"
+	"compress/gzip"
 	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"sort"
 	"strconv"
@@ -25,18 +27,24 @@ import (
 
 // LogCenterHandler 日志中心处理器
 type LogCenterHandler struct {
-	clusterSvc *services.ClusterService
-	k8sMgr     *k8s.ClusterInformerManager
-	aggregator *services.LogAggregator
-	upgrader   websocket.Upgrader
+	clusterSvc   *services.ClusterService
+	k8sMgr       *k8s.ClusterInformerManager
+	aggregator   *services.LogAggregator
+	logConfigSvc *services.LogConfigService
+	parseRuleSvc *services.LogParseRuleService
+	exportSvc    *services.LogExportService
+	upgrader     websocket.Upgrader
 }
 
 // NewLogCenterHandler 创建日志中心处理器
-func NewLogCenterHandler(clusterSvc *services.ClusterService, k8sMgr *k8s.ClusterInformerManager) *LogCenterHandler {
+func NewLogCenterHandler(clusterSvc *services.ClusterService, k8sMgr *k8s.ClusterInformerManager, logConfigSvc *services.LogConfigService, parseRuleSvc *services.LogParseRuleService, exportSvc *services.LogExportService) *LogCenterHandler {
 	return &LogCenterHandler{
-		clusterSvc: clusterSvc,
-		k8sMgr:     k8sMgr,
-		aggregator: services.NewLogAggregator(clusterSvc),
+		clusterSvc:   clusterSvc,
+		k8sMgr:       k8sMgr,
+		aggregator:   services.NewLogAggregator(clusterSvc, parseRuleSvc),
+		logConfigSvc: logConfigSvc,
+		parseRuleSvc: parseRuleSvc,
+		exportSvc:    exportSvc,
 		upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool {
 				return true
@@ -205,6 +213,13 @@ func (h *LogCenterHandler) SearchLogs(c *gin.Context) {
 		})
 		return
 	}
+	if _, err := services.ParseLogFieldFilters(query.Filters); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
 
 	cluster, err := h.clusterSvc.GetCluster(clusterID)
 	if err != nil {
@@ -215,10 +230,19 @@ func (h *LogCenterHandler) SearchLogs(c *gin.Context) {
 		return
 	}
 
+	backend, err := h.logConfigSvc.BackendForCluster(cluster, h.aggregator)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "获取日志后端失败: " + err.Error(),
+		})
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	results, total, err := h.aggregator.SearchLogs(ctx, cluster, &query)
+	results, total, err := backend.Search(ctx, &query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code":    500,
@@ -266,19 +290,19 @@ func (h *LogCenterHandler) GetLogStats(c *gin.Context) {
 	default:
 		since = time.Hour
 	}
-	startTime := time.Now().Add(-since)
-
-	// 创建K8s客户端
-	var k8sClient *services.K8sClient
-	if cluster.KubeconfigEnc != "" {
-		k8sClient, err = services.NewK8sClientFromKubeconfig(cluster.KubeconfigEnc)
-	} else {
-		k8sClient, err = services.NewK8sClientFromToken(cluster.APIServer, cluster.SATokenEnc, cluster.CAEnc)
+	query := &models.LogQuery{
+		StartTime: time.Now().Add(-since),
+		EndTime:   time.Now(),
 	}
+	if namespace != "" {
+		query.Namespaces = []string{namespace}
+	}
+
+	backend, err := h.logConfigSvc.BackendForCluster(cluster, h.aggregator)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code":    500,
-			"message": "创建K8s客户端失败: " + err.Error(),
+			"message": "获取日志后端失败: " + err.Error(),
 		})
 		return
 	}
@@ -286,61 +310,16 @@ func (h *LogCenterHandler) GetLogStats(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	// 获取事件统计
-	events, err := k8sClient.GetClientset().CoreV1().Events(namespace).List(ctx, metav1.ListOptions{})
+	// 统计由后端聚合完成（kubelet 后端使用 K8s 事件近似统计）
+	stats, err := backend.Stats(ctx, query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code":    500,
-			"message": "获取事件失败: " + err.Error(),
+			"message": "获取日志统计失败: " + err.Error(),
 		})
 		return
 	}
 
-	stats := models.LogStats{}
-	levelCount := make(map[string]int64)
-	nsCount := make(map[string]int64)
-
-	for _, e := range events.Items {
-		// 过滤时间范围
-		if e.LastTimestamp.Time.Before(startTime) {
-			continue
-		}
-
-		stats.TotalCount++
-
-		if e.Type == "Warning" {
-			stats.WarnCount++
-			levelCount["warn"]++
-		} else {
-			stats.InfoCount++
-			levelCount["info"]++
-		}
-
-		// 检查是否包含错误关键词
-		lowerMsg := strings.ToLower(e.Message)
-		if strings.Contains(lowerMsg, "error") ||
-			strings.Contains(lowerMsg, "fail") ||
-			strings.Contains(lowerMsg, "crash") {
-			stats.ErrorCount++
-			levelCount["error"]++
-		}
-
-		nsCount[e.Namespace]++
-	}
-
-	// 转换为统计数组
-	for level, count := range levelCount {
-		stats.LevelStats = append(stats.LevelStats, models.LevelStat{Level: level, Count: count})
-	}
-	for ns, count := range nsCount {
-		stats.NamespaceStats = append(stats.NamespaceStats, models.NamespaceStat{Namespace: ns, Count: count})
-	}
-
-	// 按数量排序命名空间统计
-	sort.Slice(stats.NamespaceStats, func(i, j int) bool {
-		return stats.NamespaceStats[i].Count > stats.NamespaceStats[j].Count
-	})
-
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
 		"message": "获取成功",
@@ -394,6 +373,7 @@ func (h *LogCenterHandler) HandleAggregateLogStream(c *gin.Context) {
 		TailLines:     config.TailLines,
 		SinceSeconds:  config.SinceSeconds,
 		ShowTimestamp: config.ShowTimestamp,
+		Filters:       config.Filters,
 	}
 
 	logCh, err := h.aggregator.AggregateStream(ctx, cluster, config.Targets, opts)
@@ -430,6 +410,12 @@ func (h *LogCenterHandler) HandleAggregateLogStream(c *gin.Context) {
 			"level":     entry.Level,
 			"message":   entry.Message,
 		}
+		if entry.TraceID != "" {
+			msg["trace_id"] = entry.TraceID
+		}
+		if len(entry.Fields) > 0 {
+			msg["fields"] = entry.Fields
+		}
 
 		if err := conn.WriteJSON(msg); err != nil {
 			logger.Info("发送日志失败，客户端可能已断开", "error", err)
@@ -710,7 +696,9 @@ func (h *LogCenterHandler) GetPodsForLogs(c *gin.Context) {
 	})
 }
 
-// ExportLogs 导出日志
+// ExportLogs 流式导出日志
+// 查询参数 format 为 text/ndjson/csv，gzip=true 时压缩输出；超过同步导出上限时截断，
+// 导出条数与是否截断通过 HTTP Trailer 返回，更大的导出请使用异步导出任务
 func (h *LogCenterHandler) ExportLogs(c *gin.Context) {
 	clusterID := parseClusterID(c.Param("clusterID"))
 
@@ -723,6 +711,23 @@ func (h *LogCenterHandler) ExportLogs(c *gin.Context) {
 		return
 	}
 
+	format := c.DefaultQuery("format", models.LogExportFormatText)
+	useGzip := c.Query("gzip") == "true"
+	if _, err := services.NewLogExportEncoder(format, io.Discard); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+	if _, err := services.ParseLogFieldFilters(query.Filters); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
 	cluster, err := h.clusterSvc.GetCluster(clusterID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
@@ -732,38 +737,211 @@ func (h *LogCenterHandler) ExportLogs(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
-	defer cancel()
+	// 设置响应头
+	filename := services.LogExportFileName(cluster.Name, format, useGzip, time.Now())
+	c.Set("audit_resource_name", filename)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Header("Content-Type", services.LogExportContentType(format, useGzip))
+	c.Header("Trailer", "X-Export-Count, X-Export-Truncated, X-Export-Error")
+	c.Status(http.StatusOK)
+
+	var w io.Writer = c.Writer
+	var gz *gzip.Writer
+	if useGzip {
+		gz = gzip.NewWriter(c.Writer)
+		w = gz
+	}
+	count, truncated, err := h.exportSvc.Stream(c.Request.Context(), cluster, &query, format, w, func() {
+		if gz != nil {
+			_ = gz.Flush()
+		}
+		c.Writer.Flush()
+	})
+	if gz != nil {
+		_ = gz.Close()
+	}
 
-	// 设置较大的限制用于导出
-	if query.Limit <= 0 || query.Limit > 10000 {
-		query.Limit = 10000
+	// 响应头已发送，错误只能通过 Trailer 与日志反馈
+	c.Writer.Header().Set("X-Export-Count", strconv.FormatInt(count, 10))
+	c.Writer.Header().Set("X-Export-Truncated", strconv.FormatBool(truncated))
+	if err != nil {
+		logger.Error("导出日志失败", "cluster", cluster.Name, "exported", count, "error", err)
+		c.Writer.Header().Set("X-Export-Error", err.Error())
 	}
+}
 
-	results, _, err := h.aggregator.SearchLogs(ctx, cluster, &query)
+// GetLogConfig 获取集群日志后端配置
+func (h *LogCenterHandler) GetLogConfig(c *gin.Context) {
+	config, err := h.logConfigSvc.GetLogConfig(parseClusterID(c.Param("clusterID")))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code":    500,
-			"message": "获取日志失败: " + err.Error(),
+			"message": "获取日志后端配置失败: " + err.Error(),
+			"data":    nil,
 		})
 		return
 	}
 
-	// 构建导出内容
-	var builder strings.Builder
-	for _, entry := range results {
-		builder.WriteString(fmt.Sprintf("%s [%s] [%s/%s] %s\n",
-			entry.Timestamp.Format(time.RFC3339),
-			strings.ToUpper(entry.Level),
-			entry.Namespace,
-			entry.PodName,
-			entry.Message,
-		))
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data":    config,
+	})
+}
+
+// UpdateLogConfig 更新集群日志后端配置
+func (h *LogCenterHandler) UpdateLogConfig(c *gin.Context) {
+	var config models.LogBackendConfig
+	if err := c.ShouldBindJSON(&config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误: " + err.Error(),
+			"data":    nil,
+		})
+		return
 	}
 
-	// 设置响应头
-	filename := fmt.Sprintf("logs-%s-%s.txt", cluster.Name, time.Now().Format("20060102-150405"))
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
-	c.Header("Content-Type", "text/plain; charset=utf-8")
-	c.String(http.StatusOK, builder.String())
+	if err := h.logConfigSvc.UpdateLogConfig(parseClusterID(c.Param("clusterID")), &config); err != nil {
+		logger.Error("更新日志后端配置失败", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "更新日志后端配置失败: " + err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "更新成功",
+		"data":    nil,
+	})
+}
+
+// TestLogConfig 测试日志后端连接
+func (h *LogCenterHandler) TestLogConfig(c *gin.Context) {
+	var config models.LogBackendConfig
+	if err := c.ShouldBindJSON(&config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误: " + err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	cluster, err := h.clusterSvc.GetCluster(parseClusterID(c.Param("clusterID")))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "集群不存在", "data": nil})
+		return
+	}
+
+	if err := h.logConfigSvc.ValidateConfig(&config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	backend, err := services.NewLogBackend(cluster, &config, h.aggregator)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+
+	if err := backend.Test(c.Request.Context()); err != nil {
+		logger.Error("测试日志后端连接失败", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "连接测试失败: " + err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "连接测试成功",
+		"data":    nil,
+	})
+}
+
+// ListParseRules 获取日志解析规则列表
+func (h *LogCenterHandler) ListParseRules(c *gin.Context) {
+	rules, err := h.parseRuleSvc.ListRules(parseClusterID(c.Param("clusterID")))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": rules})
+}
+
+// CreateParseRule 创建日志解析规则
+func (h *LogCenterHandler) CreateParseRule(c *gin.Context) {
+	h.saveParseRule(c, 0)
+}
+
+// UpdateParseRule 更新日志解析规则
+func (h *LogCenterHandler) UpdateParseRule(c *gin.Context) {
+	ruleID, ok := parseUintParam(c, "ruleId")
+	if !ok {
+		return
+	}
+	h.saveParseRule(c, ruleID)
+}
+
+func (h *LogCenterHandler) saveParseRule(c *gin.Context, ruleID uint) {
+	var req models.LogParseRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+
+	rule, err := h.parseRuleSvc.SaveRule(parseClusterID(c.Param("clusterID")), ruleID, &req, c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.Set("audit_resource_name", rule.Name)
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "保存成功", "data": rule})
+}
+
+// DeleteParseRule 删除日志解析规则
+func (h *LogCenterHandler) DeleteParseRule(c *gin.Context) {
+	ruleID, ok := parseUintParam(c, "ruleId")
+	if !ok {
+		return
+	}
+	if err := h.parseRuleSvc.DeleteRule(parseClusterID(c.Param("clusterID")), ruleID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "删除成功", "data": nil})
+}
+
+// PreviewParseRule 使用解析规则试解析样例日志（不保存规则）
+func (h *LogCenterHandler) PreviewParseRule(c *gin.Context) {
+	var req struct {
+		Rule  models.LogParseRuleRequest `json:"rule"`
+		Lines []string                   `json:"lines" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+
+	rule := &models.LogParseRule{
+		Format:         req.Rule.Format,
+		Pattern:        req.Rule.Pattern,
+		LevelField:     req.Rule.LevelField,
+		MessageField:   req.Rule.MessageField,
+		TraceIDField:   req.Rule.TraceIDField,
+		FoldStackTrace: req.Rule.FoldStackTrace,
+	}
+	if rule.Format == "" {
+		rule.Format = models.LogFormatAuto
+	}
+	if err := services.ValidateLogParseRule(rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "解析成功", "data": services.PreviewRule(rule, req.Lines)})
 }
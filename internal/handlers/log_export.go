@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+	"github.com/clay-wangzhi/KubePolaris/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateExportJob 创建异步日志导出任务
+func (h *LogCenterHandler) CreateExportJob(c *gin.Context) {
+	var req models.LogExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+
+	job, err := h.exportSvc.CreateJob(parseClusterID(c.Param("clusterID")), &req, c.GetUint("user_id"), c.GetString("username"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.Set("audit_resource_name", job.FileName)
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "导出任务已创建", "data": job})
+}
+
+// ListExportJobs 获取日志导出任务列表
+func (h *LogCenterHandler) ListExportJobs(c *gin.Context) {
+	jobs, err := h.exportSvc.ListJobs(parseClusterID(c.Param("clusterID")))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": jobs})
+}
+
+// GetExportJob 获取日志导出任务详情（含进度）
+func (h *LogCenterHandler) GetExportJob(c *gin.Context) {
+	jobID, ok := parseUintParam(c, "jobId")
+	if !ok {
+		return
+	}
+	job, err := h.exportSvc.GetJob(parseClusterID(c.Param("clusterID")), jobID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": job})
+}
+
+// CancelExportJob 取消日志导出任务
+func (h *LogCenterHandler) CancelExportJob(c *gin.Context) {
+	jobID, ok := parseUintParam(c, "jobId")
+	if !ok {
+		return
+	}
+	if err := h.exportSvc.CancelJob(parseClusterID(c.Param("clusterID")), jobID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "已取消", "data": nil})
+}
+
+// DeleteExportJob 删除日志导出任务及其文件
+func (h *LogCenterHandler) DeleteExportJob(c *gin.Context) {
+	jobID, ok := parseUintParam(c, "jobId")
+	if !ok {
+		return
+	}
+	if err := h.exportSvc.DeleteJob(parseClusterID(c.Param("clusterID")), jobID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "删除成功", "data": nil})
+}
+
+// DownloadExportJob 下载导出文件，支持 Range 请求断点续传
+func (h *LogCenterHandler) DownloadExportJob(c *gin.Context) {
+	jobID, ok := parseUintParam(c, "jobId")
+	if !ok {
+		return
+	}
+	job, err := h.exportSvc.GetJob(parseClusterID(c.Param("clusterID")), jobID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": err.Error(), "data": nil})
+		return
+	}
+	file, err := h.exportSvc.OpenArtifact(job)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "读取导出文件失败: " + err.Error(), "data": nil})
+		return
+	}
+
+	// 续传的分段请求不重复记录审计
+	if c.GetHeader("Range") == "" {
+		h.exportSvc.RecordDownload(job, c.GetUint("user_id"), c.GetString("username"), c.ClientIP())
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", job.FileName))
+	c.Header("Content-Type", services.LogExportContentType(job.Format, job.Gzip))
+	http.ServeContent(c.Writer, c.Request, job.FileName, info.ModTime(), file)
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+	"github.com/clay-wangzhi/KubePolaris/internal/services"
+	"github.com/clay-wangzhi/KubePolaris/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaintenanceHandler 节点维护窗口处理器
+type MaintenanceHandler struct {
+	maintenanceService *services.MaintenanceService
+}
+
+// NewMaintenanceHandler 创建节点维护窗口处理器
+func NewMaintenanceHandler(maintenanceService *services.MaintenanceService) *MaintenanceHandler {
+	return &MaintenanceHandler{maintenanceService: maintenanceService}
+}
+
+// ListWindows 获取维护窗口列表
+func (h *MaintenanceHandler) ListWindows(c *gin.Context) {
+	clusterID := parseClusterID(c.Param("clusterID"))
+	if clusterID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的集群ID", "data": nil})
+		return
+	}
+
+	windows, err := h.maintenanceService.ListWindows(clusterID, c.Query("status"))
+	if err != nil {
+		logger.Error("获取维护窗口列表失败", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error(), "data": nil})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data": gin.H{
+			"items": windows,
+			"total": len(windows),
+		},
+	})
+}
+
+// CreateWindow 创建维护窗口
+func (h *MaintenanceHandler) CreateWindow(c *gin.Context) {
+	clusterID := parseClusterID(c.Param("clusterID"))
+	if clusterID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的集群ID", "data": nil})
+		return
+	}
+
+	var req models.CreateMaintenanceWindowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+
+	window, err := h.maintenanceService.CreateWindow(c.Request.Context(), clusterID, &req, c.GetUint("user_id"), c.GetString("username"))
+	if err != nil {
+		logger.Error("创建维护窗口失败", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "创建成功", "data": window})
+}
+
+// GetWindow 获取维护窗口详情及各节点执行进度
+func (h *MaintenanceHandler) GetWindow(c *gin.Context) {
+	clusterID, windowID, ok := parseMaintenanceParams(c)
+	if !ok {
+		return
+	}
+
+	window, err := h.maintenanceService.GetWindow(clusterID, windowID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": err.Error(), "data": nil})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": window})
+}
+
+// CancelWindow 取消维护窗口
+func (h *MaintenanceHandler) CancelWindow(c *gin.Context) {
+	clusterID, windowID, ok := parseMaintenanceParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.maintenanceService.CancelWindow(c.Request.Context(), clusterID, windowID, c.GetString("username")); err != nil {
+		logger.Error("取消维护窗口失败", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "已取消", "data": nil})
+}
+
+// SignalNodeDone 标记节点维护完成，调度器将解除该节点封锁
+func (h *MaintenanceHandler) SignalNodeDone(c *gin.Context) {
+	clusterID, windowID, ok := parseMaintenanceParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.maintenanceService.SignalDone(clusterID, windowID, c.Param("nodeName")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "已标记完成", "data": nil})
+}
+
+// DeleteWindow 删除维护窗口
+func (h *MaintenanceHandler) DeleteWindow(c *gin.Context) {
+	clusterID, windowID, ok := parseMaintenanceParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.maintenanceService.DeleteWindow(clusterID, windowID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "删除成功", "data": nil})
+}
+
+func parseMaintenanceParams(c *gin.Context) (uint, uint, bool) {
+	clusterID := parseClusterID(c.Param("clusterID"))
+	windowID, err := strconv.ParseUint(c.Param("windowId"), 10, 32)
+	if clusterID == 0 || err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的集群ID或维护窗口ID", "data": nil})
+		return 0, 0, false
+	}
+	return clusterID, uint(windowID), true
+}
@@ -15,13 +15,15 @@ import (
 type MonitoringHandler struct {
 	monitoringConfigService *services.MonitoringConfigService
 	prometheusService       *services.PrometheusService
+	metricsServerService    *services.MetricsServerService // 未配置监控时的 CPU / 内存数据来源，可为 nil
 }
 
 // NewMonitoringHandler 创建监控处理器
-func NewMonitoringHandler(monitoringConfigService *services.MonitoringConfigService, prometheusService *services.PrometheusService) *MonitoringHandler {
+func NewMonitoringHandler(monitoringConfigService *services.MonitoringConfigService, prometheusService *services.PrometheusService, metricsServerService *services.MetricsServerService) *MonitoringHandler {
 	return &MonitoringHandler{
 		monitoringConfigService: monitoringConfigService,
 		prometheusService:       prometheusService,
+		metricsServerService:    metricsServerService,
 	}
 }
 
@@ -209,7 +211,7 @@ func (h *MonitoringHandler) GetNodeMetrics(c *gin.Context) {
 		return
 	}
 
-	nodeName := c.Param("nodeName")
+	nodeName := c.Param("name")
 	if nodeName == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"code":    400,
@@ -232,10 +234,9 @@ func (h *MonitoringHandler) GetNodeMetrics(c *gin.Context) {
 	}
 
 	if config.Type == "disabled" {
-		c.JSON(http.StatusOK, gin.H{
-			"code":    200,
-			"message": "监控功能已禁用",
-			"data":    &models.ClusterMetricsData{},
+		// 未配置监控时使用 metrics-server 的实时用量
+		h.respondMetricsServerData(c, func() (*models.ClusterMetricsData, error) {
+			return h.metricsServerService.NodeMetricsData(c.Request.Context(), uint(clusterID), nodeName)
 		})
 		return
 	}
@@ -301,10 +302,9 @@ func (h *MonitoringHandler) GetPodMetrics(c *gin.Context) {
 	}
 
 	if config.Type == "disabled" {
-		c.JSON(http.StatusOK, gin.H{
-			"code":    200,
-			"message": "监控功能已禁用",
-			"data":    &models.ClusterMetricsData{},
+		// 未配置监控时使用 metrics-server 的实时用量
+		h.respondMetricsServerData(c, func() (*models.ClusterMetricsData, error) {
+			return h.metricsServerService.PodMetricsData(c.Request.Context(), uint(clusterID), namespace, podName)
 		})
 		return
 	}
@@ -333,6 +333,34 @@ func (h *MonitoringHandler) GetPodMetrics(c *gin.Context) {
 	})
 }
 
+// respondMetricsServerData 监控禁用时返回 metrics-server 数据；metrics-server 不可用时与原先一样返回空数据
+func (h *MonitoringHandler) respondMetricsServerData(c *gin.Context, load func() (*models.ClusterMetricsData, error)) {
+	if h.metricsServerService == nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    200,
+			"message": "监控功能已禁用",
+			"data":    &models.ClusterMetricsData{},
+		})
+		return
+	}
+
+	metrics, err := load()
+	if err != nil {
+		logger.Warn("从 metrics-server 获取监控数据失败", "error", err)
+		c.JSON(http.StatusOK, gin.H{
+			"code":    200,
+			"message": "监控功能已禁用，metrics-server 数据不可用: " + err.Error(),
+			"data":    &models.ClusterMetricsData{},
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功（数据来自 metrics-server）",
+		"data":    metrics,
+	})
+}
+
 // GetWorkloadMetrics 获取工作负载监控指标
 func (h *MonitoringHandler) GetWorkloadMetrics(c *gin.Context) {
 	clusterIDStr := c.Param("clusterID")
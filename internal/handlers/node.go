@@ -2,12 +2,14 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/clay-wangzhi/KubePolaris/internal/config"
 	"github.com/clay-wangzhi/KubePolaris/internal/k8s"
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
 	"github.com/clay-wangzhi/KubePolaris/internal/services"
 	"github.com/clay-wangzhi/KubePolaris/pkg/logger"
 
@@ -25,10 +27,13 @@ type NodeHandler struct {
 	k8sMgr           *k8s.ClusterInformerManager
 	promService      *services.PrometheusService
 	monitoringCfgSvc *services.MonitoringConfigService
+	notificationSvc  *services.NotificationService
+	metricsServerSvc *services.MetricsServerService // 未配置监控时的节点用量来源，可为 nil
+	nodeOpSvc        *services.NodeOperationService
 }
 
 // NewNodeHandler 创建节点处理器
-func NewNodeHandler(db *gorm.DB, cfg *config.Config, clusterService *services.ClusterService, k8sMgr *k8s.ClusterInformerManager, promService *services.PrometheusService, monitoringCfgSvc *services.MonitoringConfigService) *NodeHandler {
+func NewNodeHandler(db *gorm.DB, cfg *config.Config, clusterService *services.ClusterService, k8sMgr *k8s.ClusterInformerManager, promService *services.PrometheusService, monitoringCfgSvc *services.MonitoringConfigService, notificationSvc *services.NotificationService, metricsServerSvc *services.MetricsServerService) *NodeHandler {
 	return &NodeHandler{
 		db:               db,
 		cfg:              cfg,
@@ -36,6 +41,9 @@ func NewNodeHandler(db *gorm.DB, cfg *config.Config, clusterService *services.Cl
 		k8sMgr:           k8sMgr,
 		promService:      promService,
 		monitoringCfgSvc: monitoringCfgSvc,
+		notificationSvc:  notificationSvc,
+		metricsServerSvc: metricsServerSvc,
+		nodeOpSvc:        services.NewNodeOperationService(),
 	}
 }
 
@@ -357,6 +365,15 @@ func (h *NodeHandler) GetNode(c *gin.Context) {
 	memoryCapacity := node.Status.Capacity.Memory().Value() / (1024 * 1024) // 转换为MB
 	podCapacity := node.Status.Capacity.Pods().Value()
 
+	// 获取节点注解
+	nodeAnnotations := []map[string]string{}
+	for key, value := range node.Annotations {
+		nodeAnnotations = append(nodeAnnotations, map[string]string{
+			"key":   key,
+			"value": value,
+		})
+	}
+
 	// 获取节点地址
 	addresses := []map[string]string{}
 	for _, address := range node.Status.Addresses {
@@ -397,6 +414,7 @@ func (h *NodeHandler) GetNode(c *gin.Context) {
 		"architecture":      node.Status.NodeInfo.Architecture,
 		"taints":            taints,
 		"labels":            nodeLabels,
+		"annotations":       nodeAnnotations,
 		"unschedulable":     node.Spec.Unschedulable,
 		"creationTimestamp": node.CreationTimestamp.Time,
 		"cpuUsage":          cpuUsage,
@@ -558,6 +576,7 @@ func (h *NodeHandler) DrainNode(c *gin.Context) {
 
 	// 驱逐节点
 	err = k8sClient.DrainNode(name, options)
+	h.notificationSvc.NodeDrained(cluster, name, c.GetString("username"), err)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code":    500,
@@ -604,7 +623,25 @@ func (h *NodeHandler) getNodesResourceUsage(ctx context.Context, clusterID uint)
 
 	// 获取集群的监控配置
 	config, err := h.monitoringCfgSvc.GetMonitoringConfig(clusterID)
-	if err != nil || config.Type == "disabled" {
+	if err != nil {
+		return result
+	}
+	if config.Type == "disabled" {
+		// 未配置监控时使用 metrics-server 的实时用量
+		if h.metricsServerSvc == nil {
+			return result
+		}
+		rates, err := h.metricsServerSvc.NodeUsageRates(ctx, clusterID)
+		if err != nil {
+			logger.Warn("从 metrics-server 获取节点资源使用率失败", "error", err)
+			return result
+		}
+		for name, rate := range rates {
+			result[name] = map[string]float64{
+				"cpuUsage":    rate.CPUUsageRate,
+				"memoryUsage": rate.MemoryUsageRate,
+			}
+		}
 		return result
 	}
 
@@ -625,3 +662,129 @@ func (h *NodeHandler) getNodesResourceUsage(ctx context.Context, clusterID uint)
 
 	return result
 }
+
+// UpdateNodeLabels 修改节点标签
+func (h *NodeHandler) UpdateNodeLabels(c *gin.Context) {
+	var req models.NodeChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "参数解析失败: " + err.Error()})
+		return
+	}
+	spec := models.NodeChangeSpec{Labels: req.Labels}
+	h.changeNodes(c, []string{c.Param("name")}, "", &spec, req.DryRun, req.Force)
+}
+
+// UpdateNodeAnnotations 修改节点注解
+func (h *NodeHandler) UpdateNodeAnnotations(c *gin.Context) {
+	var req models.NodeChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "参数解析失败: " + err.Error()})
+		return
+	}
+	spec := models.NodeChangeSpec{Annotations: req.Annotations}
+	h.changeNodes(c, []string{c.Param("name")}, "", &spec, req.DryRun, req.Force)
+}
+
+// UpdateNodeTaints 修改节点污点
+func (h *NodeHandler) UpdateNodeTaints(c *gin.Context) {
+	var req models.NodeChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "参数解析失败: " + err.Error()})
+		return
+	}
+	spec := models.NodeChangeSpec{Taints: req.Taints}
+	h.changeNodes(c, []string{c.Param("name")}, "", &spec, req.DryRun, req.Force)
+}
+
+// PreviewNodeChange 预览单节点标签/污点/注解变更的影响
+func (h *NodeHandler) PreviewNodeChange(c *gin.Context) {
+	var req models.NodeChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "参数解析失败: " + err.Error()})
+		return
+	}
+	h.changeNodes(c, []string{c.Param("name")}, "", &req.NodeChangeSpec, true, false)
+}
+
+// BulkUpdateNodes 对匹配标签选择器的所有节点批量修改标签/污点/注解
+func (h *NodeHandler) BulkUpdateNodes(c *gin.Context) {
+	var req models.NodeBulkChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "参数解析失败: " + err.Error()})
+		return
+	}
+	h.changeNodes(c, req.NodeNames, req.LabelSelector, &req.NodeChangeSpec, req.DryRun, req.Force)
+}
+
+// changeNodes 解析目标节点并执行（或预览）变更
+func (h *NodeHandler) changeNodes(c *gin.Context, nodeNames []string, labelSelector string, spec *models.NodeChangeSpec, dryRun, force bool) {
+	clusterId := c.Param("clusterID")
+	logger.Info("节点元数据变更: cluster=%s, nodes=%v, selector=%s, dryRun=%v", clusterId, nodeNames, labelSelector, dryRun)
+
+	clusterID := parseClusterID(clusterId)
+	cluster, err := h.clusterService.GetCluster(clusterID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    404,
+			"message": "集群不存在",
+		})
+		return
+	}
+
+	if err := h.nodeOpSvc.ValidateChange(spec); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+		return
+	}
+
+	k8sClient, err := services.NewK8sClientForCluster(cluster)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "创建K8s客户端失败: " + err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Minute)
+	defer cancel()
+
+	targets, err := h.nodeOpSvc.ResolveNodes(ctx, k8sClient.GetClientset(), labelSelector, nodeNames)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+		return
+	}
+
+	result, err := h.nodeOpSvc.Execute(ctx, k8sClient.GetClientset(), targets, spec, dryRun, force)
+	if errors.Is(err, services.ErrNodeChangeHasImpact) {
+		c.JSON(http.StatusConflict, gin.H{
+			"code":    409,
+			"message": err.Error(),
+			"data":    result,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "节点变更失败: " + err.Error(),
+		})
+		return
+	}
+
+	message := "预览成功"
+	if result.Applied {
+		message = "节点变更成功"
+		for _, r := range result.Results {
+			if !r.Success {
+				message = "部分节点变更失败"
+				break
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": message,
+		"data":    result,
+	})
+}
@@ -49,7 +49,7 @@ func (s *NodeHandlerTestSuite) SetupTest() {
 
 	cfg := &config.Config{}
 	clusterService := services.NewClusterService(gormDB)
-	s.handler = NewNodeHandler(gormDB, cfg, clusterService, nil, nil, nil)
+	s.handler = NewNodeHandler(gormDB, cfg, clusterService, nil, nil, nil, nil, nil)
 
 	s.router = gin.New()
 	s.router.GET("/api/clusters/:clusterID/nodes", s.handler.GetNodes)
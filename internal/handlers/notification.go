@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+	"github.com/clay-wangzhi/KubePolaris/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NotificationHandler 通知中心处理器
+type NotificationHandler struct {
+	notificationService *services.NotificationService
+}
+
+// NewNotificationHandler 创建通知中心处理器
+func NewNotificationHandler(notificationService *services.NotificationService) *NotificationHandler {
+	return &NotificationHandler{notificationService: notificationService}
+}
+
+// GetEventTypes 获取可订阅的事件类型
+func (h *NotificationHandler) GetEventTypes(c *gin.Context) {
+	types := make([]gin.H, 0, len(models.NotificationEventTypes))
+	for t, name := range models.NotificationEventTypes {
+		types = append(types, gin.H{"type": t, "name": name})
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i]["type"].(string) < types[j]["type"].(string) })
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": types})
+}
+
+// ListChannels 获取通知渠道列表
+func (h *NotificationHandler) ListChannels(c *gin.Context) {
+	channels, err := h.notificationService.ListChannels()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": channels})
+}
+
+// GetChannel 获取通知渠道
+func (h *NotificationHandler) GetChannel(c *gin.Context) {
+	id, ok := parseUintParam(c, "id")
+	if !ok {
+		return
+	}
+	channel, err := h.notificationService.GetChannel(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": channel})
+}
+
+// CreateChannel 创建通知渠道
+func (h *NotificationHandler) CreateChannel(c *gin.Context) {
+	h.saveChannel(c, 0)
+}
+
+// UpdateChannel 更新通知渠道
+func (h *NotificationHandler) UpdateChannel(c *gin.Context) {
+	id, ok := parseUintParam(c, "id")
+	if !ok {
+		return
+	}
+	h.saveChannel(c, id)
+}
+
+func (h *NotificationHandler) saveChannel(c *gin.Context, id uint) {
+	var req models.NotificationChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+	c.Set("audit_resource_name", req.Name)
+
+	channel, err := h.notificationService.SaveChannel(id, &req, c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "保存成功", "data": channel})
+}
+
+// DeleteChannel 删除通知渠道（同时删除其订阅）
+func (h *NotificationHandler) DeleteChannel(c *gin.Context) {
+	id, ok := parseUintParam(c, "id")
+	if !ok {
+		return
+	}
+	if err := h.notificationService.DeleteChannel(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "删除成功", "data": nil})
+}
+
+// TestChannel 发送测试消息：/channels/test 使用请求体中的配置；/channels/:id/test 请求体为空时使用已保存的配置
+func (h *NotificationHandler) TestChannel(c *gin.Context) {
+	var id uint
+	if c.Param("id") != "" {
+		parsed, ok := parseUintParam(c, "id")
+		if !ok {
+			return
+		}
+		id = parsed
+	}
+
+	var req *models.NotificationChannelRequest
+	if c.Request.ContentLength > 0 || id == 0 {
+		req = &models.NotificationChannelRequest{}
+		if err := c.ShouldBindJSON(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误: " + err.Error(), "data": nil})
+			return
+		}
+	}
+
+	if err := h.notificationService.TestChannel(c.Request.Context(), id, req, c.GetUint("user_id"), c.GetString("username")); err != nil {
+		c.JSON(http.StatusOK, gin.H{"code": 200, "message": "发送失败", "data": gin.H{"success": false, "error": err.Error()}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "发送成功", "data": gin.H{"success": true}})
+}
+
+// ListSubscriptions 获取订阅列表；mine=true 时只返回当前用户的订阅
+func (h *NotificationHandler) ListSubscriptions(c *gin.Context) {
+	var userID uint
+	if c.Query("mine") == "true" {
+		userID = c.GetUint("user_id")
+	}
+	subs, err := h.notificationService.ListSubscriptions(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": subs})
+}
+
+// CreateSubscription 创建订阅；未指定用户与用户组时订阅到当前用户
+func (h *NotificationHandler) CreateSubscription(c *gin.Context) {
+	h.saveSubscription(c, 0)
+}
+
+// UpdateSubscription 更新订阅
+func (h *NotificationHandler) UpdateSubscription(c *gin.Context) {
+	id, ok := parseUintParam(c, "id")
+	if !ok {
+		return
+	}
+	h.saveSubscription(c, id)
+}
+
+func (h *NotificationHandler) saveSubscription(c *gin.Context, id uint) {
+	var req models.NotificationSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+	if req.UserID == nil && req.UserGroupID == nil {
+		userID := c.GetUint("user_id")
+		req.UserID = &userID
+	}
+
+	sub, err := h.notificationService.SaveSubscription(id, &req, c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "保存成功", "data": sub})
+}
+
+// DeleteSubscription 删除订阅
+func (h *NotificationHandler) DeleteSubscription(c *gin.Context) {
+	id, ok := parseUintParam(c, "id")
+	if !ok {
+		return
+	}
+	if err := h.notificationService.DeleteSubscription(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "删除成功", "data": nil})
+}
+
+// ListDeliveries 分页查询投递记录
+func (h *NotificationHandler) ListDeliveries(c *gin.Context) {
+	var query models.NotificationDeliveryQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+	result, err := h.notificationService.ListDeliveries(&query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": result})
+}
+
+// RetryDelivery 重新发送失败的投递
+func (h *NotificationHandler) RetryDelivery(c *gin.Context) {
+	id, ok := parseUintParam(c, "id")
+	if !ok {
+		return
+	}
+	if err := h.notificationService.RetryDelivery(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "已重新加入发送队列", "data": nil})
+}
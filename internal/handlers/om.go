@@ -3,6 +3,7 @@ package handlers
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/clay-wangzhi/KubePolaris/internal/models"
 	"github.com/clay-wangzhi/KubePolaris/internal/services"
@@ -13,15 +14,19 @@ import (
 
 // OMHandler 运维中心处理器
 type OMHandler struct {
-	clusterSvc *services.ClusterService
-	omSvc      *services.OMService
+	clusterSvc       *services.ClusterService
+	omSvc            *services.OMService
+	healthHistorySvc *services.HealthHistoryService
+	ruleSvc          *services.DiagnosisRuleService
 }
 
 // NewOMHandler 创建运维中心处理器
-func NewOMHandler(clusterSvc *services.ClusterService, omSvc *services.OMService) *OMHandler {
+func NewOMHandler(clusterSvc *services.ClusterService, omSvc *services.OMService, healthHistorySvc *services.HealthHistoryService, ruleSvc *services.DiagnosisRuleService) *OMHandler {
 	return &OMHandler{
-		clusterSvc: clusterSvc,
-		omSvc:      omSvc,
+		clusterSvc:       clusterSvc,
+		omSvc:            omSvc,
+		healthHistorySvc: healthHistorySvc,
+		ruleSvc:          ruleSvc,
 	}
 }
 
@@ -104,6 +109,10 @@ func (h *OMHandler) GetHealthDiagnosis(c *gin.Context) {
 		})
 		return
 	}
+	// 记录诊断历史，评分较上次明显下降或出现新的严重风险时通知订阅者
+	if _, err := h.healthHistorySvc.Record(cluster, result, models.HealthDiagnosisManual); err != nil {
+		logger.Error("保存健康诊断历史失败", "cluster", cluster.Name, "error", err)
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
@@ -304,3 +313,346 @@ func (h *OMHandler) GetControlPlaneStatus(c *gin.Context) {
 		"data":    result,
 	})
 }
+
+// GetHealthHistory 获取健康诊断历史（不含风险项明细）
+// @Summary 获取健康诊断历史
+// @Tags O&M
+// @Produce json
+// @Param clusterID path int true "集群ID"
+// @Param source query string false "诊断来源" Enums(scheduled, manual)
+// @Success 200 {object} models.HealthDiagnosisHistoryResponse
+// @Router /api/v1/clusters/{clusterID}/om/health-history [get]
+func (h *OMHandler) GetHealthHistory(c *gin.Context) {
+	clusterID, ok := parseUintParam(c, "clusterID")
+	if !ok {
+		return
+	}
+	var query models.HealthDiagnosisHistoryQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+	result, err := h.healthHistorySvc.List(clusterID, &query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": result})
+}
+
+// GetHealthHistoryRecord 获取一次历史诊断的完整结果
+// @Summary 获取历史诊断详情
+// @Tags O&M
+// @Produce json
+// @Param clusterID path int true "集群ID"
+// @Param id path int true "诊断记录ID"
+// @Success 200 {object} models.HealthDiagnosisRecord
+// @Router /api/v1/clusters/{clusterID}/om/health-history/{id} [get]
+func (h *OMHandler) GetHealthHistoryRecord(c *gin.Context) {
+	clusterID, ok := parseUintParam(c, "clusterID")
+	if !ok {
+		return
+	}
+	id, ok := parseUintParam(c, "id")
+	if !ok {
+		return
+	}
+	record, err := h.healthHistorySvc.Get(clusterID, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": record})
+}
+
+// GetHealthTrend 获取健康评分趋势
+// @Summary 获取健康评分趋势
+// @Tags O&M
+// @Produce json
+// @Param clusterID path int true "集群ID"
+// @Param range query string false "时间范围" default(7d)
+// @Success 200 {array} models.HealthScoreTrendPoint
+// @Router /api/v1/clusters/{clusterID}/om/health-trend [get]
+func (h *OMHandler) GetHealthTrend(c *gin.Context) {
+	clusterID, ok := parseUintParam(c, "clusterID")
+	if !ok {
+		return
+	}
+	timeRange, err := services.ParsePromDuration(c.DefaultQuery("range", "7d"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的时间范围: " + err.Error(), "data": nil})
+		return
+	}
+	end := time.Now()
+	points, err := h.healthHistorySvc.Trend(clusterID, end.Add(-timeRange), end)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": points})
+}
+
+// GetHealthDiff 对比两次诊断的新增、已解决与持续存在的风险
+// @Summary 对比两次健康诊断
+// @Tags O&M
+// @Produce json
+// @Param clusterID path int true "集群ID"
+// @Param from query int false "较早的诊断记录ID，默认取 to 之前的一次"
+// @Param to query int false "较晚的诊断记录ID，默认取最近一次"
+// @Success 200 {object} models.HealthDiagnosisDiff
+// @Router /api/v1/clusters/{clusterID}/om/health-diff [get]
+func (h *OMHandler) GetHealthDiff(c *gin.Context) {
+	clusterID, ok := parseUintParam(c, "clusterID")
+	if !ok {
+		return
+	}
+	var ids [2]uint
+	for i, name := range []string{"from", "to"} {
+		if v := c.Query(name); v != "" {
+			id, err := strconv.ParseUint(v, 10, 32)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的诊断记录ID: " + v, "data": nil})
+				return
+			}
+			ids[i] = uint(id)
+		}
+	}
+	diff, err := h.healthHistorySvc.Diff(clusterID, ids[0], ids[1])
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": diff})
+}
+
+// ListDiagnosisRules 获取集群的诊断规则（内置规则与自定义规则）及生效配置
+// @Summary 获取诊断规则列表
+// @Tags O&M
+// @Produce json
+// @Param clusterID path int true "集群ID"
+// @Success 200 {array} models.DiagnosisRuleInfo
+// @Router /api/v1/clusters/{clusterID}/om/diagnosis-rules [get]
+func (h *OMHandler) ListDiagnosisRules(c *gin.Context) {
+	clusterID, ok := parseUintParam(c, "clusterID")
+	if !ok {
+		return
+	}
+	rules, err := h.ruleSvc.List(clusterID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": rules})
+}
+
+// UpdateDiagnosisRule 启停内置诊断规则或调整阈值
+// @Summary 更新内置诊断规则配置
+// @Tags O&M
+// @Accept json
+// @Produce json
+// @Param clusterID path int true "集群ID"
+// @Param ruleID path string true "规则ID"
+// @Param body body models.DiagnosisRuleSettingRequest true "规则配置"
+// @Success 200 {object} models.DiagnosisRuleInfo
+// @Router /api/v1/clusters/{clusterID}/om/diagnosis-rules/{ruleID} [put]
+func (h *OMHandler) UpdateDiagnosisRule(c *gin.Context) {
+	clusterID, ok := parseUintParam(c, "clusterID")
+	if !ok {
+		return
+	}
+	var req models.DiagnosisRuleSettingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+	info, err := h.ruleSvc.UpdateSetting(clusterID, c.Param("ruleID"), &req, c.GetString("username"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "更新成功", "data": info})
+}
+
+// ListCustomRules 获取集群的自定义诊断规则
+// @Summary 获取自定义诊断规则
+// @Tags O&M
+// @Produce json
+// @Param clusterID path int true "集群ID"
+// @Success 200 {array} models.CustomDiagnosisRule
+// @Router /api/v1/clusters/{clusterID}/om/custom-rules [get]
+func (h *OMHandler) ListCustomRules(c *gin.Context) {
+	clusterID, ok := parseUintParam(c, "clusterID")
+	if !ok {
+		return
+	}
+	rules, err := h.ruleSvc.ListCustom(clusterID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": rules})
+}
+
+// CreateCustomRule 创建自定义诊断规则
+// @Summary 创建自定义诊断规则
+// @Description CEL 规则对所选资源的每个对象求值，PromQL 规则对查询结果的每条序列与阈值比较
+// @Tags O&M
+// @Accept json
+// @Produce json
+// @Param clusterID path int true "集群ID"
+// @Param body body models.CustomDiagnosisRuleRequest true "规则"
+// @Success 200 {object} models.CustomDiagnosisRule
+// @Router /api/v1/clusters/{clusterID}/om/custom-rules [post]
+func (h *OMHandler) CreateCustomRule(c *gin.Context) {
+	clusterID, ok := parseUintParam(c, "clusterID")
+	if !ok {
+		return
+	}
+	req, ok := bindCustomRuleRequest(c)
+	if !ok {
+		return
+	}
+	rule, err := h.ruleSvc.SaveCustom(clusterID, nil, req, c.GetUint("user_id"), c.GetString("username"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "创建成功", "data": rule})
+}
+
+// UpdateCustomRule 更新自定义诊断规则
+// @Summary 更新自定义诊断规则
+// @Tags O&M
+// @Accept json
+// @Produce json
+// @Param clusterID path int true "集群ID"
+// @Param id path int true "规则ID"
+// @Param body body models.CustomDiagnosisRuleRequest true "规则"
+// @Success 200 {object} models.CustomDiagnosisRule
+// @Router /api/v1/clusters/{clusterID}/om/custom-rules/{id} [put]
+func (h *OMHandler) UpdateCustomRule(c *gin.Context) {
+	existing, ok := h.getCustomRule(c)
+	if !ok {
+		return
+	}
+	req, ok := bindCustomRuleRequest(c)
+	if !ok {
+		return
+	}
+	rule, err := h.ruleSvc.SaveCustom(existing.ClusterID, existing, req, c.GetUint("user_id"), c.GetString("username"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "更新成功", "data": rule})
+}
+
+// DeleteCustomRule 删除自定义诊断规则
+// @Summary 删除自定义诊断规则
+// @Tags O&M
+// @Produce json
+// @Param clusterID path int true "集群ID"
+// @Param id path int true "规则ID"
+// @Router /api/v1/clusters/{clusterID}/om/custom-rules/{id} [delete]
+func (h *OMHandler) DeleteCustomRule(c *gin.Context) {
+	rule, ok := h.getCustomRule(c)
+	if !ok {
+		return
+	}
+	if err := h.ruleSvc.DeleteCustom(rule); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "删除成功", "data": nil})
+}
+
+// TestCustomRule 在集群上试运行自定义诊断规则，不保存规则
+// @Summary 试运行自定义诊断规则
+// @Tags O&M
+// @Accept json
+// @Produce json
+// @Param clusterID path int true "集群ID"
+// @Param body body models.CustomDiagnosisRuleRequest true "规则"
+// @Success 200 {object} models.CustomDiagnosisRuleTestResponse
+// @Router /api/v1/clusters/{clusterID}/om/custom-rules/test [post]
+func (h *OMHandler) TestCustomRule(c *gin.Context) {
+	clusterID, ok := parseUintParam(c, "clusterID")
+	if !ok {
+		return
+	}
+	req, ok := bindCustomRuleRequest(c)
+	if !ok {
+		return
+	}
+	cluster, err := h.clusterSvc.GetCluster(clusterID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "集群不存在", "data": nil})
+		return
+	}
+	k8sClient, err := services.NewK8sClientForCluster(cluster)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "创建K8s客户端失败: " + err.Error(), "data": nil})
+		return
+	}
+	result, err := h.omSvc.TestCustomDiagnosisRule(c.Request.Context(), k8sClient.GetClientset(), clusterID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "执行成功", "data": result})
+}
+
+// GetWorkloadAudit 获取工作负载最佳实践检查报告
+func (h *OMHandler) GetWorkloadAudit(c *gin.Context) {
+	clusterID, ok := parseUintParam(c, "clusterID")
+	if !ok {
+		return
+	}
+	var query models.WorkloadAuditQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "参数错误: " + err.Error(), "data": nil})
+		return
+	}
+	cluster, err := h.clusterSvc.GetCluster(clusterID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "集群不存在", "data": nil})
+		return
+	}
+	k8sClient, err := services.NewK8sClientForCluster(cluster)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "创建K8s客户端失败: " + err.Error(), "data": nil})
+		return
+	}
+	report, err := h.omSvc.GetWorkloadAudit(c.Request.Context(), k8sClient.GetClientset(), clusterID, &query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "工作负载检查失败: " + err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": report})
+}
+
+func (h *OMHandler) getCustomRule(c *gin.Context) (*models.CustomDiagnosisRule, bool) {
+	clusterID, ok := parseUintParam(c, "clusterID")
+	if !ok {
+		return nil, false
+	}
+	id, ok := parseUintParam(c, "id")
+	if !ok {
+		return nil, false
+	}
+	rule, err := h.ruleSvc.GetCustom(clusterID, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": err.Error(), "data": nil})
+		return nil, false
+	}
+	return rule, true
+}
+
+func bindCustomRuleRequest(c *gin.Context) (*models.CustomDiagnosisRuleRequest, bool) {
+	var req models.CustomDiagnosisRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误: " + err.Error(), "data": nil})
+		return nil, false
+	}
+	return &req, true
+}
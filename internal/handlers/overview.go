@@ -24,6 +24,7 @@ func NewOverviewHandler(
 	monitoringCfgSvc *services.MonitoringConfigService,
 	alertManagerCfgSvc *services.AlertManagerConfigService,
 	alertManagerSvc *services.AlertManagerService,
+	capacitySvc *services.CapacityForecastService,
 ) *OverviewHandler {
 	overviewSvc := services.NewOverviewService(
 		nil, // db 可选，如果需要直接查询数据库
@@ -33,6 +34,7 @@ func NewOverviewHandler(
 		monitoringCfgSvc,
 		alertManagerCfgSvc,
 		alertManagerSvc,
+		capacitySvc,
 	)
 	return &OverviewHandler{
 		overviewService: overviewSvc,
@@ -128,22 +130,36 @@ func (h *OverviewHandler) GetDistribution(c *gin.Context) {
 
 // GetTrends 获取趋势数据
 // @Summary 获取趋势数据
-// @Description 返回 Pod 和 Node 的历史趋势数据
+// @Description 返回 Pod 和 Node 的历史趋势数据，以及各集群与节点池的容量预测
 // @Tags Overview
 // @Accept json
 // @Produce json
 // @Param timeRange query string false "时间范围: 7d, 30d" default(7d)
 // @Param step query string false "步长: 1h, 6h, 1d" default(1h)
+// @Param threshold query number false "容量预测阈值（%），默认使用配置值"
 // @Success 200 {object} services.TrendResponse
 // @Router /api/v1/overview/trends [get]
 func (h *OverviewHandler) GetTrends(c *gin.Context) {
 	startTime := time.Now()
 	timeRange := c.DefaultQuery("timeRange", "7d")
 	step := c.DefaultQuery("step", "")
+	var threshold float64
+	if raw := c.Query("threshold"); raw != "" {
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil || v <= 0 || v > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": "无效的容量预测阈值: " + raw,
+				"data":    nil,
+			})
+			return
+		}
+		threshold = v
+	}
 
 	logger.Info("获取趋势数据开始", "timeRange", timeRange, "step", step)
 
-	trends, err := h.overviewService.GetTrends(c.Request.Context(), timeRange, step)
+	trends, err := h.overviewService.GetTrends(c.Request.Context(), timeRange, step, threshold)
 
 	elapsed := time.Since(startTime)
 	logger.Info("获取趋势数据完成", "耗时", elapsed.String())
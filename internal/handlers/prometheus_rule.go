@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+	"github.com/clay-wangzhi/KubePolaris/internal/services"
+	"github.com/clay-wangzhi/KubePolaris/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PrometheusRuleHandler PrometheusRule 告警规则处理器
+type PrometheusRuleHandler struct {
+	clusterService        *services.ClusterService
+	prometheusRuleService *services.PrometheusRuleService
+}
+
+// NewPrometheusRuleHandler 创建 PrometheusRule 告警规则处理器
+func NewPrometheusRuleHandler(clusterService *services.ClusterService, prometheusRuleService *services.PrometheusRuleService) *PrometheusRuleHandler {
+	return &PrometheusRuleHandler{
+		clusterService:        clusterService,
+		prometheusRuleService: prometheusRuleService,
+	}
+}
+
+// getCluster 获取路径中的集群
+func (h *PrometheusRuleHandler) getCluster(c *gin.Context) (*models.Cluster, bool) {
+	cluster, err := h.clusterService.GetCluster(parseClusterID(c.Param("clusterID")))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "集群不存在", "data": nil})
+		return nil, false
+	}
+	return cluster, true
+}
+
+// ListRules 获取 PrometheusRule 列表
+func (h *PrometheusRuleHandler) ListRules(c *gin.Context) {
+	cluster, ok := h.getCluster(c)
+	if !ok {
+		return
+	}
+	rules, err := h.prometheusRuleService.ListRules(c.Request.Context(), cluster, c.Query("namespace"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": rules})
+}
+
+// GetRule 获取 PrometheusRule 详情
+func (h *PrometheusRuleHandler) GetRule(c *gin.Context) {
+	cluster, ok := h.getCluster(c)
+	if !ok {
+		return
+	}
+	rule, err := h.prometheusRuleService.GetRule(c.Request.Context(), cluster, c.Param("namespace"), c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": rule})
+}
+
+// CreateRule 创建 PrometheusRule，保存前校验规则结构与 PromQL
+func (h *PrometheusRuleHandler) CreateRule(c *gin.Context) {
+	var rule models.PrometheusRule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+	h.saveRule(c, &rule, h.prometheusRuleService.CreateRule)
+}
+
+// UpdateRule 更新 PrometheusRule
+func (h *PrometheusRuleHandler) UpdateRule(c *gin.Context) {
+	var rule models.PrometheusRule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+	rule.Namespace, rule.Name = c.Param("namespace"), c.Param("name")
+	h.saveRule(c, &rule, h.prometheusRuleService.UpdateRule)
+}
+
+// saveRule 校验通过后调用 save 保存规则，校验警告随结果一起返回
+func (h *PrometheusRuleHandler) saveRule(c *gin.Context, rule *models.PrometheusRule, save func(ctx context.Context, cluster *models.Cluster, rule *models.PrometheusRule) (*models.PrometheusRule, error)) {
+	cluster, ok := h.getCluster(c)
+	if !ok {
+		return
+	}
+	c.Set("audit_resource_name", rule.Namespace+"/"+rule.Name)
+
+	validation := h.prometheusRuleService.ValidateRule(c.Request.Context(), cluster.ID, rule)
+	if !validation.Valid {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "规则校验失败", "data": validation})
+		return
+	}
+
+	saved, err := save(c.Request.Context(), cluster, rule)
+	if err != nil {
+		logger.Error("保存 PrometheusRule 失败", "cluster", cluster.Name, "name", rule.Name, "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "保存成功", "data": gin.H{"rule": saved, "warnings": validation.Warnings}})
+}
+
+// DeleteRule 删除 PrometheusRule
+func (h *PrometheusRuleHandler) DeleteRule(c *gin.Context) {
+	cluster, ok := h.getCluster(c)
+	if !ok {
+		return
+	}
+	if err := h.prometheusRuleService.DeleteRule(c.Request.Context(), cluster, c.Param("namespace"), c.Param("name")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "删除成功", "data": nil})
+}
+
+// ValidateRule 校验 PrometheusRule（不保存）
+func (h *PrometheusRuleHandler) ValidateRule(c *gin.Context) {
+	var rule models.PrometheusRule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+	validation := h.prometheusRuleService.ValidateRule(c.Request.Context(), parseClusterID(c.Param("clusterID")), &rule)
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "校验完成", "data": validation})
+}
+
+// TestRule 在过去 N 小时内回放表达式，返回规则会触发的时间线
+func (h *PrometheusRuleHandler) TestRule(c *gin.Context) {
+	var req models.PrometheusRuleTestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+	result, err := h.prometheusRuleService.TestRule(c.Request.Context(), parseClusterID(c.Param("clusterID")), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": result})
+}
+
+// BuildRule 根据模板参数或表单字段生成告警规则
+func (h *PrometheusRuleHandler) BuildRule(c *gin.Context) {
+	var req models.PrometheusRuleBuildRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+	rule, err := h.prometheusRuleService.BuildRule(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": rule})
+}
+
+// GetRuleTemplates 获取内置告警规则模板
+func (h *PrometheusRuleHandler) GetRuleTemplates(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": services.PrometheusRuleTemplates()})
+}
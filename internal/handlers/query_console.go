@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/middleware"
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+	"github.com/clay-wangzhi/KubePolaris/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// QueryConsoleHandler PromQL 查询控制台处理器（查询代理、补全与保存查询）
+type QueryConsoleHandler struct {
+	monitoringConfigService *services.MonitoringConfigService
+	prometheusService       *services.PrometheusService
+	savedQueryService       *services.SavedQueryService
+}
+
+// NewQueryConsoleHandler 创建查询控制台处理器
+func NewQueryConsoleHandler(monitoringConfigService *services.MonitoringConfigService, prometheusService *services.PrometheusService, savedQueryService *services.SavedQueryService) *QueryConsoleHandler {
+	return &QueryConsoleHandler{
+		monitoringConfigService: monitoringConfigService,
+		prometheusService:       prometheusService,
+		savedQueryService:       savedQueryService,
+	}
+}
+
+// requirePrometheusConfig 获取集群监控配置，未配置数据源或获取失败时直接写出响应
+func requirePrometheusConfig(c *gin.Context, monitoringConfigService *services.MonitoringConfigService) (*models.MonitoringConfig, bool) {
+	clusterID := parseClusterID(c.Param("clusterID"))
+	if clusterID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的集群ID", "data": nil})
+		return nil, false
+	}
+	config, err := monitoringConfigService.GetMonitoringConfig(clusterID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "获取监控配置失败: " + err.Error(), "data": nil})
+		return nil, false
+	}
+	if config.Type == "disabled" || config.Endpoint == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "集群未配置监控数据源", "data": nil})
+		return nil, false
+	}
+	return config, true
+}
+
+func (h *QueryConsoleHandler) bindQuery(c *gin.Context) (*models.ConsoleQueryRequest, bool) {
+	var req models.ConsoleQueryRequest
+	var err error
+	if c.Request.Method == http.MethodPost {
+		err = c.ShouldBindJSON(&req)
+	} else {
+		err = c.ShouldBindQuery(&req)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误: " + err.Error(), "data": nil})
+		return nil, false
+	}
+	return &req, true
+}
+
+// Query 即时查询（GET 参数或 POST JSON），自动注入集群选择器
+func (h *QueryConsoleHandler) Query(c *gin.Context) {
+	req, ok := h.bindQuery(c)
+	if !ok {
+		return
+	}
+	config, ok := requirePrometheusConfig(c, h.monitoringConfigService)
+	if !ok {
+		return
+	}
+
+	var t time.Time
+	if req.Time != "" {
+		var err error
+		if t, err = parseConsoleTime(req.Time); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的 time: " + err.Error(), "data": nil})
+			return
+		}
+	}
+
+	result, err := h.prometheusService.ConsoleQuery(c.Request.Context(), config, consoleScope(c), req.Query, t)
+	if err != nil {
+		respondConsoleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "查询成功", "data": result})
+}
+
+// QueryRange 区间查询（GET 参数或 POST JSON），自动注入集群选择器；start 默认一小时前，end 默认当前时间
+func (h *QueryConsoleHandler) QueryRange(c *gin.Context) {
+	req, ok := h.bindQuery(c)
+	if !ok {
+		return
+	}
+	config, ok := requirePrometheusConfig(c, h.monitoringConfigService)
+	if !ok {
+		return
+	}
+
+	end := time.Now()
+	start := end.Add(-time.Hour)
+	var err error
+	if req.End != "" {
+		if end, err = parseConsoleTime(req.End); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的 end: " + err.Error(), "data": nil})
+			return
+		}
+	}
+	if req.Start != "" {
+		if start, err = parseConsoleTime(req.Start); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的 start: " + err.Error(), "data": nil})
+			return
+		}
+	}
+	step := defaultConsoleStep(end.Sub(start))
+	if req.Step != "" {
+		if step, err = parseConsoleStep(req.Step); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的 step: " + err.Error(), "data": nil})
+			return
+		}
+	}
+
+	result, err := h.prometheusService.ConsoleQueryRange(c.Request.Context(), config, consoleScope(c), req.Query, start, end, step)
+	if err != nil {
+		respondConsoleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "查询成功", "data": result})
+}
+
+// GetMetricNames 指标名补全，参数 prefix、limit
+func (h *QueryConsoleHandler) GetMetricNames(c *gin.Context) {
+	config, ok := requirePrometheusConfig(c, h.monitoringConfigService)
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	names, err := h.prometheusService.ConsoleMetricNames(c.Request.Context(), config, consoleScope(c), c.Query("prefix"), limit)
+	if err != nil {
+		respondConsoleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": names})
+}
+
+// GetLabelNames 标签名补全，参数 metric、prefix、limit
+func (h *QueryConsoleHandler) GetLabelNames(c *gin.Context) {
+	config, ok := requirePrometheusConfig(c, h.monitoringConfigService)
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	names, err := h.prometheusService.ConsoleLabelNames(c.Request.Context(), config, consoleScope(c), c.Query("metric"), c.Query("prefix"), limit)
+	if err != nil {
+		respondConsoleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": names})
+}
+
+// GetLabelValues 标签值补全，参数 metric、prefix、limit
+func (h *QueryConsoleHandler) GetLabelValues(c *gin.Context) {
+	config, ok := requirePrometheusConfig(c, h.monitoringConfigService)
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	values, err := h.prometheusService.ConsoleLabelValues(c.Request.Context(), config, consoleScope(c), c.Param("name"), c.Query("metric"), c.Query("prefix"), limit)
+	if err != nil {
+		respondConsoleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": values})
+}
+
+// ListSavedQueries 获取自己的与共享的保存查询，参数 clusterId、keyword
+func (h *QueryConsoleHandler) ListSavedQueries(c *gin.Context) {
+	queries, err := h.savedQueryService.List(c.GetUint("user_id"), parseClusterID(c.Query("clusterId")), c.Query("keyword"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": queries})
+}
+
+// CreateSavedQuery 保存查询
+func (h *QueryConsoleHandler) CreateSavedQuery(c *gin.Context) {
+	h.saveQuery(c, 0)
+}
+
+// UpdateSavedQuery 更新保存的查询
+func (h *QueryConsoleHandler) UpdateSavedQuery(c *gin.Context) {
+	id, ok := parseUintParam(c, "id")
+	if !ok {
+		return
+	}
+	h.saveQuery(c, id)
+}
+
+func (h *QueryConsoleHandler) saveQuery(c *gin.Context, id uint) {
+	var req models.SavedQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+	c.Set("audit_resource_name", req.Name)
+
+	saved, err := h.savedQueryService.Save(c.GetUint("user_id"), c.GetString("username"), id, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "保存成功", "data": saved})
+}
+
+// DeleteSavedQuery 删除保存的查询
+func (h *QueryConsoleHandler) DeleteSavedQuery(c *gin.Context) {
+	id, ok := parseUintParam(c, "id")
+	if !ok {
+		return
+	}
+	if err := h.savedQueryService.Delete(c.GetUint("user_id"), id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "删除成功", "data": nil})
+}
+
+// consoleScope 只能访问部分命名空间的用户，查询同样限定在这些命名空间
+func consoleScope(c *gin.Context) string {
+	namespaces, hasAll := middleware.GetAllowedNamespaces(c)
+	if hasAll {
+		return ""
+	}
+	return services.NamespaceScopeSelector(namespaces)
+}
+
+// respondConsoleError Prometheus 返回的查询错误（如语法错误）原样返回给用户，其余为数据源访问失败
+func respondConsoleError(c *gin.Context, err error) {
+	var apiErr *services.PrometheusAPIError
+	if errors.As(err, &apiErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "查询失败: " + apiErr.Message, "data": gin.H{"errorType": apiErr.ErrorType}})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+}
+
+// parseConsoleTime 解析 RFC3339 或 Unix 秒（可带小数）格式的时间
+func parseConsoleTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	sec, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(sec) || math.IsInf(sec, 0) {
+		return time.Time{}, fmt.Errorf("需为 RFC3339 或 Unix 时间戳")
+	}
+	whole, frac := math.Modf(sec)
+	return time.Unix(int64(whole), int64(frac*1e9)), nil
+}
+
+// parseConsoleStep 解析步长：Go 时长（30s、1m）、Prometheus 时长（1d）或秒数
+func parseConsoleStep(value string) (time.Duration, error) {
+	if sec, err := strconv.ParseFloat(value, 64); err == nil {
+		return time.Duration(sec * float64(time.Second)), nil
+	}
+	if strings.HasSuffix(value, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+		if err != nil {
+			return 0, fmt.Errorf("格式错误")
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("格式错误")
+	}
+	return d, nil
+}
+
+// defaultConsoleStep 未指定步长时按约 250 个点计算，最小 15 秒
+func defaultConsoleStep(rng time.Duration) time.Duration {
+	step := (rng / 250).Truncate(time.Second)
+	if step < 15*time.Second {
+		step = 15 * time.Second
+	}
+	return step
+}
@@ -28,15 +28,17 @@ type ResourceYAMLHandler struct {
 	cfg            *config.Config
 	clusterService *services.ClusterService
 	k8sMgr         *k8s.ClusterInformerManager
+	versionSvc     *services.ConfigVersionService
 }
 
 // NewResourceYAMLHandler 创建通用资源YAML处理器
-func NewResourceYAMLHandler(db *gorm.DB, cfg *config.Config, clusterService *services.ClusterService, k8sMgr *k8s.ClusterInformerManager) *ResourceYAMLHandler {
+func NewResourceYAMLHandler(db *gorm.DB, cfg *config.Config, clusterService *services.ClusterService, k8sMgr *k8s.ClusterInformerManager, versionSvc *services.ConfigVersionService) *ResourceYAMLHandler {
 	return &ResourceYAMLHandler{
 		db:             db,
 		cfg:            cfg,
 		clusterService: clusterService,
 		k8sMgr:         k8sMgr,
+		versionSvc:     versionSvc,
 	}
 }
 
@@ -133,6 +135,14 @@ func (h *ResourceYAMLHandler) ApplyConfigMapYAML(c *gin.Context) {
 		}
 	}
 
+	if !req.DryRun {
+		var before *corev1.ConfigMap
+		if !isCreated {
+			before = existing
+		}
+		h.versionSvc.RecordConfigMap(cluster.ID, before, result, models.ConfigVersionSourceKubePolaris, c.GetString("username"))
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
 		"message": "YAML应用成功",
@@ -248,7 +258,16 @@ func (h *ResourceYAMLHandler) ApplySecretYAML(c *gin.Context) {
 	var result *corev1.Secret
 	isCreated := false
 
-	if err == nil {
+	if err != nil {
+		existing = nil
+	}
+	// GetSecretYAML 返回的是脱敏后的 YAML，提交时仍为占位符的值还原为集群中的原值
+	if err := restoreMaskedSecretValues(&secret, existing); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+		return
+	}
+
+	if existing != nil {
 		secret.ResourceVersion = existing.ResourceVersion
 		result, err = clientset.CoreV1().Secrets(secret.Namespace).Update(ctx, &secret, metav1.UpdateOptions{DryRun: dryRunOpt})
 		if err != nil {
@@ -264,6 +283,14 @@ func (h *ResourceYAMLHandler) ApplySecretYAML(c *gin.Context) {
 		}
 	}
 
+	if !req.DryRun {
+		var before *corev1.Secret
+		if !isCreated {
+			before = existing
+		}
+		h.versionSvc.RecordSecret(cluster.ID, before, result, models.ConfigVersionSourceKubePolaris, c.GetString("username"))
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
 		"message": "YAML应用成功",
@@ -305,12 +332,7 @@ func (h *ResourceYAMLHandler) GetSecretYAML(c *gin.Context) {
 		return
 	}
 
-	cleanSecret := secret.DeepCopy()
-	cleanSecret.ManagedFields = nil
-	cleanSecret.APIVersion = "v1"
-	cleanSecret.Kind = "Secret"
-
-	yamlBytes, err := sigsyaml.Marshal(cleanSecret)
+	yamlBytes, err := sigsyaml.Marshal(maskSecretForYAML(secret))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "转换YAML失败: " + err.Error()})
 		return
@@ -975,3 +997,47 @@ func (h *ResourceYAMLHandler) GetStorageClassYAMLClean(c *gin.Context) {
 		},
 	})
 }
+
+// maskSecretForYAML 生成用于 YAML 编辑的 Secret 副本，数据与 last-applied-configuration 替换为脱敏占位符
+func maskSecretForYAML(secret *corev1.Secret) *corev1.Secret {
+	cleanSecret := secret.DeepCopy()
+	cleanSecret.ManagedFields = nil
+	cleanSecret.Annotations = maskSecretAnnotations(cleanSecret.Annotations)
+	for k := range cleanSecret.Data {
+		cleanSecret.Data[k] = []byte(secretMaskedValue)
+	}
+	cleanSecret.APIVersion = "v1"
+	cleanSecret.Kind = "Secret"
+	return cleanSecret
+}
+
+// restoreMaskedSecretValues 将提交的 Secret 中仍为脱敏占位符的数据与 last-applied-configuration 还原为集群中的原值，
+// existing 为 nil 表示新建。占位符在集群中没有对应原值时返回错误，避免把占位符写入 Secret
+func restoreMaskedSecretValues(secret, existing *corev1.Secret) error {
+	for k, v := range secret.Data {
+		if string(v) != secretMaskedValue {
+			continue
+		}
+		var old []byte
+		ok := false
+		if existing != nil {
+			old, ok = existing.Data[k]
+		}
+		if !ok {
+			return fmt.Errorf("键 %s 的值为脱敏占位符 %s，且集群中不存在原值，请填写实际值", k, secretMaskedValue)
+		}
+		secret.Data[k] = old
+	}
+	if secret.Annotations[corev1.LastAppliedConfigAnnotation] == secretMaskedValue {
+		var old string
+		ok := false
+		if existing != nil {
+			old, ok = existing.Annotations[corev1.LastAppliedConfigAnnotation]
+		}
+		if !ok {
+			return fmt.Errorf("注解 %s 的值为脱敏占位符，且集群中不存在原值，请删除该注解", corev1.LastAppliedConfigAnnotation)
+		}
+		secret.Annotations[corev1.LastAppliedConfigAnnotation] = old
+	}
+	return nil
+}
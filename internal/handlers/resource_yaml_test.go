@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+// ResourceYAMLHandlerTestSuite 定义资源 YAML 处理器测试套件
+type ResourceYAMLHandlerTestSuite struct {
+	suite.Suite
+	existing *corev1.Secret
+}
+
+// SetupTest 每个测试前的设置
+func (s *ResourceYAMLHandlerTestSuite) SetupTest() {
+	s.existing = &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "shop",
+			Name:      "db",
+			Annotations: map[string]string{
+				corev1.LastAppliedConfigAnnotation: `{"data":{"password":"cGFzcw=="}}`,
+				"owner":                            "dba",
+			},
+		},
+		Data: map[string][]byte{"username": []byte("root"), "password": []byte("pass")},
+	}
+}
+
+// roundTrip 模拟 YAML 编辑器：获取脱敏 YAML，编辑后按 ApplySecretYAML 的方式解析
+func (s *ResourceYAMLHandlerTestSuite) roundTrip(edit func(*corev1.Secret)) *corev1.Secret {
+	yamlBytes, err := sigsyaml.Marshal(maskSecretForYAML(s.existing))
+	s.Require().NoError(err)
+	s.Require().NotContains(string(yamlBytes), "cGFzcw==", "YAML 中不包含明文")
+
+	var edited corev1.Secret
+	s.Require().NoError(yaml.Unmarshal(yamlBytes, &edited))
+	edit(&edited)
+	out, err := sigsyaml.Marshal(&edited)
+	s.Require().NoError(err)
+
+	var submitted corev1.Secret
+	s.Require().NoError(yaml.Unmarshal(out, &submitted))
+	return &submitted
+}
+
+// TestApplySecretYAML_RoundTripKeepsValues 测试未修改的脱敏值在应用时保留原值
+func (s *ResourceYAMLHandlerTestSuite) TestApplySecretYAML_RoundTripKeepsValues() {
+	submitted := s.roundTrip(func(secret *corev1.Secret) {
+		secret.Labels = map[string]string{"app": "shop"}
+		secret.Data["username"] = []byte("admin")
+	})
+
+	s.Require().NoError(restoreMaskedSecretValues(submitted, s.existing))
+	assert.Equal(s.T(), map[string][]byte{"username": []byte("admin"), "password": []byte("pass")}, submitted.Data)
+	assert.Equal(s.T(), s.existing.Annotations, submitted.Annotations)
+	assert.Equal(s.T(), "shop", submitted.Labels["app"])
+}
+
+// TestApplySecretYAML_RejectsUnknownPlaceholder 测试占位符没有对应原值时拒绝应用
+func (s *ResourceYAMLHandlerTestSuite) TestApplySecretYAML_RejectsUnknownPlaceholder() {
+	submitted := s.roundTrip(func(secret *corev1.Secret) {
+		secret.Data["token"] = []byte(secretMaskedValue)
+	})
+	err := restoreMaskedSecretValues(submitted, s.existing)
+	s.Require().Error(err)
+	assert.True(s.T(), strings.Contains(err.Error(), "token"))
+
+	// 复制到其他名称新建时，集群中不存在原值
+	submitted = s.roundTrip(func(secret *corev1.Secret) {
+		secret.Name = "db-copy"
+		delete(secret.Data, "password")
+		secret.Data["username"] = []byte("root")
+	})
+	assert.Error(s.T(), restoreMaskedSecretValues(submitted, nil), "last-applied-configuration 仍为占位符")
+
+	submitted = s.roundTrip(func(secret *corev1.Secret) {
+		secret.Annotations = nil
+		secret.Data = map[string][]byte{"username": []byte("root")}
+	})
+	assert.NoError(s.T(), restoreMaskedSecretValues(submitted, nil))
+}
+
+// TestResourceYAMLHandlerSuite 运行资源 YAML 处理器测试套件
+func TestResourceYAMLHandlerSuite(t *testing.T) {
+	suite.Run(t, new(ResourceYAMLHandlerTestSuite))
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/middleware"
+	"github.com/clay-wangzhi/KubePolaris/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RightsizingHandler 工作负载资源规格建议处理器
+type RightsizingHandler struct {
+	monitoringConfigService *services.MonitoringConfigService
+	rightsizingService      *services.RightsizingService
+}
+
+// NewRightsizingHandler 创建规格建议处理器
+func NewRightsizingHandler(monitoringConfigService *services.MonitoringConfigService, rightsizingService *services.RightsizingService) *RightsizingHandler {
+	return &RightsizingHandler{
+		monitoringConfigService: monitoringConfigService,
+		rightsizingService:      rightsizingService,
+	}
+}
+
+// GetNamespaceRecommendations 获取命名空间内所有工作负载的规格建议，参数 namespace（必填）、window（默认 7d）
+func (h *RightsizingHandler) GetNamespaceRecommendations(c *gin.Context) {
+	namespace := c.Query("namespace")
+	if namespace == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "命名空间不能为空", "data": nil})
+		return
+	}
+	window, ok := h.prepare(c, namespace)
+	if !ok {
+		return
+	}
+	config, ok := requirePrometheusConfig(c, h.monitoringConfigService)
+	if !ok {
+		return
+	}
+
+	report, err := h.rightsizingService.NamespaceRecommendations(c.Request.Context(), parseClusterID(c.Param("clusterID")), config, namespace, window)
+	if err != nil {
+		respondConsoleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": report})
+}
+
+// GetWorkloadRecommendation 获取单个工作负载的规格建议与 patch，kind 为 deployments、statefulsets 或 daemonsets
+func (h *RightsizingHandler) GetWorkloadRecommendation(c *gin.Context) {
+	namespace := c.Param("namespace")
+	window, ok := h.prepare(c, namespace)
+	if !ok {
+		return
+	}
+	config, ok := requirePrometheusConfig(c, h.monitoringConfigService)
+	if !ok {
+		return
+	}
+
+	result, err := h.rightsizingService.WorkloadRecommendation(c.Request.Context(), parseClusterID(c.Param("clusterID")), config, c.Param("kind"), namespace, c.Param("name"), window)
+	if err != nil {
+		respondConsoleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": result})
+}
+
+// prepare 校验命名空间权限并解析统计窗口
+func (h *RightsizingHandler) prepare(c *gin.Context, namespace string) (time.Duration, bool) {
+	if _, hasAccess := middleware.CheckNamespacePermission(c, namespace); !hasAccess {
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "message": fmt.Sprintf("无权访问命名空间: %s", namespace), "data": nil})
+		return 0, false
+	}
+	window := services.RightsizingDefaultWindow
+	if value := c.Query("window"); value != "" {
+		var err error
+		if window, err = parseConsoleStep(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的 window: " + err.Error(), "data": nil})
+			return 0, false
+		}
+	}
+	return window, true
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/clay-wangzhi/KubePolaris/internal/config"
 	"github.com/clay-wangzhi/KubePolaris/internal/k8s"
 	"github.com/clay-wangzhi/KubePolaris/internal/middleware"
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
 	"github.com/clay-wangzhi/KubePolaris/internal/services"
 	"github.com/clay-wangzhi/KubePolaris/pkg/logger"
 )
@@ -26,14 +27,16 @@ type SecretHandler struct {
 	cfg        *config.Config
 	clusterSvc *services.ClusterService
 	k8sMgr     *k8s.ClusterInformerManager
+	versionSvc *services.ConfigVersionService
 }
 
-func NewSecretHandler(db *gorm.DB, cfg *config.Config, clusterSvc *services.ClusterService, k8sMgr *k8s.ClusterInformerManager) *SecretHandler {
+func NewSecretHandler(db *gorm.DB, cfg *config.Config, clusterSvc *services.ClusterService, k8sMgr *k8s.ClusterInformerManager, versionSvc *services.ConfigVersionService) *SecretHandler {
 	return &SecretHandler{
 		db:         db,
 		cfg:        cfg,
 		clusterSvc: clusterSvc,
 		k8sMgr:     k8sMgr,
+		versionSvc: versionSvc,
 	}
 }
 
@@ -55,12 +58,64 @@ type SecretDetail struct {
 	Type              string            `json:"type"`
 	Labels            map[string]string `json:"labels"`
 	Annotations       map[string]string `json:"annotations"`
-	Data              map[string]string `json:"data"` // Base64编码的数据
+	Data              map[string]string `json:"data"`      // 默认脱敏，明文需通过 reveal 接口逐个获取
+	DataSizes         map[string]int    `json:"dataSizes"` // 各键值的字节数
+	Masked            bool              `json:"masked"`
+	External          *SecretExternal   `json:"external,omitempty"` // 由外部密钥源同步时的状态
 	CreationTimestamp time.Time         `json:"creationTimestamp"`
 	Age               string            `json:"age"`
 	ResourceVersion   string            `json:"resourceVersion"`
 }
 
+// SecretExternal Secret 的外部密钥源同步信息
+type SecretExternal struct {
+	Source   string `json:"source"`
+	Path     string `json:"path"`
+	SyncedAt string `json:"syncedAt"`
+	Drifted  bool   `json:"drifted"` // 同步后集群内数据被修改过
+}
+
+// secretExternalInfo 根据同步注解计算外部密钥状态，非外部密钥管理的 Secret 返回 nil
+func secretExternalInfo(secret *corev1.Secret) *SecretExternal {
+	source := secret.Annotations[models.AnnotationExternalSecretSource]
+	if source == "" {
+		return nil
+	}
+	return &SecretExternal{
+		Source:   source,
+		Path:     secret.Annotations[models.AnnotationExternalSecretPath],
+		SyncedAt: secret.Annotations[models.AnnotationExternalSecretSynced],
+		Drifted:  secret.Annotations[models.AnnotationExternalSecretHash] != services.HashSecretData(secret.Data),
+	}
+}
+
+// secretMaskedValue Secret 值的脱敏占位符，更新时提交该占位符表示保留原值
+const secretMaskedValue = "******"
+
+// maskSecretData 将 Secret 数据替换为占位符，仅保留键名和长度
+func maskSecretData(data map[string][]byte) (map[string]string, map[string]int) {
+	masked := make(map[string]string, len(data))
+	sizes := make(map[string]int, len(data))
+	for k, v := range data {
+		masked[k] = secretMaskedValue
+		sizes[k] = len(v)
+	}
+	return masked, sizes
+}
+
+// maskSecretAnnotations 脱敏 kubectl apply 留下的 last-applied-configuration（其中包含完整明文）
+func maskSecretAnnotations(annotations map[string]string) map[string]string {
+	if _, ok := annotations[corev1.LastAppliedConfigAnnotation]; !ok {
+		return annotations
+	}
+	masked := make(map[string]string, len(annotations))
+	for k, v := range annotations {
+		masked[k] = v
+	}
+	masked[corev1.LastAppliedConfigAnnotation] = secretMaskedValue
+	return masked
+}
+
 // GetSecrets 获取Secret列表
 func (h *SecretHandler) GetSecrets(c *gin.Context) {
 	clusterID := c.Param("clusterID")
@@ -242,19 +297,19 @@ func (h *SecretHandler) GetSecret(c *gin.Context) {
 		return
 	}
 
-	// 将Data字节数组转换为Base64字符串
-	dataStr := make(map[string]string)
-	for k, v := range secret.Data {
-		dataStr[k] = string(v) // 前端需要Base64解码显示
-	}
+	// 数据默认脱敏，明文通过 RevealSecretKey 按键获取并审计
+	dataStr, dataSizes := maskSecretData(secret.Data)
 
 	detail := SecretDetail{
 		Name:              secret.Name,
 		Namespace:         secret.Namespace,
 		Type:              string(secret.Type),
 		Labels:            secret.Labels,
-		Annotations:       secret.Annotations,
+		Annotations:       maskSecretAnnotations(secret.Annotations),
 		Data:              dataStr,
+		DataSizes:         dataSizes,
+		Masked:            true,
+		External:          secretExternalInfo(secret),
 		CreationTimestamp: secret.CreationTimestamp.Time,
 		Age:               formatAge(time.Since(secret.CreationTimestamp.Time)),
 		ResourceVersion:   secret.ResourceVersion,
@@ -267,6 +322,54 @@ func (h *SecretHandler) GetSecret(c *gin.Context) {
 	})
 }
 
+// RevealSecretKey 获取 Secret 单个键的明文，需要 secret:reveal 权限（仅管理员与运维权限），每次调用均记录操作审计（仅记录键名）
+func (h *SecretHandler) RevealSecretKey(c *gin.Context) {
+	clusterID := c.Param("clusterID")
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+	key := c.Param("key")
+
+	// 审计日志资源名记录为 name/key，不包含值
+	c.Set("audit_resource_name", name+"/"+key)
+
+	id := parseClusterID(clusterID)
+	cluster, err := h.clusterSvc.GetCluster(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "集群不存在"})
+		return
+	}
+	c.Set("cluster_name", cluster.Name)
+
+	k8sClient, err := services.NewK8sClientForCluster(cluster)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": fmt.Sprintf("创建K8s客户端失败: %v", err)})
+		return
+	}
+
+	secret, err := k8sClient.GetClientset().CoreV1().Secrets(namespace).Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": fmt.Sprintf("Secret不存在: %v", err)})
+		return
+	}
+
+	value, ok := secret.Data[key]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": fmt.Sprintf("Secret中不存在键: %s", key)})
+		return
+	}
+
+	logger.Info("查看Secret明文", "cluster", cluster.Name, "namespace", namespace, "name", name, "key", key, "user", c.GetString("username"))
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data": gin.H{
+			"key":   key,
+			"value": string(value),
+		},
+	})
+}
+
 // GetSecretNamespaces 获取Secret所在的命名空间列表
 func (h *SecretHandler) GetSecretNamespaces(c *gin.Context) {
 	clusterID := c.Param("clusterID")
@@ -366,6 +469,11 @@ func (h *SecretHandler) DeleteSecret(c *gin.Context) {
 
 	clientset := k8sClient.GetClientset()
 
+	// 删除前保存最后一个版本，便于误删后恢复
+	if existing, getErr := clientset.CoreV1().Secrets(namespace).Get(context.Background(), name, metav1.GetOptions{}); getErr == nil {
+		h.versionSvc.RecordSecret(cluster.ID, nil, existing, models.ConfigVersionSourceKubePolaris, c.GetString("username"))
+	}
+
 	// 删除Secret
 	err = clientset.CoreV1().Secrets(namespace).Delete(context.Background(), name, metav1.DeleteOptions{})
 	if err != nil {
@@ -456,6 +564,7 @@ func (h *SecretHandler) CreateSecret(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("创建Secret失败: %v", err)})
 		return
 	}
+	h.versionSvc.RecordSecret(cluster.ID, nil, created, models.ConfigVersionSourceKubePolaris, c.GetString("username"))
 
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
@@ -519,11 +628,20 @@ func (h *SecretHandler) UpdateSecret(c *gin.Context) {
 		return
 	}
 
-	// 将字符串数据转换为字节数组
+	before := secret.DeepCopy()
+
+	// 将字符串数据转换为字节数组，提交脱敏占位符的键保留原值
 	dataBytes := make(map[string][]byte)
 	for k, v := range req.Data {
+		if old, ok := secret.Data[k]; ok && v == secretMaskedValue {
+			dataBytes[k] = old
+			continue
+		}
 		dataBytes[k] = []byte(v)
 	}
+	if old, ok := secret.Annotations[corev1.LastAppliedConfigAnnotation]; ok && req.Annotations[corev1.LastAppliedConfigAnnotation] == secretMaskedValue {
+		req.Annotations[corev1.LastAppliedConfigAnnotation] = old
+	}
 
 	// 更新Secret
 	secret.Labels = req.Labels
@@ -536,6 +654,7 @@ func (h *SecretHandler) UpdateSecret(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("更新Secret失败: %v", err)})
 		return
 	}
+	h.versionSvc.RecordSecret(cluster.ID, before, updated, models.ConfigVersionSourceKubePolaris, c.GetString("username"))
 
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+	"github.com/clay-wangzhi/KubePolaris/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SilenceHandler 静默管理处理器（模板、周期静默、批量、延长与孤立静默）
+type SilenceHandler struct {
+	silenceService *services.SilenceService
+}
+
+// NewSilenceHandler 创建静默管理处理器
+func NewSilenceHandler(silenceService *services.SilenceService) *SilenceHandler {
+	return &SilenceHandler{silenceService: silenceService}
+}
+
+// ListTemplates 获取静默模板
+func (h *SilenceHandler) ListTemplates(c *gin.Context) {
+	templates, err := h.silenceService.ListTemplates(parseClusterID(c.Param("clusterID")))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": templates})
+}
+
+// CreateTemplate 创建静默模板
+func (h *SilenceHandler) CreateTemplate(c *gin.Context) {
+	h.saveTemplate(c, 0)
+}
+
+// UpdateTemplate 更新静默模板
+func (h *SilenceHandler) UpdateTemplate(c *gin.Context) {
+	id, ok := parseUintParam(c, "id")
+	if !ok {
+		return
+	}
+	h.saveTemplate(c, id)
+}
+
+func (h *SilenceHandler) saveTemplate(c *gin.Context, id uint) {
+	var req models.SilenceTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+	c.Set("audit_resource_name", req.Name)
+
+	tpl, err := h.silenceService.SaveTemplate(parseClusterID(c.Param("clusterID")), id, &req, c.GetString("username"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "保存成功", "data": tpl})
+}
+
+// DeleteTemplate 删除静默模板
+func (h *SilenceHandler) DeleteTemplate(c *gin.Context) {
+	id, ok := parseUintParam(c, "id")
+	if !ok {
+		return
+	}
+	if err := h.silenceService.DeleteTemplate(parseClusterID(c.Param("clusterID")), id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "删除成功", "data": nil})
+}
+
+// ApplyTemplate 按模板创建静默
+func (h *SilenceHandler) ApplyTemplate(c *gin.Context) {
+	id, ok := parseUintParam(c, "id")
+	if !ok {
+		return
+	}
+	var req models.ApplySilenceTemplateRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误: " + err.Error(), "data": nil})
+			return
+		}
+	}
+
+	silence, err := h.silenceService.ApplyTemplate(c.Request.Context(), parseClusterID(c.Param("clusterID")), id, &req, c.GetString("username"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.Set("audit_resource_name", silence.ID)
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "创建成功", "data": silence})
+}
+
+// ListRecurring 获取周期静默
+func (h *SilenceHandler) ListRecurring(c *gin.Context) {
+	rules, err := h.silenceService.ListRecurring(parseClusterID(c.Param("clusterID")))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": rules})
+}
+
+// GetRecurring 获取周期静默详情
+func (h *SilenceHandler) GetRecurring(c *gin.Context) {
+	id, ok := parseUintParam(c, "id")
+	if !ok {
+		return
+	}
+	rule, err := h.silenceService.GetRecurring(parseClusterID(c.Param("clusterID")), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": rule})
+}
+
+// CreateRecurring 创建周期静默
+func (h *SilenceHandler) CreateRecurring(c *gin.Context) {
+	h.saveRecurring(c, 0)
+}
+
+// UpdateRecurring 更新周期静默
+func (h *SilenceHandler) UpdateRecurring(c *gin.Context) {
+	id, ok := parseUintParam(c, "id")
+	if !ok {
+		return
+	}
+	h.saveRecurring(c, id)
+}
+
+func (h *SilenceHandler) saveRecurring(c *gin.Context, id uint) {
+	var req models.RecurringSilenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+	c.Set("audit_resource_name", req.Name)
+
+	rule, err := h.silenceService.SaveRecurring(parseClusterID(c.Param("clusterID")), id, &req, c.GetString("username"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "保存成功", "data": rule})
+}
+
+// DeleteRecurring 删除周期静默
+func (h *SilenceHandler) DeleteRecurring(c *gin.Context) {
+	id, ok := parseUintParam(c, "id")
+	if !ok {
+		return
+	}
+	if err := h.silenceService.DeleteRecurring(c.Request.Context(), parseClusterID(c.Param("clusterID")), id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "删除成功", "data": nil})
+}
+
+// BulkSilence 按告警分组批量静默
+func (h *SilenceHandler) BulkSilence(c *gin.Context) {
+	var req models.BulkSilenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+
+	result, err := h.silenceService.BulkSilenceGroup(c.Request.Context(), parseClusterID(c.Param("clusterID")), &req, c.GetString("username"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.Set("audit_resource_name", req.Receiver)
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "已创建 " + strconv.Itoa(len(result.Created)) + " 条静默", "data": result})
+}
+
+// ExtendSilence 延长静默
+func (h *SilenceHandler) ExtendSilence(c *gin.Context) {
+	var req models.ExtendSilenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+
+	silence, err := h.silenceService.ExtendSilence(c.Request.Context(), parseClusterID(c.Param("clusterID")), c.Param("silenceId"), &req, c.GetString("username"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "延长成功", "data": silence})
+}
+
+// GetOrphanedSilences 孤立静默报告；days 为回溯告警历史的天数，默认 7
+func (h *SilenceHandler) GetOrphanedSilences(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "7"))
+	if err != nil || days <= 0 {
+		days = 7
+	}
+	items, err := h.silenceService.OrphanedSilences(c.Request.Context(), parseClusterID(c.Param("clusterID")), time.Duration(days)*24*time.Hour)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": items})
+}
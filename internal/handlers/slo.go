@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/middleware"
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+	"github.com/clay-wangzhi/KubePolaris/internal/services"
+	"github.com/clay-wangzhi/KubePolaris/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SLOHandler SLO 处理器（定义、错误预算与消耗速率、PrometheusRule 生成）
+type SLOHandler struct {
+	clusterService *services.ClusterService
+	sloService     *services.SLOService
+}
+
+// NewSLOHandler 创建 SLO 处理器
+func NewSLOHandler(clusterService *services.ClusterService, sloService *services.SLOService) *SLOHandler {
+	return &SLOHandler{clusterService: clusterService, sloService: sloService}
+}
+
+// GetTemplates 获取内置 SLI 模板
+func (h *SLOHandler) GetTemplates(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": services.SLOTemplates()})
+}
+
+// ListSLOs 获取集群的 SLO，可按 targetKind、namespace、targetName 过滤（工作负载详情页按工作负载查询）
+func (h *SLOHandler) ListSLOs(c *gin.Context) {
+	clusterID, ok := parseUintParam(c, "clusterID")
+	if !ok {
+		return
+	}
+	var query models.SLOListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误: " + err.Error(), "data": nil})
+		return
+	}
+	slos, err := h.sloService.List(clusterID, &query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error(), "data": nil})
+		return
+	}
+	// 只返回用户有权限的命名空间中的 SLO
+	if _, hasAll := middleware.GetAllowedNamespaces(c); !hasAll {
+		visible := make([]models.SLO, 0, len(slos))
+		for _, slo := range slos {
+			if middleware.HasNamespaceAccess(c, slo.Namespace) {
+				visible = append(visible, slo)
+			}
+		}
+		slos = visible
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": slos})
+}
+
+// GetSLO 获取 SLO 及其当前的达标率、剩余错误预算与消耗速率
+func (h *SLOHandler) GetSLO(c *gin.Context) {
+	slo, ok := h.getSLO(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": h.sloService.Status(c.Request.Context(), slo)})
+}
+
+// GetBudgetHistory 获取错误预算趋势，参数 range（默认 7d）、step（为空时自动计算）
+func (h *SLOHandler) GetBudgetHistory(c *gin.Context) {
+	slo, ok := h.getSLO(c)
+	if !ok {
+		return
+	}
+	history, err := h.sloService.BudgetHistory(c.Request.Context(), slo, c.DefaultQuery("range", "7d"), c.Query("step"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "查询错误预算趋势失败: " + err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": history})
+}
+
+// CreateSLO 创建 SLO
+func (h *SLOHandler) CreateSLO(c *gin.Context) {
+	clusterID, ok := parseUintParam(c, "clusterID")
+	if !ok {
+		return
+	}
+	req, ok := h.bindRequest(c)
+	if !ok {
+		return
+	}
+	slo, err := h.sloService.Save(clusterID, nil, req, c.GetUint("user_id"), c.GetString("username"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "创建成功", "data": slo})
+}
+
+// UpdateSLO 更新 SLO，已生成过 PrometheusRule 时同步更新规则
+func (h *SLOHandler) UpdateSLO(c *gin.Context) {
+	existing, ok := h.getSLO(c)
+	if !ok {
+		return
+	}
+	req, ok := h.bindRequest(c)
+	if !ok {
+		return
+	}
+	slo, err := h.sloService.Save(existing.ClusterID, existing, req, c.GetUint("user_id"), c.GetString("username"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+
+	message := "更新成功"
+	if slo.RuleName != "" {
+		if cluster, err := h.clusterService.GetCluster(slo.ClusterID); err != nil {
+			message = "SLO 已保存，但获取集群失败，PrometheusRule 未更新"
+		} else if _, err := h.sloService.SyncRule(c.Request.Context(), cluster, slo); err != nil {
+			logger.Error("同步 SLO 规则失败", "slo", slo.Name, "error", err)
+			message = "SLO 已保存，但更新 PrometheusRule 失败: " + err.Error()
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": message, "data": slo})
+}
+
+// DeleteSLO 删除 SLO 及其生成的 PrometheusRule
+func (h *SLOHandler) DeleteSLO(c *gin.Context) {
+	slo, ok := h.getSLO(c)
+	if !ok {
+		return
+	}
+	cluster, err := h.clusterService.GetCluster(slo.ClusterID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "集群不存在", "data": nil})
+		return
+	}
+	if err := h.sloService.Delete(c.Request.Context(), cluster, slo); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "删除成功", "data": nil})
+}
+
+// SyncRule 生成或更新 SLO 对应的 PrometheusRule（记录规则与多窗口消耗速率告警）
+func (h *SLOHandler) SyncRule(c *gin.Context) {
+	slo, ok := h.getSLO(c)
+	if !ok {
+		return
+	}
+	cluster, err := h.clusterService.GetCluster(slo.ClusterID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "集群不存在", "data": nil})
+		return
+	}
+	rule, err := h.sloService.SyncRule(c.Request.Context(), cluster, slo)
+	if err != nil {
+		logger.Error("生成 SLO 规则失败", "slo", slo.Name, "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "规则已生成", "data": rule})
+}
+
+// getSLO 获取路径中的 SLO 并校验命名空间权限，失败时直接写出响应
+func (h *SLOHandler) getSLO(c *gin.Context) (*models.SLO, bool) {
+	clusterID, ok := parseUintParam(c, "clusterID")
+	if !ok {
+		return nil, false
+	}
+	id, ok := parseUintParam(c, "id")
+	if !ok {
+		return nil, false
+	}
+	slo, err := h.sloService.Get(clusterID, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": err.Error(), "data": nil})
+		return nil, false
+	}
+	if _, hasAccess := middleware.CheckNamespacePermission(c, slo.Namespace); !hasAccess {
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "message": fmt.Sprintf("无权访问命名空间: %s", slo.Namespace), "data": nil})
+		return nil, false
+	}
+	c.Set("audit_resource_name", slo.Name)
+	return slo, true
+}
+
+// bindRequest 解析请求体并校验目标命名空间权限
+func (h *SLOHandler) bindRequest(c *gin.Context) (*models.SLORequest, bool) {
+	var req models.SLORequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误: " + err.Error(), "data": nil})
+		return nil, false
+	}
+	if _, hasAccess := middleware.CheckNamespacePermission(c, req.Namespace); !hasAccess {
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "message": fmt.Sprintf("无权访问命名空间: %s", req.Namespace), "data": nil})
+		return nil, false
+	}
+	c.Set("audit_resource_name", req.Name)
+	return &req, true
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+	"github.com/clay-wangzhi/KubePolaris/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UpgradeReadinessHandler 集群升级前废弃 API 检查处理器
+type UpgradeReadinessHandler struct {
+	clusterService          *services.ClusterService
+	upgradeReadinessService *services.UpgradeReadinessService
+}
+
+// NewUpgradeReadinessHandler 创建升级检查处理器
+func NewUpgradeReadinessHandler(clusterService *services.ClusterService, upgradeReadinessService *services.UpgradeReadinessService) *UpgradeReadinessHandler {
+	return &UpgradeReadinessHandler{
+		clusterService:          clusterService,
+		upgradeReadinessService: upgradeReadinessService,
+	}
+}
+
+// ListDeprecatedAPIs 获取内置的 API 废弃与移除版本
+func (h *UpgradeReadinessHandler) ListDeprecatedAPIs(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": services.DeprecatedAPIs()})
+}
+
+// GetUpgradeReadiness 检查集群升级到目标版本前仍在使用的废弃 API
+func (h *UpgradeReadinessHandler) GetUpgradeReadiness(c *gin.Context) {
+	var query models.UpgradeReadinessQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "参数错误: " + err.Error(), "data": nil})
+		return
+	}
+	cluster, err := h.clusterService.GetCluster(parseClusterID(c.Param("clusterID")))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "集群不存在", "data": nil})
+		return
+	}
+	report, err := h.upgradeReadinessService.Check(c.Request.Context(), cluster, &query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": report})
+}
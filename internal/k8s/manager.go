@@ -3,6 +3,7 @@ package k8s
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"sync"
 	"time"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/clay-wangzhi/KubePolaris/internal/services"
 	"github.com/clay-wangzhi/KubePolaris/pkg/logger"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/informers"
@@ -49,6 +51,9 @@ type ClusterRuntime struct {
 type ClusterInformerManager struct {
 	mu       sync.RWMutex
 	clusters map[uint]*ClusterRuntime
+
+	// configObserver 记录 Informer 观察到的 ConfigMap/Secret 变更（为空时不记录）
+	configObserver *services.ConfigVersionService
 }
 
 func NewClusterInformerManager() *ClusterInformerManager {
@@ -57,6 +62,13 @@ func NewClusterInformerManager() *ClusterInformerManager {
 	}
 }
 
+// SetConfigObserver 设置 ConfigMap/Secret 变更观察者，需在集群 informer 创建前调用
+func (m *ClusterInformerManager) SetConfigObserver(observer *services.ConfigVersionService) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.configObserver = observer
+}
+
 // EnsureForCluster 确保指定集群的 informer 已创建并启动
 func (m *ClusterInformerManager) EnsureForCluster(cluster *models.Cluster) (*ClusterRuntime, error) {
 	m.mu.Lock()
@@ -93,8 +105,11 @@ func (m *ClusterInformerManager) EnsureForCluster(cluster *models.Cluster) (*Clu
 	_ = factory.Core().V1().Nodes().Informer()
 	_ = factory.Core().V1().Namespaces().Informer()
 	_ = factory.Core().V1().Services().Informer()
-	_ = factory.Core().V1().ConfigMaps().Informer()
-	_ = factory.Core().V1().Secrets().Informer()
+	configMapInformer := factory.Core().V1().ConfigMaps().Informer()
+	secretInformer := factory.Core().V1().Secrets().Informer()
+	if m.configObserver != nil {
+		m.registerConfigObserver(cluster.ID, configMapInformer, secretInformer)
+	}
 	_ = factory.Apps().V1().Deployments().Informer()
 	_ = factory.Apps().V1().StatefulSets().Informer()
 	_ = factory.Apps().V1().DaemonSets().Informer()
@@ -132,6 +147,38 @@ func (m *ClusterInformerManager) EnsureForCluster(cluster *models.Cluster) (*Clu
 	return rt, nil
 }
 
+// registerConfigObserver 在 ConfigMap/Secret informer 上注册更新回调，仅在数据变化时记录版本
+func (m *ClusterInformerManager) registerConfigObserver(clusterID uint, configMapInformer, secretInformer cache.SharedIndexInformer) {
+	observer := m.configObserver
+	_, err := configMapInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldCM, ok1 := oldObj.(*corev1.ConfigMap)
+			newCM, ok2 := newObj.(*corev1.ConfigMap)
+			if !ok1 || !ok2 || (reflect.DeepEqual(oldCM.Data, newCM.Data) && reflect.DeepEqual(oldCM.BinaryData, newCM.BinaryData)) {
+				return
+			}
+			observer.OnConfigMapUpdate(clusterID, oldCM, newCM)
+		},
+	})
+	if err != nil {
+		logger.Error("注册ConfigMap变更回调失败", "clusterID", clusterID, "error", err)
+	}
+
+	_, err = secretInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldSecret, ok1 := oldObj.(*corev1.Secret)
+			newSecret, ok2 := newObj.(*corev1.Secret)
+			if !ok1 || !ok2 || reflect.DeepEqual(oldSecret.Data, newSecret.Data) {
+				return
+			}
+			observer.OnSecretUpdate(clusterID, oldSecret, newSecret)
+		},
+	})
+	if err != nil {
+		logger.Error("注册Secret变更回调失败", "clusterID", clusterID, "error", err)
+	}
+}
+
 // waitForSync 等待本集群的缓存同步就绪（首次可能需要几十到数百毫秒，取决于资源规模）
 func (m *ClusterInformerManager) waitForSync(ctx context.Context, rt *ClusterRuntime) bool {
 	if rt.synced {
@@ -170,6 +217,36 @@ func (m *ClusterInformerManager) waitForSync(ctx context.Context, rt *ClusterRun
 	}
 }
 
+// HasSynced 集群的内置 Informer 缓存是否已同步完成（不等待）
+func (m *ClusterInformerManager) HasSynced(clusterID uint) bool {
+	m.mu.RLock()
+	rt, ok := m.clusters[clusterID]
+	m.mu.RUnlock()
+	if !ok {
+		return false
+	}
+	if rt.synced {
+		return true
+	}
+	informers := []cache.SharedIndexInformer{
+		rt.factory.Core().V1().Pods().Informer(),
+		rt.factory.Core().V1().Nodes().Informer(),
+		rt.factory.Core().V1().Namespaces().Informer(),
+		rt.factory.Core().V1().Services().Informer(),
+		rt.factory.Core().V1().ConfigMaps().Informer(),
+		rt.factory.Apps().V1().Deployments().Informer(),
+		rt.factory.Apps().V1().StatefulSets().Informer(),
+		rt.factory.Apps().V1().DaemonSets().Informer(),
+		rt.factory.Batch().V1().Jobs().Informer(),
+	}
+	for _, informer := range informers {
+		if !informer.HasSynced() {
+			return false
+		}
+	}
+	return true
+}
+
 // GetOverviewSnapshot 从本地缓存即时汇总概览（不触发远端 List）
 func (m *ClusterInformerManager) GetOverviewSnapshot(ctx context.Context, clusterID uint) (*OverviewSnapshot, error) {
 	m.mu.RLock()
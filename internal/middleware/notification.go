@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"sigs.k8s.io/yaml"
+)
+
+// applyFailureBodyLimit 为解析错误信息缓存的响应体上限
+const applyFailureBodyLimit = 16 * 1024
+
+// bodyCaptureWriter 在写出响应的同时缓存响应体前 applyFailureBodyLimit 字节
+type bodyCaptureWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyCaptureWriter) Write(data []byte) (int, error) {
+	if remain := applyFailureBodyLimit - w.body.Len(); remain > 0 {
+		if len(data) > remain {
+			w.body.Write(data[:remain])
+		} else {
+			w.body.Write(data)
+		}
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// NotifyApplyFailure YAML 应用失败通知中间件：集群下 */yaml/apply 请求返回 4xx/5xx 时发布 apply_yaml_failed 事件，
+// dryRun 预检失败不通知
+func NotifyApplyFailure(notificationSvc *services.NotificationService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		if c.Request.Method != "POST" || !strings.HasSuffix(path, "/yaml/apply") {
+			c.Next()
+			return
+		}
+
+		var requestBody []byte
+		if c.Request.Body != nil {
+			requestBody, _ = io.ReadAll(c.Request.Body)
+			c.Request.Body = io.NopCloser(bytes.NewReader(requestBody))
+		}
+		writer := &bodyCaptureWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		if c.Writer.Status() < 400 {
+			return
+		}
+		clusterID, err := strconv.ParseUint(c.Param("clusterID"), 10, 32)
+		if err != nil {
+			return
+		}
+		var req struct {
+			YAML   string `json:"yaml"`
+			DryRun bool   `json:"dryRun"`
+		}
+		_ = json.Unmarshal(requestBody, &req)
+		if req.DryRun {
+			return
+		}
+		var obj struct {
+			Metadata struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"metadata"`
+		}
+		_ = yaml.Unmarshal([]byte(req.YAML), &obj)
+
+		message := ""
+		var resp struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(writer.body.Bytes(), &resp) == nil {
+			message = resp.Message
+		}
+		if message == "" {
+			message = "HTTP " + strconv.Itoa(c.Writer.Status())
+		}
+
+		// 路径形如 /api/v1/clusters/1/deployments/yaml/apply，取 yaml 前一段作为资源类型
+		segments := strings.Split(strings.TrimSuffix(path, "/yaml/apply"), "/")
+		resourceType := segments[len(segments)-1]
+		notificationSvc.ApplyFailed(uint(clusterID), resourceType, obj.Metadata.Namespace, obj.Metadata.Name, c.GetString("username"), message)
+	}
+}
@@ -47,11 +47,25 @@ func init() {
 		{`^/api/v1/clusters/import$`, constants.ModuleCluster, constants.ActionImport, "cluster", -1},
 		{`^/api/v1/clusters/test-connection$`, constants.ModuleCluster, constants.ActionTest, "cluster", -1},
 		{`^/api/v1/clusters/(\d+)$`, constants.ModuleCluster, "", "cluster", 1},
+		{`^/api/v1/clusters/\d+/certificates/scan$`, constants.ModuleCluster, constants.ActionSync, "certificate", -1},
+		{`^/api/v1/clusters/\d+/om/diagnosis-rules/([^/]+)$`, constants.ModuleCluster, constants.ActionUpdate, "diagnosis_rule", 1},
+		{`^/api/v1/clusters/\d+/om/custom-rules$`, constants.ModuleCluster, constants.ActionCreate, "custom_diagnosis_rule", -1},
+		{`^/api/v1/clusters/\d+/om/custom-rules/test$`, constants.ModuleCluster, constants.ActionTest, "custom_diagnosis_rule", -1},
+		{`^/api/v1/clusters/\d+/om/custom-rules/(\d+)$`, constants.ModuleCluster, "", "custom_diagnosis_rule", 1},
 
 		// 节点模块
 		{`^/api/v1/clusters/\d+/nodes/([^/]+)/cordon$`, constants.ModuleNode, constants.ActionCordon, "node", 1},
 		{`^/api/v1/clusters/\d+/nodes/([^/]+)/uncordon$`, constants.ModuleNode, constants.ActionUncordon, "node", 1},
 		{`^/api/v1/clusters/\d+/nodes/([^/]+)/drain$`, constants.ModuleNode, constants.ActionDrain, "node", 1},
+		{`^/api/v1/clusters/\d+/nodes/bulk$`, constants.ModuleNode, constants.ActionBulkUpdate, "node", -1},
+		{`^/api/v1/clusters/\d+/nodes/([^/]+)/preview$`, constants.ModuleNode, constants.ActionPreview, "node", 1},
+		{`^/api/v1/clusters/\d+/nodes/([^/]+)/labels$`, constants.ModuleNode, constants.ActionUpdate, "node_labels", 1},
+		{`^/api/v1/clusters/\d+/nodes/([^/]+)/annotations$`, constants.ModuleNode, constants.ActionUpdate, "node_annotations", 1},
+		{`^/api/v1/clusters/\d+/nodes/([^/]+)/taints$`, constants.ModuleNode, constants.ActionUpdate, "node_taints", 1},
+		{`^/api/v1/clusters/\d+/maintenance$`, constants.ModuleNode, constants.ActionCreate, "maintenance_window", -1},
+		{`^/api/v1/clusters/\d+/maintenance/(\d+)/cancel$`, constants.ModuleNode, constants.ActionCancel, "maintenance_window", 1},
+		{`^/api/v1/clusters/\d+/maintenance/\d+/nodes/([^/]+)/done$`, constants.ModuleNode, constants.ActionConfirm, "maintenance_node", 1},
+		{`^/api/v1/clusters/\d+/maintenance/(\d+)$`, constants.ModuleNode, "", "maintenance_window", 1},
 
 		// Pod 模块
 		{`^/api/v1/clusters/\d+/pods/([^/]+)/([^/]+)$`, constants.ModulePod, "", "pod", 2},
@@ -86,10 +100,19 @@ func init() {
 		// ConfigMap 模块
 		{`^/api/v1/clusters/\d+/configmaps$`, constants.ModuleConfig, constants.ActionCreate, "configmap", -1},
 		{`^/api/v1/clusters/\d+/configmaps/([^/]+)/([^/]+)$`, constants.ModuleConfig, "", "configmap", 2},
+		{`^/api/v1/clusters/\d+/configmaps/[^/]+/([^/]+)/versions/\d+/restore$`, constants.ModuleConfig, constants.ActionRestore, "configmap", 1},
 
 		// Secret 模块
 		{`^/api/v1/clusters/\d+/secrets$`, constants.ModuleConfig, constants.ActionCreate, "secret", -1},
 		{`^/api/v1/clusters/\d+/secrets/([^/]+)/([^/]+)$`, constants.ModuleConfig, "", "secret", 2},
+		{`^/api/v1/clusters/\d+/secrets/[^/]+/([^/]+)/reveal/[^/]+$`, constants.ModuleConfig, constants.ActionReveal, "secret_key", 1},
+		{`^/api/v1/clusters/\d+/secrets/[^/]+/([^/]+)/versions/\d+/restore$`, constants.ModuleConfig, constants.ActionRestore, "secret", 1},
+		{`^/api/v1/clusters/\d+/secret-sources$`, constants.ModuleConfig, constants.ActionCreate, "secret_source", -1},
+		{`^/api/v1/clusters/\d+/secret-sources/(\d+)/test$`, constants.ModuleConfig, constants.ActionTest, "secret_source", 1},
+		{`^/api/v1/clusters/\d+/secret-sources/(\d+)$`, constants.ModuleConfig, "", "secret_source", 1},
+		{`^/api/v1/clusters/\d+/external-secrets$`, constants.ModuleConfig, constants.ActionCreate, "external_secret", -1},
+		{`^/api/v1/clusters/\d+/external-secrets/(\d+)/sync$`, constants.ModuleConfig, constants.ActionSync, "external_secret", 1},
+		{`^/api/v1/clusters/\d+/external-secrets/(\d+)$`, constants.ModuleConfig, "", "external_secret", 1},
 
 		// Service 模块
 		{`^/api/v1/clusters/\d+/services$`, constants.ModuleNetwork, constants.ActionCreate, "service", -1},
@@ -111,12 +134,46 @@ func init() {
 		// 监控配置模块
 		{`^/api/v1/clusters/\d+/monitoring/config$`, constants.ModuleMonitoring, "", "monitoring_config", -1},
 		{`^/api/v1/clusters/\d+/monitoring/test-connection$`, constants.ModuleMonitoring, constants.ActionTest, "monitoring_config", -1},
+		{`^/api/v1/clusters/\d+/monitoring/query(_range)?$`, constants.ModuleMonitoring, constants.ActionQuery, "promql", -1},
+		{`^/api/v1/monitoring/saved-queries$`, constants.ModuleMonitoring, constants.ActionCreate, "saved_query", -1},
+		{`^/api/v1/monitoring/saved-queries/(\d+)$`, constants.ModuleMonitoring, "", "saved_query", 1},
+		{`^/api/v1/clusters/\d+/cost/pricing$`, constants.ModuleMonitoring, constants.ActionUpdate, "cost_pricing", -1},
+		{`^/api/v1/clusters/\d+/cost/rollup$`, constants.ModuleMonitoring, constants.ActionSync, "cost_rollup", -1},
+		{`^/api/v1/clusters/\d+/slos$`, constants.ModuleMonitoring, constants.ActionCreate, "slo", -1},
+		{`^/api/v1/clusters/\d+/slos/\d+/rule$`, constants.ModuleMonitoring, constants.ActionSync, "slo_rule", -1},
+		{`^/api/v1/clusters/\d+/slos/\d+$`, constants.ModuleMonitoring, "", "slo", -1},
+		{`^/api/v1/clusters/\d+/logs/config$`, constants.ModuleMonitoring, "", "log_config", -1},
+		{`^/api/v1/clusters/\d+/logs/config/test$`, constants.ModuleMonitoring, constants.ActionTest, "log_config", -1},
+		{`^/api/v1/clusters/\d+/logs/parse-rules$`, constants.ModuleMonitoring, constants.ActionCreate, "log_parse_rule", -1},
+		{`^/api/v1/clusters/\d+/logs/parse-rules/preview$`, constants.ModuleMonitoring, constants.ActionTest, "log_parse_rule", -1},
+		{`^/api/v1/clusters/\d+/logs/parse-rules/(\d+)$`, constants.ModuleMonitoring, "", "log_parse_rule", 1},
+		{`^/api/v1/clusters/\d+/logs/export$`, constants.ModuleMonitoring, constants.ActionExport, "logs", -1},
+		{`^/api/v1/clusters/\d+/logs/export-jobs$`, constants.ModuleMonitoring, constants.ActionCreate, "log_export_job", -1},
+		{`^/api/v1/clusters/\d+/logs/export-jobs/(\d+)/cancel$`, constants.ModuleMonitoring, constants.ActionCancel, "log_export_job", 1},
+		{`^/api/v1/clusters/\d+/logs/export-jobs/(\d+)$`, constants.ModuleMonitoring, "", "log_export_job", 1},
 
 		// AlertManager 模块
 		{`^/api/v1/clusters/\d+/alertmanager/config$`, constants.ModuleAlert, "", "alertmanager_config", -1},
 		{`^/api/v1/clusters/\d+/alertmanager/test-connection$`, constants.ModuleAlert, constants.ActionTest, "alertmanager_config", -1},
+		{`^/api/v1/clusters/\d+/alertmanager/routing$`, constants.ModuleAlert, constants.ActionUpdate, "alertmanager_routing", -1},
+		{`^/api/v1/clusters/\d+/alertmanager/routing/(validate|simulate)$`, constants.ModuleAlert, constants.ActionTest, "alertmanager_routing", -1},
+		{`^/api/v1/clusters/\d+/logs/alert-rules$`, constants.ModuleAlert, constants.ActionCreate, "log_alert_rule", -1},
+		{`^/api/v1/clusters/\d+/logs/alert-rules/(\d+)$`, constants.ModuleAlert, "", "log_alert_rule", 1},
+		{`^/api/v1/clusters/\d+/prometheus-rules$`, constants.ModuleAlert, constants.ActionCreate, "prometheusrule", -1},
+		{`^/api/v1/clusters/\d+/prometheus-rules/(validate|test)$`, constants.ModuleAlert, constants.ActionTest, "prometheusrule", -1},
+		{`^/api/v1/clusters/\d+/prometheus-rules/build$`, constants.ModuleAlert, constants.ActionPreview, "prometheusrule", -1},
+		{`^/api/v1/clusters/\d+/prometheus-rules/([^/]+)/([^/]+)$`, constants.ModuleAlert, "", "prometheusrule", 2},
 		{`^/api/v1/clusters/\d+/silences$`, constants.ModuleAlert, constants.ActionCreate, "silence", -1},
+		{`^/api/v1/clusters/\d+/silences/bulk$`, constants.ModuleAlert, constants.ActionCreate, "silence", -1},
+		{`^/api/v1/clusters/\d+/silences/templates$`, constants.ModuleAlert, constants.ActionCreate, "silence_template", -1},
+		{`^/api/v1/clusters/\d+/silences/templates/\d+/apply$`, constants.ModuleAlert, constants.ActionCreate, "silence", -1},
+		{`^/api/v1/clusters/\d+/silences/templates/(\d+)$`, constants.ModuleAlert, "", "silence_template", 1},
+		{`^/api/v1/clusters/\d+/silences/recurring$`, constants.ModuleAlert, constants.ActionCreate, "recurring_silence", -1},
+		{`^/api/v1/clusters/\d+/silences/recurring/(\d+)$`, constants.ModuleAlert, "", "recurring_silence", 1},
+		{`^/api/v1/clusters/\d+/silences/([^/]+)/extend$`, constants.ModuleAlert, constants.ActionExtend, "silence", 1},
 		{`^/api/v1/clusters/\d+/silences/([^/]+)$`, constants.ModuleAlert, constants.ActionDelete, "silence", 1},
+		{`^/api/v1/clusters/\d+/alert-history/(\d+)/acknowledge$`, constants.ModuleAlert, constants.ActionAcknowledge, "alert", 1},
+		{`^/api/v1/clusters/\d+/alert-history/(\d+)/assign$`, constants.ModuleAlert, constants.ActionAssign, "alert", 1},
 
 		// ArgoCD 模块
 		{`^/api/v1/clusters/\d+/argocd/config$`, constants.ModuleArgoCD, "", "argocd_config", -1},
@@ -135,6 +192,15 @@ func init() {
 		{`^/api/v1/permissions/cluster-permissions/(\d+)$`, constants.ModulePermission, "", "cluster_permission", 1},
 		{`^/api/v1/permissions/cluster-permissions/batch-delete$`, constants.ModulePermission, constants.ActionDelete, "cluster_permission", -1},
 
+		// 通知中心
+		{`^/api/v1/notifications/channels$`, constants.ModuleNotification, constants.ActionCreate, "notification_channel", -1},
+		{`^/api/v1/notifications/channels/test$`, constants.ModuleNotification, constants.ActionTest, "notification_channel", -1},
+		{`^/api/v1/notifications/channels/(\d+)$`, constants.ModuleNotification, "", "notification_channel", 1},
+		{`^/api/v1/notifications/channels/(\d+)/test$`, constants.ModuleNotification, constants.ActionTest, "notification_channel", 1},
+		{`^/api/v1/notifications/subscriptions$`, constants.ModuleNotification, constants.ActionCreate, "notification_subscription", -1},
+		{`^/api/v1/notifications/subscriptions/(\d+)$`, constants.ModuleNotification, "", "notification_subscription", 1},
+		{`^/api/v1/notifications/deliveries/(\d+)/retry$`, constants.ModuleNotification, constants.ActionRetry, "notification_delivery", 1},
+
 		// 系统设置模块
 		{`^/api/v1/system/ldap/config$`, constants.ModuleSystem, "", "ldap_config", -1},
 		{`^/api/v1/system/ldap/test-connection$`, constants.ModuleSystem, constants.ActionTest, "ldap_config", -1},
@@ -169,6 +235,12 @@ func OperationAudit(logSvc *services.OperationLogService) gin.HandlerFunc {
 			return
 		}
 
+		// 跳过 Alertmanager 等外部系统的 webhook 推送
+		if strings.HasPrefix(path, "/api/v1/webhooks/") {
+			c.Next()
+			return
+		}
+
 		// 跳过 WebSocket 请求（由终端审计单独处理）
 		if strings.HasPrefix(path, "/ws/") {
 			c.Next()
@@ -236,6 +308,13 @@ func OperationAudit(logSvc *services.OperationLogService) gin.HandlerFunc {
 			namespace = c.Param("ns")
 		}
 
+		// handler 可指定更精确的资源名（如 Secret 明文查看记录 name/key）
+		if rn, exists := c.Get("audit_resource_name"); exists {
+			if rnStr, ok := rn.(string); ok {
+				resourceName = rnStr
+			}
+		}
+
 		// 如果资源名还是空的，尝试从常见参数获取
 		if resourceName == "" {
 			if name := c.Param("name"); name != "" {
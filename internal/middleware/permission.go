@@ -3,6 +3,7 @@ package middleware
 import (
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/clay-wangzhi/KubePolaris/internal/models"
 	"github.com/clay-wangzhi/KubePolaris/internal/services"
@@ -219,6 +220,11 @@ func (m *PermissionMiddleware) AutoWriteCheck() gin.HandlerFunc {
 			c.Next()
 			return
 		}
+		// PromQL 查询使用 POST 只是为了承载较长的语句，不修改任何资源
+		if method == "POST" && isReadOnlyPost(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
 
 		// 获取权限信息
 		permissionInterface, exists := c.Get("cluster_permission")
@@ -252,8 +258,13 @@ func (m *PermissionMiddleware) AutoWriteCheck() gin.HandlerFunc {
 	}
 }
 
+// isReadOnlyPost 判断只读的 POST 接口
+func isReadOnlyPost(path string) bool {
+	return strings.HasSuffix(path, "/monitoring/query") || strings.HasSuffix(path, "/monitoring/query_range")
+}
+
 // PlatformAdminRequired 平台管理员权限检查
-// 用于系统设置、用户管理等平台级操作
+// 用于系统设置、通知渠道等平台级操作；平台管理员为内置 admin 用户，与默认集群权限的判定一致
 func PlatformAdminRequired() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 从上下文获取用户信息
@@ -267,11 +278,14 @@ func PlatformAdminRequired() gin.HandlerFunc {
 			return
 		}
 
-		// TODO: 检查用户是否是平台管理员
-		// 目前简单实现：ID为1的用户是超级管理员
-		// 实际应用中应该从数据库查询用户角色
-		// 这里先放行，后续完善
-		_ = userID
+		if c.GetString("username") != "admin" {
+			c.JSON(http.StatusForbidden, gin.H{
+				"code":    403,
+				"message": "需要平台管理员权限",
+			})
+			c.Abort()
+			return
+		}
 
 		c.Next()
 	}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+// rateLimiterIdleTTL 超过该时长未访问的用户令牌桶会被清理
+const rateLimiterIdleTTL = 10 * time.Minute
+
+// userRateLimiter 按用户维护的令牌桶
+type userRateLimiter struct {
+	mu       sync.Mutex
+	limit    rate.Limit
+	burst    int
+	limiters map[string]*userLimiterEntry
+	lastGC   time.Time
+}
+
+type userLimiterEntry struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func (l *userRateLimiter) allow(key string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastGC) > rateLimiterIdleTTL {
+		for k, e := range l.limiters {
+			if now.Sub(e.lastSeen) > rateLimiterIdleTTL {
+				delete(l.limiters, k)
+			}
+		}
+		l.lastGC = now
+	}
+
+	entry, ok := l.limiters[key]
+	if !ok {
+		entry = &userLimiterEntry{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.limiters[key] = entry
+	}
+	entry.lastSeen = now
+	return entry.limiter.AllowN(now, 1)
+}
+
+// RateLimitPerUser 按用户限流中间件：每个用户每分钟 perMinute 次，允许 burst 次突发；
+// 未登录请求按客户端 IP 计数。perMinute <= 0 时不限流
+func RateLimitPerUser(perMinute, burst int) gin.HandlerFunc {
+	if perMinute <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	if burst <= 0 {
+		burst = 1
+	}
+	limiter := &userRateLimiter{
+		limit:    rate.Limit(float64(perMinute) / 60),
+		burst:    burst,
+		limiters: make(map[string]*userLimiterEntry),
+		lastGC:   time.Now(),
+	}
+	// 补充一个令牌所需的秒数
+	retryAfter := strconv.Itoa(int(math.Ceil(60 / float64(perMinute))))
+
+	return func(c *gin.Context) {
+		key := "ip:" + c.ClientIP()
+		if userID := c.GetUint("user_id"); userID != 0 {
+			key = "user:" + strconv.FormatUint(uint64(userID), 10)
+		}
+		if !limiter.allow(key, time.Now()) {
+			c.Header("Retry-After", retryAfter)
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"code":    429,
+				"message": "请求过于频繁，请稍后再试",
+				"data":    nil,
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,162 @@
+package models
+
+import "time"
+
+// 告警记录状态
+const (
+	AlertRecordFiring   = "firing"
+	AlertRecordResolved = "resolved"
+)
+
+// 告警生命周期事件类型
+const (
+	AlertEventFiring       = "firing"       // 开始触发
+	AlertEventResolved     = "resolved"     // 恢复
+	AlertEventSilenced     = "silenced"     // 被静默
+	AlertEventUnsilenced   = "unsilenced"   // 静默解除
+	AlertEventAcknowledged = "acknowledged" // 在 KubePolaris 中确认
+	AlertEventAssigned     = "assigned"     // 在 KubePolaris 中指派处理人
+)
+
+// 告警来源
+const (
+	AlertSourcePoller  = "poller"  // 周期拉取 Alertmanager v2 API
+	AlertSourceWebhook = "webhook" // Alertmanager webhook_configs 推送
+)
+
+// AlertRecord 告警记录：同一指纹的一次触发（startsAt 相同）对应一条记录，恢复后再次触发产生新记录
+type AlertRecord struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	ClusterID   uint   `json:"cluster_id" gorm:"uniqueIndex:idx_alert_record_instance;index:idx_alert_record_cluster_status"`
+	Fingerprint string `json:"fingerprint" gorm:"size:64;uniqueIndex:idx_alert_record_instance"`
+	AlertName   string `json:"alert_name" gorm:"size:255;index"`
+	Severity    string `json:"severity" gorm:"size:50;index"`
+	Namespace   string `json:"namespace" gorm:"size:253"`
+	Team        string `json:"team" gorm:"size:100;index"` // 取自告警的团队标签（默认 team）
+	Summary     string `json:"summary" gorm:"size:1000"`
+	Labels      string `json:"-" gorm:"type:text"` // JSON 对象
+	Annotations string `json:"-" gorm:"type:text"` // JSON 对象
+
+	Status       string     `json:"status" gorm:"size:20;index:idx_alert_record_cluster_status"` // firing, resolved
+	Silenced     bool       `json:"silenced"`
+	StartsAt     time.Time  `json:"starts_at" gorm:"uniqueIndex:idx_alert_record_instance;index"`
+	ResolvedAt   *time.Time `json:"resolved_at,omitempty"`
+	LastSeenAt   time.Time  `json:"last_seen_at"`
+	GeneratorURL string     `json:"generator_url,omitempty" gorm:"size:1000"`
+
+	// 确认与指派
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+	AcknowledgedBy string     `json:"acknowledged_by,omitempty" gorm:"size:100"`
+	AssigneeID     *uint      `json:"assignee_id,omitempty" gorm:"index"`
+	Assignee       string     `json:"assignee,omitempty" gorm:"size:100"`
+
+	LabelMap      map[string]string   `json:"labels" gorm:"-"`
+	AnnotationMap map[string]string   `json:"annotations" gorm:"-"`
+	Events        []AlertHistoryEvent `json:"events,omitempty" gorm:"-"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName 指定告警记录表名
+func (AlertRecord) TableName() string {
+	return "alert_records"
+}
+
+// AlertHistoryEvent 告警生命周期事件
+type AlertHistoryEvent struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	AlertID   uint      `json:"alert_id" gorm:"index;not null"`
+	ClusterID uint      `json:"cluster_id" gorm:"index"`
+	Type      string    `json:"type" gorm:"size:20"`
+	Source    string    `json:"source,omitempty" gorm:"size:20"` // poller、webhook，人工操作为空
+	Operator  string    `json:"operator,omitempty" gorm:"size:100"`
+	Message   string    `json:"message,omitempty" gorm:"size:1000"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+// TableName 指定告警事件表名
+func (AlertHistoryEvent) TableName() string {
+	return "alert_history_events"
+}
+
+// AlertHistoryQuery 告警历史查询条件
+type AlertHistoryQuery struct {
+	ClusterID    uint      `form:"-"`
+	Page         int       `form:"page"`
+	PageSize     int       `form:"pageSize"`
+	AlertName    string    `form:"alertName"`
+	Severity     string    `form:"severity"`
+	Status       string    `form:"status"`
+	Team         string    `form:"team"`
+	Namespace    string    `form:"namespace"`
+	Keyword      string    `form:"keyword"`      // 匹配告警名、摘要与标签
+	Acknowledged *bool     `form:"acknowledged"` // 是否已确认
+	AssigneeID   uint      `form:"assigneeId"`
+	StartTime    time.Time `form:"startTime" time_format:"2006-01-02T15:04:05Z07:00"` // 按触发时间过滤
+	EndTime      time.Time `form:"endTime" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// AlertHistoryListResponse 告警历史列表响应
+type AlertHistoryListResponse struct {
+	Items    []AlertRecord `json:"items"`
+	Total    int64         `json:"total"`
+	Page     int           `json:"page"`
+	PageSize int           `json:"pageSize"`
+}
+
+// AlertAcknowledgeRequest 确认告警请求
+type AlertAcknowledgeRequest struct {
+	Note string `json:"note"`
+}
+
+// AlertAssignRequest 指派告警请求，AssigneeID 为 0 表示取消指派
+type AlertAssignRequest struct {
+	AssigneeID uint   `json:"assignee_id"`
+	Note       string `json:"note"`
+}
+
+// AlertNoiseItem 告警规则噪音排名项
+type AlertNoiseItem struct {
+	ClusterID        uint    `json:"cluster_id"`
+	AlertName        string  `json:"alert_name"`
+	Severity         string  `json:"severity"`
+	Firings          int     `json:"firings"`            // 触发次数
+	Instances        int     `json:"instances"`          // 不同指纹数
+	Flapping         int     `json:"flapping"`           // 短时间内恢复的触发次数
+	AckRate          float64 `json:"ack_rate"`           // 被确认的比例
+	AvgDurationSec   float64 `json:"avg_duration_sec"`   // 已恢复告警的平均持续时长
+	TotalDurationSec float64 `json:"total_duration_sec"` // 统计区间内的累计触发时长
+	NoiseScore       float64 `json:"noise_score"`        // 综合噪音分，越高越值得治理
+}
+
+// AlertMetricsItem MTTA / MTTR 统计项
+type AlertMetricsItem struct {
+	Key          string  `json:"key"` // 集群 ID 或团队名
+	Name         string  `json:"name"`
+	Total        int     `json:"total"`
+	Acknowledged int     `json:"acknowledged"`
+	Resolved     int     `json:"resolved"`
+	MTTASec      float64 `json:"mtta_sec"` // 平均确认时长（触发 → 确认）
+	MTTRSec      float64 `json:"mttr_sec"` // 平均恢复时长（触发 → 恢复）
+}
+
+// AlertmanagerWebhookPayload Alertmanager webhook_configs 推送的请求体
+type AlertmanagerWebhookPayload struct {
+	Version  string                     `json:"version"`
+	GroupKey string                     `json:"groupKey"`
+	Status   string                     `json:"status"`
+	Receiver string                     `json:"receiver"`
+	Alerts   []AlertmanagerWebhookAlert `json:"alerts"`
+}
+
+// AlertmanagerWebhookAlert webhook 请求体中的单条告警
+type AlertmanagerWebhookAlert struct {
+	Status       string            `json:"status"` // firing, resolved
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
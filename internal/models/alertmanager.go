@@ -28,6 +28,15 @@ type AlertStatus struct {
 	InhibitedBy []string `json:"inhibitedBy"`
 }
 
+// PostableAlert 推送到 Alertmanager v2 API 的告警，EndsAt 早于当前时间表示已恢复
+type PostableAlert struct {
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+	StartsAt     time.Time         `json:"startsAt,omitempty"`
+	EndsAt       time.Time         `json:"endsAt,omitempty"`
+	GeneratorURL string            `json:"generatorURL,omitempty"`
+}
+
 // AlertGroup 告警分组
 type AlertGroup struct {
 	Labels   map[string]string `json:"labels"`
@@ -72,8 +81,9 @@ type SilenceStatus struct {
 	State string `json:"state"` // active, pending, expired
 }
 
-// CreateSilenceRequest 创建静默规则请求
+// CreateSilenceRequest 创建静默规则请求；ID 不为空时更新已有静默（仅修改结束时间时 Alertmanager 原地更新）
 type CreateSilenceRequest struct {
+	ID        string    `json:"id,omitempty"`
 	Matchers  []Matcher `json:"matchers"`
 	StartsAt  time.Time `json:"startsAt"`
 	EndsAt    time.Time `json:"endsAt"`
@@ -0,0 +1,167 @@
+package models
+
+// Alertmanager 路由配置的存储位置
+const (
+	AlertManagerRoutingSourceSecret = "secret"             // alertmanager.yaml 所在的 Secret
+	AlertManagerRoutingSourceCRD    = "alertmanagerconfig" // Prometheus Operator 的 AlertmanagerConfig CRD
+
+	// AlertManagerSecretMask 读取配置时密钥类字段（密码、API Key 等）以该值返回，保存时保持原值不变
+	AlertManagerSecretMask = "<secret>"
+)
+
+// AlertManagerRoutingSource 路由配置所在的资源
+type AlertManagerRoutingSource struct {
+	Kind      string `json:"kind"` // secret 或 alertmanagerconfig
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Key       string `json:"key,omitempty"` // Secret 中的配置键，默认 alertmanager.yaml
+}
+
+// AlertManagerRoutingConfig 可编辑的路由配置，字段名与 alertmanager.yaml 保持一致
+type AlertManagerRoutingConfig struct {
+	Route        *AlertManagerRoute        `json:"route"`
+	Receivers    []AlertManagerReceiver    `json:"receivers"`
+	InhibitRules []AlertManagerInhibitRule `json:"inhibit_rules,omitempty"`
+}
+
+// AlertManagerRoute 路由树节点，未设置的字段继承父节点
+type AlertManagerRoute struct {
+	Receiver            string              `json:"receiver,omitempty"`
+	GroupBy             []string            `json:"group_by,omitempty"`
+	Matchers            []string            `json:"matchers,omitempty"` // 如 severity=~"critical|warning"
+	Continue            bool                `json:"continue,omitempty"`
+	GroupWait           string              `json:"group_wait,omitempty"`
+	GroupInterval       string              `json:"group_interval,omitempty"`
+	RepeatInterval      string              `json:"repeat_interval,omitempty"`
+	MuteTimeIntervals   []string            `json:"mute_time_intervals,omitempty"`
+	ActiveTimeIntervals []string            `json:"active_time_intervals,omitempty"`
+	Routes              []AlertManagerRoute `json:"routes,omitempty"`
+}
+
+// AlertManagerReceiver 接收器
+type AlertManagerReceiver struct {
+	Name             string                        `json:"name"`
+	WebhookConfigs   []AlertManagerWebhookConfig   `json:"webhook_configs,omitempty"`
+	EmailConfigs     []AlertManagerEmailConfig     `json:"email_configs,omitempty"`
+	SlackConfigs     []AlertManagerSlackConfig     `json:"slack_configs,omitempty"`
+	PagerdutyConfigs []AlertManagerPagerdutyConfig `json:"pagerduty_configs,omitempty"`
+	WechatConfigs    []AlertManagerWechatConfig    `json:"wechat_configs,omitempty"`   // 企业微信
+	DingTalkConfigs  []AlertManagerDingTalkConfig  `json:"dingtalk_configs,omitempty"` // 钉钉，经 prometheus-webhook-dingtalk 转发，保存为 webhook_configs
+	OtherTypes       []string                      `json:"other_types,omitempty"`      // 暂不支持编辑的通知类型（如 opsgenie_configs），保存时原样保留
+}
+
+// AlertManagerWebhookConfig Webhook 通知
+type AlertManagerWebhookConfig struct {
+	URL          string `json:"url"`
+	SendResolved *bool  `json:"send_resolved,omitempty"`
+	MaxAlerts    int    `json:"max_alerts,omitempty"`
+}
+
+// AlertManagerEmailConfig 邮件通知，smarthost / from 为空时使用 global 配置
+type AlertManagerEmailConfig struct {
+	To           string `json:"to"`
+	From         string `json:"from,omitempty"`
+	Smarthost    string `json:"smarthost,omitempty"`
+	AuthUsername string `json:"auth_username,omitempty"`
+	AuthPassword string `json:"auth_password,omitempty"`
+	RequireTLS   *bool  `json:"require_tls,omitempty"`
+	SendResolved *bool  `json:"send_resolved,omitempty"`
+}
+
+// AlertManagerSlackConfig Slack 通知，api_url 为空时使用 global.slack_api_url
+type AlertManagerSlackConfig struct {
+	APIURL       string `json:"api_url,omitempty"`
+	Channel      string `json:"channel"`
+	Title        string `json:"title,omitempty"`
+	Text         string `json:"text,omitempty"`
+	SendResolved *bool  `json:"send_resolved,omitempty"`
+}
+
+// AlertManagerPagerdutyConfig PagerDuty 通知，routing_key（Events API v2）与 service_key 二选一
+type AlertManagerPagerdutyConfig struct {
+	RoutingKey   string `json:"routing_key,omitempty"`
+	ServiceKey   string `json:"service_key,omitempty"`
+	URL          string `json:"url,omitempty"`
+	Severity     string `json:"severity,omitempty"`
+	SendResolved *bool  `json:"send_resolved,omitempty"`
+}
+
+// AlertManagerWechatConfig 企业微信通知，api_secret / corp_id 为空时使用 global 配置
+type AlertManagerWechatConfig struct {
+	APISecret    string `json:"api_secret,omitempty"`
+	CorpID       string `json:"corp_id,omitempty"`
+	AgentID      string `json:"agent_id,omitempty"`
+	ToUser       string `json:"to_user,omitempty"`
+	ToParty      string `json:"to_party,omitempty"`
+	Message      string `json:"message,omitempty"`
+	SendResolved *bool  `json:"send_resolved,omitempty"`
+}
+
+// AlertManagerDingTalkConfig 钉钉通知，URL 为 prometheus-webhook-dingtalk 的地址，如 http://dingtalk:8060/dingtalk/ops/send
+type AlertManagerDingTalkConfig struct {
+	URL          string `json:"url"`
+	SendResolved *bool  `json:"send_resolved,omitempty"`
+}
+
+// AlertManagerInhibitRule 抑制规则：存在匹配 source 的告警时，抑制 equal 标签值相同且匹配 target 的告警
+type AlertManagerInhibitRule struct {
+	SourceMatchers []string `json:"source_matchers,omitempty"`
+	TargetMatchers []string `json:"target_matchers,omitempty"`
+	Equal          []string `json:"equal,omitempty"`
+}
+
+// AlertManagerRoutingDocument 读取到的路由配置
+type AlertManagerRoutingDocument struct {
+	Source          AlertManagerRoutingSource `json:"source"`
+	ResourceVersion string                    `json:"resourceVersion"`
+	Config          AlertManagerRoutingConfig `json:"config"`
+	TimeIntervals   []string                  `json:"timeIntervals,omitempty"` // 可在路由中引用的时间区间
+}
+
+// AlertManagerRoutingSaveRequest 保存 / 校验路由配置请求
+type AlertManagerRoutingSaveRequest struct {
+	Source          AlertManagerRoutingSource `json:"source"`
+	ResourceVersion string                    `json:"resourceVersion,omitempty"` // 不为空时做冲突检测
+	Config          AlertManagerRoutingConfig `json:"config"`
+	Raw             string                    `json:"raw,omitempty"` // 仅校验时使用：完整的 alertmanager.yaml
+}
+
+// AlertManagerConfigValidationError 配置校验错误，Path 指向出错的字段，如 route.routes[0].matchers[1]
+type AlertManagerConfigValidationError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// AlertManagerConfigValidationResult 配置校验结果
+type AlertManagerConfigValidationResult struct {
+	Valid    bool                                `json:"valid"`
+	Errors   []AlertManagerConfigValidationError `json:"errors,omitempty"`
+	Warnings []string                            `json:"warnings,omitempty"`
+}
+
+// AlertManagerRoutingSimulateRequest 路由模拟请求；Config 为空时使用 Source 中已保存的配置
+type AlertManagerRoutingSimulateRequest struct {
+	Source *AlertManagerRoutingSource `json:"source,omitempty"`
+	Config *AlertManagerRoutingConfig `json:"config,omitempty"`
+	Labels map[string]string          `json:"labels" binding:"required"`
+}
+
+// AlertManagerRoutingSimulation 路由模拟结果
+type AlertManagerRoutingSimulation struct {
+	Receivers    []string                 `json:"receivers"`
+	Matches      []AlertManagerRouteMatch `json:"matches"`
+	InhibitRules []int                    `json:"inhibitRules"` // 该告警作为 target 命中的抑制规则序号（存在对应 source 告警时会被抑制）
+}
+
+// AlertManagerRouteMatch 命中的路由节点及其生效参数（已合并继承值）
+type AlertManagerRouteMatch struct {
+	Path                string            `json:"path"` // 节点位置，如 route.routes[1].routes[0]
+	Receiver            string            `json:"receiver"`
+	GroupBy             []string          `json:"groupBy"`
+	GroupLabels         map[string]string `json:"groupLabels"` // 该告警所在分组的标签值
+	GroupWait           string            `json:"groupWait"`
+	GroupInterval       string            `json:"groupInterval"`
+	RepeatInterval      string            `json:"repeatInterval"`
+	MuteTimeIntervals   []string          `json:"muteTimeIntervals,omitempty"`
+	ActiveTimeIntervals []string          `json:"activeTimeIntervals,omitempty"`
+}
@@ -0,0 +1,29 @@
+package models
+
+// 容量预测的资源指标，取值均为百分比
+const (
+	CapacityCPURequest    = "cpu_request"    // CPU Request / 可分配
+	CapacityMemoryRequest = "memory_request" // 内存 Request / 可分配
+	CapacityCPUUsage      = "cpu_usage"      // CPU 使用率
+	CapacityMemoryUsage   = "memory_usage"   // 内存使用率
+	CapacityPods          = "pods"           // Pod 数 / 可调度 Pod 上限
+)
+
+// 容量预测的拟合方法
+const (
+	ForecastLinear      = "linear"       // 线性回归
+	ForecastHoltWinters = "holt_winters" // 加法 Holt-Winters（按天的周期性）
+)
+
+// CapacityForecast 单个资源指标的容量预测结果
+type CapacityForecast struct {
+	NodePool     string      `json:"node_pool,omitempty"`     // 节点池标签值，为空表示集群整体
+	Resource     string      `json:"resource"`                // 资源指标，见 Capacity* 常量
+	Method       string      `json:"method"`                  // 拟合方法
+	Current      float64     `json:"current"`                 // 当前值（%）
+	Threshold    float64     `json:"threshold"`               // 告警阈值（%）
+	GrowthPerDay float64     `json:"growth_per_day"`          // 拟合趋势，每天增长的百分点
+	ExhaustionAt *int64      `json:"exhaustion_at,omitempty"` // 预计达到阈值的时间（Unix 秒），预测范围内不会达到时为空
+	DaysLeft     *float64    `json:"days_left,omitempty"`     // 距达到阈值的天数，已超过阈值时为 0
+	Projection   []DataPoint `json:"projection"`              // 按天的预测曲线（取当天预测的峰值）
+}
@@ -0,0 +1,73 @@
+package models
+
+import "time"
+
+// 证书来源
+const (
+	CertificateSourceAPIServer  = "apiserver"  // API Server 服务端证书
+	CertificateSourceKubeconfig = "kubeconfig" // 已保存 kubeconfig 中的客户端证书
+	CertificateSourceKubelet    = "kubelet"    // kubelet 服务端证书
+	CertificateSourceTLSSecret  = "tls_secret" // Ingress 引用的 kubernetes.io/tls Secret
+	CertificateSourceWebhook    = "webhook"    // 准入 Webhook 的 CA Bundle
+	CertificateSourceAPIService = "apiservice" // 聚合 APIService 的 CA Bundle
+)
+
+// 证书过期状态，按严重程度从低到高
+const (
+	CertificateStatusValid    = "valid"
+	CertificateStatusWarning  = "warning"  // 剩余天数不超过告警阈值
+	CertificateStatusCritical = "critical" // 剩余天数不超过严重阈值
+	CertificateStatusExpired  = "expired"
+)
+
+// ClusterCertificate 集群证书清单中的一张证书，由定时扫描或手动扫描更新
+type ClusterCertificate struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	ClusterID     uint      `json:"cluster_id" gorm:"index;not null"`
+	Source        string    `json:"source" gorm:"size:20;not null"`
+	Namespace     string    `json:"namespace" gorm:"size:253"`
+	Name          string    `json:"name" gorm:"size:253;not null"`       // Secret、Webhook 配置、APIService、节点或 kubeconfig 用户名
+	Fingerprint   string    `json:"fingerprint" gorm:"size:64;not null"` // SHA-256 指纹
+	Subject       string    `json:"subject" gorm:"size:512"`
+	Issuer        string    `json:"issuer" gorm:"size:512"`
+	DNSNames      string    `json:"dns_names" gorm:"type:text"` // 逗号分隔
+	SerialNumber  string    `json:"serial_number" gorm:"size:128"`
+	IsCA          bool      `json:"is_ca"`
+	NotBefore     time.Time `json:"not_before"`
+	NotAfter      time.Time `json:"not_after" gorm:"index"`
+	References    string    `json:"references" gorm:"type:text"` // 使用该证书的 Ingress、Webhook 等，逗号分隔
+	NotifiedLevel string    `json:"-" gorm:"size:20"`            // 已发送通知的最高状态，避免每次扫描重复通知
+	ScannedAt     time.Time `json:"scanned_at"`
+	CreatedAt     time.Time `json:"created_at"`
+
+	// 查询时计算
+	ClusterName string `json:"cluster_name,omitempty" gorm:"-"`
+	DaysLeft    int    `json:"days_left" gorm:"-"`
+	Status      string `json:"status" gorm:"-"`
+}
+
+// TableName 指定表名
+func (ClusterCertificate) TableName() string {
+	return "cluster_certificates"
+}
+
+// CertificateQuery 集群证书查询参数
+type CertificateQuery struct {
+	Source string `form:"source" binding:"omitempty,oneof=apiserver kubeconfig kubelet tls_secret webhook apiservice"`
+	Status string `form:"status" binding:"omitempty,oneof=valid warning critical expired"`
+}
+
+// ExpiringCertificateQuery 全部集群即将过期证书查询参数
+type ExpiringCertificateQuery struct {
+	Days int `form:"days" binding:"omitempty,min=1,max=3650"` // 默认 30 天，已过期的证书也会返回
+}
+
+// CertificateScanResult 一次证书扫描的结果
+type CertificateScanResult struct {
+	Certificates int       `json:"certificates"`
+	Expired      int       `json:"expired"`
+	Critical     int       `json:"critical"`
+	Warning      int       `json:"warning"`
+	Errors       []string  `json:"errors,omitempty"` // 无法读取的证书来源，不影响其他来源
+	ScannedAt    time.Time `json:"scanned_at"`
+}
@@ -30,6 +30,9 @@ type Cluster struct {
 	// Alertmanager 配置
 	AlertManagerConfig string `json:"alertmanager_config" gorm:"type:json"` // JSON 格式存储 Alertmanager 配置
 
+	// 日志后端配置
+	LogConfig string `json:"log_config" gorm:"type:json"` // JSON 格式存储日志后端配置
+
 	// 关联关系
 	Creator         User              `json:"creator" gorm:"foreignKey:CreatedBy"`
 	TerminalSession []TerminalSession `json:"terminal_sessions" gorm:"foreignKey:ClusterID"`
@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// 版本快照来源
+const (
+	ConfigVersionSourceKubePolaris = "kubepolaris" // 通过 KubePolaris 修改
+	ConfigVersionSourceBaseline    = "baseline"    // 首次修改前的原始内容
+	ConfigVersionSourceObserved    = "observed"    // Informer 观察到的外部修改
+	ConfigVersionSourceRestore     = "restore"     // 从历史版本恢复
+	ConfigVersionSourceExternal    = "external"    // 外部密钥源同步
+)
+
+// ConfigVersion ConfigMap/Secret 内容快照
+type ConfigVersion struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	ClusterID uint   `json:"cluster_id" gorm:"uniqueIndex:idx_config_version_unique;not null"`
+	Kind      string `json:"kind" gorm:"uniqueIndex:idx_config_version_unique;size:20;not null"` // ConfigMap, Secret
+	Namespace string `json:"namespace" gorm:"uniqueIndex:idx_config_version_unique;size:253;not null"`
+	Name      string `json:"name" gorm:"uniqueIndex:idx_config_version_unique;size:253;not null"`
+	Version   int    `json:"version" gorm:"uniqueIndex:idx_config_version_unique;not null"`
+
+	// Content 快照内容（JSON），Secret 为加密后的密文
+	Content   string `json:"-" gorm:"type:longtext"`
+	Encrypted bool   `json:"encrypted"`
+	Hash      string `json:"-" gorm:"size:64"`      // 内容摘要，用于去重
+	Keys      string `json:"keys" gorm:"type:text"` // 逗号分隔的键名，便于列表展示
+
+	ResourceVersion string    `json:"resource_version" gorm:"size:50"`
+	Source          string    `json:"source" gorm:"size:20"`
+	Username        string    `json:"username" gorm:"size:100"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// TableName 指定表名
+func (ConfigVersion) TableName() string {
+	return "config_versions"
+}
+
+// ConfigSnapshot 快照内容
+type ConfigSnapshot struct {
+	Type       string            `json:"type,omitempty"` // Secret 类型
+	Labels     map[string]string `json:"labels,omitempty"`
+	Data       map[string]string `json:"data"`
+	BinaryData map[string][]byte `json:"binaryData,omitempty"`
+}
+
+// ConfigKeyDiff 单个键的差异
+type ConfigKeyDiff struct {
+	Key    string `json:"key"`
+	Change string `json:"change"`         // added, removed, modified
+	Diff   string `json:"diff,omitempty"` // ConfigMap 的逐行差异，Secret 不返回内容
+}
+
+// ConfigVersionDiff 两个版本之间的差异
+type ConfigVersionDiff struct {
+	FromVersion int             `json:"from_version"`
+	ToVersion   int             `json:"to_version"`
+	Keys        []ConfigKeyDiff `json:"keys"`
+	Masked      bool            `json:"masked"` // Secret 差异只包含键名
+}
+
+// ConfigConsumer 引用 ConfigMap/Secret 的工作负载
+type ConfigConsumer struct {
+	Kind            string   `json:"kind"`
+	Namespace       string   `json:"namespace"`
+	Name            string   `json:"name"`
+	References      []string `json:"references"`       // 如 volume:config、env:DB_HOST、envFrom、imagePullSecret
+	RestartRequired bool     `json:"restart_required"` // 通过环境变量或 subPath 引用，修改后需重启才能生效
+}
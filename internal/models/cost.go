@@ -0,0 +1,101 @@
+package models
+
+import "time"
+
+// 成本数据来源
+const (
+	CostSourcePrometheus = "prometheus" // 由 Prometheus 历史数据按天汇总
+	CostSourceSnapshot   = "snapshot"   // 未配置 Prometheus 时由 informer 周期快照累加
+)
+
+// 成本分摊维度
+const (
+	CostGroupByNamespace = "namespace"
+	CostGroupByWorkload  = "workload"
+	CostGroupByTeam      = "team"
+)
+
+// CostPricing 集群资源单价
+type CostPricing struct {
+	ID                 uint      `json:"id" gorm:"primaryKey"`
+	ClusterID          uint      `json:"cluster_id" gorm:"uniqueIndex;not null"`
+	Currency           string    `json:"currency" gorm:"size:10"`
+	CPUCoreHour        float64   `json:"cpu_core_hour"`                                     // 每核小时单价
+	MemoryGiBHour      float64   `json:"memory_gib_hour" gorm:"column:memory_gib_hour"`     // 每 GiB 小时单价
+	GPUHour            float64   `json:"gpu_hour"`                                          // 每卡小时单价，0 表示不计 GPU
+	StorageGiBMonth    float64   `json:"storage_gib_month" gorm:"column:storage_gib_month"` // 未单独定价的存储类每 GiB 月单价
+	StorageClassPrices string    `json:"storage_class_prices" gorm:"type:text"`             // JSON 对象，存储类 -> 每 GiB 月单价
+	TeamLabel          string    `json:"team_label" gorm:"size:100"`                        // 命名空间上标识所属团队的标签
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// CostPricingRequest 更新集群单价的请求
+type CostPricingRequest struct {
+	Currency           string             `json:"currency"`
+	CPUCoreHour        float64            `json:"cpu_core_hour" binding:"min=0"`
+	MemoryGiBHour      float64            `json:"memory_gib_hour" binding:"min=0"`
+	GPUHour            float64            `json:"gpu_hour" binding:"min=0"`
+	StorageGiBMonth    float64            `json:"storage_gib_month" binding:"min=0"`
+	StorageClassPrices map[string]float64 `json:"storage_class_prices"`
+	TeamLabel          string             `json:"team_label"`
+}
+
+// CostDailyRollup 按天汇总的工作负载资源用量与成本，PVC 以 PersistentVolumeClaim 类型单独成行
+type CostDailyRollup struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	ClusterID       uint      `json:"cluster_id" gorm:"uniqueIndex:idx_cost_rollup_key;not null"`
+	Date            string    `json:"date" gorm:"size:10;uniqueIndex:idx_cost_rollup_key;index"` // 2006-01-02
+	Namespace       string    `json:"namespace" gorm:"size:253;uniqueIndex:idx_cost_rollup_key"`
+	WorkloadKind    string    `json:"workload_kind" gorm:"size:50;uniqueIndex:idx_cost_rollup_key"`
+	Workload        string    `json:"workload" gorm:"size:253;uniqueIndex:idx_cost_rollup_key"`
+	Team            string    `json:"team" gorm:"size:100;index"`
+	CPUCoreHours    float64   `json:"cpu_core_hours"`
+	MemoryGiBHours  float64   `json:"memory_gib_hours" gorm:"column:memory_gib_hours"`
+	GPUHours        float64   `json:"gpu_hours"`
+	StorageGiBHours float64   `json:"storage_gib_hours" gorm:"column:storage_gib_hours"`
+	CPUCost         float64   `json:"cpu_cost"`
+	MemoryCost      float64   `json:"memory_cost"`
+	GPUCost         float64   `json:"gpu_cost"`
+	StorageCost     float64   `json:"storage_cost"`
+	TotalCost       float64   `json:"total_cost"`
+	Source          string    `json:"source" gorm:"size:20"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// CostAllocationQuery 成本分摊查询条件，日期格式为 2006-01-02
+type CostAllocationQuery struct {
+	StartDate string `form:"start"`
+	EndDate   string `form:"end"`
+	GroupBy   string `form:"groupBy"`
+	Namespace string `form:"namespace"`
+	Team      string `form:"team"`
+}
+
+// CostAllocationItem 按维度汇总的成本
+type CostAllocationItem struct {
+	Namespace       string  `json:"namespace,omitempty"`
+	WorkloadKind    string  `json:"workload_kind,omitempty"`
+	Workload        string  `json:"workload,omitempty"`
+	Team            string  `json:"team"`
+	CPUCoreHours    float64 `json:"cpu_core_hours"`
+	MemoryGiBHours  float64 `json:"memory_gib_hours" gorm:"column:memory_gib_hours"`
+	GPUHours        float64 `json:"gpu_hours"`
+	StorageGiBHours float64 `json:"storage_gib_hours" gorm:"column:storage_gib_hours"`
+	CPUCost         float64 `json:"cpu_cost"`
+	MemoryCost      float64 `json:"memory_cost"`
+	GPUCost         float64 `json:"gpu_cost"`
+	StorageCost     float64 `json:"storage_cost"`
+	TotalCost       float64 `json:"total_cost"`
+}
+
+// CostAllocationReport 成本分摊结果
+type CostAllocationReport struct {
+	Currency  string               `json:"currency"`
+	StartDate string               `json:"start_date"`
+	EndDate   string               `json:"end_date"`
+	GroupBy   string               `json:"group_by"`
+	Items     []CostAllocationItem `json:"items"`
+	TotalCost float64              `json:"total_cost"`
+}
@@ -0,0 +1,122 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 自定义诊断规则类型
+const (
+	DiagnosisRuleTypeBuiltin = "builtin" // 内置规则
+	DiagnosisRuleTypeCEL     = "cel"     // 对集群对象逐个求值的 CEL 表达式
+	DiagnosisRuleTypePromQL  = "promql"  // PromQL 查询结果与阈值比较
+)
+
+// DiagnosisRuleParam 诊断规则可调整的阈值参数
+type DiagnosisRuleParam struct {
+	Key         string  `json:"key"`
+	Name        string  `json:"name"`
+	Description string  `json:"description,omitempty"`
+	Default     float64 `json:"default"`
+	Unit        string  `json:"unit,omitempty"`
+}
+
+// DiagnosisRuleInfo 诊断规则及其在集群中的生效配置
+type DiagnosisRuleInfo struct {
+	ID          string               `json:"id"`
+	Type        string               `json:"type"`
+	Name        string               `json:"name"`
+	Category    string               `json:"category"`
+	Description string               `json:"description"`
+	Enabled     bool                 `json:"enabled"`
+	Params      []DiagnosisRuleParam `json:"params,omitempty"`
+	Values      map[string]float64   `json:"values,omitempty"` // 生效的参数值（默认值与集群配置合并）
+	CustomRule  *CustomDiagnosisRule `json:"custom_rule,omitempty"`
+}
+
+// DiagnosisRuleSetting 集群对内置诊断规则的启停与阈值配置，没有记录时使用默认值
+type DiagnosisRuleSetting struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	ClusterID uint      `json:"cluster_id" gorm:"uniqueIndex:idx_diagnosis_rule_setting;not null"`
+	RuleID    string    `json:"rule_id" gorm:"uniqueIndex:idx_diagnosis_rule_setting;size:100;not null"`
+	Enabled   bool      `json:"enabled"`
+	Params    string    `json:"-" gorm:"type:text"` // JSON 对象，只保存与默认值不同的参数
+	UpdatedBy string    `json:"updated_by" gorm:"size:100"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName 指定诊断规则配置表名
+func (DiagnosisRuleSetting) TableName() string {
+	return "diagnosis_rule_settings"
+}
+
+// DiagnosisRuleSettingRequest 更新内置诊断规则配置请求
+type DiagnosisRuleSettingRequest struct {
+	Enabled *bool              `json:"enabled"`
+	Params  map[string]float64 `json:"params"` // 为空时恢复默认阈值
+}
+
+// CustomDiagnosisRule 用户自定义诊断规则：CEL 规则对 Resource 的每个对象求值，表达式为 true 时产生风险项；
+// PromQL 规则对查询结果的每条序列与阈值比较，满足条件时产生风险项
+type CustomDiagnosisRule struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	ClusterID   uint   `json:"cluster_id" gorm:"index;not null"`
+	Name        string `json:"name" gorm:"size:100;not null"`
+	Type        string `json:"type" gorm:"size:20;not null"` // cel 或 promql
+	Enabled     bool   `json:"enabled"`
+	Category    string `json:"category" gorm:"size:50;not null"`
+	Severity    string `json:"severity" gorm:"size:20;not null"`
+	Title       string `json:"title" gorm:"size:255;not null"`
+	Description string `json:"description" gorm:"size:1000"` // PromQL 规则支持 {{ $value }} 与 {{ $labels.xxx }}
+	Solution    string `json:"solution" gorm:"size:1000"`
+	Deduction   int    `json:"deduction"` // 每个风险项在所属分类中的扣分
+	MaxRisks    int    `json:"max_risks"` // 最多报告的风险项数量
+
+	Resource          string `json:"resource,omitempty" gorm:"size:50"`             // CEL 规则检查的资源类型，如 pods、deployments
+	Expression        string `json:"expression,omitempty" gorm:"type:text"`         // CEL 条件表达式，变量 object 为对象、now 为当前时间
+	MessageExpression string `json:"message_expression,omitempty" gorm:"type:text"` // CEL 描述表达式（返回字符串），为空时使用 Description
+
+	Query     string  `json:"query,omitempty" gorm:"type:text"` // PromQL 查询
+	Operator  string  `json:"operator,omitempty" gorm:"size:5"` // >、>=、<、<=、==、!=
+	Threshold float64 `json:"threshold"`                        // 阈值
+
+	CreatedBy     uint           `json:"created_by"`
+	CreatedByName string         `json:"created_by_name" gorm:"size:100"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// TableName 指定自定义诊断规则表名
+func (CustomDiagnosisRule) TableName() string {
+	return "custom_diagnosis_rules"
+}
+
+// CustomDiagnosisRuleRequest 创建/更新/试运行自定义诊断规则请求
+type CustomDiagnosisRuleRequest struct {
+	Name              string  `json:"name" binding:"required"`
+	Type              string  `json:"type" binding:"required,oneof=cel promql"`
+	Enabled           bool    `json:"enabled"`
+	Category          string  `json:"category"` // 为空时为 custom
+	Severity          string  `json:"severity" binding:"required,oneof=critical warning info"`
+	Title             string  `json:"title" binding:"required"`
+	Description       string  `json:"description"`
+	Solution          string  `json:"solution"`
+	Deduction         int     `json:"deduction" binding:"gte=0,lte=100"`
+	MaxRisks          int     `json:"max_risks" binding:"gte=0"`
+	Resource          string  `json:"resource"`
+	Expression        string  `json:"expression"`
+	MessageExpression string  `json:"message_expression"`
+	Query             string  `json:"query"`
+	Operator          string  `json:"operator"`
+	Threshold         float64 `json:"threshold"`
+}
+
+// CustomDiagnosisRuleTestResponse 自定义诊断规则试运行结果
+type CustomDiagnosisRuleTestResponse struct {
+	Risks     []RiskItem `json:"risks"`
+	Deduction int        `json:"deduction"`
+	Evaluated int        `json:"evaluated"` // 求值的对象数或序列数
+}
@@ -0,0 +1,141 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 外部密钥源类型
+const (
+	SecretSourceTypeVault = "vault" // HashiCorp Vault KV v2
+	SecretSourceTypeFile  = "file"  // 本地文件（测试/离线环境替代）
+)
+
+// 外部密钥同步状态
+const (
+	ExternalSecretSyncSuccess = "success"
+	ExternalSecretSyncFailed  = "failed"
+)
+
+// 同步到 Kubernetes Secret 上的注解
+const (
+	AnnotationExternalSecretSource = "kubepolaris.io/external-secret-source" // 密钥源名称
+	AnnotationExternalSecretPath   = "kubepolaris.io/external-secret-path"   // 外部路径
+	AnnotationExternalSecretHash   = "kubepolaris.io/external-secret-hash"   // 最近一次同步的数据摘要
+	AnnotationExternalSecretSynced = "kubepolaris.io/external-secret-synced" // 最近一次同步时间
+)
+
+// SecretSource 外部密钥源配置（按集群配置）
+type SecretSource struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	ClusterID   uint   `json:"cluster_id" gorm:"uniqueIndex:idx_secret_source_name;not null"`
+	Name        string `json:"name" gorm:"uniqueIndex:idx_secret_source_name;size:100;not null"`
+	Type        string `json:"type" gorm:"size:20;not null"` // vault, file
+	Description string `json:"description" gorm:"size:500"`
+
+	// Vault 配置
+	Address        string `json:"address" gorm:"size:255"`                   // 如 https://vault.example.com:8200
+	MountPath      string `json:"mount_path" gorm:"size:100;default:secret"` // KV v2 挂载路径
+	VaultNamespace string `json:"vault_namespace" gorm:"size:100"`           // Vault Enterprise 命名空间
+	Token          string `json:"-" gorm:"type:text"`                        // Vault Token
+	Insecure       bool   `json:"insecure"`                                  // 是否跳过 TLS 验证
+
+	// 文件源配置
+	BaseDir string `json:"base_dir" gorm:"size:500"` // 文件目录，相对服务端配置的 external_secret.file_root_dir 解析，为空表示根目录本身
+
+	CreatedBy uint           `json:"created_by"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// TableName 指定表名
+func (SecretSource) TableName() string {
+	return "secret_sources"
+}
+
+// SecretSourceRequest 创建/更新密钥源请求
+type SecretSourceRequest struct {
+	Name           string `json:"name" binding:"required"`
+	Type           string `json:"type" binding:"required"`
+	Description    string `json:"description"`
+	Address        string `json:"address"`
+	MountPath      string `json:"mount_path"`
+	VaultNamespace string `json:"vault_namespace"`
+	Token          string `json:"token"` // 更新时为空表示保留原值
+	Insecure       bool   `json:"insecure"`
+	BaseDir        string `json:"base_dir"`
+}
+
+// ExternalSecret 外部密钥与 Kubernetes Secret 的绑定关系
+type ExternalSecret struct {
+	ID         uint   `json:"id" gorm:"primaryKey"`
+	ClusterID  uint   `json:"cluster_id" gorm:"uniqueIndex:idx_external_secret_target;not null"`
+	Namespace  string `json:"namespace" gorm:"uniqueIndex:idx_external_secret_target;size:253;not null"`
+	SecretName string `json:"secret_name" gorm:"uniqueIndex:idx_external_secret_target;size:253;not null"`
+	SecretType string `json:"secret_type" gorm:"size:100"`
+	SourceID   uint   `json:"source_id" gorm:"index;not null"`
+	Path       string `json:"path" gorm:"size:500;not null"` // 外部路径，如 Vault 的 app/db
+
+	// KeyMapping Secret 键 → 外部键 的映射（JSON），为空时同步外部路径下的全部键
+	KeyMapping string `json:"key_mapping" gorm:"type:text"`
+
+	RefreshIntervalSeconds int  `json:"refresh_interval_seconds" gorm:"default:300"`
+	Enabled                bool `json:"enabled"`
+
+	// 同步状态
+	LastSyncAt      *time.Time `json:"last_sync_at"`
+	LastSyncStatus  string     `json:"last_sync_status" gorm:"size:20"`
+	LastSyncMessage string     `json:"last_sync_message" gorm:"size:1000"`
+	LastDriftKeys   string     `json:"last_drift_keys" gorm:"size:1000"` // 最近一次同步前检测到的漂移键（逗号分隔）
+	LastDriftAt     *time.Time `json:"last_drift_at"`
+
+	Source SecretSource `json:"source,omitempty" gorm:"foreignKey:SourceID"`
+
+	CreatedBy uint           `json:"created_by"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// TableName 指定表名
+func (ExternalSecret) TableName() string {
+	return "external_secrets"
+}
+
+// GetKeyMapping 解析键映射
+func (e *ExternalSecret) GetKeyMapping() map[string]string {
+	if e.KeyMapping == "" {
+		return nil
+	}
+	var mapping map[string]string
+	if err := json.Unmarshal([]byte(e.KeyMapping), &mapping); err != nil {
+		return nil
+	}
+	return mapping
+}
+
+// ExternalSecretRequest 创建/更新外部密钥绑定请求
+type ExternalSecretRequest struct {
+	Namespace              string            `json:"namespace" binding:"required"`
+	SecretName             string            `json:"secret_name" binding:"required"`
+	SecretType             string            `json:"secret_type"`
+	SourceID               uint              `json:"source_id" binding:"required"`
+	Path                   string            `json:"path" binding:"required"`
+	KeyMapping             map[string]string `json:"key_mapping"`
+	RefreshIntervalSeconds int               `json:"refresh_interval_seconds"`
+	Enabled                *bool             `json:"enabled"`
+}
+
+// ExternalSecretDrift 外部密钥与集群内 Secret 的差异（只包含键名，不包含值）
+type ExternalSecretDrift struct {
+	InSync      bool       `json:"in_sync"`
+	SecretFound bool       `json:"secret_found"`
+	Changed     []string   `json:"changed"`      // 值不一致的键
+	Missing     []string   `json:"missing"`      // 外部存在、集群内缺失的键
+	Extra       []string   `json:"extra"`        // 集群内多出的键
+	LastSyncAt  *time.Time `json:"last_sync_at"` // 最近一次同步时间
+	CheckedAt   time.Time  `json:"checked_at"`
+}
@@ -0,0 +1,85 @@
+package models
+
+import "time"
+
+// 健康诊断来源
+const (
+	HealthDiagnosisScheduled = "scheduled" // 定时诊断
+	HealthDiagnosisManual    = "manual"    // 用户在运维中心手动诊断
+)
+
+// HealthDiagnosisRecord 健康诊断历史记录，保存一次诊断的完整结果
+type HealthDiagnosisRecord struct {
+	ID            uint   `json:"id" gorm:"primaryKey"`
+	ClusterID     uint   `json:"cluster_id" gorm:"index:idx_health_record_cluster_time;not null"`
+	Source        string `json:"source" gorm:"size:20"` // scheduled 或 manual
+	HealthScore   int    `json:"health_score"`
+	Status        string `json:"status" gorm:"size:20"`
+	CriticalCount int    `json:"critical_count"`
+	WarningCount  int    `json:"warning_count"`
+	RiskCount     int    `json:"risk_count"`
+
+	CategoryScores string `json:"-" gorm:"type:text"`     // JSON 对象
+	Risks          string `json:"-" gorm:"type:longtext"` // JSON 数组
+	Suggestions    string `json:"-" gorm:"type:text"`     // JSON 数组
+
+	CategoryScoreMap map[string]int `json:"category_scores" gorm:"-"`
+	RiskItems        []RiskItem     `json:"risk_items,omitempty" gorm:"-"`  // 列表中不返回
+	SuggestionList   []string       `json:"suggestions,omitempty" gorm:"-"` // 列表中不返回
+
+	DiagnosedAt time.Time `json:"diagnosed_at" gorm:"index:idx_health_record_cluster_time"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TableName 指定健康诊断历史表名
+func (HealthDiagnosisRecord) TableName() string {
+	return "health_diagnosis_records"
+}
+
+// HealthDiagnosisHistoryQuery 健康诊断历史查询条件
+type HealthDiagnosisHistoryQuery struct {
+	Page      int       `form:"page"`
+	PageSize  int       `form:"pageSize"`
+	Source    string    `form:"source"`
+	StartTime time.Time `form:"startTime" time_format:"2006-01-02T15:04:05Z07:00"`
+	EndTime   time.Time `form:"endTime" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// HealthDiagnosisHistoryResponse 健康诊断历史列表响应
+type HealthDiagnosisHistoryResponse struct {
+	Items    []HealthDiagnosisRecord `json:"items"`
+	Total    int64                   `json:"total"`
+	Page     int                     `json:"page"`
+	PageSize int                     `json:"pageSize"`
+}
+
+// HealthScoreTrendPoint 健康评分趋势点
+type HealthScoreTrendPoint struct {
+	RecordID       uint           `json:"record_id"`
+	Timestamp      int64          `json:"timestamp"`
+	HealthScore    int            `json:"health_score"`
+	Status         string         `json:"status"`
+	CategoryScores map[string]int `json:"category_scores"`
+	CriticalCount  int            `json:"critical_count"`
+	WarningCount   int            `json:"warning_count"`
+}
+
+// HealthDiagnosisDiff 两次诊断的对比结果，风险项按 ID 匹配
+type HealthDiagnosisDiff struct {
+	From            *HealthDiagnosisRecord `json:"from"` // 不含风险项明细
+	To              *HealthDiagnosisRecord `json:"to"`
+	ScoreDelta      int                    `json:"score_delta"`     // To - From
+	CategoryDeltas  map[string]int         `json:"category_deltas"` // 两次都有的分类的评分变化
+	NewRisks        []RiskItem             `json:"new_risks"`
+	ResolvedRisks   []RiskItem             `json:"resolved_risks"`
+	PersistingRisks []RiskItem             `json:"persisting_risks"` // 取 To 中的内容
+	ChangedSeverity []RiskSeverityChange   `json:"changed_severity"` // 持续存在但严重程度变化的风险项
+}
+
+// RiskSeverityChange 风险项严重程度变化
+type RiskSeverityChange struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Previous string `json:"previous"`
+	Current  string `json:"current"`
+}
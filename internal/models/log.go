@@ -19,7 +19,9 @@ type LogEntry struct {
 	Container   string                 `json:"container"`
 	NodeName    string                 `json:"node_name"`
 	Message     string                 `json:"message"`
+	TraceID     string                 `json:"trace_id,omitempty"`
 	Labels      map[string]string      `json:"labels,omitempty"`
+	Fields      map[string]interface{} `json:"fields,omitempty"` // 结构化日志解析出的字段
 	Metadata    map[string]interface{} `json:"metadata,omitempty"`
 }
 
@@ -39,6 +41,8 @@ type LogQuery struct {
 	Limit      int       `form:"limit"`
 	Offset     int       `form:"offset"`
 	Direction  string    `form:"direction"` // forward, backward
+	// Filters 字段过滤表达式，如 fields.status>=500、level=error、trace_id=abc
+	Filters []string `form:"filters"`
 }
 
 // LogStats 日志统计模型
@@ -78,6 +82,7 @@ type LogStreamConfig struct {
 	SinceSeconds  int64             `json:"since_seconds"`
 	ShowTimestamp bool              `json:"show_timestamp"`
 	ShowSource    bool              `json:"show_source"`
+	Filters       []string          `json:"filters"` // 字段过滤表达式，同 LogQuery.Filters
 }
 
 // LogStreamTarget 日志流目标
@@ -93,6 +98,93 @@ type LogStreamOptions struct {
 	SinceSeconds  int64
 	Previous      bool
 	ShowTimestamp bool
+	Filters       []string
+}
+
+// 日志后端类型
+const (
+	LogBackendKubelet       = "kubelet"       // 直接读取 kubelet 容器日志（默认）
+	LogBackendLoki          = "loki"          // Grafana Loki（LogQL）
+	LogBackendElasticsearch = "elasticsearch" // Elasticsearch / OpenSearch
+)
+
+// LogBackendConfig 集群日志后端配置
+type LogBackendConfig struct {
+	Type     string          `json:"type"`     // kubelet, loki, elasticsearch
+	Endpoint string          `json:"endpoint"` // 后端地址
+	Auth     *MonitoringAuth `json:"auth,omitempty"`
+	// Labels Loki 流选择器的附加标签（如 {"cluster": "prod"}），Elasticsearch 中作为 term 过滤
+	Labels map[string]string `json:"labels,omitempty"`
+	// TenantID Loki 多租户 ID（X-Scope-OrgID）
+	TenantID string `json:"tenant_id,omitempty"`
+	// Index Elasticsearch 索引模式，如 logstash-*
+	Index string `json:"index,omitempty"`
+	// Fields 字段/标签映射，为空时使用各后端的常见默认值
+	Fields *LogFieldMapping `json:"fields,omitempty"`
+	// Parser Loki 字段过滤时使用的解析器（json、logfmt），默认 json
+	Parser string `json:"parser,omitempty"`
+}
+
+// LogFieldMapping 日志后端字段映射
+type LogFieldMapping struct {
+	Timestamp string `json:"timestamp,omitempty"`
+	Message   string `json:"message,omitempty"`
+	Level     string `json:"level,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Pod       string `json:"pod,omitempty"`
+	Container string `json:"container,omitempty"`
+	Node      string `json:"node,omitempty"`
+}
+
+// 日志解析格式
+const (
+	LogFormatAuto   = "auto"   // 自动识别 JSON / logfmt
+	LogFormatJSON   = "json"   // JSON
+	LogFormatLogfmt = "logfmt" // key=value
+	LogFormatRegex  = "regex"  // 正则命名分组
+	LogFormatPlain  = "plain"  // 不做结构化解析
+)
+
+// LogParseRule 日志解析规则，按命名空间/工作负载生效，越具体的规则优先
+type LogParseRule struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	ClusterID uint   `json:"cluster_id" gorm:"index;not null"`
+	Name      string `json:"name" gorm:"size:100;not null"`
+	Namespace string `json:"namespace" gorm:"size:253"` // 为空表示所有命名空间
+	Workload  string `json:"workload" gorm:"size:253"`  // 工作负载名称，按 Pod 名前缀匹配，为空表示命名空间内所有 Pod
+	Format    string `json:"format" gorm:"size:20"`     // auto, json, logfmt, regex, plain
+	Pattern   string `json:"pattern" gorm:"size:1000"`  // regex 格式的正则，使用命名分组提取字段
+
+	// 字段名，为空时使用常见字段名（level/severity、msg/message、trace_id/traceId）
+	LevelField   string `json:"level_field" gorm:"size:100"`
+	MessageField string `json:"message_field" gorm:"size:100"`
+	TraceIDField string `json:"trace_id_field" gorm:"size:100"`
+
+	// FoldStackTrace 将 Java/Go/Python 多行堆栈合并到上一条日志
+	FoldStackTrace bool `json:"fold_stack_trace"`
+
+	CreatedBy uint           `json:"created_by"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// TableName 指定日志解析规则表名
+func (LogParseRule) TableName() string {
+	return "log_parse_rules"
+}
+
+// LogParseRuleRequest 创建/更新日志解析规则请求
+type LogParseRuleRequest struct {
+	Name           string `json:"name" binding:"required"`
+	Namespace      string `json:"namespace"`
+	Workload       string `json:"workload"`
+	Format         string `json:"format"`
+	Pattern        string `json:"pattern"`
+	LevelField     string `json:"level_field"`
+	MessageField   string `json:"message_field"`
+	TraceIDField   string `json:"trace_id_field"`
+	FoldStackTrace bool   `json:"fold_stack_trace"`
 }
 
 // LogSourceConfig 外部日志源配置
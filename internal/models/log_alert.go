@@ -0,0 +1,85 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 日志告警匹配方式
+const (
+	LogAlertMatchKeyword = "keyword" // 关键词（忽略大小写）
+	LogAlertMatchRegex   = "regex"   // 正则
+)
+
+// 日志告警状态
+const (
+	LogAlertStateInactive = "inactive"
+	LogAlertStateFiring   = "firing"
+)
+
+// LogAlertSourceLabel 日志告警推送到 Alertmanager 时附带的 source 标签值，用于与 Prometheus 告警区分
+const LogAlertSourceLabel = "kubepolaris-log"
+
+// LogAlertRule 日志告警规则：时间窗口内匹配的日志行数超过阈值时触发告警
+type LogAlertRule struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	ClusterID   uint   `json:"cluster_id" gorm:"index;not null"`
+	Name        string `json:"name" gorm:"size:100;not null"` // 作为告警的 alertname
+	Description string `json:"description" gorm:"size:500"`
+	Enabled     bool   `json:"enabled"`
+
+	// 范围
+	Namespace     string `json:"namespace" gorm:"size:253;not null"`
+	LabelSelector string `json:"label_selector" gorm:"size:500"` // Pod 标签选择器，为空表示命名空间内所有 Pod
+	Container     string `json:"container" gorm:"size:253"`      // 为空表示所有容器
+
+	// 匹配条件
+	MatchType string `json:"match_type" gorm:"size:20"` // keyword, regex
+	Pattern   string `json:"pattern" gorm:"size:1000;not null"`
+	Filters   string `json:"filters" gorm:"type:text"` // JSON 数组，字段过滤表达式，同 LogQuery.Filters
+
+	// 触发条件：WindowSeconds 内匹配行数 > Threshold
+	WindowSeconds int    `json:"window_seconds"`
+	Threshold     int    `json:"threshold"`
+	Severity      string `json:"severity" gorm:"size:20"`
+	Labels        string `json:"labels" gorm:"type:text"` // JSON 对象，附加到告警的标签
+
+	// 运行状态
+	State          string     `json:"state" gorm:"size:20"`
+	FiringSince    *time.Time `json:"firing_since,omitempty"`
+	LastResolvedAt *time.Time `json:"last_resolved_at,omitempty"`
+	LastError      string     `json:"last_error,omitempty" gorm:"size:1000"`
+
+	// 评估器内存中的实时状态，不入库
+	CurrentCount    int        `json:"current_count" gorm:"-"`
+	WatchedTargets  int        `json:"watched_targets" gorm:"-"`
+	LastEvaluatedAt *time.Time `json:"last_evaluated_at,omitempty" gorm:"-"`
+
+	CreatedBy uint           `json:"created_by"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// TableName 指定日志告警规则表名
+func (LogAlertRule) TableName() string {
+	return "log_alert_rules"
+}
+
+// LogAlertRuleRequest 创建/更新日志告警规则请求
+type LogAlertRuleRequest struct {
+	Name          string            `json:"name" binding:"required"`
+	Description   string            `json:"description"`
+	Enabled       bool              `json:"enabled"`
+	Namespace     string            `json:"namespace" binding:"required"`
+	LabelSelector string            `json:"label_selector"`
+	Container     string            `json:"container"`
+	MatchType     string            `json:"match_type"`
+	Pattern       string            `json:"pattern" binding:"required"`
+	Filters       []string          `json:"filters"`
+	WindowSeconds int               `json:"window_seconds"`
+	Threshold     int               `json:"threshold"`
+	Severity      string            `json:"severity"`
+	Labels        map[string]string `json:"labels"`
+}
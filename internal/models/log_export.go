@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// 日志导出格式
+const (
+	LogExportFormatText   = "text"   // 纯文本，每行一条日志
+	LogExportFormatNDJSON = "ndjson" // 每行一个 JSON 对象
+	LogExportFormatCSV    = "csv"    // CSV
+)
+
+// 日志导出任务状态
+const (
+	LogExportStatusPending   = "pending"
+	LogExportStatusRunning   = "running"
+	LogExportStatusSucceeded = "succeeded"
+	LogExportStatusFailed    = "failed"
+	LogExportStatusCanceled  = "canceled"
+	LogExportStatusExpired   = "expired" // 导出文件已过保留期被清理
+)
+
+// LogExportJob 异步日志导出任务，导出进度持久化，服务重启后从断点继续
+type LogExportJob struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	ClusterID   uint   `json:"cluster_id" gorm:"index;not null"`
+	ClusterName string `json:"cluster_name" gorm:"size:100"`
+	Query       string `json:"query" gorm:"type:text"` // LogQuery JSON，创建时固定时间范围
+	Format      string `json:"format" gorm:"size:20"`  // text, ndjson, csv
+	Gzip        bool   `json:"gzip"`
+	Status      string `json:"status" gorm:"size:20;index"`
+
+	// 断点：已导出条数、最后一条日志的时间戳与已落盘的文件大小
+	Exported  int64      `json:"exported"`
+	Cursor    *time.Time `json:"cursor,omitempty"`
+	FileSize  int64      `json:"file_size"`
+	Truncated bool       `json:"truncated"` // 达到最大条数后截断
+
+	FileName string `json:"file_name" gorm:"size:255"`
+	FilePath string `json:"-" gorm:"size:500"`
+	Error    string `json:"error,omitempty" gorm:"type:text"`
+
+	CreatedBy  uint       `json:"created_by"`
+	Username   string     `json:"username" gorm:"size:100"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" gorm:"index"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// TableName 指定日志导出任务表名
+func (LogExportJob) TableName() string {
+	return "log_export_jobs"
+}
+
+// LogExportRequest 创建日志导出任务请求
+type LogExportRequest struct {
+	Query  LogQuery `json:"query"`
+	Format string   `json:"format"`
+	Gzip   bool     `json:"gzip"`
+}
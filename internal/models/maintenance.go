@@ -0,0 +1,127 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 维护窗口状态
+const (
+	MaintenanceStatusScheduled = "scheduled" // 等待窗口开始
+	MaintenanceStatusRunning   = "running"   // 执行中
+	MaintenanceStatusCompleted = "completed" // 全部节点已完成
+	MaintenanceStatusFailed    = "failed"    // 某个节点执行失败，已停止后续节点
+	MaintenanceStatusCancelled = "cancelled" // 已取消
+)
+
+// 维护节点任务阶段
+const (
+	MaintenancePhasePending     = "pending"     // 等待执行
+	MaintenancePhaseDraining    = "draining"    // 已封锁，正在驱逐 Pod
+	MaintenancePhaseWaiting     = "waiting"     // 驱逐完成，等待外部完成信号或超时
+	MaintenancePhaseUncordoning = "uncordoning" // 正在解除封锁
+	MaintenancePhaseCompleted   = "completed"   // 已完成
+	MaintenancePhaseFailed      = "failed"      // 执行失败（节点保持封锁）
+	MaintenancePhaseSkipped     = "skipped"     // 窗口结束或取消时未执行
+)
+
+// MaintenanceWindow 节点维护窗口（cordon → drain → 等待 → uncordon 的运行手册）
+type MaintenanceWindow struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	ClusterID   uint   `json:"cluster_id" gorm:"index;not null"`
+	Name        string `json:"name" gorm:"size:100;not null"`
+	Description string `json:"description" gorm:"size:500"`
+
+	// 目标节点
+	NodeSelector string `json:"node_selector" gorm:"size:500"` // 标签选择器
+	NodeNames    string `json:"node_names" gorm:"type:text"`   // JSON 数组，额外指定的节点
+
+	// 执行策略
+	StartAt             time.Time `json:"start_at" gorm:"index"`
+	EndAt               time.Time `json:"end_at"`
+	MaxUnavailable      int       `json:"max_unavailable" gorm:"default:1"`         // 同时处于维护中的最大节点数
+	DrainTimeoutSeconds int       `json:"drain_timeout_seconds" gorm:"default:600"` // 单节点驱逐超时
+	WaitTimeoutSeconds  int       `json:"wait_timeout_seconds" gorm:"default:1800"` // 等待完成信号的超时，超时后自动解除封锁
+	GracePeriodSeconds  int       `json:"grace_period_seconds"`                     // 驱逐宽限期，-1 使用 Pod 自身配置
+	DeleteLocalData     bool      `json:"delete_local_data"`                        // 是否允许驱逐使用 emptyDir 的 Pod
+	Force               bool      `json:"force"`                                    // 是否允许驱逐没有控制器管理的 Pod（驱逐后不会重建）
+	CreateSilence       bool      `json:"create_silence"`                           // 是否自动创建 Alertmanager 静默
+
+	// 运行状态
+	Status     string     `json:"status" gorm:"size:20;index;default:scheduled"`
+	Message    string     `json:"message" gorm:"size:1000"`
+	SilenceIDs string     `json:"silence_ids" gorm:"size:500"` // 逗号分隔的静默 ID
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+
+	CreatedBy uint           `json:"created_by"`
+	Creator   string         `json:"creator" gorm:"size:100"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	Tasks []MaintenanceNodeTask `json:"tasks,omitempty" gorm:"foreignKey:WindowID"`
+}
+
+// TableName 指定表名
+func (MaintenanceWindow) TableName() string {
+	return "maintenance_windows"
+}
+
+// IsFinished 窗口是否已结束
+func (w *MaintenanceWindow) IsFinished() bool {
+	switch w.Status {
+	case MaintenanceStatusCompleted, MaintenanceStatusFailed, MaintenanceStatusCancelled:
+		return true
+	}
+	return false
+}
+
+// MaintenanceNodeTask 维护窗口内单个节点的执行状态
+type MaintenanceNodeTask struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	WindowID     uint       `json:"window_id" gorm:"index;not null"`
+	NodeName     string     `json:"node_name" gorm:"size:253;not null"`
+	Sequence     int        `json:"sequence"`
+	Phase        string     `json:"phase" gorm:"size:20;default:pending"`
+	WasCordoned  bool       `json:"was_cordoned"` // 维护前节点已处于封锁状态，结束后不解除封锁
+	DoneSignaled bool       `json:"done_signaled"`
+	Message      string     `json:"message" gorm:"size:1000"`
+	StartedAt    *time.Time `json:"started_at"`
+	DrainedAt    *time.Time `json:"drained_at"`
+	FinishedAt   *time.Time `json:"finished_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (MaintenanceNodeTask) TableName() string {
+	return "maintenance_node_tasks"
+}
+
+// IsActive 节点是否处于维护中（占用 MaxUnavailable 配额）
+func (t *MaintenanceNodeTask) IsActive() bool {
+	switch t.Phase {
+	case MaintenancePhaseDraining, MaintenancePhaseWaiting, MaintenancePhaseUncordoning:
+		return true
+	}
+	return false
+}
+
+// CreateMaintenanceWindowRequest 创建维护窗口请求
+type CreateMaintenanceWindowRequest struct {
+	Name                string    `json:"name" binding:"required"`
+	Description         string    `json:"description"`
+	NodeSelector        string    `json:"nodeSelector"`
+	NodeNames           []string  `json:"nodeNames"`
+	StartAt             time.Time `json:"startAt" binding:"required"`
+	EndAt               time.Time `json:"endAt" binding:"required"`
+	MaxUnavailable      int       `json:"maxUnavailable"`
+	DrainTimeoutSeconds int       `json:"drainTimeoutSeconds"`
+	WaitTimeoutSeconds  int       `json:"waitTimeoutSeconds"`
+	GracePeriodSeconds  *int      `json:"gracePeriodSeconds"`
+	DeleteLocalData     bool      `json:"deleteLocalData"`
+	Force               bool      `json:"force"`
+	CreateSilence       *bool     `json:"createSilence"`
+}
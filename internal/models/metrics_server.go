@@ -0,0 +1,14 @@
+package models
+
+// ResourceUsage 某一时刻的 CPU / 内存实际用量（来自 metrics-server）
+type ResourceUsage struct {
+	Timestamp int64   `json:"timestamp"` // 采样时间（Unix 秒）
+	CPU       float64 `json:"cpu"`       // CPU 用量（cores）
+	Memory    float64 `json:"memory"`    // 内存工作集（bytes）
+}
+
+// NodeUsageRate 节点实时资源使用率（相对可分配资源）
+type NodeUsageRate struct {
+	CPUUsageRate    float64 `json:"cpu_usage_rate"`    // CPU 使用率 (%)
+	MemoryUsageRate float64 `json:"memory_usage_rate"` // 内存使用率 (%)
+}
@@ -0,0 +1,91 @@
+package models
+
+// NodeTaint 节点污点
+type NodeTaint struct {
+	Key    string `json:"key"`
+	Value  string `json:"value,omitempty"`
+	Effect string `json:"effect"` // NoSchedule, PreferNoSchedule, NoExecute
+}
+
+// NodeMetadataPatch 节点标签/注解变更
+type NodeMetadataPatch struct {
+	Set    map[string]string `json:"set,omitempty"`    // 新增或覆盖的键值
+	Remove []string          `json:"remove,omitempty"` // 需要删除的键
+}
+
+// NodeTaintPatch 节点污点变更
+type NodeTaintPatch struct {
+	Add    []NodeTaint `json:"add,omitempty"`    // 新增或覆盖的污点（按 key+effect 覆盖）
+	Remove []NodeTaint `json:"remove,omitempty"` // 需要删除的污点（effect 为空时删除该 key 的所有污点）
+}
+
+// NodeChangeSpec 节点元数据变更描述
+type NodeChangeSpec struct {
+	Labels      *NodeMetadataPatch `json:"labels,omitempty"`
+	Annotations *NodeMetadataPatch `json:"annotations,omitempty"`
+	Taints      *NodeTaintPatch    `json:"taints,omitempty"`
+}
+
+// IsEmpty 是否为空变更
+func (s *NodeChangeSpec) IsEmpty() bool {
+	if s == nil {
+		return true
+	}
+	empty := func(p *NodeMetadataPatch) bool {
+		return p == nil || (len(p.Set) == 0 && len(p.Remove) == 0)
+	}
+	return empty(s.Labels) && empty(s.Annotations) && (s.Taints == nil || (len(s.Taints.Add) == 0 && len(s.Taints.Remove) == 0))
+}
+
+// NodeChangeRequest 单节点变更请求
+type NodeChangeRequest struct {
+	NodeChangeSpec
+	DryRun bool `json:"dryRun"` // 仅预览影响，不实际修改
+	Force  bool `json:"force"`  // 存在 Pod 驱逐或不可调度影响时仍然执行
+}
+
+// NodeBulkChangeRequest 批量节点变更请求（按标签选择器匹配节点）
+type NodeBulkChangeRequest struct {
+	LabelSelector string   `json:"labelSelector"`       // 节点标签选择器，如 node-role.kubernetes.io/worker=,zone=a
+	NodeNames     []string `json:"nodeNames,omitempty"` // 额外指定的节点名称（与选择器取并集）
+	NodeChangeSpec
+	DryRun bool `json:"dryRun"`
+	Force  bool `json:"force"`
+}
+
+// PodImpact 受变更影响的 Pod
+type PodImpact struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	NodeName  string `json:"nodeName,omitempty"`
+	OwnerKind string `json:"ownerKind,omitempty"`
+	OwnerName string `json:"ownerName,omitempty"`
+	Reason    string `json:"reason"`
+}
+
+// NodeChangePreview 节点变更影响预览
+type NodeChangePreview struct {
+	Nodes             []string    `json:"nodes"`             // 受影响节点
+	EvictedPods       []PodImpact `json:"evictedPods"`       // 因 NoExecute 污点将被驱逐的 Pod
+	UnschedulablePods []PodImpact `json:"unschedulablePods"` // 变更后在集群内无可调度节点的 Pod
+	Warnings          []string    `json:"warnings,omitempty"`
+}
+
+// HasImpact 是否存在 Pod 级别影响
+func (p *NodeChangePreview) HasImpact() bool {
+	return p != nil && (len(p.EvictedPods) > 0 || len(p.UnschedulablePods) > 0)
+}
+
+// NodeChangeResult 单个节点的变更结果
+type NodeChangeResult struct {
+	Node    string `json:"node"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// NodeChangeResponse 节点变更响应
+type NodeChangeResponse struct {
+	Applied bool               `json:"applied"`
+	Preview *NodeChangePreview `json:"preview"`
+	Results []NodeChangeResult `json:"results,omitempty"`
+}
@@ -0,0 +1,211 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 通知渠道类型
+const (
+	NotificationChannelWebhook  = "webhook"  // 通用 Webhook，支持自定义请求体模板
+	NotificationChannelEmail    = "email"    // SMTP 邮件
+	NotificationChannelSlack    = "slack"    // Slack Incoming Webhook
+	NotificationChannelDingTalk = "dingtalk" // 钉钉群机器人
+	NotificationChannelFeishu   = "feishu"   // 飞书群机器人
+	NotificationChannelWeCom    = "wecom"    // 企业微信群机器人
+)
+
+// 通知事件类型
+const (
+	NotificationEventClusterUnhealthy = "cluster_unhealthy" // 集群状态变为 unhealthy / warning
+	NotificationEventClusterRecovered = "cluster_recovered" // 集群恢复 healthy
+	NotificationEventNodeDrained      = "node_drained"      // 节点驱逐完成（含失败）
+	NotificationEventApplyFailed      = "apply_yaml_failed" // YAML 应用失败
+	NotificationEventHealthScoreDrop  = "health_score_drop" // 健康诊断评分下降
+	NotificationEventHealthNewRisk    = "health_new_risk"   // 健康诊断出现新的严重风险
+	NotificationEventCertExpiring     = "cert_expiring"     // 证书即将过期或已过期
+	NotificationEventTest             = "notification_test" // 渠道测试消息，不参与订阅匹配
+)
+
+// NotificationEventTypes 可订阅的事件类型及中文名称
+var NotificationEventTypes = map[string]string{
+	NotificationEventClusterUnhealthy: "集群异常",
+	NotificationEventClusterRecovered: "集群恢复",
+	NotificationEventNodeDrained:      "节点驱逐完成",
+	NotificationEventApplyFailed:      "YAML 应用失败",
+	NotificationEventHealthScoreDrop:  "健康评分下降",
+	NotificationEventHealthNewRisk:    "新的严重风险",
+	NotificationEventCertExpiring:     "证书即将过期",
+}
+
+// 通知事件级别
+const (
+	NotificationSeverityInfo     = "info"
+	NotificationSeverityWarning  = "warning"
+	NotificationSeverityCritical = "critical"
+)
+
+// 通知投递状态
+const (
+	NotificationDeliveryPending = "pending" // 等待发送或等待重试
+	NotificationDeliverySuccess = "success"
+	NotificationDeliveryFailed  = "failed" // 重试次数用尽
+)
+
+// NotificationSecretMask 读取渠道配置时密码、签名密钥等字段以该值返回，保存时保持原值不变
+const NotificationSecretMask = "<secret>"
+
+// NotificationChannel 通知渠道
+type NotificationChannel struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	Name        string `json:"name" gorm:"size:100;not null"`
+	Type        string `json:"type" gorm:"size:20;not null"`
+	Description string `json:"description" gorm:"size:500"`
+	Enabled     bool   `json:"enabled"`
+	Config      string `json:"-" gorm:"type:text"` // JSON，NotificationChannelConfig
+
+	ChannelConfig NotificationChannelConfig `json:"config" gorm:"-"` // 返回给前端的配置（密钥已脱敏）
+
+	CreatedBy uint           `json:"created_by"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// TableName 指定通知渠道表名
+func (NotificationChannel) TableName() string {
+	return "notification_channels"
+}
+
+// NotificationChannelConfig 渠道配置，按渠道类型使用不同字段
+type NotificationChannelConfig struct {
+	// webhook / slack / dingtalk / feishu / wecom
+	URL    string `json:"url,omitempty"`
+	Secret string `json:"secret,omitempty"` // 钉钉、飞书的加签密钥
+
+	// webhook
+	Method          string            `json:"method,omitempty"` // 默认 POST
+	Headers         map[string]string `json:"headers,omitempty"`
+	PayloadTemplate string            `json:"payload_template,omitempty"` // Go text/template，为空时发送事件 JSON
+
+	// dingtalk / wecom：@ 指定手机号
+	AtMobiles []string `json:"at_mobiles,omitempty"`
+
+	// email
+	SMTPHost    string   `json:"smtp_host,omitempty"`
+	SMTPPort    int      `json:"smtp_port,omitempty"`
+	Username    string   `json:"username,omitempty"`
+	Password    string   `json:"password,omitempty"`
+	From        string   `json:"from,omitempty"`
+	To          []string `json:"to,omitempty"`           // 固定收件人，另会加上订阅用户 / 用户组成员的邮箱
+	ImplicitTLS bool     `json:"implicit_tls,omitempty"` // 465 端口等直接 TLS 连接，否则在服务端支持时使用 STARTTLS
+}
+
+// NotificationChannelRequest 创建/更新通知渠道请求
+type NotificationChannelRequest struct {
+	Name        string                    `json:"name" binding:"required"`
+	Type        string                    `json:"type" binding:"required"`
+	Description string                    `json:"description"`
+	Enabled     bool                      `json:"enabled"`
+	Config      NotificationChannelConfig `json:"config"`
+}
+
+// NotificationSubscription 通知订阅：用户或用户组订阅指定事件，经指定渠道接收
+type NotificationSubscription struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	UserID      *uint  `json:"user_id,omitempty" gorm:"index"`       // 与 UserGroupID 二选一
+	UserGroupID *uint  `json:"user_group_id,omitempty" gorm:"index"` // 与 UserID 二选一
+	ChannelID   uint   `json:"channel_id" gorm:"index;not null"`
+	EventTypes  string `json:"-" gorm:"type:text"` // JSON 数组
+	ClusterIDs  string `json:"-" gorm:"type:text"` // JSON 数组，为空表示所有集群
+	Enabled     bool   `json:"enabled"`
+
+	EventTypeList []string `json:"event_types" gorm:"-"`
+	ClusterIDList []uint   `json:"cluster_ids" gorm:"-"`
+	ChannelName   string   `json:"channel_name,omitempty" gorm:"-"`
+	SubjectName   string   `json:"subject_name,omitempty" gorm:"-"` // 用户名或用户组名
+
+	CreatedBy uint           `json:"created_by"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// TableName 指定通知订阅表名
+func (NotificationSubscription) TableName() string {
+	return "notification_subscriptions"
+}
+
+// NotificationSubscriptionRequest 创建/更新通知订阅请求
+type NotificationSubscriptionRequest struct {
+	UserID      *uint    `json:"user_id"`
+	UserGroupID *uint    `json:"user_group_id"`
+	ChannelID   uint     `json:"channel_id" binding:"required"`
+	EventTypes  []string `json:"event_types" binding:"required"`
+	ClusterIDs  []uint   `json:"cluster_ids"`
+	Enabled     bool     `json:"enabled"`
+}
+
+// NotificationEvent 通知事件
+type NotificationEvent struct {
+	ID          uint              `json:"id" gorm:"primaryKey"`
+	Type        string            `json:"type" gorm:"size:50;index;not null"`
+	Severity    string            `json:"severity" gorm:"size:20"`
+	ClusterID   uint              `json:"cluster_id" gorm:"index"`
+	ClusterName string            `json:"cluster_name" gorm:"size:100"`
+	Title       string            `json:"title" gorm:"size:255"`
+	Content     string            `json:"content" gorm:"type:text"`
+	Labels      string            `json:"-" gorm:"type:text"` // JSON 对象
+	LabelMap    map[string]string `json:"labels" gorm:"-"`    // 附加信息，如 node、namespace、operator
+	CreatedAt   time.Time         `json:"created_at" gorm:"index"`
+}
+
+// TableName 指定通知事件表名
+func (NotificationEvent) TableName() string {
+	return "notification_events"
+}
+
+// NotificationDelivery 通知投递记录：一个事件经一个渠道发送一次，失败时按退避策略重试
+type NotificationDelivery struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	EventID     uint       `json:"event_id" gorm:"index;not null"`
+	ChannelID   uint       `json:"channel_id" gorm:"index;not null"`
+	Recipients  string     `json:"recipients" gorm:"type:text"` // 邮件收件人，逗号分隔
+	Status      string     `json:"status" gorm:"size:20;index"`
+	Attempts    int        `json:"attempts"`
+	NextRetryAt *time.Time `json:"next_retry_at,omitempty" gorm:"index"`
+	LastError   string     `json:"last_error,omitempty" gorm:"size:1000"`
+	StatusCode  int        `json:"status_code,omitempty"` // 最近一次 HTTP 响应码
+	SentAt      *time.Time `json:"sent_at,omitempty"`
+
+	Event       *NotificationEvent `json:"event,omitempty" gorm:"-"`
+	ChannelName string             `json:"channel_name,omitempty" gorm:"-"`
+	ChannelType string             `json:"channel_type,omitempty" gorm:"-"`
+
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName 指定通知投递记录表名
+func (NotificationDelivery) TableName() string {
+	return "notification_deliveries"
+}
+
+// NotificationDeliveryQuery 投递记录查询条件
+type NotificationDeliveryQuery struct {
+	Page      int    `form:"page"`
+	PageSize  int    `form:"pageSize"`
+	ChannelID uint   `form:"channelId"`
+	EventType string `form:"eventType"`
+	Status    string `form:"status"`
+	ClusterID uint   `form:"clusterId"`
+}
+
+// NotificationDeliveryListResponse 投递记录列表响应
+type NotificationDeliveryListResponse struct {
+	Items    []NotificationDelivery `json:"items"`
+	Total    int64                  `json:"total"`
+	Page     int                    `json:"page"`
+	PageSize int                    `json:"pageSize"`
+}
@@ -110,8 +110,24 @@ func (cp *ClusterPermission) HasAllNamespaceAccess() bool {
 	return false
 }
 
+// ActionSecretReveal 查看 Secret 明文
+const ActionSecretReveal = "secret:reveal"
+
+// explicitActionGrants 需要显式授予的操作及被授予的权限类型，不随资源前缀或自定义权限默认放行
+var explicitActionGrants = map[string][]string{
+	ActionSecretReveal: {PermissionTypeAdmin, PermissionTypeOps},
+}
+
 // CanPerformAction 检查是否可以执行指定操作
 func (cp *ClusterPermission) CanPerformAction(action string) bool {
+	if granted, ok := explicitActionGrants[action]; ok {
+		for _, t := range granted {
+			if cp.PermissionType == t {
+				return true
+			}
+		}
+		return false
+	}
 	switch cp.PermissionType {
 	case PermissionTypeAdmin:
 		return true // 管理员可以执行所有操作
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCanPerformAction_SecretReveal 测试查看 Secret 明文需显式授权
+func TestCanPerformAction_SecretReveal(t *testing.T) {
+	cases := map[string]bool{
+		PermissionTypeAdmin:    true,
+		PermissionTypeOps:      true,
+		PermissionTypeDev:      false,
+		PermissionTypeReadonly: false,
+		PermissionTypeCustom:   false,
+	}
+	for permissionType, expected := range cases {
+		cp := &ClusterPermission{PermissionType: permissionType}
+		assert.Equal(t, expected, cp.CanPerformAction(ActionSecretReveal), permissionType)
+	}
+
+	// secret: 前缀的其他操作不受影响
+	dev := &ClusterPermission{PermissionType: PermissionTypeDev}
+	assert.True(t, dev.CanPerformAction("secret:update"))
+	assert.True(t, (&ClusterPermission{PermissionType: PermissionTypeCustom}).CanPerformAction("secret:update"))
+}
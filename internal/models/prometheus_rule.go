@@ -0,0 +1,120 @@
+package models
+
+import "time"
+
+// PrometheusRule monitoring.coreos.com/v1 PrometheusRule 资源（Prometheus Operator）
+type PrometheusRule struct {
+	Name            string                `json:"name"`
+	Namespace       string                `json:"namespace"`
+	Labels          map[string]string     `json:"labels,omitempty"`
+	Annotations     map[string]string     `json:"annotations,omitempty"`
+	ResourceVersion string                `json:"resourceVersion,omitempty"`
+	CreatedAt       time.Time             `json:"createdAt,omitempty"`
+	Groups          []PrometheusRuleGroup `json:"groups"`
+}
+
+// PrometheusRuleGroup 规则组，字段与 CRD 的 spec.groups 一致
+type PrometheusRuleGroup struct {
+	Name     string                `json:"name"`
+	Interval string                `json:"interval,omitempty"`
+	Rules    []PrometheusAlertRule `json:"rules"`
+}
+
+// PrometheusAlertRule 告警规则或记录规则（alert 与 record 二选一）
+type PrometheusAlertRule struct {
+	Alert       string            `json:"alert,omitempty"`
+	Record      string            `json:"record,omitempty"`
+	Expr        string            `json:"expr"`
+	For         string            `json:"for,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// PrometheusRuleValidationError 规则校验错误，Path 指向出错的字段，如 groups[0].rules[1].expr
+type PrometheusRuleValidationError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// PrometheusRuleValidationResult 规则校验结果
+type PrometheusRuleValidationResult struct {
+	Valid    bool                            `json:"valid"`
+	Errors   []PrometheusRuleValidationError `json:"errors,omitempty"`
+	Warnings []string                        `json:"warnings,omitempty"`
+}
+
+// PrometheusRuleTestRequest 规则试运行请求：在过去 Hours 小时内回放表达式
+type PrometheusRuleTestRequest struct {
+	Expr  string `json:"expr" binding:"required"`
+	For   string `json:"for"`
+	Hours int    `json:"hours"`
+	Step  string `json:"step"` // 为空时按时间范围自动计算
+}
+
+// PrometheusRuleTestResult 规则试运行结果
+type PrometheusRuleTestResult struct {
+	Start       time.Time                  `json:"start"`
+	End         time.Time                  `json:"end"`
+	Step        string                     `json:"step"`
+	FiringCount int                        `json:"firingCount"` // 会触发的告警次数
+	Series      []PrometheusRuleTestSeries `json:"series"`
+	Truncated   bool                       `json:"truncated"` // 序列过多被截断
+}
+
+// PrometheusRuleTestSeries 单个序列的告警时间线
+type PrometheusRuleTestSeries struct {
+	Labels map[string]string         `json:"labels"`
+	Alerts []PrometheusRuleTestAlert `json:"alerts"`
+}
+
+// PrometheusRuleTestAlert 一次告警：ActiveAt 表达式开始命中，FiredAt 满足 for 后触发（为空表示只停留在 pending），
+// ResolvedAt 恢复时间（为空表示到查询结束仍未恢复）
+type PrometheusRuleTestAlert struct {
+	ActiveAt   time.Time  `json:"activeAt"`
+	FiredAt    *time.Time `json:"firedAt,omitempty"`
+	ResolvedAt *time.Time `json:"resolvedAt,omitempty"`
+	Value      string     `json:"value"` // 最后一个采样值
+}
+
+// PrometheusRuleExprBuilder 表单方式构建 PromQL：
+// <aggregation> by (<by>) (<function>(<metric>{<matchers>}[<range>])) <operator> <threshold>
+type PrometheusRuleExprBuilder struct {
+	Metric      string            `json:"metric" binding:"required"`
+	Matchers    map[string]string `json:"matchers,omitempty"` // 值以 ~ 开头时使用正则匹配
+	Function    string            `json:"function,omitempty"` // rate, irate, increase, avg_over_time, max_over_time, min_over_time, 为空时不使用
+	Range       string            `json:"range,omitempty"`    // 区间向量范围，如 5m
+	Aggregation string            `json:"aggregation,omitempty"`
+	By          []string          `json:"by,omitempty"`
+	Operator    string            `json:"operator" binding:"required"` // >, >=, <, <=, ==, !=
+	Threshold   float64           `json:"threshold"`
+}
+
+// PrometheusRuleBuildRequest 根据模板或表单构建告警规则
+type PrometheusRuleBuildRequest struct {
+	TemplateID  string                     `json:"templateId,omitempty"`
+	Params      map[string]string          `json:"params,omitempty"`
+	Builder     *PrometheusRuleExprBuilder `json:"builder,omitempty"`
+	Alert       string                     `json:"alert,omitempty"`
+	For         string                     `json:"for,omitempty"`
+	Severity    string                     `json:"severity,omitempty"`
+	Summary     string                     `json:"summary,omitempty"`
+	Description string                     `json:"description,omitempty"`
+}
+
+// PrometheusRuleTemplate 内置告警规则模板，Rule 中的 ${参数名} 由 Params 替换
+type PrometheusRuleTemplate struct {
+	ID          string                        `json:"id"`
+	Name        string                        `json:"name"`
+	Category    string                        `json:"category"`
+	Description string                        `json:"description"`
+	Params      []PrometheusRuleTemplateParam `json:"params,omitempty"`
+	Rule        PrometheusAlertRule           `json:"rule"`
+}
+
+// PrometheusRuleTemplateParam 模板参数
+type PrometheusRuleTemplateParam struct {
+	Name    string `json:"name"`
+	Label   string `json:"label"`
+	Default string `json:"default"`
+	Unit    string `json:"unit,omitempty"`
+}
@@ -0,0 +1,72 @@
+package models
+
+// 资源规格建议分类
+const (
+	RightsizingOverProvisioned  = "over_provisioned"  // 资源申请明显高于实际用量
+	RightsizingUnderProvisioned = "under_provisioned" // 用量超过申请或 CPU 限流严重
+	RightsizingOOMProne         = "oom_prone"         // 发生过 OOM 或内存用量接近 limit
+	RightsizingOptimal          = "optimal"           // 规格合理
+	RightsizingNoData           = "no_data"           // 统计窗口内没有用量数据
+)
+
+// ContainerUsageProfile 单个 Pod 内某个容器在统计窗口内的用量画像（来自 Prometheus）
+type ContainerUsageProfile struct {
+	Pod           string  `json:"pod"`
+	Container     string  `json:"container"`
+	CPUP95        float64 `json:"cpu_p95"`        // CPU 用量 P95（cores）
+	CPUP99        float64 `json:"cpu_p99"`        // CPU 用量 P99（cores）
+	MemoryP95     float64 `json:"memory_p95"`     // 内存工作集 P95（bytes）
+	MemoryP99     float64 `json:"memory_p99"`     // 内存工作集 P99（bytes）
+	MemoryMax     float64 `json:"memory_max"`     // 内存工作集峰值（bytes）
+	OOMKills      float64 `json:"oom_kills"`      // 窗口内 OOM Kill 次数
+	CPUThrottling float64 `json:"cpu_throttling"` // 窗口内 CPU 被限流周期占比（%）
+}
+
+// ContainerResourceValues 容器资源规格，CPU 单位为 cores，内存单位为 bytes，0 表示未设置
+type ContainerResourceValues struct {
+	CPURequest    float64 `json:"cpu_request"`
+	CPULimit      float64 `json:"cpu_limit"`
+	MemoryRequest float64 `json:"memory_request"`
+	MemoryLimit   float64 `json:"memory_limit"`
+}
+
+// ContainerRightsizing 单个容器的规格建议
+type ContainerRightsizing struct {
+	Name          string                  `json:"name"`
+	Pods          int                     `json:"pods"` // 参与统计的 Pod 数
+	CPUP95        float64                 `json:"cpu_p95"`
+	CPUP99        float64                 `json:"cpu_p99"`
+	MemoryP95     float64                 `json:"memory_p95"`
+	MemoryP99     float64                 `json:"memory_p99"`
+	MemoryMax     float64                 `json:"memory_max"`
+	OOMKills      float64                 `json:"oom_kills"`
+	CPUThrottling float64                 `json:"cpu_throttling"`
+	Current       ContainerResourceValues `json:"current"`
+	Recommended   ContainerResourceValues `json:"recommended"`
+	Status        string                  `json:"status"`
+	Reasons       []string                `json:"reasons"`
+}
+
+// WorkloadRightsizing 工作负载的规格建议
+type WorkloadRightsizing struct {
+	Kind          string                 `json:"kind"`
+	Namespace     string                 `json:"namespace"`
+	Name          string                 `json:"name"`
+	Replicas      int32                  `json:"replicas"`
+	Window        string                 `json:"window"`
+	Status        string                 `json:"status"`
+	Containers    []ContainerRightsizing `json:"containers"`
+	ReclaimCPU    float64                `json:"reclaim_cpu"`    // 按副本数计算可回收的 CPU request（cores），负数表示需要增加
+	ReclaimMemory float64                `json:"reclaim_memory"` // 按副本数计算可回收的内存 request（bytes），负数表示需要增加
+	Patch         string                 `json:"patch"`          // strategic merge patch，规格无需调整时为空
+	Command       string                 `json:"command"`        // 应用 patch 的 kubectl 命令
+}
+
+// RightsizingReport 命名空间内所有工作负载的规格建议
+type RightsizingReport struct {
+	Namespace     string                `json:"namespace"`
+	Window        string                `json:"window"`
+	Workloads     []WorkloadRightsizing `json:"workloads"`
+	ReclaimCPU    float64               `json:"reclaim_cpu"`
+	ReclaimMemory float64               `json:"reclaim_memory"`
+}
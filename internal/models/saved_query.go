@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SavedQuery 查询控制台保存的 PromQL 语句，Shared 为 true 时对所有用户可见
+type SavedQuery struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	ClusterID   uint   `json:"cluster_id" gorm:"index"` // 0 表示不限集群
+	Name        string `json:"name" gorm:"size:100;not null"`
+	Description string `json:"description" gorm:"size:500"`
+	Query       string `json:"query" gorm:"type:text;not null"`
+	Shared      bool   `json:"shared" gorm:"index"`
+
+	OwnerID   uint           `json:"owner_id" gorm:"index;not null"`
+	OwnerName string         `json:"owner_name" gorm:"size:100"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// TableName 指定保存查询表名
+func (SavedQuery) TableName() string {
+	return "saved_queries"
+}
+
+// SavedQueryRequest 创建/更新保存查询请求
+type SavedQueryRequest struct {
+	ClusterID   uint   `json:"cluster_id"`
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	Query       string `json:"query" binding:"required"`
+	Shared      bool   `json:"shared"`
+}
+
+// ConsoleQueryRequest 查询控制台查询请求（POST 请求体，GET 时使用同名查询参数）
+type ConsoleQueryRequest struct {
+	Query string `json:"query" form:"query" binding:"required"`
+	Time  string `json:"time" form:"time"`   // 即时查询时间，RFC3339 或 Unix 秒，为空表示当前时间
+	Start string `json:"start" form:"start"` // 区间查询开始时间
+	End   string `json:"end" form:"end"`     // 区间查询结束时间
+	Step  string `json:"step" form:"step"`   // 区间查询步长，如 30s、1m 或秒数
+}
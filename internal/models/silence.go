@@ -0,0 +1,127 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SilenceTemplate 静默模板：保存常用的匹配器、时长与说明，一键创建静默
+type SilenceTemplate struct {
+	ID              uint   `json:"id" gorm:"primaryKey"`
+	ClusterID       uint   `json:"cluster_id" gorm:"index"` // 0 表示所有集群共用
+	Name            string `json:"name" gorm:"size:100;not null"`
+	Description     string `json:"description" gorm:"size:500"`
+	Matchers        string `json:"-" gorm:"type:text"` // JSON，[]Matcher
+	DurationMinutes int    `json:"duration_minutes"`
+	Comment         string `json:"comment" gorm:"size:500"`
+
+	MatcherList []Matcher `json:"matchers" gorm:"-"`
+
+	CreatedBy string         `json:"created_by" gorm:"size:100"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// TableName 指定静默模板表名
+func (SilenceTemplate) TableName() string {
+	return "silence_templates"
+}
+
+// SilenceTemplateRequest 创建/更新静默模板请求
+type SilenceTemplateRequest struct {
+	Name            string    `json:"name" binding:"required"`
+	Description     string    `json:"description"`
+	Shared          bool      `json:"shared"` // 是否所有集群共用
+	Matchers        []Matcher `json:"matchers" binding:"required"`
+	DurationMinutes int       `json:"duration_minutes" binding:"required"`
+	Comment         string    `json:"comment"`
+}
+
+// ApplySilenceTemplateRequest 按模板创建静默请求，未填写的字段使用模板值
+type ApplySilenceTemplateRequest struct {
+	StartsAt        *time.Time `json:"startsAt"` // 为空表示立即开始
+	DurationMinutes int        `json:"duration_minutes"`
+	Comment         string     `json:"comment"`
+}
+
+// RecurringSilence 周期静默：按周内日期与时间定义窗口，调度器在每个窗口开始前提前创建静默
+type RecurringSilence struct {
+	ID              uint   `json:"id" gorm:"primaryKey"`
+	ClusterID       uint   `json:"cluster_id" gorm:"index;not null"`
+	Name            string `json:"name" gorm:"size:100;not null"`
+	Matchers        string `json:"-" gorm:"type:text"` // JSON，[]Matcher
+	Comment         string `json:"comment" gorm:"size:500"`
+	Weekdays        string `json:"-" gorm:"size:50"`         // JSON 数组，0 表示周日
+	StartTime       string `json:"start_time" gorm:"size:5"` // 窗口开始时间 HH:MM
+	DurationMinutes int    `json:"duration_minutes"`         // 窗口时长
+	Timezone        string `json:"timezone" gorm:"size:64"`  // IANA 时区，为空时使用服务器时区
+	LeadMinutes     int    `json:"lead_minutes"`             // 提前多少分钟创建静默
+	Enabled         bool   `json:"enabled"`
+
+	LastWindowStart *time.Time `json:"last_window_start,omitempty"` // 最近一次已创建静默的窗口
+	LastSilenceID   string     `json:"last_silence_id,omitempty" gorm:"size:64"`
+	LastError       string     `json:"last_error,omitempty" gorm:"size:1000"`
+
+	MatcherList     []Matcher  `json:"matchers" gorm:"-"`
+	WeekdayList     []int      `json:"weekdays" gorm:"-"`
+	NextWindowStart *time.Time `json:"next_window_start,omitempty" gorm:"-"`
+	NextWindowEnd   *time.Time `json:"next_window_end,omitempty" gorm:"-"`
+
+	CreatedBy string         `json:"created_by" gorm:"size:100"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// TableName 指定周期静默表名
+func (RecurringSilence) TableName() string {
+	return "recurring_silences"
+}
+
+// RecurringSilenceRequest 创建/更新周期静默请求
+type RecurringSilenceRequest struct {
+	Name            string    `json:"name" binding:"required"`
+	Matchers        []Matcher `json:"matchers" binding:"required"`
+	Comment         string    `json:"comment"`
+	Weekdays        []int     `json:"weekdays" binding:"required"`
+	StartTime       string    `json:"start_time" binding:"required"`
+	DurationMinutes int       `json:"duration_minutes" binding:"required"`
+	Timezone        string    `json:"timezone"`
+	LeadMinutes     int       `json:"lead_minutes"`
+	Enabled         bool      `json:"enabled"`
+}
+
+// 批量静默方式
+const (
+	BulkSilenceByGroup = "group"  // 按分组标签创建一条静默
+	BulkSilenceByAlert = "alerts" // 为分组内每条告警按全部标签各创建一条静默
+)
+
+// BulkSilenceRequest 按告警分组批量静默请求
+type BulkSilenceRequest struct {
+	GroupLabels     map[string]string `json:"group_labels"` // 分组标签，与 Receiver 一起定位分组
+	Receiver        string            `json:"receiver"`
+	Mode            string            `json:"mode"` // group（默认）或 alerts
+	DurationMinutes int               `json:"duration_minutes" binding:"required"`
+	Comment         string            `json:"comment" binding:"required"`
+}
+
+// BulkSilenceResult 批量静默结果
+type BulkSilenceResult struct {
+	Created []Silence `json:"created"`
+	Errors  []string  `json:"errors,omitempty"`
+}
+
+// ExtendSilenceRequest 延长静默请求，Minutes 为在当前结束时间基础上延长的分钟数
+type ExtendSilenceRequest struct {
+	Minutes int    `json:"minutes" binding:"required"`
+	Comment string `json:"comment"` // 为空时保留原说明
+}
+
+// OrphanedSilence 孤立静默：未匹配任何当前或近期出现过的告警
+type OrphanedSilence struct {
+	Silence
+	ExpiresIn int64 `json:"expires_in"` // 距离过期的秒数
+}
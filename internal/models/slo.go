@@ -0,0 +1,103 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SLO 服务等级目标：SLI 为统计窗口内 good / total 的比例，Objective 为目标百分比
+type SLO struct {
+	ID             uint    `json:"id" gorm:"primaryKey"`
+	ClusterID      uint    `json:"cluster_id" gorm:"index;not null"`
+	Name           string  `json:"name" gorm:"size:100;not null"`
+	Description    string  `json:"description" gorm:"size:500"`
+	TargetKind     string  `json:"target_kind" gorm:"size:30;not null"` // Deployment、StatefulSet、DaemonSet、Rollout 或 Ingress
+	Namespace      string  `json:"namespace" gorm:"size:253;not null;index"`
+	TargetName     string  `json:"target_name" gorm:"size:253;not null"`
+	TemplateID     string  `json:"template_id" gorm:"size:50"`            // 使用的 SLI 模板，为空表示自定义表达式
+	TemplateParams string  `json:"template_params" gorm:"type:text"`      // 模板参数（JSON 对象）
+	GoodQuery      string  `json:"good_query" gorm:"type:text;not null"`  // 达标事件数 PromQL，区间使用 ${window} 占位
+	TotalQuery     string  `json:"total_query" gorm:"type:text;not null"` // 总事件数 PromQL，区间使用 ${window} 占位
+	Objective      float64 `json:"objective"`                             // 目标百分比，如 99.9
+	Window         string  `json:"window" gorm:"size:10"`                 // 统计窗口，如 30d
+	RuleNamespace  string  `json:"rule_namespace" gorm:"size:253"`        // 已生成的 PrometheusRule 命名空间
+	RuleName       string  `json:"rule_name" gorm:"size:253"`             // 已生成的 PrometheusRule 名称，为空表示未生成
+
+	CreatedBy     uint           `json:"created_by"`
+	CreatedByName string         `json:"created_by_name" gorm:"size:100"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// TableName 指定 SLO 表名
+func (SLO) TableName() string {
+	return "slos"
+}
+
+// SLORequest 创建/更新 SLO 请求；TemplateID 不为空时由模板生成 good / total 表达式
+type SLORequest struct {
+	Name           string            `json:"name" binding:"required"`
+	Description    string            `json:"description"`
+	TargetKind     string            `json:"target_kind" binding:"required"`
+	Namespace      string            `json:"namespace" binding:"required"`
+	TargetName     string            `json:"target_name" binding:"required"`
+	TemplateID     string            `json:"template_id"`
+	TemplateParams map[string]string `json:"template_params"`
+	GoodQuery      string            `json:"good_query"`
+	TotalQuery     string            `json:"total_query"`
+	Objective      float64           `json:"objective" binding:"required,gt=0,lt=100"`
+	Window         string            `json:"window"` // 为空时使用 30d
+}
+
+// SLOListQuery SLO 列表过滤条件
+type SLOListQuery struct {
+	TargetKind string `form:"targetKind"`
+	Namespace  string `form:"namespace"`
+	TargetName string `form:"targetName"`
+}
+
+// SLOTemplate 内置 SLI 模板，表达式中的 ${参数名} 由参数替换，${window} 在计算时替换为统计区间
+type SLOTemplate struct {
+	ID          string                        `json:"id"`
+	Name        string                        `json:"name"`
+	Description string                        `json:"description"`
+	Params      []PrometheusRuleTemplateParam `json:"params,omitempty"`
+	GoodQuery   string                        `json:"good_query"`
+	TotalQuery  string                        `json:"total_query"`
+}
+
+// SLOBurnRate 某个区间内的错误预算消耗速率，1 表示恰好在统计窗口结束时耗尽预算
+type SLOBurnRate struct {
+	Window string   `json:"window"`
+	Rate   *float64 `json:"rate"` // 区间内无请求时为空
+}
+
+// SLOBurnRateAlert 多窗口消耗速率告警条件：长短两个区间的消耗速率同时超过阈值时触发
+type SLOBurnRateAlert struct {
+	Severity    string  `json:"severity"`
+	LongWindow  string  `json:"long_window"`
+	ShortWindow string  `json:"short_window"`
+	Threshold   float64 `json:"threshold"`
+	Firing      bool    `json:"firing"`
+}
+
+// SLOStatus SLO 当前状态
+type SLOStatus struct {
+	SLO             *SLO               `json:"slo"`
+	SLI             *float64           `json:"sli"`              // 统计窗口内的达标率（%），无请求时为空
+	ErrorBudget     float64            `json:"error_budget"`     // 允许的错误比例（%）
+	BudgetConsumed  *float64           `json:"budget_consumed"`  // 已消耗的错误预算（%）
+	BudgetRemaining *float64           `json:"budget_remaining"` // 剩余错误预算（%），超支时为负
+	BurnRates       []SLOBurnRate      `json:"burn_rates"`
+	Alerts          []SLOBurnRateAlert `json:"alerts"`
+	Error           string             `json:"error,omitempty"` // 查询失败原因
+}
+
+// SLOBudgetHistory 错误预算趋势，每个点按截至该时刻的统计窗口计算
+type SLOBudgetHistory struct {
+	BudgetRemaining []DataPoint `json:"budget_remaining"` // 剩余错误预算（%）
+	SLI             []DataPoint `json:"sli"`              // 达标率（%）
+	BurnRate        []DataPoint `json:"burn_rate"`        // 1h 消耗速率
+}
@@ -0,0 +1,71 @@
+package models
+
+// 废弃 API 在目标版本中的状态
+const (
+	DeprecatedAPIStatusRemoved    = "removed"    // 目标版本中已移除，升级前必须迁移
+	DeprecatedAPIStatusDeprecated = "deprecated" // 目标版本中已废弃，仍可使用
+)
+
+// 废弃 API 使用的发现来源
+const (
+	DeprecatedAPISourceLastApplied   = "last_applied"   // kubectl.kubernetes.io/last-applied-configuration 注解
+	DeprecatedAPISourceManagedFields = "managed_fields" // metadata.managedFields 中的字段管理者
+)
+
+// DeprecatedAPI 内置的 API 废弃与移除版本
+type DeprecatedAPI struct {
+	Group        string `json:"group"`
+	Version      string `json:"version"`
+	Kind         string `json:"kind"`
+	Resource     string `json:"resource"`
+	DeprecatedIn string `json:"deprecated_in"`
+	RemovedIn    string `json:"removed_in"`
+	Replacement  string `json:"replacement"` // 替代的 apiVersion，为空表示无替代
+}
+
+// DeprecatedAPIObject 通过废弃 API 创建或管理的对象
+type DeprecatedAPIObject struct {
+	APIVersion   string `json:"api_version"`
+	Kind         string `json:"kind"`
+	Namespace    string `json:"namespace,omitempty"`
+	Name         string `json:"name"`
+	Source       string `json:"source"`            // last_applied, managed_fields
+	Manager      string `json:"manager,omitempty"` // managedFields 中的字段管理者，如 kubectl、helm
+	Status       string `json:"status"`            // removed, deprecated
+	DeprecatedIn string `json:"deprecated_in"`
+	RemovedIn    string `json:"removed_in"`
+	Replacement  string `json:"replacement"`
+}
+
+// DeprecatedAPIRequest API Server 指标 apiserver_requested_deprecated_apis 中的废弃 API 请求
+type DeprecatedAPIRequest struct {
+	Group       string  `json:"group"`
+	Version     string  `json:"version"`
+	Resource    string  `json:"resource"`
+	Subresource string  `json:"subresource,omitempty"`
+	RemovedIn   string  `json:"removed_in"`
+	Requests    float64 `json:"requests"` // 近 24 小时请求数
+	Status      string  `json:"status"`   // removed, deprecated
+	Replacement string  `json:"replacement"`
+}
+
+// UpgradeReadinessReport 集群升级到目标版本前的废弃 API 检查报告
+type UpgradeReadinessReport struct {
+	ClusterID        uint                   `json:"cluster_id"`
+	CurrentVersion   string                 `json:"current_version"`
+	TargetVersion    string                 `json:"target_version"`
+	Ready            bool                   `json:"ready"`    // 没有目标版本中已移除的 API 使用
+	Blocking         int                    `json:"blocking"` // 已移除 API 的对象与请求数
+	Warnings         int                    `json:"warnings"` // 已废弃 API 的对象与请求数
+	Objects          []DeprecatedAPIObject  `json:"objects"`
+	Requests         []DeprecatedAPIRequest `json:"requests"`
+	MetricsAvailable bool                   `json:"metrics_available"`
+	MetricsError     string                 `json:"metrics_error,omitempty"`
+	ScanErrors       []string               `json:"scan_errors,omitempty"` // 无法列出的资源
+	GeneratedAt      int64                  `json:"generated_at"`
+}
+
+// UpgradeReadinessQuery 升级检查查询参数
+type UpgradeReadinessQuery struct {
+	TargetVersion string `form:"target_version"` // 如 1.29 或 v1.29.3，默认为当前版本的下一个次版本
+}
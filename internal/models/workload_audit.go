@@ -0,0 +1,77 @@
+package models
+
+// 工作负载最佳实践检查项
+const (
+	WorkloadAuditLivenessProbe    = "liveness_probe"    // 未配置存活探针
+	WorkloadAuditReadinessProbe   = "readiness_probe"   // 未配置就绪探针
+	WorkloadAuditResourceRequests = "resource_requests" // 未设置 CPU / 内存 Request
+	WorkloadAuditResourceLimits   = "resource_limits"   // 未设置 CPU / 内存 Limit
+	WorkloadAuditImageTag         = "image_tag"         // 使用 latest 或未指定镜像标签
+	WorkloadAuditPrivileged       = "privileged"        // 特权容器
+	WorkloadAuditRunAsRoot        = "run_as_root"       // 以 root 用户运行
+	WorkloadAuditHostPath         = "host_path"         // 挂载 hostPath
+	WorkloadAuditPDB              = "pdb"               // 多副本工作负载缺少 PodDisruptionBudget
+	WorkloadAuditSingleReplica    = "single_replica"    // 生产环境单副本
+)
+
+// WorkloadAuditIgnoreAnnotation 工作负载上用于忽略检查项的注解，值为逗号分隔的检查项或 *
+const WorkloadAuditIgnoreAnnotation = "kubepolaris.io/audit-ignore"
+
+// WorkloadAuditFinding 一个不符合最佳实践的配置项
+type WorkloadAuditFinding struct {
+	Check     string `json:"check"`
+	Severity  string `json:"severity"` // critical, warning, info
+	Container string `json:"container,omitempty"`
+	Message   string `json:"message"`
+	Solution  string `json:"solution"`
+}
+
+// WorkloadAuditItem 单个工作负载的检查结果
+type WorkloadAuditItem struct {
+	Kind      string                 `json:"kind"` // Deployment, StatefulSet, DaemonSet, CronJob, Rollout
+	Namespace string                 `json:"namespace"`
+	Name      string                 `json:"name"`
+	Replicas  int32                  `json:"replicas"`
+	Score     int                    `json:"score"`
+	Findings  []WorkloadAuditFinding `json:"findings"`
+}
+
+// WorkloadAuditNamespace 命名空间的检查评分
+type WorkloadAuditNamespace struct {
+	Namespace  string `json:"namespace"`
+	Production bool   `json:"production"`
+	Score      int    `json:"score"` // 命名空间内工作负载评分的平均值
+	Workloads  int    `json:"workloads"`
+	Critical   int    `json:"critical"`
+	Warning    int    `json:"warning"`
+	Info       int    `json:"info"`
+}
+
+// WorkloadAuditCheckSummary 检查项的违反情况
+type WorkloadAuditCheckSummary struct {
+	Check     string `json:"check"`
+	Name      string `json:"name"`
+	Severity  string `json:"severity"`
+	Workloads int    `json:"workloads"` // 违反该检查项的工作负载数
+}
+
+// WorkloadAuditReport 工作负载最佳实践检查报告
+type WorkloadAuditReport struct {
+	Score       int                         `json:"score"` // 全部工作负载评分的平均值
+	Workloads   int                         `json:"workloads"`
+	Critical    int                         `json:"critical"`
+	Warning     int                         `json:"warning"`
+	Info        int                         `json:"info"`
+	Namespaces  []WorkloadAuditNamespace    `json:"namespaces"`
+	Checks      []WorkloadAuditCheckSummary `json:"checks"`
+	Items       []WorkloadAuditItem         `json:"items"` // 存在问题的工作负载，按评分升序
+	GeneratedAt int64                       `json:"generated_at"`
+}
+
+// WorkloadAuditQuery 工作负载检查查询参数
+type WorkloadAuditQuery struct {
+	Namespace     string `form:"namespace"`                                                // 只检查该命名空间
+	Severity      string `form:"severity" binding:"omitempty,oneof=critical warning info"` // 只返回该级别的问题
+	Check         string `form:"check"`                                                    // 只返回该检查项的问题
+	IncludeSystem bool   `form:"include_system"`                                           // 是否包含 kube-system 等系统命名空间
+}
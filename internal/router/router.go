@@ -1,6 +1,9 @@
 package router
 
 import (
+	"context"
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
@@ -62,8 +65,34 @@ func Setup(db *gorm.DB, cfg *config.Config) *gin.Engine {
 		}
 	}
 	monitoringConfigSvc := services.NewMonitoringConfigServiceWithGrafana(db, grafanaSvc)
+	// 未配置 Prometheus 的集群使用 metrics-server 获取实时 CPU / 内存用量
+	metricsServerSvc := services.NewMetricsServerService(clusterSvc, monitoringConfigSvc)
+	prometheusRuleSvc := services.NewPrometheusRuleService(monitoringConfigSvc, prometheusSvc)
+	prometheusRuleHandler := handlers.NewPrometheusRuleHandler(clusterSvc, prometheusRuleSvc)
+	// SLO（错误预算与消耗速率，可生成对应的 PrometheusRule）
+	sloHandler := handlers.NewSLOHandler(clusterSvc, services.NewSLOService(db, monitoringConfigSvc, prometheusSvc, prometheusRuleSvc))
+	logConfigSvc := services.NewLogConfigService(db)
+	logParseRuleSvc := services.NewLogParseRuleService(db)
+	logAggregator := services.NewLogAggregator(clusterSvc, logParseRuleSvc)
+	// 日志导出（同步流式导出与异步导出任务）
+	logExportSvc := services.NewLogExportService(db, clusterSvc, logConfigSvc, logAggregator, opLogSvc, services.LogExportOptions{
+		Dir:            cfg.LogExport.Dir,
+		Retention:      time.Duration(cfg.LogExport.RetentionHours) * time.Hour,
+		MaxSyncEntries: int64(cfg.LogExport.MaxSyncEntries),
+		MaxJobEntries:  int64(cfg.LogExport.MaxJobEntries),
+		Workers:        cfg.LogExport.Workers,
+	})
+	// ConfigMap/Secret 版本历史（Secret 快照加密密钥未配置时使用 JWT 密钥）
+	encryptionKey := cfg.ConfigHistory.EncryptionKey
+	if encryptionKey == "" {
+		encryptionKey = cfg.JWT.Secret
+	}
+	configVersionSvc := services.NewConfigVersionService(db, encryptionKey, cfg.ConfigHistory.MaxVersions)
 	// K8s Informer 管理器
 	k8sMgr := k8s.NewClusterInformerManager()
+	if db != nil && cfg.ConfigHistory.ObserveInformer {
+		k8sMgr.SetConfigObserver(configVersionSvc)
+	}
 	// 预热所有已存在集群的 Informer（后台执行，不阻塞启动）
 	go func() {
 		clusters, err := clusterSvc.GetAllClusters()
@@ -78,6 +107,74 @@ func Setup(db *gorm.DB, cfg *config.Config) *gin.Engine {
 		}
 	}()
 
+	// 通知中心（集群异常、节点驱逐、YAML 应用失败、健康评分下降等事件的订阅与投递）
+	notificationSvc := services.NewNotificationService(db, services.NotificationOptions{
+		MaxAttempts:     cfg.Notification.MaxAttempts,
+		RetryBase:       time.Duration(cfg.Notification.RetryBaseSeconds) * time.Second,
+		HealthScoreDrop: cfg.Notification.HealthScoreDrop,
+	})
+	// 节点维护窗口调度器（执行状态持久化在数据库中，重启后继续执行）
+	maintenanceSvc := services.NewMaintenanceService(db, clusterSvc, services.NewAlertManagerConfigService(db), services.NewAlertManagerService(), notificationSvc)
+	// 外部密钥同步器（Vault / 文件源 → Secret）
+	externalSecretSvc := services.NewExternalSecretService(db, clusterSvc, opLogSvc, configVersionSvc, cfg.ExternalSecret.FileRootDir)
+	// 日志告警评估器（匹配行数超过阈值时推送到集群 Alertmanager）
+	logAlertSvc := services.NewLogAlertService(db, clusterSvc, logAggregator, services.NewAlertManagerConfigService(db), services.NewAlertManagerService())
+	// 告警历史（拉取 Alertmanager / 接收 webhook，记录告警生命周期）
+	alertHistorySvc := services.NewAlertHistoryService(db, clusterSvc, services.NewAlertManagerConfigService(db), services.NewAlertManagerService(), services.AlertHistoryOptions{
+		PollInterval: time.Duration(cfg.AlertHistory.PollIntervalSeconds) * time.Second,
+		TeamLabel:    cfg.AlertHistory.TeamLabel,
+		Retention:    time.Duration(cfg.AlertHistory.RetentionDays) * 24 * time.Hour,
+	})
+	alertHistoryHandler := handlers.NewAlertHistoryHandler(alertHistorySvc, cfg.AlertHistory.WebhookToken)
+	// 静默管理（模板、周期静默调度、批量与延长）
+	silenceSvc := services.NewSilenceService(db, services.NewAlertManagerConfigService(db), services.NewAlertManagerService())
+	// PromQL 查询控制台（查询代理与补全共用一个按用户限流器）
+	queryConsoleHandler := handlers.NewQueryConsoleHandler(monitoringConfigSvc, prometheusSvc, services.NewSavedQueryService(db))
+	queryConsoleLimit := middleware.RateLimitPerUser(cfg.QueryConsole.RateLimitPerMinute, cfg.QueryConsole.Burst)
+	// 成本分摊（Prometheus 历史按天汇总，未配置 Prometheus 时由 informer 快照累加）
+	costSvc := services.NewCostService(db, clusterSvc, monitoringConfigSvc, prometheusSvc, k8sMgr, metricsServerSvc, services.CostOptions{
+		SnapshotInterval: time.Duration(cfg.Cost.SnapshotIntervalSeconds) * time.Second,
+		Currency:         cfg.Cost.Currency,
+		TeamLabel:        cfg.Cost.TeamLabel,
+	})
+	// 容量预测（集群整体与节点池，供健康诊断与总览趋势使用）
+	capacitySvc := services.NewCapacityForecastService(prometheusSvc, services.CapacityForecastOptions{
+		Threshold:     cfg.CapacityForecast.Threshold,
+		Lookback:      time.Duration(cfg.CapacityForecast.LookbackDays) * 24 * time.Hour,
+		Horizon:       time.Duration(cfg.CapacityForecast.HorizonDays) * 24 * time.Hour,
+		NodePoolLabel: cfg.CapacityForecast.NodePoolLabel,
+	})
+	// 健康诊断（按集群的规则配置执行内置与自定义规则；手动与定时诊断结果均记录历史，评分下降或出现新的严重风险时通知）
+	diagnosisRuleSvc := services.NewDiagnosisRuleService(db, services.DefaultDiagnosisRules)
+	// 证书清单（定时扫描各集群证书，临近过期时通知，并作为健康诊断的证书风险来源）
+	certificateSvc := services.NewCertificateService(db, clusterSvc, notificationSvc, services.CertificateOptions{
+		Interval:     time.Duration(cfg.CertificateScan.IntervalHours) * time.Hour,
+		WarningDays:  cfg.CertificateScan.WarningDays,
+		CriticalDays: cfg.CertificateScan.CriticalDays,
+	})
+	omSvc := services.NewOMService(prometheusSvc, monitoringConfigSvc, metricsServerSvc, capacitySvc, certificateSvc, diagnosisRuleSvc, k8sMgr)
+	healthHistorySvc := services.NewHealthHistoryService(db, clusterSvc, omSvc, notificationSvc, services.HealthHistoryOptions{
+		Interval:  time.Duration(cfg.HealthDiagnosis.IntervalMinutes) * time.Minute,
+		Retention: time.Duration(cfg.HealthDiagnosis.RetentionDays) * 24 * time.Hour,
+	})
+	if db != nil {
+		maintenanceSvc.Start(context.Background())
+		externalSecretSvc.Start(context.Background())
+		logExportSvc.Start(context.Background())
+		logAlertSvc.Start(context.Background())
+		notificationSvc.Start(context.Background())
+		alertHistorySvc.Start(context.Background())
+		silenceSvc.Start(context.Background())
+		metricsServerSvc.Start(context.Background())
+		costSvc.Start(context.Background())
+		healthHistorySvc.Start(context.Background())
+		certificateSvc.Start(context.Background())
+		if cfg.Notification.HealthCheckIntervalSeconds > 0 {
+			services.NewClusterHealthChecker(clusterSvc, notificationSvc.ClusterStatusChanged).
+				Start(context.Background(), time.Duration(cfg.Notification.HealthCheckIntervalSeconds)*time.Second)
+		}
+	}
+
 	// /api/v1
 	api := r.Group("/api/v1")
 
@@ -93,6 +190,12 @@ func Setup(db *gorm.DB, cfg *config.Config) *gin.Engine {
 		auth.POST("/change-password", middleware.AuthRequired(cfg.JWT.Secret), authHandler.ChangePassword)
 	}
 
+	// 外部系统 webhook（各自校验 token，不走登录认证）
+	webhooks := api.Group("/webhooks")
+	{
+		webhooks.POST("/alertmanager/:clusterID", alertHistoryHandler.ReceiveWebhook)
+	}
+
 	// 创建权限中间件（在受保护路由和 WebSocket 路由中共用）
 	permMiddleware := middleware.NewPermissionMiddleware(permissionSvc)
 
@@ -102,6 +205,7 @@ func Setup(db *gorm.DB, cfg *config.Config) *gin.Engine {
 	{
 
 		// clusters 根分组
+		certificateHandler := handlers.NewCertificateHandler(clusterSvc, certificateSvc)
 		clusters := protected.Group("/clusters")
 		{
 			clusterHandler := handlers.NewClusterHandler(db, cfg, k8sMgr, prometheusSvc, monitoringConfigSvc)
@@ -116,6 +220,8 @@ func Setup(db *gorm.DB, cfg *config.Config) *gin.Engine {
 			cluster := clusters.Group("/:clusterID")
 			cluster.Use(permMiddleware.ClusterAccessRequired()) // 启用集群权限检查
 			cluster.Use(permMiddleware.AutoWriteCheck())        // 自动检查写权限（POST/PUT/DELETE需要非只读权限）
+			// YAML 应用失败时通知订阅者
+			cluster.Use(middleware.NotifyApplyFailure(notificationSvc))
 			{
 				cluster.GET("", clusterHandler.GetCluster)
 				cluster.GET("/status", clusterHandler.GetClusterStatus)
@@ -135,13 +241,59 @@ func Setup(db *gorm.DB, cfg *config.Config) *gin.Engine {
 				}
 
 				// monitoring 子分组
-				monitoringHandler := handlers.NewMonitoringHandler(monitoringConfigSvc, prometheusSvc)
+				monitoringHandler := handlers.NewMonitoringHandler(monitoringConfigSvc, prometheusSvc, metricsServerSvc)
 				monitoring := cluster.Group("/monitoring")
 				{
 					monitoring.GET("/config", monitoringHandler.GetMonitoringConfig)
 					monitoring.PUT("/config", monitoringHandler.UpdateMonitoringConfig)
 					monitoring.POST("/test-connection", monitoringHandler.TestMonitoringConnection)
 					monitoring.GET("/metrics", monitoringHandler.GetClusterMetrics)
+					// PromQL 查询控制台（自动注入集群选择器）
+					monitoring.GET("/query", queryConsoleLimit, queryConsoleHandler.Query)
+					monitoring.POST("/query", queryConsoleLimit, queryConsoleHandler.Query)
+					monitoring.GET("/query_range", queryConsoleLimit, queryConsoleHandler.QueryRange)
+					monitoring.POST("/query_range", queryConsoleLimit, queryConsoleHandler.QueryRange)
+					monitoring.GET("/metric-names", queryConsoleLimit, queryConsoleHandler.GetMetricNames)
+					monitoring.GET("/labels", queryConsoleLimit, queryConsoleHandler.GetLabelNames)
+					monitoring.GET("/labels/:name/values", queryConsoleLimit, queryConsoleHandler.GetLabelValues)
+				}
+
+				// rightsizing 子分组（基于历史用量的资源规格建议）
+				rightsizingHandler := handlers.NewRightsizingHandler(monitoringConfigSvc, services.NewRightsizingService(clusterSvc, prometheusSvc))
+				rightsizing := cluster.Group("/rightsizing")
+				{
+					rightsizing.GET("", rightsizingHandler.GetNamespaceRecommendations)
+					rightsizing.GET("/:kind/:namespace/:name", rightsizingHandler.GetWorkloadRecommendation)
+				}
+
+				// cost 子分组（成本分摊与导出）
+				costHandler := handlers.NewCostHandler(costSvc)
+				cost := cluster.Group("/cost")
+				{
+					cost.GET("/pricing", costHandler.GetPricing)
+					cost.PUT("/pricing", costHandler.UpdatePricing)
+					cost.GET("/allocation", costHandler.GetAllocation)
+					cost.GET("/allocation/export", costHandler.ExportAllocation)
+					cost.POST("/rollup", costHandler.Rollup)
+				}
+
+				// certificates 子分组（证书清单与过期检查）
+				certificates := cluster.Group("/certificates")
+				{
+					certificates.GET("", certificateHandler.ListCertificates)
+					certificates.POST("/scan", certificateHandler.ScanCertificates)
+				}
+
+				// slos 子分组（SLO 定义、错误预算与消耗速率）
+				slos := cluster.Group("/slos")
+				{
+					slos.GET("", sloHandler.ListSLOs)
+					slos.POST("", sloHandler.CreateSLO)
+					slos.GET("/:id", sloHandler.GetSLO)
+					slos.PUT("/:id", sloHandler.UpdateSLO)
+					slos.DELETE("/:id", sloHandler.DeleteSLO)
+					slos.GET("/:id/budget", sloHandler.GetBudgetHistory) // 错误预算趋势（工作负载详情页监控面板）
+					slos.POST("/:id/rule", sloHandler.SyncRule)          // 生成 / 更新 PrometheusRule
 				}
 
 				// alertmanager 子分组
@@ -155,6 +307,14 @@ func Setup(db *gorm.DB, cfg *config.Config) *gin.Engine {
 					alertmanager.POST("/test-connection", alertHandler.TestAlertManagerConnection)
 					alertmanager.GET("/status", alertHandler.GetAlertManagerStatus)
 					alertmanager.GET("/template", alertHandler.GetAlertManagerConfigTemplate)
+
+					// 路由树、接收器与抑制规则编辑（写回 alertmanager.yaml Secret 或 AlertmanagerConfig）
+					routingHandler := handlers.NewAlertManagerRoutingHandler(clusterSvc, services.NewAlertManagerRoutingService(configVersionSvc))
+					alertmanager.GET("/routing/sources", routingHandler.ListSources)
+					alertmanager.GET("/routing", routingHandler.GetRouting)
+					alertmanager.PUT("/routing", routingHandler.SaveRouting)
+					alertmanager.POST("/routing/validate", routingHandler.ValidateRouting)
+					alertmanager.POST("/routing/simulate", routingHandler.SimulateRouting) // 路由模拟
 				}
 
 				// alerts 子分组
@@ -165,12 +325,39 @@ func Setup(db *gorm.DB, cfg *config.Config) *gin.Engine {
 					alerts.GET("/stats", alertHandler.GetAlertStats)
 				}
 
+				// 告警历史子分组（生命周期记录、噪音排名、确认与指派）
+				alertHistory := cluster.Group("/alert-history")
+				{
+					alertHistory.GET("", alertHistoryHandler.ListAlerts)
+					alertHistory.GET("/noise", alertHistoryHandler.GetNoiseRanking)
+					alertHistory.GET("/metrics", alertHistoryHandler.GetMetrics)
+					alertHistory.GET("/:alertId", alertHistoryHandler.GetAlert)
+					alertHistory.POST("/:alertId/acknowledge", alertHistoryHandler.AcknowledgeAlert)
+					alertHistory.POST("/:alertId/assign", alertHistoryHandler.AssignAlert)
+				}
+
 				// silences 子分组
+				silenceHandler := handlers.NewSilenceHandler(silenceSvc)
 				silences := cluster.Group("/silences")
 				{
 					silences.GET("", alertHandler.GetSilences)
 					silences.POST("", alertHandler.CreateSilence)
 					silences.DELETE("/:silenceId", alertHandler.DeleteSilence)
+					silences.POST("/:silenceId/extend", silenceHandler.ExtendSilence)
+					silences.POST("/bulk", silenceHandler.BulkSilence) // 按告警分组批量静默
+					silences.GET("/orphaned", silenceHandler.GetOrphanedSilences)
+					// 静默模板
+					silences.GET("/templates", silenceHandler.ListTemplates)
+					silences.POST("/templates", silenceHandler.CreateTemplate)
+					silences.PUT("/templates/:id", silenceHandler.UpdateTemplate)
+					silences.DELETE("/templates/:id", silenceHandler.DeleteTemplate)
+					silences.POST("/templates/:id/apply", silenceHandler.ApplyTemplate)
+					// 周期静默
+					silences.GET("/recurring", silenceHandler.ListRecurring)
+					silences.POST("/recurring", silenceHandler.CreateRecurring)
+					silences.GET("/recurring/:id", silenceHandler.GetRecurring)
+					silences.PUT("/recurring/:id", silenceHandler.UpdateRecurring)
+					silences.DELETE("/recurring/:id", silenceHandler.DeleteRecurring)
 				}
 
 				// receivers 子分组
@@ -180,7 +367,7 @@ func Setup(db *gorm.DB, cfg *config.Config) *gin.Engine {
 				}
 
 				// nodes 子分组
-				nodeHandler := handlers.NewNodeHandler(db, cfg, clusterSvc, k8sMgr, prometheusSvc, monitoringConfigSvc)
+				nodeHandler := handlers.NewNodeHandler(db, cfg, clusterSvc, k8sMgr, prometheusSvc, monitoringConfigSvc, notificationSvc, metricsServerSvc)
 				nodes := cluster.Group("/nodes")
 				{
 					nodes.GET("", nodeHandler.GetNodes)
@@ -189,9 +376,26 @@ func Setup(db *gorm.DB, cfg *config.Config) *gin.Engine {
 					nodes.POST("/:name/cordon", nodeHandler.CordonNode)
 					nodes.POST("/:name/uncordon", nodeHandler.UncordonNode)
 					nodes.POST("/:name/drain", nodeHandler.DrainNode)
+					nodes.PUT("/:name/labels", nodeHandler.UpdateNodeLabels)
+					nodes.PUT("/:name/annotations", nodeHandler.UpdateNodeAnnotations)
+					nodes.PUT("/:name/taints", nodeHandler.UpdateNodeTaints)
+					nodes.POST("/:name/preview", nodeHandler.PreviewNodeChange) // 预览变更对 Pod 的影响
+					nodes.POST("/bulk", nodeHandler.BulkUpdateNodes)            // 按标签选择器批量修改
 					nodes.GET("/:name/metrics", monitoringHandler.GetNodeMetrics)
 				}
 
+				// 节点维护窗口子分组
+				maintenanceHandler := handlers.NewMaintenanceHandler(maintenanceSvc)
+				maintenance := cluster.Group("/maintenance")
+				{
+					maintenance.GET("", maintenanceHandler.ListWindows)
+					maintenance.POST("", maintenanceHandler.CreateWindow)
+					maintenance.GET("/:windowId", maintenanceHandler.GetWindow)
+					maintenance.DELETE("/:windowId", maintenanceHandler.DeleteWindow)
+					maintenance.POST("/:windowId/cancel", maintenanceHandler.CancelWindow)
+					maintenance.POST("/:windowId/nodes/:nodeName/done", maintenanceHandler.SignalNodeDone) // 外部维护完成信号
+				}
+
 				// pods 子分组
 				podHandler := handlers.NewPodHandler(db, cfg, clusterSvc, k8sMgr)
 				pods := cluster.Group("/pods")
@@ -296,10 +500,13 @@ func Setup(db *gorm.DB, cfg *config.Config) *gin.Engine {
 				}
 
 				// 通用资源 YAML 处理器（用于 dry-run 和 apply）
-				resourceYAMLHandler := handlers.NewResourceYAMLHandler(db, cfg, clusterSvc, k8sMgr)
+				resourceYAMLHandler := handlers.NewResourceYAMLHandler(db, cfg, clusterSvc, k8sMgr, configVersionSvc)
+
+				// ConfigMap/Secret 版本历史处理器
+				configVersionHandler := handlers.NewConfigVersionHandler(clusterSvc, configVersionSvc)
 
 				// configmaps 子分组
-				configMapHandler := handlers.NewConfigMapHandler(db, cfg, clusterSvc, k8sMgr)
+				configMapHandler := handlers.NewConfigMapHandler(db, cfg, clusterSvc, k8sMgr, configVersionSvc)
 				configmaps := cluster.Group("/configmaps")
 				{
 					configmaps.GET("", configMapHandler.GetConfigMaps)
@@ -309,19 +516,49 @@ func Setup(db *gorm.DB, cfg *config.Config) *gin.Engine {
 					configmaps.PUT("/:namespace/:name", configMapHandler.UpdateConfigMap)
 					configmaps.DELETE("/:namespace/:name", configMapHandler.DeleteConfigMap)
 					configmaps.POST("/yaml/apply", resourceYAMLHandler.ApplyConfigMapYAML)
+					configmaps.GET("/:namespace/:name/versions", configVersionHandler.ListConfigMapVersions)
+					configmaps.GET("/:namespace/:name/versions/diff", configVersionHandler.DiffConfigMapVersions)
+					configmaps.POST("/:namespace/:name/versions/:version/restore", permMiddleware.NamespaceAccessRequired(), configVersionHandler.RestoreConfigMap)
+					configmaps.GET("/:namespace/:name/consumers", configVersionHandler.GetConfigMapConsumers)
 				}
 
 				// secrets 子分组
-				secretHandler := handlers.NewSecretHandler(db, cfg, clusterSvc, k8sMgr)
+				secretHandler := handlers.NewSecretHandler(db, cfg, clusterSvc, k8sMgr, configVersionSvc)
 				secrets := cluster.Group("/secrets")
 				{
 					secrets.GET("", secretHandler.GetSecrets)
 					secrets.GET("/namespaces", secretHandler.GetSecretNamespaces)
 					secrets.GET("/:namespace/:name", secretHandler.GetSecret)
+					secrets.POST("/:namespace/:name/reveal/:key", permMiddleware.NamespaceAccessRequired(), permMiddleware.ActionRequired("secret:reveal"), secretHandler.RevealSecretKey) // 按键查看明文（审计）
 					secrets.POST("", secretHandler.CreateSecret)
 					secrets.PUT("/:namespace/:name", secretHandler.UpdateSecret)
 					secrets.DELETE("/:namespace/:name", secretHandler.DeleteSecret)
 					secrets.POST("/yaml/apply", resourceYAMLHandler.ApplySecretYAML)
+					secrets.GET("/:namespace/:name/versions", configVersionHandler.ListSecretVersions)
+					secrets.GET("/:namespace/:name/versions/diff", configVersionHandler.DiffSecretVersions) // 只返回键名变化
+					secrets.POST("/:namespace/:name/versions/:version/restore", permMiddleware.NamespaceAccessRequired(), configVersionHandler.RestoreSecret)
+					secrets.GET("/:namespace/:name/consumers", configVersionHandler.GetSecretConsumers)
+				}
+
+				// 外部密钥源与外部密钥子分组
+				externalSecretHandler := handlers.NewExternalSecretHandler(externalSecretSvc)
+				secretSources := cluster.Group("/secret-sources")
+				{
+					secretSources.GET("", externalSecretHandler.ListSources)
+					secretSources.POST("", externalSecretHandler.CreateSource)
+					secretSources.PUT("/:sourceId", externalSecretHandler.UpdateSource)
+					secretSources.DELETE("/:sourceId", externalSecretHandler.DeleteSource)
+					secretSources.POST("/:sourceId/test", externalSecretHandler.TestSource)
+				}
+				externalSecrets := cluster.Group("/external-secrets")
+				{
+					externalSecrets.GET("", externalSecretHandler.ListExternalSecrets)
+					externalSecrets.POST("", externalSecretHandler.CreateExternalSecret)
+					externalSecrets.GET("/:id", externalSecretHandler.GetExternalSecret)
+					externalSecrets.PUT("/:id", externalSecretHandler.UpdateExternalSecret)
+					externalSecrets.DELETE("/:id", externalSecretHandler.DeleteExternalSecret)
+					externalSecrets.POST("/:id/sync", externalSecretHandler.SyncExternalSecret)
+					externalSecrets.GET("/:id/drift", externalSecretHandler.GetExternalSecretDrift)
 				}
 
 				// services 子分组
@@ -420,26 +657,78 @@ func Setup(db *gorm.DB, cfg *config.Config) *gin.Engine {
 				}
 
 				// logs - 日志中心
-				logCenterHandler := handlers.NewLogCenterHandler(clusterSvc, k8sMgr)
+				logCenterHandler := handlers.NewLogCenterHandler(clusterSvc, k8sMgr, logConfigSvc, logParseRuleSvc, logExportSvc)
 				logs := cluster.Group("/logs")
 				{
-					logs.GET("/containers", logCenterHandler.GetContainerLogs)     // 获取容器日志
-					logs.GET("/events", logCenterHandler.GetEventLogs)             // 获取K8s事件日志
-					logs.POST("/search", logCenterHandler.SearchLogs)              // 日志搜索
-					logs.GET("/stats", logCenterHandler.GetLogStats)               // 日志统计
-					logs.GET("/namespaces", logCenterHandler.GetNamespacesForLogs) // 获取命名空间列表
-					logs.GET("/pods", logCenterHandler.GetPodsForLogs)             // 获取Pod列表
-					logs.POST("/export", logCenterHandler.ExportLogs)              // 导出日志
+					logs.GET("/containers", logCenterHandler.GetContainerLogs)                   // 获取容器日志
+					logs.GET("/events", logCenterHandler.GetEventLogs)                           // 获取K8s事件日志
+					logs.POST("/search", logCenterHandler.SearchLogs)                            // 日志搜索
+					logs.GET("/stats", logCenterHandler.GetLogStats)                             // 日志统计
+					logs.GET("/namespaces", logCenterHandler.GetNamespacesForLogs)               // 获取命名空间列表
+					logs.GET("/pods", logCenterHandler.GetPodsForLogs)                           // 获取Pod列表
+					logs.POST("/export", logCenterHandler.ExportLogs)                            // 导出日志
+					logs.GET("/config", logCenterHandler.GetLogConfig)                           // 获取日志后端配置
+					logs.PUT("/config", logCenterHandler.UpdateLogConfig)                        // 更新日志后端配置
+					logs.POST("/config/test", logCenterHandler.TestLogConfig)                    // 测试日志后端连接
+					logs.GET("/parse-rules", logCenterHandler.ListParseRules)                    // 日志解析规则列表
+					logs.POST("/parse-rules", logCenterHandler.CreateParseRule)                  // 创建日志解析规则
+					logs.POST("/parse-rules/preview", logCenterHandler.PreviewParseRule)         // 试解析样例日志
+					logs.PUT("/parse-rules/:ruleId", logCenterHandler.UpdateParseRule)           // 更新日志解析规则
+					logs.DELETE("/parse-rules/:ruleId", logCenterHandler.DeleteParseRule)        // 删除日志解析规则
+					logs.POST("/export-jobs", logCenterHandler.CreateExportJob)                  // 创建异步导出任务
+					logs.GET("/export-jobs", logCenterHandler.ListExportJobs)                    // 导出任务列表
+					logs.GET("/export-jobs/:jobId", logCenterHandler.GetExportJob)               // 导出任务详情
+					logs.POST("/export-jobs/:jobId/cancel", logCenterHandler.CancelExportJob)    // 取消导出任务
+					logs.DELETE("/export-jobs/:jobId", logCenterHandler.DeleteExportJob)         // 删除导出任务
+					logs.GET("/export-jobs/:jobId/download", logCenterHandler.DownloadExportJob) // 下载导出文件
+				}
+
+				// 日志告警规则
+				logAlertHandler := handlers.NewLogAlertHandler(logAlertSvc)
+				logAlertRules := cluster.Group("/logs/alert-rules")
+				{
+					logAlertRules.GET("", logAlertHandler.ListRules)
+					logAlertRules.POST("", logAlertHandler.CreateRule)
+					logAlertRules.GET("/:ruleId", logAlertHandler.GetRule)
+					logAlertRules.PUT("/:ruleId", logAlertHandler.UpdateRule)
+					logAlertRules.DELETE("/:ruleId", logAlertHandler.DeleteRule)
+				}
+
+				// Prometheus 告警规则（PrometheusRule CRD）
+				prometheusRules := cluster.Group("/prometheus-rules")
+				{
+					prometheusRules.GET("", prometheusRuleHandler.ListRules)
+					prometheusRules.POST("", prometheusRuleHandler.CreateRule)
+					prometheusRules.POST("/validate", prometheusRuleHandler.ValidateRule) // 校验规则与 PromQL
+					prometheusRules.POST("/test", prometheusRuleHandler.TestRule)         // 按历史数据试运行
+					prometheusRules.POST("/build", prometheusRuleHandler.BuildRule)       // 模板/表单生成规则
+					prometheusRules.GET("/:namespace/:name", prometheusRuleHandler.GetRule)
+					prometheusRules.PUT("/:namespace/:name", prometheusRuleHandler.UpdateRule)
+					prometheusRules.DELETE("/:namespace/:name", prometheusRuleHandler.DeleteRule)
 				}
 
 				// O&M - 监控中心（运维）
-				omSvc := services.NewOMService(prometheusSvc, monitoringConfigSvc)
-				omHandler := handlers.NewOMHandler(clusterSvc, omSvc)
+				omHandler := handlers.NewOMHandler(clusterSvc, omSvc, healthHistorySvc, diagnosisRuleSvc)
+				upgradeReadinessHandler := handlers.NewUpgradeReadinessHandler(clusterSvc, services.NewUpgradeReadinessService(monitoringConfigSvc, prometheusSvc))
 				om := cluster.Group("/om")
 				{
-					om.GET("/health-diagnosis", omHandler.GetHealthDiagnosis)        // 集群健康诊断
-					om.GET("/resource-top", omHandler.GetResourceTop)                // 资源消耗 Top N
-					om.GET("/control-plane-status", omHandler.GetControlPlaneStatus) // 控制面组件状态
+					om.GET("/health-diagnosis", omHandler.GetHealthDiagnosis)                 // 集群健康诊断
+					om.GET("/resource-top", omHandler.GetResourceTop)                         // 资源消耗 Top N
+					om.GET("/control-plane-status", omHandler.GetControlPlaneStatus)          // 控制面组件状态
+					om.GET("/health-history", omHandler.GetHealthHistory)                     // 健康诊断历史
+					om.GET("/health-history/:id", omHandler.GetHealthHistoryRecord)           // 历史诊断详情
+					om.GET("/health-trend", omHandler.GetHealthTrend)                         // 健康评分趋势
+					om.GET("/health-diff", omHandler.GetHealthDiff)                           // 两次诊断对比
+					om.GET("/diagnosis-rules", omHandler.ListDiagnosisRules)                  // 诊断规则列表
+					om.PUT("/diagnosis-rules/:ruleID", omHandler.UpdateDiagnosisRule)         // 内置规则启停与阈值
+					om.GET("/custom-rules", omHandler.ListCustomRules)                        // 自定义诊断规则
+					om.POST("/custom-rules", omHandler.CreateCustomRule)                      // 创建自定义规则
+					om.POST("/custom-rules/test", omHandler.TestCustomRule)                   // 试运行自定义规则
+					om.PUT("/custom-rules/:id", omHandler.UpdateCustomRule)                   // 更新自定义规则
+					om.DELETE("/custom-rules/:id", omHandler.DeleteCustomRule)                // 删除自定义规则
+					om.GET("/workload-audit", omHandler.GetWorkloadAudit)                     // 工作负载最佳实践检查
+					om.GET("/deprecated-apis", upgradeReadinessHandler.ListDeprecatedAPIs)    // 内置的 API 废弃与移除版本
+					om.GET("/upgrade-readiness", upgradeReadinessHandler.GetUpgradeReadiness) // 升级前废弃 API 检查
 				}
 			}
 		}
@@ -449,7 +738,7 @@ func Setup(db *gorm.DB, cfg *config.Config) *gin.Engine {
 		{
 			alertManagerCfgSvc := services.NewAlertManagerConfigService(db)
 			alertManagerSvc := services.NewAlertManagerService()
-			overviewHandler := handlers.NewOverviewHandler(clusterSvc, k8sMgr, prometheusSvc, monitoringConfigSvc, alertManagerCfgSvc, alertManagerSvc)
+			overviewHandler := handlers.NewOverviewHandler(clusterSvc, k8sMgr, prometheusSvc, monitoringConfigSvc, alertManagerCfgSvc, alertManagerSvc, capacitySvc)
 			overview.GET("/stats", overviewHandler.GetStats)
 			overview.GET("/resource-usage", overviewHandler.GetResourceUsage)
 			overview.GET("/distribution", overviewHandler.GetDistribution)
@@ -485,9 +774,19 @@ func Setup(db *gorm.DB, cfg *config.Config) *gin.Engine {
 			audit.GET("/actions", opLogHandler.GetActions)
 		}
 
+		// 全部集群即将过期的证书
+		protected.GET("/certificates/expiring", certificateHandler.ListExpiringCertificates)
+
 		// monitoring templates
-		monitoringHandler := handlers.NewMonitoringHandler(monitoringConfigSvc, prometheusSvc)
+		monitoringHandler := handlers.NewMonitoringHandler(monitoringConfigSvc, prometheusSvc, metricsServerSvc)
 		protected.GET("/monitoring/templates", monitoringHandler.GetMonitoringTemplates)
+		protected.GET("/monitoring/rule-templates", prometheusRuleHandler.GetRuleTemplates)
+		protected.GET("/monitoring/slo-templates", sloHandler.GetTemplates)
+		// 查询控制台保存的查询（自己的与共享的）
+		protected.GET("/monitoring/saved-queries", queryConsoleHandler.ListSavedQueries)
+		protected.POST("/monitoring/saved-queries", queryConsoleHandler.CreateSavedQuery)
+		protected.PUT("/monitoring/saved-queries/:id", queryConsoleHandler.UpdateSavedQuery)
+		protected.DELETE("/monitoring/saved-queries/:id", queryConsoleHandler.DeleteSavedQuery)
 
 		// system settings - 系统设置（LDAP、SSH等）
 		systemSettings := protected.Group("/system")
@@ -504,6 +803,32 @@ func Setup(db *gorm.DB, cfg *config.Config) *gin.Engine {
 			systemSettings.GET("/ssh/credentials", systemSettingHandler.GetSSHCredentials)
 		}
 
+		// notifications - 通知中心
+		notificationHandler := handlers.NewNotificationHandler(notificationSvc)
+		notifications := protected.Group("/notifications")
+		{
+			notifications.GET("/event-types", notificationHandler.GetEventTypes)
+			// 通知渠道
+			notifications.GET("/channels", notificationHandler.ListChannels)
+			notifications.POST("/channels", middleware.PlatformAdminRequired(), notificationHandler.CreateChannel)
+			notifications.POST("/channels/test", middleware.PlatformAdminRequired(), notificationHandler.TestChannel) // 保存前测试
+			notifications.GET("/channels/:id", notificationHandler.GetChannel)
+			notifications.PUT("/channels/:id", middleware.PlatformAdminRequired(), notificationHandler.UpdateChannel)
+			notifications.DELETE("/channels/:id", middleware.PlatformAdminRequired(), notificationHandler.DeleteChannel)
+			notifications.POST("/channels/:id/test", middleware.PlatformAdminRequired(), notificationHandler.TestChannel)
+			// 订阅
+			notifications.GET("/subscriptions", notificationHandler.ListSubscriptions)
+			notifications.POST("/subscriptions", notificationHandler.CreateSubscription)
+			notifications.PUT("/subscriptions/:id", notificationHandler.UpdateSubscription)
+			notifications.DELETE("/subscriptions/:id", notificationHandler.DeleteSubscription)
+			// 投递记录
+			notifications.GET("/deliveries", notificationHandler.ListDeliveries)
+			notifications.POST("/deliveries/:id/retry", notificationHandler.RetryDelivery)
+		}
+
+		// 告警 MTTA / MTTR 跨集群统计（按集群或团队）
+		protected.GET("/alert-history/metrics", alertHistoryHandler.GetMetrics)
+
 		// permissions - 权限管理
 		globalRbacSvc := services.NewRBACService()
 		permissionHandler := handlers.NewPermissionHandler(permissionSvc, clusterSvc, globalRbacSvc)
@@ -559,7 +884,7 @@ func Setup(db *gorm.DB, cfg *config.Config) *gin.Engine {
 		podTerminal := handlers.NewPodTerminalHandler(clusterSvc, auditSvc)
 		kubectlPod := handlers.NewKubectlPodTerminalHandler(clusterSvc, auditSvc)
 		podHandler := handlers.NewPodHandler(db, cfg, clusterSvc, k8sMgr)
-		logCenterHandler := handlers.NewLogCenterHandler(clusterSvc, k8sMgr)
+		logCenterHandler := handlers.NewLogCenterHandler(clusterSvc, k8sMgr, logConfigSvc, logParseRuleSvc, logExportSvc)
 
 		// 节点 SSH 终端（不需要集群权限检查）
 		ws.GET("/ssh/terminal", ssh.SSHConnect)
@@ -0,0 +1,782 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+	"github.com/clay-wangzhi/KubePolaris/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// alertHistoryFetchTimeout 单个集群拉取告警的超时
+	alertHistoryFetchTimeout = 30 * time.Second
+	// alertHistoryCleanupInterval 过期历史清理周期
+	alertHistoryCleanupInterval = 24 * time.Hour
+	// alertFlapThreshold 触发后在该时长内恢复视为抖动
+	alertFlapThreshold = 5 * time.Minute
+)
+
+// AlertHistoryOptions 告警历史参数
+type AlertHistoryOptions struct {
+	PollInterval time.Duration // 拉取 Alertmanager 的周期，0 表示只通过 webhook 接收
+	TeamLabel    string        // 团队标签
+	Retention    time.Duration // 历史保留时长
+}
+
+// AlertHistoryService 告警历史：通过拉取 Alertmanager 或接收 webhook 记录告警的触发、恢复与静默，
+// 并提供历史检索、规则噪音排名、MTTA/MTTR 统计以及确认、指派流程
+type AlertHistoryService struct {
+	db                    *gorm.DB
+	clusterService        *ClusterService
+	alertManagerConfigSvc *AlertManagerConfigService
+	alertManagerSvc       *AlertManagerService
+	opts                  AlertHistoryOptions
+	now                   func() time.Time
+}
+
+// NewAlertHistoryService 创建告警历史服务
+func NewAlertHistoryService(db *gorm.DB, clusterService *ClusterService, alertManagerConfigSvc *AlertManagerConfigService, alertManagerSvc *AlertManagerService, opts AlertHistoryOptions) *AlertHistoryService {
+	if opts.TeamLabel == "" {
+		opts.TeamLabel = "team"
+	}
+	if opts.Retention <= 0 {
+		opts.Retention = 90 * 24 * time.Hour
+	}
+	return &AlertHistoryService{
+		db:                    db,
+		clusterService:        clusterService,
+		alertManagerConfigSvc: alertManagerConfigSvc,
+		alertManagerSvc:       alertManagerSvc,
+		opts:                  opts,
+		now:                   time.Now,
+	}
+}
+
+// Start 启动告警拉取与过期历史清理，ctx 取消后退出
+func (s *AlertHistoryService) Start(ctx context.Context) {
+	go func() {
+		interval := s.opts.PollInterval
+		if interval <= 0 {
+			interval = alertHistoryCleanupInterval
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		var lastCleanup time.Time
+		for {
+			if s.opts.PollInterval > 0 {
+				s.PollAll(ctx)
+			}
+			if s.now().Sub(lastCleanup) >= alertHistoryCleanupInterval {
+				s.cleanup()
+				lastCleanup = s.now()
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	logger.Info("告警历史记录器已启动", "poll_interval", s.opts.PollInterval)
+}
+
+// ========== 采集 ==========
+
+// alertObservation 一次观察到的告警状态，来自 v2 API 或 webhook
+type alertObservation struct {
+	Fingerprint  string
+	Labels       map[string]string
+	Annotations  map[string]string
+	StartsAt     time.Time
+	EndsAt       time.Time
+	GeneratorURL string
+	Resolved     bool
+	Silenced     bool
+}
+
+// PollAll 拉取所有启用 Alertmanager 的集群的当前告警；拉取失败的集群本轮不做任何变更
+func (s *AlertHistoryService) PollAll(ctx context.Context) {
+	clusters, err := s.clusterService.GetAllClusters()
+	if err != nil {
+		logger.Error("告警历史：获取集群列表失败", "error", err)
+		return
+	}
+	for _, cluster := range clusters {
+		if ctx.Err() != nil {
+			return
+		}
+		config, err := s.alertManagerConfigSvc.GetAlertManagerConfig(cluster.ID)
+		if err != nil || !config.Enabled {
+			continue
+		}
+		fetchCtx, cancel := context.WithTimeout(ctx, alertHistoryFetchTimeout)
+		alerts, err := s.alertManagerSvc.GetAlerts(fetchCtx, config, nil)
+		cancel()
+		if err != nil {
+			logger.Warn("告警历史：拉取告警失败", "cluster", cluster.Name, "error", err)
+			continue
+		}
+		observations := make([]alertObservation, 0, len(alerts))
+		for _, a := range alerts {
+			observations = append(observations, alertObservation{
+				Fingerprint:  a.Fingerprint,
+				Labels:       a.Labels,
+				Annotations:  a.Annotations,
+				StartsAt:     a.StartsAt,
+				EndsAt:       a.EndsAt,
+				GeneratorURL: a.GeneratorURL,
+				Resolved:     a.Status.State == "resolved",
+				Silenced:     len(a.Status.SilencedBy) > 0,
+			})
+		}
+		if err := s.ingest(cluster.ID, observations, true, models.AlertSourcePoller); err != nil {
+			logger.Error("告警历史：记录告警失败", "cluster", cluster.Name, "error", err)
+		}
+	}
+}
+
+// IngestWebhook 记录 Alertmanager webhook 推送的告警。webhook 只包含本次通知的告警分组，
+// 不会据此判断其他告警已恢复；静默状态由拉取补充
+func (s *AlertHistoryService) IngestWebhook(clusterID uint, payload *models.AlertmanagerWebhookPayload) (int, error) {
+	if _, err := s.clusterService.GetCluster(clusterID); err != nil {
+		return 0, fmt.Errorf("集群不存在: %d", clusterID)
+	}
+	observations := make([]alertObservation, 0, len(payload.Alerts))
+	for _, a := range payload.Alerts {
+		fingerprint := a.Fingerprint
+		if fingerprint == "" {
+			fingerprint = alertLabelsFingerprint(a.Labels)
+		}
+		observations = append(observations, alertObservation{
+			Fingerprint:  fingerprint,
+			Labels:       a.Labels,
+			Annotations:  a.Annotations,
+			StartsAt:     a.StartsAt,
+			EndsAt:       a.EndsAt,
+			GeneratorURL: a.GeneratorURL,
+			Resolved:     a.Status == models.AlertRecordResolved,
+		})
+	}
+	if err := s.ingest(clusterID, observations, false, models.AlertSourceWebhook); err != nil {
+		return 0, err
+	}
+	return len(observations), nil
+}
+
+// ingest 将观察结果与已有记录合并并持久化；complete 表示观察结果是 Alertmanager 中告警的全集
+func (s *AlertHistoryService) ingest(clusterID uint, observations []alertObservation, complete bool, source string) error {
+	fingerprints := make([]string, 0, len(observations))
+	for _, o := range observations {
+		fingerprints = append(fingerprints, o.Fingerprint)
+	}
+
+	var existing []models.AlertRecord
+	query := s.db.Where("cluster_id = ?", clusterID)
+	if len(fingerprints) > 0 {
+		query = query.Where("status = ? OR fingerprint IN ?", models.AlertRecordFiring, fingerprints)
+	} else {
+		query = query.Where("status = ?", models.AlertRecordFiring)
+	}
+	if err := query.Find(&existing).Error; err != nil {
+		return fmt.Errorf("查询告警记录失败: %w", err)
+	}
+
+	changes := planAlertHistory(clusterID, existing, observations, complete, s.opts.TeamLabel, s.now())
+	if len(changes) == 0 {
+		return nil
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		for _, change := range changes {
+			if err := tx.Save(change.record).Error; err != nil {
+				return fmt.Errorf("保存告警记录失败: %w", err)
+			}
+			for i := range change.events {
+				change.events[i].AlertID = change.record.ID
+				change.events[i].Source = source
+			}
+			if len(change.events) > 0 {
+				if err := tx.Create(&change.events).Error; err != nil {
+					return fmt.Errorf("保存告警事件失败: %w", err)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// alertHistoryChange 一条告警记录的变更及其产生的生命周期事件
+type alertHistoryChange struct {
+	record *models.AlertRecord
+	events []models.AlertHistoryEvent
+}
+
+// planAlertHistory 根据观察结果计算告警记录的变更：
+//   - 指纹与触发时间都相同视为同一次触发，更新最近出现时间、静默状态，已恢复的记录重新触发；
+//   - 同一指纹出现更晚的触发时间时，旧记录按新触发时间恢复并新建记录；
+//   - complete 为 true 时，未出现在观察结果中的触发中记录视为已恢复
+func planAlertHistory(clusterID uint, existing []models.AlertRecord, observations []alertObservation, complete bool, teamLabel string, now time.Time) []*alertHistoryChange {
+	latest := make(map[string]*models.AlertRecord, len(existing))
+	for i := range existing {
+		rec := &existing[i]
+		if cur, ok := latest[rec.Fingerprint]; !ok || rec.StartsAt.After(cur.StartsAt) {
+			latest[rec.Fingerprint] = rec
+		}
+	}
+
+	changes := make(map[*models.AlertRecord]*alertHistoryChange)
+	var order []*models.AlertRecord
+	touch := func(rec *models.AlertRecord, eventType, message string, at time.Time) {
+		change, ok := changes[rec]
+		if !ok {
+			change = &alertHistoryChange{record: rec}
+			changes[rec] = change
+			order = append(order, rec)
+		}
+		if eventType != "" {
+			change.events = append(change.events, models.AlertHistoryEvent{
+				ClusterID: clusterID,
+				Type:      eventType,
+				Message:   message,
+				CreatedAt: at,
+			})
+		}
+	}
+	resolve := func(rec *models.AlertRecord, at time.Time, message string) {
+		if at.IsZero() || at.After(now) || at.Before(rec.StartsAt) {
+			at = now
+		}
+		rec.Status = models.AlertRecordResolved
+		rec.ResolvedAt = &at
+		touch(rec, models.AlertEventResolved, message, at)
+	}
+
+	seen := make(map[*models.AlertRecord]bool)
+	for _, o := range observations {
+		if o.Fingerprint == "" {
+			continue
+		}
+		if o.StartsAt.IsZero() {
+			o.StartsAt = now
+		}
+		rec := latest[o.Fingerprint]
+
+		switch {
+		case rec != nil && alertSameStart(rec.StartsAt, o.StartsAt):
+			// 同一次触发
+		case rec != nil && o.StartsAt.Before(rec.StartsAt):
+			// 比已记录的触发更早，乱序推送，忽略
+			continue
+		default:
+			if rec != nil && rec.Status == models.AlertRecordFiring {
+				resolve(rec, o.StartsAt, "同一告警重新触发，前一次触发视为已恢复")
+			}
+			rec = newAlertRecord(clusterID, &o, teamLabel)
+			latest[o.Fingerprint] = rec
+			touch(rec, models.AlertEventFiring, rec.Summary, o.StartsAt)
+		}
+
+		seen[rec] = true
+		touch(rec, "", "", now)
+		rec.LastSeenAt = now
+		rec.Labels = alertMapJSON(o.Labels)
+		rec.Annotations = alertMapJSON(o.Annotations)
+		if summary := alertSummary(o.Annotations); summary != "" {
+			rec.Summary = summary
+		}
+
+		if o.Resolved {
+			if rec.Status == models.AlertRecordFiring {
+				resolve(rec, o.EndsAt, "")
+			}
+			continue
+		}
+		if rec.Status == models.AlertRecordResolved {
+			rec.Status = models.AlertRecordFiring
+			rec.ResolvedAt = nil
+			touch(rec, models.AlertEventFiring, "告警恢复后再次触发", now)
+		}
+		// webhook 不携带静默状态，只有拉取结果会更新
+		if complete && o.Silenced != rec.Silenced {
+			rec.Silenced = o.Silenced
+			if o.Silenced {
+				touch(rec, models.AlertEventSilenced, "", now)
+			} else {
+				touch(rec, models.AlertEventUnsilenced, "", now)
+			}
+		}
+	}
+
+	if complete {
+		for i := range existing {
+			rec := &existing[i]
+			if rec.Status == models.AlertRecordFiring && !seen[rec] {
+				resolve(rec, now, "Alertmanager 中已不存在该告警")
+			}
+		}
+	}
+
+	result := make([]*alertHistoryChange, 0, len(order))
+	for _, rec := range order {
+		result = append(result, changes[rec])
+	}
+	return result
+}
+
+func newAlertRecord(clusterID uint, o *alertObservation, teamLabel string) *models.AlertRecord {
+	return &models.AlertRecord{
+		ClusterID:    clusterID,
+		Fingerprint:  o.Fingerprint,
+		AlertName:    o.Labels["alertname"],
+		Severity:     o.Labels["severity"],
+		Namespace:    o.Labels["namespace"],
+		Team:         o.Labels[teamLabel],
+		Summary:      alertSummary(o.Annotations),
+		Status:       models.AlertRecordFiring,
+		StartsAt:     o.StartsAt,
+		GeneratorURL: truncateAlertText(o.GeneratorURL, 1000),
+	}
+}
+
+// alertSameStart 数据库中的时间精度可能低于 Alertmanager，按秒比较
+func alertSameStart(a, b time.Time) bool {
+	d := a.Sub(b)
+	return d < time.Second && d > -time.Second
+}
+
+func alertSummary(annotations map[string]string) string {
+	for _, key := range []string{"summary", "message", "description"} {
+		if v := annotations[key]; v != "" {
+			return truncateAlertText(v, 500)
+		}
+	}
+	return ""
+}
+
+// truncateAlertText 按字符截断，避免超出列长度
+func truncateAlertText(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit-3]) + "..."
+}
+
+func roundTo(v float64, digits int) float64 {
+	p := math.Pow(10, float64(digits))
+	return math.Round(v*p) / p
+}
+
+func alertMapJSON(m map[string]string) string {
+	if len(m) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(m)
+	return string(data)
+}
+
+// alertLabelsFingerprint webhook 缺少 fingerprint 时（旧版本 Alertmanager）按标签集计算
+func alertLabelsFingerprint(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	// FNV-1a 64
+	var h uint64 = 14695981039346656037
+	for _, k := range keys {
+		for _, part := range []string{k, "\xff", labels[k], "\xff"} {
+			for i := 0; i < len(part); i++ {
+				h ^= uint64(part[i])
+				h *= 1099511628211
+			}
+		}
+	}
+	return fmt.Sprintf("%016x", h)
+}
+
+// ========== 查询 ==========
+
+// List 分页检索告警历史
+func (s *AlertHistoryService) List(q *models.AlertHistoryQuery) (*models.AlertHistoryListResponse, error) {
+	if q.Page <= 0 {
+		q.Page = 1
+	}
+	if q.PageSize <= 0 || q.PageSize > 200 {
+		q.PageSize = 20
+	}
+
+	query := s.db.Model(&models.AlertRecord{}).Where("cluster_id = ?", q.ClusterID)
+	if q.AlertName != "" {
+		query = query.Where("alert_name = ?", q.AlertName)
+	}
+	if q.Severity != "" {
+		query = query.Where("severity = ?", q.Severity)
+	}
+	if q.Status != "" {
+		query = query.Where("status = ?", q.Status)
+	}
+	if q.Team != "" {
+		query = query.Where("team = ?", q.Team)
+	}
+	if q.Namespace != "" {
+		query = query.Where("namespace = ?", q.Namespace)
+	}
+	if q.Keyword != "" {
+		like := "%" + q.Keyword + "%"
+		query = query.Where("alert_name LIKE ? OR summary LIKE ? OR labels LIKE ?", like, like, like)
+	}
+	if q.Acknowledged != nil {
+		if *q.Acknowledged {
+			query = query.Where("acknowledged_at IS NOT NULL")
+		} else {
+			query = query.Where("acknowledged_at IS NULL")
+		}
+	}
+	if q.AssigneeID != 0 {
+		query = query.Where("assignee_id = ?", q.AssigneeID)
+	}
+	if !q.StartTime.IsZero() {
+		query = query.Where("starts_at >= ?", q.StartTime)
+	}
+	if !q.EndTime.IsZero() {
+		query = query.Where("starts_at <= ?", q.EndTime)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("查询告警历史失败: %w", err)
+	}
+	var items []models.AlertRecord
+	if err := query.Order("starts_at DESC, id DESC").Offset((q.Page - 1) * q.PageSize).Limit(q.PageSize).Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("查询告警历史失败: %w", err)
+	}
+	for i := range items {
+		decodeAlertRecord(&items[i])
+	}
+	return &models.AlertHistoryListResponse{Items: items, Total: total, Page: q.Page, PageSize: q.PageSize}, nil
+}
+
+// Get 获取告警记录及其生命周期事件
+func (s *AlertHistoryService) Get(clusterID, id uint) (*models.AlertRecord, error) {
+	rec, err := s.getRecord(clusterID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Where("alert_id = ?", rec.ID).Order("created_at ASC, id ASC").Find(&rec.Events).Error; err != nil {
+		return nil, fmt.Errorf("查询告警事件失败: %w", err)
+	}
+	decodeAlertRecord(rec)
+	return rec, nil
+}
+
+func (s *AlertHistoryService) getRecord(clusterID, id uint) (*models.AlertRecord, error) {
+	var rec models.AlertRecord
+	if err := s.db.Where("cluster_id = ?", clusterID).First(&rec, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("告警记录不存在: %d", id)
+		}
+		return nil, fmt.Errorf("查询告警记录失败: %w", err)
+	}
+	return &rec, nil
+}
+
+func decodeAlertRecord(rec *models.AlertRecord) {
+	if rec.Labels != "" {
+		_ = json.Unmarshal([]byte(rec.Labels), &rec.LabelMap)
+	}
+	if rec.Annotations != "" {
+		_ = json.Unmarshal([]byte(rec.Annotations), &rec.AnnotationMap)
+	}
+}
+
+// ========== 确认与指派 ==========
+
+// Acknowledge 确认告警，确认时间用于计算 MTTA
+func (s *AlertHistoryService) Acknowledge(clusterID, id uint, operator, note string) (*models.AlertRecord, error) {
+	rec, err := s.getRecord(clusterID, id)
+	if err != nil {
+		return nil, err
+	}
+	if rec.AcknowledgedAt != nil {
+		return nil, fmt.Errorf("告警已由 %s 确认", rec.AcknowledgedBy)
+	}
+	if rec.Status == models.AlertRecordResolved {
+		return nil, fmt.Errorf("告警已恢复，无需确认")
+	}
+
+	now := s.now()
+	rec.AcknowledgedAt = &now
+	rec.AcknowledgedBy = operator
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(rec).Updates(map[string]interface{}{"acknowledged_at": now, "acknowledged_by": operator}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.AlertHistoryEvent{
+			AlertID: rec.ID, ClusterID: clusterID, Type: models.AlertEventAcknowledged,
+			Operator: operator, Message: truncateAlertText(note, 1000), CreatedAt: now,
+		}).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("确认告警失败: %w", err)
+	}
+	decodeAlertRecord(rec)
+	return rec, nil
+}
+
+// Assign 指派告警处理人，assigneeID 为 0 时取消指派
+func (s *AlertHistoryService) Assign(clusterID, id, assigneeID uint, operator, note string) (*models.AlertRecord, error) {
+	rec, err := s.getRecord(clusterID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	message := "取消指派"
+	updates := map[string]interface{}{"assignee_id": nil, "assignee": ""}
+	rec.AssigneeID, rec.Assignee = nil, ""
+	if assigneeID != 0 {
+		var user models.User
+		if err := s.db.Select("id", "username", "display_name").First(&user, assigneeID).Error; err != nil {
+			return nil, fmt.Errorf("用户不存在: %d", assigneeID)
+		}
+		name := user.Username
+		if user.DisplayName != "" {
+			name = user.DisplayName
+		}
+		rec.AssigneeID, rec.Assignee = &user.ID, name
+		updates = map[string]interface{}{"assignee_id": user.ID, "assignee": name}
+		message = "指派给 " + name
+	}
+	if note != "" {
+		message += "：" + note
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(rec).Updates(updates).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.AlertHistoryEvent{
+			AlertID: rec.ID, ClusterID: clusterID, Type: models.AlertEventAssigned,
+			Operator: operator, Message: truncateAlertText(message, 1000), CreatedAt: s.now(),
+		}).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("指派告警失败: %w", err)
+	}
+	decodeAlertRecord(rec)
+	return rec, nil
+}
+
+// ========== 统计 ==========
+
+// NoiseRanking 统计区间内各告警规则的噪音排名
+func (s *AlertHistoryService) NoiseRanking(clusterID uint, since time.Time, limit int) ([]models.AlertNoiseItem, error) {
+	var records []models.AlertRecord
+	if err := s.db.Select("id", "cluster_id", "fingerprint", "alert_name", "severity", "status", "starts_at", "resolved_at", "acknowledged_at").
+		Where("cluster_id = ? AND starts_at >= ?", clusterID, since).Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("查询告警历史失败: %w", err)
+	}
+	items := aggregateAlertNoise(records, since, s.now())
+	if limit > 0 && len(items) > limit {
+		items = items[:limit]
+	}
+	return items, nil
+}
+
+// aggregateAlertNoise 按集群与告警名聚合噪音指标。
+// 噪音分 = (触发次数 + 2 × 抖动次数) × (1 - 确认率 / 2)：频繁触发、很快自行恢复且无人处理的规则排在前面
+func aggregateAlertNoise(records []models.AlertRecord, since, now time.Time) []models.AlertNoiseItem {
+	type key struct {
+		clusterID uint
+		name      string
+	}
+	type acc struct {
+		item         models.AlertNoiseItem
+		fingerprints map[string]bool
+		acked        int
+		resolved     int
+		resolvedDur  float64
+	}
+	groups := make(map[key]*acc)
+	for _, rec := range records {
+		k := key{rec.ClusterID, rec.AlertName}
+		g, ok := groups[k]
+		if !ok {
+			g = &acc{
+				item:         models.AlertNoiseItem{ClusterID: rec.ClusterID, AlertName: rec.AlertName, Severity: rec.Severity},
+				fingerprints: make(map[string]bool),
+			}
+			groups[k] = g
+		}
+		g.item.Firings++
+		g.fingerprints[rec.Fingerprint] = true
+		if rec.AcknowledgedAt != nil {
+			g.acked++
+		}
+
+		end := now
+		if rec.ResolvedAt != nil {
+			end = *rec.ResolvedAt
+			d := end.Sub(rec.StartsAt)
+			g.resolved++
+			g.resolvedDur += d.Seconds()
+			if d < alertFlapThreshold {
+				g.item.Flapping++
+			}
+		}
+		start := rec.StartsAt
+		if start.Before(since) {
+			start = since
+		}
+		if end.After(start) {
+			g.item.TotalDurationSec += end.Sub(start).Seconds()
+		}
+	}
+
+	items := make([]models.AlertNoiseItem, 0, len(groups))
+	for _, g := range groups {
+		item := g.item
+		item.Instances = len(g.fingerprints)
+		item.AckRate = roundTo(float64(g.acked)/float64(item.Firings), 4)
+		if g.resolved > 0 {
+			item.AvgDurationSec = roundTo(g.resolvedDur/float64(g.resolved), 1)
+		}
+		item.TotalDurationSec = roundTo(item.TotalDurationSec, 1)
+		item.NoiseScore = roundTo(float64(item.Firings+2*item.Flapping)*(1-item.AckRate/2), 2)
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].NoiseScore != items[j].NoiseScore {
+			return items[i].NoiseScore > items[j].NoiseScore
+		}
+		if items[i].Firings != items[j].Firings {
+			return items[i].Firings > items[j].Firings
+		}
+		if items[i].AlertName != items[j].AlertName {
+			return items[i].AlertName < items[j].AlertName
+		}
+		return items[i].ClusterID < items[j].ClusterID
+	})
+	return items
+}
+
+// Metrics 按集群（groupBy=cluster）或团队（groupBy=team）统计 MTTA / MTTR；clusterIDs 为空表示所有集群
+func (s *AlertHistoryService) Metrics(groupBy string, clusterIDs []uint, since time.Time) ([]models.AlertMetricsItem, error) {
+	if groupBy != "cluster" && groupBy != "team" {
+		return nil, fmt.Errorf("不支持的分组方式: %s", groupBy)
+	}
+	query := s.db.Select("id", "cluster_id", "team", "starts_at", "resolved_at", "acknowledged_at").
+		Where("starts_at >= ?", since)
+	if len(clusterIDs) > 0 {
+		query = query.Where("cluster_id IN ?", clusterIDs)
+	}
+	var records []models.AlertRecord
+	if err := query.Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("查询告警历史失败: %w", err)
+	}
+
+	if groupBy == "team" {
+		return computeAlertMetrics(records, func(rec *models.AlertRecord) (string, string) {
+			if rec.Team == "" {
+				return "", "未标记团队"
+			}
+			return rec.Team, rec.Team
+		}), nil
+	}
+
+	clusterNames := make(map[uint]string)
+	if clusters, err := s.clusterService.GetAllClusters(); err == nil {
+		for _, cl := range clusters {
+			clusterNames[cl.ID] = cl.Name
+		}
+	}
+	return computeAlertMetrics(records, func(rec *models.AlertRecord) (string, string) {
+		name := clusterNames[rec.ClusterID]
+		if name == "" {
+			name = fmt.Sprintf("集群 %d", rec.ClusterID)
+		}
+		return strconv.FormatUint(uint64(rec.ClusterID), 10), name
+	}), nil
+}
+
+// computeAlertMetrics 按分组计算平均确认时长（MTTA）与平均恢复时长（MTTR），只统计已确认 / 已恢复的告警
+func computeAlertMetrics(records []models.AlertRecord, group func(*models.AlertRecord) (key, name string)) []models.AlertMetricsItem {
+	type acc struct {
+		item     models.AlertMetricsItem
+		ackSum   float64
+		resolved float64
+	}
+	groups := make(map[string]*acc)
+	for i := range records {
+		rec := &records[i]
+		k, name := group(rec)
+		g, ok := groups[k]
+		if !ok {
+			g = &acc{item: models.AlertMetricsItem{Key: k, Name: name}}
+			groups[k] = g
+		}
+		g.item.Total++
+		if rec.AcknowledgedAt != nil {
+			g.item.Acknowledged++
+			g.ackSum += nonNegativeSeconds(rec.AcknowledgedAt.Sub(rec.StartsAt))
+		}
+		if rec.ResolvedAt != nil {
+			g.item.Resolved++
+			g.resolved += nonNegativeSeconds(rec.ResolvedAt.Sub(rec.StartsAt))
+		}
+	}
+
+	items := make([]models.AlertMetricsItem, 0, len(groups))
+	for _, g := range groups {
+		item := g.item
+		if item.Acknowledged > 0 {
+			item.MTTASec = roundTo(g.ackSum/float64(item.Acknowledged), 1)
+		}
+		if item.Resolved > 0 {
+			item.MTTRSec = roundTo(g.resolved/float64(item.Resolved), 1)
+		}
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Total != items[j].Total {
+			return items[i].Total > items[j].Total
+		}
+		return items[i].Key < items[j].Key
+	})
+	return items
+}
+
+func nonNegativeSeconds(d time.Duration) float64 {
+	if d < 0 {
+		return 0
+	}
+	return d.Seconds()
+}
+
+// ========== 清理 ==========
+
+// cleanup 删除超过保留期且已恢复的告警记录及其事件
+func (s *AlertHistoryService) cleanup() {
+	cutoff := s.now().Add(-s.opts.Retention)
+	expired := s.db.Model(&models.AlertRecord{}).Select("id").
+		Where("status = ? AND resolved_at < ?", models.AlertRecordResolved, cutoff)
+	if err := s.db.Where("alert_id IN (?)", expired).Delete(&models.AlertHistoryEvent{}).Error; err != nil {
+		logger.Error("清理告警事件失败", "error", err)
+		return
+	}
+	result := s.db.Where("status = ? AND resolved_at < ?", models.AlertRecordResolved, cutoff).Delete(&models.AlertRecord{})
+	if result.Error != nil {
+		logger.Error("清理告警历史失败", "error", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		logger.Info("已清理过期告警历史", "count", result.RowsAffected)
+	}
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// AlertHistoryServiceTestSuite 定义告警历史测试套件
+type AlertHistoryServiceTestSuite struct {
+	suite.Suite
+	now time.Time
+}
+
+// SetupTest 每个测试前的设置
+func (s *AlertHistoryServiceTestSuite) SetupTest() {
+	s.now = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+}
+
+func (s *AlertHistoryServiceTestSuite) observation(fp string, startsAgo time.Duration) alertObservation {
+	return alertObservation{
+		Fingerprint: fp,
+		Labels:      map[string]string{"alertname": "HighCPU", "severity": "warning", "namespace": "payments", "team": "pay"},
+		Annotations: map[string]string{"summary": "CPU 使用率过高"},
+		StartsAt:    s.now.Add(-startsAgo),
+	}
+}
+
+func eventTypes(change *alertHistoryChange) []string {
+	types := make([]string, 0, len(change.events))
+	for _, e := range change.events {
+		types = append(types, e.Type)
+	}
+	return types
+}
+
+// TestPlanNewAndUnchanged 测试新告警创建记录，重复观察只更新最近出现时间
+func (s *AlertHistoryServiceTestSuite) TestPlanNewAndUnchanged() {
+	changes := planAlertHistory(1, nil, []alertObservation{s.observation("a1", time.Minute)}, true, "team", s.now)
+	s.Require().Len(changes, 1)
+	rec := changes[0].record
+	assert.Equal(s.T(), "HighCPU", rec.AlertName)
+	assert.Equal(s.T(), "pay", rec.Team)
+	assert.Equal(s.T(), "CPU 使用率过高", rec.Summary)
+	assert.Equal(s.T(), models.AlertRecordFiring, rec.Status)
+	assert.Equal(s.T(), []string{models.AlertEventFiring}, eventTypes(changes[0]))
+
+	rec.ID = 10
+	existing := []models.AlertRecord{*rec}
+	changes = planAlertHistory(1, existing, []alertObservation{s.observation("a1", time.Minute)}, true, "team", s.now.Add(time.Minute))
+	s.Require().Len(changes, 1)
+	assert.Equal(s.T(), uint(10), changes[0].record.ID)
+	assert.Empty(s.T(), changes[0].events)
+	assert.Equal(s.T(), s.now.Add(time.Minute), changes[0].record.LastSeenAt)
+}
+
+// TestPlanResolveMissing 测试完整拉取时消失的告警视为恢复，webhook 推送不做此判断
+func (s *AlertHistoryServiceTestSuite) TestPlanResolveMissing() {
+	existing := []models.AlertRecord{{ID: 1, ClusterID: 1, Fingerprint: "a1", Status: models.AlertRecordFiring, StartsAt: s.now.Add(-time.Hour)}}
+
+	assert.Empty(s.T(), planAlertHistory(1, existing, nil, false, "team", s.now))
+
+	changes := planAlertHistory(1, existing, nil, true, "team", s.now)
+	s.Require().Len(changes, 1)
+	assert.Equal(s.T(), models.AlertRecordResolved, changes[0].record.Status)
+	assert.Equal(s.T(), s.now, *changes[0].record.ResolvedAt)
+	assert.Equal(s.T(), []string{models.AlertEventResolved}, eventTypes(changes[0]))
+}
+
+// TestPlanWebhookResolved 测试 webhook 恢复通知使用 endsAt 作为恢复时间
+func (s *AlertHistoryServiceTestSuite) TestPlanWebhookResolved() {
+	existing := []models.AlertRecord{{ID: 1, Fingerprint: "a1", Status: models.AlertRecordFiring, StartsAt: s.now.Add(-time.Hour)}}
+	o := s.observation("a1", time.Hour)
+	o.Resolved = true
+	o.EndsAt = s.now.Add(-10 * time.Minute)
+
+	changes := planAlertHistory(1, existing, []alertObservation{o}, false, "team", s.now)
+	s.Require().Len(changes, 1)
+	assert.Equal(s.T(), o.EndsAt, *changes[0].record.ResolvedAt)
+
+	// 重复推送的恢复通知不再产生事件
+	changes = planAlertHistory(1, []models.AlertRecord{*changes[0].record}, []alertObservation{o}, false, "team", s.now)
+	s.Require().Len(changes, 1)
+	assert.Empty(s.T(), changes[0].events)
+
+	// 未记录过触发的恢复通知同时记录触发与恢复
+	changes = planAlertHistory(1, nil, []alertObservation{o}, false, "team", s.now)
+	s.Require().Len(changes, 1)
+	assert.Equal(s.T(), []string{models.AlertEventFiring, models.AlertEventResolved}, eventTypes(changes[0]))
+}
+
+// TestPlanRefire 测试同一指纹以新的触发时间再次出现时，旧记录恢复并新建记录
+func (s *AlertHistoryServiceTestSuite) TestPlanRefire() {
+	existing := []models.AlertRecord{{ID: 1, Fingerprint: "a1", Status: models.AlertRecordFiring, StartsAt: s.now.Add(-time.Hour)}}
+	changes := planAlertHistory(1, existing, []alertObservation{s.observation("a1", 5*time.Minute)}, true, "team", s.now)
+	s.Require().Len(changes, 2)
+	assert.Equal(s.T(), uint(1), changes[0].record.ID)
+	assert.Equal(s.T(), s.now.Add(-5*time.Minute), *changes[0].record.ResolvedAt)
+	assert.Equal(s.T(), uint(0), changes[1].record.ID)
+	assert.Equal(s.T(), models.AlertRecordFiring, changes[1].record.Status)
+
+	// 早于已记录触发时间的乱序推送被忽略
+	assert.Empty(s.T(), planAlertHistory(1, existing, []alertObservation{s.observation("a1", 2*time.Hour)}, false, "team", s.now))
+}
+
+// TestPlanSilence 测试静默状态变化只由完整拉取记录
+func (s *AlertHistoryServiceTestSuite) TestPlanSilence() {
+	existing := []models.AlertRecord{{ID: 1, Fingerprint: "a1", Status: models.AlertRecordFiring, StartsAt: s.now.Add(-time.Hour)}}
+	o := s.observation("a1", time.Hour)
+	o.Silenced = true
+
+	changes := planAlertHistory(1, existing, []alertObservation{o}, true, "team", s.now)
+	s.Require().Len(changes, 1)
+	assert.True(s.T(), changes[0].record.Silenced)
+	assert.Equal(s.T(), []string{models.AlertEventSilenced}, eventTypes(changes[0]))
+
+	o.Silenced = false
+	changes = planAlertHistory(1, []models.AlertRecord{*changes[0].record}, []alertObservation{o}, true, "team", s.now)
+	assert.Equal(s.T(), []string{models.AlertEventUnsilenced}, eventTypes(changes[0]))
+
+	changes = planAlertHistory(1, []models.AlertRecord{*changes[0].record}, []alertObservation{o}, false, "team", s.now)
+	assert.Empty(s.T(), changes[0].events)
+}
+
+// TestLabelsFingerprint 测试按标签计算指纹与顺序无关
+func (s *AlertHistoryServiceTestSuite) TestLabelsFingerprint() {
+	a := alertLabelsFingerprint(map[string]string{"alertname": "HighCPU", "pod": "api-0"})
+	b := alertLabelsFingerprint(map[string]string{"pod": "api-0", "alertname": "HighCPU"})
+	c := alertLabelsFingerprint(map[string]string{"alertname": "HighCPU", "pod": "api-1"})
+	assert.Equal(s.T(), a, b)
+	assert.NotEqual(s.T(), a, c)
+	assert.Len(s.T(), a, 16)
+}
+
+// TestNoiseRanking 测试噪音排名：频繁抖动且无人确认的规则排在前面
+func (s *AlertHistoryServiceTestSuite) TestNoiseRanking() {
+	at := func(d time.Duration) *time.Time { t := s.now.Add(d); return &t }
+	since := s.now.Add(-24 * time.Hour)
+	records := []models.AlertRecord{
+		// Flappy：3 次触发，均在 1 分钟内恢复
+		{ClusterID: 1, AlertName: "Flappy", Fingerprint: "f1", StartsAt: s.now.Add(-3 * time.Hour), ResolvedAt: at(-3*time.Hour + time.Minute)},
+		{ClusterID: 1, AlertName: "Flappy", Fingerprint: "f1", StartsAt: s.now.Add(-2 * time.Hour), ResolvedAt: at(-2*time.Hour + time.Minute)},
+		{ClusterID: 1, AlertName: "Flappy", Fingerprint: "f2", StartsAt: s.now.Add(-time.Hour), ResolvedAt: at(-time.Hour + time.Minute)},
+		// DiskFull：2 次触发，都被确认，1 次仍在触发
+		{ClusterID: 1, AlertName: "DiskFull", Fingerprint: "d1", StartsAt: s.now.Add(-4 * time.Hour), ResolvedAt: at(-2 * time.Hour), AcknowledgedAt: at(-3 * time.Hour)},
+		{ClusterID: 1, AlertName: "DiskFull", Fingerprint: "d1", StartsAt: s.now.Add(-time.Hour), AcknowledgedAt: at(-30 * time.Minute)},
+	}
+
+	items := aggregateAlertNoise(records, since, s.now)
+	s.Require().Len(items, 2)
+	assert.Equal(s.T(), "Flappy", items[0].AlertName)
+	assert.Equal(s.T(), 3, items[0].Firings)
+	assert.Equal(s.T(), 2, items[0].Instances)
+	assert.Equal(s.T(), 3, items[0].Flapping)
+	assert.Equal(s.T(), 9.0, items[0].NoiseScore)
+	assert.Equal(s.T(), 60.0, items[0].AvgDurationSec)
+
+	assert.Equal(s.T(), "DiskFull", items[1].AlertName)
+	assert.Equal(s.T(), 1.0, items[1].AckRate)
+	assert.Equal(s.T(), 1.0, items[1].NoiseScore)
+	assert.Equal(s.T(), 7200.0, items[1].AvgDurationSec)
+	assert.Equal(s.T(), 3*3600.0, items[1].TotalDurationSec)
+}
+
+// TestComputeMetrics 测试按团队计算 MTTA / MTTR
+func (s *AlertHistoryServiceTestSuite) TestComputeMetrics() {
+	at := func(d time.Duration) *time.Time { t := s.now.Add(d); return &t }
+	records := []models.AlertRecord{
+		{Team: "pay", StartsAt: s.now, AcknowledgedAt: at(2 * time.Minute), ResolvedAt: at(10 * time.Minute)},
+		{Team: "pay", StartsAt: s.now, AcknowledgedAt: at(4 * time.Minute), ResolvedAt: at(30 * time.Minute)},
+		{Team: "pay", StartsAt: s.now},
+		{Team: "", StartsAt: s.now, ResolvedAt: at(time.Minute)},
+	}
+	items := computeAlertMetrics(records, func(rec *models.AlertRecord) (string, string) { return rec.Team, rec.Team })
+	s.Require().Len(items, 2)
+	assert.Equal(s.T(), models.AlertMetricsItem{Key: "pay", Name: "pay", Total: 3, Acknowledged: 2, Resolved: 2, MTTASec: 180, MTTRSec: 1200}, items[0])
+	assert.Equal(s.T(), 60.0, items[1].MTTRSec)
+	assert.Equal(s.T(), 0.0, items[1].MTTASec)
+}
+
+// TestAlertHistoryServiceTestSuite 运行告警历史测试套件
+func TestAlertHistoryServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(AlertHistoryServiceTestSuite))
+}
@@ -0,0 +1,1059 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Alertmanager 路由参数默认值，与 Alertmanager 保持一致
+const (
+	alertManagerDefaultGroupWait      = "30s"
+	alertManagerDefaultGroupInterval  = "5m"
+	alertManagerDefaultRepeatInterval = "4h"
+	alertManagerGroupByAll            = "..."
+)
+
+var (
+	alertManagerMatcherPattern = regexp.MustCompile(`^\s*([a-zA-Z_][a-zA-Z0-9_]*)\s*(=~|!~|!=|=)\s*(.*?)\s*$`)
+	alertManagerDingTalkURL    = regexp.MustCompile(`/dingtalk/[^/]+/send/?$`)
+	alertManagerSecretKeyChars = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+	// alertManagerTopLevelKeys alertmanager.yaml 支持的顶层字段
+	alertManagerTopLevelKeys = map[string]bool{
+		"global": true, "route": true, "receivers": true, "inhibit_rules": true, "templates": true,
+		"time_intervals": true, "mute_time_intervals": true, "tracing": true,
+	}
+
+	// alertManagerReceiverTypes 支持编辑的通知类型，dingtalk_configs 保存时合并到 webhook_configs
+	alertManagerReceiverTypes = map[string]reflect.Type{
+		"webhook_configs":   reflect.TypeOf(models.AlertManagerWebhookConfig{}),
+		"email_configs":     reflect.TypeOf(models.AlertManagerEmailConfig{}),
+		"slack_configs":     reflect.TypeOf(models.AlertManagerSlackConfig{}),
+		"pagerduty_configs": reflect.TypeOf(models.AlertManagerPagerdutyConfig{}),
+		"wechat_configs":    reflect.TypeOf(models.AlertManagerWechatConfig{}),
+		"dingtalk_configs":  reflect.TypeOf(models.AlertManagerDingTalkConfig{}),
+	}
+
+	// alertManagerSecretFields 读取时需要脱敏的字段；AlertmanagerConfig CRD 中这些字段引用 Secret
+	alertManagerSecretFields = map[string][]string{
+		"email_configs":     {"auth_password"},
+		"slack_configs":     {"api_url"},
+		"pagerduty_configs": {"routing_key", "service_key"},
+		"wechat_configs":    {"api_secret"},
+	}
+
+	// alertManagerCRDKeys alertmanager.yaml 与 AlertmanagerConfig CRD 中命名不符合驼峰规则的字段
+	alertManagerCRDKeys = map[string]string{
+		"api_url":     "apiURL",
+		"api_secret":  "apiSecret",
+		"corp_id":     "corpID",
+		"agent_id":    "agentID",
+		"require_tls": "requireTLS",
+	}
+)
+
+// alertManagerMatcher 标签匹配器
+type alertManagerMatcher struct {
+	Name  string
+	Op    string // =, !=, =~, !~
+	Value string
+	re    *regexp.Regexp
+}
+
+// parseAlertManagerMatchers 解析 matchers 字符串，支持 name="value" 及 {a="b", c=~"d"} 形式
+func parseAlertManagerMatchers(s string) ([]alertManagerMatcher, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+		s = s[1 : len(s)-1]
+	}
+
+	var parts []string
+	var current strings.Builder
+	inQuote, escaped := false, false
+	for _, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && inQuote:
+			escaped = true
+		case r == '"':
+			inQuote = !inQuote
+		case r == ',' && !inQuote:
+			parts = append(parts, current.String())
+			current.Reset()
+			continue
+		}
+		current.WriteRune(r)
+	}
+	if inQuote {
+		return nil, fmt.Errorf("引号未闭合: %s", s)
+	}
+	parts = append(parts, current.String())
+
+	matchers := make([]alertManagerMatcher, 0, len(parts))
+	for _, part := range parts {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		m := alertManagerMatcherPattern.FindStringSubmatch(part)
+		if m == nil {
+			return nil, fmt.Errorf("匹配条件格式错误: %q（示例：severity=\"critical\"、team=~\"a|b\"）", strings.TrimSpace(part))
+		}
+		matcher := alertManagerMatcher{Name: m[1], Op: m[2], Value: m[3]}
+		if strings.HasPrefix(matcher.Value, `"`) {
+			value, err := strconv.Unquote(matcher.Value)
+			if err != nil {
+				return nil, fmt.Errorf("匹配值格式错误: %s", matcher.Value)
+			}
+			matcher.Value = value
+		} else if strings.ContainsAny(matcher.Value, `"=!~{}`) {
+			return nil, fmt.Errorf("包含特殊字符的匹配值需要加双引号: %s", matcher.Value)
+		}
+		if matcher.Op == "=~" || matcher.Op == "!~" {
+			re, err := regexp.Compile("^(?:" + matcher.Value + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("正则表达式不合法: %s", matcher.Value)
+			}
+			matcher.re = re
+		}
+		matchers = append(matchers, matcher)
+	}
+	if len(matchers) == 0 {
+		return nil, fmt.Errorf("匹配条件不能为空")
+	}
+	return matchers, nil
+}
+
+// Matches 判断标签是否满足匹配器，缺失的标签按空字符串处理
+func (m alertManagerMatcher) Matches(labels map[string]string) bool {
+	value := labels[m.Name]
+	switch m.Op {
+	case "=":
+		return value == m.Value
+	case "!=":
+		return value != m.Value
+	case "=~":
+		return m.re.MatchString(value)
+	default:
+		return !m.re.MatchString(value)
+	}
+}
+
+// String 格式化为 name=~"value" 形式
+func (m alertManagerMatcher) String() string {
+	return m.Name + m.Op + strconv.Quote(m.Value)
+}
+
+// ValidateAlertManagerRouting 校验路由配置，规则与 Alertmanager 加载配置时的检查一致。
+// global 为 alertmanager.yaml 的 global 段，为 nil 时（AlertmanagerConfig CRD）跳过依赖 global 默认值的必填检查；
+// timeIntervals 为可引用的时间区间名
+func ValidateAlertManagerRouting(kind string, cfg *models.AlertManagerRoutingConfig, global map[string]interface{}, timeIntervals []string) ([]models.AlertManagerConfigValidationError, []string) {
+	var errs []models.AlertManagerConfigValidationError
+	var warnings []string
+	add := func(path, format string, args ...interface{}) {
+		errs = append(errs, models.AlertManagerConfigValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	receivers := make(map[string]bool, len(cfg.Receivers))
+	for i, r := range cfg.Receivers {
+		path := fmt.Sprintf("receivers[%d]", i)
+		if r.Name == "" {
+			add(path+".name", "接收器名称不能为空")
+		} else if receivers[r.Name] {
+			add(path+".name", "接收器名称重复: %s", r.Name)
+		}
+		receivers[r.Name] = true
+		validateAlertManagerReceiver(path, &r, global, add)
+	}
+
+	intervals := make(map[string]bool, len(timeIntervals))
+	for _, name := range timeIntervals {
+		intervals[name] = true
+	}
+
+	used := make(map[string]bool)
+	if cfg.Route == nil {
+		add("route", "缺少根路由")
+	} else {
+		root := cfg.Route
+		if root.Receiver == "" {
+			add("route.receiver", "根路由必须指定接收器")
+		}
+		if kind != models.AlertManagerRoutingSourceCRD {
+			if len(root.Matchers) > 0 {
+				add("route.matchers", "根路由不能设置匹配条件")
+			}
+			if root.Continue {
+				add("route.continue", "根路由不能设置 continue")
+			}
+			if len(root.MuteTimeIntervals) > 0 || len(root.ActiveTimeIntervals) > 0 {
+				add("route", "根路由不能设置时间区间")
+			}
+		}
+		validateAlertManagerRoute("route", root, receivers, intervals, used, add)
+	}
+
+	for i, rule := range cfg.InhibitRules {
+		path := fmt.Sprintf("inhibit_rules[%d]", i)
+		for j, s := range rule.SourceMatchers {
+			if _, err := parseAlertManagerMatchers(s); err != nil {
+				add(fmt.Sprintf("%s.source_matchers[%d]", path, j), "%v", err)
+			}
+		}
+		for j, s := range rule.TargetMatchers {
+			if _, err := parseAlertManagerMatchers(s); err != nil {
+				add(fmt.Sprintf("%s.target_matchers[%d]", path, j), "%v", err)
+			}
+		}
+		for _, label := range rule.Equal {
+			if !promLabelNamePattern.MatchString(label) {
+				add(path+".equal", "标签名不合法: %s", label)
+			}
+		}
+		if len(rule.TargetMatchers) == 0 {
+			warnings = append(warnings, fmt.Sprintf("抑制规则 %d 未设置 target_matchers，将抑制所有满足 equal 条件的告警", i+1))
+		}
+	}
+
+	for _, r := range cfg.Receivers {
+		if r.Name != "" && !used[r.Name] {
+			warnings = append(warnings, fmt.Sprintf("接收器 %s 未被任何路由引用", r.Name))
+		}
+	}
+	return errs, warnings
+}
+
+func validateAlertManagerRoute(path string, route *models.AlertManagerRoute, receivers, intervals, used map[string]bool, add func(path, format string, args ...interface{})) {
+	if route.Receiver != "" {
+		if !receivers[route.Receiver] {
+			add(path+".receiver", "接收器不存在: %s", route.Receiver)
+		}
+		used[route.Receiver] = true
+	}
+	for i, s := range route.Matchers {
+		if _, err := parseAlertManagerMatchers(s); err != nil {
+			add(fmt.Sprintf("%s.matchers[%d]", path, i), "%v", err)
+		}
+	}
+	for _, label := range route.GroupBy {
+		if label == alertManagerGroupByAll {
+			if len(route.GroupBy) > 1 {
+				add(path+".group_by", "group_by 包含 ... 时不能再指定其他标签")
+			}
+		} else if !promLabelNamePattern.MatchString(label) {
+			add(path+".group_by", "标签名不合法: %s", label)
+		}
+	}
+	durations := []struct {
+		field, value string
+		nonZero      bool
+	}{
+		{"group_wait", route.GroupWait, false},
+		{"group_interval", route.GroupInterval, true},
+		{"repeat_interval", route.RepeatInterval, true},
+	}
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+		parsed, err := ParsePromDuration(d.value)
+		if err != nil {
+			add(path+"."+d.field, "%v", err)
+		} else if d.nonZero && parsed == 0 {
+			add(path+"."+d.field, "%s 不能为 0", d.field)
+		}
+	}
+	for _, name := range append(append([]string{}, route.MuteTimeIntervals...), route.ActiveTimeIntervals...) {
+		if !intervals[name] {
+			add(path, "时间区间不存在: %s", name)
+		}
+	}
+	for i := range route.Routes {
+		validateAlertManagerRoute(fmt.Sprintf("%s.routes[%d]", path, i), &route.Routes[i], receivers, intervals, used, add)
+	}
+}
+
+func validateAlertManagerReceiver(path string, r *models.AlertManagerReceiver, global map[string]interface{}, add func(path, format string, args ...interface{})) {
+	// global 为 nil 表示无法确定全局默认值，跳过可由 global 提供的字段检查
+	hasGlobal := func(keys ...string) bool {
+		if global == nil {
+			return true
+		}
+		for _, key := range keys {
+			if v, ok := global[key].(string); ok && v != "" {
+				return true
+			}
+		}
+		return false
+	}
+	checkURL := func(fieldPath, value string, required bool) {
+		if value == "" {
+			if required {
+				add(fieldPath, "地址不能为空")
+			}
+			return
+		}
+		if value == models.AlertManagerSecretMask {
+			return
+		}
+		u, err := url.Parse(value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add(fieldPath, "地址不合法: %s", value)
+		}
+	}
+
+	for i, c := range r.WebhookConfigs {
+		checkURL(fmt.Sprintf("%s.webhook_configs[%d].url", path, i), c.URL, true)
+		if c.MaxAlerts < 0 {
+			add(fmt.Sprintf("%s.webhook_configs[%d].max_alerts", path, i), "max_alerts 不能为负数")
+		}
+	}
+	for i, c := range r.DingTalkConfigs {
+		checkURL(fmt.Sprintf("%s.dingtalk_configs[%d].url", path, i), c.URL, true)
+	}
+	for i, c := range r.EmailConfigs {
+		p := fmt.Sprintf("%s.email_configs[%d]", path, i)
+		if strings.TrimSpace(c.To) == "" {
+			add(p+".to", "收件人不能为空")
+		}
+		if c.Smarthost == "" {
+			if !hasGlobal("smtp_smarthost") {
+				add(p+".smarthost", "未配置 SMTP 服务器（可在 global.smtp_smarthost 中统一配置）")
+			}
+		} else if _, _, err := net.SplitHostPort(c.Smarthost); err != nil {
+			add(p+".smarthost", "SMTP 服务器需为 host:port 格式: %s", c.Smarthost)
+		}
+		if c.From == "" && !hasGlobal("smtp_from") {
+			add(p+".from", "未配置发件人（可在 global.smtp_from 中统一配置）")
+		}
+	}
+	for i, c := range r.SlackConfigs {
+		p := fmt.Sprintf("%s.slack_configs[%d]", path, i)
+		checkURL(p+".api_url", c.APIURL, !hasGlobal("slack_api_url", "slack_api_url_file"))
+	}
+	for i, c := range r.PagerdutyConfigs {
+		p := fmt.Sprintf("%s.pagerduty_configs[%d]", path, i)
+		switch {
+		case c.RoutingKey == "" && c.ServiceKey == "":
+			add(p, "需要设置 routing_key 或 service_key")
+		case c.RoutingKey != "" && c.ServiceKey != "":
+			add(p, "routing_key 与 service_key 只能设置一个")
+		}
+		checkURL(p+".url", c.URL, false)
+	}
+	for i, c := range r.WechatConfigs {
+		p := fmt.Sprintf("%s.wechat_configs[%d]", path, i)
+		if c.APISecret == "" && !hasGlobal("wechat_api_secret") {
+			add(p+".api_secret", "未配置企业微信 Secret（可在 global.wechat_api_secret 中统一配置）")
+		}
+		if c.CorpID == "" && !hasGlobal("wechat_api_corp_id") {
+			add(p+".corp_id", "未配置企业 ID（可在 global.wechat_api_corp_id 中统一配置）")
+		}
+	}
+}
+
+// SimulateAlertManagerRouting 模拟一组标签在路由树中的匹配过程，返回最终送达的接收器。
+// rootMatchers 为附加到根路由的匹配条件（AlertmanagerConfig 会按命名空间限定路由）
+func SimulateAlertManagerRouting(cfg *models.AlertManagerRoutingConfig, labels map[string]string, rootMatchers []string) (*models.AlertManagerRoutingSimulation, error) {
+	if cfg.Route == nil {
+		return nil, fmt.Errorf("缺少根路由")
+	}
+	defaults := models.AlertManagerRouteMatch{
+		GroupWait:      alertManagerDefaultGroupWait,
+		GroupInterval:  alertManagerDefaultGroupInterval,
+		RepeatInterval: alertManagerDefaultRepeatInterval,
+	}
+	matches, err := matchAlertManagerRoute(cfg.Route, "route", defaults, labels, rootMatchers)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.AlertManagerRoutingSimulation{Receivers: []string{}, Matches: []models.AlertManagerRouteMatch{}, InhibitRules: []int{}}
+	seen := make(map[string]bool)
+	for _, m := range matches {
+		result.Matches = append(result.Matches, m)
+		if !seen[m.Receiver] {
+			seen[m.Receiver] = true
+			result.Receivers = append(result.Receivers, m.Receiver)
+		}
+	}
+
+	for i, rule := range cfg.InhibitRules {
+		matched := true
+		for _, s := range rule.TargetMatchers {
+			matchers, err := parseAlertManagerMatchers(s)
+			if err != nil {
+				return nil, fmt.Errorf("抑制规则 %d: %w", i+1, err)
+			}
+			for _, m := range matchers {
+				matched = matched && m.Matches(labels)
+			}
+		}
+		if matched {
+			result.InhibitRules = append(result.InhibitRules, i)
+		}
+	}
+	return result, nil
+}
+
+// matchAlertManagerRoute 与 Alertmanager 的路由逻辑一致：深度优先匹配子路由，
+// 命中且未设置 continue 的子路由会终止同级匹配，没有子路由命中时由当前节点接收
+func matchAlertManagerRoute(route *models.AlertManagerRoute, path string, parent models.AlertManagerRouteMatch, labels map[string]string, extra []string) ([]models.AlertManagerRouteMatch, error) {
+	for _, s := range append(append([]string{}, extra...), route.Matchers...) {
+		matchers, err := parseAlertManagerMatchers(s)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		for _, m := range matchers {
+			if !m.Matches(labels) {
+				return nil, nil
+			}
+		}
+	}
+
+	current := parent
+	current.Path = path
+	if route.Receiver != "" {
+		current.Receiver = route.Receiver
+	}
+	if route.GroupBy != nil {
+		current.GroupBy = route.GroupBy
+	}
+	if route.GroupWait != "" {
+		current.GroupWait = route.GroupWait
+	}
+	if route.GroupInterval != "" {
+		current.GroupInterval = route.GroupInterval
+	}
+	if route.RepeatInterval != "" {
+		current.RepeatInterval = route.RepeatInterval
+	}
+	// 时间区间不继承父路由
+	current.MuteTimeIntervals = route.MuteTimeIntervals
+	current.ActiveTimeIntervals = route.ActiveTimeIntervals
+
+	var all []models.AlertManagerRouteMatch
+	for i := range route.Routes {
+		child := &route.Routes[i]
+		matches, err := matchAlertManagerRoute(child, fmt.Sprintf("%s.routes[%d]", path, i), current, labels, nil)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, matches...)
+		if len(matches) > 0 && !child.Continue {
+			break
+		}
+	}
+	if len(all) > 0 {
+		return all, nil
+	}
+
+	current.GroupBy = append([]string{}, current.GroupBy...)
+	current.GroupLabels = make(map[string]string)
+	for _, name := range current.GroupBy {
+		if name == alertManagerGroupByAll {
+			for k, v := range labels {
+				current.GroupLabels[k] = v
+			}
+			break
+		}
+		if v, ok := labels[name]; ok {
+			current.GroupLabels[name] = v
+		}
+	}
+	return []models.AlertManagerRouteMatch{current}, nil
+}
+
+// parseAlertManagerYAML 解析 alertmanager.yaml，返回原始文档与可编辑部分。
+// 旧版 match / match_re / source_match 等字段会转换为 matchers 写法
+func parseAlertManagerYAML(raw []byte) (map[string]interface{}, *models.AlertManagerRoutingConfig, error) {
+	doc := make(map[string]interface{})
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return nil, nil, fmt.Errorf("YAML 格式错误: %w", err)
+	}
+	if doc == nil {
+		doc = make(map[string]interface{})
+	}
+
+	cfg := &models.AlertManagerRoutingConfig{Receivers: []models.AlertManagerReceiver{}}
+	if route, ok := doc["route"].(map[string]interface{}); ok {
+		normalizeAlertManagerRoute(route)
+		cfg.Route = &models.AlertManagerRoute{}
+		if err := convertViaJSON(route, cfg.Route); err != nil {
+			return nil, nil, fmt.Errorf("解析 route 失败: %w", err)
+		}
+	}
+	for _, item := range mapList(doc["receivers"]) {
+		receiver, err := alertManagerReceiverFromMap(item, false)
+		if err != nil {
+			return nil, nil, err
+		}
+		cfg.Receivers = append(cfg.Receivers, receiver)
+	}
+	for _, item := range mapList(doc["inhibit_rules"]) {
+		for _, side := range []string{"source", "target"} {
+			item[side+"_matchers"] = legacyAlertManagerMatchers(item, side+"_matchers", side+"_match", side+"_match_re")
+		}
+		var rule models.AlertManagerInhibitRule
+		if err := convertViaJSON(item, &rule); err != nil {
+			return nil, nil, fmt.Errorf("解析 inhibit_rules 失败: %w", err)
+		}
+		cfg.InhibitRules = append(cfg.InhibitRules, rule)
+	}
+	return doc, cfg, nil
+}
+
+// alertManagerTimeIntervals 返回 alertmanager.yaml 中定义的时间区间名
+func alertManagerTimeIntervals(doc map[string]interface{}) []string {
+	var names []string
+	for _, key := range []string{"time_intervals", "mute_time_intervals"} {
+		for _, item := range mapList(doc[key]) {
+			if name, ok := item["name"].(string); ok {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// renderAlertManagerYAML 将编辑后的配置写回文档：route 与 inhibit_rules 整体替换，
+// 接收器按名称与原配置合并，保留未支持编辑的通知类型与字段
+func renderAlertManagerYAML(doc map[string]interface{}, cfg *models.AlertManagerRoutingConfig) ([]byte, error) {
+	route, err := toJSONMap(cfg.Route)
+	if err != nil {
+		return nil, err
+	}
+	doc["route"] = route
+
+	receivers, err := mergeAlertManagerReceivers(mapList(doc["receivers"]), cfg.Receivers, false, nil)
+	if err != nil {
+		return nil, err
+	}
+	doc["receivers"] = receivers
+
+	if len(cfg.InhibitRules) > 0 {
+		rules := make([]interface{}, 0, len(cfg.InhibitRules))
+		for i := range cfg.InhibitRules {
+			rule, err := toJSONMap(&cfg.InhibitRules[i])
+			if err != nil {
+				return nil, err
+			}
+			rules = append(rules, rule)
+		}
+		doc["inhibit_rules"] = rules
+	} else {
+		delete(doc, "inhibit_rules")
+	}
+	return yaml.Marshal(doc)
+}
+
+// normalizeAlertManagerRoute 把路由树中旧版的 match / match_re 转换为 matchers
+func normalizeAlertManagerRoute(route map[string]interface{}) {
+	if matchers := legacyAlertManagerMatchers(route, "matchers", "match", "match_re"); len(matchers) > 0 {
+		route["matchers"] = matchers
+	}
+	for _, child := range mapList(route["routes"]) {
+		normalizeAlertManagerRoute(child)
+	}
+}
+
+// legacyAlertManagerMatchers 合并 matchers 与旧版等值 / 正则匹配字段，并删除旧字段
+func legacyAlertManagerMatchers(m map[string]interface{}, matchersKey, equalKey, regexKey string) []string {
+	var matchers []string
+	if list, ok := m[matchersKey].([]interface{}); ok {
+		for _, item := range list {
+			if s, ok := item.(string); ok {
+				matchers = append(matchers, s)
+			}
+		}
+	}
+	for key, op := range map[string]string{equalKey: "=", regexKey: "=~"} {
+		values, ok := m[key].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		names := make([]string, 0, len(values))
+		for name := range values {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			matchers = append(matchers, alertManagerMatcher{Name: name, Op: op, Value: fmt.Sprint(values[name])}.String())
+		}
+		delete(m, key)
+	}
+	delete(m, matchersKey)
+	return matchers
+}
+
+// alertManagerReceiverFromMap 解析单个接收器，crd 为 true 时字段名为 AlertmanagerConfig 的驼峰写法。
+// 密钥类字段以 AlertManagerSecretMask 返回
+func alertManagerReceiverFromMap(raw map[string]interface{}, crd bool) (models.AlertManagerReceiver, error) {
+	converted := map[string]interface{}{"name": raw["name"]}
+	var others []string
+	for key, value := range raw {
+		if key == "name" {
+			continue
+		}
+		typ := key
+		if crd {
+			typ = alertManagerNativeKey(key)
+		}
+		if _, ok := alertManagerReceiverTypes[typ]; !ok || typ == "dingtalk_configs" {
+			others = append(others, key)
+			continue
+		}
+
+		entries := make([]interface{}, 0)
+		for _, entry := range mapList(value) {
+			item := make(map[string]interface{}, len(entry))
+			for k, v := range entry {
+				if crd {
+					k = alertManagerNativeKey(k)
+				}
+				item[k] = v
+			}
+			for _, field := range alertManagerSecretFields[typ] {
+				if v, ok := item[field]; ok && v != "" && v != nil {
+					item[field] = models.AlertManagerSecretMask
+				}
+			}
+			// AlertmanagerConfig 的 webhook 地址可以引用 Secret
+			if crd && typ == "webhook_configs" && item["url"] == nil && item["url_secret"] != nil {
+				item["url"] = models.AlertManagerSecretMask
+			}
+			entries = append(entries, item)
+		}
+		if typ == "webhook_configs" {
+			plain, dingTalk := splitDingTalkWebhooks(entries)
+			converted["webhook_configs"], converted["dingtalk_configs"] = plain, dingTalk
+			continue
+		}
+		converted[typ] = entries
+	}
+
+	var receiver models.AlertManagerReceiver
+	if err := convertViaJSON(converted, &receiver); err != nil {
+		return receiver, fmt.Errorf("解析接收器 %v 失败: %w", raw["name"], err)
+	}
+	sort.Strings(others)
+	receiver.OtherTypes = others
+	return receiver, nil
+}
+
+// mergeAlertManagerReceivers 将编辑后的接收器与原配置合并：
+// 按名称找到原接收器，已支持的通知类型按序号逐条合并（保留未建模的字段，如 http_config），
+// 值为 AlertManagerSecretMask 的字段沿用原值；secretRef 不为 nil 时（CRD）密钥字段通过它写入 Secret 并返回引用
+func mergeAlertManagerReceivers(original []map[string]interface{}, updated []models.AlertManagerReceiver, crd bool, secretRef func(receiver, typ string, index int, field, value string) interface{}) ([]interface{}, error) {
+	byName := make(map[string]map[string]interface{}, len(original))
+	for _, item := range original {
+		if name, ok := item["name"].(string); ok {
+			byName[name] = item
+		}
+	}
+	key := func(native string) string {
+		if crd {
+			return alertManagerCRDKey(native)
+		}
+		return native
+	}
+
+	result := make([]interface{}, 0, len(updated))
+	for i := range updated {
+		r := updated[i]
+		r.OtherTypes = nil
+		desired, err := toJSONMap(&r)
+		if err != nil {
+			return nil, err
+		}
+
+		orig := byName[r.Name]
+		merged := make(map[string]interface{}, len(orig)+1)
+		for k, v := range orig {
+			merged[k] = v
+		}
+		merged["name"] = r.Name
+
+		origWebhooks, origDingTalk := splitDingTalkWebhooks(listOf(orig[key("webhook_configs")]))
+		var webhooks []interface{}
+		for _, typ := range []string{"webhook_configs", "dingtalk_configs", "email_configs", "slack_configs", "pagerduty_configs", "wechat_configs"} {
+			entries := mapList(desired[typ])
+			for j, entry := range entries {
+				converted := make(map[string]interface{}, len(entry))
+				for k, v := range entry {
+					if s, ok := v.(string); ok && secretRef != nil && s != models.AlertManagerSecretMask && s != "" && containsString(alertManagerSecretFields[typ], k) {
+						v = secretRef(r.Name, typ, j, k, s)
+					}
+					converted[key(k)] = v
+				}
+				entries[j] = converted
+			}
+
+			var origEntries []interface{}
+			switch typ {
+			case "webhook_configs":
+				origEntries = origWebhooks
+			case "dingtalk_configs":
+				origEntries = origDingTalk
+			default:
+				origEntries = listOf(orig[key(typ)])
+			}
+			known := jsonFieldNames(alertManagerReceiverTypes[typ])
+			for j := range known {
+				known[j] = key(known[j])
+			}
+			mergedEntries := mergeAlertManagerEntries(origEntries, entries, known)
+
+			if typ == "webhook_configs" || typ == "dingtalk_configs" {
+				webhooks = append(webhooks, mergedEntries...)
+				continue
+			}
+			setList(merged, key(typ), mergedEntries)
+		}
+		setList(merged, key("webhook_configs"), webhooks)
+		result = append(result, merged)
+	}
+	return result, nil
+}
+
+// mergeAlertManagerEntries 逐条合并通知配置，known 为可编辑的字段名
+func mergeAlertManagerEntries(original []interface{}, updated []map[string]interface{}, known []string) []interface{} {
+	result := make([]interface{}, 0, len(updated))
+	for i, entry := range updated {
+		orig := map[string]interface{}{}
+		if i < len(original) {
+			if m, ok := original[i].(map[string]interface{}); ok {
+				orig = m
+			}
+		}
+		merged := make(map[string]interface{}, len(orig)+len(entry))
+		for k, v := range orig {
+			merged[k] = v
+		}
+		for _, k := range known {
+			delete(merged, k)
+		}
+		for k, v := range entry {
+			if v == models.AlertManagerSecretMask {
+				if ov, ok := orig[k]; ok {
+					merged[k] = ov
+				}
+				continue
+			}
+			merged[k] = v
+		}
+		result = append(result, merged)
+	}
+	return result
+}
+
+// splitDingTalkWebhooks 按地址区分普通 webhook 与钉钉转发地址
+func splitDingTalkWebhooks(entries []interface{}) (plain, dingTalk []interface{}) {
+	plain, dingTalk = []interface{}{}, []interface{}{}
+	for _, entry := range entries {
+		m, _ := entry.(map[string]interface{})
+		if u, ok := m["url"].(string); ok && alertManagerDingTalkURL.MatchString(u) {
+			dingTalk = append(dingTalk, entry)
+			continue
+		}
+		plain = append(plain, entry)
+	}
+	return plain, dingTalk
+}
+
+// alertManagerRouteToCRD 转换为 AlertmanagerConfig CRD 的 route 字段
+func alertManagerRouteToCRD(route *models.AlertManagerRoute) (map[string]interface{}, error) {
+	m := make(map[string]interface{})
+	if route.Receiver != "" {
+		m["receiver"] = route.Receiver
+	}
+	if len(route.GroupBy) > 0 {
+		m["groupBy"] = interfaceList(route.GroupBy)
+	}
+	if route.Continue {
+		m["continue"] = true
+	}
+	for key, value := range map[string]string{"groupWait": route.GroupWait, "groupInterval": route.GroupInterval, "repeatInterval": route.RepeatInterval} {
+		if value != "" {
+			m[key] = value
+		}
+	}
+	if len(route.MuteTimeIntervals) > 0 {
+		m["muteTimeIntervals"] = interfaceList(route.MuteTimeIntervals)
+	}
+	if len(route.ActiveTimeIntervals) > 0 {
+		m["activeTimeIntervals"] = interfaceList(route.ActiveTimeIntervals)
+	}
+	matchers, err := alertManagerMatchersToCRD(route.Matchers)
+	if err != nil {
+		return nil, err
+	}
+	if len(matchers) > 0 {
+		m["matchers"] = matchers
+	}
+	if len(route.Routes) > 0 {
+		children := make([]interface{}, 0, len(route.Routes))
+		for i := range route.Routes {
+			child, err := alertManagerRouteToCRD(&route.Routes[i])
+			if err != nil {
+				return nil, err
+			}
+			children = append(children, child)
+		}
+		m["routes"] = children
+	}
+	return m, nil
+}
+
+// alertManagerRouteFromCRD 解析 AlertmanagerConfig CRD 的 route 字段
+func alertManagerRouteFromCRD(m map[string]interface{}) *models.AlertManagerRoute {
+	route := &models.AlertManagerRoute{
+		Receiver:            stringValue(m["receiver"]),
+		GroupBy:             stringList(m["groupBy"]),
+		Continue:            m["continue"] == true,
+		GroupWait:           stringValue(m["groupWait"]),
+		GroupInterval:       stringValue(m["groupInterval"]),
+		RepeatInterval:      stringValue(m["repeatInterval"]),
+		MuteTimeIntervals:   stringList(m["muteTimeIntervals"]),
+		ActiveTimeIntervals: stringList(m["activeTimeIntervals"]),
+		Matchers:            alertManagerMatchersFromCRD(m["matchers"]),
+	}
+	for _, child := range mapList(m["routes"]) {
+		route.Routes = append(route.Routes, *alertManagerRouteFromCRD(child))
+	}
+	return route
+}
+
+// alertManagerMatchersToCRD 转换为 CRD 的 [{name, value, matchType}] 形式
+func alertManagerMatchersToCRD(list []string) ([]interface{}, error) {
+	var result []interface{}
+	for _, s := range list {
+		matchers, err := parseAlertManagerMatchers(s)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range matchers {
+			result = append(result, map[string]interface{}{"name": m.Name, "value": m.Value, "matchType": m.Op})
+		}
+	}
+	return result, nil
+}
+
+// alertManagerMatchersFromCRD 解析 CRD 的匹配器，兼容已废弃的 regex 字段
+func alertManagerMatchersFromCRD(value interface{}) []string {
+	var result []string
+	for _, m := range mapList(value) {
+		op := stringValue(m["matchType"])
+		if op == "" {
+			op = "="
+			if m["regex"] == true {
+				op = "=~"
+			}
+		}
+		result = append(result, alertManagerMatcher{Name: stringValue(m["name"]), Op: op, Value: stringValue(m["value"])}.String())
+	}
+	return result
+}
+
+// alertManagerRoutingFromCRD 解析 AlertmanagerConfig 的 spec
+func alertManagerRoutingFromCRD(spec map[string]interface{}) (*models.AlertManagerRoutingConfig, error) {
+	cfg := &models.AlertManagerRoutingConfig{Receivers: []models.AlertManagerReceiver{}}
+	if route, ok := spec["route"].(map[string]interface{}); ok {
+		cfg.Route = alertManagerRouteFromCRD(route)
+	}
+	for _, item := range mapList(spec["receivers"]) {
+		receiver, err := alertManagerReceiverFromMap(item, true)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Receivers = append(cfg.Receivers, receiver)
+	}
+	for _, item := range mapList(spec["inhibitRules"]) {
+		cfg.InhibitRules = append(cfg.InhibitRules, models.AlertManagerInhibitRule{
+			SourceMatchers: alertManagerMatchersFromCRD(item["sourceMatch"]),
+			TargetMatchers: alertManagerMatchersFromCRD(item["targetMatch"]),
+			Equal:          stringList(item["equal"]),
+		})
+	}
+	return cfg, nil
+}
+
+// applyAlertManagerRoutingToCRD 将编辑后的配置写入 AlertmanagerConfig 的 spec，保留 muteTimeIntervals 等其他字段
+func applyAlertManagerRoutingToCRD(spec map[string]interface{}, cfg *models.AlertManagerRoutingConfig, secretRef func(receiver, typ string, index int, field, value string) interface{}) error {
+	route, err := alertManagerRouteToCRD(cfg.Route)
+	if err != nil {
+		return err
+	}
+	spec["route"] = route
+
+	receivers, err := mergeAlertManagerReceivers(mapList(spec["receivers"]), cfg.Receivers, true, secretRef)
+	if err != nil {
+		return err
+	}
+	spec["receivers"] = receivers
+
+	var rules []interface{}
+	for _, rule := range cfg.InhibitRules {
+		m := make(map[string]interface{})
+		source, err := alertManagerMatchersToCRD(rule.SourceMatchers)
+		if err != nil {
+			return err
+		}
+		target, err := alertManagerMatchersToCRD(rule.TargetMatchers)
+		if err != nil {
+			return err
+		}
+		if len(source) > 0 {
+			m["sourceMatch"] = source
+		}
+		if len(target) > 0 {
+			m["targetMatch"] = target
+		}
+		if len(rule.Equal) > 0 {
+			m["equal"] = interfaceList(rule.Equal)
+		}
+		rules = append(rules, m)
+	}
+	setList(spec, "inhibitRules", rules)
+	return nil
+}
+
+// alertManagerCRDKey alertmanager.yaml 字段名转换为 CRD 字段名，如 send_resolved → sendResolved
+func alertManagerCRDKey(native string) string {
+	if key, ok := alertManagerCRDKeys[native]; ok {
+		return key
+	}
+	parts := strings.Split(native, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// alertManagerNativeKey CRD 字段名转换为 alertmanager.yaml 字段名，如 sendResolved → send_resolved
+func alertManagerNativeKey(crd string) string {
+	for native, key := range alertManagerCRDKeys {
+		if key == crd {
+			return native
+		}
+	}
+	var b strings.Builder
+	for i, r := range crd {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// alertManagerSecretKey 生成保存接收器密钥的 Secret 键名
+func alertManagerSecretKey(receiver, typ string, index int, field string) string {
+	key := fmt.Sprintf("%s-%s-%d-%s", receiver, strings.TrimSuffix(typ, "_configs"), index, field)
+	return strings.Trim(alertManagerSecretKeyChars.ReplaceAllString(strings.ToLower(key), "-"), "-")
+}
+
+// jsonFieldNames 返回结构体的 JSON 字段名
+func jsonFieldNames(t reflect.Type) []string {
+	names := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// convertViaJSON 通过 JSON 在 map 与结构体之间转换
+func convertViaJSON(in, out interface{}) error {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// toJSONMap 结构体转换为 map，字段名取 JSON tag
+func toJSONMap(v interface{}) (map[string]interface{}, error) {
+	m := make(map[string]interface{})
+	if err := convertViaJSON(v, &m); err != nil {
+		return nil, fmt.Errorf("序列化配置失败: %w", err)
+	}
+	return m, nil
+}
+
+// listOf 将 YAML/JSON 列表转换为 []interface{}
+func listOf(value interface{}) []interface{} {
+	list, _ := value.([]interface{})
+	return list
+}
+
+// mapList 返回列表中的 map 元素
+func mapList(value interface{}) []map[string]interface{} {
+	var result []map[string]interface{}
+	for _, item := range listOf(value) {
+		if m, ok := item.(map[string]interface{}); ok {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// setList 列表为空时删除字段
+func setList(m map[string]interface{}, key string, list []interface{}) {
+	if len(list) == 0 {
+		delete(m, key)
+		return
+	}
+	m[key] = list
+}
+
+func stringValue(value interface{}) string {
+	s, _ := value.(string)
+	return s
+}
+
+func stringList(value interface{}) []string {
+	var result []string
+	for _, item := range listOf(value) {
+		if s, ok := item.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// interfaceList 转换为 unstructured 对象可用的列表类型
+func interfaceList(list []string) []interface{} {
+	result := make([]interface{}, 0, len(list))
+	for _, item := range list {
+		result = append(result, item)
+	}
+	return result
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
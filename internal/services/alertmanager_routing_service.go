@@ -0,0 +1,419 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// alertmanagerConfigGVR Prometheus Operator 的 AlertmanagerConfig 资源
+var alertmanagerConfigGVR = schema.GroupVersionResource{Group: "monitoring.coreos.com", Version: "v1alpha1", Resource: "alertmanagerconfigs"}
+
+const (
+	// alertManagerConfigKey Secret 中默认的配置键
+	alertManagerConfigKey = "alertmanager.yaml"
+	// alertManagerReceiverSecretSuffix AlertmanagerConfig 接收器密钥所在 Secret 的名称后缀
+	alertManagerReceiverSecretSuffix = "-kubepolaris-receivers"
+)
+
+// AlertManagerRoutingService Alertmanager 路由、接收器与抑制规则编辑服务
+type AlertManagerRoutingService struct {
+	versionSvc *ConfigVersionService
+
+	// clientFor / dynamicFor 获取集群客户端，测试时可替换
+	clientFor  func(cluster *models.Cluster) (kubernetes.Interface, error)
+	dynamicFor func(cluster *models.Cluster) (dynamic.Interface, error)
+}
+
+// NewAlertManagerRoutingService 创建 Alertmanager 路由编辑服务
+func NewAlertManagerRoutingService(versionSvc *ConfigVersionService) *AlertManagerRoutingService {
+	return &AlertManagerRoutingService{
+		versionSvc: versionSvc,
+		clientFor: func(cluster *models.Cluster) (kubernetes.Interface, error) {
+			client, err := NewK8sClientForCluster(cluster)
+			if err != nil {
+				return nil, err
+			}
+			return client.GetClientset(), nil
+		},
+		dynamicFor: func(cluster *models.Cluster) (dynamic.Interface, error) {
+			client, err := NewK8sClientForCluster(cluster)
+			if err != nil {
+				return nil, err
+			}
+			return dynamic.NewForConfig(client.GetRestConfig())
+		},
+	}
+}
+
+// alertManagerRoutingState 已加载的路由配置及其所在资源
+type alertManagerRoutingState struct {
+	source          models.AlertManagerRoutingSource
+	resourceVersion string
+	config          *models.AlertManagerRoutingConfig
+	global          map[string]interface{} // CRD 为 nil
+	timeIntervals   []string
+
+	secret *corev1.Secret
+	doc    map[string]interface{}
+	crd    *unstructured.Unstructured
+}
+
+// ListSources 查找集群中可编辑的路由配置：包含 alertmanager.yaml 的 Secret（排除 Operator 生成的副本）与 AlertmanagerConfig
+func (s *AlertManagerRoutingService) ListSources(ctx context.Context, cluster *models.Cluster) ([]models.AlertManagerRoutingSource, error) {
+	clientset, err := s.clientFor(cluster)
+	if err != nil {
+		return nil, fmt.Errorf("创建K8s客户端失败: %w", err)
+	}
+	secrets, err := clientset.CoreV1().Secrets("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取 Secret 列表失败: %w", err)
+	}
+	sources := []models.AlertManagerRoutingSource{}
+	for _, secret := range secrets.Items {
+		if _, ok := secret.Data[alertManagerConfigKey]; !ok || strings.HasSuffix(secret.Name, "-generated") {
+			continue
+		}
+		sources = append(sources, models.AlertManagerRoutingSource{
+			Kind: models.AlertManagerRoutingSourceSecret, Namespace: secret.Namespace, Name: secret.Name, Key: alertManagerConfigKey,
+		})
+	}
+
+	dynamicClient, err := s.dynamicFor(cluster)
+	if err != nil {
+		return nil, fmt.Errorf("创建K8s客户端失败: %w", err)
+	}
+	list, err := dynamicClient.Resource(alertmanagerConfigGVR).Namespace("").List(ctx, metav1.ListOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("获取 AlertmanagerConfig 列表失败: %w", err)
+	}
+	if err == nil {
+		for _, item := range list.Items {
+			sources = append(sources, models.AlertManagerRoutingSource{
+				Kind: models.AlertManagerRoutingSourceCRD, Namespace: item.GetNamespace(), Name: item.GetName(),
+			})
+		}
+	}
+
+	sort.SliceStable(sources, func(i, j int) bool {
+		if sources[i].Kind != sources[j].Kind {
+			return sources[i].Kind == models.AlertManagerRoutingSourceSecret
+		}
+		if sources[i].Namespace != sources[j].Namespace {
+			return sources[i].Namespace < sources[j].Namespace
+		}
+		return sources[i].Name < sources[j].Name
+	})
+	return sources, nil
+}
+
+// GetRouting 读取路由配置，密钥类字段脱敏返回
+func (s *AlertManagerRoutingService) GetRouting(ctx context.Context, cluster *models.Cluster, source models.AlertManagerRoutingSource) (*models.AlertManagerRoutingDocument, error) {
+	state, err := s.load(ctx, cluster, source)
+	if err != nil {
+		return nil, err
+	}
+	return state.document(), nil
+}
+
+// ValidateRouting 校验路由配置；Raw 不为空时校验完整的 alertmanager.yaml，
+// 指定 Source 时使用其中的 global 配置与时间区间定义
+func (s *AlertManagerRoutingService) ValidateRouting(ctx context.Context, cluster *models.Cluster, req *models.AlertManagerRoutingSaveRequest) (*models.AlertManagerConfigValidationResult, error) {
+	if req.Raw != "" {
+		return validateAlertManagerYAML([]byte(req.Raw)), nil
+	}
+
+	kind := models.AlertManagerRoutingSourceSecret
+	global := map[string]interface{}{}
+	var timeIntervals []string
+	if req.Source.Name != "" {
+		state, err := s.load(ctx, cluster, req.Source)
+		if err != nil {
+			return nil, err
+		}
+		kind, global, timeIntervals = state.source.Kind, state.global, state.timeIntervals
+	}
+	return alertManagerValidationResult(ValidateAlertManagerRouting(kind, &req.Config, global, timeIntervals)), nil
+}
+
+// SaveRouting 校验并保存路由配置。校验不通过时返回校验结果且不写入集群
+func (s *AlertManagerRoutingService) SaveRouting(ctx context.Context, cluster *models.Cluster, req *models.AlertManagerRoutingSaveRequest, username string) (*models.AlertManagerRoutingDocument, *models.AlertManagerConfigValidationResult, error) {
+	state, err := s.load(ctx, cluster, req.Source)
+	if err != nil {
+		return nil, nil, err
+	}
+	if req.ResourceVersion != "" && req.ResourceVersion != state.resourceVersion {
+		return nil, nil, fmt.Errorf("配置已被修改，请刷新后重试")
+	}
+	result := alertManagerValidationResult(ValidateAlertManagerRouting(state.source.Kind, &req.Config, state.global, state.timeIntervals))
+	if !result.Valid {
+		return nil, result, nil
+	}
+
+	if state.source.Kind == models.AlertManagerRoutingSourceCRD {
+		err = s.saveCRD(ctx, cluster, state, &req.Config, result)
+	} else {
+		err = s.saveSecret(ctx, cluster, state, &req.Config, username, result)
+	}
+	if err != nil || !result.Valid {
+		return nil, result, err
+	}
+
+	saved, err := s.load(ctx, cluster, req.Source)
+	if err != nil {
+		return nil, result, err
+	}
+	return saved.document(), result, nil
+}
+
+// saveSecret 合并写回 alertmanager.yaml，并记录 Secret 版本
+func (s *AlertManagerRoutingService) saveSecret(ctx context.Context, cluster *models.Cluster, state *alertManagerRoutingState, cfg *models.AlertManagerRoutingConfig, username string, result *models.AlertManagerConfigValidationResult) error {
+	data, err := renderAlertManagerYAML(state.doc, cfg)
+	if err != nil {
+		return err
+	}
+	// 合并后再校验一次：改名的接收器无法沿用原密钥，需要重新填写
+	if check := validateAlertManagerYAML(data); !check.Valid {
+		*result = *check
+		return nil
+	}
+
+	clientset, err := s.clientFor(cluster)
+	if err != nil {
+		return fmt.Errorf("创建K8s客户端失败: %w", err)
+	}
+	before := state.secret.DeepCopy()
+	secret := state.secret.DeepCopy()
+	secret.Data[state.source.Key] = data
+	updated, err := clientset.CoreV1().Secrets(secret.Namespace).Update(ctx, secret, metav1.UpdateOptions{})
+	if err != nil {
+		if apierrors.IsConflict(err) {
+			return fmt.Errorf("配置已被修改，请刷新后重试")
+		}
+		return fmt.Errorf("更新 Secret 失败: %w", err)
+	}
+	s.versionSvc.RecordSecret(cluster.ID, before, updated, models.ConfigVersionSourceKubePolaris, username)
+	return nil
+}
+
+// saveCRD 写回 AlertmanagerConfig，新填写的密钥保存到同命名空间的 <name>-kubepolaris-receivers Secret 并以引用方式写入
+func (s *AlertManagerRoutingService) saveCRD(ctx context.Context, cluster *models.Cluster, state *alertManagerRoutingState, cfg *models.AlertManagerRoutingConfig, result *models.AlertManagerConfigValidationResult) error {
+	obj := state.crd.DeepCopy()
+	spec, _ := obj.Object["spec"].(map[string]interface{})
+	if spec == nil {
+		spec = make(map[string]interface{})
+		obj.Object["spec"] = spec
+	}
+
+	secretName := obj.GetName() + alertManagerReceiverSecretSuffix
+	secretData := make(map[string][]byte)
+	secretRef := func(receiver, typ string, index int, field, value string) interface{} {
+		key := alertManagerSecretKey(receiver, typ, index, field)
+		secretData[key] = []byte(value)
+		return map[string]interface{}{"name": secretName, "key": key}
+	}
+	if err := applyAlertManagerRoutingToCRD(spec, cfg, secretRef); err != nil {
+		return err
+	}
+
+	merged, err := alertManagerRoutingFromCRD(spec)
+	if err != nil {
+		return err
+	}
+	if check := alertManagerValidationResult(ValidateAlertManagerRouting(models.AlertManagerRoutingSourceCRD, merged, nil, state.timeIntervals)); !check.Valid {
+		*result = *check
+		return nil
+	}
+
+	if len(secretData) > 0 {
+		if err := s.upsertReceiverSecret(ctx, cluster, obj.GetNamespace(), secretName, secretData); err != nil {
+			return err
+		}
+	}
+
+	dynamicClient, err := s.dynamicFor(cluster)
+	if err != nil {
+		return fmt.Errorf("创建K8s客户端失败: %w", err)
+	}
+	if _, err := dynamicClient.Resource(alertmanagerConfigGVR).Namespace(obj.GetNamespace()).Update(ctx, obj, metav1.UpdateOptions{}); err != nil {
+		if apierrors.IsConflict(err) {
+			return fmt.Errorf("配置已被修改，请刷新后重试")
+		}
+		return fmt.Errorf("更新 AlertmanagerConfig 失败: %w", err)
+	}
+	return nil
+}
+
+// upsertReceiverSecret 创建或更新保存接收器密钥的 Secret
+func (s *AlertManagerRoutingService) upsertReceiverSecret(ctx context.Context, cluster *models.Cluster, namespace, name string, data map[string][]byte) error {
+	clientset, err := s.clientFor(cluster)
+	if err != nil {
+		return fmt.Errorf("创建K8s客户端失败: %w", err)
+	}
+	secrets := clientset.CoreV1().Secrets(namespace)
+	existing, err := secrets.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = secrets.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				Labels:    map[string]string{prometheusRuleManagedByLabel: "kubepolaris"},
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: data,
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("创建接收器密钥 Secret 失败: %w", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("获取接收器密钥 Secret 失败: %w", err)
+	}
+	if existing.Data == nil {
+		existing.Data = make(map[string][]byte)
+	}
+	for k, v := range data {
+		existing.Data[k] = v
+	}
+	if _, err := secrets.Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("更新接收器密钥 Secret 失败: %w", err)
+	}
+	return nil
+}
+
+// Simulate 模拟标签集的路由结果。AlertmanagerConfig 只处理本命名空间的告警，模拟时附加 namespace 匹配条件
+func (s *AlertManagerRoutingService) Simulate(ctx context.Context, cluster *models.Cluster, req *models.AlertManagerRoutingSimulateRequest) (*models.AlertManagerRoutingSimulation, error) {
+	cfg := req.Config
+	var rootMatchers []string
+	if req.Source != nil && req.Source.Name != "" {
+		if req.Source.Kind == models.AlertManagerRoutingSourceCRD {
+			rootMatchers = []string{alertManagerMatcher{Name: "namespace", Op: "=", Value: req.Source.Namespace}.String()}
+		}
+		if cfg == nil {
+			state, err := s.load(ctx, cluster, *req.Source)
+			if err != nil {
+				return nil, err
+			}
+			cfg = state.config
+		}
+	}
+	if cfg == nil {
+		return nil, fmt.Errorf("需要指定配置来源或配置内容")
+	}
+	return SimulateAlertManagerRouting(cfg, req.Labels, rootMatchers)
+}
+
+// load 读取并解析配置来源
+func (s *AlertManagerRoutingService) load(ctx context.Context, cluster *models.Cluster, source models.AlertManagerRoutingSource) (*alertManagerRoutingState, error) {
+	if source.Namespace == "" || source.Name == "" {
+		return nil, fmt.Errorf("需要指定配置所在的命名空间与名称")
+	}
+	state := &alertManagerRoutingState{source: source}
+
+	switch source.Kind {
+	case models.AlertManagerRoutingSourceSecret:
+		if state.source.Key == "" {
+			state.source.Key = alertManagerConfigKey
+		}
+		clientset, err := s.clientFor(cluster)
+		if err != nil {
+			return nil, fmt.Errorf("创建K8s客户端失败: %w", err)
+		}
+		secret, err := clientset.CoreV1().Secrets(source.Namespace).Get(ctx, source.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("获取 Secret 失败: %w", err)
+		}
+		raw, ok := secret.Data[state.source.Key]
+		if !ok {
+			return nil, fmt.Errorf("Secret %s/%s 中不存在配置键 %s", source.Namespace, source.Name, state.source.Key)
+		}
+		doc, cfg, err := parseAlertManagerYAML(raw)
+		if err != nil {
+			return nil, err
+		}
+		state.secret, state.doc, state.config = secret, doc, cfg
+		state.resourceVersion = secret.ResourceVersion
+		state.global, _ = doc["global"].(map[string]interface{})
+		if state.global == nil {
+			state.global = map[string]interface{}{}
+		}
+		state.timeIntervals = alertManagerTimeIntervals(doc)
+
+	case models.AlertManagerRoutingSourceCRD:
+		dynamicClient, err := s.dynamicFor(cluster)
+		if err != nil {
+			return nil, fmt.Errorf("创建K8s客户端失败: %w", err)
+		}
+		obj, err := dynamicClient.Resource(alertmanagerConfigGVR).Namespace(source.Namespace).Get(ctx, source.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("获取 AlertmanagerConfig 失败: %w", err)
+		}
+		spec, _ := obj.Object["spec"].(map[string]interface{})
+		cfg, err := alertManagerRoutingFromCRD(spec)
+		if err != nil {
+			return nil, err
+		}
+		state.crd, state.config = obj, cfg
+		state.resourceVersion = obj.GetResourceVersion()
+		for _, item := range mapList(spec["muteTimeIntervals"]) {
+			state.timeIntervals = append(state.timeIntervals, stringValue(item["name"]))
+		}
+
+	default:
+		return nil, fmt.Errorf("不支持的配置来源: %s", source.Kind)
+	}
+	return state, nil
+}
+
+// document 转换为接口返回的文档
+func (st *alertManagerRoutingState) document() *models.AlertManagerRoutingDocument {
+	return &models.AlertManagerRoutingDocument{
+		Source:          st.source,
+		ResourceVersion: st.resourceVersion,
+		Config:          *st.config,
+		TimeIntervals:   st.timeIntervals,
+	}
+}
+
+// validateAlertManagerYAML 校验完整的 alertmanager.yaml
+func validateAlertManagerYAML(raw []byte) *models.AlertManagerConfigValidationResult {
+	doc, cfg, err := parseAlertManagerYAML(raw)
+	if err != nil {
+		return &models.AlertManagerConfigValidationResult{Errors: []models.AlertManagerConfigValidationError{{Path: "", Message: err.Error()}}}
+	}
+	global, _ := doc["global"].(map[string]interface{})
+	if global == nil {
+		global = map[string]interface{}{}
+	}
+	errs, warnings := ValidateAlertManagerRouting(models.AlertManagerRoutingSourceSecret, cfg, global, alertManagerTimeIntervals(doc))
+	keys := make([]string, 0, len(doc))
+	for key := range doc {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !alertManagerTopLevelKeys[key] {
+			errs = append(errs, models.AlertManagerConfigValidationError{Path: key, Message: "不支持的配置项: " + key})
+		}
+	}
+	if _, ok := doc["global"].(map[string]interface{}); doc["global"] != nil && !ok {
+		errs = append(errs, models.AlertManagerConfigValidationError{Path: "global", Message: "global 必须是对象"})
+	}
+	return alertManagerValidationResult(errs, warnings)
+}
+
+func alertManagerValidationResult(errs []models.AlertManagerConfigValidationError, warnings []string) *models.AlertManagerConfigValidationResult {
+	return &models.AlertManagerConfigValidationResult{Valid: len(errs) == 0, Errors: errs, Warnings: warnings}
+}
@@ -0,0 +1,331 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/yaml"
+)
+
+const testAlertManagerYAML = `
+global:
+  smtp_smarthost: smtp.example.com:587
+  smtp_from: alert@example.com
+route:
+  receiver: default
+  group_by: [alertname]
+  routes:
+    - receiver: slack-ops
+      match:
+        team: ops
+      routes:
+        - receiver: pager
+          matchers: ['severity="critical"']
+    - receiver: dingtalk
+      match_re:
+        namespace: payments|orders
+      continue: true
+    - receiver: default
+      matchers: ['namespace=~"payments.*"']
+      group_by: ['...']
+receivers:
+  - name: default
+    email_configs:
+      - to: ops@example.com
+  - name: slack-ops
+    slack_configs:
+      - api_url: https://hooks.slack.com/services/T000/B000/XXX
+        channel: '#ops'
+        http_config:
+          proxy_url: http://proxy:3128
+    opsgenie_configs:
+      - api_key: opsgenie-key
+  - name: pager
+    pagerduty_configs:
+      - routing_key: pd-routing-key
+  - name: dingtalk
+    webhook_configs:
+      - url: http://dingtalk:8060/dingtalk/ops/send
+inhibit_rules:
+  - source_match:
+      severity: critical
+    target_match:
+      severity: warning
+    equal: [alertname]
+time_intervals:
+  - name: offhours
+`
+
+// AlertManagerRoutingTestSuite 定义 Alertmanager 路由编辑测试套件
+type AlertManagerRoutingTestSuite struct {
+	suite.Suite
+	svc       *AlertManagerRoutingService
+	clientset *fake.Clientset
+	dynamic   *dynamicfake.FakeDynamicClient
+	cluster   *models.Cluster
+}
+
+// SetupTest 每个测试前的设置
+func (s *AlertManagerRoutingTestSuite) SetupTest() {
+	s.clientset = fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "alertmanager-main", Namespace: "monitoring", ResourceVersion: "1"},
+		Data:       map[string][]byte{alertManagerConfigKey: []byte(testAlertManagerYAML)},
+	}, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "alertmanager-main-generated", Namespace: "monitoring"},
+		Data:       map[string][]byte{alertManagerConfigKey: []byte(testAlertManagerYAML)},
+	})
+	s.dynamic = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		alertmanagerConfigGVR: "AlertmanagerConfigList",
+	})
+	s.svc = NewAlertManagerRoutingService(nil)
+	s.svc.clientFor = func(*models.Cluster) (kubernetes.Interface, error) { return s.clientset, nil }
+	s.svc.dynamicFor = func(*models.Cluster) (dynamic.Interface, error) { return s.dynamic, nil }
+	s.cluster = &models.Cluster{Name: "prod"}
+}
+
+func (s *AlertManagerRoutingTestSuite) secretSource() models.AlertManagerRoutingSource {
+	return models.AlertManagerRoutingSource{Kind: models.AlertManagerRoutingSourceSecret, Namespace: "monitoring", Name: "alertmanager-main"}
+}
+
+// TestParseMatchers 测试匹配条件解析
+func (s *AlertManagerRoutingTestSuite) TestParseMatchers() {
+	matchers, err := parseAlertManagerMatchers(`{severity="critical", team=~"a|b,c", env!=prod}`)
+	s.Require().NoError(err)
+	s.Require().Len(matchers, 3)
+	assert.Equal(s.T(), "a|b,c", matchers[1].Value)
+	assert.Equal(s.T(), `env!="prod"`, matchers[2].String())
+
+	labels := map[string]string{"severity": "critical", "team": "b,c", "env": "dev"}
+	for _, m := range matchers {
+		assert.True(s.T(), m.Matches(labels), m.String())
+	}
+	// 正则需要完整匹配
+	re, _ := parseAlertManagerMatchers(`team=~"a"`)
+	assert.False(s.T(), re[0].Matches(map[string]string{"team": "ab"}))
+	// 缺失的标签按空字符串处理
+	neg, _ := parseAlertManagerMatchers(`team!~".+"`)
+	assert.True(s.T(), neg[0].Matches(map[string]string{}))
+
+	for _, in := range []string{"", "severity", `severity=="x"`, `team=~"("`, `a="b`} {
+		_, err := parseAlertManagerMatchers(in)
+		assert.Error(s.T(), err, in)
+	}
+}
+
+// TestValidate 测试路由配置校验
+func (s *AlertManagerRoutingTestSuite) TestValidate() {
+	doc, cfg, err := parseAlertManagerYAML([]byte(testAlertManagerYAML))
+	s.Require().NoError(err)
+	errs, warnings := ValidateAlertManagerRouting(models.AlertManagerRoutingSourceSecret, cfg, doc["global"].(map[string]interface{}), alertManagerTimeIntervals(doc))
+	assert.Empty(s.T(), errs)
+	assert.Empty(s.T(), warnings)
+
+	cases := map[string]func(c *models.AlertManagerRoutingConfig){
+		"route.matchers":              func(c *models.AlertManagerRoutingConfig) { c.Route.Matchers = []string{`a="b"`} },
+		"route.routes[0].receiver":    func(c *models.AlertManagerRoutingConfig) { c.Route.Routes[0].Receiver = "missing" },
+		"route.routes[0].matchers[0]": func(c *models.AlertManagerRoutingConfig) { c.Route.Routes[0].Matchers[0] = "team" },
+		"route.routes[2].group_by":    func(c *models.AlertManagerRoutingConfig) { c.Route.Routes[2].GroupBy = []string{"...", "team"} },
+		"route.repeat_interval":       func(c *models.AlertManagerRoutingConfig) { c.Route.RepeatInterval = "0s" },
+		"route.routes[1]":             func(c *models.AlertManagerRoutingConfig) { c.Route.Routes[1].MuteTimeIntervals = []string{"weekend"} },
+		"receivers[4].name": func(c *models.AlertManagerRoutingConfig) {
+			c.Receivers = append(c.Receivers, models.AlertManagerReceiver{Name: "pager"})
+		},
+		"receivers[2].pagerduty_configs[0]": func(c *models.AlertManagerRoutingConfig) { c.Receivers[2].PagerdutyConfigs[0].ServiceKey = "x" },
+		"receivers[3].dingtalk_configs[0].url": func(c *models.AlertManagerRoutingConfig) {
+			c.Receivers[3].DingTalkConfigs[0].URL = "dingtalk:8060"
+		},
+		"inhibit_rules[0].equal": func(c *models.AlertManagerRoutingConfig) { c.InhibitRules[0].Equal = []string{"alert-name"} },
+	}
+	for path, mutate := range cases {
+		_, c, _ := parseAlertManagerYAML([]byte(testAlertManagerYAML))
+		mutate(c)
+		errs, _ := ValidateAlertManagerRouting(models.AlertManagerRoutingSourceSecret, c, doc["global"].(map[string]interface{}), []string{"offhours"})
+		s.Require().Len(errs, 1, path)
+		assert.Equal(s.T(), path, errs[0].Path)
+	}
+
+	// 未配置 global 时邮件需要自行指定 SMTP 服务器与发件人；CRD 无法确定 global，跳过检查
+	errs, _ = ValidateAlertManagerRouting(models.AlertManagerRoutingSourceSecret, cfg, map[string]interface{}{}, nil)
+	assert.Len(s.T(), errs, 2)
+	errs, _ = ValidateAlertManagerRouting(models.AlertManagerRoutingSourceCRD, cfg, nil, nil)
+	assert.Empty(s.T(), errs)
+
+	result := validateAlertManagerYAML([]byte("route: {receiver: x}\nreceivers: [{name: x}]\nunknown: 1\n"))
+	assert.False(s.T(), result.Valid)
+	assert.Equal(s.T(), "unknown", result.Errors[0].Path)
+	assert.False(s.T(), validateAlertManagerYAML([]byte("route: [")).Valid)
+}
+
+// TestSimulate 测试路由模拟：子路由优先、continue 与参数继承
+func (s *AlertManagerRoutingTestSuite) TestSimulate() {
+	_, cfg, err := parseAlertManagerYAML([]byte(testAlertManagerYAML))
+	s.Require().NoError(err)
+
+	result, err := SimulateAlertManagerRouting(cfg, map[string]string{"alertname": "HighLatency", "team": "ops", "severity": "critical"}, nil)
+	s.Require().NoError(err)
+	assert.Equal(s.T(), []string{"pager"}, result.Receivers)
+	assert.Equal(s.T(), "route.routes[0].routes[0]", result.Matches[0].Path)
+	assert.Equal(s.T(), map[string]string{"alertname": "HighLatency"}, result.Matches[0].GroupLabels)
+	assert.Equal(s.T(), alertManagerDefaultRepeatInterval, result.Matches[0].RepeatInterval)
+	assert.Empty(s.T(), result.InhibitRules)
+
+	// continue 的路由命中后继续匹配后续兄弟节点
+	result, err = SimulateAlertManagerRouting(cfg, map[string]string{"alertname": "Down", "namespace": "payments", "severity": "warning", "pod": "api-0"}, nil)
+	s.Require().NoError(err)
+	assert.Equal(s.T(), []string{"dingtalk", "default"}, result.Receivers)
+	assert.Equal(s.T(), "route.routes[2]", result.Matches[1].Path)
+	assert.Equal(s.T(), "api-0", result.Matches[1].GroupLabels["pod"])
+	assert.Equal(s.T(), []int{0}, result.InhibitRules)
+
+	// 没有子路由命中时由根路由接收
+	result, err = SimulateAlertManagerRouting(cfg, map[string]string{"alertname": "Other"}, nil)
+	s.Require().NoError(err)
+	assert.Equal(s.T(), "route", result.Matches[0].Path)
+
+	// AlertmanagerConfig 只接收本命名空间的告警
+	result, err = SimulateAlertManagerRouting(cfg, map[string]string{"namespace": "orders"}, []string{`namespace="payments"`})
+	s.Require().NoError(err)
+	assert.Empty(s.T(), result.Receivers)
+}
+
+// TestSecretRoundTrip 测试读取脱敏与保存时保留未编辑的字段
+func (s *AlertManagerRoutingTestSuite) TestSecretRoundTrip() {
+	ctx := context.Background()
+
+	sources, err := s.svc.ListSources(ctx, s.cluster)
+	s.Require().NoError(err)
+	s.Require().Len(sources, 1)
+	assert.Equal(s.T(), "alertmanager-main", sources[0].Name)
+
+	doc, err := s.svc.GetRouting(ctx, s.cluster, s.secretSource())
+	s.Require().NoError(err)
+	assert.Equal(s.T(), []string{`team="ops"`}, doc.Config.Route.Routes[0].Matchers)
+	assert.Equal(s.T(), []string{`namespace=~"payments|orders"`}, doc.Config.Route.Routes[1].Matchers)
+	assert.Equal(s.T(), []string{`severity="critical"`}, doc.Config.InhibitRules[0].SourceMatchers)
+	assert.Equal(s.T(), models.AlertManagerSecretMask, doc.Config.Receivers[1].SlackConfigs[0].APIURL)
+	assert.Equal(s.T(), []string{"opsgenie_configs"}, doc.Config.Receivers[1].OtherTypes)
+	assert.Equal(s.T(), "http://dingtalk:8060/dingtalk/ops/send", doc.Config.Receivers[3].DingTalkConfigs[0].URL)
+	assert.Equal(s.T(), []string{"offhours"}, doc.TimeIntervals)
+
+	cfg := doc.Config
+	cfg.Receivers[1].SlackConfigs[0].Channel = "#sre"
+	cfg.Receivers[3].WebhookConfigs = []models.AlertManagerWebhookConfig{{URL: "https://hooks.example.com/alert"}}
+	cfg.Route.Routes[1].MuteTimeIntervals = []string{"offhours"}
+
+	saved, validation, err := s.svc.SaveRouting(ctx, s.cluster, &models.AlertManagerRoutingSaveRequest{
+		Source: s.secretSource(), ResourceVersion: doc.ResourceVersion, Config: cfg,
+	}, "admin")
+	s.Require().NoError(err)
+	s.Require().True(validation.Valid, validation.Errors)
+	assert.Equal(s.T(), "#sre", saved.Config.Receivers[1].SlackConfigs[0].Channel)
+
+	secret, err := s.clientset.CoreV1().Secrets("monitoring").Get(ctx, "alertmanager-main", metav1.GetOptions{})
+	s.Require().NoError(err)
+	var raw map[string]interface{}
+	s.Require().NoError(yaml.Unmarshal(secret.Data[alertManagerConfigKey], &raw))
+
+	receivers := raw["receivers"].([]interface{})
+	slack := receivers[1].(map[string]interface{})
+	slackConfig := slack["slack_configs"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(s.T(), "https://hooks.slack.com/services/T000/B000/XXX", slackConfig["api_url"])
+	assert.Equal(s.T(), "#sre", slackConfig["channel"])
+	assert.NotNil(s.T(), slackConfig["http_config"])
+	assert.NotNil(s.T(), slack["opsgenie_configs"])
+	assert.Len(s.T(), receivers[3].(map[string]interface{})["webhook_configs"], 2)
+	assert.NotNil(s.T(), raw["time_intervals"])
+	assert.NotNil(s.T(), raw["global"])
+
+	// 改名后的接收器无法沿用原密钥
+	cfg = saved.Config
+	cfg.Receivers[2].Name = "pagerduty"
+	cfg.Route.Routes[0].Routes[0].Receiver = "pagerduty"
+	_, validation, err = s.svc.SaveRouting(ctx, s.cluster, &models.AlertManagerRoutingSaveRequest{Source: s.secretSource(), Config: cfg}, "admin")
+	s.Require().NoError(err)
+	s.Require().False(validation.Valid)
+	assert.Equal(s.T(), "receivers[2].pagerduty_configs[0]", validation.Errors[0].Path)
+
+	// 过期的 resourceVersion 被拒绝
+	_, _, err = s.svc.SaveRouting(ctx, s.cluster, &models.AlertManagerRoutingSaveRequest{
+		Source: s.secretSource(), ResourceVersion: doc.ResourceVersion + "0", Config: saved.Config,
+	}, "admin")
+	assert.Error(s.T(), err)
+}
+
+// TestCRDRoundTrip 测试 AlertmanagerConfig 的读写与密钥引用
+func (s *AlertManagerRoutingTestSuite) TestCRDRoundTrip() {
+	ctx := context.Background()
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "monitoring.coreos.com/v1alpha1",
+		"kind":       "AlertmanagerConfig",
+		"metadata":   map[string]interface{}{"name": "payments", "namespace": "payments"},
+		"spec": map[string]interface{}{
+			"route": map[string]interface{}{
+				"receiver": "team",
+				"groupBy":  []interface{}{"alertname"},
+				"routes": []interface{}{map[string]interface{}{
+					"receiver": "oncall",
+					"matchers": []interface{}{map[string]interface{}{"name": "severity", "value": "critical", "matchType": "="}},
+				}},
+			},
+			"receivers": []interface{}{
+				map[string]interface{}{"name": "team", "webhookConfigs": []interface{}{
+					map[string]interface{}{"urlSecret": map[string]interface{}{"name": "hooks", "key": "team"}, "sendResolved": true},
+				}},
+				map[string]interface{}{"name": "oncall"},
+			},
+			"muteTimeIntervals": []interface{}{map[string]interface{}{"name": "weekend"}},
+		},
+	}}
+	_, err := s.dynamic.Resource(alertmanagerConfigGVR).Namespace("payments").Create(ctx, obj, metav1.CreateOptions{})
+	s.Require().NoError(err)
+
+	source := models.AlertManagerRoutingSource{Kind: models.AlertManagerRoutingSourceCRD, Namespace: "payments", Name: "payments"}
+	doc, err := s.svc.GetRouting(ctx, s.cluster, source)
+	s.Require().NoError(err)
+	assert.Equal(s.T(), []string{`severity="critical"`}, doc.Config.Route.Routes[0].Matchers)
+	assert.Equal(s.T(), models.AlertManagerSecretMask, doc.Config.Receivers[0].WebhookConfigs[0].URL)
+	assert.Equal(s.T(), []string{"weekend"}, doc.TimeIntervals)
+
+	cfg := doc.Config
+	cfg.Receivers[1].SlackConfigs = []models.AlertManagerSlackConfig{{APIURL: "https://hooks.slack.com/services/T/B/C", Channel: "#payments"}}
+	cfg.Route.Routes[0].MuteTimeIntervals = []string{"weekend"}
+	cfg.InhibitRules = []models.AlertManagerInhibitRule{{SourceMatchers: []string{`severity="critical"`}, TargetMatchers: []string{`severity="warning"`}}}
+	_, validation, err := s.svc.SaveRouting(ctx, s.cluster, &models.AlertManagerRoutingSaveRequest{Source: source, Config: cfg}, "admin")
+	s.Require().NoError(err)
+	s.Require().True(validation.Valid, validation.Errors)
+
+	updated, err := s.dynamic.Resource(alertmanagerConfigGVR).Namespace("payments").Get(ctx, "payments", metav1.GetOptions{})
+	s.Require().NoError(err)
+	receivers, _, _ := unstructured.NestedSlice(updated.Object, "spec", "receivers")
+	webhook := receivers[0].(map[string]interface{})["webhookConfigs"].([]interface{})[0].(map[string]interface{})
+	assert.Nil(s.T(), webhook["url"])
+	assert.NotNil(s.T(), webhook["urlSecret"])
+	slack := receivers[1].(map[string]interface{})["slackConfigs"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(s.T(), map[string]interface{}{"name": "payments" + alertManagerReceiverSecretSuffix, "key": "oncall-slack-0-api_url"}, slack["apiURL"])
+	inhibit, _, _ := unstructured.NestedSlice(updated.Object, "spec", "inhibitRules")
+	assert.Len(s.T(), inhibit, 1)
+	intervals, _, _ := unstructured.NestedSlice(updated.Object, "spec", "muteTimeIntervals")
+	assert.Len(s.T(), intervals, 1)
+
+	secret, err := s.clientset.CoreV1().Secrets("payments").Get(ctx, "payments"+alertManagerReceiverSecretSuffix, metav1.GetOptions{})
+	s.Require().NoError(err)
+	assert.Equal(s.T(), "https://hooks.slack.com/services/T/B/C", string(secret.Data["oncall-slack-0-api_url"]))
+}
+
+// TestAlertManagerRoutingSuite 运行测试套件
+func TestAlertManagerRoutingSuite(t *testing.T) {
+	suite.Run(t, new(AlertManagerRoutingTestSuite))
+}
@@ -138,6 +138,54 @@ func (s *AlertManagerService) GetAlerts(ctx context.Context, config *models.Aler
 	return alerts, nil
 }
 
+// PostAlerts 推送告警，告警需要在 EndsAt 之前重复推送以保持触发状态
+func (s *AlertManagerService) PostAlerts(ctx context.Context, config *models.AlertManagerConfig, alerts []models.PostableAlert) error {
+	if !config.Enabled {
+		return fmt.Errorf("alertmanager 未启用")
+	}
+
+	// 构建 URL
+	alertsURL, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return fmt.Errorf("无效的 Alertmanager 端点: %w", err)
+	}
+	alertsURL.Path = "/api/v2/alerts"
+
+	// 序列化请求体
+	reqBody, err := json.Marshal(alerts)
+	if err != nil {
+		return fmt.Errorf("序列化请求失败: %w", err)
+	}
+
+	// 创建请求
+	req, err := http.NewRequestWithContext(ctx, "POST", alertsURL.String(), strings.NewReader(string(reqBody)))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// 设置认证
+	if err := s.setAuth(req, config.Auth); err != nil {
+		return fmt.Errorf("设置认证失败: %w", err)
+	}
+
+	// 执行请求
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("推送告警失败: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("推送告警失败: %s, 状态码: %d", string(body), resp.StatusCode)
+	}
+
+	return nil
+}
+
 // GetAlertGroups 获取告警分组
 func (s *AlertManagerService) GetAlertGroups(ctx context.Context, config *models.AlertManagerConfig) ([]models.AlertGroup, error) {
 	if !config.Enabled {
@@ -242,6 +290,61 @@ func (s *AlertManagerService) GetSilences(ctx context.Context, config *models.Al
 	return silences, nil
 }
 
+// GetSilence 获取单个静默规则
+func (s *AlertManagerService) GetSilence(ctx context.Context, config *models.AlertManagerConfig, silenceID string) (*models.Silence, error) {
+	if !config.Enabled {
+		return nil, fmt.Errorf("alertmanager 未启用")
+	}
+
+	// 构建 URL
+	silenceURL, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("无效的 Alertmanager 端点: %w", err)
+	}
+	silenceURL.Path = fmt.Sprintf("/api/v2/silence/%s", silenceID)
+
+	// 创建请求
+	req, err := http.NewRequestWithContext(ctx, "GET", silenceURL.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+
+	// 设置认证
+	if err := s.setAuth(req, config.Auth); err != nil {
+		return nil, fmt.Errorf("设置认证失败: %w", err)
+	}
+
+	// 执行请求
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("获取静默规则失败: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	// 读取响应
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("静默规则不存在: %s", silenceID)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("获取静默规则失败: %s, 状态码: %d", string(body), resp.StatusCode)
+	}
+
+	// 解析响应
+	var silence models.Silence
+	if err := json.Unmarshal(body, &silence); err != nil {
+		return nil, fmt.Errorf("解析静默规则响应失败: %w", err)
+	}
+
+	return &silence, nil
+}
+
 // CreateSilence 创建静默规则
 func (s *AlertManagerService) CreateSilence(ctx context.Context, config *models.AlertManagerConfig, silence *models.CreateSilenceRequest) (*models.Silence, error) {
 	if !config.Enabled {
@@ -0,0 +1,390 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+)
+
+// 容量预测参数
+const (
+	CapacityForecastDefaultThreshold = 85.0
+	capacityForecastDefaultLookback  = 30 * 24 * time.Hour
+	capacityForecastDefaultHorizon   = 90 * 24 * time.Hour
+
+	capacityForecastStep      = time.Hour        // 历史采样步长
+	capacityForecastSeason    = 24               // Holt-Winters 周期长度：按天，24 个采样点
+	capacityForecastMinPoints = 48               // 不足两天的历史不做预测
+	capacityForecastCacheTTL  = 10 * time.Minute // 历史数据缓存时间，避免健康诊断与总览重复拉取 30 天数据
+)
+
+// holtWintersGrid Holt-Winters 平滑系数的候选值，按一步预测误差平方和选取最优组合
+var holtWintersGrid = struct {
+	alpha, beta, gamma []float64
+}{
+	alpha: []float64{0.1, 0.3, 0.5, 0.7, 0.9},
+	beta:  []float64{0.01, 0.05, 0.1, 0.3},
+	gamma: []float64{0.05, 0.1, 0.3, 0.5},
+}
+
+// capacityResourceNames 风险项中的资源指标名称
+var capacityResourceNames = map[string]string{
+	models.CapacityCPURequest:    " CPU Request ",
+	models.CapacityMemoryRequest: "内存 Request ",
+	models.CapacityCPUUsage:      " CPU 使用率",
+	models.CapacityMemoryUsage:   "内存使用率",
+	models.CapacityPods:          " Pod 数量",
+}
+
+// CapacityForecastOptions 容量预测配置
+type CapacityForecastOptions struct {
+	Threshold     float64       // 默认阈值（%）
+	Lookback      time.Duration // 拟合使用的历史长度
+	Horizon       time.Duration // 向后预测的最长时间
+	NodePoolLabel string        // 节点池标签，为空时只预测集群整体
+}
+
+// capacityHistoryCache 缓存的集群容量历史
+type capacityHistoryCache struct {
+	series    []capacitySeries
+	fetchedAt time.Time
+}
+
+// CapacityForecastService 基于 Prometheus 历史的集群与节点池容量预测服务
+type CapacityForecastService struct {
+	opts CapacityForecastOptions
+
+	// now 与 history 测试时可替换
+	now     func() time.Time
+	history func(ctx context.Context, config *models.MonitoringConfig, nodePoolLabel string, start, end time.Time, step time.Duration) ([]capacitySeries, error)
+
+	mu    sync.Mutex
+	cache map[uint]capacityHistoryCache
+}
+
+// NewCapacityForecastService 创建容量预测服务
+func NewCapacityForecastService(prometheusSvc *PrometheusService, opts CapacityForecastOptions) *CapacityForecastService {
+	if opts.Threshold <= 0 || opts.Threshold > 100 {
+		opts.Threshold = CapacityForecastDefaultThreshold
+	}
+	if opts.Lookback <= 0 {
+		opts.Lookback = capacityForecastDefaultLookback
+	}
+	if opts.Horizon <= 0 {
+		opts.Horizon = capacityForecastDefaultHorizon
+	}
+	return &CapacityForecastService{
+		opts:    opts,
+		now:     time.Now,
+		history: prometheusSvc.queryCapacityHistory,
+		cache:   make(map[uint]capacityHistoryCache),
+	}
+}
+
+// Threshold 默认阈值
+func (s *CapacityForecastService) Threshold() float64 {
+	return s.opts.Threshold
+}
+
+// Forecast 预测集群整体与各节点池的容量，threshold 不大于 0 时使用默认阈值。
+// 未配置监控时返回空结果；结果按预计达到阈值的时间升序，不会达到的排在最后
+func (s *CapacityForecastService) Forecast(ctx context.Context, clusterID uint, config *models.MonitoringConfig, threshold float64) ([]models.CapacityForecast, error) {
+	if config == nil || config.Type == "disabled" {
+		return nil, nil
+	}
+	if threshold <= 0 {
+		threshold = s.opts.Threshold
+	}
+	if threshold > 100 {
+		return nil, fmt.Errorf("阈值不能超过 100%%")
+	}
+
+	series, err := s.clusterHistory(ctx, clusterID, config)
+	if err != nil {
+		return nil, err
+	}
+	forecasts := make([]models.CapacityForecast, 0, len(series))
+	for _, item := range series {
+		forecast, ok := ForecastCapacity(item.points, capacityForecastStep, threshold, s.opts.Horizon)
+		if !ok {
+			continue
+		}
+		forecast.NodePool = item.nodePool
+		forecast.Resource = item.resource
+		forecasts = append(forecasts, *forecast)
+	}
+	sort.SliceStable(forecasts, func(i, j int) bool {
+		a, b := forecasts[i], forecasts[j]
+		if (a.DaysLeft == nil) != (b.DaysLeft == nil) {
+			return a.DaysLeft != nil
+		}
+		if a.DaysLeft != nil && *a.DaysLeft != *b.DaysLeft {
+			return *a.DaysLeft < *b.DaysLeft
+		}
+		if a.NodePool != b.NodePool {
+			return a.NodePool < b.NodePool
+		}
+		return a.Resource < b.Resource
+	})
+	return forecasts, nil
+}
+
+// clusterHistory 读取集群容量历史，缓存未过期时直接使用缓存
+func (s *CapacityForecastService) clusterHistory(ctx context.Context, clusterID uint, config *models.MonitoringConfig) ([]capacitySeries, error) {
+	now := s.now()
+	s.mu.Lock()
+	cached, ok := s.cache[clusterID]
+	s.mu.Unlock()
+	if ok && now.Sub(cached.fetchedAt) < capacityForecastCacheTTL {
+		return cached.series, nil
+	}
+
+	end := now.Truncate(capacityForecastStep)
+	series, err := s.history(ctx, config, s.opts.NodePoolLabel, end.Add(-s.opts.Lookback), end, capacityForecastStep)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.cache[clusterID] = capacityHistoryCache{series: series, fetchedAt: now}
+	s.mu.Unlock()
+	return series, nil
+}
+
+// ForecastCapacity 拟合历史序列并预测达到阈值的时间，历史不足时返回 false。
+// 历史覆盖至少三个周期时，分别用线性回归与 Holt-Winters 拟合前段、以末段的预测误差择优，否则使用线性回归
+func ForecastCapacity(points []models.DataPoint, step time.Duration, threshold float64, horizon time.Duration) (*models.CapacityForecast, bool) {
+	stepSeconds := int64(step / time.Second)
+	if stepSeconds <= 0 || len(points) == 0 {
+		return nil, false
+	}
+	values := regularizeSeries(points, stepSeconds)
+	if len(values) < capacityForecastMinPoints {
+		return nil, false
+	}
+
+	model := fitCapacityModel(values, capacityForecastSeason)
+	last := points[len(points)-1].Timestamp
+	current := values[len(values)-1]
+	forecast := &models.CapacityForecast{
+		Method:       model.method,
+		Current:      roundTo(current, 2),
+		Threshold:    threshold,
+		GrowthPerDay: roundTo(model.trend*float64(24*time.Hour/step), 3),
+		Projection:   []models.DataPoint{},
+	}
+	if current >= threshold {
+		days := 0.0
+		forecast.ExhaustionAt = &last
+		forecast.DaysLeft = &days
+	}
+
+	stepsPerDay := int(24 * time.Hour / step)
+	if stepsPerDay < 1 {
+		stepsPerDay = 1
+	}
+	peak := math.Inf(-1)
+	for h := 1; h <= int(horizon/step); h++ {
+		v := model.predict(h)
+		ts := last + int64(h)*stepSeconds
+		if forecast.ExhaustionAt == nil && v >= threshold {
+			days := roundTo(float64(int64(h)*stepSeconds)/86400, 1)
+			forecast.ExhaustionAt = &ts
+			forecast.DaysLeft = &days
+		}
+		peak = math.Max(peak, v)
+		if h%stepsPerDay == 0 {
+			forecast.Projection = append(forecast.Projection, models.DataPoint{Timestamp: ts, Value: roundTo(math.Max(peak, 0), 2)})
+			peak = math.Inf(-1)
+		}
+	}
+	return forecast, true
+}
+
+// CapacityRisks 把预测范围内会达到阈值的指标转换为健康诊断风险项，返回风险项与该分类的评分；
+// criticalDays / warningDays 天内达到阈值时分别为严重风险与警告
+func CapacityRisks(forecasts []models.CapacityForecast, criticalDays, warningDays float64) ([]models.RiskItem, int) {
+	risks := []models.RiskItem{}
+	score := 100
+	for _, f := range forecasts {
+		if f.DaysLeft == nil {
+			continue
+		}
+		days := *f.DaysLeft
+		severity, deduct := "info", 0
+		switch {
+		case days <= criticalDays:
+			severity, deduct = "critical", 20
+		case days <= warningDays:
+			severity, deduct = "warning", 10
+		}
+
+		scope, resource := "集群", "cluster"
+		if f.NodePool != "" {
+			scope, resource = fmt.Sprintf("节点池 %s ", f.NodePool), f.NodePool
+		}
+		name := capacityResourceNames[f.Resource]
+		title := fmt.Sprintf("%s%s预计 %.1f 天后达到 %.0f%%", scope, name, days, f.Threshold)
+		if f.Current >= f.Threshold {
+			title = fmt.Sprintf("%s%s已超过 %.0f%%", scope, name, f.Threshold)
+		}
+		method := "线性回归"
+		if f.Method == models.ForecastHoltWinters {
+			method = "Holt-Winters"
+		}
+		solution := "扩容节点或优化工作负载资源使用"
+		switch f.Resource {
+		case models.CapacityCPURequest, models.CapacityMemoryRequest:
+			solution = "扩容节点池，或参考规格建议下调过高的资源 Request"
+		case models.CapacityPods:
+			solution = "扩容节点或调大节点的 maxPods 上限"
+		}
+
+		risks = append(risks, models.RiskItem{
+			ID:       fmt.Sprintf("capacity-%s-%s", resource, f.Resource),
+			Category: "capacity",
+			Severity: severity,
+			Title:    title,
+			Description: fmt.Sprintf("当前 %.1f%%，按%s拟合每天增长 %.2f 个百分点，预计于 %s 达到阈值",
+				f.Current, method, f.GrowthPerDay, time.Unix(*f.ExhaustionAt, 0).Format("2006-01-02")),
+			Resource: resource,
+			Solution: solution,
+		})
+		score -= deduct
+	}
+	if score < 0 {
+		score = 0
+	}
+	return risks, score
+}
+
+// capacityModel 拟合得到的预测模型，predict(h) 返回最后一个采样点之后第 h 步的预测值
+type capacityModel struct {
+	method  string
+	trend   float64 // 每步的趋势增量
+	predict func(h int) float64
+}
+
+// fitCapacityModel 选择线性回归或 Holt-Winters 并在全部历史上拟合
+func fitCapacityModel(values []float64, season int) capacityModel {
+	n := len(values)
+	if n < 3*season {
+		return fitLinear(values)
+	}
+	holdout := n / 5
+	if holdout < season {
+		holdout = season
+	}
+	train, test := values[:n-holdout], values[n-holdout:]
+	if holdoutError(fitHoltWinters(train, season), test) < holdoutError(fitLinear(train), test) {
+		return fitHoltWinters(values, season)
+	}
+	return fitLinear(values)
+}
+
+// holdoutError 模型对后续序列的平均绝对误差
+func holdoutError(model capacityModel, test []float64) float64 {
+	var sum float64
+	for i, v := range test {
+		sum += math.Abs(model.predict(i+1) - v)
+	}
+	return sum / float64(len(test))
+}
+
+// fitLinear 最小二乘线性回归
+func fitLinear(values []float64) capacityModel {
+	n := float64(len(values))
+	var sx, sy, sxx, sxy float64
+	for i, v := range values {
+		x := float64(i)
+		sx += x
+		sy += v
+		sxx += x * x
+		sxy += x * v
+	}
+	var slope float64
+	if den := n*sxx - sx*sx; den != 0 {
+		slope = (n*sxy - sx*sy) / den
+	}
+	intercept := (sy - slope*sx) / n
+	last := n - 1
+	return capacityModel{
+		method:  models.ForecastLinear,
+		trend:   slope,
+		predict: func(h int) float64 { return intercept + slope*(last+float64(h)) },
+	}
+}
+
+// fitHoltWinters 在候选系数中选取一步预测误差最小的加法 Holt-Winters 模型，要求至少两个完整周期
+func fitHoltWinters(values []float64, season int) capacityModel {
+	var best capacityModel
+	bestSSE := math.Inf(1)
+	for _, alpha := range holtWintersGrid.alpha {
+		for _, beta := range holtWintersGrid.beta {
+			for _, gamma := range holtWintersGrid.gamma {
+				model, sse := holtWinters(values, season, alpha, beta, gamma)
+				if sse < bestSSE {
+					best, bestSSE = model, sse
+				}
+			}
+		}
+	}
+	return best
+}
+
+// holtWinters 加法 Holt-Winters 指数平滑，返回模型与一步预测误差平方和。
+// 以第一个周期的均值为初始水平，前两个周期均值之差为初始趋势，第一个周期各点与均值之差为初始季节项
+func holtWinters(values []float64, season int, alpha, beta, gamma float64) (capacityModel, float64) {
+	mean := func(vs []float64) float64 {
+		var sum float64
+		for _, v := range vs {
+			sum += v
+		}
+		return sum / float64(len(vs))
+	}
+	level := mean(values[:season])
+	trend := (mean(values[season:2*season]) - level) / float64(season)
+	seasonal := make([]float64, len(values))
+	for i := 0; i < season; i++ {
+		seasonal[i] = values[i] - level
+	}
+
+	var sse float64
+	for t := season; t < len(values); t++ {
+		s := seasonal[t-season]
+		diff := values[t] - (level + trend + s)
+		sse += diff * diff
+		prevLevel := level
+		level = alpha*(values[t]-s) + (1-alpha)*(level+trend)
+		trend = beta*(level-prevLevel) + (1-beta)*trend
+		seasonal[t] = gamma*(values[t]-level) + (1-gamma)*s
+	}
+
+	lastSeason := seasonal[len(values)-season:]
+	return capacityModel{
+		method:  models.ForecastHoltWinters,
+		trend:   trend,
+		predict: func(h int) float64 { return level + float64(h)*trend + lastSeason[(h-1)%season] },
+	}, sse
+}
+
+// regularizeSeries 把采样点展开为等间隔序列，缺失的采样点沿用前一个值
+func regularizeSeries(points []models.DataPoint, step int64) []float64 {
+	values := []float64{points[0].Value}
+	prev := points[0]
+	for _, p := range points[1:] {
+		if p.Timestamp <= prev.Timestamp {
+			continue
+		}
+		for ts := prev.Timestamp + step; ts < p.Timestamp; ts += step {
+			values = append(values, prev.Value)
+		}
+		values = append(values, p.Value)
+		prev = p
+	}
+	return values
+}
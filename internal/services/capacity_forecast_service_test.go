@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// CapacityForecastServiceTestSuite 定义容量预测测试套件
+type CapacityForecastServiceTestSuite struct {
+	suite.Suite
+	svc     *CapacityForecastService
+	now     time.Time
+	fetches int
+}
+
+// SetupTest 每个测试前的设置
+func (s *CapacityForecastServiceTestSuite) SetupTest() {
+	s.now = time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC)
+	s.fetches = 0
+	s.svc = NewCapacityForecastService(nil, CapacityForecastOptions{NodePoolLabel: "node-pool"})
+	s.svc.now = func() time.Time { return s.now }
+	s.svc.history = func(ctx context.Context, config *models.MonitoringConfig, nodePoolLabel string, start, end time.Time, step time.Duration) ([]capacitySeries, error) {
+		s.fetches++
+		return []capacitySeries{
+			{resource: models.CapacityCPURequest, points: capacityPoints(100, func(i int) float64 { return 10 + 0.5*float64(i) })},
+			{resource: models.CapacityMemoryUsage, points: capacityPoints(100, func(int) float64 { return 40 })},
+			{nodePool: "gpu", resource: models.CapacityPods, points: capacityPoints(100, func(int) float64 { return 90 })},
+			{nodePool: "spot", resource: models.CapacityPods, points: capacityPoints(10, func(int) float64 { return 10 })},
+		}, nil
+	}
+}
+
+func capacityPoints(n int, value func(i int) float64) []models.DataPoint {
+	points := make([]models.DataPoint, n)
+	for i := range points {
+		points[i] = models.DataPoint{Timestamp: 1700000000 + int64(i)*3600, Value: value(i)}
+	}
+	return points
+}
+
+// TestLinearForecast 测试线性增长的达到阈值时间
+func (s *CapacityForecastServiceTestSuite) TestLinearForecast() {
+	points := capacityPoints(100, func(i int) float64 { return 10 + 0.5*float64(i) })
+	forecast, ok := ForecastCapacity(points, time.Hour, 85, 90*24*time.Hour)
+	s.Require().True(ok)
+	assert.Equal(s.T(), models.ForecastLinear, forecast.Method)
+	assert.Equal(s.T(), 59.5, forecast.Current)
+	assert.InDelta(s.T(), 12, forecast.GrowthPerDay, 1e-6)
+	s.Require().NotNil(forecast.DaysLeft)
+	assert.Equal(s.T(), 2.1, *forecast.DaysLeft, "(85 - 59.5) / 0.5 = 51 小时")
+	assert.Equal(s.T(), points[99].Timestamp+51*3600, *forecast.ExhaustionAt)
+	assert.Len(s.T(), forecast.Projection, 90)
+	assert.InDelta(s.T(), 59.5+12, forecast.Projection[0].Value, 1e-6)
+}
+
+// TestHoltWintersForecast 测试带日周期的序列选用 Holt-Winters，并按峰值预测达到阈值的时间
+func (s *CapacityForecastServiceTestSuite) TestHoltWintersForecast() {
+	points := capacityPoints(24*14, func(i int) float64 {
+		return 30 + 0.05*float64(i) + 10*math.Sin(2*math.Pi*float64(i)/24)
+	})
+	forecast, ok := ForecastCapacity(points, time.Hour, 85, 90*24*time.Hour)
+	s.Require().True(ok)
+	assert.Equal(s.T(), models.ForecastHoltWinters, forecast.Method)
+	assert.InDelta(s.T(), 1.2, forecast.GrowthPerDay, 0.1)
+	s.Require().NotNil(forecast.DaysLeft)
+	// 峰值 30 + 0.05i + 10 在 i≈900 时达到 85，比仅看趋势线早约 8 天
+	assert.InDelta(s.T(), float64(900-335)/24, *forecast.DaysLeft, 1)
+}
+
+// TestForecastEdgeCases 测试已超过阈值、无增长与历史不足
+func (s *CapacityForecastServiceTestSuite) TestForecastEdgeCases() {
+	points := capacityPoints(48, func(int) float64 { return 90 })
+	forecast, ok := ForecastCapacity(points, time.Hour, 85, 90*24*time.Hour)
+	s.Require().True(ok)
+	s.Require().NotNil(forecast.DaysLeft)
+	assert.Equal(s.T(), 0.0, *forecast.DaysLeft)
+	assert.Equal(s.T(), points[47].Timestamp, *forecast.ExhaustionAt)
+
+	forecast, ok = ForecastCapacity(capacityPoints(48, func(int) float64 { return 50 }), time.Hour, 85, 90*24*time.Hour)
+	s.Require().True(ok)
+	assert.Nil(s.T(), forecast.DaysLeft)
+	assert.Nil(s.T(), forecast.ExhaustionAt)
+
+	_, ok = ForecastCapacity(capacityPoints(47, func(int) float64 { return 50 }), time.Hour, 85, 90*24*time.Hour)
+	assert.False(s.T(), ok)
+}
+
+// TestRegularizeSeries 测试缺失采样点沿用前值
+func (s *CapacityForecastServiceTestSuite) TestRegularizeSeries() {
+	values := regularizeSeries([]models.DataPoint{
+		{Timestamp: 0, Value: 1},
+		{Timestamp: 3600, Value: 2},
+		{Timestamp: 3600, Value: 9},
+		{Timestamp: 4 * 3600, Value: 5},
+	}, 3600)
+	assert.Equal(s.T(), []float64{1, 2, 2, 2, 5}, values)
+}
+
+// TestCapacityRisks 测试按剩余天数生成风险项
+func (s *CapacityForecastServiceTestSuite) TestCapacityRisks() {
+	days := func(v float64) *float64 { return &v }
+	at := int64(1710000000)
+	risks, score := CapacityRisks([]models.CapacityForecast{
+		{NodePool: "gpu", Resource: models.CapacityPods, Current: 86, Threshold: 85, DaysLeft: days(0), ExhaustionAt: &at},
+		{Resource: models.CapacityCPURequest, Current: 70, Threshold: 85, DaysLeft: days(20), ExhaustionAt: &at, Method: models.ForecastHoltWinters},
+		{Resource: models.CapacityMemoryUsage, Current: 60, Threshold: 85, DaysLeft: days(60), ExhaustionAt: &at},
+		{Resource: models.CapacityCPUUsage, Current: 20, Threshold: 85},
+	}, 7, 30)
+	s.Require().Len(risks, 3)
+	assert.Equal(s.T(), 70, score)
+
+	assert.Equal(s.T(), "capacity-gpu-pods", risks[0].ID)
+	assert.Equal(s.T(), "critical", risks[0].Severity)
+	assert.Equal(s.T(), "gpu", risks[0].Resource)
+	assert.Contains(s.T(), risks[0].Title, "已超过 85%")
+
+	assert.Equal(s.T(), "warning", risks[1].Severity)
+	assert.Equal(s.T(), "capacity", risks[1].Category)
+	assert.Contains(s.T(), risks[1].Title, "预计 20.0 天后")
+	assert.Contains(s.T(), risks[1].Description, "Holt-Winters")
+
+	assert.Equal(s.T(), "info", risks[2].Severity)
+
+	// 调整天数阈值后 20 天也视为严重风险
+	risks, score = CapacityRisks([]models.CapacityForecast{
+		{Resource: models.CapacityCPURequest, Current: 70, Threshold: 85, DaysLeft: days(20), ExhaustionAt: &at},
+	}, 21, 60)
+	assert.Equal(s.T(), "critical", risks[0].Severity)
+	assert.Equal(s.T(), 80, score)
+}
+
+// TestForecastCache 测试预测排序、阈值校验与历史缓存
+func (s *CapacityForecastServiceTestSuite) TestForecastCache() {
+	config := &models.MonitoringConfig{Type: "prometheus"}
+	forecasts, err := s.svc.Forecast(context.Background(), 1, config, 0)
+	s.Require().NoError(err)
+	s.Require().Len(forecasts, 3, "历史不足的节点池不做预测")
+	assert.Equal(s.T(), "gpu", forecasts[0].NodePool)
+	assert.Equal(s.T(), models.CapacityCPURequest, forecasts[1].Resource)
+	assert.Nil(s.T(), forecasts[2].DaysLeft)
+	assert.Equal(s.T(), CapacityForecastDefaultThreshold, forecasts[0].Threshold)
+
+	_, err = s.svc.Forecast(context.Background(), 1, config, 95)
+	s.Require().NoError(err)
+	assert.Equal(s.T(), 1, s.fetches)
+
+	s.now = s.now.Add(11 * time.Minute)
+	_, err = s.svc.Forecast(context.Background(), 1, config, 0)
+	s.Require().NoError(err)
+	assert.Equal(s.T(), 2, s.fetches)
+
+	_, err = s.svc.Forecast(context.Background(), 1, config, 120)
+	assert.Error(s.T(), err)
+
+	forecasts, err = s.svc.Forecast(context.Background(), 2, &models.MonitoringConfig{Type: "disabled"}, 0)
+	s.Require().NoError(err)
+	assert.Empty(s.T(), forecasts)
+	assert.Equal(s.T(), 2, s.fetches)
+}
+
+// TestQueryCapacityHistory 测试按节点池分组的历史查询
+func (s *CapacityForecastServiceTestSuite) TestQueryCapacityHistory() {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query().Get("query")
+		queries = append(queries, q)
+		result := `[{"metric":{},"values":[[1700000000,"40"],[1700003600,"NaN"]]}]`
+		if strings.Contains(q, "kube_node_labels") {
+			result = `[{"metric":{"label_node_pool":"gpu"},"values":[[1700000000,"70"]]},{"metric":{"label_node_pool":""},"values":[[1700000000,"10"]]}]`
+		}
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":` + result + `}}`))
+	}))
+	defer server.Close()
+
+	config := &models.MonitoringConfig{Type: "prometheus", Endpoint: server.URL, Labels: map[string]string{"cluster": "prod"}}
+	series, err := NewPrometheusService().queryCapacityHistory(context.Background(), config, "node-pool", s.now.Add(-time.Hour), s.now, time.Hour)
+	s.Require().NoError(err)
+	s.Require().Len(series, 8)
+	assert.Equal(s.T(), []models.DataPoint{{Timestamp: 1700000000, Value: 40}}, series[0].points)
+	assert.Equal(s.T(), "gpu", series[5].nodePool)
+	assert.Equal(s.T(), models.CapacityCPURequest, series[5].resource)
+	for _, q := range queries {
+		assert.Contains(s.T(), q, `cluster="prod"`)
+	}
+}
+
+// TestCapacityForecastServiceTestSuite 运行容量预测测试套件
+func TestCapacityForecastServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(CapacityForecastServiceTestSuite))
+}
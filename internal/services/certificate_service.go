@@ -0,0 +1,688 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+	"github.com/clay-wangzhi/KubePolaris/pkg/logger"
+
+	"gorm.io/gorm"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	// certificateScanTimeout 单个集群证书扫描的超时时间
+	certificateScanTimeout = 2 * time.Minute
+	// certificateDialTimeout 读取 API Server、kubelet 服务端证书的连接超时时间
+	certificateDialTimeout = 5 * time.Second
+	// certificateKubeletConcurrency 并发读取 kubelet 证书的节点数
+	certificateKubeletConcurrency = 10
+)
+
+// apiServiceGVR 聚合 APIService 资源
+var apiServiceGVR = schema.GroupVersionResource{Group: "apiregistration.k8s.io", Version: "v1", Resource: "apiservices"}
+
+// certificateStatusRank 证书状态的严重程度
+var certificateStatusRank = map[string]int{
+	"":                               0,
+	models.CertificateStatusValid:    0,
+	models.CertificateStatusWarning:  1,
+	models.CertificateStatusCritical: 2,
+	models.CertificateStatusExpired:  3,
+}
+
+// CertificateOptions 证书扫描参数
+type CertificateOptions struct {
+	Interval     time.Duration // 定时扫描周期，0 表示不定时扫描
+	WarningDays  int           // 通知与状态计算的告警阈值
+	CriticalDays int           // 通知与状态计算的严重阈值
+}
+
+// collectedCertificate 扫描到的一张证书及其所在对象
+type collectedCertificate struct {
+	source     string
+	namespace  string
+	name       string
+	references []string
+	cert       *x509.Certificate
+}
+
+// CertificateService 集群证书清单：定时扫描各集群的 API Server、kubeconfig 客户端证书、kubelet、
+// Ingress 使用的 TLS Secret 以及 Webhook / APIService 的 CA Bundle，证书临近过期时发送通知
+type CertificateService struct {
+	db              *gorm.DB
+	clusterService  *ClusterService
+	notificationSvc *NotificationService
+	opts            CertificateOptions
+
+	// collect 收集集群证书，返回无法读取的来源；now 获取当前时间，测试时可替换
+	collect func(ctx context.Context, cluster *models.Cluster) ([]collectedCertificate, []string, error)
+	now     func() time.Time
+}
+
+// NewCertificateService 创建证书清单服务
+func NewCertificateService(db *gorm.DB, clusterService *ClusterService, notificationSvc *NotificationService, opts CertificateOptions) *CertificateService {
+	if opts.WarningDays <= 0 {
+		opts.WarningDays = 30
+	}
+	if opts.CriticalDays <= 0 || opts.CriticalDays > opts.WarningDays {
+		opts.CriticalDays = min(7, opts.WarningDays)
+	}
+	return &CertificateService{
+		db:              db,
+		clusterService:  clusterService,
+		notificationSvc: notificationSvc,
+		opts:            opts,
+		collect:         collectClusterCertificates,
+		now:             time.Now,
+	}
+}
+
+// Start 启动定时扫描，ctx 取消后退出
+func (s *CertificateService) Start(ctx context.Context) {
+	if s.opts.Interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(s.opts.Interval)
+		defer ticker.Stop()
+		for {
+			s.ScanAll(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	logger.Info("定时证书扫描已启动", "interval", s.opts.Interval)
+}
+
+// ScanAll 依次扫描所有集群，单个集群失败不影响其他集群
+func (s *CertificateService) ScanAll(ctx context.Context) {
+	clusters, err := s.clusterService.GetAllClusters()
+	if err != nil {
+		logger.Error("定时证书扫描获取集群列表失败", "error", err)
+		return
+	}
+	for _, cluster := range clusters {
+		if ctx.Err() != nil {
+			return
+		}
+		scanCtx, cancel := context.WithTimeout(ctx, certificateScanTimeout)
+		_, err := s.Scan(scanCtx, cluster)
+		cancel()
+		if err != nil {
+			logger.Warn("定时证书扫描失败", "cluster", cluster.Name, "error", err)
+		}
+	}
+}
+
+// Scan 扫描集群证书并更新证书清单：新证书写入，已不存在的证书删除，状态升级的证书合并为一条通知。
+// API Server 证书的过期时间同时写入集群的 cert_expire_at
+func (s *CertificateService) Scan(ctx context.Context, cluster *models.Cluster) (*models.CertificateScanResult, error) {
+	collected, scanErrors, err := s.collect(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+
+	var existing []models.ClusterCertificate
+	if err := s.db.Where("cluster_id = ?", cluster.ID).Find(&existing).Error; err != nil {
+		return nil, fmt.Errorf("查询证书清单失败: %w", err)
+	}
+	certs, stale := reconcileCertificates(cluster.ID, existing, collected, now)
+
+	result := &models.CertificateScanResult{Certificates: len(certs), Errors: scanErrors, ScannedAt: now}
+	var notify []models.ClusterCertificate
+	var apiServerExpireAt *time.Time
+	for i := range certs {
+		cert := &certs[i]
+		s.fillStatus(cert, now)
+		switch cert.Status {
+		case models.CertificateStatusExpired:
+			result.Expired++
+		case models.CertificateStatusCritical:
+			result.Critical++
+		case models.CertificateStatusWarning:
+			result.Warning++
+		}
+		// 状态升级时通知一次，续期或恢复后重置
+		if certificateStatusRank[cert.Status] > certificateStatusRank[cert.NotifiedLevel] {
+			cert.NotifiedLevel = cert.Status
+			notify = append(notify, *cert)
+		} else if cert.Status == models.CertificateStatusValid {
+			cert.NotifiedLevel = ""
+		}
+		if cert.Source == models.CertificateSourceAPIServer && (apiServerExpireAt == nil || cert.NotAfter.Before(*apiServerExpireAt)) {
+			notAfter := cert.NotAfter
+			apiServerExpireAt = &notAfter
+		}
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if len(stale) > 0 {
+			if err := tx.Delete(&models.ClusterCertificate{}, stale).Error; err != nil {
+				return err
+			}
+		}
+		for i := range certs {
+			if err := tx.Save(&certs[i]).Error; err != nil {
+				return err
+			}
+		}
+		if apiServerExpireAt != nil {
+			return tx.Model(&models.Cluster{}).Where("id = ?", cluster.ID).Update("cert_expire_at", *apiServerExpireAt).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("保存证书清单失败: %w", err)
+	}
+	if apiServerExpireAt != nil {
+		cluster.CertExpireAt = apiServerExpireAt
+	}
+
+	s.notificationSvc.CertificatesExpiring(cluster, notify)
+	return result, nil
+}
+
+// reconcileCertificates 按来源、对象与指纹合并扫描结果与已有清单，返回需要保存的证书与需要删除的 ID
+func reconcileCertificates(clusterID uint, existing []models.ClusterCertificate, collected []collectedCertificate, now time.Time) ([]models.ClusterCertificate, []uint) {
+	key := func(source, namespace, name, fingerprint string) string {
+		return strings.Join([]string{source, namespace, name, fingerprint}, "/")
+	}
+	previous := make(map[string]models.ClusterCertificate, len(existing))
+	for _, cert := range existing {
+		previous[key(cert.Source, cert.Namespace, cert.Name, cert.Fingerprint)] = cert
+	}
+
+	certs := make([]models.ClusterCertificate, 0, len(collected))
+	seen := make(map[string]bool, len(collected))
+	for _, c := range collected {
+		fingerprint := certificateFingerprint(c.cert)
+		k := key(c.source, c.namespace, c.name, fingerprint)
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		cert := previous[k]
+		cert.ClusterID = clusterID
+		cert.Source = c.source
+		cert.Namespace = c.namespace
+		cert.Name = c.name
+		cert.Fingerprint = fingerprint
+		cert.Subject = truncateString(c.cert.Subject.String(), 512)
+		cert.Issuer = truncateString(c.cert.Issuer.String(), 512)
+		cert.DNSNames = strings.Join(c.cert.DNSNames, ",")
+		cert.SerialNumber = truncateString(c.cert.SerialNumber.Text(16), 128)
+		cert.IsCA = c.cert.IsCA
+		cert.NotBefore = c.cert.NotBefore
+		cert.NotAfter = c.cert.NotAfter
+		cert.References = strings.Join(c.references, ",")
+		cert.ScannedAt = now
+		certs = append(certs, cert)
+	}
+
+	var stale []uint
+	for k, cert := range previous {
+		if !seen[k] {
+			stale = append(stale, cert.ID)
+		}
+	}
+	sort.Slice(stale, func(i, j int) bool { return stale[i] < stale[j] })
+	return certs, stale
+}
+
+// ========== 查询 ==========
+
+// List 获取集群证书清单，按过期时间升序
+func (s *CertificateService) List(clusterID uint, query *models.CertificateQuery) ([]models.ClusterCertificate, error) {
+	db := s.db.Where("cluster_id = ?", clusterID)
+	if query.Source != "" {
+		db = db.Where("source = ?", query.Source)
+	}
+	var certs []models.ClusterCertificate
+	if err := db.Order("not_after, id").Find(&certs).Error; err != nil {
+		return nil, fmt.Errorf("查询证书清单失败: %w", err)
+	}
+	now := s.now()
+	result := make([]models.ClusterCertificate, 0, len(certs))
+	for i := range certs {
+		s.fillStatus(&certs[i], now)
+		if query.Status == "" || certs[i].Status == query.Status {
+			result = append(result, certs[i])
+		}
+	}
+	return result, nil
+}
+
+// Expiring 获取所有集群中指定天数内过期（含已过期）的证书，按过期时间升序
+func (s *CertificateService) Expiring(query *models.ExpiringCertificateQuery) ([]models.ClusterCertificate, error) {
+	days := query.Days
+	if days <= 0 {
+		days = 30
+	}
+	now := s.now()
+	var certs []models.ClusterCertificate
+	if err := s.db.Where("not_after <= ?", now.Add(time.Duration(days)*24*time.Hour)).Order("not_after, id").Find(&certs).Error; err != nil {
+		return nil, fmt.Errorf("查询证书清单失败: %w", err)
+	}
+
+	clusters, err := s.clusterService.GetAllClusters()
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[uint]string, len(clusters))
+	for _, cluster := range clusters {
+		names[cluster.ID] = cluster.Name
+	}
+	result := make([]models.ClusterCertificate, 0, len(certs))
+	for i := range certs {
+		// 已删除集群的证书不展示
+		name, ok := names[certs[i].ClusterID]
+		if !ok {
+			continue
+		}
+		certs[i].ClusterName = name
+		s.fillStatus(&certs[i], now)
+		result = append(result, certs[i])
+	}
+	return result, nil
+}
+
+// fillStatus 按服务的通知阈值计算剩余天数与状态
+func (s *CertificateService) fillStatus(cert *models.ClusterCertificate, now time.Time) {
+	cert.DaysLeft, cert.Status = certificateStatus(cert.NotAfter, now, s.opts.WarningDays, s.opts.CriticalDays)
+}
+
+// certificateStatus 计算剩余天数（不足一天按 0 天）与过期状态
+func certificateStatus(notAfter, now time.Time, warningDays, criticalDays int) (int, string) {
+	if !now.Before(notAfter) {
+		return 0, models.CertificateStatusExpired
+	}
+	daysLeft := int(notAfter.Sub(now).Hours() / 24)
+	switch {
+	case daysLeft <= criticalDays:
+		return daysLeft, models.CertificateStatusCritical
+	case daysLeft <= warningDays:
+		return daysLeft, models.CertificateStatusWarning
+	default:
+		return daysLeft, models.CertificateStatusValid
+	}
+}
+
+// CertificateRisks 把证书清单转换为健康诊断风险项：已过期与严重阈值内为 critical，告警阈值内为 warning
+func CertificateRisks(certs []models.ClusterCertificate, now time.Time, warningDays, criticalDays int) []models.RiskItem {
+	risks := []models.RiskItem{}
+	for _, cert := range certs {
+		daysLeft, status := certificateStatus(cert.NotAfter, now, warningDays, criticalDays)
+		if status == models.CertificateStatusValid {
+			continue
+		}
+		severity := "warning"
+		title := fmt.Sprintf("证书将在 %d 天后过期", daysLeft)
+		switch status {
+		case models.CertificateStatusExpired:
+			severity = "critical"
+			title = "证书已过期"
+		case models.CertificateStatusCritical:
+			severity = "critical"
+		}
+		object := cert.Name
+		if cert.Namespace != "" {
+			object = cert.Namespace + "/" + cert.Name
+		}
+		risks = append(risks, models.RiskItem{
+			ID:          fmt.Sprintf("cert-%s-%s-%s", cert.Source, strings.ReplaceAll(object, "/", "-"), cert.Fingerprint[:min(12, len(cert.Fingerprint))]),
+			Category:    "certificate",
+			Severity:    severity,
+			Title:       title,
+			Description: fmt.Sprintf("%s %s 的证书 %s 过期时间 %s", certificateSourceNames[cert.Source], object, cert.Subject, cert.NotAfter.Format("2006-01-02 15:04")),
+			Resource:    cert.Name,
+			Namespace:   cert.Namespace,
+			Solution:    certificateSolutions[cert.Source],
+		})
+	}
+	sortRiskItems(risks)
+	return risks
+}
+
+// certificateSourceNames 证书来源的中文名称
+var certificateSourceNames = map[string]string{
+	models.CertificateSourceAPIServer:  "API Server",
+	models.CertificateSourceKubeconfig: "kubeconfig 用户",
+	models.CertificateSourceKubelet:    "kubelet 节点",
+	models.CertificateSourceTLSSecret:  "TLS Secret",
+	models.CertificateSourceWebhook:    "Webhook 配置",
+	models.CertificateSourceAPIService: "APIService",
+}
+
+// certificateSolutions 各来源证书的续期建议
+var certificateSolutions = map[string]string{
+	models.CertificateSourceAPIServer:  "使用 kubeadm certs renew 或云厂商控制台续期 API Server 证书",
+	models.CertificateSourceKubeconfig: "重新生成 kubeconfig 客户端证书，并在集群设置中更新 kubeconfig",
+	models.CertificateSourceKubelet:    "开启 kubelet serverTLSBootstrap 与证书轮转，或手动续期 kubelet 证书",
+	models.CertificateSourceTLSSecret:  "续期 Ingress 证书（如通过 cert-manager），并更新对应的 TLS Secret",
+	models.CertificateSourceWebhook:    "续期 Webhook 服务证书并更新 Webhook 配置中的 caBundle",
+	models.CertificateSourceAPIService: "续期扩展 API 服务证书并更新 APIService 的 caBundle",
+}
+
+// ========== 收集 ==========
+
+// collectClusterCertificates 收集集群中各来源的证书，单个来源失败时记录错误并继续
+func collectClusterCertificates(ctx context.Context, cluster *models.Cluster) ([]collectedCertificate, []string, error) {
+	client, err := NewK8sClientForCluster(cluster)
+	if err != nil {
+		return nil, nil, fmt.Errorf("创建K8s客户端失败: %w", err)
+	}
+	dynamicClient, err := dynamic.NewForConfig(client.GetRestConfig())
+	if err != nil {
+		return nil, nil, fmt.Errorf("创建K8s客户端失败: %w", err)
+	}
+
+	var certs []collectedCertificate
+	var scanErrors []string
+	add := func(source string, found []collectedCertificate, err error) {
+		certs = append(certs, found...)
+		if err != nil {
+			scanErrors = append(scanErrors, fmt.Sprintf("%s: %v", certificateSourceNames[source], err))
+		}
+	}
+
+	if cert, err := fetchAPIServerCertificate(ctx, client.GetRestConfig()); err != nil {
+		add(models.CertificateSourceAPIServer, nil, err)
+	} else {
+		add(models.CertificateSourceAPIServer, []collectedCertificate{{source: models.CertificateSourceAPIServer, name: cluster.Name, cert: cert}}, nil)
+	}
+	found, err := kubeconfigCertificates(cluster.KubeconfigEnc)
+	add(models.CertificateSourceKubeconfig, found, err)
+	found, err = kubeletCertificates(ctx, client.GetClientset(), fetchTLSCertificate)
+	add(models.CertificateSourceKubelet, found, err)
+	found, err = ingressTLSCertificates(ctx, client.GetClientset())
+	add(models.CertificateSourceTLSSecret, found, err)
+	found, err = webhookCertificates(ctx, client.GetClientset())
+	add(models.CertificateSourceWebhook, found, err)
+	found, err = apiServiceCertificates(ctx, dynamicClient)
+	add(models.CertificateSourceAPIService, found, err)
+	return certs, scanErrors, nil
+}
+
+// fetchAPIServerCertificate 与 API Server 握手读取服务端证书
+func fetchAPIServerCertificate(ctx context.Context, config *rest.Config) (*x509.Certificate, error) {
+	u, err := url.Parse(config.Host)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("无效的 API Server 地址: %s", config.Host)
+	}
+	if u.Scheme == "http" {
+		return nil, fmt.Errorf("API Server 未启用 TLS")
+	}
+	address := u.Host
+	if u.Port() == "" {
+		address = net.JoinHostPort(u.Hostname(), "443")
+	}
+	serverName := config.TLSClientConfig.ServerName
+	if serverName == "" {
+		serverName = u.Hostname()
+	}
+	return fetchTLSCertificate(ctx, address, serverName)
+}
+
+// fetchTLSCertificate TLS 握手读取对端的叶子证书，只读取证书不校验信任链
+func fetchTLSCertificate(ctx context.Context, address, serverName string) (*x509.Certificate, error) {
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: certificateDialTimeout},
+		Config:    &tls.Config{ServerName: serverName, InsecureSkipVerify: true}, //nolint:gosec // 只读取证书信息，不传输数据
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	peers := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(peers) == 0 {
+		return nil, fmt.Errorf("%s 未返回证书", address)
+	}
+	return peers[0], nil
+}
+
+// kubeconfigCertificates 解析已保存 kubeconfig 中各用户的客户端证书
+func kubeconfigCertificates(kubeconfig string) ([]collectedCertificate, error) {
+	if kubeconfig == "" {
+		return nil, nil
+	}
+	config, err := clientcmd.Load([]byte(kubeconfig))
+	if err != nil {
+		return nil, fmt.Errorf("解析kubeconfig失败: %w", err)
+	}
+	var certs []collectedCertificate
+	for name, auth := range config.AuthInfos {
+		if len(auth.ClientCertificateData) == 0 {
+			continue
+		}
+		parsed := parsePEMCertificates(auth.ClientCertificateData)
+		if len(parsed) == 0 {
+			return certs, fmt.Errorf("用户 %s 的客户端证书无法解析", name)
+		}
+		certs = append(certs, collectedCertificate{source: models.CertificateSourceKubeconfig, name: name, cert: parsed[0]})
+	}
+	return certs, nil
+}
+
+// kubeletCertificates 并发连接各节点的 kubelet 端口读取服务端证书，无法连接的节点合并为一条错误
+func kubeletCertificates(ctx context.Context, client kubernetes.Interface, fetch func(ctx context.Context, address, serverName string) (*x509.Certificate, error)) ([]collectedCertificate, error) {
+	nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var mu sync.Mutex
+	var certs []collectedCertificate
+	var failed []string
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, certificateKubeletConcurrency)
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		address := ""
+		for _, addr := range node.Status.Addresses {
+			if addr.Type == corev1.NodeInternalIP {
+				address = addr.Address
+				break
+			}
+		}
+		if address == "" {
+			continue
+		}
+		port := int(node.Status.DaemonEndpoints.KubeletEndpoint.Port)
+		if port == 0 {
+			port = 10250
+		}
+
+		wg.Add(1)
+		go func(name, address string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			cert, err := fetch(ctx, address, name)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failed = append(failed, name)
+				return
+			}
+			certs = append(certs, collectedCertificate{source: models.CertificateSourceKubelet, name: name, cert: cert})
+		}(node.Name, net.JoinHostPort(address, strconv.Itoa(port)))
+	}
+	wg.Wait()
+
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		if len(failed) > 5 {
+			failed = append(failed[:5], "...")
+		}
+		return certs, fmt.Errorf("%d 个节点无法连接 kubelet: %s", len(failed), strings.Join(failed, ", "))
+	}
+	return certs, nil
+}
+
+// ingressTLSCertificates 读取 Ingress 引用的 kubernetes.io/tls Secret 中的证书
+func ingressTLSCertificates(ctx context.Context, client kubernetes.Interface) ([]collectedCertificate, error) {
+	ingresses, err := client.NetworkingV1().Ingresses("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	references := make(map[string][]string)
+	for _, ing := range ingresses.Items {
+		for _, t := range ing.Spec.TLS {
+			if t.SecretName == "" {
+				continue
+			}
+			key := ing.Namespace + "/" + t.SecretName
+			if !containsString(references[key], ing.Name) {
+				references[key] = append(references[key], ing.Name)
+			}
+		}
+	}
+	if len(references) == 0 {
+		return nil, nil
+	}
+
+	secrets, err := client.CoreV1().Secrets("").List(ctx, metav1.ListOptions{FieldSelector: "type=" + string(corev1.SecretTypeTLS)})
+	if err != nil {
+		return nil, err
+	}
+	var certs []collectedCertificate
+	for _, secret := range secrets.Items {
+		refs, ok := references[secret.Namespace+"/"+secret.Name]
+		if !ok || secret.Type != corev1.SecretTypeTLS {
+			continue
+		}
+		if parsed := parsePEMCertificates(secret.Data[corev1.TLSCertKey]); len(parsed) > 0 {
+			certs = append(certs, collectedCertificate{
+				source: models.CertificateSourceTLSSecret, namespace: secret.Namespace, name: secret.Name, references: refs, cert: parsed[0],
+			})
+		}
+	}
+	return certs, nil
+}
+
+// webhookCertificates 读取准入 Webhook 配置中 caBundle 的证书，同一配置中相同的证书只记录一次
+func webhookCertificates(ctx context.Context, client kubernetes.Interface) ([]collectedCertificate, error) {
+	var certs []collectedCertificate
+	addBundle := func(name, webhook string, bundle []byte) {
+		for _, cert := range parsePEMCertificates(bundle) {
+			fingerprint := certificateFingerprint(cert)
+			found := false
+			for i := range certs {
+				if certs[i].name == name && certificateFingerprint(certs[i].cert) == fingerprint {
+					if !containsString(certs[i].references, webhook) {
+						certs[i].references = append(certs[i].references, webhook)
+					}
+					found = true
+					break
+				}
+			}
+			if !found {
+				certs = append(certs, collectedCertificate{source: models.CertificateSourceWebhook, name: name, references: []string{webhook}, cert: cert})
+			}
+		}
+	}
+
+	mutating, err := client.AdmissionregistrationV1().MutatingWebhookConfigurations().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, config := range mutating.Items {
+		for _, w := range config.Webhooks {
+			addBundle(config.Name, w.Name, w.ClientConfig.CABundle)
+		}
+	}
+	validating, err := client.AdmissionregistrationV1().ValidatingWebhookConfigurations().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return certs, err
+	}
+	for _, config := range validating.Items {
+		for _, w := range config.Webhooks {
+			addBundle(config.Name, w.Name, w.ClientConfig.CABundle)
+		}
+	}
+	return certs, nil
+}
+
+// apiServiceCertificates 读取聚合 APIService 中 caBundle 的证书
+func apiServiceCertificates(ctx context.Context, client dynamic.Interface) ([]collectedCertificate, error) {
+	list, err := client.Resource(apiServiceGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var certs []collectedCertificate
+	for _, item := range list.Items {
+		encoded, _, _ := unstructured.NestedString(item.Object, "spec", "caBundle")
+		if encoded == "" {
+			continue
+		}
+		bundle, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			continue
+		}
+		for _, cert := range parsePEMCertificates(bundle) {
+			certs = append(certs, collectedCertificate{source: models.CertificateSourceAPIService, name: item.GetName(), cert: cert})
+		}
+	}
+	return certs, nil
+}
+
+// parsePEMCertificates 解析 PEM 中的全部证书，忽略无法解析的块
+func parsePEMCertificates(data []byte) []*x509.Certificate {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return certs
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+			certs = append(certs, cert)
+		}
+	}
+}
+
+// certificateFingerprint 证书的 SHA-256 指纹
+func certificateFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// truncateString 截断超过列长度的字段
+func truncateString(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,300 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// CertificateServiceTestSuite 定义证书清单测试套件
+type CertificateServiceTestSuite struct {
+	suite.Suite
+	now time.Time
+}
+
+// SetupTest 每个测试前的设置
+func (s *CertificateServiceTestSuite) SetupTest() {
+	s.now = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// newTestCertificate 生成自签名证书，返回证书与 PEM
+func (s *CertificateServiceTestSuite) newTestCertificate(commonName string, notAfter time.Time, dnsNames ...string) (*x509.Certificate, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	s.Require().NoError(err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(notAfter.Unix()),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     dnsNames,
+		NotBefore:    notAfter.AddDate(-1, 0, 0),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	s.Require().NoError(err)
+	cert, err := x509.ParseCertificate(der)
+	s.Require().NoError(err)
+	return cert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// TestStatus 测试剩余天数与过期状态
+func (s *CertificateServiceTestSuite) TestStatus() {
+	cases := []struct {
+		notAfter time.Time
+		days     int
+		status   string
+	}{
+		{s.now.AddDate(0, 0, 90), 90, models.CertificateStatusValid},
+		{s.now.AddDate(0, 0, 30), 30, models.CertificateStatusWarning},
+		{s.now.AddDate(0, 0, 7), 7, models.CertificateStatusCritical},
+		{s.now.Add(3 * time.Hour), 0, models.CertificateStatusCritical},
+		{s.now, 0, models.CertificateStatusExpired},
+		{s.now.AddDate(0, 0, -3), 0, models.CertificateStatusExpired},
+	}
+	for _, c := range cases {
+		days, status := certificateStatus(c.notAfter, s.now, 30, 7)
+		assert.Equal(s.T(), c.days, days, c.notAfter.String())
+		assert.Equal(s.T(), c.status, status, c.notAfter.String())
+	}
+}
+
+// TestRisks 测试证书清单转换为诊断风险
+func (s *CertificateServiceTestSuite) TestRisks() {
+	certs := []models.ClusterCertificate{
+		{Source: models.CertificateSourceTLSSecret, Namespace: "shop", Name: "web-tls", Fingerprint: "aabbccddeeff00112233", Subject: "CN=shop.example.com", NotAfter: s.now.AddDate(0, 0, 20)},
+		{Source: models.CertificateSourceAPIServer, Name: "prod", Fingerprint: "0123456789abcdef", Subject: "CN=kube-apiserver", NotAfter: s.now.AddDate(0, 0, -1)},
+		{Source: models.CertificateSourceKubelet, Name: "node-1", Fingerprint: "ffff", NotAfter: s.now.AddDate(1, 0, 0)},
+	}
+	risks := CertificateRisks(certs, s.now, 30, 7)
+	s.Require().Len(risks, 2)
+	assert.Equal(s.T(), "cert-apiserver-prod-0123456789ab", risks[0].ID)
+	assert.Equal(s.T(), "critical", risks[0].Severity)
+	assert.Equal(s.T(), "证书已过期", risks[0].Title)
+	assert.Equal(s.T(), "certificate", risks[0].Category)
+	assert.Equal(s.T(), "cert-tls_secret-shop-web-tls-aabbccddeeff", risks[1].ID)
+	assert.Equal(s.T(), "warning", risks[1].Severity)
+	assert.Equal(s.T(), "证书将在 20 天后过期", risks[1].Title)
+	assert.Equal(s.T(), "shop", risks[1].Namespace)
+	assert.Contains(s.T(), risks[1].Description, "TLS Secret shop/web-tls")
+
+	assert.Len(s.T(), CertificateRisks(certs, s.now, 10, 5), 1, "调高阈值后只报告已过期的证书")
+}
+
+// TestReconcile 测试扫描结果与已有清单合并
+func (s *CertificateServiceTestSuite) TestReconcile() {
+	renewed, _ := s.newTestCertificate("shop.example.com", s.now.AddDate(0, 3, 0), "shop.example.com")
+	kept, _ := s.newTestCertificate("kube-apiserver", s.now.AddDate(0, 0, 5))
+	existing := []models.ClusterCertificate{
+		{ID: 3, ClusterID: 1, Source: models.CertificateSourceTLSSecret, Namespace: "shop", Name: "web-tls", Fingerprint: "old", NotifiedLevel: models.CertificateStatusCritical},
+		{ID: 5, ClusterID: 1, Source: models.CertificateSourceAPIServer, Name: "prod", Fingerprint: certificateFingerprint(kept), NotifiedLevel: models.CertificateStatusCritical},
+		{ID: 9, ClusterID: 1, Source: models.CertificateSourceWebhook, Name: "removed-webhook", Fingerprint: "gone"},
+	}
+	collected := []collectedCertificate{
+		{source: models.CertificateSourceAPIServer, name: "prod", cert: kept},
+		{source: models.CertificateSourceTLSSecret, namespace: "shop", name: "web-tls", references: []string{"web", "api"}, cert: renewed},
+		{source: models.CertificateSourceTLSSecret, namespace: "shop", name: "web-tls", cert: renewed},
+	}
+
+	certs, stale := reconcileCertificates(1, existing, collected, s.now)
+	s.Require().Len(certs, 2, "重复的证书只保存一次")
+	assert.Equal(s.T(), uint(5), certs[0].ID)
+	assert.Equal(s.T(), models.CertificateStatusCritical, certs[0].NotifiedLevel, "未续期的证书保留通知状态")
+	assert.Equal(s.T(), "CN=kube-apiserver", certs[0].Subject)
+	assert.Equal(s.T(), kept.NotAfter, certs[0].NotAfter)
+	assert.Equal(s.T(), s.now, certs[0].ScannedAt)
+
+	assert.Zero(s.T(), certs[1].ID, "续期后的证书作为新记录保存")
+	assert.Empty(s.T(), certs[1].NotifiedLevel)
+	assert.Equal(s.T(), uint(1), certs[1].ClusterID)
+	assert.Equal(s.T(), "shop.example.com", certs[1].DNSNames)
+	assert.Equal(s.T(), "web,api", certs[1].References)
+	assert.Equal(s.T(), []uint{3, 9}, stale)
+}
+
+// TestParse 测试 PEM 与 kubeconfig 证书解析
+func (s *CertificateServiceTestSuite) TestParse() {
+	_, first := s.newTestCertificate("ca-1", s.now.AddDate(1, 0, 0))
+	_, second := s.newTestCertificate("ca-2", s.now.AddDate(2, 0, 0))
+	bundle := append(append(append([]byte{}, first...), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: []byte("key")})...), second...)
+	certs := parsePEMCertificates(bundle)
+	s.Require().Len(certs, 2)
+	assert.Equal(s.T(), "ca-1", certs[0].Subject.CommonName)
+	assert.Equal(s.T(), "ca-2", certs[1].Subject.CommonName)
+	assert.Empty(s.T(), parsePEMCertificates([]byte("not a pem")))
+
+	kubeconfig := `apiVersion: v1
+kind: Config
+clusters:
+- name: prod
+  cluster:
+    server: https://10.0.0.1:6443
+users:
+- name: admin
+  user:
+    client-certificate-data: ` + base64.StdEncoding.EncodeToString(first) + `
+- name: token-user
+  user:
+    token: abc
+contexts:
+- name: prod
+  context:
+    cluster: prod
+    user: admin
+current-context: prod
+`
+	found, err := kubeconfigCertificates(kubeconfig)
+	s.Require().NoError(err)
+	s.Require().Len(found, 1)
+	assert.Equal(s.T(), "admin", found[0].name)
+	assert.Equal(s.T(), models.CertificateSourceKubeconfig, found[0].source)
+	assert.Equal(s.T(), "ca-1", found[0].cert.Subject.CommonName)
+
+	found, err = kubeconfigCertificates("")
+	assert.NoError(s.T(), err)
+	assert.Empty(s.T(), found)
+}
+
+// TestKubelet 测试读取 kubelet 证书，无法连接的节点不影响其他节点
+func (s *CertificateServiceTestSuite) TestKubelet() {
+	node := func(name, ip string, port int32) *corev1.Node {
+		n := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if ip != "" {
+			n.Status.Addresses = []corev1.NodeAddress{{Type: corev1.NodeHostName, Address: name}, {Type: corev1.NodeInternalIP, Address: ip}}
+		}
+		n.Status.DaemonEndpoints.KubeletEndpoint.Port = port
+		return n
+	}
+	client := fake.NewSimpleClientset(
+		node("node-1", "10.0.0.1", 0),
+		node("node-2", "10.0.0.2", 10260),
+		node("node-3", "10.0.0.3", 10250),
+		node("virtual", "", 0),
+	)
+	cert, _ := s.newTestCertificate("node-1", s.now.AddDate(1, 0, 0))
+
+	var mu sync.Mutex
+	var addresses []string
+	certs, err := kubeletCertificates(context.Background(), client, func(_ context.Context, address, serverName string) (*x509.Certificate, error) {
+		mu.Lock()
+		addresses = append(addresses, serverName+"@"+address)
+		mu.Unlock()
+		if serverName == "node-3" {
+			return nil, errors.New("connection refused")
+		}
+		return cert, nil
+	})
+	assert.EqualError(s.T(), err, "1 个节点无法连接 kubelet: node-3")
+	s.Require().Len(certs, 2)
+	assert.ElementsMatch(s.T(), []string{"node-1@10.0.0.1:10250", "node-2@10.0.0.2:10260", "node-3@10.0.0.3:10250"}, addresses)
+	for _, c := range certs {
+		assert.Equal(s.T(), models.CertificateSourceKubelet, c.source)
+	}
+}
+
+// TestIngressAndWebhook 测试读取 Ingress TLS Secret 与 Webhook caBundle 证书
+func (s *CertificateServiceTestSuite) TestIngressAndWebhook() {
+	_, webPEM := s.newTestCertificate("shop.example.com", s.now.AddDate(0, 0, 20), "shop.example.com")
+	_, caPEM := s.newTestCertificate("webhook-ca", s.now.AddDate(0, 0, 3))
+	ingress := func(name, secret string) *networkingv1.Ingress {
+		return &networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: name},
+			Spec:       networkingv1.IngressSpec{TLS: []networkingv1.IngressTLS{{SecretName: secret}, {Hosts: []string{"no-secret"}}}},
+		}
+	}
+	client := fake.NewSimpleClientset(
+		ingress("web", "web-tls"),
+		ingress("api", "web-tls"),
+		ingress("admin", "missing-tls"),
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "web-tls"}, Type: corev1.SecretTypeTLS, Data: map[string][]byte{corev1.TLSCertKey: webPEM}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "unused-tls"}, Type: corev1.SecretTypeTLS, Data: map[string][]byte{corev1.TLSCertKey: webPEM}},
+		&admissionregistrationv1.MutatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: "istio-sidecar-injector"},
+			Webhooks: []admissionregistrationv1.MutatingWebhook{
+				{Name: "namespace.sidecar-injector.istio.io", ClientConfig: admissionregistrationv1.WebhookClientConfig{CABundle: caPEM}},
+				{Name: "object.sidecar-injector.istio.io", ClientConfig: admissionregistrationv1.WebhookClientConfig{CABundle: caPEM}},
+			},
+		},
+		&admissionregistrationv1.ValidatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: "gatekeeper"},
+			Webhooks:   []admissionregistrationv1.ValidatingWebhook{{Name: "validation.gatekeeper.sh"}},
+		},
+	)
+
+	certs, err := ingressTLSCertificates(context.Background(), client)
+	s.Require().NoError(err)
+	s.Require().Len(certs, 1, "只读取被 Ingress 引用的 Secret")
+	assert.Equal(s.T(), "shop", certs[0].namespace)
+	assert.Equal(s.T(), "web-tls", certs[0].name)
+	assert.ElementsMatch(s.T(), []string{"web", "api"}, certs[0].references)
+
+	certs, err = webhookCertificates(context.Background(), client)
+	s.Require().NoError(err)
+	s.Require().Len(certs, 1, "同一配置中相同的 caBundle 只记录一次")
+	assert.Equal(s.T(), "istio-sidecar-injector", certs[0].name)
+	assert.Equal(s.T(), []string{"namespace.sidecar-injector.istio.io", "object.sidecar-injector.istio.io"}, certs[0].references)
+	assert.Equal(s.T(), "webhook-ca", certs[0].cert.Subject.CommonName)
+}
+
+// TestAPIService 测试读取 APIService caBundle 证书
+func (s *CertificateServiceTestSuite) TestAPIService() {
+	_, caPEM := s.newTestCertificate("metrics-server-ca", s.now.AddDate(0, 6, 0))
+	apiService := func(name, caBundle string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": map[string]interface{}{}}}
+		obj.SetAPIVersion("apiregistration.k8s.io/v1")
+		obj.SetKind("APIService")
+		obj.SetName(name)
+		if caBundle != "" {
+			obj.Object["spec"].(map[string]interface{})["caBundle"] = caBundle
+		}
+		return obj
+	}
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{apiServiceGVR: "APIServiceList"},
+		apiService("v1beta1.metrics.k8s.io", base64.StdEncoding.EncodeToString(caPEM)),
+		apiService("v1.apps", ""),
+		apiService("v1beta1.broken.example.com", "!!!"),
+	)
+
+	certs, err := apiServiceCertificates(context.Background(), client)
+	s.Require().NoError(err)
+	s.Require().Len(certs, 1)
+	assert.Equal(s.T(), models.CertificateSourceAPIService, certs[0].source)
+	assert.Equal(s.T(), "v1beta1.metrics.k8s.io", certs[0].name)
+	assert.Equal(s.T(), "metrics-server-ca", certs[0].cert.Subject.CommonName)
+}
+
+// TestDiagnosisRuleSkipped 测试未启用证书清单时证书规则跳过
+func (s *CertificateServiceTestSuite) TestDiagnosisRuleSkipped() {
+	rule, ok := DefaultDiagnosisRules.Get("certificate-expiry")
+	s.Require().True(ok)
+	assert.Equal(s.T(), "certificate", rule.Meta().Category)
+	_, err := rule.Evaluate(context.Background(), &DiagnosisEnv{ClusterID: 1, Now: s.now}, map[string]float64{"warning_days": 30, "critical_days": 7})
+	assert.ErrorIs(s.T(), err, errDiagnosisRuleSkipped)
+}
+
+// TestCertificateServiceSuite 运行证书清单测试套件
+func TestCertificateServiceSuite(t *testing.T) {
+	suite.Run(t, new(CertificateServiceTestSuite))
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+	"github.com/clay-wangzhi/KubePolaris/pkg/logger"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// clusterHealthProbeTimeout 单个集群探测超时
+const clusterHealthProbeTimeout = 15 * time.Second
+
+// ClusterStatusObserver 集群状态变化回调
+type ClusterStatusObserver func(cluster *models.Cluster, oldStatus, newStatus string)
+
+// ClusterHealthChecker 周期探测集群 API Server 与节点就绪情况，更新集群状态并在状态变化时回调
+type ClusterHealthChecker struct {
+	clusterService *ClusterService
+	observer       ClusterStatusObserver
+
+	// clientFor 获取集群客户端，测试时可替换
+	clientFor func(cluster *models.Cluster) (kubernetes.Interface, error)
+}
+
+// NewClusterHealthChecker 创建集群健康探测器，observer 可为空
+func NewClusterHealthChecker(clusterService *ClusterService, observer ClusterStatusObserver) *ClusterHealthChecker {
+	return &ClusterHealthChecker{
+		clusterService: clusterService,
+		observer:       observer,
+		clientFor: func(cluster *models.Cluster) (kubernetes.Interface, error) {
+			client, err := NewK8sClientForCluster(cluster)
+			if err != nil {
+				return nil, err
+			}
+			return client.GetClientset(), nil
+		},
+	}
+}
+
+// Start 按 interval 周期探测全部集群，ctx 取消后退出
+func (c *ClusterHealthChecker) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			c.CheckAll(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	logger.Info("集群健康探测已启动", "interval", interval.String())
+}
+
+// CheckAll 探测全部集群
+func (c *ClusterHealthChecker) CheckAll(ctx context.Context) {
+	clusters, err := c.clusterService.GetAllClusters()
+	if err != nil {
+		return
+	}
+	for _, cluster := range clusters {
+		if ctx.Err() != nil {
+			return
+		}
+		c.check(ctx, cluster)
+	}
+}
+
+func (c *ClusterHealthChecker) check(ctx context.Context, cluster *models.Cluster) {
+	status, version, err := c.probe(ctx, cluster)
+	if err != nil {
+		logger.Warn("集群健康探测失败", "cluster", cluster.Name, "error", err)
+	}
+	if version == "" {
+		version = cluster.Version
+	}
+	if err := c.clusterService.UpdateClusterStatus(cluster.ID, status, version); err != nil {
+		logger.Error("更新集群状态失败", "cluster", cluster.Name, "error", err)
+		return
+	}
+	if status != cluster.Status && c.observer != nil {
+		c.observer(cluster, cluster.Status, status)
+	}
+}
+
+// probe 返回集群状态：API Server 不可达或没有就绪节点为 unhealthy，部分节点未就绪为 warning，与导入时的判定一致
+func (c *ClusterHealthChecker) probe(ctx context.Context, cluster *models.Cluster) (string, string, error) {
+	clientset, err := c.clientFor(cluster)
+	if err != nil {
+		return "unhealthy", "", err
+	}
+	ctx, cancel := context.WithTimeout(ctx, clusterHealthProbeTimeout)
+	defer cancel()
+
+	version, err := clientset.Discovery().ServerVersion()
+	if err != nil {
+		return "unhealthy", "", fmt.Errorf("无法获取集群版本: %w", err)
+	}
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return "unhealthy", version.String(), fmt.Errorf("无法获取节点列表: %w", err)
+	}
+
+	ready := 0
+	for _, node := range nodes.Items {
+		for _, cond := range node.Status.Conditions {
+			if cond.Type == corev1.NodeReady && cond.Status == corev1.ConditionTrue {
+				ready++
+				break
+			}
+		}
+	}
+	switch {
+	case ready == len(nodes.Items):
+		return "healthy", version.String(), nil
+	case ready == 0:
+		return "unhealthy", version.String(), nil
+	default:
+		return "warning", version.String(), nil
+	}
+}
@@ -0,0 +1,588 @@
+package services
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+	"github.com/clay-wangzhi/KubePolaris/pkg/logger"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// 快照对象类型
+const (
+	ConfigKindConfigMap = "ConfigMap"
+	ConfigKindSecret    = "Secret"
+)
+
+// defaultMaxConfigVersions 每个对象默认保留的版本数
+const defaultMaxConfigVersions = 50
+
+// maxSnapshotAttempts 版本号冲突时保存快照的最大尝试次数
+const maxSnapshotAttempts = 3
+
+// maxDiffLines 逐行差异的最大行数，超过后只返回键级差异
+const maxDiffLines = 2000
+
+// ConfigVersionService ConfigMap/Secret 版本历史服务
+type ConfigVersionService struct {
+	db          *gorm.DB
+	gcm         cipher.AEAD
+	hashKey     []byte
+	maxVersions int
+}
+
+// NewConfigVersionService 创建版本历史服务，encryptionKey 用于加密 Secret 快照
+func NewConfigVersionService(db *gorm.DB, encryptionKey string, maxVersions int) *ConfigVersionService {
+	if maxVersions <= 0 {
+		maxVersions = defaultMaxConfigVersions
+	}
+	key := sha256.Sum256([]byte(encryptionKey))
+	block, _ := aes.NewCipher(key[:])
+	gcm, _ := cipher.NewGCM(block)
+	// Secret 摘要使用独立派生的 HMAC 密钥，避免明文摘要被用来猜测内容
+	hashKey := sha256.Sum256([]byte("config-version-hash:" + encryptionKey))
+	return &ConfigVersionService{db: db, gcm: gcm, hashKey: hashKey[:], maxVersions: maxVersions}
+}
+
+// RecordConfigMap 记录 ConfigMap 变更；before 为修改前的对象，首次记录时会先保存为基线版本
+func (s *ConfigVersionService) RecordConfigMap(clusterID uint, before, after *corev1.ConfigMap, source, username string) {
+	if s == nil || s.db == nil {
+		return
+	}
+	if before != nil {
+		s.recordBaseline(clusterID, ConfigKindConfigMap, before.Namespace, before.Name, configMapSnapshot(before), before.ResourceVersion)
+	}
+	if after != nil {
+		if err := s.snapshot(clusterID, ConfigKindConfigMap, after.Namespace, after.Name, configMapSnapshot(after), after.ResourceVersion, source, username); err != nil {
+			logger.Error("记录ConfigMap版本失败", "namespace", after.Namespace, "name", after.Name, "error", err)
+		}
+	}
+}
+
+// RecordSecret 记录 Secret 变更，快照内容加密存储
+func (s *ConfigVersionService) RecordSecret(clusterID uint, before, after *corev1.Secret, source, username string) {
+	if s == nil || s.db == nil {
+		return
+	}
+	if before != nil {
+		s.recordBaseline(clusterID, ConfigKindSecret, before.Namespace, before.Name, secretSnapshot(before), before.ResourceVersion)
+	}
+	if after != nil {
+		if err := s.snapshot(clusterID, ConfigKindSecret, after.Namespace, after.Name, secretSnapshot(after), after.ResourceVersion, source, username); err != nil {
+			logger.Error("记录Secret版本失败", "namespace", after.Namespace, "name", after.Name, "error", err)
+		}
+	}
+}
+
+// recordBaseline 对象尚无历史时保存修改前内容，保证第一次修改也可以回滚
+func (s *ConfigVersionService) recordBaseline(clusterID uint, kind, namespace, name string, snap *models.ConfigSnapshot, resourceVersion string) {
+	var count int64
+	if err := s.objectQuery(clusterID, kind, namespace, name).Model(&models.ConfigVersion{}).Count(&count).Error; err != nil || count > 0 {
+		return
+	}
+	if err := s.snapshot(clusterID, kind, namespace, name, snap, resourceVersion, models.ConfigVersionSourceBaseline, ""); err != nil {
+		logger.Error("记录基线版本失败", "kind", kind, "namespace", namespace, "name", name, "error", err)
+	}
+}
+
+// snapshot 保存一个版本，内容与最新版本相同时跳过
+func (s *ConfigVersionService) snapshot(clusterID uint, kind, namespace, name string, snap *models.ConfigSnapshot, resourceVersion, source, username string) error {
+	content, err := json.Marshal(snap)
+	if err != nil {
+		return fmt.Errorf("序列化快照失败: %w", err)
+	}
+	hash := s.contentHash(kind, content)
+
+	version := &models.ConfigVersion{
+		ClusterID:       clusterID,
+		Kind:            kind,
+		Namespace:       namespace,
+		Name:            name,
+		Hash:            hash,
+		Keys:            strings.Join(snapshotKeys(snap), ","),
+		ResourceVersion: resourceVersion,
+		Source:          source,
+		Username:        username,
+	}
+	if kind == ConfigKindSecret {
+		encrypted, err := s.encrypt(content)
+		if err != nil {
+			return err
+		}
+		version.Content = encrypted
+		version.Encrypted = true
+	} else {
+		version.Content = string(content)
+	}
+
+	// 并发写入同一对象时版本号可能冲突，由唯一索引兜底并重新取号
+	for attempt := 1; ; attempt++ {
+		var latest models.ConfigVersion
+		err = s.objectQuery(clusterID, kind, namespace, name).Order("version DESC").Limit(1).Find(&latest).Error
+		if err != nil {
+			return fmt.Errorf("查询最新版本失败: %w", err)
+		}
+		if latest.ID != 0 && latest.Hash == hash {
+			return nil
+		}
+
+		version.ID = 0
+		version.Version = latest.Version + 1
+		err = s.db.Create(version).Error
+		if err == nil {
+			break
+		}
+		if !isDuplicateKeyError(err) || attempt >= maxSnapshotAttempts {
+			return fmt.Errorf("保存版本失败: %w", err)
+		}
+	}
+
+	// 清理超出保留数量的旧版本
+	if version.Version > s.maxVersions {
+		s.objectQuery(clusterID, kind, namespace, name).
+			Where("version <= ?", version.Version-s.maxVersions).
+			Delete(&models.ConfigVersion{})
+	}
+	return nil
+}
+
+// ListVersions 获取对象的版本列表（不含内容）
+func (s *ConfigVersionService) ListVersions(clusterID uint, kind, namespace, name string) ([]models.ConfigVersion, error) {
+	var versions []models.ConfigVersion
+	if err := s.objectQuery(clusterID, kind, namespace, name).Order("version DESC").Find(&versions).Error; err != nil {
+		return nil, fmt.Errorf("查询版本历史失败: %w", err)
+	}
+	return versions, nil
+}
+
+// GetSnapshot 获取指定版本的内容，version 为 0 时取最新版本
+func (s *ConfigVersionService) GetSnapshot(clusterID uint, kind, namespace, name string, version int) (*models.ConfigVersion, *models.ConfigSnapshot, error) {
+	var record models.ConfigVersion
+	query := s.objectQuery(clusterID, kind, namespace, name)
+	if version > 0 {
+		query = query.Where("version = ?", version)
+	} else {
+		query = query.Order("version DESC")
+	}
+	if err := query.First(&record).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil, fmt.Errorf("版本不存在: %d", version)
+		}
+		return nil, nil, fmt.Errorf("查询版本失败: %w", err)
+	}
+
+	content := []byte(record.Content)
+	if record.Encrypted {
+		decrypted, err := s.decrypt(record.Content)
+		if err != nil {
+			return nil, nil, err
+		}
+		content = decrypted
+	}
+	var snap models.ConfigSnapshot
+	if err := json.Unmarshal(content, &snap); err != nil {
+		return nil, nil, fmt.Errorf("解析快照失败: %w", err)
+	}
+	return &record, &snap, nil
+}
+
+// Diff 比较两个版本，to 为 0 表示最新版本；Secret 只返回键名变化
+func (s *ConfigVersionService) Diff(clusterID uint, kind, namespace, name string, from, to int) (*models.ConfigVersionDiff, error) {
+	fromRecord, fromSnap, err := s.GetSnapshot(clusterID, kind, namespace, name, from)
+	if err != nil {
+		return nil, err
+	}
+	toRecord, toSnap, err := s.GetSnapshot(clusterID, kind, namespace, name, to)
+	if err != nil {
+		return nil, err
+	}
+
+	masked := kind == ConfigKindSecret
+	result := &models.ConfigVersionDiff{
+		FromVersion: fromRecord.Version,
+		ToVersion:   toRecord.Version,
+		Keys:        []models.ConfigKeyDiff{},
+		Masked:      masked,
+	}
+
+	keys := map[string]struct{}{}
+	for k := range fromSnap.Data {
+		keys[k] = struct{}{}
+	}
+	for k := range toSnap.Data {
+		keys[k] = struct{}{}
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	for _, k := range sorted {
+		oldValue, inOld := fromSnap.Data[k]
+		newValue, inNew := toSnap.Data[k]
+		item := models.ConfigKeyDiff{Key: k}
+		switch {
+		case !inOld:
+			item.Change = "added"
+		case !inNew:
+			item.Change = "removed"
+		case oldValue != newValue:
+			item.Change = "modified"
+		default:
+			continue
+		}
+		if !masked {
+			item.Diff = lineDiff(oldValue, newValue)
+		}
+		result.Keys = append(result.Keys, item)
+	}
+	return result, nil
+}
+
+// RestoreConfigMap 将 ConfigMap 恢复到指定版本（对象已删除时重新创建）
+func (s *ConfigVersionService) RestoreConfigMap(ctx context.Context, clientset kubernetes.Interface, clusterID uint, namespace, name string, version int, username string) (*corev1.ConfigMap, error) {
+	_, snap, err := s.GetSnapshot(clusterID, ConfigKindConfigMap, namespace, name, version)
+	if err != nil {
+		return nil, err
+	}
+
+	configMaps := clientset.CoreV1().ConfigMaps(namespace)
+	current, err := configMaps.Get(ctx, name, metav1.GetOptions{})
+	var result *corev1.ConfigMap
+	switch {
+	case apierrors.IsNotFound(err):
+		result, err = configMaps.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: snap.Labels},
+			Data:       snap.Data,
+			BinaryData: snap.BinaryData,
+		}, metav1.CreateOptions{})
+	case err != nil:
+		return nil, fmt.Errorf("获取ConfigMap失败: %w", err)
+	default:
+		before := current.DeepCopy()
+		current.Data = snap.Data
+		current.BinaryData = snap.BinaryData
+		result, err = configMaps.Update(ctx, current, metav1.UpdateOptions{})
+		if err == nil {
+			s.RecordConfigMap(clusterID, before, nil, "", "")
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("恢复ConfigMap失败: %w", err)
+	}
+	s.RecordConfigMap(clusterID, nil, result, models.ConfigVersionSourceRestore, username)
+	return result, nil
+}
+
+// RestoreSecret 将 Secret 恢复到指定版本（对象已删除时重新创建）
+func (s *ConfigVersionService) RestoreSecret(ctx context.Context, clientset kubernetes.Interface, clusterID uint, namespace, name string, version int, username string) (*corev1.Secret, error) {
+	_, snap, err := s.GetSnapshot(clusterID, ConfigKindSecret, namespace, name, version)
+	if err != nil {
+		return nil, err
+	}
+	data := make(map[string][]byte, len(snap.Data))
+	for k, v := range snap.Data {
+		data[k] = []byte(v)
+	}
+
+	secrets := clientset.CoreV1().Secrets(namespace)
+	current, err := secrets.Get(ctx, name, metav1.GetOptions{})
+	var result *corev1.Secret
+	switch {
+	case apierrors.IsNotFound(err):
+		result, err = secrets.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: snap.Labels},
+			Type:       corev1.SecretType(snap.Type),
+			Data:       data,
+		}, metav1.CreateOptions{})
+	case err != nil:
+		return nil, fmt.Errorf("获取Secret失败: %w", err)
+	default:
+		before := current.DeepCopy()
+		current.Data = data
+		result, err = secrets.Update(ctx, current, metav1.UpdateOptions{})
+		if err == nil {
+			s.RecordSecret(clusterID, before, nil, "", "")
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("恢复Secret失败: %w", err)
+	}
+	s.RecordSecret(clusterID, nil, result, models.ConfigVersionSourceRestore, username)
+	return result, nil
+}
+
+// OnConfigMapUpdate Informer 观察到的 ConfigMap 变更
+func (s *ConfigVersionService) OnConfigMapUpdate(clusterID uint, oldObj, newObj *corev1.ConfigMap) {
+	s.RecordConfigMap(clusterID, oldObj, newObj, models.ConfigVersionSourceObserved, "")
+}
+
+// OnSecretUpdate Informer 观察到的 Secret 变更
+func (s *ConfigVersionService) OnSecretUpdate(clusterID uint, oldObj, newObj *corev1.Secret) {
+	// ServiceAccount Token 等由控制器维护的 Secret 不记录
+	if newObj.Type == corev1.SecretTypeServiceAccountToken || newObj.Type == "helm.sh/release.v1" {
+		return
+	}
+	s.RecordSecret(clusterID, oldObj, newObj, models.ConfigVersionSourceObserved, "")
+}
+
+// FindConsumers 查找引用 ConfigMap/Secret 的工作负载（Deployment/StatefulSet/DaemonSet/CronJob）
+func FindConsumers(ctx context.Context, clientset kubernetes.Interface, kind, namespace, name string) ([]models.ConfigConsumer, error) {
+	consumers := []models.ConfigConsumer{}
+	add := func(workloadKind, workloadName string, spec *corev1.PodSpec) {
+		refs, restart := podSpecConfigReferences(spec, kind, name)
+		if len(refs) > 0 {
+			consumers = append(consumers, models.ConfigConsumer{
+				Kind:            workloadKind,
+				Namespace:       namespace,
+				Name:            workloadName,
+				References:      refs,
+				RestartRequired: restart,
+			})
+		}
+	}
+
+	deployments, err := clientset.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取Deployment列表失败: %w", err)
+	}
+	for i := range deployments.Items {
+		add("Deployment", deployments.Items[i].Name, &deployments.Items[i].Spec.Template.Spec)
+	}
+
+	statefulSets, err := clientset.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取StatefulSet列表失败: %w", err)
+	}
+	for i := range statefulSets.Items {
+		add("StatefulSet", statefulSets.Items[i].Name, &statefulSets.Items[i].Spec.Template.Spec)
+	}
+
+	daemonSets, err := clientset.AppsV1().DaemonSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取DaemonSet列表失败: %w", err)
+	}
+	for i := range daemonSets.Items {
+		add("DaemonSet", daemonSets.Items[i].Name, &daemonSets.Items[i].Spec.Template.Spec)
+	}
+
+	cronJobs, err := clientset.BatchV1().CronJobs(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取CronJob列表失败: %w", err)
+	}
+	for i := range cronJobs.Items {
+		add("CronJob", cronJobs.Items[i].Name, &cronJobs.Items[i].Spec.JobTemplate.Spec.Template.Spec)
+	}
+
+	return consumers, nil
+}
+
+// podSpecConfigReferences 返回 PodSpec 对指定 ConfigMap/Secret 的引用，以及修改后是否需要重启
+func podSpecConfigReferences(spec *corev1.PodSpec, kind, name string) ([]string, bool) {
+	var refs []string
+	restart := false
+	mountedVolumes := map[string]bool{}
+
+	for _, volume := range spec.Volumes {
+		matched := false
+		switch {
+		case kind == ConfigKindConfigMap && volume.ConfigMap != nil && volume.ConfigMap.Name == name:
+			matched = true
+		case kind == ConfigKindSecret && volume.Secret != nil && volume.Secret.SecretName == name:
+			matched = true
+		case volume.Projected != nil:
+			for _, src := range volume.Projected.Sources {
+				if (kind == ConfigKindConfigMap && src.ConfigMap != nil && src.ConfigMap.Name == name) ||
+					(kind == ConfigKindSecret && src.Secret != nil && src.Secret.Name == name) {
+					matched = true
+				}
+			}
+		}
+		if matched {
+			mountedVolumes[volume.Name] = true
+			refs = append(refs, "volume:"+volume.Name)
+		}
+	}
+
+	if kind == ConfigKindSecret {
+		for _, ref := range spec.ImagePullSecrets {
+			if ref.Name == name {
+				refs = append(refs, "imagePullSecret")
+			}
+		}
+	}
+
+	containers := make([]corev1.Container, 0, len(spec.InitContainers)+len(spec.Containers))
+	containers = append(containers, spec.InitContainers...)
+	containers = append(containers, spec.Containers...)
+	for _, container := range containers {
+		for _, envFrom := range container.EnvFrom {
+			if (kind == ConfigKindConfigMap && envFrom.ConfigMapRef != nil && envFrom.ConfigMapRef.Name == name) ||
+				(kind == ConfigKindSecret && envFrom.SecretRef != nil && envFrom.SecretRef.Name == name) {
+				refs = append(refs, "envFrom:"+container.Name)
+				restart = true
+			}
+		}
+		for _, env := range container.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if (kind == ConfigKindConfigMap && env.ValueFrom.ConfigMapKeyRef != nil && env.ValueFrom.ConfigMapKeyRef.Name == name) ||
+				(kind == ConfigKindSecret && env.ValueFrom.SecretKeyRef != nil && env.ValueFrom.SecretKeyRef.Name == name) {
+				refs = append(refs, "env:"+container.Name+"/"+env.Name)
+				restart = true
+			}
+		}
+		// subPath 挂载不会随 ConfigMap/Secret 更新
+		for _, mount := range container.VolumeMounts {
+			if mountedVolumes[mount.Name] && mount.SubPath != "" {
+				refs = append(refs, "subPath:"+container.Name+"/"+mount.SubPath)
+				restart = true
+			}
+		}
+	}
+	return refs, restart
+}
+
+func (s *ConfigVersionService) objectQuery(clusterID uint, kind, namespace, name string) *gorm.DB {
+	return s.db.Where("cluster_id = ? AND kind = ? AND namespace = ? AND name = ?", clusterID, kind, namespace, name)
+}
+
+// isDuplicateKeyError 判断是否违反唯一索引
+func isDuplicateKeyError(err error) bool {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+	var mysqlErr *mysqldriver.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062
+}
+
+// contentHash 计算快照摘要，Secret 使用 HMAC，摘要本身不会泄露内容
+func (s *ConfigVersionService) contentHash(kind string, content []byte) string {
+	if kind == ConfigKindSecret {
+		mac := hmac.New(sha256.New, s.hashKey)
+		mac.Write(content)
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *ConfigVersionService) encrypt(plain []byte) (string, error) {
+	nonce := make([]byte, s.gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", fmt.Errorf("生成随机数失败: %w", err)
+	}
+	sealed := s.gcm.Seal(nonce, nonce, plain, nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (s *ConfigVersionService) decrypt(encoded string) ([]byte, error) {
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("解码快照失败: %w", err)
+	}
+	nonceSize := s.gcm.NonceSize()
+	if len(sealed) < nonceSize {
+		return nil, fmt.Errorf("快照密文格式错误")
+	}
+	plain, err := s.gcm.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("解密快照失败（加密密钥可能已变更）: %w", err)
+	}
+	return plain, nil
+}
+
+func configMapSnapshot(cm *corev1.ConfigMap) *models.ConfigSnapshot {
+	return &models.ConfigSnapshot{Labels: cm.Labels, Data: cm.Data, BinaryData: cm.BinaryData}
+}
+
+func secretSnapshot(secret *corev1.Secret) *models.ConfigSnapshot {
+	data := make(map[string]string, len(secret.Data))
+	for k, v := range secret.Data {
+		data[k] = string(v)
+	}
+	return &models.ConfigSnapshot{Type: string(secret.Type), Labels: secret.Labels, Data: data}
+}
+
+func snapshotKeys(snap *models.ConfigSnapshot) []string {
+	keys := make([]string, 0, len(snap.Data)+len(snap.BinaryData))
+	for k := range snap.Data {
+		keys = append(keys, k)
+	}
+	for k := range snap.BinaryData {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// lineDiff 基于最长公共子序列生成逐行差异（"-" 删除，"+" 新增，" " 未变）
+func lineDiff(oldText, newText string) string {
+	oldLines := strings.Split(oldText, "\n")
+	newLines := strings.Split(newText, "\n")
+	if oldText == "" {
+		oldLines = nil
+	}
+	if newText == "" {
+		newLines = nil
+	}
+	if len(oldLines)+len(newLines) > maxDiffLines {
+		return ""
+	}
+
+	n, m := len(oldLines), len(newLines)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var b strings.Builder
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && oldLines[i] == newLines[j]:
+			b.WriteString(" " + oldLines[i] + "\n")
+			i++
+			j++
+		case i < n && (j >= m || lcs[i+1][j] >= lcs[i][j+1]):
+			b.WriteString("-" + oldLines[i] + "\n")
+			i++
+		default:
+			b.WriteString("+" + newLines[j] + "\n")
+			j++
+		}
+	}
+	return b.String()
+}
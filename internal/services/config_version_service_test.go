@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+)
+
+// ConfigVersionTestSuite 定义配置版本历史测试套件
+type ConfigVersionTestSuite struct {
+	suite.Suite
+	svc *ConfigVersionService
+}
+
+// SetupTest 每个测试前的设置
+func (s *ConfigVersionTestSuite) SetupTest() {
+	s.svc = NewConfigVersionService(nil, "test-key", 0)
+}
+
+// TestEncryptRoundTrip 测试 Secret 快照加解密
+func (s *ConfigVersionTestSuite) TestEncryptRoundTrip() {
+	encrypted, err := s.svc.encrypt([]byte(`{"data":{"password":"s3cret"}}`))
+	s.Require().NoError(err)
+	assert.NotContains(s.T(), encrypted, "s3cret")
+
+	plain, err := s.svc.decrypt(encrypted)
+	s.Require().NoError(err)
+	assert.Equal(s.T(), `{"data":{"password":"s3cret"}}`, string(plain))
+
+	// 密钥变更后无法解密
+	_, err = NewConfigVersionService(nil, "other-key", 0).decrypt(encrypted)
+	assert.Error(s.T(), err)
+}
+
+// TestContentHash 测试 Secret 摘要使用密钥计算
+func (s *ConfigVersionTestSuite) TestContentHash() {
+	content := []byte(`{"data":{"password":"s3cret"}}`)
+	sum := sha256.Sum256(content)
+	plain := hex.EncodeToString(sum[:])
+
+	assert.Equal(s.T(), plain, s.svc.contentHash(ConfigKindConfigMap, content))
+	secretHash := s.svc.contentHash(ConfigKindSecret, content)
+	assert.NotEqual(s.T(), plain, secretHash)
+	assert.Equal(s.T(), secretHash, s.svc.contentHash(ConfigKindSecret, content))
+	assert.NotEqual(s.T(), secretHash, NewConfigVersionService(nil, "other-key", 0).contentHash(ConfigKindSecret, content))
+}
+
+// TestLineDiff 测试逐行差异
+func (s *ConfigVersionTestSuite) TestLineDiff() {
+	diff := lineDiff("a=1\nb=2\nc=3", "a=1\nb=20\nc=3")
+	assert.Equal(s.T(), " a=1\n-b=2\n+b=20\n c=3\n", diff)
+	assert.Equal(s.T(), "+x\n", lineDiff("", "x"))
+}
+
+// TestFindConsumers 测试引用 ConfigMap 的工作负载查找
+func (s *ConfigVersionTestSuite) TestFindConsumers() {
+	volumeOnly := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+			Volumes: []corev1.Volume{{Name: "conf", VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "app-config"}},
+			}}},
+			Containers: []corev1.Container{{Name: "web", VolumeMounts: []corev1.VolumeMount{{Name: "conf", MountPath: "/etc/app"}}}},
+		}}},
+	}
+	envRef := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker", Namespace: "default"},
+		Spec: appsv1.StatefulSetSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "worker", Env: []corev1.EnvVar{{
+				Name: "LEVEL",
+				ValueFrom: &corev1.EnvVarSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "app-config"}, Key: "level",
+				}},
+			}}}},
+		}}},
+	}
+	unrelated := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "other"}},
+		}}},
+	}
+	cs := fake.NewSimpleClientset(volumeOnly, envRef, unrelated)
+
+	consumers, err := FindConsumers(context.Background(), cs, ConfigKindConfigMap, "default", "app-config")
+	s.Require().NoError(err)
+	s.Require().Len(consumers, 2)
+
+	assert.Equal(s.T(), "web", consumers[0].Name)
+	assert.False(s.T(), consumers[0].RestartRequired)
+	assert.Equal(s.T(), []string{"volume:conf"}, consumers[0].References)
+
+	assert.Equal(s.T(), "StatefulSet", consumers[1].Kind)
+	assert.True(s.T(), consumers[1].RestartRequired)
+	assert.Equal(s.T(), []string{"env:worker/LEVEL"}, consumers[1].References)
+}
+
+// TestSnapshotRetryOnDuplicateVersion 测试并发写入导致版本号冲突时重新取号
+func (s *ConfigVersionTestSuite) TestSnapshotRetryOnDuplicateVersion() {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	s.Require().NoError(err)
+	defer db.Close()
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{Conn: db, SkipInitializeWithVersion: true}),
+		&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	s.Require().NoError(err)
+	svc := NewConfigVersionService(gormDB, "test-key", 0)
+
+	latestQuery := regexp.QuoteMeta("SELECT * FROM `config_versions`")
+	insert := regexp.QuoteMeta("INSERT INTO `config_versions`")
+	mock.ExpectQuery(latestQuery).WillReturnRows(sqlmock.NewRows([]string{"id", "version", "hash"}).AddRow(1, 1, "old"))
+	mock.ExpectBegin()
+	mock.ExpectExec(insert).WillReturnError(&mysqldriver.MySQLError{Number: 1062, Message: "Duplicate entry"})
+	mock.ExpectRollback()
+	mock.ExpectQuery(latestQuery).WillReturnRows(sqlmock.NewRows([]string{"id", "version", "hash"}).AddRow(2, 2, "other"))
+	mock.ExpectBegin()
+	mock.ExpectExec(insert).WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectCommit()
+
+	snap := &models.ConfigSnapshot{Data: map[string]string{"a": "1"}}
+	s.Require().NoError(svc.snapshot(1, ConfigKindConfigMap, "default", "app-config", snap, "10", models.ConfigVersionSourceKubePolaris, "admin"))
+	assert.NoError(s.T(), mock.ExpectationsWereMet())
+
+	// 非唯一索引冲突不重试
+	mock.ExpectQuery(latestQuery).WillReturnRows(sqlmock.NewRows([]string{"id", "version", "hash"}).AddRow(3, 3, "other"))
+	mock.ExpectBegin()
+	mock.ExpectExec(insert).WillReturnError(errors.New("connection reset"))
+	mock.ExpectRollback()
+	assert.Error(s.T(), svc.snapshot(1, ConfigKindConfigMap, "default", "app-config", snap, "11", models.ConfigVersionSourceKubePolaris, "admin"))
+	assert.NoError(s.T(), mock.ExpectationsWereMet())
+}
+
+// TestConfigVersionTestSuite 运行测试套件
+func TestConfigVersionTestSuite(t *testing.T) {
+	suite.Run(t, new(ConfigVersionTestSuite))
+}
@@ -0,0 +1,632 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+	"github.com/clay-wangzhi/KubePolaris/pkg/logger"
+
+	"gorm.io/gorm"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	batchv1listers "k8s.io/client-go/listers/batch/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+)
+
+const (
+	costDateLayout         = "2006-01-02"
+	costHoursPerMonth      = 730 // 存储按月定价，折算为小时单价
+	costDefaultRangeDays   = 30
+	costMaxRangeDays       = 366
+	costGPUResource        = corev1.ResourceName("nvidia.com/gpu")
+	costDefaultSnapshotGap = 10 * time.Minute
+)
+
+// CostListerProvider 快照采集用到的 informer Lister
+type CostListerProvider interface {
+	PodsLister(clusterID uint) corev1listers.PodLister
+	NamespacesLister(clusterID uint) corev1listers.NamespaceLister
+	JobsLister(clusterID uint) batchv1listers.JobLister
+}
+
+// CostOptions 成本分摊配置
+type CostOptions struct {
+	SnapshotInterval time.Duration // 采集周期：未配置 Prometheus 的集群按该周期快照累加用量
+	Currency         string        // 未设置单价时的默认币种
+	TeamLabel        string        // 未设置单价时默认的团队标签
+}
+
+// costUsage 某个工作负载（或 PVC）在一段时间内的资源用量
+type costUsage struct {
+	Namespace       string
+	Kind            string
+	Name            string
+	StorageClass    string
+	CPUCoreHours    float64
+	MemoryGiBHours  float64
+	GPUHours        float64
+	StorageGiBHours float64
+}
+
+// CostService 成本分摊服务：按集群单价将 max(request, 用量) 分摊到命名空间、工作负载与团队
+type CostService struct {
+	db                  *gorm.DB
+	clusterService      *ClusterService
+	monitoringConfigSvc *MonitoringConfigService
+	prometheusSvc       *PrometheusService
+	listers             CostListerProvider
+	usageProvider       ResourceMetricsProvider
+	opts                CostOptions
+
+	// clientFor 获取集群客户端（用于读取 PVC），测试时可替换
+	clientFor func(clusterID uint) (kubernetes.Interface, error)
+	now       func() time.Time
+
+	mu           sync.Mutex
+	lastSnapshot map[uint]time.Time
+	rolledUp     map[uint]string // 各集群最近一次自动汇总的日期
+}
+
+// NewCostService 创建成本分摊服务
+func NewCostService(db *gorm.DB, clusterService *ClusterService, monitoringConfigSvc *MonitoringConfigService, prometheusSvc *PrometheusService, listers CostListerProvider, usageProvider ResourceMetricsProvider, opts CostOptions) *CostService {
+	if opts.SnapshotInterval <= 0 {
+		opts.SnapshotInterval = costDefaultSnapshotGap
+	}
+	if opts.Currency == "" {
+		opts.Currency = "CNY"
+	}
+	if opts.TeamLabel == "" {
+		opts.TeamLabel = "team"
+	}
+	return &CostService{
+		db:                  db,
+		clusterService:      clusterService,
+		monitoringConfigSvc: monitoringConfigSvc,
+		prometheusSvc:       prometheusSvc,
+		listers:             listers,
+		usageProvider:       usageProvider,
+		opts:                opts,
+		clientFor: func(clusterID uint) (kubernetes.Interface, error) {
+			cluster, err := clusterService.GetCluster(clusterID)
+			if err != nil {
+				return nil, err
+			}
+			client, err := NewK8sClientForCluster(cluster)
+			if err != nil {
+				return nil, err
+			}
+			return client.GetClientset(), nil
+		},
+		now:          time.Now,
+		lastSnapshot: make(map[uint]time.Time),
+		rolledUp:     make(map[uint]string),
+	}
+}
+
+// ========== 单价 ==========
+
+// GetPricing 获取集群单价，未配置时返回默认值（全部为 0）
+func (s *CostService) GetPricing(clusterID uint) (*models.CostPricing, error) {
+	var pricing models.CostPricing
+	err := s.db.Where("cluster_id = ?", clusterID).First(&pricing).Error
+	if err == gorm.ErrRecordNotFound {
+		return &models.CostPricing{ClusterID: clusterID, Currency: s.opts.Currency, StorageClassPrices: "{}", TeamLabel: s.opts.TeamLabel}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("获取集群单价失败: %w", err)
+	}
+	return &pricing, nil
+}
+
+// UpdatePricing 更新集群单价，只影响之后汇总的数据
+func (s *CostService) UpdatePricing(clusterID uint, req *models.CostPricingRequest) (*models.CostPricing, error) {
+	for class, price := range req.StorageClassPrices {
+		if price < 0 {
+			return nil, fmt.Errorf("存储类 %s 的单价不能为负数", class)
+		}
+	}
+	prices, _ := json.Marshal(req.StorageClassPrices)
+	if req.StorageClassPrices == nil {
+		prices = []byte("{}")
+	}
+
+	pricing, err := s.GetPricing(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	pricing.Currency = req.Currency
+	if pricing.Currency == "" {
+		pricing.Currency = s.opts.Currency
+	}
+	pricing.CPUCoreHour = req.CPUCoreHour
+	pricing.MemoryGiBHour = req.MemoryGiBHour
+	pricing.GPUHour = req.GPUHour
+	pricing.StorageGiBMonth = req.StorageGiBMonth
+	pricing.StorageClassPrices = string(prices)
+	pricing.TeamLabel = req.TeamLabel
+	if pricing.TeamLabel == "" {
+		pricing.TeamLabel = s.opts.TeamLabel
+	}
+	if err := s.db.Save(pricing).Error; err != nil {
+		return nil, fmt.Errorf("保存集群单价失败: %w", err)
+	}
+	return pricing, nil
+}
+
+// ========== 采集 ==========
+
+// Start 启动成本采集：配置了 Prometheus 的集群每天汇总前一天的数据，其余集群按周期快照累加当天用量
+func (s *CostService) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.opts.SnapshotInterval)
+		defer ticker.Stop()
+		for {
+			s.collectAll(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	logger.Info("成本分摊采集器已启动", "interval", s.opts.SnapshotInterval)
+}
+
+func (s *CostService) collectAll(ctx context.Context) {
+	clusters, err := s.clusterService.GetAllClusters()
+	if err != nil {
+		logger.Error("成本采集获取集群列表失败", "error", err)
+		return
+	}
+	for _, cluster := range clusters {
+		config, err := s.monitoringConfigSvc.GetMonitoringConfig(cluster.ID)
+		if err == nil && config.Type != "disabled" && config.Endpoint != "" {
+			s.rollupYesterday(ctx, cluster.ID)
+			continue
+		}
+		if err := s.Snapshot(ctx, cluster.ID); err != nil {
+			logger.Debug("成本快照采集失败", "cluster", cluster.Name, "error", err)
+		}
+	}
+}
+
+// rollupYesterday 每个集群每天只自动汇总一次前一天的数据
+func (s *CostService) rollupYesterday(ctx context.Context, clusterID uint) {
+	day := s.today().AddDate(0, 0, -1)
+	date := day.Format(costDateLayout)
+	s.mu.Lock()
+	done := s.rolledUp[clusterID] == date
+	s.mu.Unlock()
+	if done {
+		return
+	}
+
+	var count int64
+	s.db.Model(&models.CostDailyRollup{}).Where("cluster_id = ? AND date = ? AND source = ?", clusterID, date, models.CostSourcePrometheus).Count(&count)
+	if count == 0 {
+		if _, err := s.RollupDay(ctx, clusterID, day); err != nil {
+			logger.Error("成本日汇总失败", "cluster_id", clusterID, "date", date, "error", err)
+			return
+		}
+	}
+	s.mu.Lock()
+	s.rolledUp[clusterID] = date
+	s.mu.Unlock()
+}
+
+// RollupDay 根据 Prometheus 历史数据汇总某一天（本地时区）的成本，覆盖该天已有的数据，返回写入的行数
+func (s *CostService) RollupDay(ctx context.Context, clusterID uint, day time.Time) (int, error) {
+	config, err := s.monitoringConfigSvc.GetMonitoringConfig(clusterID)
+	if err != nil {
+		return 0, fmt.Errorf("获取监控配置失败: %w", err)
+	}
+	if config.Type == "disabled" || config.Endpoint == "" {
+		return 0, fmt.Errorf("集群未配置监控数据源，成本由周期快照累加")
+	}
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	end := start.AddDate(0, 0, 1)
+	if end.After(s.now()) {
+		return 0, fmt.Errorf("只能汇总已结束的日期")
+	}
+
+	usages, err := s.prometheusSvc.queryCostUsage(ctx, config, start, end)
+	if err != nil {
+		return 0, err
+	}
+	pricing, err := s.GetPricing(clusterID)
+	if err != nil {
+		return 0, err
+	}
+	teams := s.namespaceTeams(clusterID, pricing.TeamLabel)
+
+	date := start.Format(costDateLayout)
+	rows := make([]models.CostDailyRollup, 0, len(usages))
+	for _, u := range usages {
+		row := models.CostDailyRollup{ClusterID: clusterID, Date: date, Namespace: u.Namespace, WorkloadKind: u.Kind, Workload: u.Name, Team: teams[u.Namespace], Source: models.CostSourcePrometheus}
+		addCostUsage(&row, u, pricing)
+		rows = append(rows, row)
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("cluster_id = ? AND date = ?", clusterID, date).Delete(&models.CostDailyRollup{}).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(rows, 200).Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("保存成本汇总失败: %w", err)
+	}
+	logger.Info("成本日汇总完成", "cluster_id", clusterID, "date", date, "rows", len(rows))
+	return len(rows), nil
+}
+
+// Snapshot 从 informer 缓存与 metrics-server 采集一次当前用量，按距上次快照的时长累加到当天的汇总中
+func (s *CostService) Snapshot(ctx context.Context, clusterID uint) error {
+	podLister := s.listers.PodsLister(clusterID)
+	if podLister == nil {
+		return fmt.Errorf("集群 informer 未启动")
+	}
+	pods, err := podLister.List(labels.Everything())
+	if err != nil {
+		return fmt.Errorf("读取 Pod 缓存失败: %w", err)
+	}
+	cronJobs := make(map[string]string)
+	if jobLister := s.listers.JobsLister(clusterID); jobLister != nil {
+		jobs, _ := jobLister.List(labels.Everything())
+		for _, job := range jobs {
+			if owner := metav1.GetControllerOf(job); owner != nil && owner.Kind == "CronJob" {
+				cronJobs[job.Namespace+"/"+job.Name] = owner.Name
+			}
+		}
+	}
+	var usage map[string]models.ResourceUsage
+	if s.usageProvider != nil {
+		if usage, err = s.usageProvider.PodUsage(ctx, clusterID, ""); err != nil {
+			// 未安装 metrics-server 时只按 request 计量
+			logger.Debug("成本快照获取 Pod 用量失败", "cluster_id", clusterID, "error", err)
+		}
+	}
+
+	now := s.now()
+	s.mu.Lock()
+	last, ok := s.lastSnapshot[clusterID]
+	s.lastSnapshot[clusterID] = now
+	s.mu.Unlock()
+	// 首次快照或中断过久（如服务重启）时只计一个周期，避免把停机期间算作在用
+	elapsed := s.opts.SnapshotInterval
+	if ok && now.Sub(last) < 2*s.opts.SnapshotInterval {
+		elapsed = now.Sub(last)
+	}
+	hours := elapsed.Hours()
+
+	usages := snapshotCostUsage(pods, cronJobs, usage, hours)
+	if client, err := s.clientFor(clusterID); err == nil {
+		if pvcs, err := client.CoreV1().PersistentVolumeClaims("").List(ctx, metav1.ListOptions{}); err == nil {
+			usages = append(usages, pvcCostUsage(pvcs.Items, hours)...)
+		}
+	}
+
+	pricing, err := s.GetPricing(clusterID)
+	if err != nil {
+		return err
+	}
+	return s.mergeSnapshot(clusterID, now.Format(costDateLayout), usages, pricing, s.namespaceTeams(clusterID, pricing.TeamLabel))
+}
+
+// mergeSnapshot 将快照用量累加到当天已有的行
+func (s *CostService) mergeSnapshot(clusterID uint, date string, usages []costUsage, pricing *models.CostPricing, teams map[string]string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var existing []models.CostDailyRollup
+		if err := tx.Where("cluster_id = ? AND date = ?", clusterID, date).Find(&existing).Error; err != nil {
+			return err
+		}
+		rows := make(map[string]*models.CostDailyRollup, len(existing))
+		for i := range existing {
+			r := &existing[i]
+			rows[r.Namespace+"/"+r.WorkloadKind+"/"+r.Workload] = r
+		}
+		for _, u := range usages {
+			row, ok := rows[u.Namespace+"/"+u.Kind+"/"+u.Name]
+			if !ok {
+				row = &models.CostDailyRollup{ClusterID: clusterID, Date: date, Namespace: u.Namespace, WorkloadKind: u.Kind, Workload: u.Name, Source: models.CostSourceSnapshot}
+			}
+			row.Team = teams[u.Namespace]
+			addCostUsage(row, u, pricing)
+			if err := tx.Save(row).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// namespaceTeams 从命名空间标签读取团队归属
+func (s *CostService) namespaceTeams(clusterID uint, teamLabel string) map[string]string {
+	teams := make(map[string]string)
+	if s.listers == nil {
+		return teams
+	}
+	lister := s.listers.NamespacesLister(clusterID)
+	if lister == nil {
+		return teams
+	}
+	namespaces, err := lister.List(labels.Everything())
+	if err != nil {
+		return teams
+	}
+	for _, ns := range namespaces {
+		teams[ns.Name] = ns.Labels[teamLabel]
+	}
+	return teams
+}
+
+// snapshotCostUsage 按 max(request, 当前用量) 计算已调度 Pod 在 hours 小时内的用量，并归属到顶层工作负载
+func snapshotCostUsage(pods []*corev1.Pod, cronJobs map[string]string, usage map[string]models.ResourceUsage, hours float64) []costUsage {
+	usages := make(map[string]*costUsage)
+	for _, pod := range pods {
+		if pod.Spec.NodeName == "" || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		kind, name := podCostOwner(pod, cronJobs)
+		key := pod.Namespace + "/" + kind + "/" + name
+		u, ok := usages[key]
+		if !ok {
+			u = &costUsage{Namespace: pod.Namespace, Kind: kind, Name: name}
+			usages[key] = u
+		}
+
+		cpuRequest, _ := PodResourceTotals(pod, corev1.ResourceCPU)
+		memRequest, _ := PodResourceTotals(pod, corev1.ResourceMemory)
+		gpuRequest, gpuLimit := PodResourceTotals(pod, costGPUResource)
+		current := usage[pod.Namespace+"/"+pod.Name]
+		u.CPUCoreHours += math.Max(cpuRequest, current.CPU) * hours
+		u.MemoryGiBHours += math.Max(memRequest, current.Memory) / (1 << 30) * hours
+		u.GPUHours += math.Max(gpuRequest, gpuLimit) * hours
+	}
+
+	out := make([]costUsage, 0, len(usages))
+	for _, u := range usages {
+		out = append(out, *u)
+	}
+	return out
+}
+
+// podCostOwner Pod 的顶层工作负载：ReplicaSet 按 pod-template-hash 还原 Deployment，Job 查找所属 CronJob
+func podCostOwner(pod *corev1.Pod, cronJobs map[string]string) (string, string) {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return "Pod", pod.Name
+	}
+	switch owner.Kind {
+	case "ReplicaSet":
+		if hash := pod.Labels["pod-template-hash"]; hash != "" && strings.HasSuffix(owner.Name, "-"+hash) {
+			return "Deployment", strings.TrimSuffix(owner.Name, "-"+hash)
+		}
+	case "Job":
+		if cronJob, ok := cronJobs[pod.Namespace+"/"+owner.Name]; ok {
+			return "CronJob", cronJob
+		}
+	}
+	return owner.Kind, owner.Name
+}
+
+// pvcCostUsage 已绑定 PVC 在 hours 小时内的存储用量
+func pvcCostUsage(pvcs []corev1.PersistentVolumeClaim, hours float64) []costUsage {
+	out := make([]costUsage, 0, len(pvcs))
+	for _, pvc := range pvcs {
+		if pvc.Status.Phase != corev1.ClaimBound {
+			continue
+		}
+		size, ok := pvc.Status.Capacity[corev1.ResourceStorage]
+		if !ok {
+			size = pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+		}
+		class := ""
+		if pvc.Spec.StorageClassName != nil {
+			class = *pvc.Spec.StorageClassName
+		}
+		out = append(out, costUsage{
+			Namespace:       pvc.Namespace,
+			Kind:            "PersistentVolumeClaim",
+			Name:            pvc.Name,
+			StorageClass:    class,
+			StorageGiBHours: float64(size.Value()) / (1 << 30) * hours,
+		})
+	}
+	return out
+}
+
+// addCostUsage 将用量及按单价折算的成本累加到汇总行
+func addCostUsage(row *models.CostDailyRollup, u costUsage, pricing *models.CostPricing) {
+	storagePrice := pricing.StorageGiBMonth
+	if u.StorageGiBHours > 0 && pricing.StorageClassPrices != "" {
+		var prices map[string]float64
+		if json.Unmarshal([]byte(pricing.StorageClassPrices), &prices) == nil {
+			if price, ok := prices[u.StorageClass]; ok {
+				storagePrice = price
+			}
+		}
+	}
+
+	row.CPUCoreHours += u.CPUCoreHours
+	row.MemoryGiBHours += u.MemoryGiBHours
+	row.GPUHours += u.GPUHours
+	row.StorageGiBHours += u.StorageGiBHours
+	row.CPUCost += u.CPUCoreHours * pricing.CPUCoreHour
+	row.MemoryCost += u.MemoryGiBHours * pricing.MemoryGiBHour
+	row.GPUCost += u.GPUHours * pricing.GPUHour
+	row.StorageCost += u.StorageGiBHours * storagePrice / costHoursPerMonth
+	row.TotalCost = row.CPUCost + row.MemoryCost + row.GPUCost + row.StorageCost
+}
+
+// ========== 查询与导出 ==========
+
+// Allocation 按命名空间、工作负载或团队汇总日期范围内的成本；namespaces 非 nil 时只统计其中的命名空间
+func (s *CostService) Allocation(clusterID uint, query *models.CostAllocationQuery, namespaces []string) (*models.CostAllocationReport, error) {
+	startDate, endDate, err := s.costDateRange(query.StartDate, query.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	groupBy := query.GroupBy
+	if groupBy == "" {
+		groupBy = models.CostGroupByNamespace
+	}
+	var groupColumns string
+	switch groupBy {
+	case models.CostGroupByNamespace:
+		groupColumns = "namespace"
+	case models.CostGroupByWorkload:
+		groupColumns = "namespace, workload_kind, workload"
+	case models.CostGroupByTeam:
+		groupColumns = "team"
+	default:
+		return nil, fmt.Errorf("不支持的分摊维度: %s", groupBy)
+	}
+	pricing, err := s.GetPricing(clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	selectColumns := groupColumns
+	if groupBy != models.CostGroupByTeam {
+		// 命名空间的团队标签可能在期间内变化，取其中的最大值作为代表（空值最小）
+		selectColumns += ", MAX(team) AS team"
+	}
+	db := s.db.Model(&models.CostDailyRollup{}).
+		Select(selectColumns+", SUM(cpu_core_hours) AS cpu_core_hours, SUM(memory_gib_hours) AS memory_gib_hours, SUM(gpu_hours) AS gpu_hours, SUM(storage_gib_hours) AS storage_gib_hours, "+
+			"SUM(cpu_cost) AS cpu_cost, SUM(memory_cost) AS memory_cost, SUM(gpu_cost) AS gpu_cost, SUM(storage_cost) AS storage_cost, SUM(total_cost) AS total_cost").
+		Where("cluster_id = ? AND date >= ? AND date <= ?", clusterID, startDate, endDate)
+	if query.Namespace != "" {
+		db = db.Where("namespace = ?", query.Namespace)
+	}
+	if query.Team != "" {
+		db = db.Where("team = ?", query.Team)
+	}
+	if namespaces != nil {
+		db = db.Where("namespace IN ?", namespaces)
+	}
+
+	var items []models.CostAllocationItem
+	if err := db.Group(groupColumns).Order("total_cost DESC").Scan(&items).Error; err != nil {
+		return nil, fmt.Errorf("查询成本数据失败: %w", err)
+	}
+
+	report := &models.CostAllocationReport{Currency: pricing.Currency, StartDate: startDate, EndDate: endDate, GroupBy: groupBy, Items: items}
+	if report.Items == nil {
+		report.Items = []models.CostAllocationItem{}
+	}
+	for i := range report.Items {
+		roundCostItem(&report.Items[i])
+		report.TotalCost += report.Items[i].TotalCost
+	}
+	report.TotalCost = roundTo(report.TotalCost, 2)
+	return report, nil
+}
+
+// Namespaces 返回有成本数据的命名空间，用于按权限过滤
+func (s *CostService) Namespaces(clusterID uint) ([]string, error) {
+	var namespaces []string
+	err := s.db.Model(&models.CostDailyRollup{}).Where("cluster_id = ?", clusterID).Distinct().Pluck("namespace", &namespaces).Error
+	return namespaces, err
+}
+
+// costDateRange 解析日期范围，默认最近 30 天（含今天）
+func (s *CostService) costDateRange(start, end string) (string, string, error) {
+	today := s.today()
+	endDay := today
+	if end != "" {
+		t, err := time.ParseInLocation(costDateLayout, end, today.Location())
+		if err != nil {
+			return "", "", fmt.Errorf("无效的结束日期: %s", end)
+		}
+		endDay = t
+	}
+	startDay := endDay.AddDate(0, 0, 1-costDefaultRangeDays)
+	if start != "" {
+		t, err := time.ParseInLocation(costDateLayout, start, today.Location())
+		if err != nil {
+			return "", "", fmt.Errorf("无效的开始日期: %s", start)
+		}
+		startDay = t
+	}
+	if startDay.After(endDay) {
+		return "", "", fmt.Errorf("开始日期不能晚于结束日期")
+	}
+	if endDay.Sub(startDay) > costMaxRangeDays*24*time.Hour {
+		return "", "", fmt.Errorf("查询范围不能超过 %d 天", costMaxRangeDays)
+	}
+	return startDay.Format(costDateLayout), endDay.Format(costDateLayout), nil
+}
+
+func (s *CostService) today() time.Time {
+	now := s.now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+}
+
+func roundCostItem(item *models.CostAllocationItem) {
+	item.CPUCoreHours = roundTo(item.CPUCoreHours, 2)
+	item.MemoryGiBHours = roundTo(item.MemoryGiBHours, 2)
+	item.GPUHours = roundTo(item.GPUHours, 2)
+	item.StorageGiBHours = roundTo(item.StorageGiBHours, 2)
+	item.CPUCost = roundTo(item.CPUCost, 2)
+	item.MemoryCost = roundTo(item.MemoryCost, 2)
+	item.GPUCost = roundTo(item.GPUCost, 2)
+	item.StorageCost = roundTo(item.StorageCost, 2)
+	item.TotalCost = roundTo(item.TotalCost, 2)
+}
+
+// WriteCostCSV 将成本分摊结果写为 CSV，首列随分摊维度变化
+func WriteCostCSV(w io.Writer, report *models.CostAllocationReport) error {
+	writer := csv.NewWriter(w)
+	var header []string
+	switch report.GroupBy {
+	case models.CostGroupByWorkload:
+		header = []string{"namespace", "workload_kind", "workload", "team"}
+	case models.CostGroupByTeam:
+		header = []string{"team"}
+	default:
+		header = []string{"namespace", "team"}
+	}
+	header = append(header, "cpu_core_hours", "memory_gib_hours", "gpu_hours", "storage_gib_hours",
+		"cpu_cost", "memory_cost", "gpu_cost", "storage_cost", "total_cost", "currency", "start_date", "end_date")
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	items := append([]models.CostAllocationItem(nil), report.Items...)
+	sort.SliceStable(items, func(i, j int) bool { return items[i].TotalCost > items[j].TotalCost })
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) }
+	for _, item := range items {
+		var record []string
+		switch report.GroupBy {
+		case models.CostGroupByWorkload:
+			record = []string{item.Namespace, item.WorkloadKind, item.Workload, item.Team}
+		case models.CostGroupByTeam:
+			record = []string{item.Team}
+		default:
+			record = []string{item.Namespace, item.Team}
+		}
+		record = append(record, f(item.CPUCoreHours), f(item.MemoryGiBHours), f(item.GPUHours), f(item.StorageGiBHours),
+			f(item.CPUCost), f(item.MemoryCost), f(item.GPUCost), f(item.StorageCost), f(item.TotalCost),
+			report.Currency, report.StartDate, report.EndDate)
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CostServiceTestSuite 定义成本分摊测试套件
+type CostServiceTestSuite struct {
+	suite.Suite
+	svc *CostService
+}
+
+// SetupTest 每个测试前的设置
+func (s *CostServiceTestSuite) SetupTest() {
+	s.svc = NewCostService(nil, nil, nil, nil, nil, nil, CostOptions{})
+	s.svc.now = func() time.Time { return time.Date(2024, 3, 15, 10, 0, 0, 0, time.UTC) }
+}
+
+func costPod(name, ownerKind, ownerName, cpu, memory string) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "prod", Labels: map[string]string{"pod-template-hash": "7d9f8c6b5"}},
+		Spec: corev1.PodSpec{NodeName: "node-1", Containers: []corev1.Container{{Name: "app", Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu), corev1.ResourceMemory: resource.MustParse(memory)},
+		}}}},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	if ownerKind != "" {
+		controller := true
+		pod.OwnerReferences = []metav1.OwnerReference{{Kind: ownerKind, Name: ownerName, Controller: &controller}}
+	}
+	return pod
+}
+
+func sortCostUsages(usages []costUsage) {
+	sort.Slice(usages, func(i, j int) bool { return usages[i].Kind+usages[i].Name < usages[j].Kind+usages[j].Name })
+}
+
+// TestSnapshotCostUsage 测试快照按 max(request, 用量) 计量并归属到顶层工作负载
+func (s *CostServiceTestSuite) TestSnapshotCostUsage() {
+	pending := costPod("api-pending", "ReplicaSet", "api-7d9f8c6b5", "1", "1Gi")
+	pending.Spec.NodeName = ""
+	done := costPod("report-1-xyz", "Job", "report-1", "1", "1Gi")
+	done.Status.Phase = corev1.PodSucceeded
+	pods := []*corev1.Pod{
+		costPod("api-7d9f8c6b5-a", "ReplicaSet", "api-7d9f8c6b5", "500m", "1Gi"),
+		costPod("api-7d9f8c6b5-b", "ReplicaSet", "api-7d9f8c6b5", "500m", "1Gi"),
+		costPod("backup-28401-abc", "Job", "backup-28401", "250m", "512Mi"),
+		costPod("debug", "", "", "100m", "128Mi"),
+		pending,
+		done,
+	}
+	usage := map[string]models.ResourceUsage{
+		"prod/api-7d9f8c6b5-a": {CPU: 2, Memory: 512 << 20}, // CPU 用量超过 request
+	}
+	usages := snapshotCostUsage(pods, map[string]string{"prod/backup-28401": "backup"}, usage, 0.5)
+	sortCostUsages(usages)
+	s.Require().Len(usages, 3)
+
+	assert.Equal(s.T(), "CronJob", usages[0].Kind)
+	assert.Equal(s.T(), "backup", usages[0].Name)
+	assert.InDelta(s.T(), 0.125, usages[0].CPUCoreHours, 1e-9)
+
+	assert.Equal(s.T(), "Deployment", usages[1].Kind)
+	assert.Equal(s.T(), "api", usages[1].Name)
+	assert.InDelta(s.T(), (2+0.5)*0.5, usages[1].CPUCoreHours, 1e-9)
+	assert.InDelta(s.T(), 1.0, usages[1].MemoryGiBHours, 1e-9)
+
+	assert.Equal(s.T(), "Pod", usages[2].Kind)
+	assert.Equal(s.T(), "debug", usages[2].Name)
+}
+
+// TestPVCAndPricing 测试 PVC 存储用量与按存储类定价
+func (s *CostServiceTestSuite) TestPVCAndPricing() {
+	fast := "fast"
+	pvcs := []corev1.PersistentVolumeClaim{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "prod"},
+			Spec:       corev1.PersistentVolumeClaimSpec{StorageClassName: &fast},
+			Status:     corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound, Capacity: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("100Gi")}},
+		},
+		{ObjectMeta: metav1.ObjectMeta{Name: "pending", Namespace: "prod"}, Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending}},
+	}
+	usages := pvcCostUsage(pvcs, 730)
+	s.Require().Len(usages, 1)
+	assert.Equal(s.T(), 73000.0, usages[0].StorageGiBHours)
+
+	pricing := &models.CostPricing{CPUCoreHour: 0.2, MemoryGiBHour: 0.05, GPUHour: 10, StorageGiBMonth: 0.5, StorageClassPrices: `{"fast":1.2}`}
+	row := &models.CostDailyRollup{}
+	addCostUsage(row, usages[0], pricing)
+	assert.InDelta(s.T(), 120, row.StorageCost, 1e-9, "100Gi × 1.2/GiB·月 × 1 个月")
+
+	addCostUsage(row, costUsage{CPUCoreHours: 10, MemoryGiBHours: 20, GPUHours: 1}, pricing)
+	assert.InDelta(s.T(), 2, row.CPUCost, 1e-9)
+	assert.InDelta(s.T(), 1, row.MemoryCost, 1e-9)
+	assert.InDelta(s.T(), 10, row.GPUCost, 1e-9)
+	assert.InDelta(s.T(), 133, row.TotalCost, 1e-9)
+
+	row = &models.CostDailyRollup{}
+	addCostUsage(row, costUsage{StorageClass: "standard", StorageGiBHours: 730}, pricing)
+	assert.InDelta(s.T(), 0.5, row.StorageCost, 1e-9, "未单独定价的存储类使用默认单价")
+}
+
+// TestQueryCostUsage 测试 Prometheus 用量汇总与 owner 链解析
+func (s *CostServiceTestSuite) TestQueryCostUsage() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query().Get("query")
+		var result string
+		switch {
+		case strings.Contains(q, "kube_pod_owner"):
+			result = `[{"metric":{"namespace":"prod","pod":"api-x-1","owner_kind":"ReplicaSet","owner_name":"api-x"},"value":[0,"1"]},
+				{"metric":{"namespace":"prod","pod":"backup-1-a","owner_kind":"Job","owner_name":"backup-1"},"value":[0,"1"]}]`
+		case strings.Contains(q, "kube_replicaset_owner"):
+			result = `[{"metric":{"namespace":"prod","replicaset":"api-x","owner_kind":"Deployment","owner_name":"api"},"value":[0,"1"]}]`
+		case strings.Contains(q, "kube_job_owner"):
+			result = `[{"metric":{"namespace":"prod","job_name":"backup-1","owner_kind":"CronJob","owner_name":"backup"},"value":[0,"1"]}]`
+		case strings.Contains(q, `resource="cpu"`):
+			result = `[{"metric":{"namespace":"prod","pod":"api-x-1"},"values":[[0,"0.5"],[3600,"1.5"]]},
+				{"metric":{"namespace":"prod","pod":"backup-1-a"},"values":[[0,"1"]]},
+				{"metric":{"namespace":"prod","pod":"orphan"},"values":[[0,"0.1"],[3600,"NaN"]]}]`
+		case strings.Contains(q, `resource="memory"`):
+			result = `[{"metric":{"namespace":"prod","pod":"api-x-1"},"values":[[0,"1073741824"],[3600,"1073741824"]]}]`
+		case strings.Contains(q, "persistentvolumeclaim"):
+			result = `[{"metric":{"namespace":"prod","persistentvolumeclaim":"data","storageclass":"fast"},"values":[[0,"10737418240"]]}]`
+		default:
+			result = `[]`
+		}
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":` + result + `}}`))
+	}))
+	defer server.Close()
+
+	prom := NewPrometheusService()
+	start := time.Date(2024, 3, 14, 0, 0, 0, 0, time.UTC)
+	usages, err := prom.queryCostUsage(context.Background(), &models.MonitoringConfig{Type: "prometheus", Endpoint: server.URL}, start, start.AddDate(0, 0, 1))
+	s.Require().NoError(err)
+	sortCostUsages(usages)
+	s.Require().Len(usages, 4)
+
+	assert.Equal(s.T(), costUsage{Namespace: "prod", Kind: "CronJob", Name: "backup", CPUCoreHours: 1}, usages[0])
+	assert.Equal(s.T(), costUsage{Namespace: "prod", Kind: "Deployment", Name: "api", CPUCoreHours: 2, MemoryGiBHours: 2}, usages[1])
+	assert.Equal(s.T(), costUsage{Namespace: "prod", Kind: "PersistentVolumeClaim", Name: "data", StorageClass: "fast", StorageGiBHours: 10}, usages[2])
+	assert.Equal(s.T(), costUsage{Namespace: "prod", Kind: "Pod", Name: "orphan", CPUCoreHours: 0.1}, usages[3])
+}
+
+// TestCostDateRange 测试日期范围解析
+func (s *CostServiceTestSuite) TestCostDateRange() {
+	start, end, err := s.svc.costDateRange("", "")
+	s.Require().NoError(err)
+	assert.Equal(s.T(), "2024-02-15", start)
+	assert.Equal(s.T(), "2024-03-15", end)
+
+	_, _, err = s.svc.costDateRange("2024-03-10", "2024-03-01")
+	assert.Error(s.T(), err)
+	_, _, err = s.svc.costDateRange("2022-01-01", "2024-03-01")
+	assert.Error(s.T(), err)
+	_, _, err = s.svc.costDateRange("03/01/2024", "")
+	assert.Error(s.T(), err)
+}
+
+// TestWriteCostCSV 测试 CSV 导出
+func (s *CostServiceTestSuite) TestWriteCostCSV() {
+	report := &models.CostAllocationReport{
+		Currency: "CNY", StartDate: "2024-03-01", EndDate: "2024-03-14", GroupBy: models.CostGroupByTeam,
+		Items: []models.CostAllocationItem{
+			{Team: "infra", CPUCost: 1, TotalCost: 1},
+			{Team: "pay", CPUCoreHours: 12.345, CPUCost: 2.5, TotalCost: 2.5},
+		},
+	}
+	var buf bytes.Buffer
+	s.Require().NoError(WriteCostCSV(&buf, report))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	s.Require().Len(lines, 3)
+	assert.True(s.T(), strings.HasPrefix(lines[0], "team,cpu_core_hours,"))
+	assert.Equal(s.T(), "pay,12.35,0.00,0.00,0.00,2.50,0.00,0.00,0.00,2.50,CNY,2024-03-01,2024-03-14", lines[1])
+}
+
+// TestCostServiceTestSuite 运行成本分摊测试套件
+func TestCostServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(CostServiceTestSuite))
+}
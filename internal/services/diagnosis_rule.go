@@ -0,0 +1,436 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+
+	rolloutsv1alpha1 "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	rolloutslisters "github.com/argoproj/argo-rollouts/pkg/client/listers/rollouts/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	appsv1listers "k8s.io/client-go/listers/apps/v1"
+	batchv1listers "k8s.io/client-go/listers/batch/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+)
+
+// errDiagnosisRuleSkipped 规则在当前集群不适用（如未配置 Prometheus），不计入分类评分
+var errDiagnosisRuleSkipped = errors.New("诊断规则不适用")
+
+// DiagnosisRuleMeta 诊断规则描述
+type DiagnosisRuleMeta struct {
+	ID          string
+	Name        string
+	Category    string
+	Description string
+	Params      []models.DiagnosisRuleParam
+	// UnavailableScore 规则因数据获取失败无法执行时，所属分类评分的上限；0 表示不限制
+	UnavailableScore int
+}
+
+// DiagnosisResult 一条规则的诊断结果
+type DiagnosisResult struct {
+	Risks     []models.RiskItem
+	Deduction int // 在所属分类中的扣分
+	Evaluated int // 自定义规则求值的对象数或序列数
+}
+
+// DiagnosisRule 健康诊断规则：检查一类问题，生成风险项并在所属分类中扣分。
+// params 为默认值与集群配置合并后的阈值参数；规则不适用时返回 errDiagnosisRuleSkipped
+type DiagnosisRule interface {
+	Meta() DiagnosisRuleMeta
+	Evaluate(ctx context.Context, env *DiagnosisEnv, params map[string]float64) (*DiagnosisResult, error)
+}
+
+// DiagnosisRuleRegistry 诊断规则注册表，按注册顺序执行
+type DiagnosisRuleRegistry struct {
+	mu    sync.RWMutex
+	rules []DiagnosisRule
+	byID  map[string]DiagnosisRule
+}
+
+// NewDiagnosisRuleRegistry 创建诊断规则注册表
+func NewDiagnosisRuleRegistry(rules ...DiagnosisRule) *DiagnosisRuleRegistry {
+	r := &DiagnosisRuleRegistry{byID: make(map[string]DiagnosisRule)}
+	for _, rule := range rules {
+		if err := r.Register(rule); err != nil {
+			panic(err)
+		}
+	}
+	return r
+}
+
+// Register 注册诊断规则，ID 不能重复
+func (r *DiagnosisRuleRegistry) Register(rule DiagnosisRule) error {
+	meta := rule.Meta()
+	if meta.ID == "" || meta.Category == "" {
+		return fmt.Errorf("诊断规则必须设置 ID 和分类")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.byID[meta.ID]; exists {
+		return fmt.Errorf("诊断规则 %s 已注册", meta.ID)
+	}
+	r.rules = append(r.rules, rule)
+	r.byID[meta.ID] = rule
+	return nil
+}
+
+// Rules 返回已注册的规则
+func (r *DiagnosisRuleRegistry) Rules() []DiagnosisRule {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]DiagnosisRule(nil), r.rules...)
+}
+
+// Get 按 ID 获取规则
+func (r *DiagnosisRuleRegistry) Get(id string) (DiagnosisRule, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	rule, ok := r.byID[id]
+	return rule, ok
+}
+
+// DefaultDiagnosisRules 内置诊断规则，可在启动时注册额外的规则
+var DefaultDiagnosisRules = NewDiagnosisRuleRegistry(builtinDiagnosisRules()...)
+
+// DiagnosisListerProvider 诊断时优先读取的 informer 缓存
+type DiagnosisListerProvider interface {
+	HasSynced(clusterID uint) bool
+	NodesLister(clusterID uint) corev1listers.NodeLister
+	NamespacesLister(clusterID uint) corev1listers.NamespaceLister
+	PodsLister(clusterID uint) corev1listers.PodLister
+	ServicesLister(clusterID uint) corev1listers.ServiceLister
+	ConfigMapsLister(clusterID uint) corev1listers.ConfigMapLister
+	DeploymentsLister(clusterID uint) appsv1listers.DeploymentLister
+	StatefulSetsLister(clusterID uint) appsv1listers.StatefulSetLister
+	DaemonSetsLister(clusterID uint) appsv1listers.DaemonSetLister
+	JobsLister(clusterID uint) batchv1listers.JobLister
+	RolloutsLister(clusterID uint) rolloutslisters.RolloutLister
+}
+
+// DiagnosisEnv 一次诊断中各规则共享的数据来源，集群对象在同一次诊断中只获取一次：
+// informer 缓存已同步时从缓存读取，否则直接 List
+type DiagnosisEnv struct {
+	ClusterID  uint
+	Client     kubernetes.Interface
+	Monitoring *models.MonitoringConfig // 未配置 Prometheus 时为 nil
+	Now        time.Time
+
+	prometheusSvc  *PrometheusService
+	usageProvider  ResourceMetricsProvider
+	capacitySvc    *CapacityForecastService
+	certificateSvc *CertificateService
+	listers        DiagnosisListerProvider // 为 nil 或未同步时直接 List
+
+	mu    sync.Mutex
+	cache map[string]*diagnosisListEntry
+}
+
+type diagnosisListEntry struct {
+	once  sync.Once
+	items interface{}
+	err   error
+}
+
+// loadDiagnosisList 获取并缓存一类对象；fromLister 为 nil 或 informer 未同步时使用 fromAPI
+func loadDiagnosisList[T any](ctx context.Context, env *DiagnosisEnv, resource string, fromLister func(clusterID uint) ([]*T, bool, error), fromAPI func(ctx context.Context) ([]T, error)) ([]*T, error) {
+	env.mu.Lock()
+	if env.cache == nil {
+		env.cache = make(map[string]*diagnosisListEntry)
+	}
+	entry, ok := env.cache[resource]
+	if !ok {
+		entry = &diagnosisListEntry{}
+		env.cache[resource] = entry
+	}
+	env.mu.Unlock()
+
+	entry.once.Do(func() {
+		if fromLister != nil && env.listers != nil && env.listers.HasSynced(env.ClusterID) {
+			if items, found, err := fromLister(env.ClusterID); found {
+				entry.items, entry.err = items, err
+				return
+			}
+		}
+		items, err := fromAPI(ctx)
+		if err != nil {
+			entry.err = fmt.Errorf("获取 %s 列表失败: %w", resource, err)
+			return
+		}
+		ptrs := make([]*T, len(items))
+		for i := range items {
+			ptrs[i] = &items[i]
+		}
+		entry.items = ptrs
+	})
+	if entry.err != nil {
+		return nil, entry.err
+	}
+	return entry.items.([]*T), nil
+}
+
+// Nodes 获取集群节点
+func (e *DiagnosisEnv) Nodes(ctx context.Context) ([]*corev1.Node, error) {
+	return loadDiagnosisList(ctx, e, "nodes", func(id uint) ([]*corev1.Node, bool, error) {
+		if l := e.listers.NodesLister(id); l != nil {
+			items, err := l.List(labels.Everything())
+			return items, true, err
+		}
+		return nil, false, nil
+	}, func(ctx context.Context) ([]corev1.Node, error) {
+		list, err := e.Client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return list.Items, nil
+	})
+}
+
+// Namespaces 获取命名空间
+func (e *DiagnosisEnv) Namespaces(ctx context.Context) ([]*corev1.Namespace, error) {
+	return loadDiagnosisList(ctx, e, "namespaces", func(id uint) ([]*corev1.Namespace, bool, error) {
+		if l := e.listers.NamespacesLister(id); l != nil {
+			items, err := l.List(labels.Everything())
+			return items, true, err
+		}
+		return nil, false, nil
+	}, func(ctx context.Context) ([]corev1.Namespace, error) {
+		list, err := e.Client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return list.Items, nil
+	})
+}
+
+// Pods 获取所有命名空间的 Pod
+func (e *DiagnosisEnv) Pods(ctx context.Context) ([]*corev1.Pod, error) {
+	return loadDiagnosisList(ctx, e, "pods", func(id uint) ([]*corev1.Pod, bool, error) {
+		if l := e.listers.PodsLister(id); l != nil {
+			items, err := l.List(labels.Everything())
+			return items, true, err
+		}
+		return nil, false, nil
+	}, func(ctx context.Context) ([]corev1.Pod, error) {
+		list, err := e.Client.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return list.Items, nil
+	})
+}
+
+// Services 获取所有命名空间的 Service
+func (e *DiagnosisEnv) Services(ctx context.Context) ([]*corev1.Service, error) {
+	return loadDiagnosisList(ctx, e, "services", func(id uint) ([]*corev1.Service, bool, error) {
+		if l := e.listers.ServicesLister(id); l != nil {
+			items, err := l.List(labels.Everything())
+			return items, true, err
+		}
+		return nil, false, nil
+	}, func(ctx context.Context) ([]corev1.Service, error) {
+		list, err := e.Client.CoreV1().Services("").List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return list.Items, nil
+	})
+}
+
+// ConfigMaps 获取所有命名空间的 ConfigMap
+func (e *DiagnosisEnv) ConfigMaps(ctx context.Context) ([]*corev1.ConfigMap, error) {
+	return loadDiagnosisList(ctx, e, "configmaps", func(id uint) ([]*corev1.ConfigMap, bool, error) {
+		if l := e.listers.ConfigMapsLister(id); l != nil {
+			items, err := l.List(labels.Everything())
+			return items, true, err
+		}
+		return nil, false, nil
+	}, func(ctx context.Context) ([]corev1.ConfigMap, error) {
+		list, err := e.Client.CoreV1().ConfigMaps("").List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return list.Items, nil
+	})
+}
+
+// Deployments 获取所有命名空间的 Deployment
+func (e *DiagnosisEnv) Deployments(ctx context.Context) ([]*appsv1.Deployment, error) {
+	return loadDiagnosisList(ctx, e, "deployments", func(id uint) ([]*appsv1.Deployment, bool, error) {
+		if l := e.listers.DeploymentsLister(id); l != nil {
+			items, err := l.List(labels.Everything())
+			return items, true, err
+		}
+		return nil, false, nil
+	}, func(ctx context.Context) ([]appsv1.Deployment, error) {
+		list, err := e.Client.AppsV1().Deployments("").List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return list.Items, nil
+	})
+}
+
+// StatefulSets 获取所有命名空间的 StatefulSet
+func (e *DiagnosisEnv) StatefulSets(ctx context.Context) ([]*appsv1.StatefulSet, error) {
+	return loadDiagnosisList(ctx, e, "statefulsets", func(id uint) ([]*appsv1.StatefulSet, bool, error) {
+		if l := e.listers.StatefulSetsLister(id); l != nil {
+			items, err := l.List(labels.Everything())
+			return items, true, err
+		}
+		return nil, false, nil
+	}, func(ctx context.Context) ([]appsv1.StatefulSet, error) {
+		list, err := e.Client.AppsV1().StatefulSets("").List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return list.Items, nil
+	})
+}
+
+// DaemonSets 获取所有命名空间的 DaemonSet
+func (e *DiagnosisEnv) DaemonSets(ctx context.Context) ([]*appsv1.DaemonSet, error) {
+	return loadDiagnosisList(ctx, e, "daemonsets", func(id uint) ([]*appsv1.DaemonSet, bool, error) {
+		if l := e.listers.DaemonSetsLister(id); l != nil {
+			items, err := l.List(labels.Everything())
+			return items, true, err
+		}
+		return nil, false, nil
+	}, func(ctx context.Context) ([]appsv1.DaemonSet, error) {
+		list, err := e.Client.AppsV1().DaemonSets("").List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return list.Items, nil
+	})
+}
+
+// Jobs 获取所有命名空间的 Job
+func (e *DiagnosisEnv) Jobs(ctx context.Context) ([]*batchv1.Job, error) {
+	return loadDiagnosisList(ctx, e, "jobs", func(id uint) ([]*batchv1.Job, bool, error) {
+		if l := e.listers.JobsLister(id); l != nil {
+			items, err := l.List(labels.Everything())
+			return items, true, err
+		}
+		return nil, false, nil
+	}, func(ctx context.Context) ([]batchv1.Job, error) {
+		list, err := e.Client.BatchV1().Jobs("").List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return list.Items, nil
+	})
+}
+
+// CronJobs 获取所有命名空间的 CronJob
+func (e *DiagnosisEnv) CronJobs(ctx context.Context) ([]*batchv1.CronJob, error) {
+	return loadDiagnosisList(ctx, e, "cronjobs", nil, func(ctx context.Context) ([]batchv1.CronJob, error) {
+		list, err := e.Client.BatchV1().CronJobs("").List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return list.Items, nil
+	})
+}
+
+// Rollouts 获取 Argo Rollouts，集群未安装 Rollouts 或 informer 未启动时返回空列表
+func (e *DiagnosisEnv) Rollouts(ctx context.Context) ([]*rolloutsv1alpha1.Rollout, error) {
+	return loadDiagnosisList(ctx, e, "rollouts", func(id uint) ([]*rolloutsv1alpha1.Rollout, bool, error) {
+		if l := e.listers.RolloutsLister(id); l != nil {
+			items, err := l.List(labels.Everything())
+			return items, true, err
+		}
+		return nil, false, nil
+	}, func(ctx context.Context) ([]rolloutsv1alpha1.Rollout, error) {
+		return nil, nil
+	})
+}
+
+// PersistentVolumeClaims 获取所有命名空间的 PVC
+func (e *DiagnosisEnv) PersistentVolumeClaims(ctx context.Context) ([]*corev1.PersistentVolumeClaim, error) {
+	return loadDiagnosisList(ctx, e, "persistentvolumeclaims", nil, func(ctx context.Context) ([]corev1.PersistentVolumeClaim, error) {
+		list, err := e.Client.CoreV1().PersistentVolumeClaims("").List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return list.Items, nil
+	})
+}
+
+// PersistentVolumes 获取 PV
+func (e *DiagnosisEnv) PersistentVolumes(ctx context.Context) ([]*corev1.PersistentVolume, error) {
+	return loadDiagnosisList(ctx, e, "persistentvolumes", nil, func(ctx context.Context) ([]corev1.PersistentVolume, error) {
+		list, err := e.Client.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return list.Items, nil
+	})
+}
+
+// ResourceQuotas 获取所有命名空间的 ResourceQuota
+func (e *DiagnosisEnv) ResourceQuotas(ctx context.Context) ([]*corev1.ResourceQuota, error) {
+	return loadDiagnosisList(ctx, e, "resourcequotas", nil, func(ctx context.Context) ([]corev1.ResourceQuota, error) {
+		list, err := e.Client.CoreV1().ResourceQuotas("").List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return list.Items, nil
+	})
+}
+
+// PodDisruptionBudgets 获取所有命名空间的 PDB
+func (e *DiagnosisEnv) PodDisruptionBudgets(ctx context.Context) ([]*policyv1.PodDisruptionBudget, error) {
+	return loadDiagnosisList(ctx, e, "poddisruptionbudgets", nil, func(ctx context.Context) ([]policyv1.PodDisruptionBudget, error) {
+		list, err := e.Client.PolicyV1().PodDisruptionBudgets("").List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return list.Items, nil
+	})
+}
+
+// Ingresses 获取所有命名空间的 Ingress
+func (e *DiagnosisEnv) Ingresses(ctx context.Context) ([]*networkingv1.Ingress, error) {
+	return loadDiagnosisList(ctx, e, "ingresses", nil, func(ctx context.Context) ([]networkingv1.Ingress, error) {
+		list, err := e.Client.NetworkingV1().Ingresses("").List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return list.Items, nil
+	})
+}
+
+// queryScalar 执行 Prometheus 查询并返回第一条序列的当前值
+func (e *DiagnosisEnv) queryScalar(ctx context.Context, query string) (float64, bool) {
+	if e.Monitoring == nil {
+		return 0, false
+	}
+	resp, err := e.queryRange(ctx, query)
+	if err != nil || len(resp.Data.Result) == 0 || len(resp.Data.Result[0].Values) == 0 {
+		return 0, false
+	}
+	return parseDiagnosisSample(resp.Data.Result[0].Values[0])
+}
+
+// queryRange 以当前时间点执行查询（与原有诊断保持一致，使用区间查询接口）
+func (e *DiagnosisEnv) queryRange(ctx context.Context, query string) (*models.MetricsResponse, error) {
+	now := e.Now.Unix()
+	return e.prometheusSvc.QueryPrometheus(ctx, e.Monitoring, &models.MetricsQuery{
+		Query: query,
+		Start: now,
+		End:   now,
+		Step:  "1m",
+	})
+}
@@ -0,0 +1,362 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	customDiagnosisCategory = "custom"
+	customDiagnosisMaxRisks = 20
+	// customDiagnosisCostLimit 单个对象求值的 CEL 代价上限，防止表达式遍历过大的列表
+	customDiagnosisCostLimit = 1000000
+)
+
+// customDiagnosisResources CEL 规则可检查的资源类型
+var customDiagnosisResources = []string{
+	"nodes", "namespaces", "pods", "services", "configmaps", "deployments", "statefulsets",
+	"daemonsets", "jobs", "persistentvolumeclaims", "persistentvolumes", "ingresses",
+}
+
+// customDiagnosisObjects 获取 CEL 规则检查的对象
+func customDiagnosisObjects(ctx context.Context, env *DiagnosisEnv, resource string) ([]metav1.Object, error) {
+	switch resource {
+	case "nodes":
+		return diagnosisObjects(env.Nodes(ctx))
+	case "namespaces":
+		return diagnosisObjects(env.Namespaces(ctx))
+	case "pods":
+		return diagnosisObjects(env.Pods(ctx))
+	case "services":
+		return diagnosisObjects(env.Services(ctx))
+	case "configmaps":
+		return diagnosisObjects(env.ConfigMaps(ctx))
+	case "deployments":
+		return diagnosisObjects(env.Deployments(ctx))
+	case "statefulsets":
+		return diagnosisObjects(env.StatefulSets(ctx))
+	case "daemonsets":
+		return diagnosisObjects(env.DaemonSets(ctx))
+	case "jobs":
+		return diagnosisObjects(env.Jobs(ctx))
+	case "persistentvolumeclaims":
+		return diagnosisObjects(env.PersistentVolumeClaims(ctx))
+	case "persistentvolumes":
+		return diagnosisObjects(env.PersistentVolumes(ctx))
+	case "ingresses":
+		return diagnosisObjects(env.Ingresses(ctx))
+	}
+	return nil, fmt.Errorf("不支持的资源类型 %q", resource)
+}
+
+// diagnosisObjects 将对象列表转换为 metav1.Object
+func diagnosisObjects[T metav1.Object](items []T, err error) ([]metav1.Object, error) {
+	if err != nil {
+		return nil, err
+	}
+	objects := make([]metav1.Object, len(items))
+	for i, item := range items {
+		objects[i] = item
+	}
+	return objects, nil
+}
+
+var (
+	diagnosisCELEnvOnce sync.Once
+	diagnosisCELEnvVal  *cel.Env
+	diagnosisCELEnvErr  error
+)
+
+// diagnosisCELEnv 自定义规则的 CEL 环境：object 为对象（与 kubectl get -o json 的结构一致），now 为诊断时间
+func diagnosisCELEnv() (*cel.Env, error) {
+	diagnosisCELEnvOnce.Do(func() {
+		diagnosisCELEnvVal, diagnosisCELEnvErr = cel.NewEnv(
+			cel.Variable("object", cel.DynType),
+			cel.Variable("now", cel.TimestampType),
+			ext.Strings(),
+		)
+	})
+	return diagnosisCELEnvVal, diagnosisCELEnvErr
+}
+
+// compileDiagnosisCEL 编译 CEL 表达式并校验返回类型
+func compileDiagnosisCEL(expression string, want *cel.Type) (cel.Program, error) {
+	env, err := diagnosisCELEnv()
+	if err != nil {
+		return nil, err
+	}
+	ast, issues := env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	if out := ast.OutputType(); !out.IsExactType(want) && !out.IsExactType(cel.DynType) {
+		return nil, fmt.Errorf("表达式应返回 %s，实际为 %s", want, out)
+	}
+	return env.Program(ast, cel.CostLimit(customDiagnosisCostLimit), cel.InterruptCheckFrequency(100))
+}
+
+// customDiagnosisRule 用户自定义诊断规则
+type customDiagnosisRule struct {
+	rule      *models.CustomDiagnosisRule
+	condition cel.Program
+	message   cel.Program
+}
+
+// customDiagnosisRuleID 自定义规则在诊断引擎中的 ID
+func customDiagnosisRuleID(id uint) string {
+	return fmt.Sprintf("custom-%d", id)
+}
+
+// newCustomDiagnosisRule 校验自定义规则并编译 CEL 表达式
+func newCustomDiagnosisRule(rule *models.CustomDiagnosisRule) (*customDiagnosisRule, error) {
+	r := &customDiagnosisRule{rule: rule}
+	switch rule.Type {
+	case models.DiagnosisRuleTypeCEL:
+		if !containsString(customDiagnosisResources, rule.Resource) {
+			return nil, fmt.Errorf("不支持的资源类型 %q，可选: %s", rule.Resource, strings.Join(customDiagnosisResources, ", "))
+		}
+		if strings.TrimSpace(rule.Expression) == "" {
+			return nil, fmt.Errorf("CEL 规则必须填写表达式")
+		}
+		program, err := compileDiagnosisCEL(rule.Expression, cel.BoolType)
+		if err != nil {
+			return nil, fmt.Errorf("CEL 表达式无效: %w", err)
+		}
+		r.condition = program
+		if strings.TrimSpace(rule.MessageExpression) != "" {
+			if r.message, err = compileDiagnosisCEL(rule.MessageExpression, cel.StringType); err != nil {
+				return nil, fmt.Errorf("CEL 描述表达式无效: %w", err)
+			}
+		}
+	case models.DiagnosisRuleTypePromQL:
+		if strings.TrimSpace(rule.Query) == "" {
+			return nil, fmt.Errorf("PromQL 规则必须填写查询语句")
+		}
+		if _, ok := compareDiagnosisValue(0, rule.Operator, rule.Threshold); !ok {
+			return nil, fmt.Errorf("不支持的比较运算符 %q，可选: >, >=, <, <=, ==, !=", rule.Operator)
+		}
+		if _, err := InjectPromQLSelector(rule.Query, `cluster="validate"`); err != nil {
+			return nil, fmt.Errorf("PromQL 查询无效: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("不支持的规则类型 %q", rule.Type)
+	}
+	return r, nil
+}
+
+func (r *customDiagnosisRule) Meta() DiagnosisRuleMeta {
+	return DiagnosisRuleMeta{
+		ID:          customDiagnosisRuleID(r.rule.ID),
+		Name:        r.rule.Name,
+		Category:    r.category(),
+		Description: r.rule.Description,
+	}
+}
+
+func (r *customDiagnosisRule) category() string {
+	if r.rule.Category == "" {
+		return customDiagnosisCategory
+	}
+	return r.rule.Category
+}
+
+func (r *customDiagnosisRule) Evaluate(ctx context.Context, env *DiagnosisEnv, params map[string]float64) (*DiagnosisResult, error) {
+	if r.rule.Type == models.DiagnosisRuleTypePromQL {
+		return r.evaluatePromQL(ctx, env)
+	}
+	return r.evaluateCEL(ctx, env)
+}
+
+// addRisk 记录一个命中项：扣分按全部命中数计算，风险项最多报告 MaxRisks 个
+func (r *customDiagnosisRule) addRisk(result *DiagnosisResult, risk models.RiskItem) {
+	maxRisks := r.rule.MaxRisks
+	if maxRisks <= 0 {
+		maxRisks = customDiagnosisMaxRisks
+	}
+	if len(result.Risks) < maxRisks {
+		risk.Category = r.category()
+		risk.Severity = r.rule.Severity
+		risk.Title = r.rule.Title
+		risk.Solution = r.rule.Solution
+		result.Risks = append(result.Risks, risk)
+	}
+	result.Deduction += r.rule.Deduction
+}
+
+// evaluateCEL 对资源的每个对象求值条件表达式
+func (r *customDiagnosisRule) evaluateCEL(ctx context.Context, env *DiagnosisEnv) (*DiagnosisResult, error) {
+	objects, err := customDiagnosisObjects(ctx, env, r.rule.Resource)
+	if err != nil {
+		return nil, err
+	}
+	result := &DiagnosisResult{}
+	for _, obj := range objects {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return nil, fmt.Errorf("转换对象 %s 失败: %w", obj.GetName(), err)
+		}
+		vars := map[string]interface{}{"object": content, "now": env.Now}
+		out, _, err := r.condition.ContextEval(ctx, vars)
+		if err != nil {
+			// 字段缺失等求值错误视为不匹配，代价超限或取消时中止
+			if ctx.Err() != nil || strings.Contains(err.Error(), "cost limit") {
+				return nil, fmt.Errorf("CEL 表达式求值失败: %w", err)
+			}
+			result.Evaluated++
+			continue
+		}
+		result.Evaluated++
+		if matched, ok := out.Value().(bool); !ok || !matched {
+			continue
+		}
+
+		ref := obj.GetName()
+		id := fmt.Sprintf("%s-%s", customDiagnosisRuleID(r.rule.ID), obj.GetName())
+		if obj.GetNamespace() != "" {
+			ref = obj.GetNamespace() + "/" + obj.GetName()
+			id = fmt.Sprintf("%s-%s-%s", customDiagnosisRuleID(r.rule.ID), obj.GetNamespace(), obj.GetName())
+		}
+		description := r.rule.Description
+		if r.message != nil {
+			if msg, _, err := r.message.ContextEval(ctx, vars); err == nil {
+				if s, ok := msg.Value().(string); ok && s != "" {
+					description = s
+				}
+			}
+		}
+		if description == "" {
+			description = fmt.Sprintf("%s 命中规则 %s", ref, r.rule.Name)
+		}
+		r.addRisk(result, models.RiskItem{
+			ID:          id,
+			Description: description,
+			Resource:    obj.GetName(),
+			Namespace:   obj.GetNamespace(),
+		})
+	}
+	return result, nil
+}
+
+// diagnosisResourceLabels 按优先级从序列标签中识别资源名
+var diagnosisResourceLabels = []string{"pod", "node", "deployment", "statefulset", "daemonset", "persistentvolumeclaim", "instance", "job"}
+
+var (
+	diagnosisValuePattern = regexp.MustCompile(`\{\{\s*\$value\s*\}\}`)
+	diagnosisLabelPattern = regexp.MustCompile(`\{\{\s*\$labels\.([a-zA-Z_][a-zA-Z0-9_]*)\s*\}\}`)
+)
+
+// evaluatePromQL 对查询结果的每条序列与阈值比较
+func (r *customDiagnosisRule) evaluatePromQL(ctx context.Context, env *DiagnosisEnv) (*DiagnosisResult, error) {
+	if env.Monitoring == nil {
+		return nil, errDiagnosisRuleSkipped
+	}
+	query, err := InjectPromQLSelector(r.rule.Query, env.prometheusSvc.buildClusterSelector(env.Monitoring.Labels, ""))
+	if err != nil {
+		return nil, fmt.Errorf("PromQL 查询无效: %w", err)
+	}
+	resp, err := env.prometheusSvc.QueryInstant(ctx, env.Monitoring, query, env.Now)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DiagnosisResult{}
+	for _, series := range resp.Data.Result {
+		val, ok := parseDiagnosisSample(series.Value)
+		if !ok {
+			continue
+		}
+		result.Evaluated++
+		if matched, _ := compareDiagnosisValue(val, r.rule.Operator, r.rule.Threshold); !matched {
+			continue
+		}
+
+		namespace, resource := series.Metric["namespace"], ""
+		for _, label := range diagnosisResourceLabels {
+			if v := series.Metric[label]; v != "" {
+				resource = v
+				break
+			}
+		}
+		id := customDiagnosisRuleID(r.rule.ID)
+		switch {
+		case resource != "":
+			id = strings.Join(nonEmptyStrings(id, namespace, resource), "-")
+		case len(series.Metric) > 0:
+			id = fmt.Sprintf("%s-%s", id, diagnosisLabelsHash(series.Metric))
+		}
+
+		value := strconv.FormatFloat(roundTo(val, 2), 'f', -1, 64)
+		description := r.rule.Description
+		if description == "" {
+			description = fmt.Sprintf("当前值 %s %s %s", value, r.rule.Operator, strconv.FormatFloat(r.rule.Threshold, 'f', -1, 64))
+		}
+		description = diagnosisValuePattern.ReplaceAllString(description, value)
+		description = diagnosisLabelPattern.ReplaceAllStringFunc(description, func(m string) string {
+			return series.Metric[diagnosisLabelPattern.FindStringSubmatch(m)[1]]
+		})
+		r.addRisk(result, models.RiskItem{
+			ID:          id,
+			Description: description,
+			Resource:    resource,
+			Namespace:   namespace,
+		})
+	}
+	return result, nil
+}
+
+// compareDiagnosisValue 按运算符比较，第二个返回值表示运算符是否有效
+func compareDiagnosisValue(val float64, operator string, threshold float64) (bool, bool) {
+	switch operator {
+	case ">":
+		return val > threshold, true
+	case ">=":
+		return val >= threshold, true
+	case "<":
+		return val < threshold, true
+	case "<=":
+		return val <= threshold, true
+	case "==":
+		return val == threshold, true
+	case "!=":
+		return val != threshold, true
+	}
+	return false, false
+}
+
+// diagnosisLabelsHash 标签集合的短摘要，用于没有资源标签的序列生成稳定的风险项 ID
+func diagnosisLabelsHash(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, k := range keys {
+		fmt.Fprintf(h, "%s=%s\n", k, labels[k])
+	}
+	return hex.EncodeToString(h.Sum(nil))[:8]
+}
+
+func nonEmptyStrings(values ...string) []string {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,363 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+	"github.com/clay-wangzhi/KubePolaris/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// DiagnosisRuleService 诊断规则管理：内置规则的集群级启停与阈值、自定义规则，并按集群配置执行诊断
+type DiagnosisRuleService struct {
+	db       *gorm.DB
+	registry *DiagnosisRuleRegistry
+}
+
+// NewDiagnosisRuleService 创建诊断规则服务
+func NewDiagnosisRuleService(db *gorm.DB, registry *DiagnosisRuleRegistry) *DiagnosisRuleService {
+	return &DiagnosisRuleService{db: db, registry: registry}
+}
+
+// resolvedDiagnosisRule 集群中生效的规则及其参数
+type resolvedDiagnosisRule struct {
+	rule   DiagnosisRule
+	params map[string]float64
+}
+
+// settings 集群的内置规则配置，按规则 ID 索引
+func (s *DiagnosisRuleService) settings(clusterID uint) (map[string]*models.DiagnosisRuleSetting, error) {
+	result := make(map[string]*models.DiagnosisRuleSetting)
+	if s.db == nil {
+		return result, nil
+	}
+	var settings []models.DiagnosisRuleSetting
+	if err := s.db.Where("cluster_id = ?", clusterID).Find(&settings).Error; err != nil {
+		return nil, fmt.Errorf("查询诊断规则配置失败: %w", err)
+	}
+	for i := range settings {
+		result[settings[i].RuleID] = &settings[i]
+	}
+	return result, nil
+}
+
+// diagnosisRuleParams 合并默认值与集群配置的参数
+func diagnosisRuleParams(meta DiagnosisRuleMeta, setting *models.DiagnosisRuleSetting) map[string]float64 {
+	params := make(map[string]float64, len(meta.Params))
+	for _, p := range meta.Params {
+		params[p.Key] = p.Default
+	}
+	if setting != nil && setting.Params != "" {
+		var overrides map[string]float64
+		if err := json.Unmarshal([]byte(setting.Params), &overrides); err == nil {
+			for k, v := range overrides {
+				if _, ok := params[k]; ok {
+					params[k] = v
+				}
+			}
+		}
+	}
+	return params
+}
+
+// List 列出集群的内置规则与自定义规则
+func (s *DiagnosisRuleService) List(clusterID uint) ([]models.DiagnosisRuleInfo, error) {
+	settings, err := s.settings(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	result := []models.DiagnosisRuleInfo{}
+	for _, rule := range s.registry.Rules() {
+		result = append(result, builtinDiagnosisRuleInfo(rule.Meta(), settings[rule.Meta().ID]))
+	}
+
+	custom, err := s.ListCustom(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range custom {
+		rule := &custom[i]
+		category := rule.Category
+		if category == "" {
+			category = customDiagnosisCategory
+		}
+		result = append(result, models.DiagnosisRuleInfo{
+			ID:          customDiagnosisRuleID(rule.ID),
+			Type:        rule.Type,
+			Name:        rule.Name,
+			Category:    category,
+			Description: rule.Description,
+			Enabled:     rule.Enabled,
+			CustomRule:  rule,
+		})
+	}
+	return result, nil
+}
+
+func builtinDiagnosisRuleInfo(meta DiagnosisRuleMeta, setting *models.DiagnosisRuleSetting) models.DiagnosisRuleInfo {
+	info := models.DiagnosisRuleInfo{
+		ID:          meta.ID,
+		Type:        models.DiagnosisRuleTypeBuiltin,
+		Name:        meta.Name,
+		Category:    meta.Category,
+		Description: meta.Description,
+		Enabled:     setting == nil || setting.Enabled,
+		Params:      meta.Params,
+	}
+	if len(meta.Params) > 0 {
+		info.Values = diagnosisRuleParams(meta, setting)
+	}
+	return info
+}
+
+// UpdateSetting 更新集群中内置规则的启停与阈值，未提供参数时恢复默认阈值
+func (s *DiagnosisRuleService) UpdateSetting(clusterID uint, ruleID string, req *models.DiagnosisRuleSettingRequest, username string) (*models.DiagnosisRuleInfo, error) {
+	rule, ok := s.registry.Get(ruleID)
+	if !ok {
+		return nil, fmt.Errorf("内置诊断规则不存在: %s", ruleID)
+	}
+	meta := rule.Meta()
+
+	overrides := make(map[string]float64)
+	for key, value := range req.Params {
+		var param *models.DiagnosisRuleParam
+		for i := range meta.Params {
+			if meta.Params[i].Key == key {
+				param = &meta.Params[i]
+			}
+		}
+		if param == nil {
+			return nil, fmt.Errorf("规则 %s 不支持参数 %s", ruleID, key)
+		}
+		if value < 0 {
+			return nil, fmt.Errorf("参数 %s 不能为负数", param.Name)
+		}
+		if value != param.Default {
+			overrides[key] = value
+		}
+	}
+	raw, err := json.Marshal(overrides)
+	if err != nil {
+		return nil, err
+	}
+
+	var setting models.DiagnosisRuleSetting
+	err = s.db.Where("cluster_id = ? AND rule_id = ?", clusterID, ruleID).First(&setting).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		setting = models.DiagnosisRuleSetting{ClusterID: clusterID, RuleID: ruleID, Enabled: true}
+	case err != nil:
+		return nil, fmt.Errorf("查询诊断规则配置失败: %w", err)
+	}
+	if req.Enabled != nil {
+		setting.Enabled = *req.Enabled
+	}
+	setting.Params = string(raw)
+	setting.UpdatedBy = username
+	if err := s.db.Save(&setting).Error; err != nil {
+		return nil, fmt.Errorf("保存诊断规则配置失败: %w", err)
+	}
+	info := builtinDiagnosisRuleInfo(meta, &setting)
+	return &info, nil
+}
+
+// ListCustom 列出集群的自定义规则
+func (s *DiagnosisRuleService) ListCustom(clusterID uint) ([]models.CustomDiagnosisRule, error) {
+	rules := []models.CustomDiagnosisRule{}
+	if s.db == nil {
+		return rules, nil
+	}
+	if err := s.db.Where("cluster_id = ?", clusterID).Order("id").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("查询自定义诊断规则失败: %w", err)
+	}
+	return rules, nil
+}
+
+// GetCustom 获取自定义规则
+func (s *DiagnosisRuleService) GetCustom(clusterID, id uint) (*models.CustomDiagnosisRule, error) {
+	var rule models.CustomDiagnosisRule
+	if err := s.db.Where("cluster_id = ?", clusterID).First(&rule, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("自定义诊断规则不存在: %d", id)
+		}
+		return nil, fmt.Errorf("查询自定义诊断规则失败: %w", err)
+	}
+	return &rule, nil
+}
+
+// customDiagnosisRuleFromRequest 根据请求填充自定义规则
+func customDiagnosisRuleFromRequest(rule *models.CustomDiagnosisRule, req *models.CustomDiagnosisRuleRequest) {
+	rule.Name = req.Name
+	rule.Type = req.Type
+	rule.Enabled = req.Enabled
+	rule.Category = req.Category
+	if rule.Category == "" {
+		rule.Category = customDiagnosisCategory
+	}
+	rule.Severity = req.Severity
+	rule.Title = req.Title
+	rule.Description = req.Description
+	rule.Solution = req.Solution
+	rule.Deduction = req.Deduction
+	rule.MaxRisks = req.MaxRisks
+	rule.Resource, rule.Expression, rule.MessageExpression = "", "", ""
+	rule.Query, rule.Operator, rule.Threshold = "", "", 0
+	if req.Type == models.DiagnosisRuleTypeCEL {
+		rule.Resource = req.Resource
+		rule.Expression = req.Expression
+		rule.MessageExpression = req.MessageExpression
+	} else {
+		rule.Query = req.Query
+		rule.Operator = req.Operator
+		rule.Threshold = req.Threshold
+	}
+}
+
+// SaveCustom 校验并保存自定义规则，existing 为 nil 时创建
+func (s *DiagnosisRuleService) SaveCustom(clusterID uint, existing *models.CustomDiagnosisRule, req *models.CustomDiagnosisRuleRequest, userID uint, username string) (*models.CustomDiagnosisRule, error) {
+	rule := existing
+	if rule == nil {
+		rule = &models.CustomDiagnosisRule{ClusterID: clusterID, CreatedBy: userID, CreatedByName: username}
+	}
+	customDiagnosisRuleFromRequest(rule, req)
+	if _, err := newCustomDiagnosisRule(rule); err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := s.db.Model(&models.CustomDiagnosisRule{}).Where("cluster_id = ? AND name = ? AND id <> ?", clusterID, rule.Name, rule.ID).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("查询自定义诊断规则失败: %w", err)
+	}
+	if count > 0 {
+		return nil, fmt.Errorf("自定义诊断规则名称已存在: %s", rule.Name)
+	}
+	if err := s.db.Save(rule).Error; err != nil {
+		return nil, fmt.Errorf("保存自定义诊断规则失败: %w", err)
+	}
+	return rule, nil
+}
+
+// DeleteCustom 删除自定义规则
+func (s *DiagnosisRuleService) DeleteCustom(rule *models.CustomDiagnosisRule) error {
+	if err := s.db.Delete(rule).Error; err != nil {
+		return fmt.Errorf("删除自定义诊断规则失败: %w", err)
+	}
+	return nil
+}
+
+// TestCustom 在集群上试运行自定义规则（不保存），返回命中的风险项
+func (s *DiagnosisRuleService) TestCustom(ctx context.Context, env *DiagnosisEnv, req *models.CustomDiagnosisRuleRequest) (*models.CustomDiagnosisRuleTestResponse, error) {
+	rule := &models.CustomDiagnosisRule{ClusterID: env.ClusterID}
+	customDiagnosisRuleFromRequest(rule, req)
+	r, err := newCustomDiagnosisRule(rule)
+	if err != nil {
+		return nil, err
+	}
+	result, err := r.Evaluate(ctx, env, nil)
+	if errors.Is(err, errDiagnosisRuleSkipped) {
+		return nil, fmt.Errorf("集群未配置监控，无法执行 PromQL 规则")
+	}
+	if err != nil {
+		return nil, err
+	}
+	risks := result.Risks
+	if risks == nil {
+		risks = []models.RiskItem{}
+	}
+	return &models.CustomDiagnosisRuleTestResponse{Risks: risks, Deduction: result.Deduction, Evaluated: result.Evaluated}, nil
+}
+
+// resolve 集群中启用的内置规则（含合并后的参数）与自定义规则，配置读取失败时使用全部内置规则的默认配置
+func (s *DiagnosisRuleService) resolve(clusterID uint) []resolvedDiagnosisRule {
+	settings, err := s.settings(clusterID)
+	if err != nil {
+		logger.Warn("读取诊断规则配置失败，使用默认配置", "clusterID", clusterID, "error", err)
+		settings = map[string]*models.DiagnosisRuleSetting{}
+	}
+	rules := []resolvedDiagnosisRule{}
+	for _, rule := range s.registry.Rules() {
+		meta := rule.Meta()
+		setting := settings[meta.ID]
+		if setting != nil && !setting.Enabled {
+			continue
+		}
+		rules = append(rules, resolvedDiagnosisRule{rule: rule, params: diagnosisRuleParams(meta, setting)})
+	}
+
+	custom, err := s.ListCustom(clusterID)
+	if err != nil {
+		logger.Warn("读取自定义诊断规则失败", "clusterID", clusterID, "error", err)
+	}
+	for i := range custom {
+		if !custom[i].Enabled {
+			continue
+		}
+		r, err := newCustomDiagnosisRule(&custom[i])
+		if err != nil {
+			logger.Warn("自定义诊断规则无效，已跳过", "rule", custom[i].Name, "error", err)
+			continue
+		}
+		rules = append(rules, resolvedDiagnosisRule{rule: r})
+	}
+	return rules
+}
+
+// Run 并发执行集群中生效的规则，返回风险项（按规则顺序）与各分类评分。
+// 分类评分为 100 减去规则扣分；规则执行失败时按 UnavailableScore 限制分类评分；分类下的规则全部不适用时不计入评分
+func (s *DiagnosisRuleService) Run(ctx context.Context, env *DiagnosisEnv) ([]models.RiskItem, map[string]int) {
+	rules := s.resolve(env.ClusterID)
+	results := make([]*DiagnosisResult, len(rules))
+	errs := make([]error, len(rules))
+
+	var wg sync.WaitGroup
+	for i := range rules {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					errs[i] = fmt.Errorf("诊断规则异常: %v", r)
+				}
+			}()
+			results[i], errs[i] = rules[i].rule.Evaluate(ctx, env, rules[i].params)
+		}(i)
+	}
+	wg.Wait()
+
+	risks := []models.RiskItem{}
+	scores := make(map[string]int)
+	caps := make(map[string]int)
+	for i, r := range rules {
+		meta := r.rule.Meta()
+		if errors.Is(errs[i], errDiagnosisRuleSkipped) {
+			continue
+		}
+		if _, ok := scores[meta.Category]; !ok {
+			scores[meta.Category] = 100
+		}
+		if errs[i] != nil {
+			logger.Warn("诊断规则执行失败", "rule", meta.ID, "clusterID", env.ClusterID, "error", errs[i])
+			if limit, ok := caps[meta.Category]; meta.UnavailableScore > 0 && (!ok || meta.UnavailableScore < limit) {
+				caps[meta.Category] = meta.UnavailableScore
+			}
+			continue
+		}
+		risks = append(risks, results[i].Risks...)
+		scores[meta.Category] -= results[i].Deduction
+	}
+	for category, score := range scores {
+		if limit, ok := caps[category]; ok && score > limit {
+			score = limit
+		}
+		if score < 0 {
+			score = 0
+		}
+		scores[category] = score
+	}
+	return risks, scores
+}
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// DiagnosisRuleServiceTestSuite 定义诊断规则引擎测试套件
+type DiagnosisRuleServiceTestSuite struct {
+	suite.Suite
+	env *DiagnosisEnv
+}
+
+func int32Ptr(v int32) *int32 { return &v }
+
+func (s *DiagnosisRuleServiceTestSuite) SetupTest() {
+	now := time.Unix(1710000000, 0)
+	client := fake.NewSimpleClientset(
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "n1"},
+			Status:     corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionFalse}}},
+		},
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "n2"},
+			Spec:       corev1.NodeSpec{Unschedulable: true},
+			Status:     corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "default"},
+			Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+				Name:         "web",
+				RestartCount: 3,
+				State:        corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+			}}},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "job-1", Namespace: "batch", CreationTimestamp: metav1.NewTime(now.Add(-10 * time.Minute))},
+			Status:     corev1.PodStatus{Phase: corev1.PodPending},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default", Labels: map[string]string{"team": "core"}},
+			Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(1)},
+			Status:     appsv1.DeploymentStatus{Replicas: 1, ReadyReplicas: 1},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "worker", Namespace: "default"},
+			Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(3)},
+			Status:     appsv1.DeploymentStatus{Replicas: 3, ReadyReplicas: 3},
+		},
+	)
+	s.env = &DiagnosisEnv{ClusterID: 1, Client: client, Now: now, prometheusSvc: NewPrometheusService()}
+}
+
+func riskIDs(risks []models.RiskItem) []string {
+	ids := make([]string, 0, len(risks))
+	for _, r := range risks {
+		ids = append(ids, r.ID)
+	}
+	return ids
+}
+
+// TestRegistry 测试规则注册与 ID 校验
+func (s *DiagnosisRuleServiceTestSuite) TestRegistry() {
+	rule, ok := DefaultDiagnosisRules.Get("pod-crashloop")
+	s.Require().True(ok)
+	assert.Equal(s.T(), "workload", rule.Meta().Category)
+
+	registry := NewDiagnosisRuleRegistry()
+	assert.Error(s.T(), registry.Register(&builtinDiagnosisRule{meta: DiagnosisRuleMeta{ID: "x"}}), "必须设置分类")
+	s.Require().NoError(registry.Register(rule))
+	assert.Error(s.T(), registry.Register(rule), "ID 不能重复")
+}
+
+// TestBuiltinParams 测试内置规则使用集群配置的阈值
+func (s *DiagnosisRuleServiceTestSuite) TestBuiltinParams() {
+	rule, _ := DefaultDiagnosisRules.Get("pod-crashloop")
+	params := diagnosisRuleParams(rule.Meta(), nil)
+	assert.Equal(s.T(), map[string]float64{"restart_threshold": 5, "max_reported": 5}, params)
+	result, err := rule.Evaluate(context.Background(), s.env, params)
+	s.Require().NoError(err)
+	assert.Empty(s.T(), result.Risks, "默认阈值下重启 3 次不报告")
+
+	params = diagnosisRuleParams(rule.Meta(), &models.DiagnosisRuleSetting{Params: `{"restart_threshold":2,"unknown":1}`})
+	assert.Equal(s.T(), map[string]float64{"restart_threshold": 2, "max_reported": 5}, params)
+	result, err = rule.Evaluate(context.Background(), s.env, params)
+	s.Require().NoError(err)
+	assert.Equal(s.T(), []string{"pod-crashloop-default-web-1"}, riskIDs(result.Risks))
+	assert.Equal(s.T(), 5, result.Deduction)
+
+	risk, deduct := clusterUsageRisk("cpu", 75, 70, 95)
+	s.Require().NotNil(risk)
+	assert.Equal(s.T(), "cluster-cpu-warning", risk.ID)
+	assert.Equal(s.T(), 10, deduct)
+}
+
+// TestRun 测试默认规则的诊断结果与分类评分
+func (s *DiagnosisRuleServiceTestSuite) TestRun() {
+	svc := NewDiagnosisRuleService(nil, DefaultDiagnosisRules)
+	risks, scores := svc.Run(context.Background(), s.env)
+	assert.Equal(s.T(), []string{"node-not-ready-n1", "node-unschedulable-n2", "pod-pending-batch-job-1", "best-practice-deployment-default-worker"}, riskIDs(risks))
+	assert.Equal(s.T(), map[string]int{"node": 75, "workload": 97, "best_practice": 95, "resource": 100, "storage": 100, "control_plane": 100}, scores,
+		"未配置 Prometheus 时容量预测与依赖指标的控制面规则不参与评分")
+}
+
+type stubDiagnosisRule struct {
+	meta   DiagnosisRuleMeta
+	result *DiagnosisResult
+	err    error
+}
+
+func (r *stubDiagnosisRule) Meta() DiagnosisRuleMeta { return r.meta }
+
+func (r *stubDiagnosisRule) Evaluate(context.Context, *DiagnosisEnv, map[string]float64) (*DiagnosisResult, error) {
+	return r.result, r.err
+}
+
+// TestRunScoring 测试扣分、执行失败与不适用规则对分类评分的影响
+func (s *DiagnosisRuleServiceTestSuite) TestRunScoring() {
+	registry := NewDiagnosisRuleRegistry(
+		&stubDiagnosisRule{meta: DiagnosisRuleMeta{ID: "a", Category: "node"}, result: &DiagnosisResult{Risks: []models.RiskItem{{ID: "a-1"}}, Deduction: 30}},
+		&stubDiagnosisRule{meta: DiagnosisRuleMeta{ID: "b", Category: "node", UnavailableScore: 50}, err: errors.New("list failed")},
+		&stubDiagnosisRule{meta: DiagnosisRuleMeta{ID: "c", Category: "workload"}, result: &DiagnosisResult{Deduction: 150}},
+		&stubDiagnosisRule{meta: DiagnosisRuleMeta{ID: "d", Category: "capacity"}, err: errDiagnosisRuleSkipped},
+		&stubDiagnosisRule{meta: DiagnosisRuleMeta{ID: "e", Category: "storage"}, result: &DiagnosisResult{Risks: []models.RiskItem{{ID: "e-1"}}, Deduction: 5}},
+	)
+	risks, scores := NewDiagnosisRuleService(nil, registry).Run(context.Background(), s.env)
+	assert.Equal(s.T(), []string{"a-1", "e-1"}, riskIDs(risks))
+	assert.Equal(s.T(), map[string]int{"node": 50, "workload": 0, "storage": 95}, scores)
+}
+
+// TestCELRule 测试 CEL 自定义规则
+func (s *DiagnosisRuleServiceTestSuite) TestCELRule() {
+	rule := &models.CustomDiagnosisRule{
+		ID:                7,
+		Name:              "副本检查",
+		Type:              models.DiagnosisRuleTypeCEL,
+		Severity:          "warning",
+		Title:             "单副本 Deployment",
+		Resource:          "deployments",
+		Expression:        `object.spec.replicas < 2 && !has(object.metadata.annotations)`,
+		MessageExpression: `object.metadata.name + " 仅有 " + string(object.spec.replicas) + " 个副本，团队 " + object.metadata.labels["team"]`,
+		Deduction:         10,
+	}
+	r, err := newCustomDiagnosisRule(rule)
+	s.Require().NoError(err)
+	assert.Equal(s.T(), "custom", r.Meta().Category)
+	result, err := r.Evaluate(context.Background(), s.env, nil)
+	s.Require().NoError(err)
+	assert.Equal(s.T(), 2, result.Evaluated)
+	s.Require().Len(result.Risks, 1)
+	assert.Equal(s.T(), models.RiskItem{
+		ID:          "custom-7-default-api",
+		Category:    "custom",
+		Severity:    "warning",
+		Title:       "单副本 Deployment",
+		Description: "api 仅有 1 个副本，团队 core",
+		Resource:    "api",
+		Namespace:   "default",
+	}, result.Risks[0])
+	assert.Equal(s.T(), 10, result.Deduction)
+
+	// now 为诊断时间
+	rule.Resource, rule.Expression, rule.MessageExpression = "pods", `now - timestamp(object.metadata.creationTimestamp) > duration("5m")`, ""
+	r, err = newCustomDiagnosisRule(rule)
+	s.Require().NoError(err)
+	result, err = r.Evaluate(context.Background(), s.env, nil)
+	s.Require().NoError(err)
+	assert.Equal(s.T(), []string{"custom-7-batch-job-1"}, riskIDs(result.Risks), "没有创建时间的对象求值出错视为不匹配")
+	assert.Equal(s.T(), "batch/job-1 命中规则 副本检查", result.Risks[0].Description)
+
+	for _, invalid := range []*models.CustomDiagnosisRule{
+		{Type: models.DiagnosisRuleTypeCEL, Resource: "secrets", Expression: "true"},
+		{Type: models.DiagnosisRuleTypeCEL, Resource: "pods", Expression: "object.metadata.name +"},
+		{Type: models.DiagnosisRuleTypeCEL, Resource: "pods", Expression: `"text"`},
+		{Type: models.DiagnosisRuleTypeCEL, Resource: "pods", Expression: "true", MessageExpression: "1 + 1"},
+	} {
+		_, err := newCustomDiagnosisRule(invalid)
+		assert.Error(s.T(), err, invalid.Expression)
+	}
+}
+
+// TestPromQLRule 测试 PromQL 自定义规则
+func (s *DiagnosisRuleServiceTestSuite) TestPromQLRule() {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Get("query")
+		fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[
+			{"metric":{"namespace":"default","persistentvolumeclaim":"data"},"value":[1710000000,"92.456"]},
+			{"metric":{"namespace":"default","persistentvolumeclaim":"logs"},"value":[1710000000,"40"]},
+			{"metric":{"device":"sda"},"value":[1710000000,"95"]}
+		]}}`)
+	}))
+	defer server.Close()
+
+	rule := &models.CustomDiagnosisRule{
+		ID:          3,
+		Type:        models.DiagnosisRuleTypePromQL,
+		Category:    "storage",
+		Severity:    "critical",
+		Title:       "磁盘使用率过高",
+		Description: "{{ $labels.persistentvolumeclaim }} 使用率 {{$value}}%",
+		Query:       `kubelet_volume_stats_used_bytes / kubelet_volume_stats_capacity_bytes * 100`,
+		Operator:    ">",
+		Threshold:   90,
+		Deduction:   20,
+		MaxRisks:    1,
+	}
+	r, err := newCustomDiagnosisRule(rule)
+	s.Require().NoError(err)
+
+	_, err = r.Evaluate(context.Background(), s.env, nil)
+	assert.ErrorIs(s.T(), err, errDiagnosisRuleSkipped, "未配置 Prometheus 时不适用")
+
+	s.env.Monitoring = &models.MonitoringConfig{Type: "prometheus", Endpoint: server.URL, Labels: map[string]string{"cluster": "prod"}}
+	result, err := r.Evaluate(context.Background(), s.env, nil)
+	s.Require().NoError(err)
+	assert.Contains(s.T(), query, `kubelet_volume_stats_used_bytes{cluster="prod"}`)
+	assert.Equal(s.T(), 3, result.Evaluated)
+	assert.Equal(s.T(), 40, result.Deduction, "扣分按全部命中的序列计算")
+	s.Require().Len(result.Risks, 1, "最多报告 MaxRisks 个风险项")
+	assert.Equal(s.T(), "custom-3-default-data", result.Risks[0].ID)
+	assert.Equal(s.T(), "storage", result.Risks[0].Category)
+	assert.Equal(s.T(), "data 使用率 92.46%", result.Risks[0].Description)
+
+	rule.MaxRisks = 0
+	result, err = r.Evaluate(context.Background(), s.env, nil)
+	s.Require().NoError(err)
+	s.Require().Len(result.Risks, 2)
+	assert.Regexp(s.T(), `^custom-3-[0-9a-f]{8}$`, result.Risks[1].ID, "没有资源标签时按标签摘要生成 ID")
+
+	_, err = newCustomDiagnosisRule(&models.CustomDiagnosisRule{Type: models.DiagnosisRuleTypePromQL, Query: "up", Operator: "=>"})
+	assert.Error(s.T(), err)
+}
+
+// TestDiagnosisRuleServiceTestSuite 运行诊断规则引擎测试套件
+func TestDiagnosisRuleServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(DiagnosisRuleServiceTestSuite))
+}
@@ -0,0 +1,681 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+	"github.com/clay-wangzhi/KubePolaris/pkg/logger"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// builtinDiagnosisRule 以函数实现的内置诊断规则
+type builtinDiagnosisRule struct {
+	meta DiagnosisRuleMeta
+	eval func(ctx context.Context, env *DiagnosisEnv, p map[string]float64) (*DiagnosisResult, error)
+}
+
+func (r *builtinDiagnosisRule) Meta() DiagnosisRuleMeta { return r.meta }
+
+func (r *builtinDiagnosisRule) Evaluate(ctx context.Context, env *DiagnosisEnv, params map[string]float64) (*DiagnosisResult, error) {
+	return r.eval(ctx, env, params)
+}
+
+// maxReportedParam 同类风险最多报告的数量
+var maxReportedParam = models.DiagnosisRuleParam{Key: "max_reported", Name: "最多报告数量", Default: 5, Unit: "个"}
+
+// builtinDiagnosisRules 内置诊断规则，按节点、工作负载、资源、存储、控制面、容量分类
+func builtinDiagnosisRules() []DiagnosisRule {
+	return []DiagnosisRule{
+		// ========== 节点 ==========
+		&builtinDiagnosisRule{
+			meta: DiagnosisRuleMeta{
+				ID: "node-not-ready", Name: "节点未就绪", Category: "node", UnavailableScore: 50,
+				Description: "节点 Ready 状态不为 True 时报告严重风险",
+			},
+			eval: func(ctx context.Context, env *DiagnosisEnv, p map[string]float64) (*DiagnosisResult, error) {
+				nodes, err := env.Nodes(ctx)
+				if err != nil {
+					return nil, err
+				}
+				result := &DiagnosisResult{}
+				for _, node := range nodes {
+					for _, condition := range node.Status.Conditions {
+						if condition.Type == corev1.NodeReady && condition.Status != corev1.ConditionTrue {
+							result.Risks = append(result.Risks, models.RiskItem{
+								ID:          fmt.Sprintf("node-not-ready-%s", node.Name),
+								Category:    "node",
+								Severity:    "critical",
+								Title:       "节点未就绪",
+								Description: fmt.Sprintf("节点 %s 处于未就绪状态", node.Name),
+								Resource:    node.Name,
+								Solution:    "检查节点 kubelet 服务状态，查看节点系统资源使用情况",
+							})
+							result.Deduction += 20
+						}
+					}
+				}
+				return result, nil
+			},
+		},
+		&builtinDiagnosisRule{
+			meta: DiagnosisRuleMeta{
+				ID: "node-pressure", Name: "节点资源压力", Category: "node", UnavailableScore: 50,
+				Description: "节点存在内存、磁盘或 PID 压力时报告警告",
+			},
+			eval: func(ctx context.Context, env *DiagnosisEnv, p map[string]float64) (*DiagnosisResult, error) {
+				nodes, err := env.Nodes(ctx)
+				if err != nil {
+					return nil, err
+				}
+				pressures := []struct {
+					condition corev1.NodeConditionType
+					id, title string
+					solution  string
+				}{
+					{corev1.NodeMemoryPressure, "memory", "内存", "考虑扩容节点内存或迁移部分工作负载"},
+					{corev1.NodeDiskPressure, "disk", "磁盘", "清理不需要的镜像和日志，或扩展磁盘容量"},
+					{corev1.NodePIDPressure, "pid", "PID", "检查是否有异常进程，考虑调整 max-pods 参数"},
+				}
+				result := &DiagnosisResult{}
+				for _, node := range nodes {
+					for _, condition := range node.Status.Conditions {
+						for _, pressure := range pressures {
+							if condition.Type != pressure.condition || condition.Status != corev1.ConditionTrue {
+								continue
+							}
+							result.Risks = append(result.Risks, models.RiskItem{
+								ID:          fmt.Sprintf("node-%s-pressure-%s", pressure.id, node.Name),
+								Category:    "node",
+								Severity:    "warning",
+								Title:       fmt.Sprintf("节点%s压力", pressure.title),
+								Description: fmt.Sprintf("节点 %s 存在%s压力", node.Name, pressure.title),
+								Resource:    node.Name,
+								Solution:    pressure.solution,
+							})
+							result.Deduction += 10
+						}
+					}
+				}
+				return result, nil
+			},
+		},
+		&builtinDiagnosisRule{
+			meta: DiagnosisRuleMeta{
+				ID: "node-unschedulable", Name: "节点不可调度", Category: "node", UnavailableScore: 50,
+				Description: "节点被标记为不可调度（cordon）时提示",
+			},
+			eval: func(ctx context.Context, env *DiagnosisEnv, p map[string]float64) (*DiagnosisResult, error) {
+				nodes, err := env.Nodes(ctx)
+				if err != nil {
+					return nil, err
+				}
+				result := &DiagnosisResult{}
+				for _, node := range nodes {
+					if node.Spec.Unschedulable {
+						result.Risks = append(result.Risks, models.RiskItem{
+							ID:          fmt.Sprintf("node-unschedulable-%s", node.Name),
+							Category:    "node",
+							Severity:    "info",
+							Title:       "节点不可调度",
+							Description: fmt.Sprintf("节点 %s 已被标记为不可调度", node.Name),
+							Resource:    node.Name,
+							Solution:    "如果节点维护已完成，请执行 uncordon 操作",
+						})
+						result.Deduction += 5
+					}
+				}
+				return result, nil
+			},
+		},
+
+		// ========== 工作负载 ==========
+		&builtinDiagnosisRule{
+			meta: DiagnosisRuleMeta{
+				ID: "deployment-not-ready", Name: "Deployment 副本未就绪", Category: "workload",
+				Description: "就绪副本数与副本数不一致时报告，没有任何就绪副本时为严重风险",
+			},
+			eval: func(ctx context.Context, env *DiagnosisEnv, p map[string]float64) (*DiagnosisResult, error) {
+				deployments, err := env.Deployments(ctx)
+				if err != nil {
+					return nil, err
+				}
+				result := &DiagnosisResult{}
+				for _, dep := range deployments {
+					if dep.Status.Replicas == dep.Status.ReadyReplicas {
+						continue
+					}
+					severity, deduction := workloadReadinessSeverity(dep.Status.ReadyReplicas, dep.Spec.Replicas)
+					result.Deduction += deduction
+					result.Risks = append(result.Risks, models.RiskItem{
+						ID:          fmt.Sprintf("deployment-not-ready-%s-%s", dep.Namespace, dep.Name),
+						Category:    "workload",
+						Severity:    severity,
+						Title:       "Deployment 副本未就绪",
+						Description: fmt.Sprintf("Deployment %s/%s: %d/%d 副本就绪", dep.Namespace, dep.Name, dep.Status.ReadyReplicas, dep.Status.Replicas),
+						Resource:    dep.Name,
+						Namespace:   dep.Namespace,
+						Solution:    "检查 Pod 事件和日志，确认容器启动失败原因",
+					})
+				}
+				return result, nil
+			},
+		},
+		&builtinDiagnosisRule{
+			meta: DiagnosisRuleMeta{
+				ID: "statefulset-not-ready", Name: "StatefulSet 副本未就绪", Category: "workload",
+				Description: "就绪副本数与副本数不一致时报告，没有任何就绪副本时为严重风险",
+			},
+			eval: func(ctx context.Context, env *DiagnosisEnv, p map[string]float64) (*DiagnosisResult, error) {
+				statefulSets, err := env.StatefulSets(ctx)
+				if err != nil {
+					return nil, err
+				}
+				result := &DiagnosisResult{}
+				for _, sts := range statefulSets {
+					if sts.Status.Replicas == sts.Status.ReadyReplicas {
+						continue
+					}
+					severity, deduction := workloadReadinessSeverity(sts.Status.ReadyReplicas, sts.Spec.Replicas)
+					result.Deduction += deduction
+					result.Risks = append(result.Risks, models.RiskItem{
+						ID:          fmt.Sprintf("statefulset-not-ready-%s-%s", sts.Namespace, sts.Name),
+						Category:    "workload",
+						Severity:    severity,
+						Title:       "StatefulSet 副本未就绪",
+						Description: fmt.Sprintf("StatefulSet %s/%s: %d/%d 副本就绪", sts.Namespace, sts.Name, sts.Status.ReadyReplicas, sts.Status.Replicas),
+						Resource:    sts.Name,
+						Namespace:   sts.Namespace,
+						Solution:    "检查 Pod 事件和日志，确认容器启动失败原因",
+					})
+				}
+				return result, nil
+			},
+		},
+		&builtinDiagnosisRule{
+			meta: DiagnosisRuleMeta{
+				ID: "daemonset-unavailable", Name: "DaemonSet 存在不可用副本", Category: "workload",
+				Description: "DaemonSet 有节点上的副本不可用时报告警告",
+			},
+			eval: func(ctx context.Context, env *DiagnosisEnv, p map[string]float64) (*DiagnosisResult, error) {
+				daemonSets, err := env.DaemonSets(ctx)
+				if err != nil {
+					return nil, err
+				}
+				result := &DiagnosisResult{}
+				for _, ds := range daemonSets {
+					if ds.Status.NumberUnavailable > 0 {
+						result.Risks = append(result.Risks, models.RiskItem{
+							ID:          fmt.Sprintf("daemonset-unavailable-%s-%s", ds.Namespace, ds.Name),
+							Category:    "workload",
+							Severity:    "warning",
+							Title:       "DaemonSet 存在不可用副本",
+							Description: fmt.Sprintf("DaemonSet %s/%s: %d 个节点上的副本不可用", ds.Namespace, ds.Name, ds.Status.NumberUnavailable),
+							Resource:    ds.Name,
+							Namespace:   ds.Namespace,
+							Solution:    "检查相关节点和 Pod 状态",
+						})
+						result.Deduction += 5
+					}
+				}
+				return result, nil
+			},
+		},
+		&builtinDiagnosisRule{
+			meta: DiagnosisRuleMeta{
+				ID: "pod-crashloop", Name: "Pod 持续崩溃重启", Category: "workload",
+				Description: "容器处于 CrashLoopBackOff 且重启次数超过阈值时报告严重风险",
+				Params: []models.DiagnosisRuleParam{
+					{Key: "restart_threshold", Name: "重启次数阈值", Default: 5, Unit: "次"},
+					maxReportedParam,
+				},
+			},
+			eval: func(ctx context.Context, env *DiagnosisEnv, p map[string]float64) (*DiagnosisResult, error) {
+				pods, err := env.Pods(ctx)
+				if err != nil {
+					return nil, err
+				}
+				result := &DiagnosisResult{}
+				count := 0
+				for _, pod := range pods {
+					for _, cs := range pod.Status.ContainerStatuses {
+						if float64(cs.RestartCount) <= p["restart_threshold"] || cs.State.Waiting == nil || cs.State.Waiting.Reason != "CrashLoopBackOff" {
+							continue
+						}
+						count++
+						if count <= int(p["max_reported"]) {
+							result.Risks = append(result.Risks, models.RiskItem{
+								ID:          fmt.Sprintf("pod-crashloop-%s-%s", pod.Namespace, pod.Name),
+								Category:    "workload",
+								Severity:    "critical",
+								Title:       "Pod 持续崩溃重启",
+								Description: fmt.Sprintf("Pod %s/%s 容器 %s 已重启 %d 次", pod.Namespace, pod.Name, cs.Name, cs.RestartCount),
+								Resource:    pod.Name,
+								Namespace:   pod.Namespace,
+								Solution:    "检查容器日志，排查应用启动失败原因",
+							})
+						}
+					}
+				}
+				result.Deduction = min(count*5, 30)
+				return result, nil
+			},
+		},
+		&builtinDiagnosisRule{
+			meta: DiagnosisRuleMeta{
+				ID: "pod-pending", Name: "Pod 长时间 Pending", Category: "workload",
+				Description: "Pod 创建后超过阈值时间仍处于 Pending 状态时报告警告",
+				Params: []models.DiagnosisRuleParam{
+					{Key: "pending_minutes", Name: "Pending 时长阈值", Default: 5, Unit: "分钟"},
+					maxReportedParam,
+				},
+			},
+			eval: func(ctx context.Context, env *DiagnosisEnv, p map[string]float64) (*DiagnosisResult, error) {
+				pods, err := env.Pods(ctx)
+				if err != nil {
+					return nil, err
+				}
+				result := &DiagnosisResult{}
+				threshold := time.Duration(p["pending_minutes"] * float64(time.Minute))
+				count := 0
+				for _, pod := range pods {
+					if pod.Status.Phase != corev1.PodPending {
+						continue
+					}
+					pendingDuration := env.Now.Sub(pod.CreationTimestamp.Time)
+					if pendingDuration <= threshold {
+						continue
+					}
+					count++
+					if count <= int(p["max_reported"]) {
+						result.Risks = append(result.Risks, models.RiskItem{
+							ID:          fmt.Sprintf("pod-pending-%s-%s", pod.Namespace, pod.Name),
+							Category:    "workload",
+							Severity:    "warning",
+							Title:       "Pod 长时间处于 Pending 状态",
+							Description: fmt.Sprintf("Pod %s/%s 已 Pending %.0f 分钟", pod.Namespace, pod.Name, pendingDuration.Minutes()),
+							Resource:    pod.Name,
+							Namespace:   pod.Namespace,
+							Solution:    "检查是否资源不足或调度约束过严",
+						})
+					}
+				}
+				result.Deduction = min(count*3, 15)
+				return result, nil
+			},
+		},
+
+		// ========== 最佳实践 ==========
+		&builtinDiagnosisRule{
+			meta: DiagnosisRuleMeta{
+				ID: "workload-best-practice", Name: "工作负载最佳实践", Category: "best_practice",
+				Description: "检查探针、资源 Request/Limit、镜像标签、特权与 root 用户、hostPath、PDB 与生产环境单副本，分类评分为工作负载评分的平均值（不含系统命名空间）",
+				Params:      []models.DiagnosisRuleParam{{Key: "max_reported", Name: "最多报告数量", Default: 10, Unit: "个"}},
+			},
+			eval: func(ctx context.Context, env *DiagnosisEnv, p map[string]float64) (*DiagnosisResult, error) {
+				in, err := loadWorkloadAuditInput(ctx, env)
+				if err != nil {
+					return nil, err
+				}
+				report := auditWorkloads(in, &models.WorkloadAuditQuery{}, env.Now)
+				return &DiagnosisResult{Risks: workloadAuditRisks(report, int(p["max_reported"])), Deduction: 100 - report.Score}, nil
+			},
+		},
+
+		// ========== 资源 ==========
+		&builtinDiagnosisRule{
+			meta: DiagnosisRuleMeta{
+				ID: "cluster-usage", Name: "集群资源使用率", Category: "resource",
+				Description: "集群 CPU / 内存使用率超过阈值时报告；未配置 Prometheus 时使用 metrics-server 的节点用量",
+				Params: []models.DiagnosisRuleParam{
+					{Key: "cpu_warning", Name: "CPU 警告阈值", Default: 80, Unit: "%"},
+					{Key: "cpu_critical", Name: "CPU 严重阈值", Default: 90, Unit: "%"},
+					{Key: "memory_warning", Name: "内存警告阈值", Default: 80, Unit: "%"},
+					{Key: "memory_critical", Name: "内存严重阈值", Default: 90, Unit: "%"},
+				},
+			},
+			eval: func(ctx context.Context, env *DiagnosisEnv, p map[string]float64) (*DiagnosisResult, error) {
+				var cpuRate, memRate float64
+				var cpuOK, memOK bool
+				if env.Monitoring != nil {
+					cpuRate, cpuOK = env.queryScalar(ctx, "(1 - avg(rate(node_cpu_seconds_total{mode=\"idle\"}[5m]))) * 100")
+					memRate, memOK = env.queryScalar(ctx, "(1 - sum(node_memory_MemAvailable_bytes) / sum(node_memory_MemTotal_bytes)) * 100")
+				} else {
+					cpuRate, memRate, cpuOK = clusterUsageFromMetricsServer(ctx, env)
+					memOK = cpuOK
+				}
+
+				result := &DiagnosisResult{}
+				if cpuOK {
+					if risk, deduct := clusterUsageRisk("cpu", cpuRate, p["cpu_warning"], p["cpu_critical"]); risk != nil {
+						result.Risks = append(result.Risks, *risk)
+						result.Deduction += deduct
+					}
+				}
+				if memOK {
+					if risk, deduct := clusterUsageRisk("memory", memRate, p["memory_warning"], p["memory_critical"]); risk != nil {
+						result.Risks = append(result.Risks, *risk)
+						result.Deduction += deduct
+					}
+				}
+				return result, nil
+			},
+		},
+		&builtinDiagnosisRule{
+			meta: DiagnosisRuleMeta{
+				ID: "quota-usage", Name: "资源配额使用率", Category: "resource",
+				Description: "命名空间 ResourceQuota 任一资源的使用率超过阈值时报告警告",
+				Params: []models.DiagnosisRuleParam{
+					{Key: "threshold", Name: "使用率阈值", Default: 90, Unit: "%"},
+				},
+			},
+			eval: func(ctx context.Context, env *DiagnosisEnv, p map[string]float64) (*DiagnosisResult, error) {
+				quotas, err := env.ResourceQuotas(ctx)
+				if err != nil {
+					return nil, err
+				}
+				result := &DiagnosisResult{}
+				for _, quota := range quotas {
+					for resource, used := range quota.Status.Used {
+						hard, ok := quota.Status.Hard[resource]
+						if !ok || hard.Value() <= 0 {
+							continue
+						}
+						usageRate := float64(used.Value()) / float64(hard.Value()) * 100
+						if usageRate > p["threshold"] {
+							result.Risks = append(result.Risks, models.RiskItem{
+								ID:          fmt.Sprintf("quota-exceeded-%s-%s-%s", quota.Namespace, quota.Name, resource),
+								Category:    "resource",
+								Severity:    "warning",
+								Title:       "资源配额使用率过高",
+								Description: fmt.Sprintf("命名空间 %s 资源 %s 使用率达到 %.1f%%", quota.Namespace, resource, usageRate),
+								Namespace:   quota.Namespace,
+								Resource:    quota.Name,
+								Solution:    "考虑提高资源配额或优化资源使用",
+							})
+							result.Deduction += 10
+						}
+					}
+				}
+				return result, nil
+			},
+		},
+
+		// ========== 存储 ==========
+		&builtinDiagnosisRule{
+			meta: DiagnosisRuleMeta{
+				ID: "pvc-pending", Name: "PVC 处于 Pending 状态", Category: "storage",
+				Description: "PVC 无法绑定到 PV 时报告警告",
+				Params:      []models.DiagnosisRuleParam{maxReportedParam},
+			},
+			eval: func(ctx context.Context, env *DiagnosisEnv, p map[string]float64) (*DiagnosisResult, error) {
+				pvcs, err := env.PersistentVolumeClaims(ctx)
+				if err != nil {
+					return nil, err
+				}
+				result := &DiagnosisResult{}
+				count := 0
+				for _, pvc := range pvcs {
+					if pvc.Status.Phase != corev1.ClaimPending {
+						continue
+					}
+					count++
+					if count <= int(p["max_reported"]) {
+						result.Risks = append(result.Risks, models.RiskItem{
+							ID:          fmt.Sprintf("pvc-pending-%s-%s", pvc.Namespace, pvc.Name),
+							Category:    "storage",
+							Severity:    "warning",
+							Title:       "PVC 处于 Pending 状态",
+							Description: fmt.Sprintf("PVC %s/%s 无法绑定到 PV", pvc.Namespace, pvc.Name),
+							Resource:    pvc.Name,
+							Namespace:   pvc.Namespace,
+							Solution:    "检查是否有可用的 StorageClass 和足够的存储资源",
+						})
+					}
+				}
+				result.Deduction = min(count*5, 20)
+				return result, nil
+			},
+		},
+		&builtinDiagnosisRule{
+			meta: DiagnosisRuleMeta{
+				ID: "pvc-lost", Name: "PVC 丢失绑定", Category: "storage",
+				Description: "PVC 处于 Lost 状态时报告严重风险",
+			},
+			eval: func(ctx context.Context, env *DiagnosisEnv, p map[string]float64) (*DiagnosisResult, error) {
+				pvcs, err := env.PersistentVolumeClaims(ctx)
+				if err != nil {
+					return nil, err
+				}
+				result := &DiagnosisResult{}
+				for _, pvc := range pvcs {
+					if pvc.Status.Phase == corev1.ClaimLost {
+						result.Risks = append(result.Risks, models.RiskItem{
+							ID:          fmt.Sprintf("pvc-lost-%s-%s", pvc.Namespace, pvc.Name),
+							Category:    "storage",
+							Severity:    "critical",
+							Title:       "PVC 丢失绑定",
+							Description: fmt.Sprintf("PVC %s/%s 已丢失与 PV 的绑定", pvc.Namespace, pvc.Name),
+							Resource:    pvc.Name,
+							Namespace:   pvc.Namespace,
+							Solution:    "检查关联的 PV 状态，可能需要恢复数据",
+						})
+						result.Deduction += 15
+					}
+				}
+				return result, nil
+			},
+		},
+		&builtinDiagnosisRule{
+			meta: DiagnosisRuleMeta{
+				ID: "pv-failed", Name: "PV 状态异常", Category: "storage",
+				Description: "PV 处于 Failed 状态时报告严重风险",
+			},
+			eval: func(ctx context.Context, env *DiagnosisEnv, p map[string]float64) (*DiagnosisResult, error) {
+				pvs, err := env.PersistentVolumes(ctx)
+				if err != nil {
+					return nil, err
+				}
+				result := &DiagnosisResult{}
+				for _, pv := range pvs {
+					if pv.Status.Phase == corev1.VolumeFailed {
+						result.Risks = append(result.Risks, models.RiskItem{
+							ID:          fmt.Sprintf("pv-failed-%s", pv.Name),
+							Category:    "storage",
+							Severity:    "critical",
+							Title:       "PV 状态异常",
+							Description: fmt.Sprintf("PV %s 处于 Failed 状态", pv.Name),
+							Resource:    pv.Name,
+							Solution:    "检查存储后端状态和网络连接",
+						})
+						result.Deduction += 15
+					}
+				}
+				return result, nil
+			},
+		},
+
+		// ========== 控制面 ==========
+		&builtinDiagnosisRule{
+			meta: DiagnosisRuleMeta{
+				ID: "control-plane-pods", Name: "控制面组件 Pod", Category: "control_plane", UnavailableScore: 50,
+				Description: "kube-system 中的 apiserver、controller-manager、scheduler、etcd Pod 未运行时报告严重风险；托管集群中控制面不可见时不报告",
+			},
+			eval: func(ctx context.Context, env *DiagnosisEnv, p map[string]float64) (*DiagnosisResult, error) {
+				pods, err := env.Pods(ctx)
+				if err != nil {
+					return nil, err
+				}
+				components := []string{"kube-apiserver", "kube-controller-manager", "kube-scheduler", "etcd"}
+				result := &DiagnosisResult{}
+				for _, pod := range pods {
+					if pod.Namespace != "kube-system" {
+						continue
+					}
+					for _, component := range components {
+						if !strings.Contains(pod.Name, component) {
+							continue
+						}
+						if pod.Status.Phase != corev1.PodRunning {
+							result.Risks = append(result.Risks, models.RiskItem{
+								ID:          fmt.Sprintf("control-plane-%s-unhealthy", component),
+								Category:    "control_plane",
+								Severity:    "critical",
+								Title:       fmt.Sprintf("控制面组件 %s 不健康", component),
+								Description: fmt.Sprintf("组件 %s (Pod: %s) 状态: %s", component, pod.Name, pod.Status.Phase),
+								Resource:    pod.Name,
+								Namespace:   "kube-system",
+								Solution:    "检查组件日志和配置",
+							})
+							result.Deduction += 20
+						}
+						break
+					}
+				}
+				return result, nil
+			},
+		},
+		&builtinDiagnosisRule{
+			meta: DiagnosisRuleMeta{
+				ID: "etcd-no-leader", Name: "Etcd 无 Leader", Category: "control_plane",
+				Description: "etcd_server_has_leader 没有任何成员为 1 时报告严重风险（需要 Prometheus）",
+			},
+			eval: func(ctx context.Context, env *DiagnosisEnv, p map[string]float64) (*DiagnosisResult, error) {
+				if env.Monitoring == nil {
+					return nil, errDiagnosisRuleSkipped
+				}
+				resp, err := env.queryRange(ctx, "etcd_server_has_leader")
+				if err != nil || len(resp.Data.Result) == 0 {
+					return &DiagnosisResult{}, nil
+				}
+				for _, series := range resp.Data.Result {
+					if len(series.Values) > 0 {
+						if val, ok := parseDiagnosisSample(series.Values[0]); ok && val == 1 {
+							return &DiagnosisResult{}, nil
+						}
+					}
+				}
+				return &DiagnosisResult{
+					Risks: []models.RiskItem{{
+						ID:          "etcd-no-leader",
+						Category:    "control_plane",
+						Severity:    "critical",
+						Title:       "Etcd 无 Leader",
+						Description: "Etcd 集群当前没有 Leader，集群可能无法正常工作",
+						Solution:    "检查 etcd 集群健康状态和网络连接",
+					}},
+					Deduction: 30,
+				}, nil
+			},
+		},
+		&builtinDiagnosisRule{
+			meta: DiagnosisRuleMeta{
+				ID: "apiserver-error-rate", Name: "API Server 错误率", Category: "control_plane",
+				Description: "API Server 5xx 请求比例超过阈值时报告警告（需要 Prometheus）",
+				Params: []models.DiagnosisRuleParam{
+					{Key: "threshold", Name: "错误率阈值", Default: 5, Unit: "%"},
+				},
+			},
+			eval: func(ctx context.Context, env *DiagnosisEnv, p map[string]float64) (*DiagnosisResult, error) {
+				if env.Monitoring == nil {
+					return nil, errDiagnosisRuleSkipped
+				}
+				val, ok := env.queryScalar(ctx, "sum(rate(apiserver_request_total{code=~\"5..\"}[5m])) / sum(rate(apiserver_request_total[5m])) * 100")
+				if !ok || val <= p["threshold"] {
+					return &DiagnosisResult{}, nil
+				}
+				return &DiagnosisResult{
+					Risks: []models.RiskItem{{
+						ID:          "apiserver-high-error-rate",
+						Category:    "control_plane",
+						Severity:    "warning",
+						Title:       "API Server 错误率较高",
+						Description: fmt.Sprintf("API Server 5xx 错误率达到 %.1f%%", val),
+						Solution:    "检查 apiserver 日志和后端 etcd 状态",
+					}},
+					Deduction: 15,
+				}, nil
+			},
+		},
+
+		// ========== 容量 ==========
+		&builtinDiagnosisRule{
+			meta: DiagnosisRuleMeta{
+				ID: "capacity-forecast", Name: "容量预测", Category: "capacity",
+				Description: "按历史趋势预测 Request 比、使用率与 Pod 占用率达到容量阈值的时间（需要 Prometheus）",
+				Params: []models.DiagnosisRuleParam{
+					{Key: "critical_days", Name: "严重风险天数", Description: "预计在该天数内达到阈值时为严重风险", Default: 7, Unit: "天"},
+					{Key: "warning_days", Name: "警告天数", Description: "预计在该天数内达到阈值时为警告", Default: 30, Unit: "天"},
+				},
+			},
+			eval: func(ctx context.Context, env *DiagnosisEnv, p map[string]float64) (*DiagnosisResult, error) {
+				if env.Monitoring == nil || env.capacitySvc == nil {
+					return nil, errDiagnosisRuleSkipped
+				}
+				forecasts, err := env.capacitySvc.Forecast(ctx, env.ClusterID, env.Monitoring, 0)
+				if err != nil {
+					logger.Warn("容量预测失败", "clusterID", env.ClusterID, "error", err)
+					return nil, errDiagnosisRuleSkipped
+				}
+				risks, score := CapacityRisks(forecasts, p["critical_days"], p["warning_days"])
+				return &DiagnosisResult{Risks: risks, Deduction: 100 - score}, nil
+			},
+		},
+
+		// ========== 证书 ==========
+		&builtinDiagnosisRule{
+			meta: DiagnosisRuleMeta{
+				ID: "certificate-expiry", Name: "证书过期", Category: "certificate",
+				Description: "按最近一次证书扫描结果检查 API Server、kubeconfig、kubelet、Ingress TLS Secret、Webhook 与 APIService 证书的过期时间",
+				Params: []models.DiagnosisRuleParam{
+					{Key: "critical_days", Name: "严重风险天数", Description: "剩余天数不超过该值或已过期时为严重风险", Default: 7, Unit: "天"},
+					{Key: "warning_days", Name: "警告天数", Description: "剩余天数不超过该值时为警告", Default: 30, Unit: "天"},
+				},
+			},
+			eval: func(ctx context.Context, env *DiagnosisEnv, p map[string]float64) (*DiagnosisResult, error) {
+				if env.certificateSvc == nil {
+					return nil, errDiagnosisRuleSkipped
+				}
+				certs, err := env.certificateSvc.List(env.ClusterID, &models.CertificateQuery{})
+				if err != nil {
+					return nil, err
+				}
+				if len(certs) == 0 {
+					// 尚未扫描过证书
+					return nil, errDiagnosisRuleSkipped
+				}
+				risks := CertificateRisks(certs, env.Now, int(p["warning_days"]), int(p["critical_days"]))
+				deduction := 0
+				for _, risk := range risks {
+					if risk.Severity == "critical" {
+						deduction += 20
+					} else {
+						deduction += 5
+					}
+				}
+				return &DiagnosisResult{Risks: risks, Deduction: deduction}, nil
+			},
+		},
+	}
+}
+
+// workloadReadinessSeverity 副本未就绪的严重程度与扣分：期望副本大于 0 且没有任何就绪副本时为严重
+func workloadReadinessSeverity(ready int32, desired *int32) (string, int) {
+	if ready == 0 && desired != nil && *desired > 0 {
+		return "critical", 15
+	}
+	return "warning", 5
+}
+
+// parseDiagnosisSample 解析 [时间戳, "值"] 形式的样本
+func parseDiagnosisSample(sample []interface{}) (float64, bool) {
+	if len(sample) < 2 {
+		return 0, false
+	}
+	val, err := strconv.ParseFloat(fmt.Sprintf("%v", sample[1]), 64)
+	return val, err == nil
+}
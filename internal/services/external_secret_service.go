@@ -0,0 +1,584 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/constants"
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+	"github.com/clay-wangzhi/KubePolaris/pkg/logger"
+
+	"gorm.io/gorm"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// externalSecretTickInterval 外部密钥同步器的检查周期
+const externalSecretTickInterval = 30 * time.Second
+
+// 同步触发方式
+const (
+	ExternalSecretTriggerManual   = "manual"
+	ExternalSecretTriggerSchedule = "schedule"
+	ExternalSecretTriggerCreate   = "create"
+)
+
+// ExternalSecretService 外部密钥源与 Secret 同步服务
+type ExternalSecretService struct {
+	db             *gorm.DB
+	clusterService *ClusterService
+	opLogSvc       *OperationLogService
+	versionSvc     *ConfigVersionService
+	fileRootDir    string // 文件密钥源允许读取的根目录，为空时不允许使用文件密钥源
+
+	// clientFor 获取集群客户端，测试时可替换
+	clientFor func(cluster *models.Cluster) (kubernetes.Interface, error)
+}
+
+// NewExternalSecretService 创建外部密钥同步服务
+func NewExternalSecretService(db *gorm.DB, clusterService *ClusterService, opLogSvc *OperationLogService, versionSvc *ConfigVersionService, fileRootDir string) *ExternalSecretService {
+	return &ExternalSecretService{
+		db:             db,
+		clusterService: clusterService,
+		opLogSvc:       opLogSvc,
+		versionSvc:     versionSvc,
+		fileRootDir:    fileRootDir,
+		clientFor: func(cluster *models.Cluster) (kubernetes.Interface, error) {
+			client, err := NewK8sClientForCluster(cluster)
+			if err != nil {
+				return nil, err
+			}
+			return client.GetClientset(), nil
+		},
+	}
+}
+
+// Start 启动后台同步器，按绑定的刷新间隔同步到期的 Secret
+func (s *ExternalSecretService) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(externalSecretTickInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.reconcileDue(ctx)
+			}
+		}
+	}()
+	logger.Info("外部密钥同步器已启动")
+}
+
+// ListSources 获取集群的密钥源列表
+func (s *ExternalSecretService) ListSources(clusterID uint) ([]models.SecretSource, error) {
+	var sources []models.SecretSource
+	if err := s.db.Where("cluster_id = ?", clusterID).Order("name ASC").Find(&sources).Error; err != nil {
+		return nil, fmt.Errorf("查询密钥源失败: %w", err)
+	}
+	return sources, nil
+}
+
+// GetSource 获取密钥源
+func (s *ExternalSecretService) GetSource(clusterID, sourceID uint) (*models.SecretSource, error) {
+	var source models.SecretSource
+	if err := s.db.Where("cluster_id = ?", clusterID).First(&source, sourceID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("密钥源不存在: %d", sourceID)
+		}
+		return nil, fmt.Errorf("查询密钥源失败: %w", err)
+	}
+	return &source, nil
+}
+
+// SaveSource 创建或更新密钥源，sourceID 为 0 时创建
+func (s *ExternalSecretService) SaveSource(clusterID, sourceID uint, req *models.SecretSourceRequest, userID uint) (*models.SecretSource, error) {
+	source := &models.SecretSource{ClusterID: clusterID, CreatedBy: userID}
+	if sourceID != 0 {
+		existing, err := s.GetSource(clusterID, sourceID)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkSecretSourceTokenReuse(existing, req); err != nil {
+			return nil, err
+		}
+		source = existing
+	}
+
+	source.Name = req.Name
+	source.Type = req.Type
+	source.Description = req.Description
+	source.Address = req.Address
+	source.MountPath = req.MountPath
+	source.VaultNamespace = req.VaultNamespace
+	source.Insecure = req.Insecure
+	source.BaseDir = req.BaseDir
+	if req.Token != "" {
+		source.Token = req.Token
+	}
+	if source.MountPath == "" {
+		source.MountPath = "secret"
+	}
+
+	switch source.Type {
+	case models.SecretSourceTypeVault:
+		if source.Address == "" || source.Token == "" {
+			return nil, fmt.Errorf("vault 密钥源需要配置地址和 Token")
+		}
+	case models.SecretSourceTypeFile:
+		// 目录只能位于服务端配置的根目录下，避免读取 KubePolaris 所在主机的任意文件
+		if _, err := ResolveFileSecretDir(s.fileRootDir, source.BaseDir); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("不支持的密钥源类型: %s", source.Type)
+	}
+
+	if err := s.db.Save(source).Error; err != nil {
+		return nil, fmt.Errorf("保存密钥源失败: %w", err)
+	}
+	return source, nil
+}
+
+// checkSecretSourceTokenReuse 更新时 Token 为空表示保留原 Token，此时不允许修改连接目标，避免把已保存的 Token 发送到其他地址
+func checkSecretSourceTokenReuse(existing *models.SecretSource, req *models.SecretSourceRequest) error {
+	if req.Token != "" || existing.Token == "" {
+		return nil
+	}
+	if req.Type != existing.Type || req.Address != existing.Address || req.VaultNamespace != existing.VaultNamespace || req.Insecure != existing.Insecure {
+		return fmt.Errorf("修改类型、地址、Vault 命名空间或 TLS 设置时需要重新填写 Token")
+	}
+	return nil
+}
+
+// DeleteSource 删除密钥源，仍被引用时拒绝删除
+func (s *ExternalSecretService) DeleteSource(clusterID, sourceID uint) error {
+	source, err := s.GetSource(clusterID, sourceID)
+	if err != nil {
+		return err
+	}
+	var count int64
+	if err := s.db.Model(&models.ExternalSecret{}).Where("source_id = ?", sourceID).Count(&count).Error; err != nil {
+		return fmt.Errorf("查询密钥源引用失败: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("密钥源仍被 %d 个外部密钥引用，无法删除", count)
+	}
+	if err := s.db.Delete(source).Error; err != nil {
+		return fmt.Errorf("删除密钥源失败: %w", err)
+	}
+	return nil
+}
+
+// TestSource 读取指定路径以验证密钥源可用，只返回键名
+func (s *ExternalSecretService) TestSource(ctx context.Context, clusterID, sourceID uint, path string) ([]string, error) {
+	source, err := s.GetSource(clusterID, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := NewSecretProvider(source, s.fileRootDir)
+	if err != nil {
+		return nil, err
+	}
+	data, err := provider.Read(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return sortedKeys(data), nil
+}
+
+// ListExternalSecrets 获取集群的外部密钥绑定列表
+func (s *ExternalSecretService) ListExternalSecrets(clusterID uint, namespace string) ([]models.ExternalSecret, error) {
+	var items []models.ExternalSecret
+	query := s.db.Preload("Source").Where("cluster_id = ?", clusterID)
+	if namespace != "" {
+		query = query.Where("namespace = ?", namespace)
+	}
+	if err := query.Order("namespace ASC, secret_name ASC").Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("查询外部密钥失败: %w", err)
+	}
+	return items, nil
+}
+
+// GetExternalSecret 获取外部密钥绑定
+func (s *ExternalSecretService) GetExternalSecret(clusterID, id uint) (*models.ExternalSecret, error) {
+	var item models.ExternalSecret
+	if err := s.db.Preload("Source").Where("cluster_id = ?", clusterID).First(&item, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("外部密钥不存在: %d", id)
+		}
+		return nil, fmt.Errorf("查询外部密钥失败: %w", err)
+	}
+	return &item, nil
+}
+
+// SaveExternalSecret 创建或更新外部密钥绑定，创建后立即同步一次
+func (s *ExternalSecretService) SaveExternalSecret(ctx context.Context, clusterID, id uint, req *models.ExternalSecretRequest, userID uint, username string) (*models.ExternalSecret, error) {
+	if _, err := s.GetSource(clusterID, req.SourceID); err != nil {
+		return nil, err
+	}
+
+	item := &models.ExternalSecret{ClusterID: clusterID, CreatedBy: userID, Enabled: true}
+	if id != 0 {
+		existing, err := s.GetExternalSecret(clusterID, id)
+		if err != nil {
+			return nil, err
+		}
+		if existing.Namespace != req.Namespace || existing.SecretName != req.SecretName {
+			return nil, fmt.Errorf("不允许修改目标 Secret，请删除后重新创建")
+		}
+		item = existing
+	}
+
+	item.Namespace = req.Namespace
+	item.SecretName = req.SecretName
+	item.SecretType = req.SecretType
+	item.SourceID = req.SourceID
+	item.Source = models.SecretSource{}
+	item.Path = req.Path
+	item.RefreshIntervalSeconds = req.RefreshIntervalSeconds
+	if item.RefreshIntervalSeconds <= 0 {
+		item.RefreshIntervalSeconds = 300
+	}
+	if req.Enabled != nil {
+		item.Enabled = *req.Enabled
+	}
+	item.KeyMapping = ""
+	if len(req.KeyMapping) > 0 {
+		mapping, _ := json.Marshal(req.KeyMapping)
+		item.KeyMapping = string(mapping)
+	}
+
+	if id == 0 {
+		// 避免接管非外部密钥管理的已有 Secret
+		cluster, err := s.clusterService.GetCluster(clusterID)
+		if err != nil {
+			return nil, fmt.Errorf("获取集群失败: %w", err)
+		}
+		clientset, err := s.clientFor(cluster)
+		if err != nil {
+			return nil, fmt.Errorf("创建K8s客户端失败: %w", err)
+		}
+		existing, err := clientset.CoreV1().Secrets(req.Namespace).Get(ctx, req.SecretName, metav1.GetOptions{})
+		if err == nil && existing.Annotations[models.AnnotationExternalSecretSource] == "" {
+			return nil, fmt.Errorf("Secret %s/%s 已存在且不受外部密钥管理", req.Namespace, req.SecretName)
+		}
+	}
+
+	if err := s.db.Omit("Source").Save(item).Error; err != nil {
+		return nil, fmt.Errorf("保存外部密钥失败: %w", err)
+	}
+
+	trigger := ExternalSecretTriggerManual
+	if id == 0 {
+		trigger = ExternalSecretTriggerCreate
+	}
+	if _, err := s.Sync(ctx, item, trigger, username); err != nil {
+		logger.Error("外部密钥首次同步失败", "id", item.ID, "error", err)
+	}
+	return s.GetExternalSecret(clusterID, item.ID)
+}
+
+// DeleteExternalSecret 删除外部密钥绑定，集群内的 Secret 保留
+func (s *ExternalSecretService) DeleteExternalSecret(clusterID, id uint) error {
+	item, err := s.GetExternalSecret(clusterID, id)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Delete(item).Error; err != nil {
+		return fmt.Errorf("删除外部密钥失败: %w", err)
+	}
+	return nil
+}
+
+// CheckDrift 对比外部密钥与集群内 Secret 的差异，不做修改
+func (s *ExternalSecretService) CheckDrift(ctx context.Context, item *models.ExternalSecret) (*models.ExternalSecretDrift, error) {
+	desired, clientset, err := s.loadDesired(ctx, item)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := clientset.CoreV1().Secrets(item.Namespace).Get(ctx, item.SecretName, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("获取Secret失败: %w", err)
+	}
+	if apierrors.IsNotFound(err) {
+		secret = nil
+	}
+	drift := diffSecretData(secret, desired)
+	drift.LastSyncAt = item.LastSyncAt
+	return drift, nil
+}
+
+// Sync 从外部源读取数据并写入 Secret，返回同步前的差异；每次同步都会记录操作日志
+func (s *ExternalSecretService) Sync(ctx context.Context, item *models.ExternalSecret, trigger, username string) (*models.ExternalSecretDrift, error) {
+	drift, err := s.syncSecret(ctx, item)
+
+	now := time.Now()
+	item.LastSyncAt = &now
+	if err != nil {
+		item.LastSyncStatus = models.ExternalSecretSyncFailed
+		item.LastSyncMessage = err.Error()
+	} else {
+		item.LastSyncStatus = models.ExternalSecretSyncSuccess
+		item.LastSyncMessage = ""
+		if !drift.InSync {
+			item.LastDriftKeys = strings.Join(driftKeys(drift), ",")
+			item.LastDriftAt = &now
+		}
+	}
+	if dbErr := s.db.Model(item).Select("last_sync_at", "last_sync_status", "last_sync_message", "last_drift_keys", "last_drift_at").Updates(item).Error; dbErr != nil {
+		logger.Error("更新外部密钥同步状态失败", "id", item.ID, "error", dbErr)
+	}
+
+	s.recordSync(item, trigger, username, drift, err)
+	return drift, err
+}
+
+func (s *ExternalSecretService) syncSecret(ctx context.Context, item *models.ExternalSecret) (*models.ExternalSecretDrift, error) {
+	desired, clientset, err := s.loadDesired(ctx, item)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := clientset.CoreV1().Secrets(item.Namespace)
+	secret, err := secrets.Get(ctx, item.SecretName, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("获取Secret失败: %w", err)
+	}
+	notFound := apierrors.IsNotFound(err)
+	if notFound {
+		secret = nil
+	}
+
+	drift := diffSecretData(secret, desired)
+	drift.LastSyncAt = item.LastSyncAt
+	hash := hashSecretData(desired)
+	if drift.InSync && secret != nil && secret.Annotations[models.AnnotationExternalSecretHash] == hash {
+		return drift, nil
+	}
+
+	data := make(map[string][]byte, len(desired))
+	for k, v := range desired {
+		data[k] = []byte(v)
+	}
+	annotations := map[string]string{
+		models.AnnotationExternalSecretSource: item.Source.Name,
+		models.AnnotationExternalSecretPath:   item.Path,
+		models.AnnotationExternalSecretHash:   hash,
+		models.AnnotationExternalSecretSynced: time.Now().Format(time.RFC3339),
+	}
+
+	if notFound {
+		secretType := corev1.SecretTypeOpaque
+		if item.SecretType != "" {
+			secretType = corev1.SecretType(item.SecretType)
+		}
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        item.SecretName,
+				Namespace:   item.Namespace,
+				Labels:      map[string]string{"app.kubernetes.io/managed-by": "kubepolaris"},
+				Annotations: annotations,
+			},
+			Type: secretType,
+			Data: data,
+		}
+		created, err := secrets.Create(ctx, secret, metav1.CreateOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("创建Secret失败: %w", err)
+		}
+		s.versionSvc.RecordSecret(item.ClusterID, nil, created, models.ConfigVersionSourceExternal, "")
+		return drift, nil
+	}
+
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	for k, v := range annotations {
+		secret.Annotations[k] = v
+	}
+	before := secret.DeepCopy()
+	secret.Data = data
+	updated, err := secrets.Update(ctx, secret, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("更新Secret失败: %w", err)
+	}
+	s.versionSvc.RecordSecret(item.ClusterID, before, updated, models.ConfigVersionSourceExternal, "")
+	return drift, nil
+}
+
+// loadDesired 读取外部数据并按键映射生成期望的 Secret 数据
+func (s *ExternalSecretService) loadDesired(ctx context.Context, item *models.ExternalSecret) (map[string]string, kubernetes.Interface, error) {
+	if item.Source.ID == 0 {
+		source, err := s.GetSource(item.ClusterID, item.SourceID)
+		if err != nil {
+			return nil, nil, err
+		}
+		item.Source = *source
+	}
+	provider, err := NewSecretProvider(&item.Source, s.fileRootDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	remote, err := provider.Read(ctx, item.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	desired := remote
+	if mapping := item.GetKeyMapping(); len(mapping) > 0 {
+		desired = make(map[string]string, len(mapping))
+		for secretKey, remoteKey := range mapping {
+			value, ok := remote[remoteKey]
+			if !ok {
+				return nil, nil, fmt.Errorf("外部路径 %s 中不存在键: %s", item.Path, remoteKey)
+			}
+			desired[secretKey] = value
+		}
+	}
+
+	cluster, err := s.clusterService.GetCluster(item.ClusterID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取集群失败: %w", err)
+	}
+	clientset, err := s.clientFor(cluster)
+	if err != nil {
+		return nil, nil, fmt.Errorf("创建K8s客户端失败: %w", err)
+	}
+	return desired, clientset, nil
+}
+
+// reconcileDue 同步已到刷新时间的外部密钥
+func (s *ExternalSecretService) reconcileDue(ctx context.Context) {
+	var items []models.ExternalSecret
+	if err := s.db.Preload("Source").Where("enabled = ?", true).Find(&items).Error; err != nil {
+		logger.Error("查询外部密钥失败", "error", err)
+		return
+	}
+
+	now := time.Now()
+	for i := range items {
+		item := &items[i]
+		if item.LastSyncAt != nil && now.Sub(*item.LastSyncAt) < time.Duration(item.RefreshIntervalSeconds)*time.Second {
+			continue
+		}
+		if _, err := s.Sync(ctx, item, ExternalSecretTriggerSchedule, "system"); err != nil {
+			logger.Error("外部密钥定时同步失败", "id", item.ID, "namespace", item.Namespace, "name", item.SecretName, "error", err)
+		}
+	}
+}
+
+// recordSync 将同步结果写入操作日志（只包含键名）
+func (s *ExternalSecretService) recordSync(item *models.ExternalSecret, trigger, username string, drift *models.ExternalSecretDrift, syncErr error) {
+	if s.opLogSvc == nil {
+		return
+	}
+	clusterID := item.ClusterID
+	body := map[string]interface{}{
+		"source":  item.Source.Name,
+		"path":    item.Path,
+		"trigger": trigger,
+	}
+	if drift != nil {
+		body["in_sync"] = drift.InSync
+		body["changed"] = drift.Changed
+		body["missing"] = drift.Missing
+		body["extra"] = drift.Extra
+	}
+	entry := &LogEntry{
+		Username:     username,
+		Method:       "SYNC",
+		Path:         fmt.Sprintf("/external-secrets/%d/sync", item.ID),
+		Module:       constants.ModuleConfig,
+		Action:       constants.ActionSync,
+		ClusterID:    &clusterID,
+		Namespace:    item.Namespace,
+		ResourceType: "external_secret",
+		ResourceName: item.SecretName,
+		RequestBody:  body,
+		StatusCode:   200,
+		Success:      syncErr == nil,
+	}
+	if syncErr != nil {
+		entry.StatusCode = 500
+		entry.ErrorMessage = syncErr.Error()
+	}
+	s.opLogSvc.RecordAsync(entry)
+}
+
+// diffSecretData 计算集群内 Secret 与期望数据的差异
+func diffSecretData(secret *corev1.Secret, desired map[string]string) *models.ExternalSecretDrift {
+	drift := &models.ExternalSecretDrift{
+		SecretFound: secret != nil,
+		Changed:     []string{},
+		Missing:     []string{},
+		Extra:       []string{},
+		CheckedAt:   time.Now(),
+	}
+	var current map[string][]byte
+	if secret != nil {
+		current = secret.Data
+	}
+	for _, k := range sortedKeys(desired) {
+		value, ok := current[k]
+		if !ok {
+			drift.Missing = append(drift.Missing, k)
+		} else if string(value) != desired[k] {
+			drift.Changed = append(drift.Changed, k)
+		}
+	}
+	for k := range current {
+		if _, ok := desired[k]; !ok {
+			drift.Extra = append(drift.Extra, k)
+		}
+	}
+	sort.Strings(drift.Extra)
+	drift.InSync = secret != nil && len(drift.Changed) == 0 && len(drift.Missing) == 0 && len(drift.Extra) == 0
+	return drift
+}
+
+// HashSecretData 计算 Secret 数据摘要，用于判断集群内 Secret 是否在同步后被修改
+func HashSecretData(data map[string][]byte) string {
+	values := make(map[string]string, len(data))
+	for k, v := range data {
+		values[k] = string(v)
+	}
+	return hashSecretData(values)
+}
+
+func hashSecretData(data map[string]string) string {
+	h := sha256.New()
+	for _, k := range sortedKeys(data) {
+		h.Write([]byte(k))
+		h.Write([]byte{0})
+		h.Write([]byte(data[k]))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func driftKeys(drift *models.ExternalSecretDrift) []string {
+	keys := make([]string, 0, len(drift.Changed)+len(drift.Missing)+len(drift.Extra))
+	keys = append(keys, drift.Changed...)
+	keys = append(keys, drift.Missing...)
+	keys = append(keys, drift.Extra...)
+	return keys
+}
+
+func sortedKeys(data map[string]string) []string {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
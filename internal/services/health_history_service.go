@@ -0,0 +1,447 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+	"github.com/clay-wangzhi/KubePolaris/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// healthDiagnosisTimeout 单个集群定时诊断的超时时间
+	healthDiagnosisTimeout = 2 * time.Minute
+	// healthHistoryCleanupInterval 过期历史清理周期
+	healthHistoryCleanupInterval = 24 * time.Hour
+	// healthTrendMaxRange 评分趋势最长查询区间
+	healthTrendMaxRange = 366 * 24 * time.Hour
+)
+
+// HealthHistoryOptions 定时健康诊断参数
+type HealthHistoryOptions struct {
+	Interval  time.Duration // 定时诊断周期，0 表示不定时诊断
+	Retention time.Duration // 历史保留时长
+}
+
+// HealthHistoryService 健康诊断历史：定时对所有集群执行诊断并保存结果，提供评分趋势与两次诊断的对比，
+// 评分明显下降或出现新的严重风险时发送通知
+type HealthHistoryService struct {
+	db              *gorm.DB
+	clusterService  *ClusterService
+	notificationSvc *NotificationService
+	opts            HealthHistoryOptions
+
+	// diagnose 对集群执行一次健康诊断，测试时可替换
+	diagnose func(ctx context.Context, cluster *models.Cluster) (*models.HealthDiagnosisResponse, error)
+	now      func() time.Time
+}
+
+// NewHealthHistoryService 创建健康诊断历史服务
+func NewHealthHistoryService(db *gorm.DB, clusterService *ClusterService, omSvc *OMService, notificationSvc *NotificationService, opts HealthHistoryOptions) *HealthHistoryService {
+	if opts.Retention <= 0 {
+		opts.Retention = 90 * 24 * time.Hour
+	}
+	return &HealthHistoryService{
+		db:              db,
+		clusterService:  clusterService,
+		notificationSvc: notificationSvc,
+		opts:            opts,
+		diagnose: func(ctx context.Context, cluster *models.Cluster) (*models.HealthDiagnosisResponse, error) {
+			client, err := NewK8sClientForCluster(cluster)
+			if err != nil {
+				return nil, fmt.Errorf("创建K8s客户端失败: %w", err)
+			}
+			return omSvc.GetHealthDiagnosis(ctx, client.GetClientset(), cluster.ID)
+		},
+		now: time.Now,
+	}
+}
+
+// Start 启动定时诊断与过期历史清理，ctx 取消后退出
+func (s *HealthHistoryService) Start(ctx context.Context) {
+	go func() {
+		interval := s.opts.Interval
+		if interval <= 0 {
+			interval = healthHistoryCleanupInterval
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		var lastCleanup time.Time
+		for {
+			if s.opts.Interval > 0 {
+				s.DiagnoseAll(ctx)
+			}
+			if s.now().Sub(lastCleanup) >= healthHistoryCleanupInterval {
+				s.cleanup()
+				lastCleanup = s.now()
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	logger.Info("定时健康诊断已启动", "interval", s.opts.Interval)
+}
+
+// DiagnoseAll 依次诊断所有集群并记录结果，单个集群失败不影响其他集群
+func (s *HealthHistoryService) DiagnoseAll(ctx context.Context) {
+	clusters, err := s.clusterService.GetAllClusters()
+	if err != nil {
+		logger.Error("定时健康诊断获取集群列表失败", "error", err)
+		return
+	}
+	for _, cluster := range clusters {
+		if ctx.Err() != nil {
+			return
+		}
+		diagCtx, cancel := context.WithTimeout(ctx, healthDiagnosisTimeout)
+		result, err := s.diagnose(diagCtx, cluster)
+		cancel()
+		if err != nil {
+			logger.Warn("定时健康诊断失败", "cluster", cluster.Name, "error", err)
+			continue
+		}
+		if _, err := s.Record(cluster, result, models.HealthDiagnosisScheduled); err != nil {
+			logger.Error("保存健康诊断历史失败", "cluster", cluster.Name, "error", err)
+		}
+	}
+}
+
+// Record 保存一次诊断结果，并与该集群上一次诊断对比：评分下降超过阈值或出现新的严重风险时发送通知。
+// 集群的第一次诊断没有对比基准，不发送通知
+func (s *HealthHistoryService) Record(cluster *models.Cluster, result *models.HealthDiagnosisResponse, source string) (*models.HealthDiagnosisRecord, error) {
+	record, err := newHealthDiagnosisRecord(cluster.ID, result, source)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := s.latest(cluster.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(record).Error; err != nil {
+		return nil, fmt.Errorf("保存健康诊断历史失败: %w", err)
+	}
+
+	if previous != nil {
+		diff := DiffHealthDiagnosis(previous, record)
+		s.notificationSvc.HealthScoreDropped(cluster, previous.HealthScore, record.HealthScore, record.Status)
+		s.notificationSvc.HealthNewRisks(cluster, newCriticalRisks(diff, record))
+	}
+	return record, nil
+}
+
+// latest 获取集群最近一次诊断记录（含风险项），没有记录时返回 nil
+func (s *HealthHistoryService) latest(clusterID uint) (*models.HealthDiagnosisRecord, error) {
+	var records []models.HealthDiagnosisRecord
+	if err := s.db.Where("cluster_id = ?", clusterID).Order("diagnosed_at DESC, id DESC").Limit(1).Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("查询健康诊断历史失败: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	decodeHealthDiagnosisRecord(&records[0], true)
+	return &records[0], nil
+}
+
+// ========== 查询 ==========
+
+// List 分页查询集群的诊断历史，不含风险项明细
+func (s *HealthHistoryService) List(clusterID uint, q *models.HealthDiagnosisHistoryQuery) (*models.HealthDiagnosisHistoryResponse, error) {
+	if q.Page <= 0 {
+		q.Page = 1
+	}
+	if q.PageSize <= 0 || q.PageSize > 200 {
+		q.PageSize = 20
+	}
+	query := s.db.Model(&models.HealthDiagnosisRecord{}).Where("cluster_id = ?", clusterID)
+	if q.Source != "" {
+		query = query.Where("source = ?", q.Source)
+	}
+	if !q.StartTime.IsZero() {
+		query = query.Where("diagnosed_at >= ?", q.StartTime)
+	}
+	if !q.EndTime.IsZero() {
+		query = query.Where("diagnosed_at <= ?", q.EndTime)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("查询健康诊断历史失败: %w", err)
+	}
+	var items []models.HealthDiagnosisRecord
+	if err := query.Omit("risks", "suggestions").Order("diagnosed_at DESC, id DESC").
+		Offset((q.Page - 1) * q.PageSize).Limit(q.PageSize).Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("查询健康诊断历史失败: %w", err)
+	}
+	for i := range items {
+		decodeHealthDiagnosisRecord(&items[i], false)
+	}
+	return &models.HealthDiagnosisHistoryResponse{Items: items, Total: total, Page: q.Page, PageSize: q.PageSize}, nil
+}
+
+// Get 获取一次诊断的完整结果
+func (s *HealthHistoryService) Get(clusterID, id uint) (*models.HealthDiagnosisRecord, error) {
+	var record models.HealthDiagnosisRecord
+	if err := s.db.Where("cluster_id = ?", clusterID).First(&record, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("诊断记录不存在")
+		}
+		return nil, fmt.Errorf("查询健康诊断历史失败: %w", err)
+	}
+	decodeHealthDiagnosisRecord(&record, true)
+	return &record, nil
+}
+
+// Trend 获取时间区间内的评分趋势（按诊断时间升序）
+func (s *HealthHistoryService) Trend(clusterID uint, start, end time.Time) ([]models.HealthScoreTrendPoint, error) {
+	if !start.Before(end) {
+		return nil, fmt.Errorf("开始时间必须早于结束时间")
+	}
+	if end.Sub(start) > healthTrendMaxRange {
+		return nil, fmt.Errorf("查询区间不能超过 366 天")
+	}
+	var records []models.HealthDiagnosisRecord
+	if err := s.db.Omit("risks", "suggestions").
+		Where("cluster_id = ? AND diagnosed_at >= ? AND diagnosed_at <= ?", clusterID, start, end).
+		Order("diagnosed_at ASC, id ASC").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("查询健康评分趋势失败: %w", err)
+	}
+	points := make([]models.HealthScoreTrendPoint, 0, len(records))
+	for i := range records {
+		decodeHealthDiagnosisRecord(&records[i], false)
+		points = append(points, models.HealthScoreTrendPoint{
+			RecordID:       records[i].ID,
+			Timestamp:      records[i].DiagnosedAt.Unix(),
+			HealthScore:    records[i].HealthScore,
+			Status:         records[i].Status,
+			CategoryScores: records[i].CategoryScoreMap,
+			CriticalCount:  records[i].CriticalCount,
+			WarningCount:   records[i].WarningCount,
+		})
+	}
+	return points, nil
+}
+
+// Diff 对比两次诊断；toID 为 0 时取最近一次，fromID 为 0 时取 to 之前的一次
+func (s *HealthHistoryService) Diff(clusterID, fromID, toID uint) (*models.HealthDiagnosisDiff, error) {
+	var to *models.HealthDiagnosisRecord
+	var err error
+	if toID != 0 {
+		to, err = s.Get(clusterID, toID)
+	} else {
+		to, err = s.latest(clusterID)
+		if err == nil && to == nil {
+			err = fmt.Errorf("集群还没有诊断记录")
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var from *models.HealthDiagnosisRecord
+	if fromID != 0 {
+		if from, err = s.Get(clusterID, fromID); err != nil {
+			return nil, err
+		}
+	} else {
+		var records []models.HealthDiagnosisRecord
+		if err := s.db.Where("cluster_id = ? AND (diagnosed_at < ? OR (diagnosed_at = ? AND id < ?))", clusterID, to.DiagnosedAt, to.DiagnosedAt, to.ID).
+			Order("diagnosed_at DESC, id DESC").Limit(1).Find(&records).Error; err != nil {
+			return nil, fmt.Errorf("查询健康诊断历史失败: %w", err)
+		}
+		if len(records) == 0 {
+			return nil, fmt.Errorf("没有可对比的更早诊断记录")
+		}
+		from = &records[0]
+		decodeHealthDiagnosisRecord(from, true)
+	}
+	return DiffHealthDiagnosis(from, to), nil
+}
+
+// ========== 清理 ==========
+
+// cleanup 删除超过保留期的诊断历史
+func (s *HealthHistoryService) cleanup() {
+	result := s.db.Where("diagnosed_at < ?", s.now().Add(-s.opts.Retention)).Delete(&models.HealthDiagnosisRecord{})
+	if result.Error != nil {
+		logger.Error("清理健康诊断历史失败", "error", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		logger.Info("已清理过期健康诊断历史", "count", result.RowsAffected)
+	}
+}
+
+// ========== 对比 ==========
+
+// DiffHealthDiagnosis 按风险项 ID 对比两次诊断，结果中的风险项按严重程度与 ID 排序
+func DiffHealthDiagnosis(from, to *models.HealthDiagnosisRecord) *models.HealthDiagnosisDiff {
+	diff := &models.HealthDiagnosisDiff{
+		From:            healthRecordSummary(from),
+		To:              healthRecordSummary(to),
+		ScoreDelta:      to.HealthScore - from.HealthScore,
+		CategoryDeltas:  make(map[string]int),
+		NewRisks:        []models.RiskItem{},
+		ResolvedRisks:   []models.RiskItem{},
+		PersistingRisks: []models.RiskItem{},
+		ChangedSeverity: []models.RiskSeverityChange{},
+	}
+	for category, score := range to.CategoryScoreMap {
+		if previous, ok := from.CategoryScoreMap[category]; ok {
+			diff.CategoryDeltas[category] = score - previous
+		}
+	}
+
+	previous := make(map[string]models.RiskItem, len(from.RiskItems))
+	for _, risk := range from.RiskItems {
+		previous[risk.ID] = risk
+	}
+	current := make(map[string]bool, len(to.RiskItems))
+	for _, risk := range to.RiskItems {
+		current[risk.ID] = true
+		old, ok := previous[risk.ID]
+		if !ok {
+			diff.NewRisks = append(diff.NewRisks, risk)
+			continue
+		}
+		diff.PersistingRisks = append(diff.PersistingRisks, risk)
+		if old.Severity != risk.Severity {
+			diff.ChangedSeverity = append(diff.ChangedSeverity, models.RiskSeverityChange{
+				ID: risk.ID, Title: risk.Title, Previous: old.Severity, Current: risk.Severity,
+			})
+		}
+	}
+	for _, risk := range from.RiskItems {
+		if !current[risk.ID] {
+			diff.ResolvedRisks = append(diff.ResolvedRisks, risk)
+		}
+	}
+
+	sortRiskItems(diff.NewRisks)
+	sortRiskItems(diff.ResolvedRisks)
+	sortRiskItems(diff.PersistingRisks)
+	sort.Slice(diff.ChangedSeverity, func(i, j int) bool { return diff.ChangedSeverity[i].ID < diff.ChangedSeverity[j].ID })
+	return diff
+}
+
+// newCriticalRisks 本次诊断中新出现的严重风险，包括由较低级别升级为严重的风险项
+func newCriticalRisks(diff *models.HealthDiagnosisDiff, to *models.HealthDiagnosisRecord) []models.RiskItem {
+	escalated := make(map[string]bool)
+	for _, change := range diff.ChangedSeverity {
+		if change.Current == "critical" {
+			escalated[change.ID] = true
+		}
+	}
+	var risks []models.RiskItem
+	for _, risk := range diff.NewRisks {
+		if risk.Severity == "critical" {
+			risks = append(risks, risk)
+		}
+	}
+	for _, risk := range to.RiskItems {
+		if escalated[risk.ID] {
+			risks = append(risks, risk)
+		}
+	}
+	return risks
+}
+
+// riskSeverityRank 严重程度排序权重，越严重越小
+func riskSeverityRank(severity string) int {
+	switch severity {
+	case "critical":
+		return 0
+	case "warning":
+		return 1
+	default:
+		return 2
+	}
+}
+
+func sortRiskItems(risks []models.RiskItem) {
+	sort.SliceStable(risks, func(i, j int) bool {
+		if ri, rj := riskSeverityRank(risks[i].Severity), riskSeverityRank(risks[j].Severity); ri != rj {
+			return ri < rj
+		}
+		return risks[i].ID < risks[j].ID
+	})
+}
+
+// healthRecordSummary 去掉风险项与建议明细的记录副本
+func healthRecordSummary(record *models.HealthDiagnosisRecord) *models.HealthDiagnosisRecord {
+	summary := *record
+	summary.RiskItems = nil
+	summary.SuggestionList = nil
+	return &summary
+}
+
+// ========== 编解码 ==========
+
+func newHealthDiagnosisRecord(clusterID uint, result *models.HealthDiagnosisResponse, source string) (*models.HealthDiagnosisRecord, error) {
+	categoryScores, err := json.Marshal(result.CategoryScores)
+	if err != nil {
+		return nil, fmt.Errorf("序列化诊断结果失败: %w", err)
+	}
+	risks, err := json.Marshal(result.RiskItems)
+	if err != nil {
+		return nil, fmt.Errorf("序列化诊断结果失败: %w", err)
+	}
+	suggestions, err := json.Marshal(result.Suggestions)
+	if err != nil {
+		return nil, fmt.Errorf("序列化诊断结果失败: %w", err)
+	}
+
+	diagnosedAt := time.Unix(result.DiagnosisTime, 0)
+	if result.DiagnosisTime == 0 {
+		diagnosedAt = time.Now()
+	}
+	record := &models.HealthDiagnosisRecord{
+		ClusterID:        clusterID,
+		Source:           source,
+		HealthScore:      result.HealthScore,
+		Status:           result.Status,
+		RiskCount:        len(result.RiskItems),
+		CategoryScores:   string(categoryScores),
+		Risks:            string(risks),
+		Suggestions:      string(suggestions),
+		CategoryScoreMap: result.CategoryScores,
+		RiskItems:        result.RiskItems,
+		SuggestionList:   result.Suggestions,
+		DiagnosedAt:      diagnosedAt,
+	}
+	for _, risk := range result.RiskItems {
+		switch risk.Severity {
+		case "critical":
+			record.CriticalCount++
+		case "warning":
+			record.WarningCount++
+		}
+	}
+	return record, nil
+}
+
+// decodeHealthDiagnosisRecord 解析 JSON 字段，withDetails 为 false 时只解析分类评分
+func decodeHealthDiagnosisRecord(record *models.HealthDiagnosisRecord, withDetails bool) {
+	record.CategoryScoreMap = map[string]int{}
+	if record.CategoryScores != "" {
+		_ = json.Unmarshal([]byte(record.CategoryScores), &record.CategoryScoreMap)
+	}
+	if !withDetails {
+		return
+	}
+	record.RiskItems = []models.RiskItem{}
+	if record.Risks != "" {
+		_ = json.Unmarshal([]byte(record.Risks), &record.RiskItems)
+	}
+	record.SuggestionList = []string{}
+	if record.Suggestions != "" {
+		_ = json.Unmarshal([]byte(record.Suggestions), &record.SuggestionList)
+	}
+}
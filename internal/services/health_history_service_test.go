@@ -0,0 +1,99 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// HealthHistoryServiceTestSuite 定义健康诊断历史测试套件
+type HealthHistoryServiceTestSuite struct {
+	suite.Suite
+}
+
+func healthRecord(score int, categories map[string]int, risks ...models.RiskItem) *models.HealthDiagnosisRecord {
+	record, err := newHealthDiagnosisRecord(1, &models.HealthDiagnosisResponse{
+		HealthScore:    score,
+		Status:         "warning",
+		RiskItems:      risks,
+		Suggestions:    []string{},
+		CategoryScores: categories,
+		DiagnosisTime:  1710000000,
+	}, models.HealthDiagnosisScheduled)
+	if err != nil {
+		panic(err)
+	}
+	return record
+}
+
+// TestRecordEncoding 测试诊断结果的序列化与风险计数
+func (s *HealthHistoryServiceTestSuite) TestRecordEncoding() {
+	record := healthRecord(72, map[string]int{"node": 80, "workload": 60},
+		models.RiskItem{ID: "node-not-ready-n1", Severity: "critical"},
+		models.RiskItem{ID: "pod-pending-default-a", Severity: "warning"},
+		models.RiskItem{ID: "pod-pending-default-b", Severity: "warning"},
+		models.RiskItem{ID: "capacity-cluster-pods", Severity: "info"},
+	)
+	assert.Equal(s.T(), 1, record.CriticalCount)
+	assert.Equal(s.T(), 2, record.WarningCount)
+	assert.Equal(s.T(), 4, record.RiskCount)
+	assert.Equal(s.T(), int64(1710000000), record.DiagnosedAt.Unix())
+	assert.Equal(s.T(), models.HealthDiagnosisScheduled, record.Source)
+
+	stored := models.HealthDiagnosisRecord{CategoryScores: record.CategoryScores, Risks: record.Risks, Suggestions: record.Suggestions}
+	decodeHealthDiagnosisRecord(&stored, false)
+	assert.Equal(s.T(), map[string]int{"node": 80, "workload": 60}, stored.CategoryScoreMap)
+	assert.Nil(s.T(), stored.RiskItems)
+
+	decodeHealthDiagnosisRecord(&stored, true)
+	assert.Equal(s.T(), record.RiskItems, stored.RiskItems)
+	assert.Equal(s.T(), []string{}, stored.SuggestionList)
+}
+
+// TestDiff 测试按风险项 ID 对比两次诊断
+func (s *HealthHistoryServiceTestSuite) TestDiff() {
+	from := healthRecord(85, map[string]int{"node": 100, "workload": 70, "capacity": 90},
+		models.RiskItem{ID: "pod-crashloop-default-a", Severity: "warning"},
+		models.RiskItem{ID: "pvc-pending-default-data", Severity: "warning"},
+		models.RiskItem{ID: "capacity-cluster-cpu_request", Severity: "warning", Title: "CPU Request 预计 20 天后达到 85%"},
+	)
+	to := healthRecord(60, map[string]int{"node": 70, "workload": 70},
+		models.RiskItem{ID: "pod-crashloop-default-a", Severity: "warning"},
+		models.RiskItem{ID: "capacity-cluster-cpu_request", Severity: "critical", Title: "CPU Request 预计 5 天后达到 85%"},
+		models.RiskItem{ID: "pod-pending-default-b", Severity: "warning"},
+		models.RiskItem{ID: "node-not-ready-n1", Severity: "critical"},
+	)
+
+	diff := DiffHealthDiagnosis(from, to)
+	assert.Equal(s.T(), -25, diff.ScoreDelta)
+	assert.Equal(s.T(), map[string]int{"node": -30, "workload": 0}, diff.CategoryDeltas)
+	assert.Nil(s.T(), diff.From.RiskItems)
+	assert.Nil(s.T(), diff.To.RiskItems)
+
+	ids := func(risks []models.RiskItem) []string {
+		result := make([]string, 0, len(risks))
+		for _, r := range risks {
+			result = append(result, r.ID)
+		}
+		return result
+	}
+	assert.Equal(s.T(), []string{"node-not-ready-n1", "pod-pending-default-b"}, ids(diff.NewRisks))
+	assert.Equal(s.T(), []string{"pvc-pending-default-data"}, ids(diff.ResolvedRisks))
+	assert.Equal(s.T(), []string{"capacity-cluster-cpu_request", "pod-crashloop-default-a"}, ids(diff.PersistingRisks))
+	assert.Equal(s.T(), "CPU Request 预计 5 天后达到 85%", diff.PersistingRisks[0].Title, "持续存在的风险取最新内容")
+	assert.Equal(s.T(), []models.RiskSeverityChange{{
+		ID: "capacity-cluster-cpu_request", Title: "CPU Request 预计 5 天后达到 85%", Previous: "warning", Current: "critical",
+	}}, diff.ChangedSeverity)
+
+	// 新出现与升级为严重的风险都需要通知
+	assert.Equal(s.T(), []string{"node-not-ready-n1", "capacity-cluster-cpu_request"}, ids(newCriticalRisks(diff, to)))
+	assert.Empty(s.T(), newCriticalRisks(DiffHealthDiagnosis(to, to), to))
+}
+
+// TestHealthHistoryServiceTestSuite 运行健康诊断历史测试套件
+func TestHealthHistoryServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(HealthHistoryServiceTestSuite))
+}
@@ -8,6 +8,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+
 	rolloutsclientset "github.com/argoproj/argo-rollouts/pkg/client/clientset/versioned"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -31,6 +33,8 @@ type ClusterInfo struct {
 	RunningPods       int    `json:"runningPods,omitempty"`
 	CanAccessPods     bool   `json:"canAccessPods,omitempty"`
 	CanAccessServices bool   `json:"canAccessServices,omitempty"`
+
+	CertExpireAt *time.Time `json:"certExpireAt,omitempty"` // API Server 服务端证书过期时间，无法读取时为空
 }
 
 // NewK8sClientFromKubeconfig 从kubeconfig创建客户端
@@ -157,7 +161,13 @@ func (c *K8sClient) TestConnection() (*ClusterInfo, error) {
 		CanAccessServices: canAccessServices,
 	}
 
-	// 8. 尝试获取更多统计信息（可选，不影响连接测试结果）
+	// 8. 读取 API Server 服务端证书过期时间（可选，不影响连接测试结果）
+	if cert, err := fetchAPIServerCertificate(ctx, c.config); err == nil {
+		notAfter := cert.NotAfter
+		clusterInfo.CertExpireAt = &notAfter
+	}
+
+	// 9. 尝试获取更多统计信息（可选，不影响连接测试结果）
 	if canAccessPods && pods != nil {
 		// 统计Pod数量（仅在有权限时）
 		allPods, err := c.clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
@@ -310,6 +320,14 @@ func CreateKubeconfigFromToken(clusterName, apiServer, token, caCert string) str
 	return string(configBytes)
 }
 
+// NewK8sClientForCluster 根据集群记录创建客户端（优先使用 kubeconfig）
+func NewK8sClientForCluster(cluster *models.Cluster) (*K8sClient, error) {
+	if cluster.KubeconfigEnc != "" {
+		return NewK8sClientFromKubeconfig(cluster.KubeconfigEnc)
+	}
+	return NewK8sClientFromToken(cluster.APIServer, cluster.SATokenEnc, cluster.CAEnc)
+}
+
 // ValidateKubeconfig 验证kubeconfig格式
 func ValidateKubeconfig(kubeconfig string) error {
 	_, err := clientcmd.RESTConfigFromKubeConfig([]byte(kubeconfig))
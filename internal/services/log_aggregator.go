@@ -3,6 +3,8 @@ package services
 import (
 	"bufio"
 	"context"
+	"fmt"
+	"io"
 	"regexp"
 	"strings"
 	"sync"
@@ -14,17 +16,23 @@ import (
 	"github.com/google/uuid"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 )
 
+// logFoldFlushInterval 流式日志中堆栈折叠的等待时间，超过该时间没有续行则输出
+const logFoldFlushInterval = 500 * time.Millisecond
+
 // LogAggregator 日志聚合器
 type LogAggregator struct {
-	clusterSvc *ClusterService
+	clusterSvc   *ClusterService
+	parseRuleSvc *LogParseRuleService
 }
 
 // NewLogAggregator 创建日志聚合器
-func NewLogAggregator(clusterSvc *ClusterService) *LogAggregator {
+func NewLogAggregator(clusterSvc *ClusterService, parseRuleSvc *LogParseRuleService) *LogAggregator {
 	return &LogAggregator{
-		clusterSvc: clusterSvc,
+		clusterSvc:   clusterSvc,
+		parseRuleSvc: parseRuleSvc,
 	}
 }
 
@@ -35,6 +43,15 @@ func (a *LogAggregator) AggregateStream(
 	targets []models.LogStreamTarget,
 	opts *models.LogStreamOptions,
 ) (<-chan *models.LogEntry, error) {
+	var filters []LogFieldFilter
+	if opts != nil {
+		var err error
+		if filters, err = ParseLogFieldFilters(opts.Filters); err != nil {
+			return nil, err
+		}
+	}
+	parser := a.parseRuleSvc.ParserForCluster(cluster.ID)
+
 	outputCh := make(chan *models.LogEntry, 1000)
 	var wg sync.WaitGroup
 
@@ -43,7 +60,7 @@ func (a *LogAggregator) AggregateStream(
 		wg.Add(1)
 		go func(t models.LogStreamTarget) {
 			defer wg.Done()
-			a.streamPodLogs(ctx, cluster, t, opts, outputCh)
+			a.streamPodLogs(ctx, cluster, t, opts, parser, filters, outputCh)
 		}(target)
 	}
 
@@ -62,6 +79,8 @@ func (a *LogAggregator) streamPodLogs(
 	cluster *models.Cluster,
 	target models.LogStreamTarget,
 	opts *models.LogStreamOptions,
+	parser *LogParser,
+	filters []LogFieldFilter,
 	outputCh chan<- *models.LogEntry,
 ) {
 	// 创建K8s客户端
@@ -111,38 +130,90 @@ func (a *LogAggregator) streamPodLogs(
 		_ = stream.Close()
 	}()
 
-	reader := bufio.NewReader(stream)
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		default:
+	// 读取协程逐行读取，主循环负责堆栈折叠与超时输出
+	lines := make(chan string, 100)
+	go func() {
+		defer close(lines)
+		reader := bufio.NewReader(stream)
+		for {
 			line, err := reader.ReadString('\n')
+			if line != "" {
+				select {
+				case lines <- line:
+				case <-ctx.Done():
+					return
+				}
+			}
 			if err != nil {
 				// 检查是否是正常关闭
 				if ctx.Err() != nil {
 					return
 				}
-				if strings.Contains(err.Error(), "closed") ||
+				if err == io.EOF ||
+					strings.Contains(err.Error(), "closed") ||
 					strings.Contains(err.Error(), "canceled") {
 					return
 				}
 				logger.Error("读取日志失败", "pod", target.Pod, "error", err)
 				return
 			}
+		}
+	}()
+
+	rule := parser.RuleFor(target.Namespace, target.Pod)
+	folder := &stackTraceFolder{}
+	emit := func(entry *models.LogEntry) bool {
+		parser.Apply(entry, rule)
+		if !MatchLogFieldFilters(entry, filters) {
+			return true
+		}
+		select {
+		case outputCh <- entry:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
 
+	ticker := time.NewTicker(logFoldFlushInterval)
+	defer ticker.Stop()
+	lastLine := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case line, ok := <-lines:
+			if !ok {
+				if pending := folder.Flush(); pending != nil {
+					emit(pending)
+				}
+				return
+			}
+			lastLine = time.Now()
 			entry := a.parseLogLine(line, target, cluster)
-			select {
-			case outputCh <- entry:
-			case <-ctx.Done():
+			if rule.FoldStackTrace {
+				if entry = folder.Add(entry); entry == nil {
+					continue
+				}
+			}
+			if !emit(entry) {
+				return
+			}
+		case <-ticker.C:
+			if time.Since(lastLine) < logFoldFlushInterval {
+				continue
+			}
+			if pending := folder.Flush(); pending != nil && !emit(pending) {
 				return
 			}
 		}
 	}
 }
 
-// parseLogLine 解析日志行
+// parseLogLine 解析日志行的时间戳与原始消息，级别与结构化字段由 LogParser 解析
+// 保留消息开头的缩进，用于识别堆栈续行
 func (a *LogAggregator) parseLogLine(line string, target models.LogStreamTarget, cluster *models.Cluster) *models.LogEntry {
+	line = strings.TrimRight(line, " \t\r\n")
 	entry := &models.LogEntry{
 		ID:          uuid.New().String(),
 		Type:        "container",
@@ -151,7 +222,7 @@ func (a *LogAggregator) parseLogLine(line string, target models.LogStreamTarget,
 		Namespace:   target.Namespace,
 		PodName:     target.Pod,
 		Container:   target.Container,
-		Message:     strings.TrimSpace(line),
+		Message:     line,
 		Timestamp:   time.Now(),
 	}
 
@@ -159,19 +230,16 @@ func (a *LogAggregator) parseLogLine(line string, target models.LogStreamTarget,
 	if len(line) > 30 && line[10] == 'T' {
 		if t, err := time.Parse(time.RFC3339Nano, line[:30]); err == nil {
 			entry.Timestamp = t
-			entry.Message = strings.TrimSpace(line[31:])
+			entry.Message = line[31:]
 		} else if len(line) > 20 {
 			// 尝试其他时间格式
 			if t, err := time.Parse(time.RFC3339, line[:20]); err == nil {
 				entry.Timestamp = t
-				entry.Message = strings.TrimSpace(line[21:])
+				entry.Message = line[21:]
 			}
 		}
 	}
 
-	// 智能识别日志级别
-	entry.Level = a.detectLogLevel(entry.Message)
-
 	return entry
 }
 
@@ -259,7 +327,7 @@ func (a *LogAggregator) GetContainerLogs(
 	return string(logs), nil
 }
 
-// SearchLogs 搜索日志
+// SearchLogs 搜索日志（kubelet 后端，逐个容器读取日志后过滤）
 func (a *LogAggregator) SearchLogs(
 	ctx context.Context,
 	cluster *models.Cluster,
@@ -288,94 +356,288 @@ func (a *LogAggregator) SearchLogs(
 		}
 	}
 
-	// 确定要搜索的命名空间
-	namespaces := query.Namespaces
-	if len(namespaces) == 0 {
-		namespaces = []string{""} // 搜索所有命名空间
+	filters, err := ParseLogFieldFilters(query.Filters)
+	if err != nil {
+		return nil, 0, err
 	}
+	parser := a.parseRuleSvc.ParserForCluster(cluster.ID)
 
 	limit := query.Limit
 	if limit <= 0 {
 		limit = 100
 	}
+	// 需要跳过 offset 条，多收集一些
+	want := query.Offset + limit
+
+	// 遍历容器获取日志并搜索
+	for _, target := range listLogTargets(ctx, k8sClient.GetClientset(), query) {
+		// 获取日志
+		logOpts := &corev1.PodLogOptions{
+			Container:  target.Container,
+			Timestamps: true,
+		}
 
-	// 遍历命名空间获取日志并搜索
-	for _, ns := range namespaces {
-		pods, err := k8sClient.GetClientset().CoreV1().Pods(ns).List(ctx, metav1.ListOptions{})
+		tailLines := int64(want * 10) // 获取更多行以便过滤
+		logOpts.TailLines = &tailLines
+		if !query.StartTime.IsZero() {
+			logOpts.SinceTime = &metav1.Time{Time: query.StartTime}
+		}
+
+		logs, err := k8sClient.GetClientset().
+			CoreV1().
+			Pods(target.Namespace).
+			GetLogs(target.Pod, logOpts).
+			Do(ctx).
+			Raw()
 		if err != nil {
 			continue
 		}
 
-		for _, pod := range pods.Items {
-			// 检查Pod名称过滤
-			if len(query.Pods) > 0 && !contains(query.Pods, pod.Name) {
+		rule := parser.RuleFor(target.Namespace, target.Pod)
+		folder := &stackTraceFolder{}
+		// collect 解析并过滤一条（折叠后的）日志，收集够数量时返回 true
+		collect := func(entry *models.LogEntry) bool {
+			parser.Apply(entry, rule)
+			entry.NodeName = target.NodeName
+			if matchLogQuery(entry, query, regexPattern, filters) {
+				results = append(results, *entry)
+			}
+			return len(results) >= want
+		}
+
+		// 按行解析，堆栈续行合并到上一条日志后再匹配
+		lines := strings.Split(string(logs), "\n")
+		for _, line := range lines {
+			if line == "" {
 				continue
 			}
 
-			for _, container := range pod.Spec.Containers {
-				// 检查容器过滤
-				if len(query.Containers) > 0 && !contains(query.Containers, container.Name) {
+			entry := a.parseLogLine(line, target.LogStreamTarget, cluster)
+			if rule.FoldStackTrace {
+				if entry = folder.Add(entry); entry == nil {
 					continue
 				}
+			}
+			if collect(entry) {
+				return pageLogEntries(results, query.Offset, limit, query.Direction), len(results), nil
+			}
+		}
+		if pending := folder.Flush(); pending != nil && collect(pending) {
+			return pageLogEntries(results, query.Offset, limit, query.Direction), len(results), nil
+		}
+	}
 
-				// 获取日志
-				logOpts := &corev1.PodLogOptions{
-					Container:  container.Name,
-					Timestamps: true,
-				}
+	return pageLogEntries(results, query.Offset, limit, query.Direction), len(results), nil
+}
+
+// IterateLogs 逐个容器流式读取查询时间范围内的全部日志，按批次回调（用于导出）
+// 结果按容器分组而非全局按时间排序；断点续传时跳过 cursor.Count 条已导出的日志
+func (a *LogAggregator) IterateLogs(
+	ctx context.Context,
+	cluster *models.Cluster,
+	query *models.LogQuery,
+	cursor *LogExportCursor,
+	maxEntries int64,
+	fn func([]models.LogEntry) error,
+) (bool, error) {
+	k8sClient, err := NewK8sClientForCluster(cluster)
+	if err != nil {
+		return false, err
+	}
+	var regexPattern *regexp.Regexp
+	if query.Regex != "" {
+		if regexPattern, err = regexp.Compile(query.Regex); err != nil {
+			return false, err
+		}
+	}
+	filters, err := ParseLogFieldFilters(query.Filters)
+	if err != nil {
+		return false, err
+	}
+	parser := a.parseRuleSvc.ParserForCluster(cluster.ID)
+	start, end := logQueryWindow(query)
+	q := *query
+	q.EndTime = end
+
+	skip := cursor.Count
+	batch := make([]models.LogEntry, 0, logExportBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		cursor.Count += int64(len(batch))
+		cursor.Time = batch[len(batch)-1].Timestamp
+		if err := fn(batch); err != nil {
+			return err
+		}
+		batch = batch[:0]
+		return nil
+	}
+	errTruncated := fmt.Errorf("truncated")
+
+	for _, target := range listLogTargets(ctx, k8sClient.GetClientset(), query) {
+		stream, err := k8sClient.GetClientset().
+			CoreV1().
+			Pods(target.Namespace).
+			GetLogs(target.Pod, &corev1.PodLogOptions{
+				Container:  target.Container,
+				Timestamps: true,
+				SinceTime:  &metav1.Time{Time: start},
+			}).
+			Stream(ctx)
+		if err != nil {
+			logger.Warn("读取容器日志失败，跳过", "pod", target.Pod, "container", target.Container, "error", err)
+			continue
+		}
 
-				tailLines := int64(limit * 10) // 获取更多行以便过滤
-				logOpts.TailLines = &tailLines
+		rule := parser.RuleFor(target.Namespace, target.Pod)
+		folder := &stackTraceFolder{}
+		collect := func(entry *models.LogEntry) error {
+			parser.Apply(entry, rule)
+			entry.NodeName = target.NodeName
+			if !matchLogQuery(entry, &q, regexPattern, filters) {
+				return nil
+			}
+			if skip > 0 {
+				skip--
+				return nil
+			}
+			if maxEntries > 0 && cursor.Count+int64(len(batch)) >= maxEntries {
+				return errTruncated
+			}
+			batch = append(batch, *entry)
+			if len(batch) >= logExportBatchSize {
+				return flush()
+			}
+			return nil
+		}
 
-				logs, err := k8sClient.GetClientset().
-					CoreV1().
-					Pods(pod.Namespace).
-					GetLogs(pod.Name, logOpts).
-					Do(ctx).
-					Raw()
-				if err != nil {
+		reader := bufio.NewReader(stream)
+		var readErr error
+		for readErr == nil {
+			var line string
+			line, readErr = reader.ReadString('\n')
+			if line == "" {
+				continue
+			}
+			entry := a.parseLogLine(line, target.LogStreamTarget, cluster)
+			if rule.FoldStackTrace {
+				if entry = folder.Add(entry); entry == nil {
 					continue
 				}
+			}
+			if err = collect(entry); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			if pending := folder.Flush(); pending != nil {
+				err = collect(pending)
+			}
+		}
+		_ = stream.Close()
 
-				// 按行搜索
-				lines := strings.Split(string(logs), "\n")
-				for _, line := range lines {
-					if line == "" {
-						continue
-					}
+		if err == errTruncated {
+			return true, flush()
+		}
+		if err != nil {
+			return false, err
+		}
+		if readErr != nil && readErr != io.EOF {
+			return false, fmt.Errorf("读取 %s/%s 日志失败: %w", target.Pod, target.Container, readErr)
+		}
+	}
+	return false, flush()
+}
 
-					// 关键词匹配
-					if query.Keyword != "" && !strings.Contains(strings.ToLower(line), strings.ToLower(query.Keyword)) {
-						continue
-					}
+// logSearchTarget 待搜索的容器
+type logSearchTarget struct {
+	models.LogStreamTarget
+	NodeName string
+}
 
-					// 正则匹配
-					if regexPattern != nil && !regexPattern.MatchString(line) {
-						continue
-					}
+// listLogTargets 按查询条件列出待搜索的容器
+func listLogTargets(ctx context.Context, clientset kubernetes.Interface, query *models.LogQuery) []logSearchTarget {
+	// 确定要搜索的命名空间
+	namespaces := query.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{""} // 搜索所有命名空间
+	}
+
+	var targets []logSearchTarget
+	for _, ns := range namespaces {
+		pods, err := clientset.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			continue
+		}
+
+		for _, pod := range pods.Items {
+			// 检查Pod名称过滤
+			if len(query.Pods) > 0 && !contains(query.Pods, pod.Name) {
+				continue
+			}
+			if len(query.Nodes) > 0 && !contains(query.Nodes, pod.Spec.NodeName) {
+				continue
+			}
 
-					entry := a.parseLogLine(line, models.LogStreamTarget{
+			for _, container := range pod.Spec.Containers {
+				// 检查容器过滤
+				if len(query.Containers) > 0 && !contains(query.Containers, container.Name) {
+					continue
+				}
+				targets = append(targets, logSearchTarget{
+					LogStreamTarget: models.LogStreamTarget{
 						Namespace: pod.Namespace,
 						Pod:       pod.Name,
 						Container: container.Name,
-					}, cluster)
+					},
+					NodeName: pod.Spec.NodeName,
+				})
+			}
+		}
+	}
+	return targets
+}
 
-					// 日志级别过滤
-					if len(query.Levels) > 0 && !contains(query.Levels, entry.Level) {
-						continue
-					}
+// matchLogQuery 判断解析后的日志条目是否满足查询条件
+func matchLogQuery(entry *models.LogEntry, query *models.LogQuery, regexPattern *regexp.Regexp, filters []LogFieldFilter) bool {
+	// 关键词匹配
+	if query.Keyword != "" && !strings.Contains(strings.ToLower(entry.Message), strings.ToLower(query.Keyword)) {
+		return false
+	}
 
-					results = append(results, *entry)
+	// 正则匹配
+	if regexPattern != nil && !regexPattern.MatchString(entry.Message) {
+		return false
+	}
 
-					if len(results) >= limit {
-						return results, len(results), nil
-					}
-				}
-			}
-		}
+	// 时间范围过滤
+	if !query.EndTime.IsZero() && entry.Timestamp.After(query.EndTime) {
+		return false
+	}
+
+	// 日志级别过滤
+	if len(query.Levels) > 0 && !contains(query.Levels, entry.Level) {
+		return false
 	}
 
-	return results, len(results), nil
+	// 字段过滤
+	return MatchLogFieldFilters(entry, filters)
+}
+
+// pageLogEntries 按方向排序后分页；未指定方向时保持读取顺序
+func pageLogEntries(entries []models.LogEntry, offset, limit int, direction string) []models.LogEntry {
+	if direction != "" {
+		sortLogEntries(entries, direction)
+	}
+	if offset >= len(entries) {
+		return []models.LogEntry{}
+	}
+	entries = entries[offset:]
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries
 }
 
 // contains 检查切片是否包含某个元素
@@ -0,0 +1,696 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+	"github.com/clay-wangzhi/KubePolaris/pkg/logger"
+
+	"gorm.io/gorm"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// logAlertEvalInterval 日志告警评估周期，触发中的告警按该周期重复推送
+	logAlertEvalInterval = 15 * time.Second
+	// logAlertReconcileInterval 重新列出目标 Pod 的周期，新 Pod 与重启后的容器在该周期内接入
+	logAlertReconcileInterval = time.Minute
+	// logAlertBucketSeconds 滑动窗口计数的分桶粒度
+	logAlertBucketSeconds = 5
+	// logAlertSampleLength 告警注解中样例日志的最大长度
+	logAlertSampleLength = 500
+
+	logAlertMinWindow = time.Minute
+	logAlertMaxWindow = 6 * time.Hour
+)
+
+// LogAlertService 日志告警服务
+// 每条启用的规则由一个评估器跟随范围内容器的日志流，按滑动窗口统计匹配行数，
+// 超过阈值时通过 Alertmanager v2 API 推送告警，与 Prometheus 告警一起展示
+type LogAlertService struct {
+	db                    *gorm.DB
+	clusterService        *ClusterService
+	aggregator            *LogAggregator
+	alertManagerConfigSvc *AlertManagerConfigService
+	alertManagerSvc       *AlertManagerService
+
+	// clientFor 获取集群客户端，测试时可替换
+	clientFor func(cluster *models.Cluster) (kubernetes.Interface, error)
+
+	mu       sync.Mutex
+	ctx      context.Context
+	watchers map[uint]*logAlertWatcher
+}
+
+// NewLogAlertService 创建日志告警服务
+func NewLogAlertService(db *gorm.DB, clusterService *ClusterService, aggregator *LogAggregator, alertManagerConfigSvc *AlertManagerConfigService, alertManagerSvc *AlertManagerService) *LogAlertService {
+	return &LogAlertService{
+		db:                    db,
+		clusterService:        clusterService,
+		aggregator:            aggregator,
+		alertManagerConfigSvc: alertManagerConfigSvc,
+		alertManagerSvc:       alertManagerSvc,
+		clientFor: func(cluster *models.Cluster) (kubernetes.Interface, error) {
+			client, err := NewK8sClientForCluster(cluster)
+			if err != nil {
+				return nil, err
+			}
+			return client.GetClientset(), nil
+		},
+		watchers: make(map[uint]*logAlertWatcher),
+	}
+}
+
+// Start 为全部启用的规则启动评估器，ctx 取消后全部退出
+func (s *LogAlertService) Start(ctx context.Context) {
+	var rules []models.LogAlertRule
+	if err := s.db.Where("enabled = ?", true).Find(&rules).Error; err != nil {
+		logger.Error("加载日志告警规则失败", "error", err)
+	}
+
+	s.mu.Lock()
+	s.ctx = ctx
+	s.mu.Unlock()
+	for i := range rules {
+		s.restart(&rules[i])
+	}
+	logger.Info("日志告警评估器已启动", "rules", len(rules))
+}
+
+// ListRules 获取集群的日志告警规则（含评估器实时状态）
+func (s *LogAlertService) ListRules(clusterID uint) ([]models.LogAlertRule, error) {
+	var rules []models.LogAlertRule
+	if err := s.db.Where("cluster_id = ?", clusterID).Order("id ASC").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("查询日志告警规则失败: %w", err)
+	}
+	for i := range rules {
+		s.fillStatus(&rules[i])
+	}
+	return rules, nil
+}
+
+// GetRule 获取日志告警规则
+func (s *LogAlertService) GetRule(clusterID, ruleID uint) (*models.LogAlertRule, error) {
+	var rule models.LogAlertRule
+	if err := s.db.Where("cluster_id = ?", clusterID).First(&rule, ruleID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("日志告警规则不存在: %d", ruleID)
+		}
+		return nil, fmt.Errorf("查询日志告警规则失败: %w", err)
+	}
+	s.fillStatus(&rule)
+	return &rule, nil
+}
+
+// SaveRule 创建或更新日志告警规则，ruleID 为 0 时创建；保存后重启该规则的评估器
+func (s *LogAlertService) SaveRule(clusterID, ruleID uint, req *models.LogAlertRuleRequest, userID uint) (*models.LogAlertRule, error) {
+	rule := &models.LogAlertRule{ClusterID: clusterID, CreatedBy: userID, State: models.LogAlertStateInactive}
+	if ruleID != 0 {
+		existing, err := s.GetRule(clusterID, ruleID)
+		if err != nil {
+			return nil, err
+		}
+		rule = existing
+	}
+
+	rule.Name = strings.TrimSpace(req.Name)
+	rule.Description = req.Description
+	rule.Enabled = req.Enabled
+	rule.Namespace = strings.TrimSpace(req.Namespace)
+	rule.LabelSelector = strings.TrimSpace(req.LabelSelector)
+	rule.Container = strings.TrimSpace(req.Container)
+	rule.MatchType = req.MatchType
+	rule.Pattern = req.Pattern
+	rule.WindowSeconds = req.WindowSeconds
+	rule.Threshold = req.Threshold
+	rule.Severity = req.Severity
+	rule.Filters = ""
+	if len(req.Filters) > 0 {
+		rule.Filters = jsonString(req.Filters)
+	}
+	rule.Labels = ""
+	if len(req.Labels) > 0 {
+		rule.Labels = jsonString(req.Labels)
+	}
+	if rule.MatchType == "" {
+		rule.MatchType = models.LogAlertMatchKeyword
+	}
+	if rule.WindowSeconds <= 0 {
+		rule.WindowSeconds = int(5 * time.Minute / time.Second)
+	}
+	if rule.Severity == "" {
+		rule.Severity = "warning"
+	}
+	if err := ValidateLogAlertRule(rule); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Save(rule).Error; err != nil {
+		return nil, fmt.Errorf("保存日志告警规则失败: %w", err)
+	}
+	s.restart(rule)
+	return rule, nil
+}
+
+// DeleteRule 删除日志告警规则，触发中的告警立即恢复
+func (s *LogAlertService) DeleteRule(clusterID, ruleID uint) error {
+	rule, err := s.GetRule(clusterID, ruleID)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Delete(rule).Error; err != nil {
+		return fmt.Errorf("删除日志告警规则失败: %w", err)
+	}
+	rule.Enabled = false
+	s.restart(rule)
+	return nil
+}
+
+// ValidateLogAlertRule 验证日志告警规则
+func ValidateLogAlertRule(rule *models.LogAlertRule) error {
+	if rule.Name == "" {
+		return fmt.Errorf("规则名称不能为空")
+	}
+	if rule.Namespace == "" {
+		return fmt.Errorf("命名空间不能为空")
+	}
+	if rule.LabelSelector != "" {
+		if _, err := labels.Parse(rule.LabelSelector); err != nil {
+			return fmt.Errorf("标签选择器错误: %w", err)
+		}
+	}
+	if _, err := newLogAlertMatcher(rule); err != nil {
+		return err
+	}
+	if _, err := ParseLogFieldFilters(logAlertFilters(rule)); err != nil {
+		return err
+	}
+	window := time.Duration(rule.WindowSeconds) * time.Second
+	if window < logAlertMinWindow || window > logAlertMaxWindow {
+		return fmt.Errorf("时间窗口需在 %s 到 %s 之间", logAlertMinWindow, logAlertMaxWindow)
+	}
+	if rule.Threshold < 0 {
+		return fmt.Errorf("阈值不能为负数")
+	}
+	if rule.Labels != "" {
+		var extra map[string]string
+		if err := json.Unmarshal([]byte(rule.Labels), &extra); err != nil {
+			return fmt.Errorf("附加标签格式错误: %w", err)
+		}
+		for name := range extra {
+			if name == "alertname" || name == "source" || name == "log_rule_id" {
+				return fmt.Errorf("附加标签不能覆盖内置标签 %s", name)
+			}
+		}
+	}
+	return nil
+}
+
+// restart 停止规则当前的评估器，规则仍启用时以新配置重新启动
+// 停止时若告警的标签发生变化（或规则已停用/删除），先恢复旧告警，避免其在 Alertmanager 中残留到超时
+func (s *LogAlertService) restart(rule *models.LogAlertRule) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var next *logAlertWatcher
+	if s.ctx != nil && rule.Enabled {
+		cluster, err := s.clusterService.GetCluster(rule.ClusterID)
+		if err != nil {
+			s.recordStartError(rule.ID, fmt.Sprintf("获取集群失败: %v", err))
+		} else if next, err = newLogAlertWatcher(rule, cluster); err != nil {
+			s.recordStartError(rule.ID, err.Error())
+		}
+	}
+
+	if prev := s.watchers[rule.ID]; prev != nil {
+		prev.cancel()
+		delete(s.watchers, rule.ID)
+		if alert := prev.resolvedAlert(time.Now()); alert != nil && (next == nil || !sameLabels(alert.Labels, next.alertLabels())) {
+			go s.push(prev, []models.PostableAlert{*alert})
+		}
+	}
+	if next == nil {
+		if !rule.Enabled && rule.State == models.LogAlertStateFiring {
+			s.persist(rule.ID, map[string]interface{}{"state": models.LogAlertStateInactive, "firing_since": nil})
+		}
+		return
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	next.cancel = cancel
+	s.watchers[rule.ID] = next
+	go s.run(ctx, next)
+}
+
+// run 评估器主循环
+func (s *LogAlertService) run(ctx context.Context, w *logAlertWatcher) {
+	evalTicker := time.NewTicker(logAlertEvalInterval)
+	defer evalTicker.Stop()
+	reconcileTicker := time.NewTicker(logAlertReconcileInterval)
+	defer reconcileTicker.Stop()
+
+	s.reconcileTargets(ctx, w)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-reconcileTicker.C:
+			s.reconcileTargets(ctx, w)
+		case now := <-evalTicker.C:
+			s.evaluate(ctx, w, now)
+		}
+	}
+}
+
+// reconcileTargets 列出范围内的容器，为尚未跟随的容器启动日志流
+func (s *LogAlertService) reconcileTargets(ctx context.Context, w *logAlertWatcher) {
+	clientset, err := s.clientFor(w.cluster)
+	if err != nil {
+		w.setError(fmt.Sprintf("创建K8s客户端失败: %v", err))
+		return
+	}
+	pods, err := clientset.CoreV1().Pods(w.rule.Namespace).List(ctx, metav1.ListOptions{LabelSelector: w.rule.LabelSelector})
+	if err != nil {
+		w.setError(fmt.Sprintf("获取 Pod 列表失败: %v", err))
+		return
+	}
+
+	var targets []models.LogStreamTarget
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		for _, container := range pod.Spec.Containers {
+			if w.rule.Container != "" && container.Name != w.rule.Container {
+				continue
+			}
+			targets = append(targets, models.LogStreamTarget{Namespace: pod.Namespace, Pod: pod.Name, Container: container.Name})
+		}
+	}
+
+	for _, target := range w.track(targets) {
+		s.follow(ctx, w, target)
+	}
+}
+
+// follow 跟随单个容器的日志流，流结束（容器重启、Pod 删除）后由下一轮 reconcile 重新接入
+func (s *LogAlertService) follow(ctx context.Context, w *logAlertWatcher, target models.LogStreamTarget) {
+	key := logAlertTargetKey(target)
+	streamCtx, cancel := context.WithCancel(ctx)
+	entries, err := s.aggregator.AggregateStream(streamCtx, w.cluster, []models.LogStreamTarget{target}, &models.LogStreamOptions{
+		SinceSeconds: w.sinceSeconds(key, time.Now()),
+		Filters:      logAlertFilters(&w.rule),
+	})
+	if err != nil {
+		cancel()
+		w.untrack(key)
+		w.setError(err.Error())
+		return
+	}
+
+	go func() {
+		defer func() {
+			cancel()
+			w.untrack(key)
+		}()
+		for entry := range entries {
+			w.observe(key, entry)
+		}
+	}()
+}
+
+// evaluate 评估规则并推送告警，状态变化时持久化
+func (s *LogAlertService) evaluate(ctx context.Context, w *logAlertWatcher, now time.Time) {
+	alert, changed := w.evaluate(now)
+	if changed {
+		w.mu.Lock()
+		updates := map[string]interface{}{"state": w.state, "firing_since": w.firingSince}
+		if w.state == models.LogAlertStateInactive {
+			updates["last_resolved_at"] = now
+		}
+		w.mu.Unlock()
+		s.persist(w.rule.ID, updates)
+		logger.Info("日志告警状态变化", "rule", w.rule.Name, "cluster", w.cluster.Name, "state", updates["state"])
+	}
+	if alert == nil {
+		return
+	}
+
+	pushCtx, cancel := context.WithTimeout(ctx, logAlertEvalInterval)
+	defer cancel()
+	s.pushWithContext(pushCtx, w, []models.PostableAlert{*alert})
+}
+
+// push 推送告警（用于评估器已停止后的恢复通知）
+func (s *LogAlertService) push(w *logAlertWatcher, alerts []models.PostableAlert) {
+	ctx, cancel := context.WithTimeout(context.Background(), logAlertEvalInterval)
+	defer cancel()
+	s.pushWithContext(ctx, w, alerts)
+}
+
+// pushWithContext 推送告警到集群的 Alertmanager，推送结果记录到规则的 last_error
+func (s *LogAlertService) pushWithContext(ctx context.Context, w *logAlertWatcher, alerts []models.PostableAlert) {
+	config, err := s.alertManagerConfigSvc.GetAlertManagerConfig(w.rule.ClusterID)
+	if err == nil {
+		err = s.alertManagerSvc.PostAlerts(ctx, config, alerts)
+	}
+	message := ""
+	if err != nil {
+		message = err.Error()
+		logger.Error("推送日志告警失败", "rule", w.rule.Name, "cluster", w.cluster.Name, "error", err)
+	}
+	if w.setError(message) {
+		s.persist(w.rule.ID, map[string]interface{}{"last_error": message})
+	}
+}
+
+// persist 更新规则的运行状态
+func (s *LogAlertService) persist(ruleID uint, updates map[string]interface{}) {
+	if err := s.db.Model(&models.LogAlertRule{}).Where("id = ?", ruleID).Updates(updates).Error; err != nil {
+		logger.Error("更新日志告警规则状态失败", "rule_id", ruleID, "error", err)
+	}
+}
+
+// recordStartError 记录评估器启动失败原因
+func (s *LogAlertService) recordStartError(ruleID uint, message string) {
+	logger.Error("启动日志告警评估器失败", "rule_id", ruleID, "error", message)
+	s.persist(ruleID, map[string]interface{}{"last_error": message})
+}
+
+// fillStatus 填充评估器的实时状态
+func (s *LogAlertService) fillStatus(rule *models.LogAlertRule) {
+	s.mu.Lock()
+	w := s.watchers[rule.ID]
+	s.mu.Unlock()
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	rule.CurrentCount = w.count
+	rule.WatchedTargets = len(w.streams)
+	if !w.evaluatedAt.IsZero() {
+		evaluatedAt := w.evaluatedAt
+		rule.LastEvaluatedAt = &evaluatedAt
+	}
+}
+
+// logAlertWatcher 单条规则的评估器状态
+type logAlertWatcher struct {
+	rule    models.LogAlertRule
+	cluster *models.Cluster
+	matcher *logAlertMatcher
+	counter *logAlertCounter
+	cancel  context.CancelFunc
+
+	mu          sync.Mutex
+	streams     map[string]bool      // 正在跟随的容器
+	lastSeen    map[string]time.Time // 各容器最后一条日志的时间，重新接入时跳过已统计的日志
+	sample      string               // 最近一条匹配的日志
+	count       int
+	evaluatedAt time.Time
+	state       string
+	firingSince *time.Time
+	lastError   string
+}
+
+// newLogAlertWatcher 创建评估器，沿用规则持久化的触发状态
+func newLogAlertWatcher(rule *models.LogAlertRule, cluster *models.Cluster) (*logAlertWatcher, error) {
+	matcher, err := newLogAlertMatcher(rule)
+	if err != nil {
+		return nil, err
+	}
+	w := &logAlertWatcher{
+		rule:        *rule,
+		cluster:     cluster,
+		matcher:     matcher,
+		counter:     newLogAlertCounter(time.Duration(rule.WindowSeconds) * time.Second),
+		streams:     make(map[string]bool),
+		lastSeen:    make(map[string]time.Time),
+		state:       rule.State,
+		firingSince: rule.FiringSince,
+		lastError:   rule.LastError,
+	}
+	if w.state == "" {
+		w.state = models.LogAlertStateInactive
+	}
+	return w, nil
+}
+
+// track 记录当前范围内的容器，返回需要新启动日志流的容器，并清理已消失容器的进度
+func (w *logAlertWatcher) track(targets []models.LogStreamTarget) []models.LogStreamTarget {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	current := make(map[string]bool, len(targets))
+	var added []models.LogStreamTarget
+	for _, target := range targets {
+		key := logAlertTargetKey(target)
+		current[key] = true
+		if !w.streams[key] {
+			w.streams[key] = true
+			added = append(added, target)
+		}
+	}
+	for key := range w.lastSeen {
+		if !current[key] {
+			delete(w.lastSeen, key)
+		}
+	}
+	return added
+}
+
+// untrack 容器日志流结束
+func (w *logAlertWatcher) untrack(key string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.streams, key)
+}
+
+// sinceSeconds 日志流的起始位置：首次接入回溯一个窗口，重新接入时从上次读到的位置继续
+func (w *logAlertWatcher) sinceSeconds(key string, now time.Time) int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	since := int64(w.rule.WindowSeconds)
+	if last, ok := w.lastSeen[key]; ok {
+		if elapsed := int64(now.Sub(last)/time.Second) + 1; elapsed < since {
+			since = elapsed
+		}
+	}
+	return since
+}
+
+// observe 统计一条日志
+func (w *logAlertWatcher) observe(key string, entry *models.LogEntry) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if last, ok := w.lastSeen[key]; ok && !entry.Timestamp.After(last) {
+		return
+	}
+	w.lastSeen[key] = entry.Timestamp
+	if !w.matcher.Match(entry.Message) {
+		return
+	}
+	w.counter.Add(entry.Timestamp)
+	w.sample = entry.Message
+}
+
+// evaluate 统计窗口内的匹配行数并推进状态
+// 返回需要推送的告警（触发中重复推送，恢复时推送一次）以及状态是否变化
+func (w *logAlertWatcher) evaluate(now time.Time) (*models.PostableAlert, bool) {
+	count := w.counter.Count(now)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.count = count
+	w.evaluatedAt = now
+
+	firing := count > w.rule.Threshold
+	switch {
+	case firing && w.state != models.LogAlertStateFiring:
+		since := now
+		w.state = models.LogAlertStateFiring
+		w.firingSince = &since
+		return w.alertLocked(now), true
+	case firing:
+		return w.alertLocked(now), false
+	case w.state == models.LogAlertStateFiring:
+		alert := w.alertLocked(now)
+		alert.EndsAt = now
+		w.state = models.LogAlertStateInactive
+		w.firingSince = nil
+		return alert, true
+	}
+	return nil, false
+}
+
+// resolvedAlert 评估器停止时用于恢复的告警，未触发时返回 nil
+func (w *logAlertWatcher) resolvedAlert(now time.Time) *models.PostableAlert {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.state != models.LogAlertStateFiring {
+		return nil
+	}
+	alert := w.alertLocked(now)
+	alert.EndsAt = now
+	return alert
+}
+
+// alertLocked 构建告警；EndsAt 设为若干个评估周期之后，评估器异常退出时告警会自动恢复
+func (w *logAlertWatcher) alertLocked(now time.Time) *models.PostableAlert {
+	startsAt := now
+	if w.firingSince != nil {
+		startsAt = *w.firingSince
+	}
+	sample := w.sample
+	if len(sample) > logAlertSampleLength {
+		sample = sample[:logAlertSampleLength] + "..."
+	}
+	window := time.Duration(w.rule.WindowSeconds) * time.Second
+	return &models.PostableAlert{
+		Labels: w.alertLabels(),
+		Annotations: map[string]string{
+			"summary":     fmt.Sprintf("%s: %s 内匹配 %d 行日志，超过阈值 %d", w.rule.Name, window, w.count, w.rule.Threshold),
+			"description": w.rule.Description,
+			"pattern":     w.rule.Pattern,
+			"count":       strconv.Itoa(w.count),
+			"sample":      sample,
+		},
+		StartsAt: startsAt,
+		EndsAt:   now.Add(4 * logAlertEvalInterval),
+	}
+}
+
+// alertLabels 告警标签：内置标签加规则附加标签
+func (w *logAlertWatcher) alertLabels() map[string]string {
+	result := make(map[string]string)
+	if w.rule.Labels != "" {
+		_ = json.Unmarshal([]byte(w.rule.Labels), &result)
+	}
+	result["alertname"] = w.rule.Name
+	result["severity"] = w.rule.Severity
+	result["namespace"] = w.rule.Namespace
+	result["cluster"] = w.cluster.Name
+	result["source"] = models.LogAlertSourceLabel
+	result["log_rule_id"] = strconv.FormatUint(uint64(w.rule.ID), 10)
+	if w.rule.Container != "" {
+		result["container"] = w.rule.Container
+	}
+	return result
+}
+
+// setError 更新评估器错误信息，返回是否变化
+func (w *logAlertWatcher) setError(message string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.lastError == message {
+		return false
+	}
+	w.lastError = message
+	return true
+}
+
+// logAlertMatcher 日志告警匹配器
+type logAlertMatcher struct {
+	keyword string
+	pattern *regexp.Regexp
+}
+
+// newLogAlertMatcher 根据规则创建匹配器
+func newLogAlertMatcher(rule *models.LogAlertRule) (*logAlertMatcher, error) {
+	if rule.Pattern == "" {
+		return nil, fmt.Errorf("匹配内容不能为空")
+	}
+	switch rule.MatchType {
+	case models.LogAlertMatchKeyword, "":
+		return &logAlertMatcher{keyword: strings.ToLower(rule.Pattern)}, nil
+	case models.LogAlertMatchRegex:
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("正则表达式错误: %w", err)
+		}
+		return &logAlertMatcher{pattern: pattern}, nil
+	default:
+		return nil, fmt.Errorf("不支持的匹配方式: %s", rule.MatchType)
+	}
+}
+
+// Match 判断日志是否匹配
+func (m *logAlertMatcher) Match(message string) bool {
+	if m.pattern != nil {
+		return m.pattern.MatchString(message)
+	}
+	return strings.Contains(strings.ToLower(message), m.keyword)
+}
+
+// logAlertCounter 按 logAlertBucketSeconds 分桶的滑动窗口计数器
+type logAlertCounter struct {
+	mu      sync.Mutex
+	window  time.Duration
+	buckets map[int64]int
+}
+
+// newLogAlertCounter 创建滑动窗口计数器
+func newLogAlertCounter(window time.Duration) *logAlertCounter {
+	return &logAlertCounter{window: window, buckets: make(map[int64]int)}
+}
+
+// Add 记录一次匹配
+func (c *logAlertCounter) Add(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.buckets[t.Unix()/logAlertBucketSeconds]++
+}
+
+// Count 统计 now 之前一个窗口内的匹配次数，并清理窗口外的分桶
+func (c *logAlertCounter) Count(now time.Time) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	oldest := now.Add(-c.window).Unix() / logAlertBucketSeconds
+	total := 0
+	for bucket, n := range c.buckets {
+		if bucket < oldest {
+			delete(c.buckets, bucket)
+			continue
+		}
+		total += n
+	}
+	return total
+}
+
+// logAlertFilters 解析规则的字段过滤表达式
+func logAlertFilters(rule *models.LogAlertRule) []string {
+	var filters []string
+	if rule.Filters != "" {
+		_ = json.Unmarshal([]byte(rule.Filters), &filters)
+	}
+	return filters
+}
+
+// logAlertTargetKey 容器唯一键
+func logAlertTargetKey(target models.LogStreamTarget) string {
+	return target.Namespace + "/" + target.Pod + "/" + target.Container
+}
+
+// sameLabels 判断两组标签是否相同
+func sameLabels(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if bv, ok := b[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// LogAlertServiceTestSuite 定义日志告警测试套件
+type LogAlertServiceTestSuite struct {
+	suite.Suite
+	now time.Time
+}
+
+// SetupTest 每个测试前的设置
+func (s *LogAlertServiceTestSuite) SetupTest() {
+	s.now = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+}
+
+func (s *LogAlertServiceTestSuite) newWatcher(rule *models.LogAlertRule) *logAlertWatcher {
+	w, err := newLogAlertWatcher(rule, &models.Cluster{Name: "prod"})
+	s.Require().NoError(err)
+	return w
+}
+
+// TestCounterWindow 测试滑动窗口计数与过期清理
+func (s *LogAlertServiceTestSuite) TestCounterWindow() {
+	counter := newLogAlertCounter(5 * time.Minute)
+	counter.Add(s.now.Add(-10 * time.Minute))
+	counter.Add(s.now.Add(-4 * time.Minute))
+	counter.Add(s.now.Add(-time.Second))
+	counter.Add(s.now.Add(-time.Second))
+	assert.Equal(s.T(), 3, counter.Count(s.now))
+	assert.Len(s.T(), counter.buckets, 2)
+	assert.Equal(s.T(), 2, counter.Count(s.now.Add(2*time.Minute)))
+}
+
+// TestEvaluateTransitions 测试触发、持续与恢复
+func (s *LogAlertServiceTestSuite) TestEvaluateTransitions() {
+	w := s.newWatcher(&models.LogAlertRule{
+		ID:            7,
+		Name:          "PaymentsOOM",
+		Namespace:     "payments",
+		MatchType:     models.LogAlertMatchKeyword,
+		Pattern:       "OutOfMemoryError",
+		WindowSeconds: 300,
+		Threshold:     2,
+		Severity:      "critical",
+		Labels:        `{"team":"payments"}`,
+	})
+
+	key := "payments/api-0/app"
+	for i, msg := range []string{"java.lang.OutOfMemoryError: heap", "ok", "outofmemoryerror again", "OutOfMemoryError: metaspace"} {
+		w.observe(key, &models.LogEntry{Timestamp: s.now.Add(time.Duration(i-10) * time.Second), Message: msg})
+	}
+	// 重新接入日志流时重复读到的日志不计数
+	w.observe(key, &models.LogEntry{Timestamp: s.now.Add(-10 * time.Second), Message: "OutOfMemoryError"})
+
+	alert, changed := w.evaluate(s.now)
+	s.Require().NotNil(alert)
+	assert.True(s.T(), changed)
+	assert.Equal(s.T(), models.LogAlertStateFiring, w.state)
+	assert.Equal(s.T(), "PaymentsOOM", alert.Labels["alertname"])
+	assert.Equal(s.T(), models.LogAlertSourceLabel, alert.Labels["source"])
+	assert.Equal(s.T(), "payments", alert.Labels["team"])
+	assert.Equal(s.T(), "7", alert.Labels["log_rule_id"])
+	assert.Equal(s.T(), "3", alert.Annotations["count"])
+	assert.Equal(s.T(), "OutOfMemoryError: metaspace", alert.Annotations["sample"])
+	assert.Equal(s.T(), s.now, alert.StartsAt)
+	assert.True(s.T(), alert.EndsAt.After(s.now))
+
+	// 持续触发：重复推送，开始时间不变
+	alert, changed = w.evaluate(s.now.Add(logAlertEvalInterval))
+	s.Require().NotNil(alert)
+	assert.False(s.T(), changed)
+	assert.Equal(s.T(), s.now, alert.StartsAt)
+
+	// 窗口滑过后恢复：推送一次 EndsAt=当前时间的告警
+	later := s.now.Add(10 * time.Minute)
+	alert, changed = w.evaluate(later)
+	s.Require().NotNil(alert)
+	assert.True(s.T(), changed)
+	assert.Equal(s.T(), later, alert.EndsAt)
+	assert.Equal(s.T(), models.LogAlertStateInactive, w.state)
+
+	alert, changed = w.evaluate(later.Add(logAlertEvalInterval))
+	assert.Nil(s.T(), alert)
+	assert.False(s.T(), changed)
+}
+
+// TestValidateRule 测试规则校验
+func (s *LogAlertServiceTestSuite) TestValidateRule() {
+	rule := &models.LogAlertRule{Name: "r", Namespace: "payments", MatchType: models.LogAlertMatchRegex, Pattern: `Out[Oo]f`, WindowSeconds: 300}
+	assert.NoError(s.T(), ValidateLogAlertRule(rule))
+
+	invalid := []func(r *models.LogAlertRule){
+		func(r *models.LogAlertRule) { r.Pattern = "(" },
+		func(r *models.LogAlertRule) { r.WindowSeconds = 10 },
+		func(r *models.LogAlertRule) { r.LabelSelector = "app in (" },
+		func(r *models.LogAlertRule) { r.Filters = `["status>=500"]` },
+		func(r *models.LogAlertRule) { r.Labels = `{"alertname":"x"}` },
+	}
+	for _, mutate := range invalid {
+		r := *rule
+		mutate(&r)
+		assert.Error(s.T(), ValidateLogAlertRule(&r))
+	}
+}
+
+// TestPostAlerts 测试推送告警到 Alertmanager v2 API
+func (s *LogAlertServiceTestSuite) TestPostAlerts() {
+	var received []models.PostableAlert
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(s.T(), http.MethodPost, r.Method)
+		assert.Equal(s.T(), "/api/v2/alerts", r.URL.Path)
+		assert.NoError(s.T(), json.NewDecoder(r.Body).Decode(&received))
+	}))
+	defer server.Close()
+
+	err := NewAlertManagerService().PostAlerts(context.Background(), &models.AlertManagerConfig{Enabled: true, Endpoint: server.URL}, []models.PostableAlert{
+		{Labels: map[string]string{"alertname": "PaymentsOOM"}, StartsAt: s.now, EndsAt: s.now.Add(time.Minute)},
+	})
+	s.Require().NoError(err)
+	s.Require().Len(received, 1)
+	assert.Equal(s.T(), "PaymentsOOM", received[0].Labels["alertname"])
+}
+
+// TestLogAlertServiceSuite 运行测试套件
+func TestLogAlertServiceSuite(t *testing.T) {
+	suite.Run(t, new(LogAlertServiceTestSuite))
+}
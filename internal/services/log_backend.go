@@ -0,0 +1,254 @@
+package services
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// LogBackend 日志查询后端
+type LogBackend interface {
+	// Search 按查询条件检索日志，返回当前页日志与命中总数
+	Search(ctx context.Context, query *models.LogQuery) ([]models.LogEntry, int, error)
+	// Stats 统计查询时间范围内的日志分布
+	Stats(ctx context.Context, query *models.LogQuery) (*models.LogStats, error)
+	// Test 测试后端连通性
+	Test(ctx context.Context) error
+}
+
+// 默认查询参数
+const (
+	defaultLogSearchLimit = 100
+	defaultLogTimeRange   = time.Hour
+	logStatsBuckets       = 60 // 时间分布的分桶数
+)
+
+// levelKeywords 日志级别对应的关键词（与 detectLogLevel 保持一致）
+var levelKeywords = map[string][]string{
+	"error": {"error", "err", "fail", "fatal", "exception", "panic", "critical"},
+	"warn":  {"warn", "warning", "caution"},
+	"debug": {"debug", "trace", "verbose"},
+}
+
+// NewLogBackend 根据集群日志配置创建日志后端，未配置时使用 kubelet 后端
+func NewLogBackend(cluster *models.Cluster, config *models.LogBackendConfig, aggregator *LogAggregator) (LogBackend, error) {
+	if config == nil || config.Type == "" || config.Type == models.LogBackendKubelet {
+		return NewKubeletLogBackend(cluster, aggregator), nil
+	}
+	switch config.Type {
+	case models.LogBackendLoki:
+		return NewLokiLogBackend(cluster, config), nil
+	case models.LogBackendElasticsearch:
+		return NewElasticsearchLogBackend(cluster, config), nil
+	default:
+		return nil, fmt.Errorf("不支持的日志后端类型: %s", config.Type)
+	}
+}
+
+// KubeletLogBackend 直接通过 API Server 读取容器日志，只能看到仍存在的 Pod
+type KubeletLogBackend struct {
+	cluster    *models.Cluster
+	aggregator *LogAggregator
+}
+
+// NewKubeletLogBackend 创建 kubelet 日志后端
+func NewKubeletLogBackend(cluster *models.Cluster, aggregator *LogAggregator) *KubeletLogBackend {
+	return &KubeletLogBackend{cluster: cluster, aggregator: aggregator}
+}
+
+// Search 扫描 Pod 容器日志
+func (b *KubeletLogBackend) Search(ctx context.Context, query *models.LogQuery) ([]models.LogEntry, int, error) {
+	return b.aggregator.SearchLogs(ctx, b.cluster, query)
+}
+
+// Stats kubelet 没有日志索引，使用 K8s 事件近似统计
+func (b *KubeletLogBackend) Stats(ctx context.Context, query *models.LogQuery) (*models.LogStats, error) {
+	k8sClient, err := NewK8sClientForCluster(b.cluster)
+	if err != nil {
+		return nil, fmt.Errorf("创建K8s客户端失败: %w", err)
+	}
+
+	namespace := ""
+	if len(query.Namespaces) == 1 {
+		namespace = query.Namespaces[0]
+	}
+	events, err := k8sClient.GetClientset().CoreV1().Events(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取事件失败: %w", err)
+	}
+
+	stats := &models.LogStats{}
+	levelCount := make(map[string]int64)
+	nsCount := make(map[string]int64)
+
+	for _, e := range events.Items {
+		// 过滤时间范围
+		if e.LastTimestamp.Time.Before(query.StartTime) {
+			continue
+		}
+		if len(query.Namespaces) > 1 && !contains(query.Namespaces, e.Namespace) {
+			continue
+		}
+
+		stats.TotalCount++
+
+		if e.Type == "Warning" {
+			stats.WarnCount++
+			levelCount["warn"]++
+		} else {
+			stats.InfoCount++
+			levelCount["info"]++
+		}
+
+		// 检查是否包含错误关键词
+		lowerMsg := strings.ToLower(e.Message)
+		if strings.Contains(lowerMsg, "error") ||
+			strings.Contains(lowerMsg, "fail") ||
+			strings.Contains(lowerMsg, "crash") {
+			stats.ErrorCount++
+			levelCount["error"]++
+		}
+
+		nsCount[e.Namespace]++
+	}
+
+	for level, count := range levelCount {
+		stats.LevelStats = append(stats.LevelStats, models.LevelStat{Level: level, Count: count})
+	}
+	for ns, count := range nsCount {
+		stats.NamespaceStats = append(stats.NamespaceStats, models.NamespaceStat{Namespace: ns, Count: count})
+	}
+	sortLogStats(stats)
+	return stats, nil
+}
+
+// iterate kubelet 后端逐个容器流式读取全部日志
+func (b *KubeletLogBackend) iterate(ctx context.Context, query *models.LogQuery, cursor *LogExportCursor, maxEntries int64, fn func([]models.LogEntry) error) (bool, error) {
+	return b.aggregator.IterateLogs(ctx, b.cluster, query, cursor, maxEntries, fn)
+}
+
+// Test kubelet 后端无需额外连接
+func (b *KubeletLogBackend) Test(ctx context.Context) error {
+	return nil
+}
+
+// newLogBackendHTTPClient 创建日志后端 HTTP 客户端
+func newLogBackendHTTPClient() *http.Client {
+	return &http.Client{
+		Timeout: 60 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: true, // 与监控数据源保持一致
+			},
+		},
+	}
+}
+
+// setLogBackendAuth 设置日志后端认证
+func setLogBackendAuth(req *http.Request, auth *models.MonitoringAuth) error {
+	if auth == nil {
+		return nil
+	}
+	switch auth.Type {
+	case "", "none":
+		return nil
+	case "basic":
+		req.SetBasicAuth(auth.Username, auth.Password)
+	case "bearer":
+		req.Header.Set("Authorization", "Bearer "+auth.Token)
+	case "apikey":
+		// Elasticsearch API Key
+		req.Header.Set("Authorization", "ApiKey "+auth.Token)
+	default:
+		return fmt.Errorf("不支持的认证类型: %s", auth.Type)
+	}
+	return nil
+}
+
+// logQueryWindow 返回查询的时间范围，未指定时默认最近 1 小时
+func logQueryWindow(query *models.LogQuery) (time.Time, time.Time) {
+	end := query.EndTime
+	if end.IsZero() {
+		end = time.Now()
+	}
+	start := query.StartTime
+	if start.IsZero() || !start.Before(end) {
+		start = end.Add(-defaultLogTimeRange)
+	}
+	return start, end
+}
+
+// logQueryLimit 返回分页大小
+func logQueryLimit(query *models.LogQuery) int {
+	if query.Limit <= 0 {
+		return defaultLogSearchLimit
+	}
+	return query.Limit
+}
+
+// logStatsStep 按时间范围计算时间分布的步长（至少 1 分钟）
+func logStatsStep(start, end time.Time) time.Duration {
+	step := end.Sub(start) / logStatsBuckets
+	if step < time.Minute {
+		step = time.Minute
+	}
+	return step.Truncate(time.Second)
+}
+
+// levelFilterKeywords 返回按级别过滤时可下推的关键词；包含 info 时无法用关键词表达，返回空
+func levelFilterKeywords(levels []string) []string {
+	var keywords []string
+	for _, level := range levels {
+		words, ok := levelKeywords[strings.ToLower(level)]
+		if !ok {
+			return nil
+		}
+		keywords = append(keywords, words...)
+	}
+	return keywords
+}
+
+// sortLogEntries 按查询方向排序日志
+func sortLogEntries(entries []models.LogEntry, direction string) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if direction == "forward" {
+			return entries[i].Timestamp.Before(entries[j].Timestamp)
+		}
+		return entries[i].Timestamp.After(entries[j].Timestamp)
+	})
+}
+
+// sortLogStats 统计结果排序，命名空间按数量降序
+func sortLogStats(stats *models.LogStats) {
+	sort.Slice(stats.NamespaceStats, func(i, j int) bool {
+		return stats.NamespaceStats[i].Count > stats.NamespaceStats[j].Count
+	})
+	sort.Slice(stats.LevelStats, func(i, j int) bool {
+		return stats.LevelStats[i].Level < stats.LevelStats[j].Level
+	})
+	sort.Slice(stats.TimeDistribution, func(i, j int) bool {
+		return stats.TimeDistribution[i].Time.Before(stats.TimeDistribution[j].Time)
+	})
+}
+
+// fillLevelCounts 根据级别统计填充汇总字段
+func fillLevelCounts(stats *models.LogStats) {
+	for _, item := range stats.LevelStats {
+		switch strings.ToLower(item.Level) {
+		case "error", "err", "fatal", "critical":
+			stats.ErrorCount += item.Count
+		case "warn", "warning":
+			stats.WarnCount += item.Count
+		case "info":
+			stats.InfoCount += item.Count
+		}
+	}
+}
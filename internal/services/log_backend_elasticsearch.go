@@ -0,0 +1,404 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// ElasticsearchLogBackend Elasticsearch / OpenSearch 日志后端，查询条件翻译为 Query DSL
+type ElasticsearchLogBackend struct {
+	cluster    *models.Cluster
+	config     *models.LogBackendConfig
+	fields     models.LogFieldMapping
+	index      string
+	httpClient *http.Client
+}
+
+// esSearchResponse _search 响应
+type esSearchResponse struct {
+	Hits struct {
+		Total struct {
+			Value int `json:"value"`
+		} `json:"total"`
+		Hits []struct {
+			ID     string                 `json:"_id"`
+			Source map[string]interface{} `json:"_source"`
+		} `json:"hits"`
+	} `json:"hits"`
+	Aggregations map[string]esAggregation `json:"aggregations"`
+}
+
+// esAggregation 聚合结果
+type esAggregation struct {
+	Buckets json.RawMessage `json:"buckets"`
+}
+
+// esBucket terms / date_histogram 分桶
+type esBucket struct {
+	Key         interface{} `json:"key"`
+	KeyAsString string      `json:"key_as_string"`
+	DocCount    int64       `json:"doc_count"`
+}
+
+// NewElasticsearchLogBackend 创建 Elasticsearch 日志后端
+func NewElasticsearchLogBackend(cluster *models.Cluster, config *models.LogBackendConfig) *ElasticsearchLogBackend {
+	// 默认使用 Fluent Bit / Fluentd kubernetes 过滤器输出的字段
+	fields := models.LogFieldMapping{
+		Timestamp: "@timestamp",
+		Message:   "log",
+		Namespace: "kubernetes.namespace_name",
+		Pod:       "kubernetes.pod_name",
+		Container: "kubernetes.container_name",
+		Node:      "kubernetes.host",
+	}
+	if config.Fields != nil {
+		mergeLogFieldMapping(&fields, config.Fields)
+	}
+	index := config.Index
+	if index == "" {
+		index = "logstash-*"
+	}
+	return &ElasticsearchLogBackend{
+		cluster:    cluster,
+		config:     config,
+		fields:     fields,
+		index:      index,
+		httpClient: newLogBackendHTTPClient(),
+	}
+}
+
+// Search 使用 from/size 分页检索日志
+func (b *ElasticsearchLogBackend) Search(ctx context.Context, query *models.LogQuery) ([]models.LogEntry, int, error) {
+	boolQuery, err := b.buildQuery(query)
+	if err != nil {
+		return nil, 0, err
+	}
+	order := "desc"
+	if query.Direction == "forward" {
+		order = "asc"
+	}
+	body := map[string]interface{}{
+		"from":             query.Offset,
+		"size":             logQueryLimit(query),
+		"track_total_hits": true,
+		"sort":             []interface{}{map[string]interface{}{b.fields.Timestamp: map[string]string{"order": order}}},
+		"query":            boolQuery,
+	}
+
+	var resp esSearchResponse
+	if err := b.search(ctx, body, &resp); err != nil {
+		return nil, 0, err
+	}
+
+	entries := make([]models.LogEntry, 0, len(resp.Hits.Hits))
+	for _, hit := range resp.Hits.Hits {
+		entries = append(entries, b.toLogEntry(hit.ID, hit.Source))
+	}
+	return entries, resp.Hits.Total.Value, nil
+}
+
+// Stats 使用聚合统计级别、命名空间与时间分布
+func (b *ElasticsearchLogBackend) Stats(ctx context.Context, query *models.LogQuery) (*models.LogStats, error) {
+	start, end := logQueryWindow(query)
+	step := logStatsStep(start, end)
+
+	aggs := map[string]interface{}{
+		"namespaces": map[string]interface{}{
+			"terms": map[string]interface{}{"field": b.fields.Namespace, "size": 50},
+		},
+		"timeline": map[string]interface{}{
+			"date_histogram": map[string]interface{}{
+				"field":          b.fields.Timestamp,
+				"fixed_interval": fmt.Sprintf("%ds", int64(step.Seconds())),
+				"min_doc_count":  0,
+			},
+		},
+	}
+	if b.fields.Level != "" {
+		aggs["levels"] = map[string]interface{}{
+			"terms": map[string]interface{}{"field": b.fields.Level, "size": 20},
+		}
+	} else {
+		aggs["levels"] = map[string]interface{}{
+			"filters": map[string]interface{}{
+				"filters": map[string]interface{}{
+					"error": b.keywordsQuery(levelKeywords["error"]),
+					"warn":  b.keywordsQuery(levelKeywords["warn"]),
+				},
+			},
+		}
+	}
+
+	boolQuery, err := b.buildQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	body := map[string]interface{}{
+		"size":             0,
+		"track_total_hits": true,
+		"query":            boolQuery,
+		"aggs":             aggs,
+	}
+	var resp esSearchResponse
+	if err := b.search(ctx, body, &resp); err != nil {
+		return nil, err
+	}
+
+	stats := &models.LogStats{TotalCount: int64(resp.Hits.Total.Value)}
+	for _, bucket := range parseESBuckets(resp.Aggregations["namespaces"].Buckets) {
+		stats.NamespaceStats = append(stats.NamespaceStats, models.NamespaceStat{Namespace: fmt.Sprint(bucket.Key), Count: bucket.DocCount})
+	}
+	for _, bucket := range parseESBuckets(resp.Aggregations["timeline"].Buckets) {
+		ms, _ := bucket.Key.(float64)
+		stats.TimeDistribution = append(stats.TimeDistribution, models.TimePoint{Time: time.UnixMilli(int64(ms)), Count: bucket.DocCount})
+	}
+	for _, bucket := range parseESBuckets(resp.Aggregations["levels"].Buckets) {
+		stats.LevelStats = append(stats.LevelStats, models.LevelStat{Level: strings.ToLower(fmt.Sprint(bucket.Key)), Count: bucket.DocCount})
+	}
+	fillLevelCounts(stats)
+	if b.fields.Level == "" {
+		if info := stats.TotalCount - stats.ErrorCount - stats.WarnCount; info > 0 {
+			stats.InfoCount = info
+			stats.LevelStats = append(stats.LevelStats, models.LevelStat{Level: "info", Count: info})
+		}
+	}
+
+	sortLogStats(stats)
+	return stats, nil
+}
+
+// Test 检查集群健康状态
+func (b *ElasticsearchLogBackend) Test(ctx context.Context) error {
+	_, err := b.do(ctx, http.MethodGet, "/_cluster/health", nil)
+	return err
+}
+
+// buildQuery 将查询条件翻译为 bool 查询
+func (b *ElasticsearchLogBackend) buildQuery(query *models.LogQuery) (map[string]interface{}, error) {
+	start, end := logQueryWindow(query)
+	filters := []interface{}{
+		map[string]interface{}{
+			"range": map[string]interface{}{
+				b.fields.Timestamp: map[string]interface{}{
+					"gte":    start.UTC().Format(time.RFC3339Nano),
+					"lte":    end.UTC().Format(time.RFC3339Nano),
+					"format": "strict_date_optional_time",
+				},
+			},
+		},
+	}
+
+	labelKeys := make([]string, 0, len(b.config.Labels))
+	for k := range b.config.Labels {
+		labelKeys = append(labelKeys, k)
+	}
+	sort.Strings(labelKeys)
+	for _, k := range labelKeys {
+		filters = append(filters, map[string]interface{}{"term": map[string]interface{}{k: b.config.Labels[k]}})
+	}
+	filters = appendESTerms(filters, b.fields.Namespace, query.Namespaces)
+	filters = appendESTerms(filters, b.fields.Pod, query.Pods)
+	filters = appendESTerms(filters, b.fields.Container, query.Containers)
+	filters = appendESTerms(filters, b.fields.Node, query.Nodes)
+
+	var must []interface{}
+	if query.Keyword != "" {
+		must = append(must, map[string]interface{}{"match_phrase": map[string]interface{}{b.fields.Message: query.Keyword}})
+	}
+	if query.Regex != "" {
+		must = append(must, map[string]interface{}{"regexp": map[string]interface{}{b.fields.Message: map[string]interface{}{"value": query.Regex}}})
+	}
+	if len(query.Levels) > 0 {
+		if b.fields.Level != "" {
+			filters = appendESTerms(filters, b.fields.Level, query.Levels)
+		} else if keywords := levelFilterKeywords(query.Levels); len(keywords) > 0 {
+			must = append(must, b.keywordsQuery(keywords))
+		}
+	}
+
+	fieldFilters, err := ParseLogFieldFilters(query.Filters)
+	if err != nil {
+		return nil, err
+	}
+	var mustNot []interface{}
+	for _, filter := range fieldFilters {
+		clause, negate := b.fieldFilterQuery(filter)
+		if negate {
+			mustNot = append(mustNot, clause)
+		} else {
+			filters = append(filters, clause)
+		}
+	}
+
+	boolQuery := map[string]interface{}{"filter": filters}
+	if len(must) > 0 {
+		boolQuery["must"] = must
+	}
+	if len(mustNot) > 0 {
+		boolQuery["must_not"] = mustNot
+	}
+	return map[string]interface{}{"bool": boolQuery}, nil
+}
+
+// fieldFilterQuery 将字段过滤翻译为查询子句，negate 表示放入 must_not
+func (b *ElasticsearchLogBackend) fieldFilterQuery(filter LogFieldFilter) (map[string]interface{}, bool) {
+	var field string
+	switch filter.Field {
+	case "level":
+		field = b.fields.Level
+		if field == "" {
+			field = "level"
+		}
+	case "trace_id":
+		field = "trace_id"
+	default:
+		field = filter.FieldPath()
+	}
+
+	switch filter.Op {
+	case ">", ">=", "<", "<=":
+		op := map[string]string{">": "gt", ">=": "gte", "<": "lt", "<=": "lte"}[filter.Op]
+		return map[string]interface{}{"range": map[string]interface{}{field: map[string]interface{}{op: filter.NumberString()}}}, false
+	case "=~", "!~":
+		return map[string]interface{}{"regexp": map[string]interface{}{field: map[string]interface{}{"value": filter.Value}}}, filter.Op == "!~"
+	default:
+		return map[string]interface{}{"match_phrase": map[string]interface{}{field: filter.Value}}, filter.Op == "!="
+	}
+}
+
+// keywordsQuery 消息字段匹配任意关键词
+func (b *ElasticsearchLogBackend) keywordsQuery(keywords []string) map[string]interface{} {
+	return map[string]interface{}{
+		"match": map[string]interface{}{
+			b.fields.Message: map[string]interface{}{"query": strings.Join(keywords, " "), "operator": "or"},
+		},
+	}
+}
+
+// toLogEntry 转换文档
+func (b *ElasticsearchLogBackend) toLogEntry(id string, source map[string]interface{}) models.LogEntry {
+	entry := models.LogEntry{
+		ID:          id,
+		Type:        "container",
+		ClusterID:   b.cluster.ID,
+		ClusterName: b.cluster.Name,
+		Namespace:   esFieldString(source, b.fields.Namespace),
+		PodName:     esFieldString(source, b.fields.Pod),
+		Container:   esFieldString(source, b.fields.Container),
+		NodeName:    esFieldString(source, b.fields.Node),
+		Message:     strings.TrimSpace(esFieldString(source, b.fields.Message)),
+	}
+	if entry.ID == "" {
+		entry.ID = uuid.New().String()
+	}
+	if ts, err := time.Parse(time.RFC3339Nano, esFieldString(source, b.fields.Timestamp)); err == nil {
+		entry.Timestamp = ts
+	}
+	if level := esFieldString(source, b.fields.Level); b.fields.Level != "" && level != "" {
+		entry.Level = normalizeLogLevel(level)
+	} else {
+		entry.Level = (&LogAggregator{}).detectLogLevel(entry.Message)
+	}
+	return entry
+}
+
+func (b *ElasticsearchLogBackend) search(ctx context.Context, body map[string]interface{}, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("序列化查询失败: %w", err)
+	}
+	respBody, err := b.do(ctx, http.MethodPost, "/"+b.index+"/_search?ignore_unavailable=true", payload)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("解析Elasticsearch响应失败: %w", err)
+	}
+	return nil
+}
+
+func (b *ElasticsearchLogBackend) do(ctx context.Context, method, path string, payload []byte) ([]byte, error) {
+	var reader io.Reader
+	if payload != nil {
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(b.config.Endpoint, "/")+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if err := setLogBackendAuth(req, b.config.Auth); err != nil {
+		return nil, err
+	}
+
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求Elasticsearch失败: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取Elasticsearch响应失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Elasticsearch返回错误 %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+// appendESTerms 添加 terms 过滤
+func appendESTerms(filters []interface{}, field string, values []string) []interface{} {
+	if field == "" || len(values) == 0 {
+		return filters
+	}
+	return append(filters, map[string]interface{}{"terms": map[string]interface{}{field: values}})
+}
+
+// parseESBuckets 解析分桶，兼容 terms/date_histogram 的数组形式与 filters 的对象形式
+func parseESBuckets(raw json.RawMessage) []esBucket {
+	if len(raw) == 0 {
+		return nil
+	}
+	var list []esBucket
+	if err := json.Unmarshal(raw, &list); err == nil {
+		return list
+	}
+	var keyed map[string]esBucket
+	if err := json.Unmarshal(raw, &keyed); err != nil {
+		return nil
+	}
+	keys := make([]string, 0, len(keyed))
+	for k := range keyed {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		bucket := keyed[k]
+		bucket.Key = k
+		list = append(list, bucket)
+	}
+	return list
+}
+
+// esFieldString 读取文档字段，支持点号路径（如 kubernetes.pod_name）与扁平字段名
+func esFieldString(source map[string]interface{}, field string) string {
+	v, ok := lookupLogField(source, field)
+	if !ok || v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
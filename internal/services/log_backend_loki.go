@@ -0,0 +1,497 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// LokiLogBackend Grafana Loki 日志后端，查询条件翻译为 LogQL
+type LokiLogBackend struct {
+	cluster    *models.Cluster
+	config     *models.LogBackendConfig
+	fields     models.LogFieldMapping
+	httpClient *http.Client
+}
+
+// lokiResponse Loki 查询响应
+type lokiResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+// lokiStream streams 类型结果
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// lokiSample vector/matrix 类型结果
+type lokiSample struct {
+	Metric map[string]string `json:"metric"`
+	Value  []interface{}     `json:"value,omitempty"`
+	Values [][]interface{}   `json:"values,omitempty"`
+}
+
+// NewLokiLogBackend 创建 Loki 日志后端
+func NewLokiLogBackend(cluster *models.Cluster, config *models.LogBackendConfig) *LokiLogBackend {
+	// 默认使用 Promtail / Grafana Agent 的 Kubernetes 标签
+	fields := models.LogFieldMapping{
+		Namespace: "namespace",
+		Pod:       "pod",
+		Container: "container",
+		Node:      "node_name",
+	}
+	if config.Fields != nil {
+		mergeLogFieldMapping(&fields, config.Fields)
+	}
+	return &LokiLogBackend{
+		cluster:    cluster,
+		config:     config,
+		fields:     fields,
+		httpClient: newLogBackendHTTPClient(),
+	}
+}
+
+// Search 使用 query_range 检索日志
+func (b *LokiLogBackend) Search(ctx context.Context, query *models.LogQuery) ([]models.LogEntry, int, error) {
+	logQL, err := b.buildLogQL(query)
+	if err != nil {
+		return nil, 0, err
+	}
+	start, end := logQueryWindow(query)
+	limit := logQueryLimit(query)
+	direction := query.Direction
+	if direction != "forward" {
+		direction = "backward"
+	}
+
+	// Loki 不支持 offset，多取 offset 条后跳过
+	params := url.Values{}
+	params.Set("query", logQL)
+	params.Set("start", strconv.FormatInt(start.UnixNano(), 10))
+	params.Set("end", strconv.FormatInt(end.UnixNano(), 10))
+	params.Set("limit", strconv.Itoa(query.Offset+limit))
+	params.Set("direction", direction)
+
+	resp, err := b.get(ctx, "/loki/api/v1/query_range", params)
+	if err != nil {
+		return nil, 0, err
+	}
+	var streams []lokiStream
+	if err := json.Unmarshal(resp.Data.Result, &streams); err != nil {
+		return nil, 0, fmt.Errorf("解析Loki响应失败: %w", err)
+	}
+
+	var entries []models.LogEntry
+	for _, stream := range streams {
+		for _, value := range stream.Values {
+			entry := b.toLogEntry(stream.Stream, value)
+			if len(query.Levels) > 0 && !contains(query.Levels, entry.Level) {
+				continue
+			}
+			entries = append(entries, entry)
+		}
+	}
+	sortLogEntries(entries, direction)
+
+	if query.Offset >= len(entries) {
+		entries = nil
+	} else {
+		entries = entries[query.Offset:]
+	}
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+
+	// 命中总数通过 count_over_time 聚合获取，失败时退化为当前结果数
+	total := query.Offset + len(entries)
+	if count, err := b.instantSum(ctx, fmt.Sprintf("sum(count_over_time(%s [%s]))", logQL, lokiDuration(end.Sub(start))), end); err == nil && int(count) > total {
+		total = int(count)
+	}
+	return entries, total, nil
+}
+
+// Stats 使用 LogQL 指标查询统计
+func (b *LokiLogBackend) Stats(ctx context.Context, query *models.LogQuery) (*models.LogStats, error) {
+	logQL, err := b.buildLogQL(query)
+	if err != nil {
+		return nil, err
+	}
+	start, end := logQueryWindow(query)
+	window := lokiDuration(end.Sub(start))
+	stats := &models.LogStats{}
+
+	total, err := b.instantSum(ctx, fmt.Sprintf("sum(count_over_time(%s [%s]))", logQL, window), end)
+	if err != nil {
+		return nil, err
+	}
+	stats.TotalCount = int64(total)
+
+	// 按命名空间
+	byNamespace, err := b.instantVector(ctx, fmt.Sprintf("sum by (%s) (count_over_time(%s [%s]))", b.fields.Namespace, logQL, window), end)
+	if err != nil {
+		return nil, err
+	}
+	for _, sample := range byNamespace {
+		stats.NamespaceStats = append(stats.NamespaceStats, models.NamespaceStat{
+			Namespace: sample.Metric[b.fields.Namespace],
+			Count:     int64(sampleValue(sample.Value)),
+		})
+	}
+
+	// 按级别：有级别标签时直接聚合，否则按关键词行过滤计数
+	if b.fields.Level != "" {
+		byLevel, err := b.instantVector(ctx, fmt.Sprintf("sum by (%s) (count_over_time(%s [%s]))", b.fields.Level, logQL, window), end)
+		if err != nil {
+			return nil, err
+		}
+		for _, sample := range byLevel {
+			stats.LevelStats = append(stats.LevelStats, models.LevelStat{
+				Level: strings.ToLower(sample.Metric[b.fields.Level]),
+				Count: int64(sampleValue(sample.Value)),
+			})
+		}
+		fillLevelCounts(stats)
+	} else {
+		for _, level := range []string{"error", "warn"} {
+			count, err := b.instantSum(ctx, fmt.Sprintf("sum(count_over_time(%s |~ %s [%s]))", logQL, strconv.Quote(keywordPattern(levelKeywords[level])), window), end)
+			if err != nil {
+				return nil, err
+			}
+			stats.LevelStats = append(stats.LevelStats, models.LevelStat{Level: level, Count: int64(count)})
+		}
+		fillLevelCounts(stats)
+		if info := stats.TotalCount - stats.ErrorCount - stats.WarnCount; info > 0 {
+			stats.InfoCount = info
+			stats.LevelStats = append(stats.LevelStats, models.LevelStat{Level: "info", Count: info})
+		}
+	}
+
+	// 时间分布
+	step := logStatsStep(start, end)
+	params := url.Values{}
+	params.Set("query", fmt.Sprintf("sum(count_over_time(%s [%s]))", logQL, lokiDuration(step)))
+	params.Set("start", strconv.FormatInt(start.UnixNano(), 10))
+	params.Set("end", strconv.FormatInt(end.UnixNano(), 10))
+	params.Set("step", lokiDuration(step))
+	resp, err := b.get(ctx, "/loki/api/v1/query_range", params)
+	if err != nil {
+		return nil, err
+	}
+	var matrix []lokiSample
+	if err := json.Unmarshal(resp.Data.Result, &matrix); err != nil {
+		return nil, fmt.Errorf("解析Loki响应失败: %w", err)
+	}
+	for _, series := range matrix {
+		for _, value := range series.Values {
+			if len(value) < 2 {
+				continue
+			}
+			ts, _ := value[0].(float64)
+			stats.TimeDistribution = append(stats.TimeDistribution, models.TimePoint{
+				Time:  time.Unix(int64(ts), 0),
+				Count: int64(sampleValue(value)),
+			})
+		}
+	}
+
+	sortLogStats(stats)
+	return stats, nil
+}
+
+// Test 检查 Loki 就绪状态
+func (b *LokiLogBackend) Test(ctx context.Context) error {
+	_, err := b.do(ctx, "/loki/api/v1/labels", url.Values{})
+	return err
+}
+
+// buildLogQL 将查询条件翻译为 LogQL
+func (b *LokiLogBackend) buildLogQL(query *models.LogQuery) (string, error) {
+	var matchers []string
+	labelKeys := make([]string, 0, len(b.config.Labels))
+	for k := range b.config.Labels {
+		labelKeys = append(labelKeys, k)
+	}
+	sort.Strings(labelKeys)
+	for _, k := range labelKeys {
+		matchers = append(matchers, fmt.Sprintf("%s=%s", k, strconv.Quote(b.config.Labels[k])))
+	}
+	matchers = appendLokiMatcher(matchers, b.fields.Namespace, query.Namespaces)
+	matchers = appendLokiMatcher(matchers, b.fields.Pod, query.Pods)
+	matchers = appendLokiMatcher(matchers, b.fields.Container, query.Containers)
+	matchers = appendLokiMatcher(matchers, b.fields.Node, query.Nodes)
+	if len(matchers) == 0 {
+		// Loki 要求至少一个非空匹配器
+		matchers = append(matchers, fmt.Sprintf("%s=~\".+\"", b.fields.Namespace))
+	}
+
+	var builder strings.Builder
+	builder.WriteString("{" + strings.Join(matchers, ", ") + "}")
+
+	if query.Keyword != "" {
+		builder.WriteString(" |~ " + strconv.Quote("(?i)"+regexp.QuoteMeta(query.Keyword)))
+	}
+	if query.Regex != "" {
+		if _, err := regexp.Compile(query.Regex); err != nil {
+			return "", fmt.Errorf("正则表达式错误: %w", err)
+		}
+		builder.WriteString(" |~ " + strconv.Quote(query.Regex))
+	}
+	if len(query.Levels) > 0 {
+		if b.fields.Level != "" {
+			builder.WriteString(fmt.Sprintf(" | %s=~%s", b.fields.Level, strconv.Quote("(?i)"+strings.Join(quoteAll(query.Levels), "|"))))
+		} else if keywords := levelFilterKeywords(query.Levels); len(keywords) > 0 {
+			builder.WriteString(" |~ " + strconv.Quote(keywordPattern(keywords)))
+		}
+	}
+
+	filters, err := ParseLogFieldFilters(query.Filters)
+	if err != nil {
+		return "", err
+	}
+	if len(filters) > 0 {
+		// 字段过滤需要先用解析器把日志行解析为标签
+		parser := b.config.Parser
+		if parser == "" {
+			parser = models.LogFormatJSON
+		}
+		builder.WriteString(" | " + parser)
+		for _, filter := range filters {
+			builder.WriteString(" | " + b.labelFilter(filter))
+		}
+	}
+	return builder.String(), nil
+}
+
+// labelFilter 将字段过滤翻译为 LogQL 标签过滤表达式
+func (b *LokiLogBackend) labelFilter(filter LogFieldFilter) string {
+	var label string
+	switch filter.Field {
+	case "level":
+		label = b.fields.Level
+		if label == "" {
+			label = "level"
+		}
+	case "trace_id":
+		label = "trace_id"
+	default:
+		// json/logfmt 解析器会把嵌套字段的 . 等字符替换为 _
+		label = lokiLabelReplacer.Replace(filter.FieldPath())
+	}
+
+	switch {
+	case filter.IsNumeric():
+		return fmt.Sprintf("%s %s %s", label, filter.Op, filter.NumberString())
+	case filter.Field == "level" && (filter.Op == "=" || filter.Op == "!="):
+		op := "=~"
+		if filter.Op == "!=" {
+			op = "!~"
+		}
+		return label + op + strconv.Quote("(?i)"+regexp.QuoteMeta(filter.Value))
+	default:
+		return label + filter.Op + strconv.Quote(filter.Value)
+	}
+}
+
+// toLogEntry 转换 Loki 日志行
+func (b *LokiLogBackend) toLogEntry(stream map[string]string, value [2]string) models.LogEntry {
+	entry := models.LogEntry{
+		ID:          uuid.New().String(),
+		Type:        "container",
+		ClusterID:   b.cluster.ID,
+		ClusterName: b.cluster.Name,
+		Namespace:   stream[b.fields.Namespace],
+		PodName:     stream[b.fields.Pod],
+		Container:   stream[b.fields.Container],
+		NodeName:    stream[b.fields.Node],
+		Message:     strings.TrimSpace(value[1]),
+		Labels:      stream,
+	}
+	if ns, err := strconv.ParseInt(value[0], 10, 64); err == nil {
+		entry.Timestamp = time.Unix(0, ns)
+	}
+	if level := stream[b.fields.Level]; b.fields.Level != "" && level != "" {
+		entry.Level = normalizeLogLevel(level)
+	} else {
+		entry.Level = (&LogAggregator{}).detectLogLevel(entry.Message)
+	}
+	return entry
+}
+
+func (b *LokiLogBackend) instantVector(ctx context.Context, logQL string, at time.Time) ([]lokiSample, error) {
+	params := url.Values{}
+	params.Set("query", logQL)
+	params.Set("time", strconv.FormatInt(at.UnixNano(), 10))
+	resp, err := b.get(ctx, "/loki/api/v1/query", params)
+	if err != nil {
+		return nil, err
+	}
+	var vector []lokiSample
+	if err := json.Unmarshal(resp.Data.Result, &vector); err != nil {
+		return nil, fmt.Errorf("解析Loki响应失败: %w", err)
+	}
+	return vector, nil
+}
+
+func (b *LokiLogBackend) instantSum(ctx context.Context, logQL string, at time.Time) (float64, error) {
+	vector, err := b.instantVector(ctx, logQL, at)
+	if err != nil {
+		return 0, err
+	}
+	var sum float64
+	for _, sample := range vector {
+		sum += sampleValue(sample.Value)
+	}
+	return sum, nil
+}
+
+func (b *LokiLogBackend) get(ctx context.Context, path string, params url.Values) (*lokiResponse, error) {
+	body, err := b.do(ctx, path, params)
+	if err != nil {
+		return nil, err
+	}
+	var result lokiResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("解析Loki响应失败: %w", err)
+	}
+	if result.Status != "success" {
+		return nil, fmt.Errorf("Loki查询失败: %s", result.Status)
+	}
+	return &result, nil
+}
+
+func (b *LokiLogBackend) do(ctx context.Context, path string, params url.Values) ([]byte, error) {
+	reqURL := strings.TrimRight(b.config.Endpoint, "/") + path
+	if encoded := params.Encode(); encoded != "" {
+		reqURL += "?" + encoded
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	if err := setLogBackendAuth(req, b.config.Auth); err != nil {
+		return nil, err
+	}
+	// 多租户 Loki 通过 X-Scope-OrgID 区分租户
+	if b.config.TenantID != "" {
+		req.Header.Set("X-Scope-OrgID", b.config.TenantID)
+	}
+
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求Loki失败: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取Loki响应失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Loki返回错误 %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+// appendLokiMatcher 添加标签匹配器，多个值使用正则匹配
+func appendLokiMatcher(matchers []string, label string, values []string) []string {
+	if label == "" || len(values) == 0 {
+		return matchers
+	}
+	if len(values) == 1 {
+		return append(matchers, fmt.Sprintf("%s=%s", label, strconv.Quote(values[0])))
+	}
+	return append(matchers, fmt.Sprintf("%s=~%s", label, strconv.Quote(strings.Join(quoteAll(values), "|"))))
+}
+
+// lokiLabelReplacer 字段路径转换为 Loki 解析器生成的标签名
+var lokiLabelReplacer = strings.NewReplacer(".", "_", "-", "_", "@", "_")
+
+// lokiDuration 转换为 LogQL 时间范围（秒）
+func lokiDuration(d time.Duration) string {
+	seconds := int64(d.Seconds())
+	if seconds < 1 {
+		seconds = 1
+	}
+	return fmt.Sprintf("%ds", seconds)
+}
+
+// sampleValue 解析 [timestamp, "value"] 中的数值
+func sampleValue(value []interface{}) float64 {
+	if len(value) < 2 {
+		return 0
+	}
+	str, _ := value[1].(string)
+	v, _ := strconv.ParseFloat(str, 64)
+	return v
+}
+
+// keywordPattern 生成不区分大小写的关键词正则
+func keywordPattern(keywords []string) string {
+	return "(?i)(" + strings.Join(quoteAll(keywords), "|") + ")"
+}
+
+func quoteAll(values []string) []string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = regexp.QuoteMeta(v)
+	}
+	return quoted
+}
+
+// normalizeLogLevel 统一日志级别名称
+func normalizeLogLevel(level string) string {
+	switch strings.ToLower(level) {
+	case "error", "err", "fatal", "critical", "panic", "crit", "emerg", "alert":
+		return "error"
+	case "warn", "warning":
+		return "warn"
+	case "debug", "trace", "verbose":
+		return "debug"
+	default:
+		return "info"
+	}
+}
+
+// mergeLogFieldMapping 使用自定义映射覆盖默认值
+func mergeLogFieldMapping(dst *models.LogFieldMapping, src *models.LogFieldMapping) {
+	if src.Timestamp != "" {
+		dst.Timestamp = src.Timestamp
+	}
+	if src.Message != "" {
+		dst.Message = src.Message
+	}
+	if src.Level != "" {
+		dst.Level = src.Level
+	}
+	if src.Namespace != "" {
+		dst.Namespace = src.Namespace
+	}
+	if src.Pod != "" {
+		dst.Pod = src.Pod
+	}
+	if src.Container != "" {
+		dst.Container = src.Container
+	}
+	if src.Node != "" {
+		dst.Node = src.Node
+	}
+}
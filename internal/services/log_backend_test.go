@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// LogBackendTestSuite 定义日志后端测试套件
+type LogBackendTestSuite struct {
+	suite.Suite
+	cluster *models.Cluster
+}
+
+// SetupTest 每个测试前的设置
+func (s *LogBackendTestSuite) SetupTest() {
+	s.cluster = &models.Cluster{ID: 1, Name: "test"}
+}
+
+// TestLokiBuildLogQL 测试 LogQL 翻译
+func (s *LogBackendTestSuite) TestLokiBuildLogQL() {
+	backend := NewLokiLogBackend(s.cluster, &models.LogBackendConfig{
+		Type:   models.LogBackendLoki,
+		Labels: map[string]string{"cluster": "prod"},
+	})
+
+	logQL, err := backend.buildLogQL(&models.LogQuery{
+		Namespaces: []string{"default", "kube-system"},
+		Pods:       []string{"web-1"},
+		Keyword:    "timeout",
+		Levels:     []string{"error"},
+	})
+	s.Require().NoError(err)
+	assert.Equal(s.T(), `{cluster="prod", namespace=~"default|kube-system", pod="web-1"} |~ "(?i)timeout" |~ "(?i)(error|err|fail|fatal|exception|panic|critical)"`, logQL)
+
+	// 字段过滤下推为解析器与标签过滤
+	logQL, err = backend.buildLogQL(&models.LogQuery{Filters: []string{"fields.http.status>=500", "trace_id=abc"}})
+	s.Require().NoError(err)
+	assert.Equal(s.T(), `{cluster="prod"} | json | http_status >= 500 | trace_id="abc"`, logQL)
+
+	// 数值比较值按规范格式输出
+	logQL, err = backend.buildLogQL(&models.LogQuery{Filters: []string{"fields.latency>0x1p3", "fields.size<=1e3"}})
+	s.Require().NoError(err)
+	assert.Equal(s.T(), `{cluster="prod"} | json | latency > 8 | size <= 1000`, logQL)
+
+	// 无任何匹配器时使用命名空间非空匹配
+	logQL, err = NewLokiLogBackend(s.cluster, &models.LogBackendConfig{}).buildLogQL(&models.LogQuery{})
+	s.Require().NoError(err)
+	assert.Equal(s.T(), `{namespace=~".+"}`, logQL)
+}
+
+// TestLokiSearch 测试 Loki 检索、方向与 offset
+func (s *LogBackendTestSuite) TestLokiSearch() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/loki/api/v1/query_range":
+			assert.Equal(s.T(), "forward", r.URL.Query().Get("direction"))
+			assert.Equal(s.T(), "3", r.URL.Query().Get("limit"))
+			_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"streams","result":[
+				{"stream":{"namespace":"default","pod":"web-1","container":"web"},"values":[["1700000002000000000","second"],["1700000001000000000","first"]]},
+				{"stream":{"namespace":"default","pod":"web-2","container":"web"},"values":[["1700000003000000000","third ERROR"]]}
+			]}}`))
+		case "/loki/api/v1/query":
+			_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1700000003,"42"]}]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	backend := NewLokiLogBackend(s.cluster, &models.LogBackendConfig{Type: models.LogBackendLoki, Endpoint: server.URL})
+	entries, total, err := backend.Search(context.Background(), &models.LogQuery{Direction: "forward", Offset: 1, Limit: 2})
+	s.Require().NoError(err)
+	s.Require().Len(entries, 2)
+	assert.Equal(s.T(), "second", entries[0].Message)
+	assert.Equal(s.T(), "third ERROR", entries[1].Message)
+	assert.Equal(s.T(), "error", entries[1].Level)
+	assert.Equal(s.T(), "web-2", entries[1].PodName)
+	assert.Equal(s.T(), 42, total)
+}
+
+// TestElasticsearchSearch 测试 Query DSL 翻译与结果解析
+func (s *LogBackendTestSuite) TestElasticsearchSearch() {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(s.T(), "/app-logs-*/_search", r.URL.Path)
+		s.Require().NoError(json.NewDecoder(r.Body).Decode(&body))
+		_, _ = w.Write([]byte(`{"hits":{"total":{"value":57},"hits":[
+			{"_id":"a1","_source":{"@timestamp":"2024-01-01T00:00:00Z","log":"request failed","kubernetes":{"namespace_name":"default","pod_name":"web-1","container_name":"web"}}}
+		]}}`))
+	}))
+	defer server.Close()
+
+	backend := NewElasticsearchLogBackend(s.cluster, &models.LogBackendConfig{
+		Type:     models.LogBackendElasticsearch,
+		Endpoint: server.URL,
+		Index:    "app-logs-*",
+	})
+	entries, total, err := backend.Search(context.Background(), &models.LogQuery{
+		Namespaces: []string{"default"},
+		Keyword:    "failed",
+		StartTime:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndTime:    time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC),
+		Offset:     20,
+		Limit:      10,
+	})
+	s.Require().NoError(err)
+	assert.Equal(s.T(), 57, total)
+	s.Require().Len(entries, 1)
+	assert.Equal(s.T(), "web-1", entries[0].PodName)
+	assert.Equal(s.T(), "error", entries[0].Level)
+
+	assert.Equal(s.T(), float64(20), body["from"])
+	assert.Equal(s.T(), float64(10), body["size"])
+	sort := body["sort"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(s.T(), "desc", sort["@timestamp"].(map[string]interface{})["order"])
+	boolQuery := body["query"].(map[string]interface{})["bool"].(map[string]interface{})
+	assert.Len(s.T(), boolQuery["filter"], 2)
+	assert.Len(s.T(), boolQuery["must"], 1)
+}
+
+// TestLogBackendTestSuite 运行测试套件
+func TestLogBackendTestSuite(t *testing.T) {
+	suite.Run(t, new(LogBackendTestSuite))
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+	"github.com/clay-wangzhi/KubePolaris/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// LogConfigService 集群日志后端配置服务
+type LogConfigService struct {
+	db *gorm.DB
+}
+
+// NewLogConfigService 创建日志后端配置服务
+func NewLogConfigService(db *gorm.DB) *LogConfigService {
+	return &LogConfigService{db: db}
+}
+
+// GetLogConfig 获取集群日志后端配置，未配置时返回 kubelet 后端
+func (s *LogConfigService) GetLogConfig(clusterID uint) (*models.LogBackendConfig, error) {
+	var cluster models.Cluster
+	if err := s.db.Select("log_config").First(&cluster, clusterID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("集群不存在: %d", clusterID)
+		}
+		return nil, fmt.Errorf("获取集群失败: %w", err)
+	}
+
+	if cluster.LogConfig == "" {
+		return &models.LogBackendConfig{Type: models.LogBackendKubelet}, nil
+	}
+
+	var config models.LogBackendConfig
+	if err := json.Unmarshal([]byte(cluster.LogConfig), &config); err != nil {
+		logger.Error("解析日志后端配置失败", "cluster_id", clusterID, "error", err)
+		return &models.LogBackendConfig{Type: models.LogBackendKubelet}, nil
+	}
+
+	return &config, nil
+}
+
+// UpdateLogConfig 更新集群日志后端配置
+func (s *LogConfigService) UpdateLogConfig(clusterID uint, config *models.LogBackendConfig) error {
+	if err := s.ValidateConfig(config); err != nil {
+		return fmt.Errorf("配置验证失败: %w", err)
+	}
+
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("序列化配置失败: %w", err)
+	}
+
+	result := s.db.Model(&models.Cluster{}).Where("id = ?", clusterID).Update("log_config", string(configJSON))
+	if result.Error != nil {
+		return fmt.Errorf("更新日志后端配置失败: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("集群不存在: %d", clusterID)
+	}
+
+	logger.Info("日志后端配置更新成功", "cluster_id", clusterID, "type", config.Type)
+	return nil
+}
+
+// ValidateConfig 验证日志后端配置
+func (s *LogConfigService) ValidateConfig(config *models.LogBackendConfig) error {
+	switch config.Type {
+	case "", models.LogBackendKubelet:
+		return nil
+	case models.LogBackendLoki, models.LogBackendElasticsearch:
+	default:
+		return fmt.Errorf("不支持的日志后端类型: %s", config.Type)
+	}
+
+	if config.Endpoint == "" {
+		return fmt.Errorf("日志后端地址不能为空")
+	}
+
+	switch config.Parser {
+	case "", models.LogFormatJSON, models.LogFormatLogfmt:
+	default:
+		return fmt.Errorf("不支持的 Loki 解析器: %s", config.Parser)
+	}
+
+	if config.Auth != nil {
+		switch config.Auth.Type {
+		case "none", "":
+		case "basic":
+			if config.Auth.Username == "" || config.Auth.Password == "" {
+				return fmt.Errorf("basic 认证需要用户名和密码")
+			}
+		case "bearer", "apikey":
+			if config.Auth.Token == "" {
+				return fmt.Errorf("%s 认证需要 token", config.Auth.Type)
+			}
+		default:
+			return fmt.Errorf("不支持的认证类型: %s", config.Auth.Type)
+		}
+	}
+
+	return nil
+}
+
+// BackendForCluster 获取集群当前配置的日志后端
+func (s *LogConfigService) BackendForCluster(cluster *models.Cluster, aggregator *LogAggregator) (LogBackend, error) {
+	config, err := s.GetLogConfig(cluster.ID)
+	if err != nil {
+		return nil, err
+	}
+	return NewLogBackend(cluster, config, aggregator)
+}
@@ -0,0 +1,231 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+)
+
+// logExportBatchSize 导出时每批读取的日志条数
+const logExportBatchSize = 1000
+
+// LogExportCursor 导出断点：已导出条数与最后一条日志的时间戳
+type LogExportCursor struct {
+	Count int64
+	Time  time.Time
+}
+
+// logIterator 自行遍历全部结果的后端（kubelet 后端无法按时间分页）
+type logIterator interface {
+	iterate(ctx context.Context, query *models.LogQuery, cursor *LogExportCursor, maxEntries int64, fn func([]models.LogEntry) error) (bool, error)
+}
+
+// IterateLogs 按时间正序分批遍历查询结果，直到结束或达到 maxEntries（返回 truncated=true）
+// cursor 记录进度（调用 fn 前已包含本批），非零时从断点继续：
+// 按时间分页的后端从断点时间之后继续，kubelet 后端跳过已导出的条数
+func IterateLogs(ctx context.Context, backend LogBackend, query *models.LogQuery, cursor *LogExportCursor, maxEntries int64, fn func([]models.LogEntry) error) (bool, error) {
+	if it, ok := backend.(logIterator); ok {
+		return it.iterate(ctx, query, cursor, maxEntries, fn)
+	}
+
+	start, end := logQueryWindow(query)
+	if !cursor.Time.IsZero() {
+		// 断点续传时从最后一条日志之后继续，同一纳秒内未导出的日志会被跳过
+		start = cursor.Time.Add(time.Nanosecond)
+	}
+	q := *query
+	q.Direction = "forward"
+	q.Offset = 0
+	q.Limit = logExportBatchSize
+	q.EndTime = end
+
+	// seen 记录游标时间戳上已输出的日志，避免翻页时重复
+	seen := make(map[string]bool)
+	for start.Before(end) {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+		q.StartTime = start
+		entries, _, err := backend.Search(ctx, &q)
+		if err != nil {
+			return false, err
+		}
+
+		batch := make([]models.LogEntry, 0, len(entries))
+		for _, entry := range entries {
+			if entry.Timestamp.Equal(start) && seen[logEntryKey(&entry)] {
+				continue
+			}
+			batch = append(batch, entry)
+		}
+		if len(batch) == 0 {
+			if len(entries) < q.Limit {
+				return false, nil
+			}
+			// 整批日志时间戳相同且都已输出，跳过该时间点
+			start = start.Add(time.Nanosecond)
+			seen = make(map[string]bool)
+			continue
+		}
+
+		truncated := false
+		if maxEntries > 0 && cursor.Count+int64(len(batch)) > maxEntries {
+			batch = batch[:maxEntries-cursor.Count]
+			truncated = true
+		}
+		if len(batch) > 0 {
+			cursor.Count += int64(len(batch))
+			cursor.Time = batch[len(batch)-1].Timestamp
+			if err := fn(batch); err != nil {
+				return false, err
+			}
+		}
+		if truncated {
+			return true, nil
+		}
+		if len(entries) < q.Limit {
+			return false, nil
+		}
+
+		last := batch[len(batch)-1].Timestamp
+		if !last.Equal(start) {
+			seen = make(map[string]bool)
+		}
+		for i := range batch {
+			if batch[i].Timestamp.Equal(last) {
+				seen[logEntryKey(&batch[i])] = true
+			}
+		}
+		start = last
+	}
+	return false, nil
+}
+
+// logEntryKey 日志去重键
+func logEntryKey(entry *models.LogEntry) string {
+	return strings.Join([]string{
+		strconv.FormatInt(entry.Timestamp.UnixNano(), 10),
+		entry.Namespace, entry.PodName, entry.Container, entry.Message,
+	}, "\x00")
+}
+
+// LogExportEncoder 日志导出编码器
+type LogExportEncoder struct {
+	format string
+	w      io.Writer
+	csv    *csv.Writer
+}
+
+// NewLogExportEncoder 创建日志导出编码器，format 为空时使用纯文本
+func NewLogExportEncoder(format string, w io.Writer) (*LogExportEncoder, error) {
+	switch format {
+	case "", models.LogExportFormatText:
+		format = models.LogExportFormatText
+	case models.LogExportFormatNDJSON:
+	case models.LogExportFormatCSV:
+		return &LogExportEncoder{format: format, w: w, csv: csv.NewWriter(w)}, nil
+	default:
+		return nil, fmt.Errorf("不支持的导出格式: %s", format)
+	}
+	return &LogExportEncoder{format: format, w: w}, nil
+}
+
+// WriteHeader 写入文件头（仅 CSV 有表头）
+func (e *LogExportEncoder) WriteHeader() error {
+	if e.csv == nil {
+		return nil
+	}
+	if err := e.csv.Write([]string{"timestamp", "level", "namespace", "pod", "container", "node", "trace_id", "message", "fields"}); err != nil {
+		return err
+	}
+	e.csv.Flush()
+	return e.csv.Error()
+}
+
+// Write 写入一批日志
+func (e *LogExportEncoder) Write(entries []models.LogEntry) error {
+	for i := range entries {
+		entry := &entries[i]
+		switch e.format {
+		case models.LogExportFormatNDJSON:
+			data, err := json.Marshal(entry)
+			if err != nil {
+				return err
+			}
+			if _, err := e.w.Write(append(data, '\n')); err != nil {
+				return err
+			}
+		case models.LogExportFormatCSV:
+			fields := ""
+			if len(entry.Fields) > 0 {
+				fields = jsonString(entry.Fields)
+			}
+			if err := e.csv.Write([]string{
+				entry.Timestamp.Format(time.RFC3339Nano),
+				entry.Level,
+				entry.Namespace,
+				entry.PodName,
+				entry.Container,
+				entry.NodeName,
+				entry.TraceID,
+				entry.Message,
+				fields,
+			}); err != nil {
+				return err
+			}
+		default:
+			if _, err := fmt.Fprintf(e.w, "%s [%s] [%s/%s] %s\n",
+				entry.Timestamp.Format(time.RFC3339),
+				strings.ToUpper(entry.Level),
+				entry.Namespace,
+				entry.PodName,
+				entry.Message,
+			); err != nil {
+				return err
+			}
+		}
+	}
+	if e.csv != nil {
+		e.csv.Flush()
+		return e.csv.Error()
+	}
+	return nil
+}
+
+// LogExportFileName 导出文件名
+func LogExportFileName(clusterName, format string, gzip bool, t time.Time) string {
+	ext := "txt"
+	switch format {
+	case models.LogExportFormatNDJSON:
+		ext = "ndjson"
+	case models.LogExportFormatCSV:
+		ext = "csv"
+	}
+	name := fmt.Sprintf("logs-%s-%s.%s", clusterName, t.Format("20060102-150405"), ext)
+	if gzip {
+		name += ".gz"
+	}
+	return name
+}
+
+// LogExportContentType 导出内容类型
+func LogExportContentType(format string, gzip bool) string {
+	if gzip {
+		return "application/gzip"
+	}
+	switch format {
+	case models.LogExportFormatNDJSON:
+		return "application/x-ndjson"
+	case models.LogExportFormatCSV:
+		return "text/csv; charset=utf-8"
+	default:
+		return "text/plain; charset=utf-8"
+	}
+}
@@ -0,0 +1,507 @@
+package services
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/constants"
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+	"github.com/clay-wangzhi/KubePolaris/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// logExportCleanupInterval 过期导出文件清理间隔
+const logExportCleanupInterval = 10 * time.Minute
+
+// LogExportOptions 日志导出配置
+type LogExportOptions struct {
+	Dir            string        // 导出文件目录
+	Retention      time.Duration // 导出文件保留时长
+	MaxSyncEntries int64         // 同步流式导出的最大条数
+	MaxJobEntries  int64         // 单个异步任务的最大条数
+	Workers        int           // 并发执行的任务数
+}
+
+// LogExportService 日志导出服务：同步流式导出与异步导出任务
+type LogExportService struct {
+	db           *gorm.DB
+	clusterSvc   *ClusterService
+	logConfigSvc *LogConfigService
+	aggregator   *LogAggregator
+	opLogSvc     *OperationLogService
+	opts         LogExportOptions
+
+	queue   chan uint
+	mu      sync.Mutex
+	cancels map[uint]context.CancelFunc
+}
+
+// NewLogExportService 创建日志导出服务
+func NewLogExportService(db *gorm.DB, clusterSvc *ClusterService, logConfigSvc *LogConfigService, aggregator *LogAggregator, opLogSvc *OperationLogService, opts LogExportOptions) *LogExportService {
+	if opts.Retention <= 0 {
+		opts.Retention = 72 * time.Hour
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = 2
+	}
+	return &LogExportService{
+		db:           db,
+		clusterSvc:   clusterSvc,
+		logConfigSvc: logConfigSvc,
+		aggregator:   aggregator,
+		opLogSvc:     opLogSvc,
+		opts:         opts,
+		queue:        make(chan uint, 100),
+		cancels:      make(map[uint]context.CancelFunc),
+	}
+}
+
+// MaxSyncEntries 同步流式导出的最大条数
+func (s *LogExportService) MaxSyncEntries() int64 {
+	return s.opts.MaxSyncEntries
+}
+
+// Start 启动导出任务执行器，未完成的任务（含重启前中断的任务）从断点继续
+func (s *LogExportService) Start(ctx context.Context) {
+	if err := os.MkdirAll(s.opts.Dir, 0o750); err != nil {
+		logger.Error("创建日志导出目录失败", "dir", s.opts.Dir, "error", err)
+	}
+
+	for i := 0; i < s.opts.Workers; i++ {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case id := <-s.queue:
+					s.runJob(ctx, id)
+				}
+			}
+		}()
+	}
+
+	go func() {
+		var unfinished []models.LogExportJob
+		if err := s.db.Where("status IN ?", []string{models.LogExportStatusPending, models.LogExportStatusRunning}).
+			Order("id ASC").Find(&unfinished).Error; err != nil {
+			logger.Error("加载未完成的日志导出任务失败", "error", err)
+		}
+		for _, job := range unfinished {
+			s.enqueue(ctx, job.ID)
+		}
+
+		ticker := time.NewTicker(logExportCleanupInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.cleanupExpired()
+			}
+		}
+	}()
+	logger.Info("日志导出任务执行器已启动", "dir", s.opts.Dir, "workers", s.opts.Workers)
+}
+
+// Stream 同步流式导出：按批次写入 w，每批写完调用 flush；返回导出条数与是否因达到上限被截断
+func (s *LogExportService) Stream(ctx context.Context, cluster *models.Cluster, query *models.LogQuery, format string, w io.Writer, flush func()) (int64, bool, error) {
+	backend, err := s.logConfigSvc.BackendForCluster(cluster, s.aggregator)
+	if err != nil {
+		return 0, false, fmt.Errorf("获取日志后端失败: %w", err)
+	}
+	encoder, err := NewLogExportEncoder(format, w)
+	if err != nil {
+		return 0, false, err
+	}
+	if err := encoder.WriteHeader(); err != nil {
+		return 0, false, err
+	}
+
+	cursor := &LogExportCursor{}
+	truncated, err := IterateLogs(ctx, backend, query, cursor, s.opts.MaxSyncEntries, func(entries []models.LogEntry) error {
+		if err := encoder.Write(entries); err != nil {
+			return err
+		}
+		flush()
+		return nil
+	})
+	return cursor.Count, truncated, err
+}
+
+// CreateJob 创建异步导出任务，时间范围在创建时固定，保证断点续传结果一致
+func (s *LogExportService) CreateJob(clusterID uint, req *models.LogExportRequest, userID uint, username string) (*models.LogExportJob, error) {
+	cluster, err := s.clusterSvc.GetCluster(clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("集群不存在: %d", clusterID)
+	}
+	if _, err := NewLogExportEncoder(req.Format, io.Discard); err != nil {
+		return nil, err
+	}
+	if _, err := ParseLogFieldFilters(req.Query.Filters); err != nil {
+		return nil, err
+	}
+
+	query := req.Query
+	query.StartTime, query.EndTime = logQueryWindow(&query)
+	query.Offset, query.Limit = 0, 0
+	queryJSON, err := json.Marshal(query)
+	if err != nil {
+		return nil, fmt.Errorf("序列化查询条件失败: %w", err)
+	}
+
+	format := req.Format
+	if format == "" {
+		format = models.LogExportFormatText
+	}
+	job := &models.LogExportJob{
+		ClusterID:   clusterID,
+		ClusterName: cluster.Name,
+		Query:       string(queryJSON),
+		Format:      format,
+		Gzip:        req.Gzip,
+		Status:      models.LogExportStatusPending,
+		FileName:    LogExportFileName(cluster.Name, format, req.Gzip, time.Now()),
+		CreatedBy:   userID,
+		Username:    username,
+	}
+	if err := s.db.Create(job).Error; err != nil {
+		return nil, fmt.Errorf("创建日志导出任务失败: %w", err)
+	}
+	job.FilePath = filepath.Join(s.opts.Dir, fmt.Sprintf("%d-%s", job.ID, job.FileName))
+	if err := s.db.Model(job).Update("file_path", job.FilePath).Error; err != nil {
+		return nil, fmt.Errorf("创建日志导出任务失败: %w", err)
+	}
+
+	s.enqueue(context.Background(), job.ID)
+	return job, nil
+}
+
+// ListJobs 获取集群的导出任务列表
+func (s *LogExportService) ListJobs(clusterID uint) ([]models.LogExportJob, error) {
+	var jobs []models.LogExportJob
+	if err := s.db.Where("cluster_id = ?", clusterID).Order("id DESC").Limit(200).Find(&jobs).Error; err != nil {
+		return nil, fmt.Errorf("查询日志导出任务失败: %w", err)
+	}
+	return jobs, nil
+}
+
+// GetJob 获取导出任务
+func (s *LogExportService) GetJob(clusterID, jobID uint) (*models.LogExportJob, error) {
+	var job models.LogExportJob
+	if err := s.db.Where("cluster_id = ?", clusterID).First(&job, jobID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("日志导出任务不存在: %d", jobID)
+		}
+		return nil, fmt.Errorf("查询日志导出任务失败: %w", err)
+	}
+	return &job, nil
+}
+
+// CancelJob 取消未完成的导出任务，已导出的部分文件被删除
+func (s *LogExportService) CancelJob(clusterID, jobID uint) error {
+	job, err := s.GetJob(clusterID, jobID)
+	if err != nil {
+		return err
+	}
+	if job.Status != models.LogExportStatusPending && job.Status != models.LogExportStatusRunning {
+		return fmt.Errorf("任务状态为 %s，无法取消", job.Status)
+	}
+
+	now := time.Now()
+	if err := s.db.Model(job).Updates(map[string]interface{}{
+		"status":      models.LogExportStatusCanceled,
+		"finished_at": &now,
+	}).Error; err != nil {
+		return fmt.Errorf("取消日志导出任务失败: %w", err)
+	}
+
+	s.mu.Lock()
+	cancel := s.cancels[job.ID]
+	s.mu.Unlock()
+	if cancel != nil {
+		// 执行中的任务退出后自行删除文件
+		cancel()
+	} else {
+		s.removeFile(job)
+	}
+	return nil
+}
+
+// DeleteJob 删除已结束的导出任务及其文件
+func (s *LogExportService) DeleteJob(clusterID, jobID uint) error {
+	job, err := s.GetJob(clusterID, jobID)
+	if err != nil {
+		return err
+	}
+	if job.Status == models.LogExportStatusPending || job.Status == models.LogExportStatusRunning {
+		return fmt.Errorf("任务仍在执行，请先取消")
+	}
+	s.removeFile(job)
+	if err := s.db.Delete(job).Error; err != nil {
+		return fmt.Errorf("删除日志导出任务失败: %w", err)
+	}
+	return nil
+}
+
+// OpenArtifact 打开已完成任务的导出文件
+func (s *LogExportService) OpenArtifact(job *models.LogExportJob) (*os.File, error) {
+	if job.Status != models.LogExportStatusSucceeded {
+		return nil, fmt.Errorf("任务状态为 %s，没有可下载的文件", job.Status)
+	}
+	if job.ExpiresAt != nil && time.Now().After(*job.ExpiresAt) {
+		return nil, fmt.Errorf("导出文件已过期")
+	}
+	file, err := os.Open(job.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("打开导出文件失败: %w", err)
+	}
+	return file, nil
+}
+
+// RecordDownload 记录导出文件下载审计
+func (s *LogExportService) RecordDownload(job *models.LogExportJob, userID uint, username, clientIP string) {
+	s.record(job, constants.ActionDownload, "GET", fmt.Sprintf("/logs/export-jobs/%d/download", job.ID), &userID, username, clientIP, nil)
+}
+
+// enqueue 将任务加入执行队列，队列满时异步等待
+func (s *LogExportService) enqueue(ctx context.Context, id uint) {
+	select {
+	case s.queue <- id:
+	default:
+		go func() {
+			select {
+			case s.queue <- id:
+			case <-ctx.Done():
+			}
+		}()
+	}
+}
+
+// runJob 执行导出任务，每批写入后记录断点
+func (s *LogExportService) runJob(parent context.Context, id uint) {
+	var job models.LogExportJob
+	if err := s.db.First(&job, id).Error; err != nil {
+		logger.Error("加载日志导出任务失败", "id", id, "error", err)
+		return
+	}
+	if job.Status != models.LogExportStatusPending && job.Status != models.LogExportStatusRunning {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(parent)
+	s.mu.Lock()
+	s.cancels[job.ID] = cancel
+	s.mu.Unlock()
+	defer func() {
+		cancel()
+		s.mu.Lock()
+		delete(s.cancels, job.ID)
+		s.mu.Unlock()
+	}()
+
+	now := time.Now()
+	updates := map[string]interface{}{"status": models.LogExportStatusRunning}
+	if job.StartedAt == nil {
+		updates["started_at"] = &now
+	}
+	if err := s.db.Model(&job).Updates(updates).Error; err != nil {
+		logger.Error("更新日志导出任务状态失败", "id", job.ID, "error", err)
+		return
+	}
+
+	truncated, err := s.export(ctx, &job)
+	if ctx.Err() != nil && parent.Err() == nil {
+		// 被用户取消
+		s.removeFile(&job)
+		return
+	}
+	if parent.Err() != nil {
+		// 服务停止，保留断点等待重启后继续
+		return
+	}
+
+	finished := time.Now()
+	if err != nil {
+		logger.Error("日志导出任务失败", "id", job.ID, "error", err)
+		s.removeFile(&job)
+		s.db.Model(&job).Updates(map[string]interface{}{
+			"status":      models.LogExportStatusFailed,
+			"error":       err.Error(),
+			"finished_at": &finished,
+		})
+		job.Status = models.LogExportStatusFailed
+		s.record(&job, constants.ActionExport, "EXPORT", fmt.Sprintf("/logs/export-jobs/%d", job.ID), &job.CreatedBy, job.Username, "", err)
+		return
+	}
+
+	expiresAt := finished.Add(s.opts.Retention)
+	s.db.Model(&job).Updates(map[string]interface{}{
+		"status":      models.LogExportStatusSucceeded,
+		"truncated":   truncated,
+		"finished_at": &finished,
+		"expires_at":  &expiresAt,
+	})
+	job.Status = models.LogExportStatusSucceeded
+	job.Truncated = truncated
+	s.record(&job, constants.ActionExport, "EXPORT", fmt.Sprintf("/logs/export-jobs/%d", job.ID), &job.CreatedBy, job.Username, "", nil)
+	logger.Info("日志导出任务完成", "id", job.ID, "exported", job.Exported, "size", job.FileSize)
+}
+
+// export 写入导出文件；gzip 模式下每批写成独立的 gzip member，断点处截断后追加即可续传
+func (s *LogExportService) export(ctx context.Context, job *models.LogExportJob) (bool, error) {
+	var query models.LogQuery
+	if err := json.Unmarshal([]byte(job.Query), &query); err != nil {
+		return false, fmt.Errorf("解析查询条件失败: %w", err)
+	}
+	cluster, err := s.clusterSvc.GetCluster(job.ClusterID)
+	if err != nil {
+		return false, fmt.Errorf("集群不存在: %d", job.ClusterID)
+	}
+	backend, err := s.logConfigSvc.BackendForCluster(cluster, s.aggregator)
+	if err != nil {
+		return false, fmt.Errorf("获取日志后端失败: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(job.FilePath), 0o750); err != nil {
+		return false, fmt.Errorf("创建导出目录失败: %w", err)
+	}
+	file, err := os.OpenFile(job.FilePath, os.O_CREATE|os.O_WRONLY, 0o640)
+	if err != nil {
+		return false, fmt.Errorf("创建导出文件失败: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	// 丢弃上次断点之后未确认的内容
+	if err := file.Truncate(job.FileSize); err != nil {
+		return false, fmt.Errorf("截断导出文件失败: %w", err)
+	}
+	if _, err := file.Seek(job.FileSize, io.SeekStart); err != nil {
+		return false, fmt.Errorf("定位导出文件失败: %w", err)
+	}
+
+	cursor := &LogExportCursor{Count: job.Exported}
+	if job.Cursor != nil {
+		cursor.Time = *job.Cursor
+	}
+
+	// writeChunk 写入一段内容并记录断点
+	writeChunk := func(write func(enc *LogExportEncoder) error) error {
+		var w io.Writer = file
+		var gz *gzip.Writer
+		if job.Gzip {
+			gz = gzip.NewWriter(file)
+			w = gz
+		}
+		encoder, err := NewLogExportEncoder(job.Format, w)
+		if err != nil {
+			return err
+		}
+		if err := write(encoder); err != nil {
+			return err
+		}
+		if gz != nil {
+			if err := gz.Close(); err != nil {
+				return err
+			}
+		}
+		if err := file.Sync(); err != nil {
+			return err
+		}
+		size, err := file.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+		job.FileSize = size
+		job.Exported = cursor.Count
+		updates := map[string]interface{}{"file_size": job.FileSize, "exported": job.Exported}
+		if !cursor.Time.IsZero() {
+			cursorTime := cursor.Time
+			job.Cursor = &cursorTime
+			updates["cursor"] = &cursorTime
+		}
+		return s.db.Model(job).Updates(updates).Error
+	}
+
+	if job.FileSize == 0 {
+		if err := writeChunk(func(enc *LogExportEncoder) error { return enc.WriteHeader() }); err != nil {
+			return false, fmt.Errorf("写入导出文件失败: %w", err)
+		}
+	}
+
+	return IterateLogs(ctx, backend, &query, cursor, s.opts.MaxJobEntries, func(entries []models.LogEntry) error {
+		return writeChunk(func(enc *LogExportEncoder) error { return enc.Write(entries) })
+	})
+}
+
+// cleanupExpired 删除过期的导出文件
+func (s *LogExportService) cleanupExpired() {
+	var jobs []models.LogExportJob
+	if err := s.db.Where("status = ? AND expires_at < ?", models.LogExportStatusSucceeded, time.Now()).Find(&jobs).Error; err != nil {
+		logger.Error("查询过期日志导出任务失败", "error", err)
+		return
+	}
+	for i := range jobs {
+		s.removeFile(&jobs[i])
+		s.db.Model(&jobs[i]).Update("status", models.LogExportStatusExpired)
+	}
+	if len(jobs) > 0 {
+		logger.Info("已清理过期日志导出文件", "count", len(jobs))
+	}
+}
+
+// removeFile 删除导出文件
+func (s *LogExportService) removeFile(job *models.LogExportJob) {
+	if job.FilePath == "" {
+		return
+	}
+	if err := os.Remove(job.FilePath); err != nil && !os.IsNotExist(err) {
+		logger.Error("删除日志导出文件失败", "path", job.FilePath, "error", err)
+	}
+}
+
+// record 记录导出任务审计
+func (s *LogExportService) record(job *models.LogExportJob, action, method, path string, userID *uint, username, clientIP string, exportErr error) {
+	if s.opLogSvc == nil {
+		return
+	}
+	clusterID := job.ClusterID
+	entry := &LogEntry{
+		UserID:       userID,
+		Username:     username,
+		Method:       method,
+		Path:         path,
+		Module:       constants.ModuleMonitoring,
+		Action:       action,
+		ClusterID:    &clusterID,
+		ClusterName:  job.ClusterName,
+		ResourceType: "log_export_job",
+		ResourceName: job.FileName,
+		RequestBody: map[string]interface{}{
+			"query":     job.Query,
+			"format":    job.Format,
+			"gzip":      job.Gzip,
+			"status":    job.Status,
+			"exported":  job.Exported,
+			"file_size": job.FileSize,
+			"truncated": job.Truncated,
+		},
+		StatusCode: 200,
+		Success:    exportErr == nil,
+		ClientIP:   clientIP,
+	}
+	if exportErr != nil {
+		entry.StatusCode = 500
+		entry.ErrorMessage = exportErr.Error()
+	}
+	s.opLogSvc.RecordAsync(entry)
+}
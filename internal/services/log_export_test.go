@@ -0,0 +1,158 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// fakeLogBackend 按时间正序返回 [StartTime, EndTime) 内日志的内存后端
+type fakeLogBackend struct {
+	entries  []models.LogEntry
+	searches int
+}
+
+func (b *fakeLogBackend) Search(_ context.Context, query *models.LogQuery) ([]models.LogEntry, int, error) {
+	b.searches++
+	var result []models.LogEntry
+	for _, entry := range b.entries {
+		if entry.Timestamp.Before(query.StartTime) || !entry.Timestamp.Before(query.EndTime) {
+			continue
+		}
+		if len(result) == query.Limit {
+			break
+		}
+		result = append(result, entry)
+	}
+	return result, len(result), nil
+}
+
+func (b *fakeLogBackend) Stats(context.Context, *models.LogQuery) (*models.LogStats, error) {
+	return &models.LogStats{}, nil
+}
+
+func (b *fakeLogBackend) Test(context.Context) error { return nil }
+
+// LogExportTestSuite 定义日志导出测试套件
+type LogExportTestSuite struct {
+	suite.Suite
+	base    time.Time
+	backend *fakeLogBackend
+	query   *models.LogQuery
+}
+
+// SetupTest 每 3 条日志共用一个时间戳，使分页边界落在同一时间戳内
+func (s *LogExportTestSuite) SetupTest() {
+	s.base = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.backend = &fakeLogBackend{}
+	for i := 0; i < 2500; i++ {
+		s.backend.entries = append(s.backend.entries, models.LogEntry{
+			Timestamp: s.base.Add(time.Duration(i/3) * time.Second),
+			Namespace: "default",
+			PodName:   "api-0",
+			Message:   fmt.Sprintf("line %d", i),
+		})
+	}
+	s.query = &models.LogQuery{StartTime: s.base, EndTime: s.base.Add(time.Hour)}
+}
+
+// TestIterateLogsPaging 测试按时间游标翻页不重不漏
+func (s *LogExportTestSuite) TestIterateLogsPaging() {
+	var messages []string
+	cursor := &LogExportCursor{}
+	truncated, err := IterateLogs(context.Background(), s.backend, s.query, cursor, 0, func(entries []models.LogEntry) error {
+		for _, entry := range entries {
+			messages = append(messages, entry.Message)
+		}
+		return nil
+	})
+	s.Require().NoError(err)
+	assert.False(s.T(), truncated)
+	s.Require().Len(messages, 2500)
+	for i, msg := range messages {
+		s.Require().Equal(fmt.Sprintf("line %d", i), msg)
+	}
+	assert.Equal(s.T(), int64(2500), cursor.Count)
+}
+
+// TestIterateLogsResume 测试达到上限截断后从断点继续
+func (s *LogExportTestSuite) TestIterateLogsResume() {
+	var messages []string
+	collect := func(entries []models.LogEntry) error {
+		for _, entry := range entries {
+			messages = append(messages, entry.Message)
+		}
+		return nil
+	}
+
+	cursor := &LogExportCursor{}
+	truncated, err := IterateLogs(context.Background(), s.backend, s.query, cursor, 1200, collect)
+	s.Require().NoError(err)
+	assert.True(s.T(), truncated)
+	assert.Equal(s.T(), int64(1200), cursor.Count)
+	assert.Equal(s.T(), s.base.Add(399*time.Second), cursor.Time)
+
+	truncated, err = IterateLogs(context.Background(), s.backend, s.query, cursor, 0, collect)
+	s.Require().NoError(err)
+	assert.False(s.T(), truncated)
+	s.Require().Len(messages, 2500)
+	assert.Equal(s.T(), "line 1200", messages[1200])
+	assert.Equal(s.T(), int64(2500), cursor.Count)
+}
+
+// TestEncoders 测试 CSV / NDJSON / 文本编码
+func (s *LogExportTestSuite) TestEncoders() {
+	entries := []models.LogEntry{{
+		Timestamp: s.base,
+		Level:     "error",
+		Namespace: "default",
+		PodName:   "api-0",
+		TraceID:   "abc",
+		Message:   `failed, "quoted"`,
+		Fields:    map[string]interface{}{"status": 500},
+	}}
+
+	var buf bytes.Buffer
+	encoder, err := NewLogExportEncoder(models.LogExportFormatCSV, &buf)
+	s.Require().NoError(err)
+	s.Require().NoError(encoder.WriteHeader())
+	s.Require().NoError(encoder.Write(entries))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	s.Require().Len(lines, 2)
+	assert.True(s.T(), strings.HasPrefix(lines[0], "timestamp,level,"))
+	assert.Contains(s.T(), lines[1], `"failed, ""quoted"""`)
+	assert.Contains(s.T(), lines[1], `"{""status"":500}"`)
+
+	buf.Reset()
+	encoder, err = NewLogExportEncoder(models.LogExportFormatNDJSON, &buf)
+	s.Require().NoError(err)
+	s.Require().NoError(encoder.Write(append(entries, entries...)))
+	lines = strings.Split(strings.TrimSpace(buf.String()), "\n")
+	s.Require().Len(lines, 2)
+	var decoded models.LogEntry
+	s.Require().NoError(json.Unmarshal([]byte(lines[0]), &decoded))
+	assert.Equal(s.T(), "abc", decoded.TraceID)
+
+	buf.Reset()
+	encoder, err = NewLogExportEncoder("", &buf)
+	s.Require().NoError(err)
+	s.Require().NoError(encoder.Write(entries))
+	assert.Equal(s.T(), "2024-01-01T00:00:00Z [ERROR] [default/api-0] failed, \"quoted\"\n", buf.String())
+
+	_, err = NewLogExportEncoder("xml", &buf)
+	assert.Error(s.T(), err)
+}
+
+// TestLogExportTestSuite 运行测试套件
+func TestLogExportTestSuite(t *testing.T) {
+	suite.Run(t, new(LogExportTestSuite))
+}
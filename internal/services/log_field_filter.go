@@ -0,0 +1,161 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+)
+
+// logFieldFilterPattern 字段过滤表达式：<字段><运算符><值>
+var logFieldFilterPattern = regexp.MustCompile(`^\s*(level|trace_id|fields\.[\w.@-]+)\s*(>=|<=|!=|!~|=~|==|=|>|<)\s*(.*?)\s*$`)
+
+// LogFieldFilter 日志字段过滤条件，如 fields.status>=500
+type LogFieldFilter struct {
+	Field string // level、trace_id 或 fields.<字段路径>
+	Op    string // =, !=, >, >=, <, <=, =~, !~
+	Value string
+
+	number  float64
+	pattern *regexp.Regexp
+}
+
+// ParseLogFieldFilters 解析字段过滤表达式
+func ParseLogFieldFilters(exprs []string) ([]LogFieldFilter, error) {
+	var filters []LogFieldFilter
+	for _, expr := range exprs {
+		if strings.TrimSpace(expr) == "" {
+			continue
+		}
+		m := logFieldFilterPattern.FindStringSubmatch(expr)
+		if m == nil {
+			return nil, fmt.Errorf("无效的字段过滤表达式: %s（支持 level、trace_id、fields.<字段>，运算符 = != > >= < <= =~ !~）", expr)
+		}
+		filter := LogFieldFilter{Field: m[1], Op: m[2], Value: unquoteFilterValue(m[3])}
+		if filter.Op == "==" {
+			filter.Op = "="
+		}
+
+		switch filter.Op {
+		case ">", ">=", "<", "<=":
+			number, err := strconv.ParseFloat(filter.Value, 64)
+			if err != nil || math.IsInf(number, 0) || math.IsNaN(number) {
+				return nil, fmt.Errorf("字段过滤表达式 %s 的比较值必须是数字", expr)
+			}
+			filter.number = number
+		case "=~", "!~":
+			pattern, err := regexp.Compile(filter.Value)
+			if err != nil {
+				return nil, fmt.Errorf("字段过滤表达式 %s 的正则无效: %w", expr, err)
+			}
+			filter.pattern = pattern
+		}
+		filters = append(filters, filter)
+	}
+	return filters, nil
+}
+
+// IsNumeric 是否为数值比较
+func (f LogFieldFilter) IsNumeric() bool {
+	switch f.Op {
+	case ">", ">=", "<", "<=":
+		return true
+	}
+	return false
+}
+
+// NumberString 返回规范化后的比较值，下推到后端查询时使用，避免原始输入中的 Inf、十六进制等写法
+func (f LogFieldFilter) NumberString() string {
+	return strconv.FormatFloat(f.number, 'f', -1, 64)
+}
+
+// FieldPath 返回去掉 fields. 前缀的字段路径
+func (f LogFieldFilter) FieldPath() string {
+	return strings.TrimPrefix(f.Field, "fields.")
+}
+
+// Match 判断日志条目是否满足过滤条件；字段不存在时只有否定条件成立
+func (f LogFieldFilter) Match(entry *models.LogEntry) bool {
+	actual, ok := f.value(entry)
+	if !ok {
+		return f.Op == "!=" || f.Op == "!~"
+	}
+
+	switch f.Op {
+	case "=":
+		return f.equal(actual)
+	case "!=":
+		return !f.equal(actual)
+	case "=~":
+		return f.pattern.MatchString(actual)
+	case "!~":
+		return !f.pattern.MatchString(actual)
+	}
+
+	number, err := strconv.ParseFloat(actual, 64)
+	if err != nil {
+		return false
+	}
+	switch f.Op {
+	case ">":
+		return number > f.number
+	case ">=":
+		return number >= f.number
+	case "<":
+		return number < f.number
+	case "<=":
+		return number <= f.number
+	}
+	return false
+}
+
+// value 取日志条目中的字段值
+func (f LogFieldFilter) value(entry *models.LogEntry) (string, bool) {
+	switch f.Field {
+	case "level":
+		return entry.Level, entry.Level != ""
+	case "trace_id":
+		return entry.TraceID, entry.TraceID != ""
+	}
+	v, ok := lookupLogField(entry.Fields, f.FieldPath())
+	if !ok || v == nil {
+		return "", false
+	}
+	return jsonString(v), true
+}
+
+// equal 相等比较：两侧都是数字时按数值比较，级别忽略大小写
+func (f LogFieldFilter) equal(actual string) bool {
+	if f.Field == "level" {
+		return strings.EqualFold(actual, f.Value)
+	}
+	if a, err := strconv.ParseFloat(actual, 64); err == nil {
+		if b, err := strconv.ParseFloat(f.Value, 64); err == nil {
+			return a == b
+		}
+	}
+	return actual == f.Value
+}
+
+// MatchLogFieldFilters 判断日志条目是否满足全部过滤条件
+func MatchLogFieldFilters(entry *models.LogEntry, filters []LogFieldFilter) bool {
+	for _, filter := range filters {
+		if !filter.Match(entry) {
+			return false
+		}
+	}
+	return true
+}
+
+// unquoteFilterValue 去掉过滤值两侧的引号
+func unquoteFilterValue(value string) string {
+	if len(value) >= 2 {
+		if (value[0] == '"' && value[len(value)-1] == '"') || (value[0] == '\'' && value[len(value)-1] == '\'') {
+			return value[1 : len(value)-1]
+		}
+	}
+	return value
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+	"github.com/clay-wangzhi/KubePolaris/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// LogParseRuleService 日志解析规则服务
+type LogParseRuleService struct {
+	db *gorm.DB
+}
+
+// NewLogParseRuleService 创建日志解析规则服务
+func NewLogParseRuleService(db *gorm.DB) *LogParseRuleService {
+	return &LogParseRuleService{db: db}
+}
+
+// ListRules 获取集群的日志解析规则
+func (s *LogParseRuleService) ListRules(clusterID uint) ([]models.LogParseRule, error) {
+	var rules []models.LogParseRule
+	if err := s.db.Where("cluster_id = ?", clusterID).Order("namespace ASC, workload ASC, id ASC").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("查询日志解析规则失败: %w", err)
+	}
+	return rules, nil
+}
+
+// GetRule 获取日志解析规则
+func (s *LogParseRuleService) GetRule(clusterID, ruleID uint) (*models.LogParseRule, error) {
+	var rule models.LogParseRule
+	if err := s.db.Where("cluster_id = ?", clusterID).First(&rule, ruleID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("日志解析规则不存在: %d", ruleID)
+		}
+		return nil, fmt.Errorf("查询日志解析规则失败: %w", err)
+	}
+	return &rule, nil
+}
+
+// SaveRule 创建或更新日志解析规则，ruleID 为 0 时创建
+func (s *LogParseRuleService) SaveRule(clusterID, ruleID uint, req *models.LogParseRuleRequest, userID uint) (*models.LogParseRule, error) {
+	rule := &models.LogParseRule{ClusterID: clusterID, CreatedBy: userID}
+	if ruleID != 0 {
+		existing, err := s.GetRule(clusterID, ruleID)
+		if err != nil {
+			return nil, err
+		}
+		rule = existing
+	}
+
+	rule.Name = req.Name
+	rule.Namespace = strings.TrimSpace(req.Namespace)
+	rule.Workload = strings.TrimSpace(req.Workload)
+	rule.Format = req.Format
+	rule.Pattern = req.Pattern
+	rule.LevelField = req.LevelField
+	rule.MessageField = req.MessageField
+	rule.TraceIDField = req.TraceIDField
+	rule.FoldStackTrace = req.FoldStackTrace
+	if rule.Format == "" {
+		rule.Format = models.LogFormatAuto
+	}
+	if err := ValidateLogParseRule(rule); err != nil {
+		return nil, err
+	}
+
+	// 同一作用域只允许一条规则
+	var count int64
+	if err := s.db.Model(&models.LogParseRule{}).
+		Where("cluster_id = ? AND namespace = ? AND workload = ? AND id <> ?", clusterID, rule.Namespace, rule.Workload, rule.ID).
+		Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("查询日志解析规则失败: %w", err)
+	}
+	if count > 0 {
+		return nil, fmt.Errorf("该命名空间/工作负载已存在解析规则")
+	}
+
+	if err := s.db.Save(rule).Error; err != nil {
+		return nil, fmt.Errorf("保存日志解析规则失败: %w", err)
+	}
+	return rule, nil
+}
+
+// DeleteRule 删除日志解析规则
+func (s *LogParseRuleService) DeleteRule(clusterID, ruleID uint) error {
+	rule, err := s.GetRule(clusterID, ruleID)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Delete(rule).Error; err != nil {
+		return fmt.Errorf("删除日志解析规则失败: %w", err)
+	}
+	return nil
+}
+
+// ParserForCluster 加载集群的解析规则；未配置数据库或查询失败时使用默认规则
+func (s *LogParseRuleService) ParserForCluster(clusterID uint) *LogParser {
+	if s == nil || s.db == nil {
+		return NewLogParser(nil)
+	}
+	rules, err := s.ListRules(clusterID)
+	if err != nil {
+		logger.Error("加载日志解析规则失败，使用默认规则", "cluster_id", clusterID, "error", err)
+		return NewLogParser(nil)
+	}
+	return NewLogParser(rules)
+}
+
+// ValidateLogParseRule 验证日志解析规则
+func ValidateLogParseRule(rule *models.LogParseRule) error {
+	switch rule.Format {
+	case models.LogFormatAuto, models.LogFormatJSON, models.LogFormatLogfmt, models.LogFormatPlain:
+	case models.LogFormatRegex:
+		if rule.Pattern == "" {
+			return fmt.Errorf("regex 格式需要配置正则表达式")
+		}
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return fmt.Errorf("正则表达式错误: %w", err)
+		}
+		named := false
+		for _, name := range re.SubexpNames() {
+			if name != "" {
+				named = true
+				break
+			}
+		}
+		if !named {
+			return fmt.Errorf("正则表达式需要使用命名分组提取字段，如 (?P<level>\\w+)")
+		}
+	default:
+		return fmt.Errorf("不支持的日志格式: %s", rule.Format)
+	}
+	return nil
+}
+
+// PreviewRule 使用规则解析样例日志，返回解析后的日志条目
+func PreviewRule(rule *models.LogParseRule, lines []string) []models.LogEntry {
+	parser := NewLogParser([]models.LogParseRule{*rule})
+	folder := &stackTraceFolder{}
+	var entries []models.LogEntry
+	emit := func(entry *models.LogEntry) {
+		parser.Apply(entry, rule)
+		entries = append(entries, *entry)
+	}
+	for _, line := range lines {
+		entry := &models.LogEntry{Type: "container", Message: strings.TrimRight(line, " \t\r\n")}
+		if !rule.FoldStackTrace {
+			emit(entry)
+			continue
+		}
+		if done := folder.Add(entry); done != nil {
+			emit(done)
+		}
+	}
+	if done := folder.Flush(); done != nil {
+		emit(done)
+	}
+	return entries
+}
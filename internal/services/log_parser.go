@@ -0,0 +1,394 @@
+package services
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+)
+
+// 结构化日志中常见的字段名，按优先级排列
+var (
+	logLevelKeys   = []string{"level", "lvl", "severity", "loglevel", "log.level", "levelname"}
+	logMessageKeys = []string{"msg", "message", "log", "@message"}
+	logTraceIDKeys = []string{"trace_id", "traceId", "traceID", "traceid", "trace.id", "dd.trace_id"}
+)
+
+var (
+	// logfmtKeyPattern logfmt 自动识别时要求的键名格式
+	logfmtKeyPattern = regexp.MustCompile(`^[A-Za-z_@][\w.@-]*$`)
+	// explicitLevelPattern 文本日志行首的显式级别，如 "2024-01-01 10:00:00 INFO ..."、"[ERROR] ..."
+	explicitLevelPattern = regexp.MustCompile(`(?:^|[\s\[|])(TRACE|DEBUG|INFO|WARN|WARNING|ERROR|FATAL|PANIC|CRITICAL)(?:[\s\]|:]|$)`)
+	// klogLevelPattern klog 格式的级别前缀，如 "E0102 15:04:05.000000 ..."
+	klogLevelPattern = regexp.MustCompile(`^([IWEF])\d{4} \d{2}:\d{2}:\d{2}`)
+)
+
+// defaultLogParseRule 未配置规则时使用：自动识别 JSON/logfmt 并折叠堆栈
+var defaultLogParseRule = models.LogParseRule{
+	Name:           "default",
+	Format:         models.LogFormatAuto,
+	FoldStackTrace: true,
+}
+
+// LogParser 按命名空间/工作负载匹配解析规则并解析日志
+type LogParser struct {
+	rules    []models.LogParseRule
+	patterns map[uint]*regexp.Regexp
+}
+
+// NewLogParser 创建日志解析器，正则无效的规则退化为自动识别
+func NewLogParser(rules []models.LogParseRule) *LogParser {
+	p := &LogParser{rules: rules, patterns: make(map[uint]*regexp.Regexp)}
+	for _, rule := range rules {
+		if rule.Format != models.LogFormatRegex || rule.Pattern == "" {
+			continue
+		}
+		if re, err := regexp.Compile(rule.Pattern); err == nil {
+			p.patterns[rule.ID] = re
+		}
+	}
+	return p
+}
+
+// RuleFor 返回 Pod 适用的解析规则：工作负载 > 命名空间 > 集群默认
+func (p *LogParser) RuleFor(namespace, pod string) *models.LogParseRule {
+	var best *models.LogParseRule
+	bestScore := -1
+	for i := range p.rules {
+		rule := &p.rules[i]
+		if rule.Namespace != "" && rule.Namespace != namespace {
+			continue
+		}
+		if rule.Workload != "" && pod != rule.Workload && !strings.HasPrefix(pod, rule.Workload+"-") {
+			continue
+		}
+		score := 0
+		if rule.Workload != "" {
+			score = 2 + len(rule.Workload)*4
+		}
+		if rule.Namespace != "" {
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = rule, score
+		}
+	}
+	if best == nil {
+		rule := defaultLogParseRule
+		return &rule
+	}
+	return best
+}
+
+// Apply 解析日志条目：提取结构化字段、级别、消息与 Trace ID
+// 多行条目只解析首行，其余行（堆栈）保留在消息末尾
+func (p *LogParser) Apply(entry *models.LogEntry, rule *models.LogParseRule) {
+	header, rest := entry.Message, ""
+	if i := strings.IndexByte(header, '\n'); i >= 0 {
+		header, rest = header[:i], header[i+1:]
+	}
+
+	if fields := p.parseFields(header, rule); len(fields) > 0 {
+		entry.Fields = fields
+		if msg, ok := pickLogField(fields, rule.MessageField, logMessageKeys); ok {
+			entry.Message = msg
+			if rest != "" {
+				entry.Message += "\n" + rest
+			}
+		}
+		if traceID, ok := pickLogField(fields, rule.TraceIDField, logTraceIDKeys); ok {
+			entry.TraceID = traceID
+		}
+		if level, ok := pickLogField(fields, rule.LevelField, logLevelKeys); ok {
+			entry.Level = normalizeLogLevel(level)
+			return
+		}
+	}
+
+	if level := explicitLogLevel(header); level != "" {
+		entry.Level = level
+		return
+	}
+	entry.Level = (&LogAggregator{}).detectLogLevel(entry.Message)
+}
+
+// parseFields 按规则格式解析首行，无法解析时返回 nil
+func (p *LogParser) parseFields(line string, rule *models.LogParseRule) map[string]interface{} {
+	switch rule.Format {
+	case models.LogFormatPlain:
+		return nil
+	case models.LogFormatJSON:
+		return parseJSONFields(line)
+	case models.LogFormatLogfmt:
+		return parseLogfmtFields(line, false)
+	case models.LogFormatRegex:
+		re := p.patterns[rule.ID]
+		if re == nil {
+			return nil
+		}
+		match := re.FindStringSubmatch(line)
+		if match == nil {
+			return nil
+		}
+		fields := make(map[string]interface{})
+		for i, name := range re.SubexpNames() {
+			if name != "" && match[i] != "" {
+				fields[name] = match[i]
+			}
+		}
+		return fields
+	default:
+		if strings.HasPrefix(strings.TrimSpace(line), "{") {
+			return parseJSONFields(line)
+		}
+		return parseLogfmtFields(line, true)
+	}
+}
+
+// parseJSONFields 解析 JSON 日志行，数字保留原始精度
+func parseJSONFields(line string) map[string]interface{} {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "{") {
+		return nil
+	}
+	decoder := json.NewDecoder(strings.NewReader(line))
+	decoder.UseNumber()
+	var fields map[string]interface{}
+	if err := decoder.Decode(&fields); err != nil {
+		return nil
+	}
+	return fields
+}
+
+// parseLogfmtFields 解析 logfmt 日志行
+// strict 为 true 时（自动识别）要求所有片段都是合法的 key=value 且至少两对，避免误判普通文本
+func parseLogfmtFields(line string, strict bool) map[string]interface{} {
+	fields := make(map[string]interface{})
+	pairs := 0
+	i := 0
+	for i < len(line) {
+		for i < len(line) && line[i] == ' ' {
+			i++
+		}
+		if i >= len(line) {
+			break
+		}
+		start := i
+		for i < len(line) && line[i] != '=' && line[i] != ' ' {
+			i++
+		}
+		key := line[start:i]
+		if strict && !logfmtKeyPattern.MatchString(key) {
+			return nil
+		}
+		if i >= len(line) || line[i] == ' ' {
+			// 无值的键
+			if strict {
+				return nil
+			}
+			fields[key] = true
+			continue
+		}
+		i++ // 跳过 '='
+
+		var value string
+		if i < len(line) && line[i] == '"' {
+			j := i + 1
+			for j < len(line) && line[j] != '"' {
+				if line[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(line) {
+				return nil
+			}
+			if unquoted, err := strconv.Unquote(line[i : j+1]); err == nil {
+				value = unquoted
+			} else {
+				value = line[i+1 : j]
+			}
+			i = j + 1
+		} else {
+			start = i
+			for i < len(line) && line[i] != ' ' {
+				i++
+			}
+			value = line[start:i]
+		}
+		if key == "" {
+			return nil
+		}
+		fields[key] = value
+		pairs++
+	}
+	if pairs == 0 || (strict && pairs < 2) {
+		return nil
+	}
+	return fields
+}
+
+// pickLogField 按指定字段名或候选字段名取值
+func pickLogField(fields map[string]interface{}, preferred string, candidates []string) (string, bool) {
+	if preferred != "" {
+		candidates = []string{preferred}
+	}
+	for _, key := range candidates {
+		if v, ok := lookupLogField(fields, key); ok && v != nil {
+			if s, ok := v.(string); ok {
+				return s, s != ""
+			}
+			return jsonString(v), true
+		}
+	}
+	return "", false
+}
+
+// lookupLogField 查找字段，先按完整键名，再按点号分隔的嵌套路径
+func lookupLogField(fields map[string]interface{}, path string) (interface{}, bool) {
+	if fields == nil || path == "" {
+		return nil, false
+	}
+	if v, ok := fields[path]; ok {
+		return v, true
+	}
+	var current interface{} = fields
+	for _, part := range strings.Split(path, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// jsonString 将字段值转为字符串，对象/数组使用 JSON 表示
+func jsonString(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case json.Number:
+		return val.String()
+	default:
+		data, err := json.Marshal(val)
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+}
+
+// explicitLogLevel 识别文本日志行首的显式级别
+func explicitLogLevel(line string) string {
+	if m := klogLevelPattern.FindStringSubmatch(line); m != nil {
+		switch m[1] {
+		case "E", "F":
+			return "error"
+		case "W":
+			return "warn"
+		default:
+			return "info"
+		}
+	}
+	if len(line) > 64 {
+		line = line[:64]
+	}
+	if m := explicitLevelPattern.FindStringSubmatch(line); m != nil {
+		return normalizeLogLevel(m[1])
+	}
+	return ""
+}
+
+// 多行堆栈折叠
+const maxFoldedLines = 500
+
+var (
+	javaExceptionPattern   = regexp.MustCompile(`^([a-zA-Z_$][\w$]*\.)+[\w$]*(Exception|Error|Throwable)(:.*)?$`)
+	javaCausePattern       = regexp.MustCompile(`^(Caused by|Suppressed): `)
+	pythonExceptionPattern = regexp.MustCompile(`^[A-Za-z_][\w.]*(Error|Exception|Exit|Interrupt|Warning)(:.*)?$`)
+	goroutinePattern       = regexp.MustCompile(`^goroutine \d+ \[.+\]:$`)
+	goFramePattern         = regexp.MustCompile(`^[\w./*()\[\]{}$-]+\(.*\)$`)
+)
+
+// 堆栈类型
+const (
+	stackTraceNone   = ""
+	stackTracePython = "python"
+	stackTraceGo     = "go"
+)
+
+// stackTraceFolder 将 Java/Go/Python 多行堆栈折叠到上一条日志（单个容器内使用）
+type stackTraceFolder struct {
+	pending *models.LogEntry
+	lines   int
+	mode    string
+}
+
+// Add 加入一行日志，返回已完整的上一条日志（仍在折叠时返回 nil）
+func (f *stackTraceFolder) Add(entry *models.LogEntry) *models.LogEntry {
+	if f.pending != nil && f.lines < maxFoldedLines && f.isContinuation(entry.Message) {
+		f.pending.Message += "\n" + entry.Message
+		f.lines++
+		f.updateMode(entry.Message)
+		return nil
+	}
+	done := f.pending
+	f.pending, f.lines, f.mode = entry, 1, stackTraceNone
+	f.updateMode(entry.Message)
+	return done
+}
+
+// Flush 取出尚未输出的日志
+func (f *stackTraceFolder) Flush() *models.LogEntry {
+	done := f.pending
+	f.pending, f.lines, f.mode = nil, 0, stackTraceNone
+	return done
+}
+
+// isContinuation 判断该行是否为上一条日志的堆栈续行
+func (f *stackTraceFolder) isContinuation(msg string) bool {
+	if strings.TrimSpace(msg) == "" {
+		// Go panic 堆栈中包含空行
+		return f.mode != stackTraceNone
+	}
+	if msg[0] == ' ' || msg[0] == '\t' {
+		return true
+	}
+	if javaCausePattern.MatchString(msg) || javaExceptionPattern.MatchString(msg) ||
+		goroutinePattern.MatchString(msg) ||
+		strings.HasPrefix(msg, "Traceback (most recent call last):") ||
+		strings.HasPrefix(msg, "During handling of the above exception") ||
+		strings.HasPrefix(msg, "The above exception was the direct cause") {
+		return true
+	}
+	switch f.mode {
+	case stackTracePython:
+		return pythonExceptionPattern.MatchString(msg)
+	case stackTraceGo:
+		return goFramePattern.MatchString(msg) ||
+			strings.HasPrefix(msg, "created by ") ||
+			strings.HasPrefix(msg, "[signal ") ||
+			strings.HasPrefix(msg, "exit status ")
+	}
+	return false
+}
+
+// updateMode 根据当前行识别堆栈类型
+func (f *stackTraceFolder) updateMode(msg string) {
+	switch {
+	case strings.HasPrefix(msg, "Traceback (most recent call last):"),
+		strings.HasPrefix(msg, "During handling of the above exception"),
+		strings.HasPrefix(msg, "The above exception was the direct cause"):
+		f.mode = stackTracePython
+	case strings.HasPrefix(msg, "panic: "), strings.HasPrefix(msg, "fatal error: "), goroutinePattern.MatchString(msg):
+		f.mode = stackTraceGo
+	case f.mode == stackTracePython && pythonExceptionPattern.MatchString(msg):
+		// Python 堆栈以异常行结束
+		f.mode = stackTraceNone
+	}
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// LogParserTestSuite 定义日志解析测试套件
+type LogParserTestSuite struct {
+	suite.Suite
+}
+
+// TestAutoDetect 测试 JSON / logfmt / 文本自动识别
+func (s *LogParserTestSuite) TestAutoDetect() {
+	entries := PreviewRule(&defaultLogParseRule, []string{
+		`{"level":"WARN","msg":"slow request","trace_id":"abc123","http":{"status":503}}`,
+		`time=2024-01-01T00:00:00Z level=error msg="db timeout" status=500`,
+		`2024-01-01 10:00:00.123 INFO [main] connected to errors-service`,
+		`E0102 15:04:05.000000       1 controller.go:42] sync failed`,
+	})
+	s.Require().Len(entries, 4)
+
+	assert.Equal(s.T(), "warn", entries[0].Level)
+	assert.Equal(s.T(), "slow request", entries[0].Message)
+	assert.Equal(s.T(), "abc123", entries[0].TraceID)
+
+	assert.Equal(s.T(), "error", entries[1].Level)
+	assert.Equal(s.T(), "db timeout", entries[1].Message)
+	assert.Equal(s.T(), "500", entries[1].Fields["status"])
+
+	// 显式级别优先于关键词猜测
+	assert.Equal(s.T(), "info", entries[2].Level)
+	assert.Nil(s.T(), entries[2].Fields)
+	assert.Equal(s.T(), "error", entries[3].Level)
+}
+
+// TestFoldStackTraces 测试 Java / Go / Python 堆栈折叠
+func (s *LogParserTestSuite) TestFoldStackTraces() {
+	entries := PreviewRule(&defaultLogParseRule, []string{
+		"2024-01-01 10:00:00 ERROR request failed",
+		"java.lang.IllegalStateException: boom",
+		"\tat com.example.Foo.bar(Foo.java:10)",
+		"Caused by: java.io.IOException: closed",
+		"\t... 3 more",
+		"panic: runtime error: index out of range",
+		"",
+		"goroutine 1 [running]:",
+		"main.main()",
+		"\t/app/main.go:10 +0x1d",
+		"exit status 2",
+		"ERROR:root:handler crashed",
+		"Traceback (most recent call last):",
+		`  File "app.py", line 3, in <module>`,
+		"ValueError: bad value",
+		"INFO next request",
+	})
+	s.Require().Len(entries, 4)
+	assert.Contains(s.T(), entries[0].Message, "Caused by: java.io.IOException")
+	assert.Contains(s.T(), entries[0].Message, "... 3 more")
+	assert.Contains(s.T(), entries[1].Message, "/app/main.go:10")
+	assert.Contains(s.T(), entries[1].Message, "exit status 2")
+	assert.Equal(s.T(), "error", entries[2].Level)
+	assert.Contains(s.T(), entries[2].Message, "ValueError: bad value")
+	assert.Equal(s.T(), "INFO next request", entries[3].Message)
+}
+
+// TestRuleFor 测试规则匹配优先级
+func (s *LogParserTestSuite) TestRuleFor() {
+	parser := NewLogParser([]models.LogParseRule{
+		{ID: 1, Namespace: "", Format: models.LogFormatPlain},
+		{ID: 2, Namespace: "prod", Format: models.LogFormatJSON},
+		{ID: 3, Namespace: "prod", Workload: "api", Format: models.LogFormatLogfmt},
+	})
+	assert.Equal(s.T(), uint(3), parser.RuleFor("prod", "api-7d9f8-abcde").ID)
+	assert.Equal(s.T(), uint(2), parser.RuleFor("prod", "apiserver-0").ID)
+	assert.Equal(s.T(), uint(1), parser.RuleFor("dev", "api-0").ID)
+	assert.Equal(s.T(), "default", NewLogParser(nil).RuleFor("dev", "api-0").Name)
+}
+
+// TestFieldFilters 测试字段过滤表达式
+func (s *LogParserTestSuite) TestFieldFilters() {
+	entry := &models.LogEntry{Message: `{"level":"error","status":502,"http":{"path":"/api/v1"}}`}
+	NewLogParser(nil).Apply(entry, &defaultLogParseRule)
+
+	cases := map[string]bool{
+		"fields.status>=500":        true,
+		"fields.status < 500":       false,
+		"fields.status=502":         true,
+		`fields.http.path=~"^/api"`: true,
+		"fields.missing!=1":         true,
+		"fields.missing=1":          false,
+		"level=ERROR":               true,
+	}
+	for expr, want := range cases {
+		filters, err := ParseLogFieldFilters([]string{expr})
+		s.Require().NoError(err, expr)
+		assert.Equal(s.T(), want, MatchLogFieldFilters(entry, filters), expr)
+	}
+
+	_, err := ParseLogFieldFilters([]string{"fields.status>=abc"})
+	assert.Error(s.T(), err)
+	_, err = ParseLogFieldFilters([]string{"status>=500"})
+	assert.Error(s.T(), err)
+	for _, expr := range []string{"fields.status>Inf", "fields.status<-infinity", "fields.status>=NaN"} {
+		_, err = ParseLogFieldFilters([]string{expr})
+		assert.Error(s.T(), err, expr)
+	}
+}
+
+// TestLogParserTestSuite 运行测试套件
+func TestLogParserTestSuite(t *testing.T) {
+	suite.Run(t, new(LogParserTestSuite))
+}
@@ -0,0 +1,681 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+	"github.com/clay-wangzhi/KubePolaris/pkg/logger"
+
+	"gorm.io/gorm"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+// maintenanceTickInterval 维护调度器的推进周期
+const maintenanceTickInterval = 15 * time.Second
+
+// MaintenanceService 节点维护窗口服务
+// 每个节点的执行阶段都持久化在数据库中，调度器按周期推进状态机，服务重启后可从上次的阶段继续执行
+type MaintenanceService struct {
+	db                    *gorm.DB
+	clusterService        *ClusterService
+	alertManagerConfigSvc *AlertManagerConfigService
+	alertManagerSvc       *AlertManagerService
+	notificationSvc       *NotificationService
+	nodeOpSvc             *NodeOperationService
+
+	// clientFor 获取集群客户端，测试时可替换
+	clientFor func(cluster *models.Cluster) (kubernetes.Interface, error)
+	// mu 串行化调度器与取消/完成信号等人工操作，避免状态被相互覆盖
+	mu sync.Mutex
+}
+
+// NewMaintenanceService 创建节点维护窗口服务
+func NewMaintenanceService(db *gorm.DB, clusterService *ClusterService, alertManagerConfigSvc *AlertManagerConfigService, alertManagerSvc *AlertManagerService, notificationSvc *NotificationService) *MaintenanceService {
+	return &MaintenanceService{
+		db:                    db,
+		clusterService:        clusterService,
+		alertManagerConfigSvc: alertManagerConfigSvc,
+		alertManagerSvc:       alertManagerSvc,
+		notificationSvc:       notificationSvc,
+		nodeOpSvc:             NewNodeOperationService(),
+		clientFor: func(cluster *models.Cluster) (kubernetes.Interface, error) {
+			client, err := NewK8sClientForCluster(cluster)
+			if err != nil {
+				return nil, err
+			}
+			return client.GetClientset(), nil
+		},
+	}
+}
+
+// Start 启动后台调度器，ctx 取消后退出
+func (s *MaintenanceService) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(maintenanceTickInterval)
+		defer ticker.Stop()
+
+		s.reconcileAll(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.reconcileAll(ctx)
+			}
+		}
+	}()
+	logger.Info("节点维护调度器已启动")
+}
+
+// CreateWindow 创建维护窗口，解析目标节点并生成节点任务
+func (s *MaintenanceService) CreateWindow(ctx context.Context, clusterID uint, req *models.CreateMaintenanceWindowRequest, userID uint, username string) (*models.MaintenanceWindow, error) {
+	if !req.EndAt.After(req.StartAt) {
+		return nil, fmt.Errorf("结束时间必须晚于开始时间")
+	}
+	if req.EndAt.Before(time.Now()) {
+		return nil, fmt.Errorf("结束时间不能早于当前时间")
+	}
+
+	cluster, err := s.clusterService.GetCluster(clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("获取集群失败: %w", err)
+	}
+	clientset, err := s.clientFor(cluster)
+	if err != nil {
+		return nil, fmt.Errorf("创建K8s客户端失败: %w", err)
+	}
+	nodeNames, err := s.nodeOpSvc.ResolveNodes(ctx, clientset, req.NodeSelector, req.NodeNames)
+	if err != nil {
+		return nil, err
+	}
+
+	window := &models.MaintenanceWindow{
+		ClusterID:           clusterID,
+		Name:                req.Name,
+		Description:         req.Description,
+		NodeSelector:        req.NodeSelector,
+		StartAt:             req.StartAt,
+		EndAt:               req.EndAt,
+		MaxUnavailable:      req.MaxUnavailable,
+		DrainTimeoutSeconds: req.DrainTimeoutSeconds,
+		WaitTimeoutSeconds:  req.WaitTimeoutSeconds,
+		GracePeriodSeconds:  -1,
+		DeleteLocalData:     req.DeleteLocalData,
+		Force:               req.Force,
+		CreateSilence:       true,
+		Status:              models.MaintenanceStatusScheduled,
+		CreatedBy:           userID,
+		Creator:             username,
+	}
+	if window.MaxUnavailable <= 0 {
+		window.MaxUnavailable = 1
+	}
+	if window.DrainTimeoutSeconds <= 0 {
+		window.DrainTimeoutSeconds = 600
+	}
+	if window.WaitTimeoutSeconds <= 0 {
+		window.WaitTimeoutSeconds = 1800
+	}
+	if req.GracePeriodSeconds != nil {
+		window.GracePeriodSeconds = *req.GracePeriodSeconds
+	}
+	if req.CreateSilence != nil {
+		window.CreateSilence = *req.CreateSilence
+	}
+	namesJSON, _ := json.Marshal(nodeNames)
+	window.NodeNames = string(namesJSON)
+
+	for i, name := range nodeNames {
+		window.Tasks = append(window.Tasks, models.MaintenanceNodeTask{
+			NodeName: name,
+			Sequence: i,
+			Phase:    models.MaintenancePhasePending,
+		})
+	}
+
+	if err := s.db.Create(window).Error; err != nil {
+		return nil, fmt.Errorf("创建维护窗口失败: %w", err)
+	}
+	logger.Info("创建节点维护窗口", "cluster_id", clusterID, "window_id", window.ID, "nodes", len(nodeNames))
+	return window, nil
+}
+
+// ListWindows 获取集群的维护窗口列表
+func (s *MaintenanceService) ListWindows(clusterID uint, status string) ([]models.MaintenanceWindow, error) {
+	var windows []models.MaintenanceWindow
+	query := s.db.Where("cluster_id = ?", clusterID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Order("start_at DESC").Find(&windows).Error; err != nil {
+		return nil, fmt.Errorf("查询维护窗口失败: %w", err)
+	}
+	return windows, nil
+}
+
+// GetWindow 获取维护窗口详情（含节点任务）
+func (s *MaintenanceService) GetWindow(clusterID, windowID uint) (*models.MaintenanceWindow, error) {
+	var window models.MaintenanceWindow
+	err := s.db.Preload("Tasks", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sequence ASC")
+	}).Where("cluster_id = ?", clusterID).First(&window, windowID).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("维护窗口不存在: %d", windowID)
+		}
+		return nil, fmt.Errorf("查询维护窗口失败: %w", err)
+	}
+	return &window, nil
+}
+
+// DeleteWindow 删除维护窗口，执行中的窗口需先取消
+func (s *MaintenanceService) DeleteWindow(clusterID, windowID uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	window, err := s.GetWindow(clusterID, windowID)
+	if err != nil {
+		return err
+	}
+	if window.Status == models.MaintenanceStatusRunning {
+		return fmt.Errorf("维护窗口执行中，请先取消")
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("window_id = ?", windowID).Delete(&models.MaintenanceNodeTask{}).Error; err != nil {
+			return fmt.Errorf("删除节点任务失败: %w", err)
+		}
+		if err := tx.Delete(window).Error; err != nil {
+			return fmt.Errorf("删除维护窗口失败: %w", err)
+		}
+		return nil
+	})
+}
+
+// SignalDone 外部维护完成信号，节点将在下个周期解除封锁
+func (s *MaintenanceService) SignalDone(clusterID, windowID uint, nodeName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	window, err := s.GetWindow(clusterID, windowID)
+	if err != nil {
+		return err
+	}
+	for i := range window.Tasks {
+		task := &window.Tasks[i]
+		if task.NodeName != nodeName {
+			continue
+		}
+		switch task.Phase {
+		case models.MaintenancePhaseDraining, models.MaintenancePhaseWaiting:
+		default:
+			return fmt.Errorf("节点 %s 当前阶段为 %s，无法标记完成", nodeName, task.Phase)
+		}
+		if err := s.db.Model(task).Update("done_signaled", true).Error; err != nil {
+			return fmt.Errorf("更新节点任务失败: %w", err)
+		}
+		return nil
+	}
+	return fmt.Errorf("节点 %s 不在维护窗口中", nodeName)
+}
+
+// CancelWindow 取消维护窗口：未开始的节点跳过，已封锁的节点解除封锁，并删除静默
+func (s *MaintenanceService) CancelWindow(ctx context.Context, clusterID, windowID uint, username string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	window, err := s.GetWindow(clusterID, windowID)
+	if err != nil {
+		return err
+	}
+	if window.IsFinished() {
+		return fmt.Errorf("维护窗口已结束，无法取消")
+	}
+
+	var clientset kubernetes.Interface
+	if window.Status == models.MaintenanceStatusRunning {
+		cluster, err := s.clusterService.GetCluster(clusterID)
+		if err != nil {
+			return fmt.Errorf("获取集群失败: %w", err)
+		}
+		if clientset, err = s.clientFor(cluster); err != nil {
+			return fmt.Errorf("创建K8s客户端失败: %w", err)
+		}
+	}
+
+	now := time.Now()
+	for i := range window.Tasks {
+		task := &window.Tasks[i]
+		switch {
+		case task.Phase == models.MaintenancePhasePending:
+			s.finishTask(task, models.MaintenancePhaseSkipped, "维护窗口已取消", now)
+		case task.IsActive():
+			msg := "维护窗口已取消，已解除封锁"
+			if !task.WasCordoned {
+				if _, err := setNodeUnschedulable(ctx, clientset, task.NodeName, false); err != nil {
+					msg = fmt.Sprintf("维护窗口已取消，解除封锁失败: %v", err)
+				}
+			}
+			s.finishTask(task, models.MaintenancePhaseSkipped, msg, now)
+		}
+	}
+
+	s.deleteSilences(ctx, window)
+	window.Status = models.MaintenanceStatusCancelled
+	window.Message = fmt.Sprintf("由 %s 取消", username)
+	window.FinishedAt = &now
+	return s.saveWindow(window)
+}
+
+// reconcileAll 推进所有到期或执行中的维护窗口
+func (s *MaintenanceService) reconcileAll(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var windows []models.MaintenanceWindow
+	err := s.db.Preload("Tasks", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sequence ASC")
+	}).Where("status = ? OR (status = ? AND start_at <= ?)",
+		models.MaintenanceStatusRunning, models.MaintenanceStatusScheduled, time.Now()).
+		Find(&windows).Error
+	if err != nil {
+		logger.Error("查询待执行的维护窗口失败", "error", err)
+		return
+	}
+
+	for i := range windows {
+		if err := s.reconcileWindow(ctx, &windows[i], time.Now()); err != nil {
+			logger.Error("推进维护窗口失败", "window_id", windows[i].ID, "error", err)
+		}
+	}
+}
+
+// reconcileWindow 推进单个维护窗口的状态机
+func (s *MaintenanceService) reconcileWindow(ctx context.Context, window *models.MaintenanceWindow, now time.Time) error {
+	cluster, err := s.clusterService.GetCluster(window.ClusterID)
+	if err != nil {
+		return fmt.Errorf("获取集群失败: %w", err)
+	}
+	clientset, err := s.clientFor(cluster)
+	if err != nil {
+		return fmt.Errorf("创建K8s客户端失败: %w", err)
+	}
+
+	if window.Status == models.MaintenanceStatusScheduled {
+		if !now.Before(window.EndAt) {
+			// 服务停机期间错过了整个窗口
+			for i := range window.Tasks {
+				s.finishTask(&window.Tasks[i], models.MaintenancePhaseSkipped, "维护窗口已过期，未执行", now)
+			}
+			window.Status = models.MaintenanceStatusCancelled
+			window.Message = "维护窗口已过期，未执行"
+			window.FinishedAt = &now
+			return s.saveWindow(window)
+		}
+		window.Status = models.MaintenanceStatusRunning
+		window.StartedAt = &now
+		s.createSilences(ctx, window)
+		if err := s.saveWindow(window); err != nil {
+			return err
+		}
+		logger.Info("维护窗口开始执行", "window_id", window.ID, "name", window.Name)
+	}
+
+	// 推进处于维护中的节点
+	active, failed := 0, 0
+	for i := range window.Tasks {
+		task := &window.Tasks[i]
+		if task.IsActive() {
+			s.advanceTask(ctx, clientset, cluster, window, task, now)
+		}
+		if task.IsActive() {
+			active++
+		}
+		if task.Phase == models.MaintenancePhaseFailed {
+			failed++
+		}
+	}
+
+	// 有节点失败时不再启动新节点；窗口结束后未开始的节点跳过
+	stopReason := ""
+	if failed > 0 {
+		stopReason = "存在执行失败的节点，已停止后续节点"
+	} else if !now.Before(window.EndAt) {
+		stopReason = "维护窗口已结束，未执行"
+	}
+	for i := range window.Tasks {
+		task := &window.Tasks[i]
+		if task.Phase != models.MaintenancePhasePending {
+			continue
+		}
+		if stopReason != "" {
+			if active == 0 {
+				s.finishTask(task, models.MaintenancePhaseSkipped, stopReason, now)
+			}
+			continue
+		}
+		if active >= window.MaxUnavailable {
+			break
+		}
+		s.startTask(ctx, clientset, window, task, now)
+		if task.IsActive() {
+			active++
+		} else if task.Phase == models.MaintenancePhaseFailed {
+			failed++
+			stopReason = "存在执行失败的节点，已停止后续节点"
+		}
+	}
+
+	if active > 0 || hasPendingTask(window.Tasks) {
+		return nil
+	}
+
+	// 全部节点处理完毕
+	window.FinishedAt = &now
+	if failed > 0 {
+		window.Status = models.MaintenanceStatusFailed
+		window.Message = fmt.Sprintf("%d 个节点执行失败，失败节点保持封锁状态", failed)
+	} else {
+		window.Status = models.MaintenanceStatusCompleted
+		window.Message = ""
+	}
+	s.deleteSilences(ctx, window)
+	logger.Info("维护窗口执行结束", "window_id", window.ID, "status", window.Status)
+	return s.saveWindow(window)
+}
+
+// startTask 检查节点上的 Pod 均可驱逐后封锁节点并进入驱逐阶段
+func (s *MaintenanceService) startTask(ctx context.Context, clientset kubernetes.Interface, window *models.MaintenanceWindow, task *models.MaintenanceNodeTask, now time.Time) {
+	if _, err := listEvictablePods(ctx, clientset, task.NodeName, window.DeleteLocalData, window.Force); err != nil {
+		s.finishTask(task, models.MaintenancePhaseFailed, fmt.Sprintf("驱逐前检查失败: %v", err), now)
+		return
+	}
+	wasCordoned, err := setNodeUnschedulable(ctx, clientset, task.NodeName, true)
+	if err != nil {
+		s.finishTask(task, models.MaintenancePhaseFailed, fmt.Sprintf("封锁节点失败: %v", err), now)
+		return
+	}
+	task.WasCordoned = wasCordoned
+	task.Phase = models.MaintenancePhaseDraining
+	task.StartedAt = &now
+	task.Message = "节点已封锁，开始驱逐 Pod"
+	s.saveTask(task)
+}
+
+// advanceTask 推进单个节点：draining → waiting → uncordoning → completed
+func (s *MaintenanceService) advanceTask(ctx context.Context, clientset kubernetes.Interface, cluster *models.Cluster, window *models.MaintenanceWindow, task *models.MaintenanceNodeTask, now time.Time) {
+	operator := "维护窗口 " + window.Name
+	switch task.Phase {
+	case models.MaintenancePhaseDraining:
+		remaining, blocked, err := drainNodePods(ctx, clientset, task.NodeName, window.DeleteLocalData, window.Force, window.GracePeriodSeconds)
+		if err != nil {
+			s.finishTask(task, models.MaintenancePhaseFailed, fmt.Sprintf("驱逐失败: %v", err), now)
+			s.notificationSvc.NodeDrained(cluster, task.NodeName, operator, err)
+			return
+		}
+		if remaining == 0 {
+			task.Phase = models.MaintenancePhaseWaiting
+			task.DrainedAt = &now
+			task.Message = "驱逐完成，等待维护完成信号"
+			s.saveTask(task)
+			s.notificationSvc.NodeDrained(cluster, task.NodeName, operator, nil)
+			return
+		}
+		if task.StartedAt != nil && now.Sub(*task.StartedAt) > time.Duration(window.DrainTimeoutSeconds)*time.Second {
+			s.finishTask(task, models.MaintenancePhaseFailed,
+				fmt.Sprintf("驱逐超时，剩余 %d 个 Pod（%s），节点保持封锁", remaining, strings.Join(blocked, ", ")), now)
+			s.notificationSvc.NodeDrained(cluster, task.NodeName, operator, errors.New(task.Message))
+			return
+		}
+		task.Message = fmt.Sprintf("剩余 %d 个 Pod 待驱逐", remaining)
+		if len(blocked) > 0 {
+			task.Message += fmt.Sprintf("，受 PDB 限制: %s", strings.Join(blocked, ", "))
+		}
+		s.saveTask(task)
+
+	case models.MaintenancePhaseWaiting:
+		timedOut := task.DrainedAt != nil && now.Sub(*task.DrainedAt) > time.Duration(window.WaitTimeoutSeconds)*time.Second
+		if !task.DoneSignaled && !timedOut {
+			return
+		}
+		task.Phase = models.MaintenancePhaseUncordoning
+		if task.DoneSignaled {
+			task.Message = "已收到维护完成信号，解除封锁"
+		} else {
+			task.Message = "等待完成信号超时，解除封锁"
+		}
+		s.saveTask(task)
+		fallthrough
+
+	case models.MaintenancePhaseUncordoning:
+		if task.WasCordoned {
+			s.finishTask(task, models.MaintenancePhaseCompleted, "维护前节点已封锁，保持封锁状态", now)
+			return
+		}
+		if _, err := setNodeUnschedulable(ctx, clientset, task.NodeName, false); err != nil {
+			// 保持 uncordoning 阶段，下个周期重试
+			task.Message = fmt.Sprintf("解除封锁失败，稍后重试: %v", err)
+			s.saveTask(task)
+			return
+		}
+		s.finishTask(task, models.MaintenancePhaseCompleted, task.Message, now)
+	}
+}
+
+func (s *MaintenanceService) finishTask(task *models.MaintenanceNodeTask, phase, message string, now time.Time) {
+	task.Phase = phase
+	task.Message = message
+	task.FinishedAt = &now
+	s.saveTask(task)
+}
+
+func (s *MaintenanceService) saveTask(task *models.MaintenanceNodeTask) {
+	if err := s.db.Save(task).Error; err != nil {
+		logger.Error("保存维护节点任务失败", "task_id", task.ID, "error", err)
+	}
+}
+
+func (s *MaintenanceService) saveWindow(window *models.MaintenanceWindow) error {
+	if err := s.db.Omit("Tasks").Save(window).Error; err != nil {
+		return fmt.Errorf("保存维护窗口失败: %w", err)
+	}
+	return nil
+}
+
+// createSilences 为维护节点创建 Alertmanager 静默，失败不影响维护执行
+func (s *MaintenanceService) createSilences(ctx context.Context, window *models.MaintenanceWindow) {
+	if !window.CreateSilence || len(window.Tasks) == 0 {
+		return
+	}
+	config, err := s.alertManagerConfigSvc.GetAlertManagerConfig(window.ClusterID)
+	if err != nil || !config.Enabled {
+		return
+	}
+
+	names := make([]string, 0, len(window.Tasks))
+	for _, task := range window.Tasks {
+		names = append(names, regexp.QuoteMeta(task.NodeName))
+	}
+	nodePattern := strings.Join(names, "|")
+
+	// 节点类告警常见的标签为 node 或 instance（可能带端口）
+	matcherSets := [][]models.Matcher{
+		{{Name: "node", Value: nodePattern, IsRegex: true, IsEqual: true}},
+		{{Name: "instance", Value: fmt.Sprintf("(%s)(:.*)?", nodePattern), IsRegex: true, IsEqual: true}},
+	}
+	var ids []string
+	for _, matchers := range matcherSets {
+		silence, err := s.alertManagerSvc.CreateSilence(ctx, config, &models.CreateSilenceRequest{
+			Matchers:  matchers,
+			StartsAt:  time.Now(),
+			EndsAt:    window.EndAt,
+			CreatedBy: window.Creator,
+			Comment:   fmt.Sprintf("KubePolaris 节点维护窗口 #%d: %s", window.ID, window.Name),
+		})
+		if err != nil {
+			logger.Error("创建维护静默失败", "window_id", window.ID, "error", err)
+			continue
+		}
+		ids = append(ids, silence.ID)
+	}
+	window.SilenceIDs = strings.Join(ids, ",")
+}
+
+// deleteSilences 删除维护窗口创建的静默
+func (s *MaintenanceService) deleteSilences(ctx context.Context, window *models.MaintenanceWindow) {
+	if window.SilenceIDs == "" {
+		return
+	}
+	config, err := s.alertManagerConfigSvc.GetAlertManagerConfig(window.ClusterID)
+	if err != nil || !config.Enabled {
+		return
+	}
+	for _, id := range strings.Split(window.SilenceIDs, ",") {
+		if err := s.alertManagerSvc.DeleteSilence(ctx, config, id); err != nil {
+			logger.Error("删除维护静默失败", "window_id", window.ID, "silence_id", id, "error", err)
+		}
+	}
+	window.SilenceIDs = ""
+}
+
+func hasPendingTask(tasks []models.MaintenanceNodeTask) bool {
+	for _, task := range tasks {
+		if task.Phase == models.MaintenancePhasePending {
+			return true
+		}
+	}
+	return false
+}
+
+// setNodeUnschedulable 设置节点封锁状态，返回修改前的封锁状态
+func setNodeUnschedulable(ctx context.Context, clientset kubernetes.Interface, nodeName string, unschedulable bool) (bool, error) {
+	previous := false
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		node, err := clientset.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		previous = node.Spec.Unschedulable
+		if previous == unschedulable {
+			return nil
+		}
+		node.Spec.Unschedulable = unschedulable
+		_, err = clientset.CoreV1().Nodes().Update(ctx, node, metav1.UpdateOptions{})
+		return err
+	})
+	return previous, err
+}
+
+// listEvictablePods 列出节点上需要驱逐的 Pod，与 kubectl drain 一致：
+// 存在使用 emptyDir 的 Pod（未开启 deleteLocalData）或没有控制器管理的 Pod（未开启 force）时返回错误，不驱逐任何 Pod
+func listEvictablePods(ctx context.Context, clientset kubernetes.Interface, nodeName string, deleteLocalData, force bool) ([]*corev1.Pod, error) {
+	list, err := clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("获取节点Pod列表失败: %w", err)
+	}
+
+	var pods []*corev1.Pod
+	var localStorage, unmanaged []string
+	for i := range list.Items {
+		pod := &list.Items[i]
+		if !podNeedsEviction(pod) {
+			continue
+		}
+		if !deleteLocalData && podHasLocalStorage(pod) {
+			localStorage = append(localStorage, pod.Namespace+"/"+pod.Name)
+		}
+		if !force && metav1.GetControllerOf(pod) == nil {
+			unmanaged = append(unmanaged, pod.Namespace+"/"+pod.Name)
+		}
+		pods = append(pods, pod)
+	}
+
+	var problems []string
+	if len(localStorage) > 0 {
+		problems = append(problems, fmt.Sprintf("Pod %s 使用 emptyDir 本地存储，需开启 deleteLocalData", strings.Join(localStorage, ", ")))
+	}
+	if len(unmanaged) > 0 {
+		problems = append(problems, fmt.Sprintf("Pod %s 没有控制器管理，驱逐后不会重建，需开启 force", strings.Join(unmanaged, ", ")))
+	}
+	if len(problems) > 0 {
+		return nil, errors.New(strings.Join(problems, "；"))
+	}
+	return pods, nil
+}
+
+// drainNodePods 通过 Eviction API 驱逐节点上的 Pod（遵守 PDB），驱逐前检查全部 Pod，任一 Pod 不可驱逐时不驱逐任何 Pod
+// 返回仍未驱逐完成的 Pod 数量，以及本轮因 PDB 限制被拒绝的 Pod
+func drainNodePods(ctx context.Context, clientset kubernetes.Interface, nodeName string, deleteLocalData, force bool, gracePeriodSeconds int) (int, []string, error) {
+	pods, err := listEvictablePods(ctx, clientset, nodeName, deleteLocalData, force)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	remaining := 0
+	var blocked []string
+	for _, pod := range pods {
+		remaining++
+		if pod.DeletionTimestamp != nil {
+			// 已在终止中，等待其退出
+			continue
+		}
+
+		eviction := &policyv1.Eviction{
+			ObjectMeta: metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace},
+		}
+		if gracePeriodSeconds >= 0 {
+			grace := int64(gracePeriodSeconds)
+			eviction.DeleteOptions = &metav1.DeleteOptions{GracePeriodSeconds: &grace}
+		}
+		err := clientset.CoreV1().Pods(pod.Namespace).EvictV1(ctx, eviction)
+		switch {
+		case err == nil:
+		case apierrors.IsNotFound(err):
+			remaining--
+		case apierrors.IsTooManyRequests(err):
+			// PDB 不允许此时驱逐，下个周期重试
+			blocked = append(blocked, pod.Namespace+"/"+pod.Name)
+		default:
+			return 0, nil, fmt.Errorf("驱逐 Pod %s/%s 失败: %w", pod.Namespace, pod.Name, err)
+		}
+	}
+	return remaining, blocked, nil
+}
+
+// podNeedsEviction 判断 Pod 是否需要驱逐（跳过 DaemonSet、静态 Pod 和已结束的 Pod）
+func podNeedsEviction(pod *corev1.Pod) bool {
+	if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		return false
+	}
+	if _, ok := pod.Annotations[corev1.MirrorPodAnnotationKey]; ok {
+		return false
+	}
+	if kind, _ := podOwner(pod); kind == "DaemonSet" {
+		return false
+	}
+	return true
+}
+
+func podHasLocalStorage(pod *corev1.Pod) bool {
+	for _, volume := range pod.Spec.Volumes {
+		if volume.EmptyDir != nil {
+			return true
+		}
+	}
+	return false
+}
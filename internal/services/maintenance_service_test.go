@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// MaintenanceServiceTestSuite 定义节点维护服务测试套件
+type MaintenanceServiceTestSuite struct {
+	suite.Suite
+	clientset *fake.Clientset
+}
+
+// SetupTest 每个测试前的设置
+func (s *MaintenanceServiceTestSuite) SetupTest() {
+	isController := true
+	s.clientset = fake.NewSimpleClientset(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-a"}},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "web",
+				Namespace:       "default",
+				OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "web-5d8f", Controller: &isController}},
+			},
+			Spec:   corev1.PodSpec{NodeName: "node-a"},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "db",
+				Namespace:       "default",
+				OwnerReferences: []metav1.OwnerReference{{Kind: "StatefulSet", Name: "db", Controller: &isController}},
+			},
+			Spec:   corev1.PodSpec{NodeName: "node-a"},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "agent",
+				Namespace:       "kube-system",
+				OwnerReferences: []metav1.OwnerReference{{Kind: "DaemonSet", Name: "agent", Controller: &isController}},
+			},
+			Spec:   corev1.PodSpec{NodeName: "node-a"},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "etcd-node-a",
+				Namespace:   "kube-system",
+				Annotations: map[string]string{corev1.MirrorPodAnnotationKey: "hash"},
+			},
+			Spec:   corev1.PodSpec{NodeName: "node-a"},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+	)
+}
+
+// TestSetNodeUnschedulable 测试封锁节点并返回原状态
+func (s *MaintenanceServiceTestSuite) TestSetNodeUnschedulable() {
+	previous, err := setNodeUnschedulable(context.Background(), s.clientset, "node-a", true)
+	s.Require().NoError(err)
+	assert.False(s.T(), previous)
+
+	previous, err = setNodeUnschedulable(context.Background(), s.clientset, "node-a", true)
+	s.Require().NoError(err)
+	assert.True(s.T(), previous)
+
+	node, err := s.clientset.CoreV1().Nodes().Get(context.Background(), "node-a", metav1.GetOptions{})
+	s.Require().NoError(err)
+	assert.True(s.T(), node.Spec.Unschedulable)
+}
+
+// TestDrainNodePods_PDBBlocked 测试驱逐跳过 DaemonSet/静态 Pod，PDB 拒绝的 Pod 留待重试
+func (s *MaintenanceServiceTestSuite) TestDrainNodePods_PDBBlocked() {
+	evicted := []string{}
+	s.clientset.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "eviction" {
+			return false, nil, nil
+		}
+		name := action.(k8stesting.CreateAction).GetObject().(metav1.Object).GetName()
+		if name == "db" {
+			return true, nil, apierrors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 10)
+		}
+		evicted = append(evicted, name)
+		return true, nil, nil
+	})
+
+	remaining, blocked, err := drainNodePods(context.Background(), s.clientset, "node-a", false, false, -1)
+	s.Require().NoError(err)
+	assert.Equal(s.T(), 2, remaining)
+	assert.Equal(s.T(), []string{"default/db"}, blocked)
+	assert.Equal(s.T(), []string{"web"}, evicted)
+}
+
+// recordEvictions 记录被驱逐的 Pod
+func (s *MaintenanceServiceTestSuite) recordEvictions() *[]string {
+	evicted := []string{}
+	s.clientset.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "eviction" {
+			return false, nil, nil
+		}
+		evicted = append(evicted, action.(k8stesting.CreateAction).GetObject().(metav1.Object).GetName())
+		return true, nil, nil
+	})
+	return &evicted
+}
+
+// TestDrainNodePods_LocalStorage 测试未开启 deleteLocalData 时拒绝驱逐，且不驱逐任何 Pod
+func (s *MaintenanceServiceTestSuite) TestDrainNodePods_LocalStorage() {
+	isController := true
+	_, err := s.clientset.CoreV1().Pods("default").Create(context.Background(), &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "cache",
+			Namespace:       "default",
+			OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "cache-7c9d", Controller: &isController}},
+		},
+		Spec: corev1.PodSpec{
+			NodeName: "node-a",
+			Volumes:  []corev1.Volume{{Name: "tmp", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}, metav1.CreateOptions{})
+	s.Require().NoError(err)
+	evicted := s.recordEvictions()
+
+	_, _, err = drainNodePods(context.Background(), s.clientset, "node-a", false, false, -1)
+	assert.ErrorContains(s.T(), err, "default/cache")
+	assert.Empty(s.T(), *evicted, "检查失败时不驱逐其他 Pod")
+
+	remaining, _, err := drainNodePods(context.Background(), s.clientset, "node-a", true, false, -1)
+	s.Require().NoError(err)
+	assert.Equal(s.T(), 3, remaining)
+	assert.ElementsMatch(s.T(), []string{"web", "db", "cache"}, *evicted)
+}
+
+// TestDrainNodePods_Unmanaged 测试未开启 force 时拒绝驱逐没有控制器管理的 Pod
+func (s *MaintenanceServiceTestSuite) TestDrainNodePods_Unmanaged() {
+	_, err := s.clientset.CoreV1().Pods("default").Create(context.Background(), &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "debug", Namespace: "default"},
+		Spec:       corev1.PodSpec{NodeName: "node-a"},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}, metav1.CreateOptions{})
+	s.Require().NoError(err)
+	evicted := s.recordEvictions()
+
+	_, _, err = drainNodePods(context.Background(), s.clientset, "node-a", true, false, -1)
+	assert.ErrorContains(s.T(), err, "default/debug 没有控制器管理")
+	assert.Empty(s.T(), *evicted)
+
+	remaining, _, err := drainNodePods(context.Background(), s.clientset, "node-a", true, true, -1)
+	s.Require().NoError(err)
+	assert.Equal(s.T(), 3, remaining)
+	assert.ElementsMatch(s.T(), []string{"web", "db", "debug"}, *evicted)
+}
+
+// TestMaintenanceServiceSuite 运行测试套件
+func TestMaintenanceServiceSuite(t *testing.T) {
+	suite.Run(t, new(MaintenanceServiceTestSuite))
+}
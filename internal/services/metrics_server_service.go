@@ -0,0 +1,457 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+	"github.com/clay-wangzhi/KubePolaris/pkg/logger"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// metrics-server 采样参数
+const (
+	metricsServerSampleInterval = 30 * time.Second // 后台采样周期
+	metricsServerCacheTTL       = 15 * time.Second // 与 metrics-server 默认采集周期一致，期间重复请求直接使用缓存
+	metricsServerHistorySize    = 120              // 每个节点 / Pod 保留的采样点数（30 秒一次约 1 小时）
+	metricsServerHistoryIdle    = 10 * time.Minute // 超过该时长未再出现的节点 / Pod 历史会被清理
+	metricsServerNodesPath      = "/apis/metrics.k8s.io/v1beta1/nodes"
+	metricsServerPodsPath       = "/apis/metrics.k8s.io/v1beta1/pods"
+)
+
+// ResourceMetricsProvider 实时资源用量提供方，未配置 Prometheus 时用于获取节点与 Pod 的当前 CPU / 内存用量
+type ResourceMetricsProvider interface {
+	// NodeUsage 返回各节点当前用量，key 为节点名
+	NodeUsage(ctx context.Context, clusterID uint) (map[string]models.ResourceUsage, error)
+	// PodUsage 返回各 Pod 当前用量，key 为 namespace/name；namespace 为空表示所有命名空间
+	PodUsage(ctx context.Context, clusterID uint, namespace string) (map[string]models.ResourceUsage, error)
+}
+
+// metricsServerList metrics.k8s.io NodeMetricsList / PodMetricsList 中用到的字段
+type metricsServerList struct {
+	Items []struct {
+		Metadata struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+		Timestamp  time.Time                     `json:"timestamp"`
+		Usage      map[string]resource.Quantity  `json:"usage"`
+		Containers []metricsServerContainerUsage `json:"containers"`
+	} `json:"items"`
+}
+
+type metricsServerContainerUsage struct {
+	Name  string                       `json:"name"`
+	Usage map[string]resource.Quantity `json:"usage"`
+}
+
+// usageRing 固定容量的采样环形缓冲区
+type usageRing struct {
+	samples  []models.ResourceUsage
+	next     int
+	full     bool
+	lastSeen time.Time
+}
+
+func (r *usageRing) add(sample models.ResourceUsage) {
+	if r.samples == nil {
+		r.samples = make([]models.ResourceUsage, metricsServerHistorySize)
+	}
+	// metrics-server 在一个采集周期内返回相同时间戳，重复的不再记录
+	if last, ok := r.latest(); ok && last.Timestamp >= sample.Timestamp {
+		return
+	}
+	r.samples[r.next] = sample
+	r.next = (r.next + 1) % len(r.samples)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+func (r *usageRing) latest() (models.ResourceUsage, bool) {
+	if r.samples == nil || (!r.full && r.next == 0) {
+		return models.ResourceUsage{}, false
+	}
+	return r.samples[(r.next-1+len(r.samples))%len(r.samples)], true
+}
+
+// list 按时间顺序返回全部采样
+func (r *usageRing) list() []models.ResourceUsage {
+	if r.samples == nil {
+		return nil
+	}
+	if !r.full {
+		return append([]models.ResourceUsage(nil), r.samples[:r.next]...)
+	}
+	out := make([]models.ResourceUsage, 0, len(r.samples))
+	out = append(out, r.samples[r.next:]...)
+	return append(out, r.samples[:r.next]...)
+}
+
+type metricsServerCacheEntry struct {
+	usage     map[string]models.ResourceUsage
+	fetchedAt time.Time
+}
+
+// MetricsServerService 基于 metrics-server（metrics.k8s.io）的实时资源用量服务。
+// 未配置 Prometheus 的集群由后台采样器定期采集，并在内存环形缓冲区中保留最近约 1 小时的数据用于趋势小图
+type MetricsServerService struct {
+	clusterService      *ClusterService
+	monitoringConfigSvc *MonitoringConfigService
+
+	// clientFor 获取集群客户端，fetchRaw 请求 metrics.k8s.io 接口，测试时可替换
+	clientFor func(clusterID uint) (kubernetes.Interface, error)
+	fetchRaw  func(ctx context.Context, client kubernetes.Interface, path string) ([]byte, error)
+	now       func() time.Time
+
+	mu      sync.Mutex
+	cache   map[string]*metricsServerCacheEntry
+	history map[string]*usageRing
+}
+
+// NewMetricsServerService 创建 metrics-server 用量服务
+func NewMetricsServerService(clusterService *ClusterService, monitoringConfigSvc *MonitoringConfigService) *MetricsServerService {
+	return &MetricsServerService{
+		clusterService:      clusterService,
+		monitoringConfigSvc: monitoringConfigSvc,
+		clientFor: func(clusterID uint) (kubernetes.Interface, error) {
+			cluster, err := clusterService.GetCluster(clusterID)
+			if err != nil {
+				return nil, err
+			}
+			client, err := NewK8sClientForCluster(cluster)
+			if err != nil {
+				return nil, err
+			}
+			return client.GetClientset(), nil
+		},
+		fetchRaw: func(ctx context.Context, client kubernetes.Interface, path string) ([]byte, error) {
+			return client.CoreV1().RESTClient().Get().AbsPath(path).DoRaw(ctx)
+		},
+		now:     time.Now,
+		cache:   make(map[string]*metricsServerCacheEntry),
+		history: make(map[string]*usageRing),
+	}
+}
+
+// Start 启动后台采样器：为监控类型为 disabled 的集群定期采集节点与 Pod 用量，ctx 取消后退出
+func (s *MetricsServerService) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(metricsServerSampleInterval)
+		defer ticker.Stop()
+
+		for {
+			s.sampleAll(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (s *MetricsServerService) sampleAll(ctx context.Context) {
+	clusters, err := s.clusterService.GetAllClusters()
+	if err != nil {
+		logger.Error("metrics-server 采样获取集群列表失败", "error", err)
+		return
+	}
+	for _, cluster := range clusters {
+		if !s.Enabled(cluster.ID) {
+			continue
+		}
+		// 采样失败（如集群未安装 metrics-server）只记录调试日志，页面请求时会返回具体错误
+		if _, err := s.NodeUsage(ctx, cluster.ID); err != nil {
+			logger.Debug("metrics-server 节点采样失败", "cluster", cluster.Name, "error", err)
+			continue
+		}
+		if _, err := s.PodUsage(ctx, cluster.ID, ""); err != nil {
+			logger.Debug("metrics-server Pod 采样失败", "cluster", cluster.Name, "error", err)
+		}
+	}
+	s.pruneHistory()
+}
+
+// Enabled 集群是否使用 metrics-server 作为用量来源（即未配置 Prometheus / VictoriaMetrics）
+func (s *MetricsServerService) Enabled(clusterID uint) bool {
+	if s.monitoringConfigSvc == nil {
+		return true
+	}
+	config, err := s.monitoringConfigSvc.GetMonitoringConfig(clusterID)
+	return err == nil && config.Type == "disabled"
+}
+
+// NodeUsage 获取各节点当前用量，key 为节点名
+func (s *MetricsServerService) NodeUsage(ctx context.Context, clusterID uint) (map[string]models.ResourceUsage, error) {
+	return s.usage(ctx, clusterID, "node", metricsServerNodesPath)
+}
+
+// PodUsage 获取各 Pod 当前用量（容器用量之和），key 为 namespace/name；namespace 为空表示所有命名空间
+func (s *MetricsServerService) PodUsage(ctx context.Context, clusterID uint, namespace string) (map[string]models.ResourceUsage, error) {
+	path := metricsServerPodsPath
+	if namespace != "" {
+		path = "/apis/metrics.k8s.io/v1beta1/namespaces/" + namespace + "/pods"
+	}
+	return s.usage(ctx, clusterID, "pod", path)
+}
+
+// NodeHistory 返回节点最近的采样（时间升序）
+func (s *MetricsServerService) NodeHistory(clusterID uint, nodeName string) []models.ResourceUsage {
+	return s.historyFor(metricsHistoryKey(clusterID, "node", nodeName))
+}
+
+// PodHistory 返回 Pod 最近的采样（时间升序）
+func (s *MetricsServerService) PodHistory(clusterID uint, namespace, podName string) []models.ResourceUsage {
+	return s.historyFor(metricsHistoryKey(clusterID, "pod", namespace+"/"+podName))
+}
+
+func (s *MetricsServerService) historyFor(key string) []models.ResourceUsage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ring, ok := s.history[key]; ok {
+		return ring.list()
+	}
+	return nil
+}
+
+func (s *MetricsServerService) usage(ctx context.Context, clusterID uint, kind, path string) (map[string]models.ResourceUsage, error) {
+	cacheKey := fmt.Sprintf("%d|%s", clusterID, path)
+	now := s.now()
+	s.mu.Lock()
+	if entry, ok := s.cache[cacheKey]; ok && now.Sub(entry.fetchedAt) < metricsServerCacheTTL {
+		s.mu.Unlock()
+		return entry.usage, nil
+	}
+	s.mu.Unlock()
+
+	client, err := s.clientFor(clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("获取集群客户端失败: %w", err)
+	}
+	raw, err := s.fetchRaw(ctx, client, path)
+	if err != nil {
+		return nil, fmt.Errorf("查询 metrics-server 失败（请确认集群已安装 metrics-server）: %w", err)
+	}
+	usage, err := parseMetricsServerList(raw, kind == "pod")
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.cache[cacheKey] = &metricsServerCacheEntry{usage: usage, fetchedAt: now}
+	for key, u := range usage {
+		historyKey := metricsHistoryKey(clusterID, kind, key)
+		ring, ok := s.history[historyKey]
+		if !ok {
+			ring = &usageRing{}
+			s.history[historyKey] = ring
+		}
+		ring.add(u)
+		ring.lastSeen = now
+	}
+	s.mu.Unlock()
+	return usage, nil
+}
+
+// pruneHistory 清理长时间未出现的节点 / Pod（已删除的 Pod）的历史与过期缓存
+func (s *MetricsServerService) pruneHistory() {
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, ring := range s.history {
+		if now.Sub(ring.lastSeen) > metricsServerHistoryIdle {
+			delete(s.history, key)
+		}
+	}
+	for key, entry := range s.cache {
+		if now.Sub(entry.fetchedAt) > metricsServerCacheTTL {
+			delete(s.cache, key)
+		}
+	}
+}
+
+// NodeUsageRates 获取各节点相对可分配资源的 CPU / 内存使用率，key 为节点名
+func (s *MetricsServerService) NodeUsageRates(ctx context.Context, clusterID uint) (map[string]models.NodeUsageRate, error) {
+	usage, err := s.NodeUsage(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	client, err := s.clientFor(clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("获取集群客户端失败: %w", err)
+	}
+	nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取节点列表失败: %w", err)
+	}
+
+	rates := make(map[string]models.NodeUsageRate, len(nodes.Items))
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		if u, ok := usage[node.Name]; ok {
+			rates[node.Name] = nodeUsageRate(node, u)
+		}
+	}
+	return rates, nil
+}
+
+// NodeMetricsData 构建节点监控数据：CPU / 内存为相对可分配资源的使用率，趋势来自内存中的采样
+func (s *MetricsServerService) NodeMetricsData(ctx context.Context, clusterID uint, nodeName string) (*models.ClusterMetricsData, error) {
+	usage, err := s.NodeUsage(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	current, ok := usage[nodeName]
+	if !ok {
+		return nil, fmt.Errorf("metrics-server 中没有节点 %s 的数据", nodeName)
+	}
+	client, err := s.clientFor(clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("获取集群客户端失败: %w", err)
+	}
+	node, err := client.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取节点失败: %w", err)
+	}
+
+	history := s.NodeHistory(clusterID, nodeName)
+	if len(history) == 0 {
+		history = []models.ResourceUsage{current}
+	}
+	return &models.ClusterMetricsData{
+		CPU: usageSeries(history, func(u models.ResourceUsage) float64 { return nodeUsageRate(node, u).CPUUsageRate }),
+		Memory: usageSeries(history, func(u models.ResourceUsage) float64 {
+			return nodeUsageRate(node, u).MemoryUsageRate
+		}),
+		CPUUsageAbsolute: usageSeries(history, func(u models.ResourceUsage) float64 { return u.CPU }),
+		MemoryUsageBytes: usageSeries(history, func(u models.ResourceUsage) float64 { return u.Memory }),
+	}, nil
+}
+
+// PodMetricsData 构建 Pod 监控数据：CPU / 内存使用率相对 limit（未设置 limit 时为 0），同时返回绝对用量与 request / limit
+func (s *MetricsServerService) PodMetricsData(ctx context.Context, clusterID uint, namespace, podName string) (*models.ClusterMetricsData, error) {
+	usage, err := s.PodUsage(ctx, clusterID, namespace)
+	if err != nil {
+		return nil, err
+	}
+	current, ok := usage[namespace+"/"+podName]
+	if !ok {
+		return nil, fmt.Errorf("metrics-server 中没有 Pod %s/%s 的数据", namespace, podName)
+	}
+	client, err := s.clientFor(clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("获取集群客户端失败: %w", err)
+	}
+	pod, err := client.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取 Pod 失败: %w", err)
+	}
+
+	cpuRequest, cpuLimit := PodResourceTotals(pod, corev1.ResourceCPU)
+	memRequest, memLimit := PodResourceTotals(pod, corev1.ResourceMemory)
+	history := s.PodHistory(clusterID, namespace, podName)
+	if len(history) == 0 {
+		history = []models.ResourceUsage{current}
+	}
+	constant := func(v float64) *models.MetricSeries {
+		return usageSeries(history, func(models.ResourceUsage) float64 { return v })
+	}
+	return &models.ClusterMetricsData{
+		CPU:              usageSeries(history, func(u models.ResourceUsage) float64 { return percentOf(u.CPU, cpuLimit) }),
+		Memory:           usageSeries(history, func(u models.ResourceUsage) float64 { return percentOf(u.Memory, memLimit) }),
+		CPUUsageAbsolute: usageSeries(history, func(u models.ResourceUsage) float64 { return u.CPU }),
+		MemoryUsageBytes: usageSeries(history, func(u models.ResourceUsage) float64 { return u.Memory }),
+		CPURequest:       constant(cpuRequest),
+		CPULimit:         constant(cpuLimit),
+		MemoryRequest:    constant(memRequest),
+		MemoryLimit:      constant(memLimit),
+	}, nil
+}
+
+// PodResourceTotals 汇总 Pod 内容器的 request 与 limit；CPU 单位为 cores，内存单位为 bytes
+func PodResourceTotals(pod *corev1.Pod, name corev1.ResourceName) (request, limit float64) {
+	for _, container := range pod.Spec.Containers {
+		if q, ok := container.Resources.Requests[name]; ok {
+			request += quantityValue(q, name)
+		}
+		if q, ok := container.Resources.Limits[name]; ok {
+			limit += quantityValue(q, name)
+		}
+	}
+	return request, limit
+}
+
+func quantityValue(q resource.Quantity, name corev1.ResourceName) float64 {
+	if name == corev1.ResourceCPU {
+		return float64(q.MilliValue()) / 1000
+	}
+	return float64(q.Value())
+}
+
+// parseMetricsServerList 解析 NodeMetricsList / PodMetricsList；Pod 的用量为各容器之和，key 为 namespace/name
+func parseMetricsServerList(raw []byte, pods bool) (map[string]models.ResourceUsage, error) {
+	var list metricsServerList
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return nil, fmt.Errorf("解析 metrics-server 响应失败: %w", err)
+	}
+
+	usage := make(map[string]models.ResourceUsage, len(list.Items))
+	for _, item := range list.Items {
+		u := models.ResourceUsage{Timestamp: item.Timestamp.Unix()}
+		key := item.Metadata.Name
+		if pods {
+			key = item.Metadata.Namespace + "/" + item.Metadata.Name
+			for _, c := range item.Containers {
+				u.CPU += quantityValue(c.Usage["cpu"], corev1.ResourceCPU)
+				u.Memory += quantityValue(c.Usage["memory"], corev1.ResourceMemory)
+			}
+		} else {
+			u.CPU = quantityValue(item.Usage["cpu"], corev1.ResourceCPU)
+			u.Memory = quantityValue(item.Usage["memory"], corev1.ResourceMemory)
+		}
+		usage[key] = u
+	}
+	return usage, nil
+}
+
+// nodeUsageRate 计算相对可分配资源的使用率，节点未上报 allocatable 时使用 capacity
+func nodeUsageRate(node *corev1.Node, u models.ResourceUsage) models.NodeUsageRate {
+	resources := node.Status.Allocatable
+	if len(resources) == 0 {
+		resources = node.Status.Capacity
+	}
+	return models.NodeUsageRate{
+		CPUUsageRate:    percentOf(u.CPU, quantityValue(resources[corev1.ResourceCPU], corev1.ResourceCPU)),
+		MemoryUsageRate: percentOf(u.Memory, quantityValue(resources[corev1.ResourceMemory], corev1.ResourceMemory)),
+	}
+}
+
+func percentOf(value, total float64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return roundTo(value/total*100, 2)
+}
+
+func usageSeries(history []models.ResourceUsage, value func(models.ResourceUsage) float64) *models.MetricSeries {
+	series := &models.MetricSeries{Series: make([]models.DataPoint, 0, len(history))}
+	for _, u := range history {
+		series.Series = append(series.Series, models.DataPoint{Timestamp: u.Timestamp, Value: value(u)})
+	}
+	if len(series.Series) > 0 {
+		series.Current = series.Series[len(series.Series)-1].Value
+	}
+	return series
+}
+
+func metricsHistoryKey(clusterID uint, kind, name string) string {
+	return fmt.Sprintf("%d|%s|%s", clusterID, kind, name)
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// MetricsServerServiceTestSuite 定义 metrics-server 用量服务测试套件
+type MetricsServerServiceTestSuite struct {
+	suite.Suite
+	svc     *MetricsServerService
+	now     time.Time
+	fetches int
+	stamp   time.Time // metrics-server 返回的采样时间
+}
+
+// SetupTest 每个测试前的设置
+func (s *MetricsServerServiceTestSuite) SetupTest() {
+	s.now = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	s.stamp = s.now
+	s.fetches = 0
+
+	client := fake.NewSimpleClientset(
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+			Status: corev1.NodeStatus{Allocatable: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("4"),
+				corev1.ResourceMemory: resource.MustParse("8Gi"),
+			}},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "api-0", Namespace: "prod"},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "app", Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("250m"), corev1.ResourceMemory: resource.MustParse("256Mi")},
+					Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourceMemory: resource.MustParse("1Gi")},
+				}},
+				{Name: "sidecar", Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")},
+				}},
+			}},
+		},
+	)
+
+	s.svc = NewMetricsServerService(nil, nil)
+	s.svc.now = func() time.Time { return s.now }
+	s.svc.clientFor = func(uint) (kubernetes.Interface, error) { return client, nil }
+	s.svc.fetchRaw = func(_ context.Context, _ kubernetes.Interface, path string) ([]byte, error) {
+		s.fetches++
+		ts := s.stamp.Format(time.RFC3339)
+		switch path {
+		case metricsServerNodesPath:
+			return []byte(fmt.Sprintf(`{"items":[{"metadata":{"name":"node-1"},"timestamp":%q,"window":"15s","usage":{"cpu":"1","memory":"2Gi"}}]}`, ts)), nil
+		case "/apis/metrics.k8s.io/v1beta1/namespaces/prod/pods", metricsServerPodsPath:
+			return []byte(fmt.Sprintf(`{"items":[{"metadata":{"name":"api-0","namespace":"prod"},"timestamp":%q,"window":"15s","containers":[
+				{"name":"app","usage":{"cpu":"300m","memory":"512Mi"}},
+				{"name":"sidecar","usage":{"cpu":"150000000n","memory":"0"}}]}]}`, ts)), nil
+		}
+		return nil, fmt.Errorf("unexpected path %s", path)
+	}
+}
+
+// TestNodeUsageRates 测试节点用量解析与使用率计算
+func (s *MetricsServerServiceTestSuite) TestNodeUsageRates() {
+	rates, err := s.svc.NodeUsageRates(context.Background(), 1)
+	s.Require().NoError(err)
+	assert.Equal(s.T(), models.NodeUsageRate{CPUUsageRate: 25, MemoryUsageRate: 25}, rates["node-1"])
+}
+
+// TestPodUsageAndCache 测试 Pod 容器用量求和与缓存
+func (s *MetricsServerServiceTestSuite) TestPodUsageAndCache() {
+	usage, err := s.svc.PodUsage(context.Background(), 1, "")
+	s.Require().NoError(err)
+	assert.InDelta(s.T(), 0.45, usage["prod/api-0"].CPU, 1e-9)
+	assert.Equal(s.T(), float64(512<<20), usage["prod/api-0"].Memory)
+
+	_, err = s.svc.PodUsage(context.Background(), 1, "")
+	s.Require().NoError(err)
+	assert.Equal(s.T(), 1, s.fetches, "缓存有效期内不重复请求")
+
+	s.now = s.now.Add(metricsServerCacheTTL)
+	_, err = s.svc.PodUsage(context.Background(), 1, "")
+	s.Require().NoError(err)
+	assert.Equal(s.T(), 2, s.fetches)
+}
+
+// TestPodMetricsData 测试 Pod 监控数据：使用率相对 limit，趋势来自历史采样
+func (s *MetricsServerServiceTestSuite) TestPodMetricsData() {
+	for i := 0; i < 3; i++ {
+		_, err := s.svc.PodUsage(context.Background(), 1, "prod")
+		s.Require().NoError(err)
+		s.now = s.now.Add(30 * time.Second)
+		s.stamp = s.stamp.Add(30 * time.Second)
+	}
+
+	data, err := s.svc.PodMetricsData(context.Background(), 1, "prod", "api-0")
+	s.Require().NoError(err)
+	assert.Len(s.T(), data.CPU.Series, 4, "三次采样加本次请求的最新数据")
+	assert.Equal(s.T(), 30.0, data.CPU.Current)    // 0.45 / 1.5 cores
+	assert.Equal(s.T(), 50.0, data.Memory.Current) // 512Mi / 1Gi
+	assert.Equal(s.T(), 0.25, data.CPURequest.Current)
+	assert.Equal(s.T(), 1.5, data.CPULimit.Current)
+
+	_, err = s.svc.PodMetricsData(context.Background(), 1, "prod", "missing")
+	assert.Error(s.T(), err)
+}
+
+// TestUsageRing 测试环形缓冲区：重复时间戳去重、写满后覆盖最旧的采样
+func (s *MetricsServerServiceTestSuite) TestUsageRing() {
+	ring := &usageRing{}
+	ring.add(models.ResourceUsage{Timestamp: 1})
+	ring.add(models.ResourceUsage{Timestamp: 1})
+	assert.Len(s.T(), ring.list(), 1)
+
+	for ts := int64(2); ts <= metricsServerHistorySize+5; ts++ {
+		ring.add(models.ResourceUsage{Timestamp: ts})
+	}
+	list := ring.list()
+	s.Require().Len(list, metricsServerHistorySize)
+	assert.Equal(s.T(), int64(6), list[0].Timestamp)
+	assert.Equal(s.T(), int64(metricsServerHistorySize+5), list[len(list)-1].Timestamp)
+}
+
+// TestClusterUsageRisk 测试集群使用率风险阈值
+func (s *MetricsServerServiceTestSuite) TestClusterUsageRisk() {
+	risk, deduct := clusterUsageRisk("memory", 95, 80, 90)
+	s.Require().NotNil(risk)
+	assert.Equal(s.T(), "cluster-memory-critical", risk.ID)
+	assert.Equal(s.T(), "集群内存使用率过高", risk.Title)
+	assert.Equal(s.T(), 25, deduct)
+
+	risk, deduct = clusterUsageRisk("cpu", 85, 80, 90)
+	s.Require().NotNil(risk)
+	assert.Equal(s.T(), "集群 CPU 使用率较高", risk.Title)
+	assert.Equal(s.T(), 10, deduct)
+
+	risk, _ = clusterUsageRisk("cpu", 50, 80, 90)
+	assert.Nil(s.T(), risk)
+}
+
+// TestMetricsServerServiceTestSuite 运行 metrics-server 用量服务测试套件
+func TestMetricsServerServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(MetricsServerServiceTestSuite))
+}
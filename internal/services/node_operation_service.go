@@ -0,0 +1,479 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+	"github.com/clay-wangzhi/KubePolaris/pkg/logger"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+// ErrNodeChangeHasImpact 变更会导致 Pod 被驱逐或无法调度，且未设置 force
+var ErrNodeChangeHasImpact = errors.New("节点变更会导致 Pod 被驱逐或无法调度，请确认后使用 force 执行")
+
+// NodeOperationService 节点标签、污点、注解变更服务
+type NodeOperationService struct{}
+
+// NewNodeOperationService 创建节点变更服务
+func NewNodeOperationService() *NodeOperationService {
+	return &NodeOperationService{}
+}
+
+// ValidateChange 校验变更内容是否合法
+func (s *NodeOperationService) ValidateChange(spec *models.NodeChangeSpec) error {
+	if spec.IsEmpty() {
+		return fmt.Errorf("变更内容不能为空")
+	}
+
+	if spec.Labels != nil {
+		for key, value := range spec.Labels.Set {
+			if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+				return fmt.Errorf("标签键 %q 不合法: %s", key, strings.Join(errs, "; "))
+			}
+			if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+				return fmt.Errorf("标签 %q 的值 %q 不合法: %s", key, value, strings.Join(errs, "; "))
+			}
+		}
+	}
+
+	if spec.Annotations != nil {
+		for key := range spec.Annotations.Set {
+			if errs := validation.IsQualifiedName(strings.ToLower(key)); len(errs) > 0 {
+				return fmt.Errorf("注解键 %q 不合法: %s", key, strings.Join(errs, "; "))
+			}
+		}
+	}
+
+	if spec.Taints != nil {
+		for _, taint := range spec.Taints.Add {
+			if errs := validation.IsQualifiedName(taint.Key); len(errs) > 0 {
+				return fmt.Errorf("污点键 %q 不合法: %s", taint.Key, strings.Join(errs, "; "))
+			}
+			if errs := validation.IsValidLabelValue(taint.Value); len(errs) > 0 {
+				return fmt.Errorf("污点 %q 的值 %q 不合法: %s", taint.Key, taint.Value, strings.Join(errs, "; "))
+			}
+			if !isValidTaintEffect(taint.Effect) {
+				return fmt.Errorf("污点 %q 的效果 %q 不合法，仅支持 NoSchedule、PreferNoSchedule、NoExecute", taint.Key, taint.Effect)
+			}
+		}
+		for _, taint := range spec.Taints.Remove {
+			if taint.Key == "" {
+				return fmt.Errorf("删除污点时 key 不能为空")
+			}
+			if taint.Effect != "" && !isValidTaintEffect(taint.Effect) {
+				return fmt.Errorf("污点 %q 的效果 %q 不合法", taint.Key, taint.Effect)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ResolveNodes 根据标签选择器和节点名称解析目标节点
+func (s *NodeOperationService) ResolveNodes(ctx context.Context, clientset kubernetes.Interface, labelSelector string, nodeNames []string) ([]string, error) {
+	if strings.TrimSpace(labelSelector) == "" && len(nodeNames) == 0 {
+		return nil, fmt.Errorf("必须指定标签选择器或节点名称")
+	}
+
+	targets := make(map[string]struct{})
+	if strings.TrimSpace(labelSelector) != "" {
+		if _, err := labels.Parse(labelSelector); err != nil {
+			return nil, fmt.Errorf("标签选择器不合法: %v", err)
+		}
+		nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+		if err != nil {
+			return nil, fmt.Errorf("获取节点列表失败: %v", err)
+		}
+		for _, node := range nodes.Items {
+			targets[node.Name] = struct{}{}
+		}
+	}
+
+	for _, name := range nodeNames {
+		if _, err := clientset.CoreV1().Nodes().Get(ctx, name, metav1.GetOptions{}); err != nil {
+			return nil, fmt.Errorf("获取节点 %s 失败: %v", name, err)
+		}
+		targets[name] = struct{}{}
+	}
+
+	result := make([]string, 0, len(targets))
+	for name := range targets {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// Execute 对目标节点执行变更；dryRun 时仅返回影响预览
+func (s *NodeOperationService) Execute(ctx context.Context, clientset kubernetes.Interface, nodeNames []string, spec *models.NodeChangeSpec, dryRun, force bool) (*models.NodeChangeResponse, error) {
+	if err := s.ValidateChange(spec); err != nil {
+		return nil, err
+	}
+	if len(nodeNames) == 0 {
+		return nil, fmt.Errorf("没有匹配的节点")
+	}
+
+	preview, err := s.PreviewChange(ctx, clientset, nodeNames, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.NodeChangeResponse{Preview: preview}
+	if dryRun {
+		return response, nil
+	}
+	if preview.HasImpact() && !force {
+		return response, ErrNodeChangeHasImpact
+	}
+
+	for _, name := range nodeNames {
+		result := models.NodeChangeResult{Node: name, Success: true}
+		if err := s.applyToNode(ctx, clientset, name, spec); err != nil {
+			result.Success = false
+			result.Error = err.Error()
+			logger.Error("节点变更失败", "node", name, "error", err)
+		}
+		response.Results = append(response.Results, result)
+	}
+	response.Applied = true
+
+	return response, nil
+}
+
+// applyToNode 以乐观锁重试的方式修改单个节点
+func (s *NodeOperationService) applyToNode(ctx context.Context, clientset kubernetes.Interface, nodeName string, spec *models.NodeChangeSpec) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		node, err := clientset.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("获取节点失败: %v", err)
+		}
+		updated := applyNodeChange(node, spec)
+		if _, err := clientset.CoreV1().Nodes().Update(ctx, updated, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+// PreviewChange 模拟变更并分析对现有 Pod 的影响
+func (s *NodeOperationService) PreviewChange(ctx context.Context, clientset kubernetes.Interface, nodeNames []string, spec *models.NodeChangeSpec) (*models.NodeChangePreview, error) {
+	nodeList, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取节点列表失败: %v", err)
+	}
+	podList, err := clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取 Pod 列表失败: %v", err)
+	}
+
+	return analyzeNodeChange(nodeList.Items, podList.Items, nodeNames, spec), nil
+}
+
+// analyzeNodeChange 基于节点与 Pod 快照计算变更影响
+func analyzeNodeChange(nodes []corev1.Node, pods []corev1.Pod, nodeNames []string, spec *models.NodeChangeSpec) *models.NodeChangePreview {
+	preview := &models.NodeChangePreview{
+		Nodes:             nodeNames,
+		EvictedPods:       []models.PodImpact{},
+		UnschedulablePods: []models.PodImpact{},
+	}
+
+	targets := make(map[string]bool, len(nodeNames))
+	for _, name := range nodeNames {
+		targets[name] = true
+	}
+
+	original := make(map[string]*corev1.Node, len(nodes))
+	simulated := make([]*corev1.Node, 0, len(nodes))
+	for i := range nodes {
+		node := &nodes[i]
+		original[node.Name] = node
+		if targets[node.Name] {
+			simulated = append(simulated, applyNodeChange(node, spec))
+		} else {
+			simulated = append(simulated, node)
+		}
+	}
+	simulatedByName := make(map[string]*corev1.Node, len(simulated))
+	for _, node := range simulated {
+		simulatedByName[node.Name] = node
+	}
+
+	for _, name := range nodeNames {
+		if _, ok := original[name]; !ok {
+			preview.Warnings = append(preview.Warnings, fmt.Sprintf("节点 %s 不存在", name))
+		}
+	}
+
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		if !targets[pod.Spec.NodeName] {
+			continue
+		}
+		before, ok := original[pod.Spec.NodeName]
+		if !ok {
+			continue
+		}
+		after := simulatedByName[pod.Spec.NodeName]
+		ownerKind, ownerName := podOwner(pod)
+
+		impact := models.PodImpact{
+			Namespace: pod.Namespace,
+			Name:      pod.Name,
+			NodeName:  pod.Spec.NodeName,
+			OwnerKind: ownerKind,
+			OwnerName: ownerName,
+		}
+
+		// 新增的 NoExecute 污点会立即驱逐未容忍的 Pod
+		if taint := untoleratedNewTaint(pod, before, after, corev1.TaintEffectNoExecute); taint != nil {
+			impact.Reason = fmt.Sprintf("未容忍 NoExecute 污点 %s=%s", taint.Key, taint.Value)
+			preview.EvictedPods = append(preview.EvictedPods, impact)
+			continue
+		}
+
+		// DaemonSet 的节点选择不再匹配时，控制器会删除该节点上的 Pod
+		if ownerKind == "DaemonSet" && podMatchesNodeAffinity(pod, before) && !podMatchesNodeAffinity(pod, after) {
+			impact.Reason = "DaemonSet 节点选择器/亲和性不再匹配该节点"
+			preview.EvictedPods = append(preview.EvictedPods, impact)
+			continue
+		}
+
+		// 变更前可以运行在该节点、变更后不能，且集群内没有其它可调度节点
+		if podFitsNode(pod, before) && !podFitsNode(pod, after) && ownerKind != "DaemonSet" {
+			if !podHasFeasibleNode(pod, simulated) {
+				impact.Reason = "变更后集群内没有满足 nodeSelector、节点亲和性和污点容忍的可调度节点"
+				preview.UnschedulablePods = append(preview.UnschedulablePods, impact)
+			}
+		}
+	}
+
+	return preview
+}
+
+// applyNodeChange 返回应用变更后的节点副本
+func applyNodeChange(node *corev1.Node, spec *models.NodeChangeSpec) *corev1.Node {
+	updated := node.DeepCopy()
+
+	if spec.Labels != nil {
+		if updated.Labels == nil {
+			updated.Labels = map[string]string{}
+		}
+		for _, key := range spec.Labels.Remove {
+			delete(updated.Labels, key)
+		}
+		for key, value := range spec.Labels.Set {
+			updated.Labels[key] = value
+		}
+	}
+
+	if spec.Annotations != nil {
+		if updated.Annotations == nil {
+			updated.Annotations = map[string]string{}
+		}
+		for _, key := range spec.Annotations.Remove {
+			delete(updated.Annotations, key)
+		}
+		for key, value := range spec.Annotations.Set {
+			updated.Annotations[key] = value
+		}
+	}
+
+	if spec.Taints != nil {
+		taints := make([]corev1.Taint, 0, len(updated.Spec.Taints)+len(spec.Taints.Add))
+		for _, existing := range updated.Spec.Taints {
+			removed := false
+			for _, r := range spec.Taints.Remove {
+				if existing.Key == r.Key && (r.Effect == "" || string(existing.Effect) == r.Effect) {
+					removed = true
+					break
+				}
+			}
+			for _, a := range spec.Taints.Add {
+				if existing.Key == a.Key && string(existing.Effect) == a.Effect {
+					removed = true // 将被新值覆盖
+					break
+				}
+			}
+			if !removed {
+				taints = append(taints, existing)
+			}
+		}
+		for _, a := range spec.Taints.Add {
+			taints = append(taints, corev1.Taint{
+				Key:    a.Key,
+				Value:  a.Value,
+				Effect: corev1.TaintEffect(a.Effect),
+			})
+		}
+		updated.Spec.Taints = taints
+	}
+
+	return updated
+}
+
+// untoleratedNewTaint 返回变更后新增、且 Pod 未容忍的指定效果污点
+func untoleratedNewTaint(pod *corev1.Pod, before, after *corev1.Node, effect corev1.TaintEffect) *corev1.Taint {
+	for i := range after.Spec.Taints {
+		taint := &after.Spec.Taints[i]
+		if taint.Effect != effect || hasTaint(before, taint) {
+			continue
+		}
+		if !toleratesTaint(pod.Spec.Tolerations, taint) {
+			return taint
+		}
+	}
+	return nil
+}
+
+func hasTaint(node *corev1.Node, taint *corev1.Taint) bool {
+	for i := range node.Spec.Taints {
+		if node.Spec.Taints[i].MatchTaint(taint) && node.Spec.Taints[i].Value == taint.Value {
+			return true
+		}
+	}
+	return false
+}
+
+func toleratesTaint(tolerations []corev1.Toleration, taint *corev1.Taint) bool {
+	for i := range tolerations {
+		if tolerations[i].ToleratesTaint(taint) {
+			return true
+		}
+	}
+	return false
+}
+
+// podFitsNode 判断 Pod 的节点选择、亲和性与污点容忍是否允许其运行在节点上
+func podFitsNode(pod *corev1.Pod, node *corev1.Node) bool {
+	if !podMatchesNodeAffinity(pod, node) {
+		return false
+	}
+	for i := range node.Spec.Taints {
+		taint := &node.Spec.Taints[i]
+		if taint.Effect == corev1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		if !toleratesTaint(pod.Spec.Tolerations, taint) {
+			return false
+		}
+	}
+	return true
+}
+
+// podHasFeasibleNode 判断集群内是否存在可调度该 Pod 的节点
+func podHasFeasibleNode(pod *corev1.Pod, nodes []*corev1.Node) bool {
+	for _, node := range nodes {
+		if node.Spec.Unschedulable {
+			continue
+		}
+		if podFitsNode(pod, node) {
+			return true
+		}
+	}
+	return false
+}
+
+// podMatchesNodeAffinity 判断 nodeSelector 与 requiredDuringScheduling 节点亲和性
+func podMatchesNodeAffinity(pod *corev1.Pod, node *corev1.Node) bool {
+	if len(pod.Spec.NodeSelector) > 0 {
+		if !labels.SelectorFromSet(pod.Spec.NodeSelector).Matches(labels.Set(node.Labels)) {
+			return false
+		}
+	}
+
+	affinity := pod.Spec.Affinity
+	if affinity == nil || affinity.NodeAffinity == nil || affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return true
+	}
+
+	terms := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	if len(terms) == 0 {
+		return true
+	}
+	// 多个 term 之间为 OR 关系
+	for _, term := range terms {
+		if nodeSelectorTermMatches(term, node) {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeSelectorTermMatches term 内各表达式为 AND 关系
+func nodeSelectorTermMatches(term corev1.NodeSelectorTerm, node *corev1.Node) bool {
+	if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+		return false
+	}
+	for _, expr := range term.MatchExpressions {
+		req, err := nodeSelectorRequirement(expr)
+		if err != nil || !req.Matches(labels.Set(node.Labels)) {
+			return false
+		}
+	}
+	for _, expr := range term.MatchFields {
+		if expr.Key != "metadata.name" {
+			return false
+		}
+		req, err := nodeSelectorRequirement(expr)
+		if err != nil || !req.Matches(labels.Set{"metadata.name": node.Name}) {
+			return false
+		}
+	}
+	return true
+}
+
+func nodeSelectorRequirement(expr corev1.NodeSelectorRequirement) (*labels.Requirement, error) {
+	var op selection.Operator
+	switch expr.Operator {
+	case corev1.NodeSelectorOpIn:
+		op = selection.In
+	case corev1.NodeSelectorOpNotIn:
+		op = selection.NotIn
+	case corev1.NodeSelectorOpExists:
+		op = selection.Exists
+	case corev1.NodeSelectorOpDoesNotExist:
+		op = selection.DoesNotExist
+	case corev1.NodeSelectorOpGt:
+		op = selection.GreaterThan
+	case corev1.NodeSelectorOpLt:
+		op = selection.LessThan
+	default:
+		return nil, fmt.Errorf("不支持的操作符: %s", expr.Operator)
+	}
+	return labels.NewRequirement(expr.Key, op, expr.Values)
+}
+
+// podOwner 返回 Pod 的控制器类型与名称
+func podOwner(pod *corev1.Pod) (string, string) {
+	for _, owner := range pod.OwnerReferences {
+		if owner.Controller != nil && *owner.Controller {
+			return owner.Kind, owner.Name
+		}
+	}
+	if len(pod.OwnerReferences) > 0 {
+		return pod.OwnerReferences[0].Kind, pod.OwnerReferences[0].Name
+	}
+	return "", ""
+}
+
+func isValidTaintEffect(effect string) bool {
+	switch corev1.TaintEffect(effect) {
+	case corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+)
+
+// NodeOperationServiceTestSuite 定义节点变更服务测试套件
+type NodeOperationServiceTestSuite struct {
+	suite.Suite
+	clientset *fake.Clientset
+	service   *NodeOperationService
+}
+
+// SetupTest 每个测试前的设置
+func (s *NodeOperationServiceTestSuite) SetupTest() {
+	isController := true
+	s.clientset = fake.NewSimpleClientset(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-a", Labels: map[string]string{"zone": "a", "disk": "ssd"}}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-b", Labels: map[string]string{"zone": "b"}}},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec:       corev1.PodSpec{NodeName: "node-a"},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+			Spec:       corev1.PodSpec{NodeName: "node-a", NodeSelector: map[string]string{"disk": "ssd"}},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "agent",
+				Namespace:       "kube-system",
+				OwnerReferences: []metav1.OwnerReference{{Kind: "DaemonSet", Name: "agent", Controller: &isController}},
+			},
+			Spec: corev1.PodSpec{
+				NodeName:    "node-a",
+				Tolerations: []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+	)
+	s.service = NewNodeOperationService()
+}
+
+// TestValidateChange 测试变更内容校验
+func (s *NodeOperationServiceTestSuite) TestValidateChange() {
+	assert.Error(s.T(), s.service.ValidateChange(&models.NodeChangeSpec{}))
+	assert.Error(s.T(), s.service.ValidateChange(&models.NodeChangeSpec{
+		Labels: &models.NodeMetadataPatch{Set: map[string]string{"bad key": "v"}},
+	}))
+	assert.Error(s.T(), s.service.ValidateChange(&models.NodeChangeSpec{
+		Taints: &models.NodeTaintPatch{Add: []models.NodeTaint{{Key: "maintenance", Effect: "Evict"}}},
+	}))
+	assert.NoError(s.T(), s.service.ValidateChange(&models.NodeChangeSpec{
+		Taints: &models.NodeTaintPatch{Add: []models.NodeTaint{{Key: "maintenance", Value: "true", Effect: "NoExecute"}}},
+	}))
+}
+
+// TestPreviewChange_NoExecuteTaint 测试 NoExecute 污点驱逐预览
+func (s *NodeOperationServiceTestSuite) TestPreviewChange_NoExecuteTaint() {
+	spec := &models.NodeChangeSpec{
+		Taints: &models.NodeTaintPatch{Add: []models.NodeTaint{{Key: "maintenance", Value: "true", Effect: "NoExecute"}}},
+	}
+
+	preview, err := s.service.PreviewChange(context.Background(), s.clientset, []string{"node-a"}, spec)
+	s.Require().NoError(err)
+
+	evicted := []string{}
+	for _, p := range preview.EvictedPods {
+		evicted = append(evicted, p.Name)
+	}
+	assert.ElementsMatch(s.T(), []string{"web", "db"}, evicted)
+}
+
+// TestPreviewChange_RemoveLabel 测试删除标签导致 Pod 无可调度节点
+func (s *NodeOperationServiceTestSuite) TestPreviewChange_RemoveLabel() {
+	spec := &models.NodeChangeSpec{
+		Labels: &models.NodeMetadataPatch{Remove: []string{"disk"}},
+	}
+
+	preview, err := s.service.PreviewChange(context.Background(), s.clientset, []string{"node-a"}, spec)
+	s.Require().NoError(err)
+
+	assert.Empty(s.T(), preview.EvictedPods)
+	s.Require().Len(preview.UnschedulablePods, 1)
+	assert.Equal(s.T(), "db", preview.UnschedulablePods[0].Name)
+}
+
+// TestExecute_RequiresForce 测试存在影响时需要 force
+func (s *NodeOperationServiceTestSuite) TestExecute_RequiresForce() {
+	spec := &models.NodeChangeSpec{
+		Labels: &models.NodeMetadataPatch{Remove: []string{"disk"}},
+	}
+
+	resp, err := s.service.Execute(context.Background(), s.clientset, []string{"node-a"}, spec, false, false)
+	assert.ErrorIs(s.T(), err, ErrNodeChangeHasImpact)
+	assert.False(s.T(), resp.Applied)
+
+	resp, err = s.service.Execute(context.Background(), s.clientset, []string{"node-a"}, spec, false, true)
+	s.Require().NoError(err)
+	assert.True(s.T(), resp.Applied)
+
+	node, err := s.clientset.CoreV1().Nodes().Get(context.Background(), "node-a", metav1.GetOptions{})
+	s.Require().NoError(err)
+	assert.NotContains(s.T(), node.Labels, "disk")
+}
+
+// TestResolveNodes 测试标签选择器解析
+func (s *NodeOperationServiceTestSuite) TestResolveNodes() {
+	nodes, err := s.service.ResolveNodes(context.Background(), s.clientset, "zone=b", nil)
+	s.Require().NoError(err)
+	assert.Equal(s.T(), []string{"node-b"}, nodes)
+
+	_, err = s.service.ResolveNodes(context.Background(), s.clientset, "", nil)
+	assert.Error(s.T(), err)
+}
+
+// TestNodeOperationServiceSuite 运行测试套件
+func TestNodeOperationServiceSuite(t *testing.T) {
+	suite.Run(t, new(NodeOperationServiceTestSuite))
+}
@@ -0,0 +1,416 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+)
+
+// notificationResponseLimit 记录到投递日志的响应体最大长度
+const notificationResponseLimit = 500
+
+// notificationMessage 一次发送的内容
+type notificationMessage struct {
+	Channel    *models.NotificationChannel
+	Config     *models.NotificationChannelConfig
+	Event      *models.NotificationEvent
+	Recipients []string // 邮件收件人（已合并渠道固定收件人）
+}
+
+// notificationSendError 发送失败，StatusCode 为 HTTP 响应码（非 HTTP 渠道为 0）
+type notificationSendError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *notificationSendError) Error() string {
+	if e.StatusCode > 0 {
+		return fmt.Sprintf("HTTP %d: %s", e.StatusCode, e.Message)
+	}
+	return e.Message
+}
+
+// sendMailFunc 按渠道的 SMTP 配置发送邮件，测试时可替换
+type sendMailFunc func(cfg *models.NotificationChannelConfig, from string, to []string, msg []byte) error
+
+// notificationSender 按渠道类型发送通知
+type notificationSender struct {
+	httpClient *http.Client
+	sendMail   sendMailFunc
+	now        func() time.Time
+}
+
+func newNotificationSender() *notificationSender {
+	return &notificationSender{
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+		sendMail:   smtpSendMail,
+		now:        time.Now,
+	}
+}
+
+// Send 发送通知，返回 HTTP 响应码（邮件为 0）
+func (s *notificationSender) Send(ctx context.Context, msg *notificationMessage) (int, error) {
+	switch msg.Channel.Type {
+	case models.NotificationChannelWebhook:
+		return s.sendWebhook(ctx, msg)
+	case models.NotificationChannelEmail:
+		return 0, s.sendEmail(msg)
+	case models.NotificationChannelSlack:
+		return s.postJSON(ctx, msg.Config.URL, nil, slackPayload(msg.Event), false)
+	case models.NotificationChannelDingTalk:
+		target, err := dingTalkSignedURL(msg.Config.URL, msg.Config.Secret, s.now())
+		if err != nil {
+			return 0, err
+		}
+		return s.postJSON(ctx, target, nil, dingTalkPayload(msg.Event, msg.Config.AtMobiles), true)
+	case models.NotificationChannelFeishu:
+		return s.postJSON(ctx, msg.Config.URL, nil, feishuPayload(msg.Event, msg.Config.Secret, s.now()), true)
+	case models.NotificationChannelWeCom:
+		return s.postJSON(ctx, msg.Config.URL, nil, weComPayload(msg.Event, msg.Config.AtMobiles), true)
+	default:
+		return 0, fmt.Errorf("不支持的通知渠道类型: %s", msg.Channel.Type)
+	}
+}
+
+// sendWebhook 发送通用 Webhook，请求体由模板渲染，未配置模板时发送事件 JSON
+func (s *notificationSender) sendWebhook(ctx context.Context, msg *notificationMessage) (int, error) {
+	body, err := renderNotificationPayload(msg.Config.PayloadTemplate, msg.Event)
+	if err != nil {
+		return 0, err
+	}
+	method := strings.ToUpper(msg.Config.Method)
+	if method == "" {
+		method = http.MethodPost
+	}
+	return s.do(ctx, method, msg.Config.URL, msg.Config.Headers, body, false)
+}
+
+func (s *notificationSender) postJSON(ctx context.Context, target string, headers map[string]string, payload interface{}, checkBody bool) (int, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("序列化通知内容失败: %w", err)
+	}
+	return s.do(ctx, http.MethodPost, target, headers, body, checkBody)
+}
+
+// do 发送 HTTP 请求；checkBody 为 true 时按 IM 机器人的约定检查响应体中的错误码（errcode / code 非 0 即失败）
+func (s *notificationSender) do(ctx context.Context, method, target string, headers map[string]string, body []byte, checkBody bool) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, &notificationSendError{StatusCode: resp.StatusCode, Message: truncateNotificationText(strings.TrimSpace(string(respBody)))}
+	}
+	if checkBody {
+		if msg, ok := imResponseError(respBody); !ok {
+			return resp.StatusCode, &notificationSendError{StatusCode: resp.StatusCode, Message: msg}
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+// imResponseError 解析钉钉 / 飞书 / 企业微信机器人的响应，ok 为 false 时返回错误信息
+func imResponseError(body []byte) (string, bool) {
+	var resp struct {
+		ErrCode *int   `json:"errcode"` // 钉钉、企业微信
+		ErrMsg  string `json:"errmsg"`
+		Code    *int   `json:"code"` // 飞书
+		Msg     string `json:"msg"`
+	}
+	if len(bytes.TrimSpace(body)) == 0 || json.Unmarshal(body, &resp) != nil {
+		return "", true
+	}
+	if resp.ErrCode != nil && *resp.ErrCode != 0 {
+		return fmt.Sprintf("errcode=%d %s", *resp.ErrCode, resp.ErrMsg), false
+	}
+	if resp.Code != nil && *resp.Code != 0 {
+		return fmt.Sprintf("code=%d %s", *resp.Code, resp.Msg), false
+	}
+	return "", true
+}
+
+// notificationTemplateData 请求体模板可用的数据
+type notificationTemplateData struct {
+	*models.NotificationEvent
+	Labels    map[string]string // 覆盖事件中的 JSON 字符串字段，便于 {{ .Labels.node }} 取值
+	EventName string            // 事件类型中文名
+	Time      string            // RFC3339
+}
+
+// renderNotificationPayload 渲染 Webhook 请求体；模板中可使用 {{ .Title }}、{{ .Labels.node }} 等字段，
+// 以及 json 函数输出转义后的 JSON 字符串，如 {"text": {{ json .Content }}}
+func renderNotificationPayload(tpl string, event *models.NotificationEvent) ([]byte, error) {
+	if strings.TrimSpace(tpl) == "" {
+		return json.Marshal(event)
+	}
+	t, err := parseNotificationTemplate(tpl)
+	if err != nil {
+		return nil, err
+	}
+	data := notificationTemplateData{
+		NotificationEvent: event,
+		Labels:            event.LabelMap,
+		EventName:         notificationEventName(event.Type),
+		Time:              event.CreatedAt.Format(time.RFC3339),
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("渲染请求体模板失败: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func parseNotificationTemplate(tpl string) (*template.Template, error) {
+	t, err := template.New("payload").Option("missingkey=zero").Funcs(template.FuncMap{
+		"json": func(v interface{}) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}).Parse(tpl)
+	if err != nil {
+		return nil, fmt.Errorf("请求体模板语法错误: %w", err)
+	}
+	return t, nil
+}
+
+// notificationEventName 事件类型中文名
+func notificationEventName(eventType string) string {
+	if name, ok := models.NotificationEventTypes[eventType]; ok {
+		return name
+	}
+	if eventType == models.NotificationEventTest {
+		return "测试消息"
+	}
+	return eventType
+}
+
+// notificationText 纯文本正文，IM 与邮件共用
+func notificationText(event *models.NotificationEvent) string {
+	var b strings.Builder
+	b.WriteString(event.Content)
+	b.WriteString("\n\n")
+	if event.ClusterName != "" {
+		fmt.Fprintf(&b, "集群: %s\n", event.ClusterName)
+	}
+	fmt.Fprintf(&b, "事件: %s\n", notificationEventName(event.Type))
+	fmt.Fprintf(&b, "级别: %s\n", event.Severity)
+	for _, k := range sortedKeys(event.LabelMap) {
+		fmt.Fprintf(&b, "%s: %s\n", k, event.LabelMap[k])
+	}
+	fmt.Fprintf(&b, "时间: %s", event.CreatedAt.Format("2006-01-02 15:04:05"))
+	return b.String()
+}
+
+// notificationMarkdown Markdown 正文，用于钉钉、企业微信
+func notificationMarkdown(event *models.NotificationEvent) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "### %s\n\n%s\n\n", event.Title, event.Content)
+	if event.ClusterName != "" {
+		fmt.Fprintf(&b, "- 集群: %s\n", event.ClusterName)
+	}
+	fmt.Fprintf(&b, "- 事件: %s\n", notificationEventName(event.Type))
+	fmt.Fprintf(&b, "- 级别: %s\n", event.Severity)
+	for _, k := range sortedKeys(event.LabelMap) {
+		fmt.Fprintf(&b, "- %s: %s\n", k, event.LabelMap[k])
+	}
+	fmt.Fprintf(&b, "- 时间: %s", event.CreatedAt.Format("2006-01-02 15:04:05"))
+	return b.String()
+}
+
+func slackPayload(event *models.NotificationEvent) map[string]interface{} {
+	return map[string]interface{}{
+		"text": fmt.Sprintf("*%s*\n%s", event.Title, notificationText(event)),
+	}
+}
+
+func dingTalkPayload(event *models.NotificationEvent, atMobiles []string) map[string]interface{} {
+	text := notificationMarkdown(event)
+	for _, m := range atMobiles {
+		text += " @" + m
+	}
+	return map[string]interface{}{
+		"msgtype":  "markdown",
+		"markdown": map[string]string{"title": event.Title, "text": text},
+		"at":       map[string]interface{}{"atMobiles": atMobiles},
+	}
+}
+
+func weComPayload(event *models.NotificationEvent, atMobiles []string) map[string]interface{} {
+	// 企业微信 markdown 消息不支持 @手机号，配置了 @ 时改用 text 消息
+	if len(atMobiles) > 0 {
+		return map[string]interface{}{
+			"msgtype": "text",
+			"text": map[string]interface{}{
+				"content":               event.Title + "\n" + notificationText(event),
+				"mentioned_mobile_list": atMobiles,
+			},
+		}
+	}
+	return map[string]interface{}{
+		"msgtype":  "markdown",
+		"markdown": map[string]string{"content": notificationMarkdown(event)},
+	}
+}
+
+func feishuPayload(event *models.NotificationEvent, secret string, now time.Time) map[string]interface{} {
+	payload := map[string]interface{}{
+		"msg_type": "text",
+		"content":  map[string]string{"text": event.Title + "\n" + notificationText(event)},
+	}
+	if secret != "" {
+		timestamp := strconv.FormatInt(now.Unix(), 10)
+		payload["timestamp"] = timestamp
+		payload["sign"] = feishuSign(timestamp, secret)
+	}
+	return payload
+}
+
+// feishuSign 飞书加签：以 timestamp + "\n" + secret 为密钥对空串做 HmacSHA256 后 Base64
+func feishuSign(timestamp, secret string) string {
+	h := hmac.New(sha256.New, []byte(timestamp+"\n"+secret))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// dingTalkSignedURL 钉钉加签：以 secret 为密钥对 timestamp + "\n" + secret 做 HmacSHA256 后 Base64，
+// timestamp（毫秒）与 sign 作为查询参数附加到 Webhook 地址
+func dingTalkSignedURL(webhook, secret string, now time.Time) (string, error) {
+	if secret == "" {
+		return webhook, nil
+	}
+	u, err := url.Parse(webhook)
+	if err != nil {
+		return "", fmt.Errorf("无效的 Webhook 地址: %w", err)
+	}
+	timestamp := strconv.FormatInt(now.UnixMilli(), 10)
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(timestamp + "\n" + secret))
+	q := u.Query()
+	q.Set("timestamp", timestamp)
+	q.Set("sign", base64.StdEncoding.EncodeToString(h.Sum(nil)))
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// sendEmail 发送纯文本邮件
+func (s *notificationSender) sendEmail(msg *notificationMessage) error {
+	if len(msg.Recipients) == 0 {
+		return fmt.Errorf("没有收件人：渠道未配置收件人且订阅用户未设置邮箱")
+	}
+	from := msg.Config.From
+	if from == "" {
+		from = msg.Config.Username
+	}
+	return s.sendMail(msg.Config, from, msg.Recipients, buildNotificationMail(from, msg.Recipients, msg.Event, s.now()))
+}
+
+// buildNotificationMail 构造邮件内容，主题按 RFC 2047 编码
+func buildNotificationMail(from string, to []string, event *models.NotificationEvent, now time.Time) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", "[KubePolaris] "+event.Title))
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+	encoded := base64.StdEncoding.EncodeToString([]byte(notificationText(event)))
+	for len(encoded) > 76 {
+		b.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	b.WriteString(encoded + "\r\n")
+	return b.Bytes()
+}
+
+// smtpSendMail 通过 SMTP 发送邮件：ImplicitTLS 时直接建立 TLS 连接，否则由 smtp.SendMail 在服务端支持时升级 STARTTLS
+func smtpSendMail(cfg *models.NotificationChannelConfig, from string, to []string, msg []byte) error {
+	port := cfg.SMTPPort
+	if port == 0 {
+		port = 25
+		if cfg.ImplicitTLS {
+			port = 465
+		}
+	}
+	addr := net.JoinHostPort(cfg.SMTPHost, strconv.Itoa(port))
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.SMTPHost)
+	}
+	if !cfg.ImplicitTLS {
+		return smtp.SendMail(addr, auth, from, to, msg)
+	}
+
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 15 * time.Second}, "tcp", addr, &tls.Config{ServerName: cfg.SMTPHost})
+	if err != nil {
+		return fmt.Errorf("连接 SMTP 服务器失败: %w", err)
+	}
+	client, err := smtp.NewClient(conn, cfg.SMTPHost)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("连接 SMTP 服务器失败: %w", err)
+	}
+	defer client.Close()
+	if auth != nil {
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("SMTP 认证失败: %w", err)
+		}
+	}
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := client.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("收件人 %s 被拒绝: %w", rcpt, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// truncateNotificationText 截断响应体、错误信息等写入投递日志的文本
+func truncateNotificationText(s string) string {
+	runes := []rune(s)
+	if len(runes) <= notificationResponseLimit {
+		return s
+	}
+	return string(runes[:notificationResponseLimit]) + "..."
+}
@@ -0,0 +1,938 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+	"github.com/clay-wangzhi/KubePolaris/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// notificationDispatchInterval 投递调度周期，新事件会立即唤醒调度器
+	notificationDispatchInterval = 10 * time.Second
+	// notificationDispatchBatch 每轮最多处理的投递数
+	notificationDispatchBatch = 100
+	// notificationSendTimeout 单次发送超时
+	notificationSendTimeout = 30 * time.Second
+	// notificationMaxBackoff 重试间隔上限
+	notificationMaxBackoff = 30 * time.Minute
+)
+
+// NotificationOptions 通知中心参数
+type NotificationOptions struct {
+	MaxAttempts     int           // 单次投递的最大尝试次数
+	RetryBase       time.Duration // 首次重试间隔，之后每次翻倍
+	HealthScoreDrop int           // 健康评分较上次诊断下降超过该值时发送通知
+}
+
+// NotificationService 通知中心：管理通知渠道与订阅，将平台事件投递到订阅的渠道，失败时按指数退避重试
+type NotificationService struct {
+	db     *gorm.DB
+	sender *notificationSender
+	opts   NotificationOptions
+
+	wake chan struct{}
+}
+
+// NewNotificationService 创建通知中心服务
+func NewNotificationService(db *gorm.DB, opts NotificationOptions) *NotificationService {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 5
+	}
+	if opts.RetryBase <= 0 {
+		opts.RetryBase = 30 * time.Second
+	}
+	if opts.HealthScoreDrop <= 0 {
+		opts.HealthScoreDrop = 10
+	}
+	return &NotificationService{
+		db:     db,
+		sender: newNotificationSender(),
+		opts:   opts,
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// Start 启动投递调度器，ctx 取消后退出；重启前未完成的投递会继续发送
+func (s *NotificationService) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(notificationDispatchInterval)
+		defer ticker.Stop()
+		for {
+			s.dispatchDue(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-s.wake:
+			}
+		}
+	}()
+	logger.Info("通知投递调度器已启动")
+}
+
+// ========== 渠道 ==========
+
+// ListChannels 获取通知渠道列表（密钥已脱敏）
+func (s *NotificationService) ListChannels() ([]models.NotificationChannel, error) {
+	var channels []models.NotificationChannel
+	if err := s.db.Order("id ASC").Find(&channels).Error; err != nil {
+		return nil, fmt.Errorf("查询通知渠道失败: %w", err)
+	}
+	for i := range channels {
+		channels[i].ChannelConfig = maskNotificationConfig(decodeNotificationConfig(&channels[i]))
+	}
+	return channels, nil
+}
+
+// GetChannel 获取通知渠道（密钥已脱敏）
+func (s *NotificationService) GetChannel(id uint) (*models.NotificationChannel, error) {
+	channel, err := s.getChannel(id)
+	if err != nil {
+		return nil, err
+	}
+	channel.ChannelConfig = maskNotificationConfig(decodeNotificationConfig(channel))
+	return channel, nil
+}
+
+func (s *NotificationService) getChannel(id uint) (*models.NotificationChannel, error) {
+	var channel models.NotificationChannel
+	if err := s.db.First(&channel, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("通知渠道不存在: %d", id)
+		}
+		return nil, fmt.Errorf("查询通知渠道失败: %w", err)
+	}
+	return &channel, nil
+}
+
+// SaveChannel 创建或更新通知渠道，id 为 0 时创建；配置中的脱敏值保持原值
+func (s *NotificationService) SaveChannel(id uint, req *models.NotificationChannelRequest, userID uint) (*models.NotificationChannel, error) {
+	channel := &models.NotificationChannel{CreatedBy: userID}
+	if id != 0 {
+		existing, err := s.getChannel(id)
+		if err != nil {
+			return nil, err
+		}
+		channel = existing
+	}
+
+	cfg := req.Config
+	if id != 0 {
+		if err := restoreNotificationSecrets(&cfg, decodeNotificationConfig(channel)); err != nil {
+			return nil, err
+		}
+	}
+	if err := ValidateNotificationChannel(req.Type, &cfg); err != nil {
+		return nil, err
+	}
+
+	channel.Name = strings.TrimSpace(req.Name)
+	channel.Type = req.Type
+	channel.Description = req.Description
+	channel.Enabled = req.Enabled
+	data, _ := json.Marshal(cfg)
+	channel.Config = string(data)
+
+	var count int64
+	s.db.Model(&models.NotificationChannel{}).Where("name = ? AND id <> ?", channel.Name, channel.ID).Count(&count)
+	if count > 0 {
+		return nil, fmt.Errorf("通知渠道名称已存在: %s", channel.Name)
+	}
+	if err := s.db.Save(channel).Error; err != nil {
+		return nil, fmt.Errorf("保存通知渠道失败: %w", err)
+	}
+	channel.ChannelConfig = maskNotificationConfig(cfg)
+	return channel, nil
+}
+
+// DeleteChannel 删除通知渠道及其订阅
+func (s *NotificationService) DeleteChannel(id uint) error {
+	if _, err := s.getChannel(id); err != nil {
+		return err
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("channel_id = ?", id).Delete(&models.NotificationSubscription{}).Error; err != nil {
+			return fmt.Errorf("删除渠道订阅失败: %w", err)
+		}
+		if err := tx.Delete(&models.NotificationChannel{}, id).Error; err != nil {
+			return fmt.Errorf("删除通知渠道失败: %w", err)
+		}
+		return nil
+	})
+}
+
+// TestChannel 立即向渠道发送一条测试消息（不重试、不写投递日志）。
+// req 不为空时使用请求中的配置（用于保存前测试，channelID 不为 0 时脱敏值取已保存的原值），否则使用已保存的配置；
+// 邮件渠道未配置收件人时发送给当前用户
+func (s *NotificationService) TestChannel(ctx context.Context, channelID uint, req *models.NotificationChannelRequest, userID uint, username string) error {
+	var channel *models.NotificationChannel
+	var cfg models.NotificationChannelConfig
+	if req != nil {
+		channel = &models.NotificationChannel{Name: req.Name, Type: req.Type}
+		cfg = req.Config
+		if channelID != 0 {
+			saved, err := s.getChannel(channelID)
+			if err != nil {
+				return err
+			}
+			if err := restoreNotificationSecrets(&cfg, decodeNotificationConfig(saved)); err != nil {
+				return err
+			}
+		}
+	} else {
+		saved, err := s.getChannel(channelID)
+		if err != nil {
+			return err
+		}
+		channel = saved
+		cfg = decodeNotificationConfig(saved)
+	}
+	if err := ValidateNotificationChannel(channel.Type, &cfg); err != nil {
+		return err
+	}
+
+	recipients := append([]string(nil), cfg.To...)
+	if channel.Type == models.NotificationChannelEmail && len(recipients) == 0 {
+		var user models.User
+		if err := s.db.First(&user, userID).Error; err == nil && user.Email != "" {
+			recipients = []string{user.Email}
+		}
+	}
+
+	event := &models.NotificationEvent{
+		Type:      models.NotificationEventTest,
+		Severity:  models.NotificationSeverityInfo,
+		Title:     "KubePolaris 通知测试",
+		Content:   fmt.Sprintf("这是一条来自 KubePolaris 的测试消息，用于验证通知渠道「%s」配置是否正确。", channel.Name),
+		LabelMap:  map[string]string{"operator": username},
+		CreatedAt: time.Now(),
+	}
+	ctx, cancel := context.WithTimeout(ctx, notificationSendTimeout)
+	defer cancel()
+	_, err := s.sender.Send(ctx, &notificationMessage{Channel: channel, Config: &cfg, Event: event, Recipients: recipients})
+	return err
+}
+
+// ValidateNotificationChannel 校验渠道配置
+func ValidateNotificationChannel(channelType string, cfg *models.NotificationChannelConfig) error {
+	switch channelType {
+	case models.NotificationChannelWebhook, models.NotificationChannelSlack, models.NotificationChannelDingTalk,
+		models.NotificationChannelFeishu, models.NotificationChannelWeCom:
+		u, err := url.Parse(strings.TrimSpace(cfg.URL))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("请填写有效的 Webhook 地址（http/https）")
+		}
+		if channelType == models.NotificationChannelWebhook {
+			switch strings.ToUpper(cfg.Method) {
+			case "", "POST", "PUT", "PATCH":
+			default:
+				return fmt.Errorf("Webhook 请求方法仅支持 POST、PUT、PATCH")
+			}
+			if cfg.PayloadTemplate != "" {
+				if _, err := parseNotificationTemplate(cfg.PayloadTemplate); err != nil {
+					return err
+				}
+			}
+		}
+	case models.NotificationChannelEmail:
+		if strings.TrimSpace(cfg.SMTPHost) == "" {
+			return fmt.Errorf("请填写 SMTP 服务器地址")
+		}
+		if cfg.SMTPPort < 0 || cfg.SMTPPort > 65535 {
+			return fmt.Errorf("无效的 SMTP 端口: %d", cfg.SMTPPort)
+		}
+		if cfg.From == "" && !strings.Contains(cfg.Username, "@") {
+			return fmt.Errorf("请填写发件人地址")
+		}
+	default:
+		return fmt.Errorf("不支持的通知渠道类型: %s", channelType)
+	}
+	return nil
+}
+
+func decodeNotificationConfig(channel *models.NotificationChannel) models.NotificationChannelConfig {
+	var cfg models.NotificationChannelConfig
+	if channel.Config != "" {
+		_ = json.Unmarshal([]byte(channel.Config), &cfg)
+	}
+	return cfg
+}
+
+// notificationSensitiveHeader 判断 Webhook 请求头是否为认证类信息
+func notificationSensitiveHeader(name string) bool {
+	name = strings.ToLower(name)
+	for _, key := range []string{"authorization", "token", "secret", "key", "password"} {
+		if strings.Contains(name, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// maskNotificationConfig 脱敏密码、加签密钥与认证类请求头
+func maskNotificationConfig(cfg models.NotificationChannelConfig) models.NotificationChannelConfig {
+	if cfg.Password != "" {
+		cfg.Password = models.NotificationSecretMask
+	}
+	if cfg.Secret != "" {
+		cfg.Secret = models.NotificationSecretMask
+	}
+	if len(cfg.Headers) > 0 {
+		headers := make(map[string]string, len(cfg.Headers))
+		for k, v := range cfg.Headers {
+			if notificationSensitiveHeader(k) && v != "" {
+				v = models.NotificationSecretMask
+			}
+			headers[k] = v
+		}
+		cfg.Headers = headers
+	}
+	return cfg
+}
+
+// restoreNotificationSecrets 将请求中的脱敏值还原为已保存的原值；
+// 发送目标（Webhook 地址、SMTP 服务器、端口、用户名）变更时不还原，避免原密钥被发往新的地址
+func restoreNotificationSecrets(cfg *models.NotificationChannelConfig, saved models.NotificationChannelConfig) error {
+	masked := cfg.Password == models.NotificationSecretMask || cfg.Secret == models.NotificationSecretMask
+	for _, v := range cfg.Headers {
+		if v == models.NotificationSecretMask {
+			masked = true
+		}
+	}
+	if !masked {
+		return nil
+	}
+	if strings.TrimSpace(cfg.URL) != strings.TrimSpace(saved.URL) ||
+		strings.TrimSpace(cfg.SMTPHost) != strings.TrimSpace(saved.SMTPHost) ||
+		cfg.SMTPPort != saved.SMTPPort || cfg.Username != saved.Username {
+		return fmt.Errorf("修改发送地址、SMTP 服务器、端口或用户名时需要重新填写密码、密钥和认证请求头")
+	}
+
+	if cfg.Password == models.NotificationSecretMask {
+		cfg.Password = saved.Password
+	}
+	if cfg.Secret == models.NotificationSecretMask {
+		cfg.Secret = saved.Secret
+	}
+	for k, v := range cfg.Headers {
+		if v == models.NotificationSecretMask {
+			cfg.Headers[k] = saved.Headers[k]
+		}
+	}
+	return nil
+}
+
+// ========== 订阅 ==========
+
+// ListSubscriptions 获取订阅列表，userID 不为 0 时只返回该用户本人的订阅
+func (s *NotificationService) ListSubscriptions(userID uint) ([]models.NotificationSubscription, error) {
+	query := s.db.Order("id ASC")
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	var subs []models.NotificationSubscription
+	if err := query.Find(&subs).Error; err != nil {
+		return nil, fmt.Errorf("查询通知订阅失败: %w", err)
+	}
+
+	channelNames := make(map[uint]string)
+	var channels []models.NotificationChannel
+	s.db.Select("id", "name").Find(&channels)
+	for _, ch := range channels {
+		channelNames[ch.ID] = ch.Name
+	}
+	for i := range subs {
+		decodeNotificationSubscription(&subs[i])
+		subs[i].ChannelName = channelNames[subs[i].ChannelID]
+		if subs[i].UserID != nil {
+			var user models.User
+			if s.db.Select("id", "username").First(&user, *subs[i].UserID).Error == nil {
+				subs[i].SubjectName = user.Username
+			}
+		} else if subs[i].UserGroupID != nil {
+			var group models.UserGroup
+			if s.db.Select("id", "name").First(&group, *subs[i].UserGroupID).Error == nil {
+				subs[i].SubjectName = group.Name
+			}
+		}
+	}
+	return subs, nil
+}
+
+// SaveSubscription 创建或更新订阅，id 为 0 时创建
+func (s *NotificationService) SaveSubscription(id uint, req *models.NotificationSubscriptionRequest, userID uint) (*models.NotificationSubscription, error) {
+	sub := &models.NotificationSubscription{CreatedBy: userID}
+	if id != 0 {
+		if err := s.db.First(sub, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, fmt.Errorf("通知订阅不存在: %d", id)
+			}
+			return nil, fmt.Errorf("查询通知订阅失败: %w", err)
+		}
+	}
+	if err := validateNotificationSubscription(req); err != nil {
+		return nil, err
+	}
+	if _, err := s.getChannel(req.ChannelID); err != nil {
+		return nil, err
+	}
+	if req.UserID != nil {
+		if err := s.db.First(&models.User{}, *req.UserID).Error; err != nil {
+			return nil, fmt.Errorf("用户不存在: %d", *req.UserID)
+		}
+	} else if err := s.db.First(&models.UserGroup{}, *req.UserGroupID).Error; err != nil {
+		return nil, fmt.Errorf("用户组不存在: %d", *req.UserGroupID)
+	}
+
+	sub.UserID = req.UserID
+	sub.UserGroupID = req.UserGroupID
+	sub.ChannelID = req.ChannelID
+	sub.Enabled = req.Enabled
+	eventTypes, _ := json.Marshal(req.EventTypes)
+	sub.EventTypes = string(eventTypes)
+	sub.ClusterIDs = ""
+	if len(req.ClusterIDs) > 0 {
+		clusterIDs, _ := json.Marshal(req.ClusterIDs)
+		sub.ClusterIDs = string(clusterIDs)
+	}
+	if err := s.db.Save(sub).Error; err != nil {
+		return nil, fmt.Errorf("保存通知订阅失败: %w", err)
+	}
+	decodeNotificationSubscription(sub)
+	return sub, nil
+}
+
+// DeleteSubscription 删除订阅
+func (s *NotificationService) DeleteSubscription(id uint) error {
+	result := s.db.Delete(&models.NotificationSubscription{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("删除通知订阅失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("通知订阅不存在: %d", id)
+	}
+	return nil
+}
+
+func validateNotificationSubscription(req *models.NotificationSubscriptionRequest) error {
+	if (req.UserID == nil) == (req.UserGroupID == nil) {
+		return fmt.Errorf("请指定订阅的用户或用户组（二选一）")
+	}
+	if len(req.EventTypes) == 0 {
+		return fmt.Errorf("请至少选择一种事件类型")
+	}
+	for _, t := range req.EventTypes {
+		if _, ok := models.NotificationEventTypes[t]; !ok {
+			return fmt.Errorf("不支持的事件类型: %s", t)
+		}
+	}
+	return nil
+}
+
+func decodeNotificationSubscription(sub *models.NotificationSubscription) {
+	sub.EventTypeList = nil
+	sub.ClusterIDList = nil
+	if sub.EventTypes != "" {
+		_ = json.Unmarshal([]byte(sub.EventTypes), &sub.EventTypeList)
+	}
+	if sub.ClusterIDs != "" {
+		_ = json.Unmarshal([]byte(sub.ClusterIDs), &sub.ClusterIDList)
+	}
+}
+
+// matchNotificationSubscription 判断订阅是否接收该事件
+func matchNotificationSubscription(sub *models.NotificationSubscription, event *models.NotificationEvent) bool {
+	if !sub.Enabled || !containsString(sub.EventTypeList, event.Type) {
+		return false
+	}
+	if len(sub.ClusterIDList) == 0 {
+		return true
+	}
+	for _, id := range sub.ClusterIDList {
+		if id == event.ClusterID {
+			return true
+		}
+	}
+	return false
+}
+
+// ========== 事件发布 ==========
+
+// Publish 记录事件并为每个订阅了该事件的渠道创建投递，由调度器异步发送。
+// 未启用数据库时忽略，调用方无需判断
+func (s *NotificationService) Publish(event *models.NotificationEvent) {
+	if s == nil || s.db == nil {
+		return
+	}
+	if err := s.publish(event); err != nil {
+		logger.Error("发布通知事件失败", "type", event.Type, "cluster", event.ClusterName, "error", err)
+		return
+	}
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *NotificationService) publish(event *models.NotificationEvent) error {
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	if event.Severity == "" {
+		event.Severity = models.NotificationSeverityWarning
+	}
+	if event.ClusterID != 0 && event.ClusterName == "" {
+		var cluster models.Cluster
+		if s.db.Select("id", "name").First(&cluster, event.ClusterID).Error == nil {
+			event.ClusterName = cluster.Name
+		}
+	}
+
+	var subs []models.NotificationSubscription
+	if err := s.db.Where("enabled = ?", true).Find(&subs).Error; err != nil {
+		return fmt.Errorf("查询通知订阅失败: %w", err)
+	}
+	byChannel := make(map[uint][]*models.NotificationSubscription)
+	for i := range subs {
+		decodeNotificationSubscription(&subs[i])
+		if matchNotificationSubscription(&subs[i], event) {
+			byChannel[subs[i].ChannelID] = append(byChannel[subs[i].ChannelID], &subs[i])
+		}
+	}
+	if len(byChannel) == 0 {
+		return nil
+	}
+
+	if len(event.LabelMap) > 0 {
+		labels, _ := json.Marshal(event.LabelMap)
+		event.Labels = string(labels)
+	}
+	if err := s.db.Create(event).Error; err != nil {
+		return fmt.Errorf("保存通知事件失败: %w", err)
+	}
+
+	channelIDs := make([]uint, 0, len(byChannel))
+	for id := range byChannel {
+		channelIDs = append(channelIDs, id)
+	}
+	sort.Slice(channelIDs, func(i, j int) bool { return channelIDs[i] < channelIDs[j] })
+	for _, channelID := range channelIDs {
+		channel, err := s.getChannel(channelID)
+		if err != nil || !channel.Enabled {
+			continue
+		}
+		delivery := &models.NotificationDelivery{
+			EventID:   event.ID,
+			ChannelID: channelID,
+			Status:    models.NotificationDeliveryPending,
+		}
+		if channel.Type == models.NotificationChannelEmail {
+			cfg := decodeNotificationConfig(channel)
+			delivery.Recipients = strings.Join(s.emailRecipients(cfg.To, byChannel[channelID]), ",")
+		}
+		if err := s.db.Create(delivery).Error; err != nil {
+			return fmt.Errorf("创建通知投递失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// emailRecipients 合并渠道固定收件人与订阅用户、用户组成员的邮箱（去重，忽略非 active 用户）
+func (s *NotificationService) emailRecipients(fixed []string, subs []*models.NotificationSubscription) []string {
+	var userIDs, groupIDs []uint
+	for _, sub := range subs {
+		if sub.UserID != nil {
+			userIDs = append(userIDs, *sub.UserID)
+		} else if sub.UserGroupID != nil {
+			groupIDs = append(groupIDs, *sub.UserGroupID)
+		}
+	}
+	if len(groupIDs) > 0 {
+		var members []models.UserGroupMember
+		s.db.Where("user_group_id IN ?", groupIDs).Find(&members)
+		for _, m := range members {
+			userIDs = append(userIDs, m.UserID)
+		}
+	}
+	var users []models.User
+	if len(userIDs) > 0 {
+		s.db.Where("id IN ? AND status = ?", userIDs, "active").Find(&users)
+	}
+
+	emails := make([]string, 0, len(fixed)+len(users))
+	seen := make(map[string]bool)
+	add := func(addr string) {
+		addr = strings.TrimSpace(addr)
+		key := strings.ToLower(addr)
+		if addr == "" || seen[key] {
+			return
+		}
+		seen[key] = true
+		emails = append(emails, addr)
+	}
+	for _, addr := range fixed {
+		add(addr)
+	}
+	for _, u := range users {
+		add(u.Email)
+	}
+	return emails
+}
+
+// ClusterStatusChanged 集群状态变化时发布集群异常 / 恢复事件
+func (s *NotificationService) ClusterStatusChanged(cluster *models.Cluster, oldStatus, newStatus string) {
+	if oldStatus == newStatus {
+		return
+	}
+	event := &models.NotificationEvent{
+		ClusterID:   cluster.ID,
+		ClusterName: cluster.Name,
+		LabelMap:    map[string]string{"previous_status": oldStatus, "status": newStatus},
+	}
+	switch newStatus {
+	case "unhealthy", "warning":
+		event.Type = models.NotificationEventClusterUnhealthy
+		event.Severity = models.NotificationSeverityCritical
+		if newStatus == "warning" {
+			event.Severity = models.NotificationSeverityWarning
+		}
+		event.Title = fmt.Sprintf("集群 %s 状态异常", cluster.Name)
+		event.Content = fmt.Sprintf("集群 %s 状态由 %s 变为 %s，请检查 API Server 连通性与节点状态。", cluster.Name, oldStatus, newStatus)
+	case "healthy":
+		if oldStatus != "unhealthy" && oldStatus != "warning" {
+			return
+		}
+		event.Type = models.NotificationEventClusterRecovered
+		event.Severity = models.NotificationSeverityInfo
+		event.Title = fmt.Sprintf("集群 %s 已恢复", cluster.Name)
+		event.Content = fmt.Sprintf("集群 %s 状态由 %s 恢复为 healthy。", cluster.Name, oldStatus)
+	default:
+		return
+	}
+	s.Publish(event)
+}
+
+// NodeDrained 节点驱逐结束时发布事件，drainErr 不为空表示驱逐失败
+func (s *NotificationService) NodeDrained(cluster *models.Cluster, nodeName, operator string, drainErr error) {
+	event := &models.NotificationEvent{
+		Type:        models.NotificationEventNodeDrained,
+		Severity:    models.NotificationSeverityInfo,
+		ClusterID:   cluster.ID,
+		ClusterName: cluster.Name,
+		Title:       fmt.Sprintf("节点 %s 驱逐完成", nodeName),
+		Content:     fmt.Sprintf("集群 %s 的节点 %s 已完成驱逐。", cluster.Name, nodeName),
+		LabelMap:    map[string]string{"node": nodeName},
+	}
+	if operator != "" {
+		event.LabelMap["operator"] = operator
+	}
+	if drainErr != nil {
+		event.Severity = models.NotificationSeverityWarning
+		event.Title = fmt.Sprintf("节点 %s 驱逐失败", nodeName)
+		event.Content = fmt.Sprintf("集群 %s 的节点 %s 驱逐失败: %s", cluster.Name, nodeName, drainErr.Error())
+	}
+	s.Publish(event)
+}
+
+// ApplyFailed YAML 应用失败时发布事件
+func (s *NotificationService) ApplyFailed(clusterID uint, resourceType, namespace, name, operator, message string) {
+	labels := map[string]string{"resource_type": resourceType}
+	if namespace != "" {
+		labels["namespace"] = namespace
+	}
+	if name != "" {
+		labels["name"] = name
+	}
+	if operator != "" {
+		labels["operator"] = operator
+	}
+	s.Publish(&models.NotificationEvent{
+		Type:      models.NotificationEventApplyFailed,
+		Severity:  models.NotificationSeverityWarning,
+		ClusterID: clusterID,
+		Title:     fmt.Sprintf("%s YAML 应用失败", resourceType),
+		Content:   truncateNotificationText(message),
+		LabelMap:  labels,
+	})
+}
+
+// HealthScoreDropped 健康诊断评分较上次下降超过阈值时发布事件
+func (s *NotificationService) HealthScoreDropped(cluster *models.Cluster, previous, score int, status string) {
+	if s == nil || previous-score < s.opts.HealthScoreDrop {
+		return
+	}
+
+	severity := models.NotificationSeverityWarning
+	if status == "critical" {
+		severity = models.NotificationSeverityCritical
+	}
+	s.Publish(&models.NotificationEvent{
+		Type:        models.NotificationEventHealthScoreDrop,
+		Severity:    severity,
+		ClusterID:   cluster.ID,
+		ClusterName: cluster.Name,
+		Title:       fmt.Sprintf("集群 %s 健康评分下降", cluster.Name),
+		Content:     fmt.Sprintf("集群 %s 健康评分由 %d 下降到 %d（%s）。", cluster.Name, previous, score, status),
+		LabelMap: map[string]string{
+			"previous_score": fmt.Sprintf("%d", previous),
+			"score":          fmt.Sprintf("%d", score),
+			"status":         status,
+		},
+	})
+}
+
+// HealthNewRisks 健康诊断出现上次没有的严重风险时发布事件，多个风险合并为一条通知
+func (s *NotificationService) HealthNewRisks(cluster *models.Cluster, risks []models.RiskItem) {
+	if s == nil || len(risks) == 0 {
+		return
+	}
+	lines := make([]string, 0, len(risks))
+	for _, risk := range risks {
+		line := "- " + risk.Title
+		if risk.Description != "" {
+			line += ": " + risk.Description
+		}
+		lines = append(lines, line)
+	}
+	title := fmt.Sprintf("集群 %s 出现严重风险: %s", cluster.Name, risks[0].Title)
+	if len(risks) > 1 {
+		title = fmt.Sprintf("集群 %s 出现 %d 个新的严重风险", cluster.Name, len(risks))
+	}
+	s.Publish(&models.NotificationEvent{
+		Type:        models.NotificationEventHealthNewRisk,
+		Severity:    models.NotificationSeverityCritical,
+		ClusterID:   cluster.ID,
+		ClusterName: cluster.Name,
+		Title:       title,
+		Content:     truncateNotificationText(strings.Join(lines, "\n")),
+		LabelMap:    map[string]string{"count": fmt.Sprintf("%d", len(risks))},
+	})
+}
+
+// CertificatesExpiring 证书进入告警、严重阈值或已过期时发布事件，同一集群一次扫描的证书合并为一条通知
+func (s *NotificationService) CertificatesExpiring(cluster *models.Cluster, certs []models.ClusterCertificate) {
+	if s == nil || len(certs) == 0 {
+		return
+	}
+	severity := models.NotificationSeverityWarning
+	lines := make([]string, 0, len(certs))
+	for _, cert := range certs {
+		if cert.Status == models.CertificateStatusCritical || cert.Status == models.CertificateStatusExpired {
+			severity = models.NotificationSeverityCritical
+		}
+		object := cert.Name
+		if cert.Namespace != "" {
+			object = cert.Namespace + "/" + cert.Name
+		}
+		state := fmt.Sprintf("剩余 %d 天", cert.DaysLeft)
+		if cert.Status == models.CertificateStatusExpired {
+			state = "已过期"
+		}
+		lines = append(lines, fmt.Sprintf("- [%s] %s（%s）: %s，过期时间 %s", cert.Source, object, cert.Subject, state, cert.NotAfter.Format("2006-01-02 15:04")))
+	}
+	title := fmt.Sprintf("集群 %s 有 %d 个证书即将过期", cluster.Name, len(certs))
+	if len(certs) == 1 {
+		title = fmt.Sprintf("集群 %s 证书 %s 即将过期", cluster.Name, certs[0].Name)
+		if certs[0].Status == models.CertificateStatusExpired {
+			title = fmt.Sprintf("集群 %s 证书 %s 已过期", cluster.Name, certs[0].Name)
+		}
+	}
+	s.Publish(&models.NotificationEvent{
+		Type:        models.NotificationEventCertExpiring,
+		Severity:    severity,
+		ClusterID:   cluster.ID,
+		ClusterName: cluster.Name,
+		Title:       title,
+		Content:     truncateNotificationText(strings.Join(lines, "\n")),
+		LabelMap:    map[string]string{"count": fmt.Sprintf("%d", len(certs))},
+	})
+}
+
+// ========== 投递 ==========
+
+// ListDeliveries 分页查询投递记录
+func (s *NotificationService) ListDeliveries(q *models.NotificationDeliveryQuery) (*models.NotificationDeliveryListResponse, error) {
+	if q.Page <= 0 {
+		q.Page = 1
+	}
+	if q.PageSize <= 0 || q.PageSize > 200 {
+		q.PageSize = 20
+	}
+
+	query := s.db.Model(&models.NotificationDelivery{})
+	if q.ChannelID != 0 {
+		query = query.Where("channel_id = ?", q.ChannelID)
+	}
+	if q.Status != "" {
+		query = query.Where("status = ?", q.Status)
+	}
+	if q.EventType != "" || q.ClusterID != 0 {
+		events := s.db.Model(&models.NotificationEvent{}).Select("id")
+		if q.EventType != "" {
+			events = events.Where("type = ?", q.EventType)
+		}
+		if q.ClusterID != 0 {
+			events = events.Where("cluster_id = ?", q.ClusterID)
+		}
+		query = query.Where("event_id IN (?)", events)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("查询投递记录失败: %w", err)
+	}
+	var items []models.NotificationDelivery
+	if err := query.Order("id DESC").Offset((q.Page - 1) * q.PageSize).Limit(q.PageSize).Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("查询投递记录失败: %w", err)
+	}
+
+	eventIDs := make([]uint, 0, len(items))
+	for _, d := range items {
+		eventIDs = append(eventIDs, d.EventID)
+	}
+	events := make(map[uint]*models.NotificationEvent)
+	if len(eventIDs) > 0 {
+		var list []models.NotificationEvent
+		s.db.Where("id IN ?", eventIDs).Find(&list)
+		for i := range list {
+			if list[i].Labels != "" {
+				_ = json.Unmarshal([]byte(list[i].Labels), &list[i].LabelMap)
+			}
+			events[list[i].ID] = &list[i]
+		}
+	}
+	var channels []models.NotificationChannel
+	s.db.Unscoped().Select("id", "name", "type").Find(&channels)
+	channelByID := make(map[uint]models.NotificationChannel, len(channels))
+	for _, ch := range channels {
+		channelByID[ch.ID] = ch
+	}
+	for i := range items {
+		items[i].Event = events[items[i].EventID]
+		items[i].ChannelName = channelByID[items[i].ChannelID].Name
+		items[i].ChannelType = channelByID[items[i].ChannelID].Type
+	}
+
+	return &models.NotificationDeliveryListResponse{Items: items, Total: total, Page: q.Page, PageSize: q.PageSize}, nil
+}
+
+// RetryDelivery 将失败的投递重新加入发送队列，重置尝试次数
+func (s *NotificationService) RetryDelivery(id uint) error {
+	result := s.db.Model(&models.NotificationDelivery{}).
+		Where("id = ? AND status = ?", id, models.NotificationDeliveryFailed).
+		Updates(map[string]interface{}{
+			"status":        models.NotificationDeliveryPending,
+			"attempts":      0,
+			"next_retry_at": nil,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("重试投递失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("投递记录不存在或不是失败状态: %d", id)
+	}
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// dispatchDue 发送到期的投递
+func (s *NotificationService) dispatchDue(ctx context.Context) {
+	var deliveries []models.NotificationDelivery
+	err := s.db.Where("status = ? AND (next_retry_at IS NULL OR next_retry_at <= ?)", models.NotificationDeliveryPending, time.Now()).
+		Order("id ASC").Limit(notificationDispatchBatch).Find(&deliveries).Error
+	if err != nil {
+		logger.Error("查询待发送通知失败", "error", err)
+		return
+	}
+	for i := range deliveries {
+		if ctx.Err() != nil {
+			return
+		}
+		s.deliver(ctx, &deliveries[i])
+	}
+}
+
+// deliver 发送一次并更新投递状态
+func (s *NotificationService) deliver(ctx context.Context, delivery *models.NotificationDelivery) {
+	var event models.NotificationEvent
+	if err := s.db.First(&event, delivery.EventID).Error; err != nil {
+		s.finish(delivery, 0, fmt.Errorf("通知事件不存在: %d", delivery.EventID), true)
+		return
+	}
+	if event.Labels != "" {
+		_ = json.Unmarshal([]byte(event.Labels), &event.LabelMap)
+	}
+	channel, err := s.getChannel(delivery.ChannelID)
+	if err != nil {
+		s.finish(delivery, 0, err, true)
+		return
+	}
+	cfg := decodeNotificationConfig(channel)
+	var recipients []string
+	if delivery.Recipients != "" {
+		recipients = strings.Split(delivery.Recipients, ",")
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, notificationSendTimeout)
+	defer cancel()
+	statusCode, sendErr := s.sender.Send(sendCtx, &notificationMessage{Channel: channel, Config: &cfg, Event: &event, Recipients: recipients})
+	s.finish(delivery, statusCode, sendErr, false)
+}
+
+// finish 记录发送结果；失败且未达到最大次数时按指数退避安排重试，permanent 为 true 时不再重试
+func (s *NotificationService) finish(delivery *models.NotificationDelivery, statusCode int, sendErr error, permanent bool) {
+	now := time.Now()
+	delivery.Attempts++
+	updates := map[string]interface{}{
+		"attempts":    delivery.Attempts,
+		"status_code": statusCode,
+	}
+	switch {
+	case sendErr == nil:
+		updates["status"] = models.NotificationDeliverySuccess
+		updates["sent_at"] = &now
+		updates["last_error"] = ""
+		updates["next_retry_at"] = nil
+	case permanent || delivery.Attempts >= s.opts.MaxAttempts:
+		updates["status"] = models.NotificationDeliveryFailed
+		updates["last_error"] = truncateNotificationText(sendErr.Error())
+		updates["next_retry_at"] = nil
+	default:
+		next := now.Add(notificationBackoff(s.opts.RetryBase, delivery.Attempts))
+		updates["last_error"] = truncateNotificationText(sendErr.Error())
+		updates["next_retry_at"] = &next
+	}
+	if sendErr != nil {
+		logger.Warn("通知发送失败", "delivery", delivery.ID, "channel", delivery.ChannelID, "attempts", delivery.Attempts, "error", sendErr)
+	}
+	if err := s.db.Model(&models.NotificationDelivery{}).Where("id = ?", delivery.ID).Updates(updates).Error; err != nil {
+		logger.Error("更新通知投递状态失败", "delivery", delivery.ID, "error", err)
+	}
+}
+
+// notificationBackoff 第 attempts 次失败后的重试间隔：base * 2^(attempts-1)，不超过 notificationMaxBackoff
+func notificationBackoff(base time.Duration, attempts int) time.Duration {
+	d := base
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= notificationMaxBackoff {
+			return notificationMaxBackoff
+		}
+	}
+	if d > notificationMaxBackoff {
+		return notificationMaxBackoff
+	}
+	return d
+}
@@ -0,0 +1,310 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// notificationRequest 本地 HTTP 替身收到的请求
+type notificationRequest struct {
+	Method string
+	Query  map[string]string
+	Header http.Header
+	Body   map[string]interface{}
+	Raw    string
+}
+
+// NotificationServiceTestSuite 定义通知中心测试套件
+type NotificationServiceTestSuite struct {
+	suite.Suite
+	now      time.Time
+	sender   *notificationSender
+	server   *httptest.Server
+	requests []notificationRequest
+	response string
+	status   int
+	event    *models.NotificationEvent
+}
+
+// SetupTest 每个测试前启动一个记录请求的本地 HTTP 服务，代替 Webhook / IM 机器人地址
+func (s *NotificationServiceTestSuite) SetupTest() {
+	s.now = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	s.requests = nil
+	s.response = `{"errcode":0,"errmsg":"ok"}`
+	s.status = http.StatusOK
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		req := notificationRequest{Method: r.Method, Query: map[string]string{}, Header: r.Header, Raw: string(raw)}
+		for k := range r.URL.Query() {
+			req.Query[k] = r.URL.Query().Get(k)
+		}
+		_ = json.Unmarshal(raw, &req.Body)
+		s.requests = append(s.requests, req)
+		w.WriteHeader(s.status)
+		_, _ = w.Write([]byte(s.response))
+	}))
+	s.sender = newNotificationSender()
+	s.sender.now = func() time.Time { return s.now }
+	s.event = &models.NotificationEvent{
+		Type:        models.NotificationEventNodeDrained,
+		Severity:    models.NotificationSeverityInfo,
+		ClusterID:   1,
+		ClusterName: "prod",
+		Title:       "节点 node-a 驱逐完成",
+		Content:     "集群 prod 的节点 node-a 已完成驱逐。",
+		LabelMap:    map[string]string{"node": "node-a", "operator": "admin"},
+		CreatedAt:   s.now,
+	}
+}
+
+// TearDownTest 每个测试后关闭本地 HTTP 服务
+func (s *NotificationServiceTestSuite) TearDownTest() {
+	s.server.Close()
+}
+
+func (s *NotificationServiceTestSuite) send(channelType string, cfg models.NotificationChannelConfig, recipients ...string) (int, error) {
+	return s.sender.Send(context.Background(), &notificationMessage{
+		Channel:    &models.NotificationChannel{Name: "test", Type: channelType},
+		Config:     &cfg,
+		Event:      s.event,
+		Recipients: recipients,
+	})
+}
+
+// TestWebhookTemplate 测试通用 Webhook 的请求体模板、请求方法与请求头
+func (s *NotificationServiceTestSuite) TestWebhookTemplate() {
+	code, err := s.send(models.NotificationChannelWebhook, models.NotificationChannelConfig{
+		URL:             s.server.URL + "/hook",
+		Method:          "put",
+		Headers:         map[string]string{"Authorization": "Bearer abc"},
+		PayloadTemplate: `{"text": {{ json .Content }}, "node": "{{ .Labels.node }}", "event": "{{ .EventName }}", "at": "{{ .Time }}"}`,
+	})
+	s.Require().NoError(err)
+	assert.Equal(s.T(), http.StatusOK, code)
+	s.Require().Len(s.requests, 1)
+	req := s.requests[0]
+	assert.Equal(s.T(), http.MethodPut, req.Method)
+	assert.Equal(s.T(), "Bearer abc", req.Header.Get("Authorization"))
+	assert.Equal(s.T(), s.event.Content, req.Body["text"])
+	assert.Equal(s.T(), "node-a", req.Body["node"])
+	assert.Equal(s.T(), "节点驱逐完成", req.Body["event"])
+	assert.Equal(s.T(), "2024-01-01T12:00:00Z", req.Body["at"])
+
+	// 未配置模板时发送事件 JSON
+	_, err = s.send(models.NotificationChannelWebhook, models.NotificationChannelConfig{URL: s.server.URL})
+	s.Require().NoError(err)
+	assert.Equal(s.T(), models.NotificationEventNodeDrained, s.requests[1].Body["type"])
+	assert.Equal(s.T(), "node-a", s.requests[1].Body["labels"].(map[string]interface{})["node"])
+
+	// 非 2xx 响应视为失败，并带上响应码
+	s.status = http.StatusBadGateway
+	s.response = "upstream unavailable"
+	code, err = s.send(models.NotificationChannelWebhook, models.NotificationChannelConfig{URL: s.server.URL})
+	assert.Equal(s.T(), http.StatusBadGateway, code)
+	assert.EqualError(s.T(), err, "HTTP 502: upstream unavailable")
+}
+
+// TestDingTalk 测试钉钉 Markdown 消息与加签参数
+func (s *NotificationServiceTestSuite) TestDingTalk() {
+	_, err := s.send(models.NotificationChannelDingTalk, models.NotificationChannelConfig{
+		URL:       s.server.URL + "/robot/send?access_token=t",
+		Secret:    "SEC123",
+		AtMobiles: []string{"13800000000"},
+	})
+	s.Require().NoError(err)
+	req := s.requests[0]
+	assert.Equal(s.T(), "t", req.Query["access_token"])
+	assert.Equal(s.T(), "1704110400000", req.Query["timestamp"])
+	assert.NotEmpty(s.T(), req.Query["sign"])
+	assert.Equal(s.T(), "markdown", req.Body["msgtype"])
+	text := req.Body["markdown"].(map[string]interface{})["text"].(string)
+	assert.Contains(s.T(), text, "### 节点 node-a 驱逐完成")
+	assert.Contains(s.T(), text, "- node: node-a")
+	assert.Contains(s.T(), text, "@13800000000")
+
+	// 机器人返回 errcode 非 0 时视为失败
+	s.response = `{"errcode":310000,"errmsg":"sign not match"}`
+	_, err = s.send(models.NotificationChannelDingTalk, models.NotificationChannelConfig{URL: s.server.URL})
+	assert.EqualError(s.T(), err, "HTTP 200: errcode=310000 sign not match")
+}
+
+// TestFeishuWeComSlack 测试飞书、企业微信与 Slack 的消息格式
+func (s *NotificationServiceTestSuite) TestFeishuWeComSlack() {
+	s.response = `{"code":0,"msg":"success"}`
+	_, err := s.send(models.NotificationChannelFeishu, models.NotificationChannelConfig{URL: s.server.URL, Secret: "abc"})
+	s.Require().NoError(err)
+	feishu := s.requests[0].Body
+	assert.Equal(s.T(), "text", feishu["msg_type"])
+	assert.Equal(s.T(), "1704110400", feishu["timestamp"])
+	assert.Equal(s.T(), feishuSign("1704110400", "abc"), feishu["sign"])
+	assert.Contains(s.T(), feishu["content"].(map[string]interface{})["text"], "集群: prod")
+
+	s.response = `{"code":19021,"msg":"sign match fail"}`
+	_, err = s.send(models.NotificationChannelFeishu, models.NotificationChannelConfig{URL: s.server.URL})
+	assert.Error(s.T(), err)
+
+	s.response = `{"errcode":0}`
+	_, err = s.send(models.NotificationChannelWeCom, models.NotificationChannelConfig{URL: s.server.URL})
+	s.Require().NoError(err)
+	assert.Equal(s.T(), "markdown", s.requests[2].Body["msgtype"])
+	_, err = s.send(models.NotificationChannelWeCom, models.NotificationChannelConfig{URL: s.server.URL, AtMobiles: []string{"13800000000"}})
+	s.Require().NoError(err)
+	assert.Equal(s.T(), "text", s.requests[3].Body["msgtype"])
+
+	s.response = "ok"
+	_, err = s.send(models.NotificationChannelSlack, models.NotificationChannelConfig{URL: s.server.URL})
+	s.Require().NoError(err)
+	assert.True(s.T(), strings.HasPrefix(s.requests[4].Body["text"].(string), "*节点 node-a 驱逐完成*\n"))
+}
+
+// TestEmail 测试邮件内容与收件人
+func (s *NotificationServiceTestSuite) TestEmail() {
+	var gotFrom string
+	var gotTo []string
+	var gotMsg string
+	s.sender.sendMail = func(cfg *models.NotificationChannelConfig, from string, to []string, msg []byte) error {
+		gotFrom, gotTo, gotMsg = from, to, string(msg)
+		return nil
+	}
+	cfg := models.NotificationChannelConfig{SMTPHost: "smtp.example.com", Username: "ops@example.com"}
+
+	_, err := s.send(models.NotificationChannelEmail, cfg)
+	assert.Error(s.T(), err, "没有收件人时应失败")
+
+	_, err = s.send(models.NotificationChannelEmail, cfg, "a@example.com", "b@example.com")
+	s.Require().NoError(err)
+	assert.Equal(s.T(), "ops@example.com", gotFrom)
+	assert.Equal(s.T(), []string{"a@example.com", "b@example.com"}, gotTo)
+	assert.Contains(s.T(), gotMsg, "To: a@example.com, b@example.com\r\n")
+	assert.Contains(s.T(), gotMsg, "Subject: =?UTF-8?b?")
+	assert.Contains(s.T(), gotMsg, "Content-Transfer-Encoding: base64")
+}
+
+// TestValidateChannel 测试渠道配置校验
+func (s *NotificationServiceTestSuite) TestValidateChannel() {
+	assert.NoError(s.T(), ValidateNotificationChannel(models.NotificationChannelSlack, &models.NotificationChannelConfig{URL: "https://hooks.slack.com/services/x"}))
+	assert.Error(s.T(), ValidateNotificationChannel(models.NotificationChannelFeishu, &models.NotificationChannelConfig{URL: "ftp://example.com"}))
+	assert.Error(s.T(), ValidateNotificationChannel(models.NotificationChannelWebhook, &models.NotificationChannelConfig{URL: "http://example.com", Method: "GET"}))
+	assert.Error(s.T(), ValidateNotificationChannel(models.NotificationChannelWebhook, &models.NotificationChannelConfig{URL: "http://example.com", PayloadTemplate: "{{ .Title "}))
+	assert.Error(s.T(), ValidateNotificationChannel(models.NotificationChannelEmail, &models.NotificationChannelConfig{SMTPHost: "smtp.example.com", Username: "ops"}))
+	assert.NoError(s.T(), ValidateNotificationChannel(models.NotificationChannelEmail, &models.NotificationChannelConfig{SMTPHost: "smtp.example.com", From: "ops@example.com"}))
+	assert.Error(s.T(), ValidateNotificationChannel("sms", &models.NotificationChannelConfig{}))
+}
+
+// TestMaskAndRestoreSecrets 测试密钥脱敏与保存时还原
+func (s *NotificationServiceTestSuite) TestMaskAndRestoreSecrets() {
+	saved := models.NotificationChannelConfig{
+		URL:      "http://example.com",
+		Secret:   "sec",
+		Password: "pwd",
+		Headers:  map[string]string{"Authorization": "Bearer abc", "X-Team": "ops"},
+	}
+	masked := maskNotificationConfig(saved)
+	assert.Equal(s.T(), models.NotificationSecretMask, masked.Secret)
+	assert.Equal(s.T(), models.NotificationSecretMask, masked.Password)
+	assert.Equal(s.T(), models.NotificationSecretMask, masked.Headers["Authorization"])
+	assert.Equal(s.T(), "ops", masked.Headers["X-Team"])
+	assert.Equal(s.T(), "Bearer abc", saved.Headers["Authorization"], "脱敏不应修改原配置")
+
+	masked.Password = "new-pwd"
+	s.Require().NoError(restoreNotificationSecrets(&masked, saved))
+	assert.Equal(s.T(), "sec", masked.Secret)
+	assert.Equal(s.T(), "new-pwd", masked.Password)
+	assert.Equal(s.T(), "Bearer abc", masked.Headers["Authorization"])
+
+	// 发送目标变更时不还原原密钥
+	changed := maskNotificationConfig(saved)
+	changed.URL = "http://attacker.example.com"
+	assert.Error(s.T(), restoreNotificationSecrets(&changed, saved))
+	assert.Equal(s.T(), models.NotificationSecretMask, changed.Secret)
+
+	email := models.NotificationChannelConfig{SMTPHost: "smtp.example.com", SMTPPort: 465, Username: "ops", Password: "pwd"}
+	for _, mutate := range []func(*models.NotificationChannelConfig){
+		func(c *models.NotificationChannelConfig) { c.SMTPHost = "smtp.attacker.com" },
+		func(c *models.NotificationChannelConfig) { c.SMTPPort = 25 },
+		func(c *models.NotificationChannelConfig) { c.Username = "other" },
+	} {
+		cfg := maskNotificationConfig(email)
+		mutate(&cfg)
+		assert.Error(s.T(), restoreNotificationSecrets(&cfg, email))
+	}
+
+	// 重新填写密钥后允许修改发送目标
+	changed = models.NotificationChannelConfig{URL: "http://new.example.com", Secret: "new-sec"}
+	assert.NoError(s.T(), restoreNotificationSecrets(&changed, saved))
+}
+
+// TestMatchSubscription 测试订阅按事件类型与集群过滤
+func (s *NotificationServiceTestSuite) TestMatchSubscription() {
+	sub := &models.NotificationSubscription{Enabled: true, EventTypes: `["node_drained","apply_yaml_failed"]`}
+	decodeNotificationSubscription(sub)
+	assert.True(s.T(), matchNotificationSubscription(sub, s.event))
+
+	sub.ClusterIDs = `[2,3]`
+	decodeNotificationSubscription(sub)
+	assert.False(s.T(), matchNotificationSubscription(sub, s.event))
+	s.event.ClusterID = 3
+	assert.True(s.T(), matchNotificationSubscription(sub, s.event))
+
+	s.event.Type = models.NotificationEventClusterUnhealthy
+	assert.False(s.T(), matchNotificationSubscription(sub, s.event))
+
+	sub.Enabled = false
+	s.event.Type = models.NotificationEventNodeDrained
+	assert.False(s.T(), matchNotificationSubscription(sub, s.event))
+
+	group := uint(1)
+	assert.Error(s.T(), validateNotificationSubscription(&models.NotificationSubscriptionRequest{EventTypes: []string{"node_drained"}}))
+	assert.Error(s.T(), validateNotificationSubscription(&models.NotificationSubscriptionRequest{UserGroupID: &group, EventTypes: []string{"unknown"}}))
+	assert.NoError(s.T(), validateNotificationSubscription(&models.NotificationSubscriptionRequest{UserGroupID: &group, EventTypes: []string{"node_drained"}}))
+}
+
+// TestBackoff 测试指数退避与上限
+func (s *NotificationServiceTestSuite) TestBackoff() {
+	base := 30 * time.Second
+	assert.Equal(s.T(), 30*time.Second, notificationBackoff(base, 1))
+	assert.Equal(s.T(), time.Minute, notificationBackoff(base, 2))
+	assert.Equal(s.T(), 4*time.Minute, notificationBackoff(base, 4))
+	assert.Equal(s.T(), notificationMaxBackoff, notificationBackoff(base, 10))
+}
+
+// TestClusterHealthProbe 测试集群健康探测的状态判定
+func (s *NotificationServiceTestSuite) TestClusterHealthProbe() {
+	node := func(name string, ready corev1.ConditionStatus) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: ready}}},
+		}
+	}
+	checker := NewClusterHealthChecker(nil, nil)
+	probe := func(clientset *fake.Clientset) string {
+		checker.clientFor = func(*models.Cluster) (kubernetes.Interface, error) { return clientset, nil }
+		status, _, err := checker.probe(context.Background(), &models.Cluster{Name: "prod"})
+		s.Require().NoError(err)
+		return status
+	}
+
+	assert.Equal(s.T(), "healthy", probe(fake.NewSimpleClientset(node("a", corev1.ConditionTrue), node("b", corev1.ConditionTrue))))
+	assert.Equal(s.T(), "warning", probe(fake.NewSimpleClientset(node("a", corev1.ConditionTrue), node("b", corev1.ConditionFalse))))
+	assert.Equal(s.T(), "unhealthy", probe(fake.NewSimpleClientset(node("a", corev1.ConditionUnknown))))
+}
+
+// TestNotificationServiceSuite 运行测试套件
+func TestNotificationServiceSuite(t *testing.T) {
+	suite.Run(t, new(NotificationServiceTestSuite))
+}
@@ -6,7 +6,6 @@ import (
 	"sort"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/clay-wangzhi/KubePolaris/internal/models"
@@ -21,86 +20,54 @@ import (
 type OMService struct {
 	prometheusSvc       *PrometheusService
 	monitoringConfigSvc *MonitoringConfigService
+	usageProvider       ResourceMetricsProvider  // 未配置监控时的实时用量来源（metrics-server），可为 nil
+	capacitySvc         *CapacityForecastService // 容量预测，可为 nil
+	certificateSvc      *CertificateService      // 证书清单，可为 nil
+	ruleSvc             *DiagnosisRuleService
+	listers             DiagnosisListerProvider // informer 缓存，可为 nil
 }
 
 // NewOMService 创建运维服务
-func NewOMService(prometheusSvc *PrometheusService, monitoringConfigSvc *MonitoringConfigService) *OMService {
+func NewOMService(prometheusSvc *PrometheusService, monitoringConfigSvc *MonitoringConfigService, usageProvider ResourceMetricsProvider, capacitySvc *CapacityForecastService, certificateSvc *CertificateService, ruleSvc *DiagnosisRuleService, listers DiagnosisListerProvider) *OMService {
 	return &OMService{
 		prometheusSvc:       prometheusSvc,
 		monitoringConfigSvc: monitoringConfigSvc,
+		usageProvider:       usageProvider,
+		capacitySvc:         capacitySvc,
+		certificateSvc:      certificateSvc,
+		ruleSvc:             ruleSvc,
+		listers:             listers,
 	}
 }
 
-// GetHealthDiagnosis 获取集群健康诊断
+// diagnosisEnv 构建一次诊断的数据来源
+func (s *OMService) diagnosisEnv(clientset kubernetes.Interface, clusterID uint) *DiagnosisEnv {
+	env := &DiagnosisEnv{
+		ClusterID:      clusterID,
+		Client:         clientset,
+		Now:            time.Now(),
+		prometheusSvc:  s.prometheusSvc,
+		usageProvider:  s.usageProvider,
+		capacitySvc:    s.capacitySvc,
+		certificateSvc: s.certificateSvc,
+		listers:        s.listers,
+	}
+	if config, err := s.monitoringConfigSvc.GetMonitoringConfig(clusterID); err == nil && config.Type != "disabled" {
+		env.Monitoring = config
+	}
+	return env
+}
+
+// GetHealthDiagnosis 获取集群健康诊断，按集群的规则配置执行内置规则与自定义规则
 func (s *OMService) GetHealthDiagnosis(ctx context.Context, clientset *kubernetes.Clientset, clusterID uint) (*models.HealthDiagnosisResponse, error) {
+	env := s.diagnosisEnv(clientset, clusterID)
+	risks, categoryScores := s.ruleSvc.Run(ctx, env)
 	response := &models.HealthDiagnosisResponse{
-		DiagnosisTime:  time.Now().Unix(),
-		RiskItems:      []models.RiskItem{},
-		Suggestions:    []string{},
-		CategoryScores: make(map[string]int),
+		DiagnosisTime:  env.Now.Unix(),
+		RiskItems:      risks,
+		CategoryScores: categoryScores,
 	}
 
-	var wg sync.WaitGroup
-	var mu sync.Mutex
-
-	// 并发执行各项诊断
-	// 1. 节点健康诊断
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		nodeRisks, nodeScore := s.diagnoseNodes(ctx, clientset)
-		mu.Lock()
-		response.RiskItems = append(response.RiskItems, nodeRisks...)
-		response.CategoryScores["node"] = nodeScore
-		mu.Unlock()
-	}()
-
-	// 2. 工作负载诊断
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		workloadRisks, workloadScore := s.diagnoseWorkloads(ctx, clientset)
-		mu.Lock()
-		response.RiskItems = append(response.RiskItems, workloadRisks...)
-		response.CategoryScores["workload"] = workloadScore
-		mu.Unlock()
-	}()
-
-	// 3. 资源诊断
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		resourceRisks, resourceScore := s.diagnoseResources(ctx, clientset, clusterID)
-		mu.Lock()
-		response.RiskItems = append(response.RiskItems, resourceRisks...)
-		response.CategoryScores["resource"] = resourceScore
-		mu.Unlock()
-	}()
-
-	// 4. 存储诊断
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		storageRisks, storageScore := s.diagnoseStorage(ctx, clientset)
-		mu.Lock()
-		response.RiskItems = append(response.RiskItems, storageRisks...)
-		response.CategoryScores["storage"] = storageScore
-		mu.Unlock()
-	}()
-
-	// 5. 控制面诊断
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		controlPlaneRisks, controlPlaneScore := s.diagnoseControlPlane(ctx, clientset, clusterID)
-		mu.Lock()
-		response.RiskItems = append(response.RiskItems, controlPlaneRisks...)
-		response.CategoryScores["control_plane"] = controlPlaneScore
-		mu.Unlock()
-	}()
-
-	wg.Wait()
-
 	// 计算综合健康评分
 	response.HealthScore = s.calculateOverallScore(response.CategoryScores)
 
@@ -113,554 +80,79 @@ func (s *OMService) GetHealthDiagnosis(ctx context.Context, clientset *kubernete
 	return response, nil
 }
 
-// diagnoseNodes 诊断节点健康状况
-func (s *OMService) diagnoseNodes(ctx context.Context, clientset *kubernetes.Clientset) ([]models.RiskItem, int) {
-	risks := []models.RiskItem{}
-	score := 100
-
-	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+// GetWorkloadAudit 检查集群工作负载配置是否符合最佳实践
+func (s *OMService) GetWorkloadAudit(ctx context.Context, clientset *kubernetes.Clientset, clusterID uint, query *models.WorkloadAuditQuery) (*models.WorkloadAuditReport, error) {
+	env := s.diagnosisEnv(clientset, clusterID)
+	in, err := loadWorkloadAuditInput(ctx, env)
 	if err != nil {
-		logger.Error("获取节点列表失败", "error", err)
-		return risks, 50
-	}
-
-	for _, node := range nodes.Items {
-		// 检查节点状态
-		for _, condition := range node.Status.Conditions {
-			if condition.Type == corev1.NodeReady {
-				if condition.Status != corev1.ConditionTrue {
-					risks = append(risks, models.RiskItem{
-						ID:          fmt.Sprintf("node-not-ready-%s", node.Name),
-						Category:    "node",
-						Severity:    "critical",
-						Title:       "节点未就绪",
-						Description: fmt.Sprintf("节点 %s 处于未就绪状态", node.Name),
-						Resource:    node.Name,
-						Solution:    "检查节点 kubelet 服务状态，查看节点系统资源使用情况",
-					})
-					score -= 20
-				}
-			}
-			if condition.Type == corev1.NodeMemoryPressure && condition.Status == corev1.ConditionTrue {
-				risks = append(risks, models.RiskItem{
-					ID:          fmt.Sprintf("node-memory-pressure-%s", node.Name),
-					Category:    "node",
-					Severity:    "warning",
-					Title:       "节点内存压力",
-					Description: fmt.Sprintf("节点 %s 存在内存压力", node.Name),
-					Resource:    node.Name,
-					Solution:    "考虑扩容节点内存或迁移部分工作负载",
-				})
-				score -= 10
-			}
-			if condition.Type == corev1.NodeDiskPressure && condition.Status == corev1.ConditionTrue {
-				risks = append(risks, models.RiskItem{
-					ID:          fmt.Sprintf("node-disk-pressure-%s", node.Name),
-					Category:    "node",
-					Severity:    "warning",
-					Title:       "节点磁盘压力",
-					Description: fmt.Sprintf("节点 %s 存在磁盘压力", node.Name),
-					Resource:    node.Name,
-					Solution:    "清理不需要的镜像和日志，或扩展磁盘容量",
-				})
-				score -= 10
-			}
-			if condition.Type == corev1.NodePIDPressure && condition.Status == corev1.ConditionTrue {
-				risks = append(risks, models.RiskItem{
-					ID:          fmt.Sprintf("node-pid-pressure-%s", node.Name),
-					Category:    "node",
-					Severity:    "warning",
-					Title:       "节点PID压力",
-					Description: fmt.Sprintf("节点 %s 存在PID压力", node.Name),
-					Resource:    node.Name,
-					Solution:    "检查是否有异常进程，考虑调整 max-pods 参数",
-				})
-				score -= 10
-			}
-		}
-
-		// 检查节点是否被标记为不可调度
-		if node.Spec.Unschedulable {
-			risks = append(risks, models.RiskItem{
-				ID:          fmt.Sprintf("node-unschedulable-%s", node.Name),
-				Category:    "node",
-				Severity:    "info",
-				Title:       "节点不可调度",
-				Description: fmt.Sprintf("节点 %s 已被标记为不可调度", node.Name),
-				Resource:    node.Name,
-				Solution:    "如果节点维护已完成，请执行 uncordon 操作",
-			})
-			score -= 5
-		}
-	}
-
-	if score < 0 {
-		score = 0
+		return nil, err
 	}
-	return risks, score
+	return auditWorkloads(in, query, env.Now), nil
 }
 
-// diagnoseWorkloads 诊断工作负载状态
-func (s *OMService) diagnoseWorkloads(ctx context.Context, clientset *kubernetes.Clientset) ([]models.RiskItem, int) {
-	risks := []models.RiskItem{}
-	score := 100
-
-	// 检查 Deployment
-	deployments, err := clientset.AppsV1().Deployments("").List(ctx, metav1.ListOptions{})
-	if err != nil {
-		logger.Error("获取 Deployment 列表失败", "error", err)
-	} else {
-		for _, dep := range deployments.Items {
-			if dep.Status.Replicas != dep.Status.ReadyReplicas {
-				severity := "warning"
-				if dep.Status.ReadyReplicas == 0 && *dep.Spec.Replicas > 0 {
-					severity = "critical"
-					score -= 15
-				} else {
-					score -= 5
-				}
-				risks = append(risks, models.RiskItem{
-					ID:          fmt.Sprintf("deployment-not-ready-%s-%s", dep.Namespace, dep.Name),
-					Category:    "workload",
-					Severity:    severity,
-					Title:       "Deployment 副本未就绪",
-					Description: fmt.Sprintf("Deployment %s/%s: %d/%d 副本就绪", dep.Namespace, dep.Name, dep.Status.ReadyReplicas, dep.Status.Replicas),
-					Resource:    dep.Name,
-					Namespace:   dep.Namespace,
-					Solution:    "检查 Pod 事件和日志，确认容器启动失败原因",
-				})
-			}
-		}
-	}
-
-	// 检查 StatefulSet
-	statefulSets, err := clientset.AppsV1().StatefulSets("").List(ctx, metav1.ListOptions{})
-	if err != nil {
-		logger.Error("获取 StatefulSet 列表失败", "error", err)
-	} else {
-		for _, sts := range statefulSets.Items {
-			if sts.Status.Replicas != sts.Status.ReadyReplicas {
-				severity := "warning"
-				if sts.Status.ReadyReplicas == 0 && *sts.Spec.Replicas > 0 {
-					severity = "critical"
-					score -= 15
-				} else {
-					score -= 5
-				}
-				risks = append(risks, models.RiskItem{
-					ID:          fmt.Sprintf("statefulset-not-ready-%s-%s", sts.Namespace, sts.Name),
-					Category:    "workload",
-					Severity:    severity,
-					Title:       "StatefulSet 副本未就绪",
-					Description: fmt.Sprintf("StatefulSet %s/%s: %d/%d 副本就绪", sts.Namespace, sts.Name, sts.Status.ReadyReplicas, sts.Status.Replicas),
-					Resource:    sts.Name,
-					Namespace:   sts.Namespace,
-					Solution:    "检查 Pod 事件和日志，确认容器启动失败原因",
-				})
-			}
-		}
-	}
-
-	// 检查 DaemonSet
-	daemonSets, err := clientset.AppsV1().DaemonSets("").List(ctx, metav1.ListOptions{})
-	if err != nil {
-		logger.Error("获取 DaemonSet 列表失败", "error", err)
-	} else {
-		for _, ds := range daemonSets.Items {
-			if ds.Status.NumberUnavailable > 0 {
-				risks = append(risks, models.RiskItem{
-					ID:          fmt.Sprintf("daemonset-unavailable-%s-%s", ds.Namespace, ds.Name),
-					Category:    "workload",
-					Severity:    "warning",
-					Title:       "DaemonSet 存在不可用副本",
-					Description: fmt.Sprintf("DaemonSet %s/%s: %d 个节点上的副本不可用", ds.Namespace, ds.Name, ds.Status.NumberUnavailable),
-					Resource:    ds.Name,
-					Namespace:   ds.Namespace,
-					Solution:    "检查相关节点和 Pod 状态",
-				})
-				score -= 5
-			}
-		}
-	}
-
-	// 检查异常 Pod
-	pods, err := clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
-	if err != nil {
-		logger.Error("获取 Pod 列表失败", "error", err)
-	} else {
-		crashLoopCount := 0
-		pendingCount := 0
-		for _, pod := range pods.Items {
-			// 检查 CrashLoopBackOff
-			for _, containerStatus := range pod.Status.ContainerStatuses {
-				if containerStatus.RestartCount > 5 {
-					if containerStatus.State.Waiting != nil && containerStatus.State.Waiting.Reason == "CrashLoopBackOff" {
-						crashLoopCount++
-						if crashLoopCount <= 5 { // 只报告前5个
-							risks = append(risks, models.RiskItem{
-								ID:          fmt.Sprintf("pod-crashloop-%s-%s", pod.Namespace, pod.Name),
-								Category:    "workload",
-								Severity:    "critical",
-								Title:       "Pod 持续崩溃重启",
-								Description: fmt.Sprintf("Pod %s/%s 容器 %s 已重启 %d 次", pod.Namespace, pod.Name, containerStatus.Name, containerStatus.RestartCount),
-								Resource:    pod.Name,
-								Namespace:   pod.Namespace,
-								Solution:    "检查容器日志，排查应用启动失败原因",
-							})
-						}
-					}
-				}
-			}
-
-			// 检查长时间 Pending
-			if pod.Status.Phase == corev1.PodPending {
-				pendingDuration := time.Since(pod.CreationTimestamp.Time)
-				if pendingDuration > 5*time.Minute {
-					pendingCount++
-					if pendingCount <= 5 { // 只报告前5个
-						risks = append(risks, models.RiskItem{
-							ID:          fmt.Sprintf("pod-pending-%s-%s", pod.Namespace, pod.Name),
-							Category:    "workload",
-							Severity:    "warning",
-							Title:       "Pod 长时间处于 Pending 状态",
-							Description: fmt.Sprintf("Pod %s/%s 已 Pending %.0f 分钟", pod.Namespace, pod.Name, pendingDuration.Minutes()),
-							Resource:    pod.Name,
-							Namespace:   pod.Namespace,
-							Solution:    "检查是否资源不足或调度约束过严",
-						})
-					}
-				}
-			}
-		}
-		if crashLoopCount > 0 {
-			score -= min(crashLoopCount*5, 30)
-		}
-		if pendingCount > 0 {
-			score -= min(pendingCount*3, 15)
-		}
-	}
-
-	if score < 0 {
-		score = 0
-	}
-	return risks, score
+// TestCustomDiagnosisRule 在集群上试运行自定义诊断规则
+func (s *OMService) TestCustomDiagnosisRule(ctx context.Context, clientset *kubernetes.Clientset, clusterID uint, req *models.CustomDiagnosisRuleRequest) (*models.CustomDiagnosisRuleTestResponse, error) {
+	return s.ruleSvc.TestCustom(ctx, s.diagnosisEnv(clientset, clusterID), req)
 }
 
-// diagnoseResources 诊断资源使用情况
-func (s *OMService) diagnoseResources(ctx context.Context, clientset *kubernetes.Clientset, clusterID uint) ([]models.RiskItem, int) {
-	risks := []models.RiskItem{}
-	score := 100
-
-	// 获取监控配置
-	config, err := s.monitoringConfigSvc.GetMonitoringConfig(clusterID)
-	if err != nil || config.Type == "disabled" {
-		// 如果没有配置监控，通过 K8s API 获取基本信息
-		return s.diagnoseResourcesFromK8s(ctx, clientset)
-	}
-
-	now := time.Now().Unix()
-
-	// 查询集群 CPU 使用率
-	cpuQuery := "(1 - avg(rate(node_cpu_seconds_total{mode=\"idle\"}[5m]))) * 100"
-	if cpuResp, err := s.prometheusSvc.QueryPrometheus(ctx, config, &models.MetricsQuery{
-		Query: cpuQuery,
-		Start: now,
-		End:   now,
-		Step:  "1m",
-	}); err == nil && len(cpuResp.Data.Result) > 0 && len(cpuResp.Data.Result[0].Values) > 0 {
-		if val, err := strconv.ParseFloat(fmt.Sprintf("%v", cpuResp.Data.Result[0].Values[0][1]), 64); err == nil {
-			if val > 90 {
-				risks = append(risks, models.RiskItem{
-					ID:          "cluster-cpu-critical",
-					Category:    "resource",
-					Severity:    "critical",
-					Title:       "集群 CPU 使用率过高",
-					Description: fmt.Sprintf("集群 CPU 使用率达到 %.1f%%", val),
-					Solution:    "考虑扩展节点或优化工作负载",
-				})
-				score -= 25
-			} else if val > 80 {
-				risks = append(risks, models.RiskItem{
-					ID:          "cluster-cpu-warning",
-					Category:    "resource",
-					Severity:    "warning",
-					Title:       "集群 CPU 使用率较高",
-					Description: fmt.Sprintf("集群 CPU 使用率达到 %.1f%%", val),
-					Solution:    "关注 CPU 使用趋势，准备扩容计划",
-				})
-				score -= 10
-			}
-		}
+// clusterUsageFromMetricsServer 以 metrics-server 节点用量之和除以可分配资源之和计算集群 CPU / 内存使用率
+func clusterUsageFromMetricsServer(ctx context.Context, env *DiagnosisEnv) (cpuRate, memRate float64, ok bool) {
+	if env.usageProvider == nil {
+		return 0, 0, false
 	}
-
-	// 查询集群内存使用率
-	memQuery := "(1 - sum(node_memory_MemAvailable_bytes) / sum(node_memory_MemTotal_bytes)) * 100"
-	if memResp, err := s.prometheusSvc.QueryPrometheus(ctx, config, &models.MetricsQuery{
-		Query: memQuery,
-		Start: now,
-		End:   now,
-		Step:  "1m",
-	}); err == nil && len(memResp.Data.Result) > 0 && len(memResp.Data.Result[0].Values) > 0 {
-		if val, err := strconv.ParseFloat(fmt.Sprintf("%v", memResp.Data.Result[0].Values[0][1]), 64); err == nil {
-			if val > 90 {
-				risks = append(risks, models.RiskItem{
-					ID:          "cluster-memory-critical",
-					Category:    "resource",
-					Severity:    "critical",
-					Title:       "集群内存使用率过高",
-					Description: fmt.Sprintf("集群内存使用率达到 %.1f%%", val),
-					Solution:    "考虑扩展节点内存或优化内存使用",
-				})
-				score -= 25
-			} else if val > 80 {
-				risks = append(risks, models.RiskItem{
-					ID:          "cluster-memory-warning",
-					Category:    "resource",
-					Severity:    "warning",
-					Title:       "集群内存使用率较高",
-					Description: fmt.Sprintf("集群内存使用率达到 %.1f%%", val),
-					Solution:    "关注内存使用趋势，准备扩容计划",
-				})
-				score -= 10
-			}
-		}
-	}
-
-	if score < 0 {
-		score = 0
+	usage, err := env.usageProvider.NodeUsage(ctx, env.ClusterID)
+	if err != nil || len(usage) == 0 {
+		return 0, 0, false
 	}
-	return risks, score
-}
-
-// diagnoseResourcesFromK8s 从 K8s API 诊断资源（无监控数据时）
-func (s *OMService) diagnoseResourcesFromK8s(ctx context.Context, clientset *kubernetes.Clientset) ([]models.RiskItem, int) {
-	risks := []models.RiskItem{}
-	score := 100
-
-	// 检查资源配额
-	quotas, err := clientset.CoreV1().ResourceQuotas("").List(ctx, metav1.ListOptions{})
-	if err == nil {
-		for _, quota := range quotas.Items {
-			for resource, used := range quota.Status.Used {
-				if hard, ok := quota.Status.Hard[resource]; ok {
-					usedVal := used.Value()
-					hardVal := hard.Value()
-					if hardVal > 0 {
-						usageRate := float64(usedVal) / float64(hardVal) * 100
-						if usageRate > 90 {
-							risks = append(risks, models.RiskItem{
-								ID:          fmt.Sprintf("quota-exceeded-%s-%s-%s", quota.Namespace, quota.Name, resource),
-								Category:    "resource",
-								Severity:    "warning",
-								Title:       "资源配额使用率过高",
-								Description: fmt.Sprintf("命名空间 %s 资源 %s 使用率达到 %.1f%%", quota.Namespace, resource, usageRate),
-								Namespace:   quota.Namespace,
-								Resource:    quota.Name,
-								Solution:    "考虑提高资源配额或优化资源使用",
-							})
-							score -= 10
-						}
-					}
-				}
-			}
-		}
-	}
-
-	if score < 0 {
-		score = 0
-	}
-	return risks, score
-}
-
-// diagnoseStorage 诊断存储状态
-func (s *OMService) diagnoseStorage(ctx context.Context, clientset *kubernetes.Clientset) ([]models.RiskItem, int) {
-	risks := []models.RiskItem{}
-	score := 100
-
-	// 检查 PVC 状态
-	pvcs, err := clientset.CoreV1().PersistentVolumeClaims("").List(ctx, metav1.ListOptions{})
-	if err != nil {
-		logger.Error("获取 PVC 列表失败", "error", err)
-		return risks, score
-	}
-
-	pendingPVCCount := 0
-	for _, pvc := range pvcs.Items {
-		if pvc.Status.Phase == corev1.ClaimPending {
-			pendingPVCCount++
-			if pendingPVCCount <= 5 {
-				risks = append(risks, models.RiskItem{
-					ID:          fmt.Sprintf("pvc-pending-%s-%s", pvc.Namespace, pvc.Name),
-					Category:    "storage",
-					Severity:    "warning",
-					Title:       "PVC 处于 Pending 状态",
-					Description: fmt.Sprintf("PVC %s/%s 无法绑定到 PV", pvc.Namespace, pvc.Name),
-					Resource:    pvc.Name,
-					Namespace:   pvc.Namespace,
-					Solution:    "检查是否有可用的 StorageClass 和足够的存储资源",
-				})
-			}
-		}
-		if pvc.Status.Phase == corev1.ClaimLost {
-			risks = append(risks, models.RiskItem{
-				ID:          fmt.Sprintf("pvc-lost-%s-%s", pvc.Namespace, pvc.Name),
-				Category:    "storage",
-				Severity:    "critical",
-				Title:       "PVC 丢失绑定",
-				Description: fmt.Sprintf("PVC %s/%s 已丢失与 PV 的绑定", pvc.Namespace, pvc.Name),
-				Resource:    pvc.Name,
-				Namespace:   pvc.Namespace,
-				Solution:    "检查关联的 PV 状态，可能需要恢复数据",
-			})
-			score -= 15
-		}
-	}
-
-	if pendingPVCCount > 0 {
-		score -= min(pendingPVCCount*5, 20)
-	}
-
-	// 检查 PV 状态
-	pvs, err := clientset.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+	nodes, err := env.Nodes(ctx)
 	if err != nil {
-		logger.Error("获取 PV 列表失败", "error", err)
-		return risks, score
-	}
-
-	for _, pv := range pvs.Items {
-		if pv.Status.Phase == corev1.VolumeFailed {
-			risks = append(risks, models.RiskItem{
-				ID:          fmt.Sprintf("pv-failed-%s", pv.Name),
-				Category:    "storage",
-				Severity:    "critical",
-				Title:       "PV 状态异常",
-				Description: fmt.Sprintf("PV %s 处于 Failed 状态", pv.Name),
-				Resource:    pv.Name,
-				Solution:    "检查存储后端状态和网络连接",
-			})
-			score -= 15
-		}
-	}
-
-	if score < 0 {
-		score = 0
-	}
-	return risks, score
-}
-
-// diagnoseControlPlane 诊断控制面组件
-func (s *OMService) diagnoseControlPlane(ctx context.Context, clientset *kubernetes.Clientset, clusterID uint) ([]models.RiskItem, int) {
-	risks := []models.RiskItem{}
-	score := 100
-
-	// 检查 kube-system 命名空间下的核心组件
-	pods, err := clientset.CoreV1().Pods("kube-system").List(ctx, metav1.ListOptions{})
-	if err != nil {
-		logger.Error("获取 kube-system Pod 列表失败", "error", err)
-		return risks, 50
-	}
-
-	components := map[string]bool{
-		"kube-apiserver":          false,
-		"kube-controller-manager": false,
-		"kube-scheduler":          false,
-		"etcd":                    false,
-	}
-
-	for _, pod := range pods.Items {
-		for component := range components {
-			if strings.Contains(pod.Name, component) {
-				if pod.Status.Phase == corev1.PodRunning {
-					components[component] = true
-				} else {
-					risks = append(risks, models.RiskItem{
-						ID:          fmt.Sprintf("control-plane-%s-unhealthy", component),
-						Category:    "control_plane",
-						Severity:    "critical",
-						Title:       fmt.Sprintf("控制面组件 %s 不健康", component),
-						Description: fmt.Sprintf("组件 %s (Pod: %s) 状态: %s", component, pod.Name, pod.Status.Phase),
-						Resource:    pod.Name,
-						Namespace:   "kube-system",
-						Solution:    "检查组件日志和配置",
-					})
-					score -= 20
-				}
-				break
-			}
-		}
+		return 0, 0, false
 	}
 
-	// 检查是否缺少核心组件
-	for component, found := range components {
+	var cpuUsed, cpuTotal, memUsed, memTotal float64
+	for _, node := range nodes {
+		u, found := usage[node.Name]
 		if !found {
-			// 可能是托管集群，控制面不可见，不算风险
-			logger.Info("未找到控制面组件", "component", component)
+			continue
 		}
+		cpuUsed += u.CPU
+		memUsed += u.Memory
+		cpuTotal += quantityValue(node.Status.Allocatable[corev1.ResourceCPU], corev1.ResourceCPU)
+		memTotal += quantityValue(node.Status.Allocatable[corev1.ResourceMemory], corev1.ResourceMemory)
 	}
-
-	// 通过 Prometheus 检查 etcd 和 apiserver 指标
-	config, err := s.monitoringConfigSvc.GetMonitoringConfig(clusterID)
-	if err == nil && config.Type != "disabled" {
-		now := time.Now().Unix()
-
-		// 检查 etcd leader 状态
-		etcdQuery := "etcd_server_has_leader"
-		if etcdResp, err := s.prometheusSvc.QueryPrometheus(ctx, config, &models.MetricsQuery{
-			Query: etcdQuery,
-			Start: now,
-			End:   now,
-			Step:  "1m",
-		}); err == nil && len(etcdResp.Data.Result) > 0 {
-			hasLeader := false
-			for _, result := range etcdResp.Data.Result {
-				if len(result.Values) > 0 {
-					if val, err := strconv.ParseFloat(fmt.Sprintf("%v", result.Values[0][1]), 64); err == nil && val == 1 {
-						hasLeader = true
-						break
-					}
-				}
-			}
-			if !hasLeader {
-				risks = append(risks, models.RiskItem{
-					ID:          "etcd-no-leader",
-					Category:    "control_plane",
-					Severity:    "critical",
-					Title:       "Etcd 无 Leader",
-					Description: "Etcd 集群当前没有 Leader，集群可能无法正常工作",
-					Solution:    "检查 etcd 集群健康状态和网络连接",
-				})
-				score -= 30
-			}
-		}
-
-		// 检查 apiserver 错误率
-		apiErrorQuery := "sum(rate(apiserver_request_total{code=~\"5..\"}[5m])) / sum(rate(apiserver_request_total[5m])) * 100"
-		if apiResp, err := s.prometheusSvc.QueryPrometheus(ctx, config, &models.MetricsQuery{
-			Query: apiErrorQuery,
-			Start: now,
-			End:   now,
-			Step:  "1m",
-		}); err == nil && len(apiResp.Data.Result) > 0 && len(apiResp.Data.Result[0].Values) > 0 {
-			if val, err := strconv.ParseFloat(fmt.Sprintf("%v", apiResp.Data.Result[0].Values[0][1]), 64); err == nil {
-				if val > 5 {
-					risks = append(risks, models.RiskItem{
-						ID:          "apiserver-high-error-rate",
-						Category:    "control_plane",
-						Severity:    "warning",
-						Title:       "API Server 错误率较高",
-						Description: fmt.Sprintf("API Server 5xx 错误率达到 %.1f%%", val),
-						Solution:    "检查 apiserver 日志和后端 etcd 状态",
-					})
-					score -= 15
-				}
-			}
-		}
+	if cpuTotal <= 0 || memTotal <= 0 {
+		return 0, 0, false
 	}
+	return percentOf(cpuUsed, cpuTotal), percentOf(memUsed, memTotal), true
+}
 
-	if score < 0 {
-		score = 0
-	}
-	return risks, score
+// clusterUsageRisk 按集群 CPU / 内存使用率与警告、严重阈值生成风险项，返回扣分；未达到阈值时返回 nil
+func clusterUsageRisk(resource string, val, warningThreshold, criticalThreshold float64) (*models.RiskItem, int) {
+	name, critical, warning := " CPU ", "考虑扩展节点或优化工作负载", "关注 CPU 使用趋势，准备扩容计划"
+	if resource == "memory" {
+		name, critical, warning = "内存", "考虑扩展节点内存或优化内存使用", "关注内存使用趋势，准备扩容计划"
+	}
+	switch {
+	case val > criticalThreshold:
+		return &models.RiskItem{
+			ID:          fmt.Sprintf("cluster-%s-critical", resource),
+			Category:    "resource",
+			Severity:    "critical",
+			Title:       fmt.Sprintf("集群%s使用率过高", name),
+			Description: fmt.Sprintf("集群%s使用率达到 %.1f%%", name, val),
+			Solution:    critical,
+		}, 25
+	case val > warningThreshold:
+		return &models.RiskItem{
+			ID:          fmt.Sprintf("cluster-%s-warning", resource),
+			Category:    "resource",
+			Severity:    "warning",
+			Title:       fmt.Sprintf("集群%s使用率较高", name),
+			Description: fmt.Sprintf("集群%s使用率达到 %.1f%%", name, val),
+			Solution:    warning,
+		}, 10
+	}
+	return nil, 0
 }
 
 // calculateOverallScore 计算综合健康评分
@@ -676,6 +168,9 @@ func (s *OMService) calculateOverallScore(categoryScores map[string]int) int {
 		"resource":      0.20,
 		"storage":       0.15,
 		"control_plane": 0.20,
+		"capacity":      0.10,
+		"best_practice": 0.10,
+		"certificate":   0.10,
 	}
 
 	var totalWeight float64
@@ -739,6 +234,15 @@ func (s *OMService) generateSuggestions(risks []models.RiskItem) []string {
 	if categoryCount["control_plane"] > 0 {
 		suggestions = append(suggestions, "建议检查控制面组件健康状态，确保集群核心功能正常")
 	}
+	if categoryCount["capacity"] > 0 {
+		suggestions = append(suggestions, "部分资源预计将在预测范围内达到容量阈值，建议提前规划扩容")
+	}
+	if categoryCount["certificate"] > 0 {
+		suggestions = append(suggestions, "部分证书即将过期或已过期，建议尽快续期，避免集群访问或业务 HTTPS 中断")
+	}
+	if categoryCount["best_practice"] > 0 {
+		suggestions = append(suggestions, "部分工作负载配置不符合最佳实践，建议参考工作负载检查报告补充探针、资源限制与安全配置")
+	}
 
 	if len(suggestions) == 0 {
 		suggestions = append(suggestions, "集群整体运行健康，建议定期进行健康检查以预防问题")
@@ -765,7 +269,7 @@ func (s *OMService) GetResourceTop(ctx context.Context, clientset *kubernetes.Cl
 	config, err := s.monitoringConfigSvc.GetMonitoringConfig(clusterID)
 	if err != nil || config.Type == "disabled" {
 		// 没有监控数据，从 K8s 获取基本信息
-		return s.getResourceTopFromK8s(ctx, clientset, req, limit)
+		return s.getResourceTopFromK8s(ctx, clientset, clusterID, req, limit)
 	}
 
 	now := time.Now().Unix()
@@ -893,8 +397,8 @@ func (s *OMService) GetResourceTop(ctx context.Context, clientset *kubernetes.Cl
 	return response, nil
 }
 
-// getResourceTopFromK8s 从 K8s 获取资源 Top N（无监控数据时）
-func (s *OMService) getResourceTopFromK8s(ctx context.Context, clientset *kubernetes.Clientset, req *models.ResourceTopRequest, limit int) (*models.ResourceTopResponse, error) {
+// getResourceTopFromK8s 从 K8s 获取资源 Top N（无监控数据时）：metrics-server 可用时按实际用量排序，否则按 request 排序
+func (s *OMService) getResourceTopFromK8s(ctx context.Context, clientset *kubernetes.Clientset, clusterID uint, req *models.ResourceTopRequest, limit int) (*models.ResourceTopResponse, error) {
 	response := &models.ResourceTopResponse{
 		Type:      req.Type,
 		Level:     req.Level,
@@ -911,11 +415,13 @@ func (s *OMService) getResourceTopFromK8s(ctx context.Context, clientset *kubern
 	type usageData struct {
 		name      string
 		namespace string
-		request   int64
-		limit     int64
+		request   float64
+		limit     float64
+		usage     float64
 	}
 
 	var items []usageData
+	measured := false // 是否为 metrics-server 实际用量
 
 	switch req.Type {
 	case "cpu", "memory":
@@ -924,53 +430,67 @@ func (s *OMService) getResourceTopFromK8s(ctx context.Context, clientset *kubern
 			resourceName = corev1.ResourceMemory
 		}
 
+		// 实际用量（key 为 namespace/name），metrics-server 不可用时为 nil
+		var podUsage map[string]models.ResourceUsage
+		if s.usageProvider != nil {
+			if podUsage, err = s.usageProvider.PodUsage(ctx, clusterID, ""); err != nil {
+				logger.Warn("从 metrics-server 获取 Pod 用量失败，按 request 排序", "error", err)
+				podUsage = nil
+			}
+		}
+		measured = podUsage != nil
+		usageOf := func(pod *corev1.Pod, request float64) float64 {
+			if podUsage == nil {
+				return request // 无实际用量时用 request 代替
+			}
+			u := podUsage[pod.Namespace+"/"+pod.Name]
+			if req.Type == "memory" {
+				return u.Memory
+			}
+			return u.CPU
+		}
+
 		switch req.Level {
 		case "namespace":
 			nsUsage := make(map[string]*usageData)
-			for _, pod := range pods.Items {
+			for i := range pods.Items {
+				pod := &pods.Items[i]
 				if _, ok := nsUsage[pod.Namespace]; !ok {
 					nsUsage[pod.Namespace] = &usageData{
 						name:      pod.Namespace,
 						namespace: pod.Namespace,
 					}
 				}
-				for _, container := range pod.Spec.Containers {
-					if req := container.Resources.Requests[resourceName]; !req.IsZero() {
-						nsUsage[pod.Namespace].request += req.Value()
-					}
-					if lim := container.Resources.Limits[resourceName]; !lim.IsZero() {
-						nsUsage[pod.Namespace].limit += lim.Value()
-					}
-				}
+				request, limit := PodResourceTotals(pod, resourceName)
+				nsUsage[pod.Namespace].request += request
+				nsUsage[pod.Namespace].limit += limit
+				nsUsage[pod.Namespace].usage += usageOf(pod, request)
 			}
 			for _, v := range nsUsage {
 				items = append(items, *v)
 			}
 
 		case "pod":
-			for _, pod := range pods.Items {
+			for i := range pods.Items {
+				pod := &pods.Items[i]
+				request, limit := PodResourceTotals(pod, resourceName)
 				item := usageData{
 					name:      pod.Name,
 					namespace: pod.Namespace,
+					request:   request,
+					limit:     limit,
+					usage:     usageOf(pod, request),
 				}
-				for _, container := range pod.Spec.Containers {
-					if req := container.Resources.Requests[resourceName]; !req.IsZero() {
-						item.request += req.Value()
-					}
-					if lim := container.Resources.Limits[resourceName]; !lim.IsZero() {
-						item.limit += lim.Value()
-					}
-				}
-				if item.request > 0 || item.limit > 0 {
+				if item.request > 0 || item.limit > 0 || item.usage > 0 {
 					items = append(items, item)
 				}
 			}
 		}
 	}
 
-	// 按 request 值排序
+	// 按用量排序
 	sort.Slice(items, func(i, j int) bool {
-		return items[i].request > items[j].request
+		return items[i].usage > items[j].usage
 	})
 
 	// 取 Top N
@@ -985,11 +505,14 @@ func (s *OMService) getResourceTopFromK8s(ctx context.Context, clientset *kubern
 			Rank:      i + 1,
 			Name:      item.name,
 			Namespace: item.namespace,
-			Request:   float64(item.request),
-			Limit:     float64(item.limit),
-			Usage:     float64(item.request), // 无监控数据时用 request 代替
+			Request:   item.request,
+			Limit:     item.limit,
+			Usage:     item.usage,
 			Unit:      unit,
 		}
+		if measured {
+			topItem.UsageRate = percentOf(item.usage, item.limit)
+		}
 		response.Items = append(response.Items, topItem)
 	}
 
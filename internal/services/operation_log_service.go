@@ -3,6 +3,7 @@ package services
 import (
 	"encoding/json"
 	"reflect"
+	"regexp"
 	"strings"
 	"time"
 
@@ -60,7 +61,7 @@ func (s *OperationLogService) Record(entry *LogEntry) error {
 		Namespace:    entry.Namespace,
 		ResourceType: entry.ResourceType,
 		ResourceName: entry.ResourceName,
-		RequestBody:  sanitizeAndMarshal(entry.RequestBody),
+		RequestBody:  sanitizeAndMarshal(sanitizeSecretBody(entry.ResourceType, entry.RequestBody)),
 		StatusCode:   entry.StatusCode,
 		Success:      entry.Success,
 		ErrorMessage: entry.ErrorMessage,
@@ -104,6 +105,82 @@ var sensitiveKeys = map[string]bool{
 	"salt":          true,
 }
 
+// redactedValue 脱敏占位符
+const redactedValue = "***REDACTED***"
+
+// secretKindPattern 匹配 YAML 中的 kind: Secret
+var secretKindPattern = regexp.MustCompile(`(?m)^\s*kind:\s*["']?Secret["']?\s*$`)
+
+// sanitizeSecretBody Secret 表单接口的请求体直接携带 data，按资源类型整体脱敏
+func sanitizeSecretBody(resourceType string, body interface{}) interface{} {
+	if resourceType != "secret" {
+		return body
+	}
+	if m, ok := body.(map[string]interface{}); ok {
+		return redactSecretFields(m)
+	}
+	return body
+}
+
+// redactSecretFields 脱敏 Secret 对象中的 data/stringData
+func redactSecretFields(m map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if k == "data" || k == "stringData" {
+			result[k] = redactedValue
+			continue
+		}
+		result[k] = v
+	}
+	return result
+}
+
+// maskSecretYAML 脱敏 YAML 文本中 Secret 的 data/stringData 块
+// 按缩进识别块范围，保留键名，值（含多行块值）替换为占位符
+func maskSecretYAML(text string) string {
+	if !secretKindPattern.MatchString(text) {
+		return text
+	}
+
+	lines := strings.Split(text, "\n")
+	blockIndent, keyIndent := -1, -1
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+
+		if blockIndent >= 0 {
+			if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+				continue
+			}
+			if indent > blockIndent {
+				if keyIndent < 0 {
+					keyIndent = indent
+				}
+				if idx := strings.Index(line, ":"); indent == keyIndent && idx >= 0 {
+					lines[i] = line[:idx+1] + " " + redactedValue
+				} else {
+					lines[i] = line[:indent] + redactedValue
+				}
+				continue
+			}
+			blockIndent, keyIndent = -1, -1
+		}
+
+		for _, field := range []string{"data:", "stringData:"} {
+			if !strings.HasPrefix(trimmed, field) {
+				continue
+			}
+			if rest := strings.TrimSpace(strings.TrimPrefix(trimmed, field)); rest == "" {
+				blockIndent = indent
+			} else {
+				// 行内写法，如 data: {password: xxx}
+				lines[i] = line[:indent] + field + " " + redactedValue
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
 // sanitizeAndMarshal 脱敏并序列化请求体
 func sanitizeAndMarshal(body interface{}) string {
 	if body == nil {
@@ -119,8 +196,8 @@ func sanitizeAndMarshal(body interface{}) string {
 		if err := json.Unmarshal([]byte(str), &data); err == nil {
 			body = data
 		} else {
-			// 不是有效JSON，直接返回
-			return str
+			// 不是有效JSON（如 YAML 文本），仅做 Secret 脱敏
+			return maskSecretYAML(str)
 		}
 	}
 
@@ -147,7 +224,12 @@ func sanitizeValue(v interface{}) interface{} {
 	}
 
 	switch val := v.(type) {
+	case string:
+		return maskSecretYAML(val)
 	case map[string]interface{}:
+		if kind, _ := val["kind"].(string); kind == "Secret" {
+			val = redactSecretFields(val)
+		}
 		result := make(map[string]interface{})
 		for k, v := range val {
 			if isSensitiveKey(k) {
@@ -575,6 +657,12 @@ func getActionName(action string) string {
 		"cordon":          "禁止调度",
 		"uncordon":        "允许调度",
 		"drain":           "驱逐节点",
+		"bulk_update":     "批量更新",
+		"preview":         "预览",
+		"reveal":          "查看明文",
+		"restore":         "恢复版本",
+		"cancel":          "取消",
+		"confirm":         "确认完成",
 		"sync":            "同步",
 		"test":            "测试",
 		"import":          "导入",
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// OperationLogSanitizeTestSuite 定义操作日志脱敏测试套件
+type OperationLogSanitizeTestSuite struct {
+	suite.Suite
+}
+
+// TestMaskSecretYAML 测试 YAML 中 Secret data 块脱敏
+func (s *OperationLogSanitizeTestSuite) TestMaskSecretYAML() {
+	yaml := `apiVersion: v1
+kind: Secret
+metadata:
+  name: db
+  labels:
+    app: db
+data:
+  username: YWRtaW4=
+  password: cGFzc3dvcmQ=
+stringData:
+  config.yaml: |
+    user: admin
+    pass: hunter2
+type: Opaque
+`
+	masked := maskSecretYAML(yaml)
+
+	assert.NotContains(s.T(), masked, "YWRtaW4=")
+	assert.NotContains(s.T(), masked, "cGFzc3dvcmQ=")
+	assert.NotContains(s.T(), masked, "hunter2")
+	assert.Contains(s.T(), masked, "username: ***REDACTED***")
+	assert.Contains(s.T(), masked, "app: db")
+	assert.Contains(s.T(), masked, "type: Opaque")
+}
+
+// TestMaskSecretYAML_NonSecret 测试非 Secret 资源保持不变
+func (s *OperationLogSanitizeTestSuite) TestMaskSecretYAML_NonSecret() {
+	yaml := "kind: ConfigMap\ndata:\n  foo: bar\n"
+	assert.Equal(s.T(), yaml, maskSecretYAML(yaml))
+}
+
+// TestSanitizeAndMarshal_SecretBodies 测试 apply 请求体与表单请求体脱敏
+func (s *OperationLogSanitizeTestSuite) TestSanitizeAndMarshal_SecretBodies() {
+	applyBody := map[string]interface{}{
+		"yaml":   "kind: Secret\ndata:\n  token: c2VjcmV0\n",
+		"dryRun": false,
+	}
+	assert.NotContains(s.T(), sanitizeAndMarshal(applyBody), "c2VjcmV0")
+
+	jsonManifest := `{"kind":"Secret","data":{"tls.crt":"LS0tLS1CRUdJTg=="}}`
+	assert.NotContains(s.T(), sanitizeAndMarshal(jsonManifest), "LS0tLS1CRUdJTg==")
+
+	formBody := map[string]interface{}{
+		"name": "db",
+		"data": map[string]interface{}{"username": "admin"},
+	}
+	result := sanitizeAndMarshal(sanitizeSecretBody("secret", formBody))
+	assert.NotContains(s.T(), result, "admin")
+	assert.Contains(s.T(), result, `"name":"db"`)
+}
+
+// TestOperationLogSanitizeSuite 运行测试套件
+func TestOperationLogSanitizeSuite(t *testing.T) {
+	suite.Run(t, new(OperationLogSanitizeTestSuite))
+}
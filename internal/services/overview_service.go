@@ -38,6 +38,7 @@ type OverviewService struct {
 	monitoringCfgSvc   *MonitoringConfigService
 	alertManagerCfgSvc *AlertManagerConfigService
 	alertManagerSvc    *AlertManagerService
+	capacitySvc        *CapacityForecastService
 }
 
 // NewOverviewService 创建总览服务
@@ -49,6 +50,7 @@ func NewOverviewService(
 	monitoringCfgSvc *MonitoringConfigService,
 	alertManagerCfgSvc *AlertManagerConfigService,
 	alertManagerSvc *AlertManagerService,
+	capacitySvc *CapacityForecastService,
 ) *OverviewService {
 	return &OverviewService{
 		db:                 db,
@@ -58,6 +60,7 @@ func NewOverviewService(
 		monitoringCfgSvc:   monitoringCfgSvc,
 		alertManagerCfgSvc: alertManagerCfgSvc,
 		alertManagerSvc:    alertManagerSvc,
+		capacitySvc:        capacitySvc,
 	}
 }
 
@@ -134,8 +137,9 @@ type ClusterResourceCount struct {
 
 // TrendResponse 趋势数据响应
 type TrendResponse struct {
-	PodTrends  []ClusterTrendSeries `json:"podTrends"`
-	NodeTrends []ClusterTrendSeries `json:"nodeTrends"`
+	PodTrends         []ClusterTrendSeries      `json:"podTrends"`
+	NodeTrends        []ClusterTrendSeries      `json:"nodeTrends"`
+	CapacityForecasts []ClusterCapacityForecast `json:"capacityForecasts"`
 }
 
 // ClusterTrendSeries 集群趋势序列
@@ -145,6 +149,13 @@ type ClusterTrendSeries struct {
 	DataPoints  []TrendDataPoint `json:"dataPoints"`
 }
 
+// ClusterCapacityForecast 集群容量预测
+type ClusterCapacityForecast struct {
+	ClusterID   uint                      `json:"clusterId"`
+	ClusterName string                    `json:"clusterName"`
+	Forecasts   []models.CapacityForecast `json:"forecasts"`
+}
+
 // TrendDataPoint 趋势数据点
 type TrendDataPoint struct {
 	Timestamp int64   `json:"timestamp"`
@@ -499,8 +510,8 @@ func (s *OverviewService) GetResourceUsage(ctx context.Context) (*ResourceUsageR
 	return resp, nil
 }
 
-// GetTrends 获取趋势数据（并发查询优化性能）
-func (s *OverviewService) GetTrends(ctx context.Context, timeRange string, step string) (*TrendResponse, error) {
+// GetTrends 获取趋势数据（并发查询优化性能），threshold 为容量预测阈值，不大于 0 时使用默认阈值
+func (s *OverviewService) GetTrends(ctx context.Context, timeRange string, step string, threshold float64) (*TrendResponse, error) {
 	clusters, err := s.clusterService.GetAllClusters()
 	if err != nil {
 		return nil, fmt.Errorf("获取集群列表失败: %w", err)
@@ -514,8 +525,9 @@ func (s *OverviewService) GetTrends(ctx context.Context, timeRange string, step
 	}
 
 	resp := &TrendResponse{
-		PodTrends:  make([]ClusterTrendSeries, 0),
-		NodeTrends: make([]ClusterTrendSeries, 0),
+		PodTrends:         make([]ClusterTrendSeries, 0),
+		NodeTrends:        make([]ClusterTrendSeries, 0),
+		CapacityForecasts: make([]ClusterCapacityForecast, 0),
 	}
 
 	// 使用并发查询所有集群
@@ -524,6 +536,7 @@ func (s *OverviewService) GetTrends(ctx context.Context, timeRange string, step
 		ClusterName string
 		PodPoints   []TrendDataPoint
 		NodePoints  []TrendDataPoint
+		Forecasts   []models.CapacityForecast
 	}
 
 	resultCh := make(chan trendResult, len(clusters))
@@ -568,6 +581,15 @@ func (s *OverviewService) GetTrends(ctx context.Context, timeRange string, step
 				result.NodePoints = extractRangeSeriesWithDefault(promResp)
 			}
 
+			// 容量预测
+			if s.capacitySvc != nil {
+				if forecasts, err := s.capacitySvc.Forecast(ctx, c.ID, config, threshold); err == nil {
+					result.Forecasts = forecasts
+				} else {
+					logger.Warn("容量预测失败", "cluster", c.Name, "error", err)
+				}
+			}
+
 			logger.Info("集群趋势查询完成", "cluster", c.Name, "耗时", time.Since(clusterStart).String())
 
 			resultCh <- result
@@ -596,6 +618,13 @@ func (s *OverviewService) GetTrends(ctx context.Context, timeRange string, step
 				DataPoints:  result.NodePoints,
 			})
 		}
+		if len(result.Forecasts) > 0 {
+			resp.CapacityForecasts = append(resp.CapacityForecasts, ClusterCapacityForecast{
+				ClusterID:   result.ClusterID,
+				ClusterName: result.ClusterName,
+				Forecasts:   result.Forecasts,
+			})
+		}
 	}
 
 	return resp, nil
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+)
+
+// invalidPromLabelChars kube-state-metrics 把节点标签转换为 label_<name> 时替换为下划线的字符
+var invalidPromLabelChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// capacityQuery 容量指标查询，byPool 为 true 时结果按节点池标签分组
+type capacityQuery struct {
+	resource string
+	query    string
+	byPool   bool
+}
+
+// capacitySeries 容量指标的历史序列
+type capacitySeries struct {
+	nodePool string
+	resource string
+	points   []models.DataPoint
+}
+
+// queryCapacityHistory 查询 [start, end] 内集群整体与各节点池的容量指标历史（百分比）。
+// 节点池按 kube-state-metrics 的 kube_node_labels 分组，需要在 --metric-labels-allowlist 中放行该节点标签；
+// 节点 exporter 的指标不带 node 标签，使用率只按集群整体统计
+func (s *PrometheusService) queryCapacityHistory(ctx context.Context, config *models.MonitoringConfig, nodePoolLabel string, start, end time.Time, step time.Duration) ([]capacitySeries, error) {
+	sel := s.buildClusterSelector(config.Labels, "")
+	with := func(matchers string) string {
+		if sel == "" {
+			return matchers
+		}
+		if matchers == "" {
+			return sel
+		}
+		return matchers + "," + sel
+	}
+
+	// 集群整体的比值与 ClusterOverview 中的 CPURequestRatio / MemRequestRatio / PodUsageRate 口径一致
+	queries := []capacityQuery{
+		{resource: models.CapacityCPURequest, query: fmt.Sprintf(`sum(namespace_cpu:kube_pod_container_resource_requests:sum{%s}) / sum(kube_node_status_allocatable{%s} unless on(node) kube_node_role) * 100`, sel, with(`resource="cpu"`))},
+		{resource: models.CapacityMemoryRequest, query: fmt.Sprintf(`sum(namespace_memory:kube_pod_container_resource_requests:sum{%s}) / sum(kube_node_status_allocatable{%s} unless on(node) kube_node_role) * 100`, sel, with(`resource="memory"`))},
+		{resource: models.CapacityCPUUsage, query: fmt.Sprintf(`(1 - avg(rate(node_cpu_seconds_total{%s}[5m]))) * 100`, with(`mode="idle"`))},
+		{resource: models.CapacityMemoryUsage, query: fmt.Sprintf(`(1 - sum(node_memory_MemAvailable_bytes{%s}) / sum(node_memory_MemTotal_bytes{%s})) * 100`, sel, sel)},
+		{resource: models.CapacityPods, query: fmt.Sprintf(`count(kube_pod_info{%s}) / sum(kube_node_status_allocatable{%s}) * 100`, sel, with(`resource="pods"`))},
+	}
+
+	poolLabel := ""
+	if nodePoolLabel != "" {
+		poolLabel = "label_" + invalidPromLabelChars.ReplaceAllString(nodePoolLabel, "_")
+		pools := fmt.Sprintf(`max by (node, %s) (kube_node_labels{%s})`, poolLabel, with(poolLabel+`!=""`))
+		byPool := func(numerator, resource string) string {
+			return fmt.Sprintf(`sum by (%s) (%s * on (node) group_left (%s) %s) / sum by (%s) (kube_node_status_allocatable{%s} * on (node) group_left (%s) %s) * 100`,
+				poolLabel, numerator, poolLabel, pools, poolLabel, with(`resource="`+resource+`"`), poolLabel, pools)
+		}
+		// 只统计 Pending / Running 的 Pod 的 request，与 namespace_*:kube_pod_container_resource_requests:sum 记录规则一致
+		requests := func(resource string) string {
+			return fmt.Sprintf(`sum by (node) (kube_pod_container_resource_requests{%s} and on (namespace, pod) (kube_pod_status_phase{%s} == 1))`,
+				with(`resource="`+resource+`"`), with(`phase=~"Pending|Running"`))
+		}
+		queries = append(queries,
+			capacityQuery{resource: models.CapacityCPURequest, query: byPool(requests("cpu"), "cpu"), byPool: true},
+			capacityQuery{resource: models.CapacityMemoryRequest, query: byPool(requests("memory"), "memory"), byPool: true},
+			capacityQuery{resource: models.CapacityPods, query: byPool(fmt.Sprintf(`count by (node) (kube_pod_info{%s})`, with(`node!=""`)), "pods"), byPool: true},
+		)
+	}
+
+	var out []capacitySeries
+	for _, q := range queries {
+		resp, err := s.QueryPrometheus(ctx, config, &models.MetricsQuery{
+			Query: q.query,
+			Start: start.Unix(),
+			End:   end.Unix(),
+			Step:  fmt.Sprintf("%ds", int64(step/time.Second)),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("查询 %s 容量历史失败: %w", q.resource, err)
+		}
+		for _, result := range resp.Data.Result {
+			series := capacitySeries{resource: q.resource, points: metricDataPoints(result.Values)}
+			if q.byPool {
+				series.nodePool = result.Metric[poolLabel]
+				if series.nodePool == "" {
+					continue
+				}
+			}
+			if len(series.points) > 0 {
+				out = append(out, series)
+			}
+		}
+	}
+	return out, nil
+}
+
+// metricDataPoints 解析范围查询的采样点，跳过 NaN / Inf
+func metricDataPoints(values [][]interface{}) []models.DataPoint {
+	points := make([]models.DataPoint, 0, len(values))
+	for _, value := range values {
+		if len(value) < 2 {
+			continue
+		}
+		ts, err := strconv.ParseFloat(fmt.Sprintf("%v", value[0]), 64)
+		if err != nil {
+			continue
+		}
+		v, err := strconv.ParseFloat(fmt.Sprintf("%v", value[1]), 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		points = append(points, models.DataPoint{Timestamp: int64(ts), Value: v})
+	}
+	return points
+}
@@ -0,0 +1,314 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+)
+
+// 查询控制台限制
+const (
+	consoleMaxPoints       = 11000 // 与 Prometheus 单次区间查询的点数上限一致
+	consoleMaxQueryLength  = 10000
+	consoleDefaultLimit    = 100
+	consoleMaxLimit        = 1000
+	consoleLabelLookback   = 6 * time.Hour // 补全时只查询近期出现过的序列，避免扫描全部历史
+	consoleResponseMaxSize = 20 << 20
+)
+
+// ConsoleQueryResult 查询控制台的查询结果，Data 为 Prometheus 返回的 data 字段原文
+type ConsoleQueryResult struct {
+	Query         string          `json:"query"`          // 注入选择器后实际执行的语句
+	ClusterFilter string          `json:"cluster_filter"` // 注入的选择器，为空表示数据源为集群独占且用户不受命名空间限制
+	Data          json.RawMessage `json:"data"`
+	Warnings      []string        `json:"warnings,omitempty"`
+}
+
+// consoleAPIResponse Prometheus HTTP API 通用响应
+type consoleAPIResponse struct {
+	Status    string          `json:"status"`
+	Data      json.RawMessage `json:"data"`
+	ErrorType string          `json:"errorType"`
+	Error     string          `json:"error"`
+	Warnings  []string        `json:"warnings"`
+}
+
+// ConsoleSelector 返回查询控制台注入的标签选择器：监控配置中的集群标签，加上调用方的权限范围（如命名空间限制）
+func (s *PrometheusService) ConsoleSelector(config *models.MonitoringConfig, scope string) string {
+	selector := s.buildClusterSelector(config.Labels, "")
+	switch {
+	case scope == "":
+		return selector
+	case selector == "":
+		return scope
+	default:
+		return selector + "," + scope
+	}
+}
+
+// NamespaceScopeSelector 将命名空间权限列表转换为 namespace 标签的正则匹配器，支持 "app-*" 形式的前缀通配；
+// 列表包含 "*" 时返回空字符串（不限制）
+func NamespaceScopeSelector(namespaces []string) string {
+	patterns := make([]string, 0, len(namespaces))
+	for _, ns := range namespaces {
+		if ns == "*" {
+			return ""
+		}
+		if len(ns) > 1 && strings.HasSuffix(ns, "*") {
+			patterns = append(patterns, regexp.QuoteMeta(strings.TrimSuffix(ns, "*"))+".*")
+			continue
+		}
+		if ns != "" {
+			patterns = append(patterns, regexp.QuoteMeta(ns))
+		}
+	}
+	if len(patterns) == 0 {
+		// 没有任何命名空间权限时匹配不到任何序列
+		return `namespace="__none__"`
+	}
+	return "namespace=~" + strconv.Quote(strings.Join(patterns, "|"))
+}
+
+// ConsoleQuery 查询控制台即时查询，自动注入集群选择器与权限范围；t 为零值时使用当前时间
+func (s *PrometheusService) ConsoleQuery(ctx context.Context, config *models.MonitoringConfig, scope, query string, t time.Time) (*ConsoleQueryResult, error) {
+	selector := s.ConsoleSelector(config, scope)
+	injected, err := prepareConsoleQuery(query, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	params.Set("query", injected)
+	if !t.IsZero() {
+		params.Set("time", strconv.FormatInt(t.Unix(), 10))
+	}
+	resp, err := s.consoleGet(ctx, config, "/api/v1/query", params)
+	if err != nil {
+		return nil, err
+	}
+	return &ConsoleQueryResult{Query: injected, ClusterFilter: selector, Data: resp.Data, Warnings: resp.Warnings}, nil
+}
+
+// ConsoleQueryRange 查询控制台区间查询，自动注入集群选择器与权限范围
+func (s *PrometheusService) ConsoleQueryRange(ctx context.Context, config *models.MonitoringConfig, scope, query string, start, end time.Time, step time.Duration) (*ConsoleQueryResult, error) {
+	if err := validateConsoleRange(start, end, step); err != nil {
+		return nil, err
+	}
+	selector := s.ConsoleSelector(config, scope)
+	injected, err := prepareConsoleQuery(query, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	params.Set("query", injected)
+	params.Set("start", strconv.FormatInt(start.Unix(), 10))
+	params.Set("end", strconv.FormatInt(end.Unix(), 10))
+	params.Set("step", strconv.FormatFloat(step.Seconds(), 'f', -1, 64))
+	resp, err := s.consoleGet(ctx, config, "/api/v1/query_range", params)
+	if err != nil {
+		return nil, err
+	}
+	return &ConsoleQueryResult{Query: injected, ClusterFilter: selector, Data: resp.Data, Warnings: resp.Warnings}, nil
+}
+
+// ConsoleMetricNames 指标名补全，prefix 为空时返回全部（最多 limit 个）
+func (s *PrometheusService) ConsoleMetricNames(ctx context.Context, config *models.MonitoringConfig, scope, prefix string, limit int) ([]string, error) {
+	params := s.consoleMatchParams(config, scope, "")
+	values, err := s.consoleStrings(ctx, config, "/api/v1/label/__name__/values", params)
+	if err != nil {
+		return nil, err
+	}
+	return filterConsoleValues(values, prefix, limit), nil
+}
+
+// ConsoleLabelNames 标签名补全，metric 不为空时只返回该指标上的标签
+func (s *PrometheusService) ConsoleLabelNames(ctx context.Context, config *models.MonitoringConfig, scope, metric, prefix string, limit int) ([]string, error) {
+	if metric != "" && !isValidMetricName(metric) {
+		return nil, fmt.Errorf("无效的指标名: %s", metric)
+	}
+	params := s.consoleMatchParams(config, scope, metric)
+	values, err := s.consoleStrings(ctx, config, "/api/v1/labels", params)
+	if err != nil {
+		return nil, err
+	}
+	return filterConsoleValues(values, prefix, limit), nil
+}
+
+// ConsoleLabelValues 标签值补全，metric 不为空时只返回该指标上的取值
+func (s *PrometheusService) ConsoleLabelValues(ctx context.Context, config *models.MonitoringConfig, scope, label, metric, prefix string, limit int) ([]string, error) {
+	if !isValidLabelName(label) {
+		return nil, fmt.Errorf("无效的标签名: %s", label)
+	}
+	if metric != "" && !isValidMetricName(metric) {
+		return nil, fmt.Errorf("无效的指标名: %s", metric)
+	}
+	params := s.consoleMatchParams(config, scope, metric)
+	values, err := s.consoleStrings(ctx, config, "/api/v1/label/"+url.PathEscape(label)+"/values", params)
+	if err != nil {
+		return nil, err
+	}
+	return filterConsoleValues(values, prefix, limit), nil
+}
+
+// consoleMatchParams 构建补全接口的 match[] 与时间范围参数，使补全结果同样限定在当前集群
+func (s *PrometheusService) consoleMatchParams(config *models.MonitoringConfig, scope, metric string) url.Values {
+	params := url.Values{}
+	now := time.Now()
+	params.Set("start", strconv.FormatInt(now.Add(-consoleLabelLookback).Unix(), 10))
+	params.Set("end", strconv.FormatInt(now.Unix(), 10))
+
+	selector := s.ConsoleSelector(config, scope)
+	switch {
+	case metric != "" && selector != "":
+		params.Set("match[]", metric+"{"+selector+"}")
+	case metric != "":
+		params.Set("match[]", metric)
+	case selector != "":
+		params.Set("match[]", "{"+selector+"}")
+	}
+	return params
+}
+
+func (s *PrometheusService) consoleStrings(ctx context.Context, config *models.MonitoringConfig, path string, params url.Values) ([]string, error) {
+	resp, err := s.consoleGet(ctx, config, path, params)
+	if err != nil {
+		return nil, err
+	}
+	var values []string
+	if err := json.Unmarshal(resp.Data, &values); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+	return values, nil
+}
+
+// consoleGet 调用 Prometheus HTTP API，Prometheus 返回的错误以 *PrometheusAPIError 返回
+func (s *PrometheusService) consoleGet(ctx context.Context, config *models.MonitoringConfig, path string, params url.Values) (*consoleAPIResponse, error) {
+	if config == nil || config.Type == "disabled" || config.Type == "" {
+		return nil, fmt.Errorf("监控功能已禁用")
+	}
+
+	apiURL, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("无效的监控端点: %w", err)
+	}
+	apiURL.Path = strings.TrimSuffix(apiURL.Path, "/") + path
+	apiURL.RawQuery = params.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	if err := s.setAuth(req, config.Auth); err != nil {
+		return nil, fmt.Errorf("设置认证失败: %w", err)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("执行请求失败: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, consoleResponseMaxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+	if len(body) > consoleResponseMaxSize {
+		return nil, fmt.Errorf("查询结果过大，请缩小时间范围或增加过滤条件")
+	}
+
+	var result consoleAPIResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("查询失败: %s, 状态码: %d", truncateAlertText(string(body), 500), resp.StatusCode)
+		}
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+	if result.Status != "success" {
+		return nil, &PrometheusAPIError{ErrorType: result.ErrorType, Message: result.Error}
+	}
+	return &result, nil
+}
+
+// prepareConsoleQuery 校验查询语句并注入集群选择器
+func prepareConsoleQuery(query, selector string) (string, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return "", fmt.Errorf("查询语句不能为空")
+	}
+	if len(query) > consoleMaxQueryLength {
+		return "", fmt.Errorf("查询语句过长（最多 %d 个字符）", consoleMaxQueryLength)
+	}
+	injected, err := InjectPromQLSelector(query, selector)
+	if err != nil {
+		return "", fmt.Errorf("解析查询语句失败: %w", err)
+	}
+	return injected, nil
+}
+
+// validateConsoleRange 校验区间查询的时间范围与步长
+func validateConsoleRange(start, end time.Time, step time.Duration) error {
+	if start.IsZero() || end.IsZero() {
+		return fmt.Errorf("start 与 end 不能为空")
+	}
+	if !end.After(start) {
+		return fmt.Errorf("end 必须晚于 start")
+	}
+	if step <= 0 {
+		return fmt.Errorf("step 必须大于 0")
+	}
+	if points := end.Sub(start) / step; points > consoleMaxPoints {
+		return fmt.Errorf("查询点数过多（%d），请增大 step 或缩小时间范围（最多 %d 个点）", points, consoleMaxPoints)
+	}
+	return nil
+}
+
+// filterConsoleValues 按前缀（不区分大小写）过滤、排序并截断补全结果
+func filterConsoleValues(values []string, prefix string, limit int) []string {
+	if limit <= 0 {
+		limit = consoleDefaultLimit
+	}
+	if limit > consoleMaxLimit {
+		limit = consoleMaxLimit
+	}
+	prefix = strings.ToLower(prefix)
+	result := make([]string, 0, limit)
+	for _, v := range values {
+		if prefix == "" || strings.HasPrefix(strings.ToLower(v), prefix) {
+			result = append(result, v)
+		}
+	}
+	sort.Strings(result)
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+func isValidMetricName(name string) bool {
+	if name == "" || !isPromQLIdentStart(name[0]) {
+		return false
+	}
+	for i := 1; i < len(name); i++ {
+		if !isPromQLIdentChar(name[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isValidLabelName(name string) bool {
+	return isValidMetricName(name) && !strings.Contains(name, ":")
+}
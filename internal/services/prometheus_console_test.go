@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// PrometheusConsoleTestSuite 定义 PromQL 查询控制台测试套件
+type PrometheusConsoleTestSuite struct {
+	suite.Suite
+	svc *PrometheusService
+}
+
+// SetupTest 每个测试前的设置
+func (s *PrometheusConsoleTestSuite) SetupTest() {
+	s.svc = NewPrometheusService()
+}
+
+// TestInjectPromQLSelector 测试集群选择器注入
+func (s *PrometheusConsoleTestSuite) TestInjectPromQLSelector() {
+	sel := `cluster="prod"`
+	cases := []struct {
+		query string
+		want  string
+	}{
+		{`up`, `up{cluster="prod"}`},
+		{`up{job="node"}`, `up{job="node",cluster="prod"}`},
+		{`up{}`, `up{cluster="prod"}`},
+		{`{__name__=~"node_.*"}`, `{__name__=~"node_.*",cluster="prod"}`},
+		{`rate(http_requests_total{code=~"5.."}[5m])`, `rate(http_requests_total{code=~"5..",cluster="prod"}[5m])`},
+		{`sum by (namespace) (rate(container_cpu_usage_seconds_total[5m]))`, `sum by (namespace) (rate(container_cpu_usage_seconds_total{cluster="prod"}[5m]))`},
+		{`sum(rate(x[1m])) without (pod)`, `sum(rate(x{cluster="prod"}[1m])) without (pod)`},
+		{`a / on(instance) group_left(nodename) b`, `a{cluster="prod"} / on(instance) group_left(nodename) b{cluster="prod"}`},
+		{`max_over_time(up[1h:5m] offset 1d)`, `max_over_time(up{cluster="prod"}[1h:5m] offset 1d)`},
+		{`label_replace(up, "dst", "$1", "src", "(.*)")`, `label_replace(up{cluster="prod"}, "dst", "$1", "src", "(.*)")`},
+		{`topk(5, node_load1) > bool 1e3`, `topk(5, node_load1{cluster="prod"}) > bool 1e3`},
+		{`up{job="a}b"} and up`, `up{job="a}b",cluster="prod"} and up{cluster="prod"}`},
+		{`1 + 1`, `1 + 1`},
+		{`up # 注释 foo`, `up{cluster="prod"} # 注释 foo`},
+		// 关键字出现在操作数位置时按指标名处理
+		{`sum`, `sum{cluster="prod"}`},
+		{`by`, `by{cluster="prod"}`},
+		{`offset`, `offset{cluster="prod"}`},
+		{`sum{job="a"} offset 5m`, `sum{job="a",cluster="prod"} offset 5m`},
+		{`rate(by[5m]) and on(job) offset`, `rate(by{cluster="prod"}[5m]) and on(job) offset{cluster="prod"}`},
+		{`topk(3, sum) / without`, `topk(3, sum{cluster="prod"}) / without{cluster="prod"}`},
+		{`a * on(x) group_left b`, `a{cluster="prod"} * on(x) group_left b{cluster="prod"}`},
+		// Inf、NaN 是数字字面量
+		{`up > -Inf or up != nan`, `up{cluster="prod"} > -Inf or up{cluster="prod"} != nan`},
+	}
+	for _, tc := range cases {
+		got, err := InjectPromQLSelector(tc.query, sel)
+		s.Require().NoError(err, tc.query)
+		assert.Equal(s.T(), tc.want, got, tc.query)
+	}
+
+	got, err := InjectPromQLSelector(`up`, "")
+	s.Require().NoError(err)
+	assert.Equal(s.T(), `up`, got)
+
+	for _, bad := range []string{
+		`up{job="a"`, `up{job="a`, `rate(up[5m)`, `up{job="a" or job="b"}`,
+		// 注释会改变括号的匹配位置
+		"up{job=\"a\" # }\n}", "sum by (job # )\n) (up)", "rate(up[5m # ]\n])",
+	} {
+		_, err := InjectPromQLSelector(bad, sel)
+		assert.Error(s.T(), err, bad)
+	}
+}
+
+// TestNamespaceScopeSelector 测试命名空间权限转换为匹配器
+func (s *PrometheusConsoleTestSuite) TestNamespaceScopeSelector() {
+	assert.Equal(s.T(), "", NamespaceScopeSelector([]string{"dev", "*"}))
+	assert.Equal(s.T(), `namespace=~"dev|app-.*"`, NamespaceScopeSelector([]string{"dev", "app-*"}))
+	assert.Equal(s.T(), `namespace=~"a\\.b"`, NamespaceScopeSelector([]string{"a.b"}))
+	assert.Equal(s.T(), `namespace="__none__"`, NamespaceScopeSelector(nil))
+
+	cfg := &models.MonitoringConfig{Labels: map[string]string{"cluster": "prod"}}
+	assert.Equal(s.T(), `cluster="prod",namespace=~"dev"`, s.svc.ConsoleSelector(cfg, `namespace=~"dev"`))
+	assert.Equal(s.T(), `namespace=~"dev"`, s.svc.ConsoleSelector(&models.MonitoringConfig{}, `namespace=~"dev"`))
+}
+
+// TestValidateConsoleRange 测试区间查询校验
+func (s *PrometheusConsoleTestSuite) TestValidateConsoleRange() {
+	end := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	assert.NoError(s.T(), validateConsoleRange(end.Add(-time.Hour), end, 15*time.Second))
+	assert.Error(s.T(), validateConsoleRange(end, end.Add(-time.Hour), 15*time.Second))
+	assert.Error(s.T(), validateConsoleRange(end.Add(-time.Hour), end, 0))
+	assert.Error(s.T(), validateConsoleRange(end.Add(-30*24*time.Hour), end, time.Second), "点数超过上限")
+}
+
+// TestFilterConsoleValues 测试补全结果过滤
+func (s *PrometheusConsoleTestSuite) TestFilterConsoleValues() {
+	values := []string{"node_load1", "up", "Node_memory", "node_cpu"}
+	assert.Equal(s.T(), []string{"Node_memory", "node_cpu", "node_load1"}, filterConsoleValues(values, "node", 0))
+	assert.Equal(s.T(), []string{"Node_memory"}, filterConsoleValues(values, "node", 1))
+}
+
+// TestConsoleQuery 测试查询代理：注入选择器后转发，Prometheus 错误以 PrometheusAPIError 返回
+func (s *PrometheusConsoleTestSuite) TestConsoleQuery() {
+	var gotQuery, gotMatch string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/prom/api/v1/query":
+			gotQuery = r.URL.Query().Get("query")
+			if gotQuery == `bad{cluster="prod"}` {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"status":"error","errorType":"bad_data","error":"parse error"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`))
+		case "/prom/api/v1/label/__name__/values":
+			gotMatch = r.URL.Query().Get("match[]")
+			_, _ = w.Write([]byte(`{"status":"success","data":["up","node_load1"]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	cfg := &models.MonitoringConfig{Type: "prometheus", Endpoint: server.URL + "/prom/", Labels: map[string]string{"cluster": "prod"}}
+	result, err := s.svc.ConsoleQuery(context.Background(), cfg, "", `sum(up)`, time.Time{})
+	s.Require().NoError(err)
+	assert.Equal(s.T(), `sum(up{cluster="prod"})`, gotQuery)
+	assert.Equal(s.T(), `sum(up{cluster="prod"})`, result.Query)
+	assert.JSONEq(s.T(), `{"resultType":"vector","result":[]}`, string(result.Data))
+
+	_, err = s.svc.ConsoleQuery(context.Background(), cfg, "", `bad`, time.Time{})
+	var apiErr *PrometheusAPIError
+	s.Require().ErrorAs(err, &apiErr)
+	assert.Equal(s.T(), "bad_data", apiErr.ErrorType)
+
+	names, err := s.svc.ConsoleMetricNames(context.Background(), cfg, `namespace=~"dev"`, "", 10)
+	s.Require().NoError(err)
+	assert.Equal(s.T(), []string{"node_load1", "up"}, names)
+	assert.Equal(s.T(), `{cluster="prod",namespace=~"dev"}`, gotMatch)
+}
+
+// TestPrometheusConsoleTestSuite 运行 PromQL 查询控制台测试套件
+func TestPrometheusConsoleTestSuite(t *testing.T) {
+	suite.Run(t, new(PrometheusConsoleTestSuite))
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+)
+
+// queryCostUsage 按小时采样统计 [start, end) 内各工作负载的资源用量（每个采样点代表一小时）。
+// CPU / 内存按 max(request, 实际用量) 计量，Pod 通过 kube-state-metrics 的 owner 指标归属到 Deployment、StatefulSet、CronJob 等
+func (s *PrometheusService) queryCostUsage(ctx context.Context, config *models.MonitoringConfig, start, end time.Time) ([]costUsage, error) {
+	sel := s.buildClusterSelector(config.Labels, "")
+	with := func(matchers string) string {
+		if sel == "" {
+			return matchers
+		}
+		if matchers == "" {
+			return sel
+		}
+		return matchers + "," + sel
+	}
+	maxOf := func(a, b string) string {
+		return fmt.Sprintf("(%s > %s or %s) or %s", a, b, b, a)
+	}
+	rangeSum := func(query string) (map[string]float64, []models.MetricsResult, error) {
+		resp, err := s.QueryPrometheus(ctx, config, &models.MetricsQuery{
+			Query: query,
+			Start: start.Add(time.Hour).Unix(),
+			End:   end.Unix(),
+			Step:  "1h",
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		sums := make(map[string]float64, len(resp.Data.Result))
+		for _, result := range resp.Data.Result {
+			sums[result.Metric["namespace"]+"/"+result.Metric["pod"]] += sumMetricValues(result.Values)
+		}
+		return sums, resp.Data.Result, nil
+	}
+
+	cpu, _, err := rangeSum(maxOf(
+		fmt.Sprintf(`sum by (namespace, pod) (kube_pod_container_resource_requests{%s})`, with(`resource="cpu"`)),
+		fmt.Sprintf(`sum by (namespace, pod) (rate(container_cpu_usage_seconds_total{%s}[1h]))`, with(`container!="",container!="POD"`)),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("查询 CPU 用量失败: %w", err)
+	}
+	memory, _, err := rangeSum(maxOf(
+		fmt.Sprintf(`sum by (namespace, pod) (kube_pod_container_resource_requests{%s})`, with(`resource="memory"`)),
+		fmt.Sprintf(`sum by (namespace, pod) (avg_over_time(container_memory_working_set_bytes{%s}[1h]))`, with(`container!="",container!="POD"`)),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("查询内存用量失败: %w", err)
+	}
+	gpu, _, err := rangeSum(fmt.Sprintf(`sum by (namespace, pod) (kube_pod_container_resource_requests{%s})`, with(`resource="nvidia_com_gpu"`)))
+	if err != nil {
+		return nil, fmt.Errorf("查询 GPU 用量失败: %w", err)
+	}
+	_, storage, err := rangeSum(fmt.Sprintf(
+		`sum by (namespace, persistentvolumeclaim, storageclass) (kube_persistentvolumeclaim_resource_requests_storage_bytes{%s} * on (namespace, persistentvolumeclaim) group_left (storageclass) max by (namespace, persistentvolumeclaim, storageclass) (kube_persistentvolumeclaim_info{%s}))`,
+		sel, sel))
+	if err != nil {
+		return nil, fmt.Errorf("查询存储用量失败: %w", err)
+	}
+
+	// Pod 归属：Pod -> ReplicaSet / Job -> Deployment / CronJob
+	window := fmt.Sprintf("%ds", int64(end.Sub(start)/time.Second))
+	owners := make(map[string]costOwner)
+	for _, o := range []struct{ metric, nameLabel, kind string }{
+		{"kube_pod_owner", "pod", "Pod"},
+		{"kube_replicaset_owner", "replicaset", "ReplicaSet"},
+		{"kube_job_owner", "job_name", "Job"},
+	} {
+		resp, err := s.QueryInstant(ctx, config, fmt.Sprintf(`max by (namespace, %s, owner_kind, owner_name) (max_over_time(%s{%s}[%s]))`, o.nameLabel, o.metric, sel, window), end)
+		if err != nil {
+			return nil, fmt.Errorf("查询 %s 失败: %w", o.metric, err)
+		}
+		for _, result := range resp.Data.Result {
+			m := result.Metric
+			owners[o.kind+"/"+m["namespace"]+"/"+m[o.nameLabel]] = costOwner{kind: m["owner_kind"], name: m["owner_name"]}
+		}
+	}
+
+	usages := make(map[string]*costUsage)
+	workload := func(namespace, kind, name string) *costUsage {
+		key := namespace + "/" + kind + "/" + name
+		u, ok := usages[key]
+		if !ok {
+			u = &costUsage{Namespace: namespace, Kind: kind, Name: name}
+			usages[key] = u
+		}
+		return u
+	}
+	pods := make(map[string]bool)
+	for _, m := range []map[string]float64{cpu, memory, gpu} {
+		for key := range m {
+			pods[key] = true
+		}
+	}
+	for key := range pods {
+		namespace, pod, _ := strings.Cut(key, "/")
+		kind, name := resolveCostOwner(owners, namespace, pod)
+		u := workload(namespace, kind, name)
+		u.CPUCoreHours += cpu[key]
+		u.MemoryGiBHours += memory[key] / (1 << 30)
+		u.GPUHours += gpu[key]
+	}
+	for _, result := range storage {
+		m := result.Metric
+		u := workload(m["namespace"], "PersistentVolumeClaim", m["persistentvolumeclaim"])
+		u.StorageClass = m["storageclass"]
+		u.StorageGiBHours += sumMetricValues(result.Values) / (1 << 30)
+	}
+
+	out := make([]costUsage, 0, len(usages))
+	for _, u := range usages {
+		out = append(out, *u)
+	}
+	return out, nil
+}
+
+// costOwner kube-state-metrics owner 指标中的归属对象
+type costOwner struct {
+	kind string
+	name string
+}
+
+// resolveCostOwner 沿 owner 链把 Pod 归属到顶层工作负载，无 owner 的 Pod 以自身计
+func resolveCostOwner(owners map[string]costOwner, namespace, pod string) (string, string) {
+	owner, ok := owners["Pod/"+namespace+"/"+pod]
+	if !ok || owner.kind == "" || owner.kind == "<none>" {
+		return "Pod", pod
+	}
+	if owner.kind == "ReplicaSet" || owner.kind == "Job" {
+		if parent, ok := owners[owner.kind+"/"+namespace+"/"+owner.name]; ok && parent.kind != "" && parent.kind != "<none>" {
+			return parent.kind, parent.name
+		}
+	}
+	return owner.kind, owner.name
+}
+
+func sumMetricValues(values [][]interface{}) float64 {
+	var sum float64
+	for _, value := range values {
+		if len(value) < 2 {
+			continue
+		}
+		v, err := strconv.ParseFloat(fmt.Sprintf("%v", value[1]), 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		sum += v
+	}
+	return sum
+}
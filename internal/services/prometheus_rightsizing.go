@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+)
+
+// rightsizingSubqueryStep CPU 分位数子查询的采样步长
+const rightsizingSubqueryStep = "5m"
+
+// QueryContainerUsageProfiles 查询统计窗口内各 Pod 容器的 CPU / 内存分位数、峰值、OOM 次数与限流占比。
+// selector 为额外的标签匹配器（如 namespace、pod），会与监控配置中的集群标签合并
+func (s *PrometheusService) QueryContainerUsageProfiles(ctx context.Context, config *models.MonitoringConfig, selector string, window time.Duration) ([]models.ContainerUsageProfile, error) {
+	sel := `container!="",container!="POD"`
+	for _, part := range []string{s.buildClusterSelector(config.Labels, ""), selector} {
+		if part != "" {
+			sel += "," + part
+		}
+	}
+	w := fmt.Sprintf("%ds", int64(window/time.Second))
+	cpuRate := fmt.Sprintf("rate(container_cpu_usage_seconds_total{%s}[%s])[%s:%s]", sel, rightsizingSubqueryStep, w, rightsizingSubqueryStep)
+	memory := fmt.Sprintf("container_memory_working_set_bytes{%s}[%s]", sel, w)
+
+	queries := []struct {
+		query string
+		set   func(p *models.ContainerUsageProfile, v float64)
+	}{
+		{fmt.Sprintf("max by (pod, container) (quantile_over_time(0.95, %s))", cpuRate), func(p *models.ContainerUsageProfile, v float64) { p.CPUP95 = v }},
+		{fmt.Sprintf("max by (pod, container) (quantile_over_time(0.99, %s))", cpuRate), func(p *models.ContainerUsageProfile, v float64) { p.CPUP99 = v }},
+		{fmt.Sprintf("max by (pod, container) (quantile_over_time(0.95, %s))", memory), func(p *models.ContainerUsageProfile, v float64) { p.MemoryP95 = v }},
+		{fmt.Sprintf("max by (pod, container) (quantile_over_time(0.99, %s))", memory), func(p *models.ContainerUsageProfile, v float64) { p.MemoryP99 = v }},
+		{fmt.Sprintf("max by (pod, container) (max_over_time(%s))", memory), func(p *models.ContainerUsageProfile, v float64) { p.MemoryMax = v }},
+		{fmt.Sprintf("sum by (pod, container) (increase(container_oom_events_total{%s}[%s]))", sel, w), func(p *models.ContainerUsageProfile, v float64) { p.OOMKills = math.Round(v) }},
+		{fmt.Sprintf("sum by (pod, container) (increase(container_cpu_cfs_throttled_periods_total{%s}[%s])) / sum by (pod, container) (increase(container_cpu_cfs_periods_total{%s}[%s])) * 100", sel, w, sel, w),
+			func(p *models.ContainerUsageProfile, v float64) { p.CPUThrottling = roundTo(v, 2) }},
+	}
+
+	profiles := make(map[string]*models.ContainerUsageProfile)
+	now := time.Now()
+	for _, q := range queries {
+		resp, err := s.QueryInstant(ctx, config, q.query, now)
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range resp.Data.Result {
+			if len(result.Value) < 2 {
+				continue
+			}
+			v, err := strconv.ParseFloat(fmt.Sprintf("%v", result.Value[1]), 64)
+			if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+				continue
+			}
+			pod, container := result.Metric["pod"], result.Metric["container"]
+			key := pod + "/" + container
+			p, ok := profiles[key]
+			if !ok {
+				p = &models.ContainerUsageProfile{Pod: pod, Container: container}
+				profiles[key] = p
+			}
+			q.set(p, v)
+		}
+	}
+
+	keys := make([]string, 0, len(profiles))
+	for key := range profiles {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	out := make([]models.ContainerUsageProfile, 0, len(keys))
+	for _, key := range keys {
+		out = append(out, *profiles[key])
+	}
+	return out, nil
+}
+
+// rightsizingPodSelector 生成匹配命名空间及 Pod 名称正则的选择器，podPattern 为空时只匹配命名空间
+func rightsizingPodSelector(namespace, podPattern string) string {
+	parts := []string{"namespace=" + strconv.Quote(namespace)}
+	if podPattern != "" {
+		parts = append(parts, "pod=~"+strconv.Quote(podPattern))
+	}
+	return strings.Join(parts, ",")
+}
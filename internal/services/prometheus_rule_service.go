@@ -0,0 +1,605 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/dynamic"
+)
+
+// prometheusRuleGVR Prometheus Operator 的 PrometheusRule 资源
+var prometheusRuleGVR = schema.GroupVersionResource{Group: "monitoring.coreos.com", Version: "v1", Resource: "prometheusrules"}
+
+const (
+	// prometheusRuleManagedByLabel 由 KubePolaris 创建的 PrometheusRule 带有该标签
+	prometheusRuleManagedByLabel = "app.kubernetes.io/managed-by"
+
+	// 规则试运行的时间范围与序列数上限
+	prometheusRuleTestDefaultHours = 6
+	prometheusRuleTestMaxHours     = 72
+	prometheusRuleTestMaxPoints    = 600
+	prometheusRuleTestMaxSeries    = 100
+)
+
+var (
+	promMetricNamePattern = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	promLabelNamePattern  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	promDurationPattern   = regexp.MustCompile(`^(([0-9]+)y)?(([0-9]+)w)?(([0-9]+)d)?(([0-9]+)h)?(([0-9]+)m)?(([0-9]+)s)?(([0-9]+)ms)?$`)
+	promTemplateParam     = regexp.MustCompile(`\$\{(\w+)\}`)
+)
+
+// PrometheusRuleService PrometheusRule 告警规则管理服务
+type PrometheusRuleService struct {
+	monitoringConfigSvc *MonitoringConfigService
+	prometheusSvc       *PrometheusService
+
+	// dynamicFor 获取集群动态客户端，测试时可替换
+	dynamicFor func(cluster *models.Cluster) (dynamic.Interface, error)
+}
+
+// NewPrometheusRuleService 创建 PrometheusRule 管理服务
+func NewPrometheusRuleService(monitoringConfigSvc *MonitoringConfigService, prometheusSvc *PrometheusService) *PrometheusRuleService {
+	return &PrometheusRuleService{
+		monitoringConfigSvc: monitoringConfigSvc,
+		prometheusSvc:       prometheusSvc,
+		dynamicFor: func(cluster *models.Cluster) (dynamic.Interface, error) {
+			client, err := NewK8sClientForCluster(cluster)
+			if err != nil {
+				return nil, err
+			}
+			return dynamic.NewForConfig(client.GetRestConfig())
+		},
+	}
+}
+
+// ListRules 列出 PrometheusRule，namespace 为空时列出所有命名空间
+func (s *PrometheusRuleService) ListRules(ctx context.Context, cluster *models.Cluster, namespace string) ([]models.PrometheusRule, error) {
+	client, err := s.dynamicFor(cluster)
+	if err != nil {
+		return nil, fmt.Errorf("创建K8s客户端失败: %w", err)
+	}
+	list, err := client.Resource(prometheusRuleGVR).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("集群未安装 Prometheus Operator（未找到 PrometheusRule CRD）")
+		}
+		return nil, fmt.Errorf("获取 PrometheusRule 列表失败: %w", err)
+	}
+
+	rules := make([]models.PrometheusRule, 0, len(list.Items))
+	for i := range list.Items {
+		rule, err := prometheusRuleFromUnstructured(&list.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, *rule)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].Namespace != rules[j].Namespace {
+			return rules[i].Namespace < rules[j].Namespace
+		}
+		return rules[i].Name < rules[j].Name
+	})
+	return rules, nil
+}
+
+// GetRule 获取 PrometheusRule
+func (s *PrometheusRuleService) GetRule(ctx context.Context, cluster *models.Cluster, namespace, name string) (*models.PrometheusRule, error) {
+	client, err := s.dynamicFor(cluster)
+	if err != nil {
+		return nil, fmt.Errorf("创建K8s客户端失败: %w", err)
+	}
+	obj, err := client.Resource(prometheusRuleGVR).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取 PrometheusRule 失败: %w", err)
+	}
+	return prometheusRuleFromUnstructured(obj)
+}
+
+// CreateRule 创建 PrometheusRule
+func (s *PrometheusRuleService) CreateRule(ctx context.Context, cluster *models.Cluster, rule *models.PrometheusRule) (*models.PrometheusRule, error) {
+	client, err := s.dynamicFor(cluster)
+	if err != nil {
+		return nil, fmt.Errorf("创建K8s客户端失败: %w", err)
+	}
+	if rule.Labels == nil {
+		rule.Labels = map[string]string{}
+	}
+	if _, ok := rule.Labels[prometheusRuleManagedByLabel]; !ok {
+		rule.Labels[prometheusRuleManagedByLabel] = "kubepolaris"
+	}
+	obj, err := prometheusRuleToUnstructured(rule)
+	if err != nil {
+		return nil, err
+	}
+	created, err := client.Resource(prometheusRuleGVR).Namespace(rule.Namespace).Create(ctx, obj, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("创建 PrometheusRule 失败: %w", err)
+	}
+	return prometheusRuleFromUnstructured(created)
+}
+
+// UpdateRule 更新 PrometheusRule 的标签、注解与规则组；请求携带 resourceVersion 时做冲突检测
+func (s *PrometheusRuleService) UpdateRule(ctx context.Context, cluster *models.Cluster, rule *models.PrometheusRule) (*models.PrometheusRule, error) {
+	client, err := s.dynamicFor(cluster)
+	if err != nil {
+		return nil, fmt.Errorf("创建K8s客户端失败: %w", err)
+	}
+	resource := client.Resource(prometheusRuleGVR).Namespace(rule.Namespace)
+	existing, err := resource.Get(ctx, rule.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取 PrometheusRule 失败: %w", err)
+	}
+
+	desired, err := prometheusRuleToUnstructured(rule)
+	if err != nil {
+		return nil, err
+	}
+	existing.SetLabels(rule.Labels)
+	existing.SetAnnotations(rule.Annotations)
+	if rule.ResourceVersion != "" {
+		existing.SetResourceVersion(rule.ResourceVersion)
+	}
+	existing.Object["spec"] = desired.Object["spec"]
+
+	updated, err := resource.Update(ctx, existing, metav1.UpdateOptions{})
+	if err != nil {
+		if apierrors.IsConflict(err) {
+			return nil, fmt.Errorf("PrometheusRule 已被修改，请刷新后重试")
+		}
+		return nil, fmt.Errorf("更新 PrometheusRule 失败: %w", err)
+	}
+	return prometheusRuleFromUnstructured(updated)
+}
+
+// DeleteRule 删除 PrometheusRule
+func (s *PrometheusRuleService) DeleteRule(ctx context.Context, cluster *models.Cluster, namespace, name string) error {
+	client, err := s.dynamicFor(cluster)
+	if err != nil {
+		return fmt.Errorf("创建K8s客户端失败: %w", err)
+	}
+	if err := client.Resource(prometheusRuleGVR).Namespace(namespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil {
+		return fmt.Errorf("删除 PrometheusRule 失败: %w", err)
+	}
+	return nil
+}
+
+// ValidateRule 校验规则结构，并在集群配置了监控数据源时通过数据源校验 PromQL 语法
+func (s *PrometheusRuleService) ValidateRule(ctx context.Context, clusterID uint, rule *models.PrometheusRule) *models.PrometheusRuleValidationResult {
+	result := &models.PrometheusRuleValidationResult{Errors: ValidatePrometheusRule(rule)}
+
+	config, err := s.monitoringConfigSvc.GetMonitoringConfig(clusterID)
+	if err != nil || config.Type == "disabled" || config.Endpoint == "" {
+		result.Warnings = append(result.Warnings, "集群未配置监控数据源，未校验 PromQL 语法")
+	} else {
+		checked := make(map[string]bool)
+	groups:
+		for i, group := range rule.Groups {
+			for j, r := range group.Rules {
+				if r.Expr == "" || checked[r.Expr] {
+					continue
+				}
+				checked[r.Expr] = true
+				_, err := s.prometheusSvc.QueryInstant(ctx, config, r.Expr, time.Now())
+				var apiErr *PrometheusAPIError
+				switch {
+				case err == nil:
+				case errors.As(err, &apiErr) && apiErr.ErrorType == "bad_data":
+					result.Errors = append(result.Errors, models.PrometheusRuleValidationError{
+						Path:    fmt.Sprintf("groups[%d].rules[%d].expr", i, j),
+						Message: "PromQL 语法错误: " + apiErr.Message,
+					})
+				default:
+					result.Warnings = append(result.Warnings, "监控数据源查询失败，未完成 PromQL 校验: "+err.Error())
+					break groups
+				}
+			}
+		}
+	}
+
+	result.Valid = len(result.Errors) == 0
+	return result
+}
+
+// ValidatePrometheusRule 校验 PrometheusRule 的结构（不含 PromQL 语法）
+func ValidatePrometheusRule(rule *models.PrometheusRule) []models.PrometheusRuleValidationError {
+	var errs []models.PrometheusRuleValidationError
+	add := func(path, format string, args ...interface{}) {
+		errs = append(errs, models.PrometheusRuleValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for _, msg := range validation.IsDNS1123Subdomain(rule.Name) {
+		add("name", "名称不合法: %s", msg)
+	}
+	if rule.Namespace == "" {
+		add("namespace", "命名空间不能为空")
+	}
+	if len(rule.Groups) == 0 {
+		add("groups", "至少需要一个规则组")
+	}
+
+	groupNames := make(map[string]bool)
+	for i, group := range rule.Groups {
+		path := fmt.Sprintf("groups[%d]", i)
+		if group.Name == "" {
+			add(path+".name", "规则组名称不能为空")
+		} else if groupNames[group.Name] {
+			add(path+".name", "规则组名称重复: %s", group.Name)
+		}
+		groupNames[group.Name] = true
+		if group.Interval != "" {
+			if _, err := ParsePromDuration(group.Interval); err != nil {
+				add(path+".interval", "%v", err)
+			}
+		}
+		if len(group.Rules) == 0 {
+			add(path+".rules", "规则组至少需要一条规则")
+		}
+
+		for j, r := range group.Rules {
+			rulePath := fmt.Sprintf("%s.rules[%d]", path, j)
+			switch {
+			case r.Alert != "" && r.Record != "":
+				add(rulePath, "alert 与 record 只能设置一个")
+			case r.Alert == "" && r.Record == "":
+				add(rulePath, "需要设置 alert（告警规则）或 record（记录规则）")
+			case r.Record != "":
+				if !promMetricNamePattern.MatchString(r.Record) {
+					add(rulePath+".record", "记录规则名称不是合法的指标名: %s", r.Record)
+				}
+				if r.For != "" || len(r.Annotations) > 0 {
+					add(rulePath, "记录规则不支持 for 与 annotations")
+				}
+			}
+			if strings.TrimSpace(r.Expr) == "" {
+				add(rulePath+".expr", "表达式不能为空")
+			}
+			if r.For != "" {
+				if _, err := ParsePromDuration(r.For); err != nil {
+					add(rulePath+".for", "%v", err)
+				}
+			}
+			for name := range r.Labels {
+				if !promLabelNamePattern.MatchString(name) {
+					add(rulePath+".labels", "标签名不合法: %s", name)
+				}
+			}
+			for name := range r.Annotations {
+				if !promLabelNamePattern.MatchString(name) {
+					add(rulePath+".annotations", "注解名不合法: %s", name)
+				}
+			}
+		}
+	}
+	return errs
+}
+
+// ruleTestRange 计算回放的起始时间与步长，hours 为 0 时使用默认范围，stepExpr 为空时按最大采样点数自动计算
+func ruleTestRange(end time.Time, hours int, stepExpr string) (time.Time, time.Duration, error) {
+	if hours <= 0 {
+		hours = prometheusRuleTestDefaultHours
+	}
+	if hours > prometheusRuleTestMaxHours {
+		return time.Time{}, 0, fmt.Errorf("回放时间范围不能超过 %d 小时", prometheusRuleTestMaxHours)
+	}
+
+	start := end.Add(-time.Duration(hours) * time.Hour)
+	step := (end.Sub(start) / prometheusRuleTestMaxPoints).Truncate(time.Second)
+	if stepExpr != "" {
+		var err error
+		if step, err = ParsePromDuration(stepExpr); err != nil {
+			return time.Time{}, 0, err
+		}
+		if step <= 0 {
+			return time.Time{}, 0, fmt.Errorf("step 必须大于 0")
+		}
+		if end.Sub(start)/step > 11000 {
+			return time.Time{}, 0, fmt.Errorf("步长过小，采样点超过 11000 个")
+		}
+	}
+	if step < 15*time.Second {
+		step = 15 * time.Second
+	}
+	return start, step, nil
+}
+
+// TestRule 在过去一段时间内回放表达式，推算规则会在何时进入 pending / firing / 恢复
+func (s *PrometheusRuleService) TestRule(ctx context.Context, clusterID uint, req *models.PrometheusRuleTestRequest) (*models.PrometheusRuleTestResult, error) {
+	config, err := s.monitoringConfigSvc.GetMonitoringConfig(clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("获取监控配置失败: %w", err)
+	}
+	if config.Type == "disabled" || config.Endpoint == "" {
+		return nil, fmt.Errorf("集群未配置监控数据源")
+	}
+
+	var forDuration time.Duration
+	if req.For != "" {
+		if forDuration, err = ParsePromDuration(req.For); err != nil {
+			return nil, err
+		}
+	}
+	end := time.Now().Truncate(time.Second)
+	start, step, err := ruleTestRange(end, req.Hours, req.Step)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.prometheusSvc.QueryPrometheus(ctx, config, &models.MetricsQuery{
+		Query: req.Expr,
+		Start: start.Unix(),
+		End:   end.Unix(),
+		Step:  fmt.Sprintf("%ds", int64(step/time.Second)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Status != "success" {
+		return nil, fmt.Errorf("查询失败: %s", resp.Status)
+	}
+
+	result := simulatePrometheusRule(resp.Data.Result, end, step, forDuration)
+	result.Start, result.End = start, end
+	result.Step = step.String()
+	return result, nil
+}
+
+// simulatePrometheusRule 根据区间查询结果推算告警时间线：
+// 连续有采样的区间视为表达式命中，持续时间达到 for 后触发，下一个采样点无数据时恢复
+func simulatePrometheusRule(series []models.MetricsResult, end time.Time, step, forDuration time.Duration) *models.PrometheusRuleTestResult {
+	result := &models.PrometheusRuleTestResult{Series: []models.PrometheusRuleTestSeries{}}
+	maxGap := step + step/2
+
+	for _, s := range series {
+		item := models.PrometheusRuleTestSeries{Labels: s.Metric, Alerts: []models.PrometheusRuleTestAlert{}}
+		var active *models.PrometheusRuleTestAlert
+		var last time.Time
+		closeAlert := func() {
+			if active == nil {
+				return
+			}
+			if last.Sub(active.ActiveAt) >= forDuration {
+				firedAt := active.ActiveAt.Add(forDuration)
+				active.FiredAt = &firedAt
+				result.FiringCount++
+				if resolvedAt := last.Add(step); !resolvedAt.After(end) {
+					active.ResolvedAt = &resolvedAt
+				}
+			}
+			item.Alerts = append(item.Alerts, *active)
+			active = nil
+		}
+
+		for _, point := range s.Values {
+			ts, value, ok := promSample(point)
+			if !ok {
+				continue
+			}
+			if active != nil && ts.Sub(last) > maxGap {
+				closeAlert()
+			}
+			if active == nil {
+				active = &models.PrometheusRuleTestAlert{ActiveAt: ts}
+			}
+			active.Value = value
+			last = ts
+		}
+		closeAlert()
+
+		if len(result.Series) >= prometheusRuleTestMaxSeries {
+			result.Truncated = true
+			continue
+		}
+		result.Series = append(result.Series, item)
+	}
+	return result
+}
+
+// promSample 解析区间查询的采样点 [时间戳, "值"]
+func promSample(point []interface{}) (time.Time, string, bool) {
+	if len(point) != 2 {
+		return time.Time{}, "", false
+	}
+	ts, ok := point[0].(float64)
+	if !ok {
+		return time.Time{}, "", false
+	}
+	value, _ := point[1].(string)
+	return time.Unix(0, int64(ts*float64(time.Second))).UTC(), value, true
+}
+
+// BuildRule 根据模板或表单构建告警规则
+func (s *PrometheusRuleService) BuildRule(req *models.PrometheusRuleBuildRequest) (*models.PrometheusAlertRule, error) {
+	var rule models.PrometheusAlertRule
+	switch {
+	case req.TemplateID != "":
+		rendered, err := RenderPrometheusRuleTemplate(req.TemplateID, req.Params)
+		if err != nil {
+			return nil, err
+		}
+		rule = *rendered
+	case req.Builder != nil:
+		expr, err := BuildPromQLExpr(req.Builder)
+		if err != nil {
+			return nil, err
+		}
+		rule = models.PrometheusAlertRule{Expr: expr, Labels: map[string]string{"severity": "warning"}, Annotations: map[string]string{}}
+	default:
+		return nil, fmt.Errorf("需要指定模板或表单")
+	}
+
+	if req.Alert != "" {
+		rule.Alert = req.Alert
+	}
+	if req.For != "" {
+		if _, err := ParsePromDuration(req.For); err != nil {
+			return nil, err
+		}
+		rule.For = req.For
+	}
+	if req.Severity != "" {
+		rule.Labels["severity"] = req.Severity
+	}
+	if req.Summary != "" {
+		rule.Annotations["summary"] = req.Summary
+	}
+	if req.Description != "" {
+		rule.Annotations["description"] = req.Description
+	}
+	return &rule, nil
+}
+
+// BuildPromQLExpr 由表单字段生成 PromQL 表达式
+func BuildPromQLExpr(b *models.PrometheusRuleExprBuilder) (string, error) {
+	if !promMetricNamePattern.MatchString(b.Metric) {
+		return "", fmt.Errorf("指标名不合法: %s", b.Metric)
+	}
+
+	names := make([]string, 0, len(b.Matchers))
+	for name := range b.Matchers {
+		if !promLabelNamePattern.MatchString(name) {
+			return "", fmt.Errorf("标签名不合法: %s", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	matchers := make([]string, 0, len(names))
+	for _, name := range names {
+		value := b.Matchers[name]
+		if strings.HasPrefix(value, "~") {
+			if _, err := regexp.Compile(value[1:]); err != nil {
+				return "", fmt.Errorf("标签 %s 的正则不合法: %w", name, err)
+			}
+			matchers = append(matchers, name+"=~"+strconv.Quote(value[1:]))
+		} else {
+			matchers = append(matchers, name+"="+strconv.Quote(value))
+		}
+	}
+	expr := b.Metric
+	if len(matchers) > 0 {
+		expr += "{" + strings.Join(matchers, ", ") + "}"
+	}
+
+	switch b.Function {
+	case "":
+	case "rate", "irate", "increase", "avg_over_time", "max_over_time", "min_over_time":
+		rangeStr := b.Range
+		if rangeStr == "" {
+			rangeStr = "5m"
+		}
+		if _, err := ParsePromDuration(rangeStr); err != nil {
+			return "", err
+		}
+		expr = fmt.Sprintf("%s(%s[%s])", b.Function, expr, rangeStr)
+	default:
+		return "", fmt.Errorf("不支持的函数: %s", b.Function)
+	}
+
+	switch b.Aggregation {
+	case "":
+	case "sum", "avg", "max", "min", "count":
+		for _, label := range b.By {
+			if !promLabelNamePattern.MatchString(label) {
+				return "", fmt.Errorf("分组标签不合法: %s", label)
+			}
+		}
+		if len(b.By) > 0 {
+			expr = fmt.Sprintf("%s by (%s) (%s)", b.Aggregation, strings.Join(b.By, ", "), expr)
+		} else {
+			expr = fmt.Sprintf("%s(%s)", b.Aggregation, expr)
+		}
+	default:
+		return "", fmt.Errorf("不支持的聚合方式: %s", b.Aggregation)
+	}
+
+	switch b.Operator {
+	case ">", ">=", "<", "<=", "==", "!=":
+	default:
+		return "", fmt.Errorf("不支持的比较运算符: %s", b.Operator)
+	}
+	return fmt.Sprintf("%s %s %s", expr, b.Operator, strconv.FormatFloat(b.Threshold, 'f', -1, 64)), nil
+}
+
+// ParsePromDuration 解析 Prometheus 时长（如 30s、5m、1h30m、1d）
+func ParsePromDuration(s string) (time.Duration, error) {
+	m := promDurationPattern.FindStringSubmatch(s)
+	if s == "" || m == nil {
+		return 0, fmt.Errorf("无效的时长: %q（示例：30s、5m、1h30m、1d）", s)
+	}
+	units := []time.Duration{365 * 24 * time.Hour, 7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second, time.Millisecond}
+	var d time.Duration
+	for i, unit := range units {
+		if value := m[2*i+2]; value != "" {
+			n, _ := strconv.ParseInt(value, 10, 64)
+			d += time.Duration(n) * unit
+		}
+	}
+	return d, nil
+}
+
+// prometheusRuleToUnstructured 转换为 PrometheusRule 资源对象
+func prometheusRuleToUnstructured(rule *models.PrometheusRule) (*unstructured.Unstructured, error) {
+	data, err := json.Marshal(map[string]interface{}{"groups": rule.Groups})
+	if err != nil {
+		return nil, fmt.Errorf("序列化规则组失败: %w", err)
+	}
+	var spec map[string]interface{}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("序列化规则组失败: %w", err)
+	}
+
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	obj.SetAPIVersion(prometheusRuleGVR.GroupVersion().String())
+	obj.SetKind("PrometheusRule")
+	obj.SetName(rule.Name)
+	obj.SetNamespace(rule.Namespace)
+	obj.SetLabels(rule.Labels)
+	obj.SetAnnotations(rule.Annotations)
+	if rule.ResourceVersion != "" {
+		obj.SetResourceVersion(rule.ResourceVersion)
+	}
+	return obj, nil
+}
+
+// prometheusRuleFromUnstructured 从 PrometheusRule 资源对象解析
+func prometheusRuleFromUnstructured(obj *unstructured.Unstructured) (*models.PrometheusRule, error) {
+	rule := &models.PrometheusRule{
+		Name:            obj.GetName(),
+		Namespace:       obj.GetNamespace(),
+		Labels:          obj.GetLabels(),
+		Annotations:     obj.GetAnnotations(),
+		ResourceVersion: obj.GetResourceVersion(),
+		CreatedAt:       obj.GetCreationTimestamp().Time,
+		Groups:          []models.PrometheusRuleGroup{},
+	}
+	if raw, ok := obj.Object["spec"]; ok {
+		var spec struct {
+			Groups []models.PrometheusRuleGroup `json:"groups"`
+		}
+		data, err := json.Marshal(raw)
+		if err == nil {
+			err = json.Unmarshal(data, &spec)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解析 PrometheusRule %s/%s 失败: %w", rule.Namespace, rule.Name, err)
+		}
+		if spec.Groups != nil {
+			rule.Groups = spec.Groups
+		}
+	}
+	return rule, nil
+}
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+// PrometheusRuleServiceTestSuite 定义 PrometheusRule 告警规则测试套件
+type PrometheusRuleServiceTestSuite struct {
+	suite.Suite
+	svc     *PrometheusRuleService
+	cluster *models.Cluster
+}
+
+// SetupTest 每个测试前的设置
+func (s *PrometheusRuleServiceTestSuite) SetupTest() {
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		prometheusRuleGVR: "PrometheusRuleList",
+	})
+	s.svc = NewPrometheusRuleService(nil, nil)
+	s.svc.dynamicFor = func(*models.Cluster) (dynamic.Interface, error) { return client, nil }
+	s.cluster = &models.Cluster{Name: "prod"}
+}
+
+func (s *PrometheusRuleServiceTestSuite) newRule() *models.PrometheusRule {
+	return &models.PrometheusRule{
+		Name:      "payments-alerts",
+		Namespace: "monitoring",
+		Groups: []models.PrometheusRuleGroup{{
+			Name:     "payments",
+			Interval: "30s",
+			Rules: []models.PrometheusAlertRule{{
+				Alert:       "PaymentsHighErrorRate",
+				Expr:        `sum(rate(http_requests_total{code=~"5.."}[5m])) > 1`,
+				For:         "5m",
+				Labels:      map[string]string{"severity": "critical"},
+				Annotations: map[string]string{"summary": "支付接口错误率过高"},
+			}},
+		}},
+	}
+}
+
+// TestParsePromDuration 测试 Prometheus 时长解析
+func (s *PrometheusRuleServiceTestSuite) TestParsePromDuration() {
+	cases := map[string]time.Duration{
+		"30s":    30 * time.Second,
+		"5m":     5 * time.Minute,
+		"1h30m":  90 * time.Minute,
+		"1d":     24 * time.Hour,
+		"1w":     7 * 24 * time.Hour,
+		"500ms":  500 * time.Millisecond,
+		"1m30s":  90 * time.Second,
+		"2h0m0s": 2 * time.Hour,
+	}
+	for in, want := range cases {
+		got, err := ParsePromDuration(in)
+		s.Require().NoError(err, in)
+		assert.Equal(s.T(), want, got, in)
+	}
+	for _, in := range []string{"", "5", "m", "5min", "1.5h", "30s5m"} {
+		_, err := ParsePromDuration(in)
+		assert.Error(s.T(), err, in)
+	}
+}
+
+// TestRuleTestRange 测试规则回放的时间范围与步长
+func (s *PrometheusRuleServiceTestSuite) TestRuleTestRange() {
+	end := time.Unix(1710000000, 0)
+
+	start, step, err := ruleTestRange(end, 0, "")
+	s.Require().NoError(err)
+	assert.Equal(s.T(), end.Add(-6*time.Hour), start)
+	assert.Equal(s.T(), 36*time.Second, step)
+
+	_, step, err = ruleTestRange(end, 24, "5m")
+	s.Require().NoError(err)
+	assert.Equal(s.T(), 5*time.Minute, step)
+
+	_, step, err = ruleTestRange(end, 1, "1s")
+	s.Require().NoError(err)
+	assert.Equal(s.T(), 15*time.Second, step, "步长不小于 15 秒")
+
+	_, _, err = ruleTestRange(end, 6, "0s")
+	assert.EqualError(s.T(), err, "step 必须大于 0")
+	_, _, err = ruleTestRange(end, 6, "1s")
+	assert.Error(s.T(), err, "采样点过多")
+	_, _, err = ruleTestRange(end, 73, "")
+	assert.Error(s.T(), err)
+}
+
+// TestValidatePrometheusRule 测试规则结构校验
+func (s *PrometheusRuleServiceTestSuite) TestValidatePrometheusRule() {
+	assert.Empty(s.T(), ValidatePrometheusRule(s.newRule()))
+
+	cases := map[string]func(r *models.PrometheusRule){
+		"name":                           func(r *models.PrometheusRule) { r.Name = "Payments_Alerts" },
+		"groups[0].interval":             func(r *models.PrometheusRule) { r.Groups[0].Interval = "30" },
+		"groups[0].rules[0]":             func(r *models.PrometheusRule) { r.Groups[0].Rules[0].Record = "job:errors:rate5m" },
+		"groups[0].rules[0].expr":        func(r *models.PrometheusRule) { r.Groups[0].Rules[0].Expr = " " },
+		"groups[0].rules[0].for":         func(r *models.PrometheusRule) { r.Groups[0].Rules[0].For = "5 minutes" },
+		"groups[0].rules[0].labels":      func(r *models.PrometheusRule) { r.Groups[0].Rules[0].Labels["team-name"] = "payments" },
+		"groups[0].rules[0].annotations": func(r *models.PrometheusRule) { r.Groups[0].Rules[0].Annotations["run-book"] = "x" },
+		"groups[1].name": func(r *models.PrometheusRule) {
+			r.Groups = append(r.Groups, models.PrometheusRuleGroup{Name: "payments", Rules: r.Groups[0].Rules})
+		},
+	}
+	for path, mutate := range cases {
+		rule := s.newRule()
+		mutate(rule)
+		errs := ValidatePrometheusRule(rule)
+		s.Require().Len(errs, 1, path)
+		assert.Equal(s.T(), path, errs[0].Path)
+	}
+}
+
+// TestSimulatePrometheusRule 测试试运行的 pending / firing / resolved 时间线
+func (s *PrometheusRuleServiceTestSuite) TestSimulatePrometheusRule() {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	step := time.Minute
+	sample := func(minute int) []interface{} {
+		return []interface{}{float64(base.Add(time.Duration(minute) * step).Unix()), "3"}
+	}
+	series := []models.MetricsResult{{
+		Metric: map[string]string{"pod": "api-0"},
+		// 0-2 分钟命中但未满 for；5-10 分钟命中并触发后恢复；58-59 分钟命中到查询结束仍在触发
+		Values: [][]interface{}{sample(0), sample(1), sample(2), sample(5), sample(6), sample(7), sample(8), sample(9), sample(10), sample(58), sample(59)},
+	}}
+
+	result := simulatePrometheusRule(series, base.Add(59*time.Minute), step, 3*time.Minute)
+	s.Require().Len(result.Series, 1)
+	alerts := result.Series[0].Alerts
+	s.Require().Len(alerts, 3)
+	assert.Equal(s.T(), 1, result.FiringCount)
+
+	assert.Nil(s.T(), alerts[0].FiredAt)
+
+	s.Require().NotNil(alerts[1].FiredAt)
+	assert.Equal(s.T(), base.Add(5*time.Minute), alerts[1].ActiveAt)
+	assert.Equal(s.T(), base.Add(8*time.Minute), *alerts[1].FiredAt)
+	s.Require().NotNil(alerts[1].ResolvedAt)
+	assert.Equal(s.T(), base.Add(11*time.Minute), *alerts[1].ResolvedAt)
+
+	assert.Nil(s.T(), alerts[2].FiredAt)
+
+	// for 为 0 时首个采样点即触发，查询结束时未恢复
+	result = simulatePrometheusRule(series, base.Add(59*time.Minute), step, 0)
+	assert.Equal(s.T(), 3, result.FiringCount)
+	assert.Nil(s.T(), result.Series[0].Alerts[2].ResolvedAt)
+}
+
+// TestBuildPromQLExpr 测试表单生成 PromQL
+func (s *PrometheusRuleServiceTestSuite) TestBuildPromQLExpr() {
+	expr, err := BuildPromQLExpr(&models.PrometheusRuleExprBuilder{
+		Metric:      "http_requests_total",
+		Matchers:    map[string]string{"code": "~5..", "job": "api"},
+		Function:    "rate",
+		Aggregation: "sum",
+		By:          []string{"namespace"},
+		Operator:    ">",
+		Threshold:   1,
+	})
+	s.Require().NoError(err)
+	assert.Equal(s.T(), `sum by (namespace) (rate(http_requests_total{code=~"5..", job="api"}[5m])) > 1`, expr)
+
+	expr, err = BuildPromQLExpr(&models.PrometheusRuleExprBuilder{Metric: "up", Operator: "==", Threshold: 0})
+	s.Require().NoError(err)
+	assert.Equal(s.T(), "up == 0", expr)
+
+	invalid := []models.PrometheusRuleExprBuilder{
+		{Metric: "http-requests", Operator: ">"},
+		{Metric: "up", Matchers: map[string]string{"job": "~("}, Operator: ">"},
+		{Metric: "up", Function: "deriv", Operator: ">"},
+		{Metric: "up", Aggregation: "topk", Operator: ">"},
+		{Metric: "up", Operator: "=~"},
+	}
+	for i := range invalid {
+		_, err := BuildPromQLExpr(&invalid[i])
+		assert.Error(s.T(), err, invalid[i])
+	}
+}
+
+// TestBuildRuleFromTemplate 测试模板渲染与覆盖字段
+func (s *PrometheusRuleServiceTestSuite) TestBuildRuleFromTemplate() {
+	rule, err := s.svc.BuildRule(&models.PrometheusRuleBuildRequest{
+		TemplateID: "pod-crash-looping",
+		Params:     map[string]string{"threshold": "5"},
+		Severity:   "critical",
+	})
+	s.Require().NoError(err)
+	assert.Equal(s.T(), "KubePodCrashLooping", rule.Alert)
+	assert.Equal(s.T(), `increase(kube_pod_container_status_restarts_total[15m]) > 5`, rule.Expr)
+	assert.Equal(s.T(), "5m", rule.For)
+	assert.Equal(s.T(), "critical", rule.Labels["severity"])
+	// 覆盖字段不能影响模板本身
+	assert.Equal(s.T(), "warning", prometheusRuleTemplates[4].Rule.Labels["severity"])
+
+	_, err = s.svc.BuildRule(&models.PrometheusRuleBuildRequest{TemplateID: "pod-crash-looping", Params: map[string]string{"threshold": "3; drop"}})
+	assert.Error(s.T(), err)
+	_, err = s.svc.BuildRule(&models.PrometheusRuleBuildRequest{TemplateID: "not-exists"})
+	assert.Error(s.T(), err)
+
+	// 所有内置模板使用默认参数都能生成合法规则
+	for _, tmpl := range PrometheusRuleTemplates() {
+		rendered, err := RenderPrometheusRuleTemplate(tmpl.ID, nil)
+		s.Require().NoError(err, tmpl.ID)
+		assert.NotContains(s.T(), rendered.Expr, "${", tmpl.ID)
+		rule := s.newRule()
+		rule.Groups[0].Rules = []models.PrometheusAlertRule{*rendered}
+		assert.Empty(s.T(), ValidatePrometheusRule(rule), tmpl.ID)
+	}
+}
+
+// TestCRUD 测试 PrometheusRule 的增删改查
+func (s *PrometheusRuleServiceTestSuite) TestCRUD() {
+	ctx := context.Background()
+
+	created, err := s.svc.CreateRule(ctx, s.cluster, s.newRule())
+	s.Require().NoError(err)
+	assert.Equal(s.T(), "kubepolaris", created.Labels[prometheusRuleManagedByLabel])
+	s.Require().Len(created.Groups, 1)
+	assert.Equal(s.T(), "5m", created.Groups[0].Rules[0].For)
+
+	rules, err := s.svc.ListRules(ctx, s.cluster, "")
+	s.Require().NoError(err)
+	s.Require().Len(rules, 1)
+	assert.Equal(s.T(), "PaymentsHighErrorRate", rules[0].Groups[0].Rules[0].Alert)
+
+	update := s.newRule()
+	update.Labels = created.Labels
+	update.Groups[0].Rules[0].For = "10m"
+	_, err = s.svc.UpdateRule(ctx, s.cluster, update)
+	s.Require().NoError(err)
+
+	got, err := s.svc.GetRule(ctx, s.cluster, "monitoring", "payments-alerts")
+	s.Require().NoError(err)
+	assert.Equal(s.T(), "10m", got.Groups[0].Rules[0].For)
+	assert.Equal(s.T(), "30s", got.Groups[0].Interval)
+
+	s.Require().NoError(s.svc.DeleteRule(ctx, s.cluster, "monitoring", "payments-alerts"))
+	_, err = s.svc.GetRule(ctx, s.cluster, "monitoring", "payments-alerts")
+	assert.Error(s.T(), err)
+}
+
+// TestPrometheusRuleServiceSuite 运行测试套件
+func TestPrometheusRuleServiceSuite(t *testing.T) {
+	suite.Run(t, new(PrometheusRuleServiceTestSuite))
+}
@@ -0,0 +1,275 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+)
+
+// prometheusRuleTemplates 内置告警规则模板（基于 node-exporter、kube-state-metrics 与 kubelet 指标）
+var prometheusRuleTemplates = []models.PrometheusRuleTemplate{
+	{
+		ID: "node-cpu-high", Name: "节点 CPU 使用率过高", Category: "节点",
+		Description: "节点 CPU 使用率持续超过阈值",
+		Params: []models.PrometheusRuleTemplateParam{
+			{Name: "threshold", Label: "CPU 使用率", Default: "85", Unit: "%"},
+			{Name: "for", Label: "持续时间", Default: "10m"},
+		},
+		Rule: models.PrometheusAlertRule{
+			Alert:  "NodeCPUUsageHigh",
+			Expr:   `100 - (avg by (instance) (rate(node_cpu_seconds_total{mode="idle"}[5m])) * 100) > ${threshold}`,
+			For:    "${for}",
+			Labels: map[string]string{"severity": "warning"},
+			Annotations: map[string]string{
+				"summary":     "节点 {{ $labels.instance }} CPU 使用率过高",
+				"description": "节点 {{ $labels.instance }} CPU 使用率为 {{ $value | humanize }}%，超过 ${threshold}%",
+			},
+		},
+	},
+	{
+		ID: "node-memory-high", Name: "节点内存使用率过高", Category: "节点",
+		Description: "节点内存使用率持续超过阈值",
+		Params: []models.PrometheusRuleTemplateParam{
+			{Name: "threshold", Label: "内存使用率", Default: "90", Unit: "%"},
+			{Name: "for", Label: "持续时间", Default: "10m"},
+		},
+		Rule: models.PrometheusAlertRule{
+			Alert:  "NodeMemoryUsageHigh",
+			Expr:   `(1 - node_memory_MemAvailable_bytes / node_memory_MemTotal_bytes) * 100 > ${threshold}`,
+			For:    "${for}",
+			Labels: map[string]string{"severity": "warning"},
+			Annotations: map[string]string{
+				"summary":     "节点 {{ $labels.instance }} 内存使用率过高",
+				"description": "节点 {{ $labels.instance }} 内存使用率为 {{ $value | humanize }}%，超过 ${threshold}%",
+			},
+		},
+	},
+	{
+		ID: "node-disk-almost-full", Name: "节点磁盘空间不足", Category: "节点",
+		Description: "节点文件系统使用率超过阈值",
+		Params: []models.PrometheusRuleTemplateParam{
+			{Name: "threshold", Label: "磁盘使用率", Default: "85", Unit: "%"},
+			{Name: "for", Label: "持续时间", Default: "15m"},
+		},
+		Rule: models.PrometheusAlertRule{
+			Alert:  "NodeFilesystemAlmostFull",
+			Expr:   `(1 - node_filesystem_avail_bytes{fstype!~"tmpfs|overlay|squashfs"} / node_filesystem_size_bytes{fstype!~"tmpfs|overlay|squashfs"}) * 100 > ${threshold}`,
+			For:    "${for}",
+			Labels: map[string]string{"severity": "warning"},
+			Annotations: map[string]string{
+				"summary":     "节点 {{ $labels.instance }} 磁盘 {{ $labels.mountpoint }} 空间不足",
+				"description": "磁盘使用率为 {{ $value | humanize }}%，超过 ${threshold}%",
+			},
+		},
+	},
+	{
+		ID: "node-not-ready", Name: "节点 NotReady", Category: "节点",
+		Description: "节点 Ready 状态持续为 false 或 unknown",
+		Params: []models.PrometheusRuleTemplateParam{
+			{Name: "for", Label: "持续时间", Default: "5m"},
+		},
+		Rule: models.PrometheusAlertRule{
+			Alert:  "KubeNodeNotReady",
+			Expr:   `kube_node_status_condition{condition="Ready",status="true"} == 0`,
+			For:    "${for}",
+			Labels: map[string]string{"severity": "critical"},
+			Annotations: map[string]string{
+				"summary":     "节点 {{ $labels.node }} 处于 NotReady 状态",
+				"description": "节点 {{ $labels.node }} 已超过 ${for} 未就绪",
+			},
+		},
+	},
+	{
+		ID: "pod-crash-looping", Name: "容器频繁重启", Category: "工作负载",
+		Description: "15 分钟内容器重启次数超过阈值",
+		Params: []models.PrometheusRuleTemplateParam{
+			{Name: "threshold", Label: "重启次数", Default: "3", Unit: "次"},
+			{Name: "for", Label: "持续时间", Default: "5m"},
+		},
+		Rule: models.PrometheusAlertRule{
+			Alert:  "KubePodCrashLooping",
+			Expr:   `increase(kube_pod_container_status_restarts_total[15m]) > ${threshold}`,
+			For:    "${for}",
+			Labels: map[string]string{"severity": "warning"},
+			Annotations: map[string]string{
+				"summary":     "容器 {{ $labels.namespace }}/{{ $labels.pod }}/{{ $labels.container }} 频繁重启",
+				"description": "15 分钟内重启 {{ $value | humanize }} 次",
+			},
+		},
+	},
+	{
+		ID: "pod-not-ready", Name: "Pod 长时间未就绪", Category: "工作负载",
+		Description: "Pod 持续处于 Pending / Unknown / Failed 状态",
+		Params: []models.PrometheusRuleTemplateParam{
+			{Name: "for", Label: "持续时间", Default: "15m"},
+		},
+		Rule: models.PrometheusAlertRule{
+			Alert:  "KubePodNotReady",
+			Expr:   `sum by (namespace, pod) (kube_pod_status_phase{phase=~"Pending|Unknown|Failed"}) > 0`,
+			For:    "${for}",
+			Labels: map[string]string{"severity": "warning"},
+			Annotations: map[string]string{
+				"summary":     "Pod {{ $labels.namespace }}/{{ $labels.pod }} 未就绪",
+				"description": "Pod 已超过 ${for} 处于非运行状态",
+			},
+		},
+	},
+	{
+		ID: "container-oom-killed", Name: "容器 OOMKilled", Category: "工作负载",
+		Description: "容器因内存超限被终止",
+		Rule: models.PrometheusAlertRule{
+			Alert:  "KubeContainerOOMKilled",
+			Expr:   `increase(kube_pod_container_status_restarts_total[10m]) > 0 and on (namespace, pod, container) kube_pod_container_status_last_terminated_reason{reason="OOMKilled"} == 1`,
+			Labels: map[string]string{"severity": "warning"},
+			Annotations: map[string]string{
+				"summary":     "容器 {{ $labels.namespace }}/{{ $labels.pod }}/{{ $labels.container }} 被 OOMKilled",
+				"description": "容器内存超过 limit 被终止，请检查内存限制或内存泄漏",
+			},
+		},
+	},
+	{
+		ID: "container-cpu-throttling", Name: "容器 CPU 限流", Category: "工作负载",
+		Description: "容器 CPU 被限流的周期占比超过阈值",
+		Params: []models.PrometheusRuleTemplateParam{
+			{Name: "threshold", Label: "限流占比", Default: "25", Unit: "%"},
+			{Name: "for", Label: "持续时间", Default: "15m"},
+		},
+		Rule: models.PrometheusAlertRule{
+			Alert:  "KubeContainerCPUThrottling",
+			Expr:   `sum by (namespace, pod, container) (increase(container_cpu_cfs_throttled_periods_total{container!=""}[5m])) / sum by (namespace, pod, container) (increase(container_cpu_cfs_periods_total{container!=""}[5m])) * 100 > ${threshold}`,
+			For:    "${for}",
+			Labels: map[string]string{"severity": "info"},
+			Annotations: map[string]string{
+				"summary":     "容器 {{ $labels.namespace }}/{{ $labels.pod }}/{{ $labels.container }} CPU 限流",
+				"description": "{{ $value | humanize }}% 的 CPU 周期被限流",
+			},
+		},
+	},
+	{
+		ID: "deployment-replicas-mismatch", Name: "Deployment 副本不足", Category: "工作负载",
+		Description: "Deployment 可用副本数持续低于期望副本数",
+		Params: []models.PrometheusRuleTemplateParam{
+			{Name: "for", Label: "持续时间", Default: "15m"},
+		},
+		Rule: models.PrometheusAlertRule{
+			Alert:  "KubeDeploymentReplicasMismatch",
+			Expr:   `kube_deployment_spec_replicas > kube_deployment_status_replicas_available`,
+			For:    "${for}",
+			Labels: map[string]string{"severity": "warning"},
+			Annotations: map[string]string{
+				"summary":     "Deployment {{ $labels.namespace }}/{{ $labels.deployment }} 副本不足",
+				"description": "可用副本数已超过 ${for} 低于期望值",
+			},
+		},
+	},
+	{
+		ID: "job-failed", Name: "Job 执行失败", Category: "工作负载",
+		Description: "Job 存在失败的 Pod",
+		Rule: models.PrometheusAlertRule{
+			Alert:  "KubeJobFailed",
+			Expr:   `kube_job_status_failed > 0`,
+			Labels: map[string]string{"severity": "warning"},
+			Annotations: map[string]string{
+				"summary":     "Job {{ $labels.namespace }}/{{ $labels.job_name }} 执行失败",
+				"description": "Job 有 {{ $value }} 个失败的 Pod",
+			},
+		},
+	},
+	{
+		ID: "pvc-almost-full", Name: "PVC 空间不足", Category: "存储",
+		Description: "持久卷使用率超过阈值",
+		Params: []models.PrometheusRuleTemplateParam{
+			{Name: "threshold", Label: "使用率", Default: "85", Unit: "%"},
+			{Name: "for", Label: "持续时间", Default: "10m"},
+		},
+		Rule: models.PrometheusAlertRule{
+			Alert:  "KubePersistentVolumeFillingUp",
+			Expr:   `kubelet_volume_stats_used_bytes / kubelet_volume_stats_capacity_bytes * 100 > ${threshold}`,
+			For:    "${for}",
+			Labels: map[string]string{"severity": "warning"},
+			Annotations: map[string]string{
+				"summary":     "PVC {{ $labels.namespace }}/{{ $labels.persistentvolumeclaim }} 空间不足",
+				"description": "使用率为 {{ $value | humanize }}%，超过 ${threshold}%",
+			},
+		},
+	},
+	{
+		ID: "apiserver-error-rate", Name: "APIServer 错误率过高", Category: "控制面",
+		Description: "APIServer 5xx 请求占比超过阈值",
+		Params: []models.PrometheusRuleTemplateParam{
+			{Name: "threshold", Label: "错误率", Default: "5", Unit: "%"},
+			{Name: "for", Label: "持续时间", Default: "10m"},
+		},
+		Rule: models.PrometheusAlertRule{
+			Alert:  "KubeAPIServerErrorRateHigh",
+			Expr:   `sum(rate(apiserver_request_total{code=~"5.."}[5m])) / sum(rate(apiserver_request_total[5m])) * 100 > ${threshold}`,
+			For:    "${for}",
+			Labels: map[string]string{"severity": "critical"},
+			Annotations: map[string]string{
+				"summary":     "APIServer 错误率过高",
+				"description": "APIServer 5xx 请求占比为 {{ $value | humanize }}%，超过 ${threshold}%",
+			},
+		},
+	},
+}
+
+// PrometheusRuleTemplates 获取内置告警规则模板
+func PrometheusRuleTemplates() []models.PrometheusRuleTemplate {
+	return prometheusRuleTemplates
+}
+
+// RenderPrometheusRuleTemplate 使用参数渲染模板，未提供的参数使用默认值
+func RenderPrometheusRuleTemplate(id string, params map[string]string) (*models.PrometheusAlertRule, error) {
+	var tmpl *models.PrometheusRuleTemplate
+	for i := range prometheusRuleTemplates {
+		if prometheusRuleTemplates[i].ID == id {
+			tmpl = &prometheusRuleTemplates[i]
+			break
+		}
+	}
+	if tmpl == nil {
+		return nil, fmt.Errorf("告警规则模板不存在: %s", id)
+	}
+
+	values := make(map[string]string, len(tmpl.Params))
+	for _, param := range tmpl.Params {
+		values[param.Name] = param.Default
+		if v, ok := params[param.Name]; ok && v != "" {
+			values[param.Name] = v
+		}
+	}
+	if v := values["for"]; v != "" {
+		if _, err := ParsePromDuration(v); err != nil {
+			return nil, err
+		}
+	}
+	if v, ok := values["threshold"]; ok {
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			return nil, fmt.Errorf("阈值必须是数字: %s", v)
+		}
+	}
+	render := func(s string) string {
+		return promTemplateParam.ReplaceAllStringFunc(s, func(m string) string {
+			if v, ok := values[promTemplateParam.FindStringSubmatch(m)[1]]; ok {
+				return v
+			}
+			return m
+		})
+	}
+
+	rule := &models.PrometheusAlertRule{
+		Alert:       tmpl.Rule.Alert,
+		Expr:        render(tmpl.Rule.Expr),
+		For:         render(tmpl.Rule.For),
+		Labels:      make(map[string]string, len(tmpl.Rule.Labels)),
+		Annotations: make(map[string]string, len(tmpl.Rule.Annotations)),
+	}
+	for k, v := range tmpl.Rule.Labels {
+		rule.Labels[k] = render(v)
+	}
+	for k, v := range tmpl.Rule.Annotations {
+		rule.Annotations[k] = render(v)
+	}
+	return rule, nil
+}
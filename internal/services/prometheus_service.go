@@ -580,7 +580,6 @@ func (s *PrometheusService) parseTimeRange(timeRange string) (int64, int64, erro
 }
 
 // buildClusterSelector 构建集群标签选择器
-//nolint:unused // 保留用于未来使用
 func (s *PrometheusService) buildClusterSelector(labels map[string]string, clusterName string) string {
 	selectors := []string{}
 
@@ -1111,6 +1110,76 @@ func (s *PrometheusService) TestConnection(ctx context.Context, config *models.M
 	return nil
 }
 
+// PrometheusAPIError Prometheus HTTP API 返回的错误，ErrorType 为 bad_data 时表示查询语句有误
+type PrometheusAPIError struct {
+	ErrorType string `json:"errorType"`
+	Message   string `json:"error"`
+}
+
+func (e *PrometheusAPIError) Error() string {
+	return fmt.Sprintf("%s: %s", e.ErrorType, e.Message)
+}
+
+// QueryInstant 即时查询，Prometheus 返回的错误以 *PrometheusAPIError 返回
+func (s *PrometheusService) QueryInstant(ctx context.Context, config *models.MonitoringConfig, query string, t time.Time) (*models.MetricsResponse, error) {
+	if config.Type == "disabled" {
+		return nil, fmt.Errorf("监控功能已禁用")
+	}
+
+	// 构建查询 URL
+	queryURL, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("无效的监控端点: %w", err)
+	}
+	queryURL.Path = "/api/v1/query"
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("time", strconv.FormatInt(t.Unix(), 10))
+	queryURL.RawQuery = params.Encode()
+
+	// 创建请求
+	req, err := http.NewRequestWithContext(ctx, "GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+
+	// 设置认证
+	if err := s.setAuth(req, config.Auth); err != nil {
+		return nil, fmt.Errorf("设置认证失败: %w", err)
+	}
+
+	// 执行请求
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("执行请求失败: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	// 读取响应
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		apiErr := &PrometheusAPIError{}
+		if json.Unmarshal(body, apiErr) == nil && apiErr.ErrorType != "" {
+			return nil, apiErr
+		}
+		return nil, fmt.Errorf("查询失败: %s, 状态码: %d", string(body), resp.StatusCode)
+	}
+
+	// 解析响应
+	var result models.MetricsResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+
+	return &result, nil
+}
+
 // queryPodNetworkPPS 查询 Pod 网络PPS指标
 func (s *PrometheusService) queryPodNetworkPPS(ctx context.Context, config *models.MonitoringConfig, selector string, start, end int64, step string) (*models.NetworkPPS, error) {
 	// 查询入站PPS
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+)
+
+// promqlAggregators 聚合运算符，后面可以跟 by/without 子句再跟括号
+var promqlAggregators = map[string]bool{
+	"sum": true, "min": true, "max": true, "avg": true, "group": true, "stddev": true, "stdvar": true,
+	"count": true, "count_values": true, "bottomk": true, "topk": true, "quantile": true,
+	"limitk": true, "limit_ratio": true,
+}
+
+// promqlOperatorKeywords 跟在操作数之后时作为二元运算符或 offset 修饰符的关键字；
+// 出现在操作数位置时与其他关键字一样按指标名处理
+var promqlOperatorKeywords = map[string]bool{
+	"and": true, "or": true, "unless": true, "atan2": true, "offset": true,
+}
+
+// promqlNumberKeywords 数字字面量 Inf、NaN（不区分大小写），不会选择任何序列
+var promqlNumberKeywords = map[string]bool{
+	"inf": true, "nan": true,
+}
+
+// promqlLabelListKeywords 后面括号中是标签名列表的关键字
+var promqlLabelListKeywords = map[string]bool{
+	"by": true, "without": true, "on": true, "ignoring": true, "group_left": true, "group_right": true,
+}
+
+// InjectPromQLSelector 为 PromQL 中的每个向量选择器追加标签匹配器（如 cluster="prod"），用于把查询限定在当前集群。
+// 只做词法扫描：跳过字符串、注释、区间 / 子查询方括号、函数名与 by/on 等子句中的标签名，
+// 将指标名后或独立的 {...} 视为向量选择器；sum、by、offset 等关键字出现在操作数位置且后面不是括号时同样按指标名处理。
+// 选择器、标签列表与方括号内不允许注释。selector 为空时原样返回
+func InjectPromQLSelector(query, selector string) (string, error) {
+	selector = strings.TrimSpace(selector)
+	if selector == "" {
+		return query, nil
+	}
+
+	var out strings.Builder
+	n := len(query)
+	i := 0
+
+	// writeBraces 输出从 query[start] 的 '{' 开始的匹配器并追加 selector，返回 '}' 之后的位置
+	writeBraces := func(start int) (int, error) {
+		j := start + 1
+		for j < n && query[j] != '}' {
+			switch query[j] {
+			case '"', '\'', '`':
+				end, err := skipPromQLString(query, j)
+				if err != nil {
+					return 0, err
+				}
+				j = end
+				continue
+			case '#':
+				return 0, fmt.Errorf("标签选择器中不支持注释")
+			}
+			if isPromQLIdentStart(query[j]) {
+				k := j
+				for k < n && isPromQLIdentChar(query[k]) {
+					k++
+				}
+				if strings.EqualFold(query[j:k], "or") {
+					return 0, fmt.Errorf("标签选择器中不支持 or，请拆分为多个选择器")
+				}
+				j = k
+				continue
+			}
+			j++
+		}
+		if j >= n {
+			return 0, fmt.Errorf("标签选择器缺少 }")
+		}
+		inner := strings.TrimSpace(query[start+1 : j])
+		inner = strings.TrimSuffix(inner, ",")
+		out.WriteByte('{')
+		if inner != "" {
+			out.WriteString(inner)
+			out.WriteByte(',')
+		}
+		out.WriteString(selector)
+		out.WriteByte('}')
+		return j + 1, nil
+	}
+
+	// expectOperand 当前位置是否应出现操作数，用于区分关键字是运算符还是指标名；
+	// afterJoin 上一个记号是否为 on/ignoring 标签列表，其后的 group_left/group_right 可以不带括号
+	expectOperand := true
+	afterJoin := false
+	for i < n {
+		c := query[i]
+		joinModifier := afterJoin
+		if !isPromQLSpace(c) {
+			afterJoin = false
+		}
+		switch {
+		case c == '"' || c == '\'' || c == '`':
+			end, err := skipPromQLString(query, i)
+			if err != nil {
+				return "", err
+			}
+			out.WriteString(query[i:end])
+			i = end
+			expectOperand = false
+		case c == '#':
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = n - i
+			}
+			out.WriteString(query[i : i+end])
+			i += end
+		case c == '[':
+			end := strings.IndexByte(query[i:], ']')
+			if end < 0 {
+				return "", fmt.Errorf("区间选择器缺少 ]")
+			}
+			if strings.IndexByte(query[i:i+end], '#') >= 0 {
+				return "", fmt.Errorf("区间选择器中不支持注释")
+			}
+			out.WriteString(query[i : i+end+1])
+			i += end + 1
+			expectOperand = false
+		case c == '{':
+			next, err := writeBraces(i)
+			if err != nil {
+				return "", err
+			}
+			i = next
+			expectOperand = false
+		case c >= '0' && c <= '9' || c == '.' && i+1 < n && query[i+1] >= '0' && query[i+1] <= '9':
+			// 数字与时长（5m、1e3、0x1f）
+			j := i
+			for j < n && (isPromQLIdentChar(query[j]) || query[j] == '.') {
+				j++
+			}
+			out.WriteString(query[i:j])
+			i = j
+			expectOperand = false
+		case isPromQLIdentStart(c):
+			j := i
+			for j < n && isPromQLIdentChar(query[j]) {
+				j++
+			}
+			ident := query[i:j]
+			k := j
+			for k < n && isPromQLSpace(query[k]) {
+				k++
+			}
+			lower := strings.ToLower(ident)
+			afterComparison := strings.ContainsAny(lastPromQLChar(out.String()), "=<>")
+			out.WriteString(ident)
+			switch {
+			case promqlLabelListKeywords[lower] && k < n && query[k] == '(':
+				// by (a, b) 等标签列表原样输出
+				end := strings.IndexByte(query[k:], ')')
+				if end < 0 {
+					return "", fmt.Errorf("标签列表缺少 )")
+				}
+				if strings.IndexByte(query[k:k+end], '#') >= 0 {
+					return "", fmt.Errorf("标签列表中不支持注释")
+				}
+				out.WriteString(query[j : k+end+1])
+				j = k + end + 1
+				afterJoin = lower == "on" || lower == "ignoring"
+				expectOperand = lower != "by" && lower != "without"
+			case joinModifier && (lower == "group_left" || lower == "group_right"):
+				// 不带标签列表的 group_left/group_right
+			case !expectOperand && promqlOperatorKeywords[lower]:
+				expectOperand = true
+			case expectOperand && lower == "bool" && afterComparison:
+				// 比较运算符的 bool 修饰符
+			case promqlNumberKeywords[lower]:
+				expectOperand = false
+			case k < n && query[k] == '(':
+				// 函数调用或聚合
+			case promqlAggregators[lower] && promqlLabelListKeywords[strings.ToLower(peekPromQLIdent(query, k))]:
+				// sum by (...) (...)
+			case k < n && query[k] == '{':
+				out.WriteString(query[j:k])
+				next, err := writeBraces(k)
+				if err != nil {
+					return "", err
+				}
+				j = next
+				expectOperand = false
+			default:
+				out.WriteByte('{')
+				out.WriteString(selector)
+				out.WriteByte('}')
+				expectOperand = false
+			}
+			i = j
+		default:
+			switch {
+			case c == '(' || c == ',' || strings.IndexByte("+-*/%^=!<>~@", c) >= 0:
+				expectOperand = true
+			case c == ')':
+				expectOperand = false
+			}
+			out.WriteByte(c)
+			i++
+		}
+	}
+
+	return out.String(), nil
+}
+
+// skipPromQLString 返回从 query[start] 开始的字符串字面量结束后的位置
+func skipPromQLString(query string, start int) (int, error) {
+	quote := query[start]
+	for j := start + 1; j < len(query); j++ {
+		if query[j] == '\\' && quote != '`' {
+			j++
+			continue
+		}
+		if query[j] == quote {
+			return j + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("字符串缺少结束引号")
+}
+
+// peekPromQLIdent 返回从 query[start] 开始的标识符，不是标识符时返回空串
+func peekPromQLIdent(query string, start int) string {
+	j := start
+	for j < len(query) && isPromQLIdentChar(query[j]) {
+		j++
+	}
+	if j == start || !isPromQLIdentStart(query[start]) {
+		return ""
+	}
+	return query[start:j]
+}
+
+// lastPromQLChar 返回去掉末尾空白后的最后一个字符
+func lastPromQLChar(s string) string {
+	s = strings.TrimRight(s, " \t\r\n")
+	if s == "" {
+		return ""
+	}
+	return s[len(s)-1:]
+}
+
+func isPromQLIdentStart(c byte) bool {
+	return c == '_' || c == ':' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isPromQLIdentChar(c byte) bool {
+	return isPromQLIdentStart(c) || c >= '0' && c <= '9'
+}
+
+func isPromQLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
@@ -0,0 +1,436 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// 规格建议参数
+const (
+	RightsizingDefaultWindow = 7 * 24 * time.Hour
+	rightsizingMinWindow     = time.Hour
+	rightsizingMaxWindow     = 30 * 24 * time.Hour
+
+	rightsizingRequestHeadroom = 1.15     // request 在 P95 基础上预留的余量
+	rightsizingLimitHeadroom   = 1.3      // limit 在 P99 / 峰值基础上预留的余量
+	rightsizingOOMLimitFactor  = 1.5      // 发生 OOM 或严重限流时 limit 至少在当前基础上放大的倍数
+	rightsizingMinCPU          = 0.01     // 建议的最小 CPU request（10m）
+	rightsizingMinMemory       = 32 << 20 // 建议的最小内存 request（32Mi）
+	rightsizingCPUStep         = 0.005    // CPU 建议值向上取整到 5m
+	rightsizingMemoryStep      = 1 << 20  // 内存建议值向上取整到 1Mi
+
+	rightsizingOverRatio       = 0.5 // P95 低于 request 的该比例视为资源过剩
+	rightsizingThrottlePercent = 25  // CPU 限流周期占比超过该值视为资源不足
+	rightsizingMemoryNearLimit = 0.9 // 内存 P99 超过 limit 的该比例视为有 OOM 风险
+)
+
+// rightsizingStatusPriority 工作负载状态取其容器中最严重的一个
+var rightsizingStatusPriority = map[string]int{
+	models.RightsizingNoData:           0,
+	models.RightsizingOptimal:          1,
+	models.RightsizingOverProvisioned:  2,
+	models.RightsizingUnderProvisioned: 3,
+	models.RightsizingOOMProne:         4,
+}
+
+// rightsizingWorkload 待分析的工作负载
+type rightsizingWorkload struct {
+	kind       string
+	namespace  string
+	name       string
+	replicas   int32
+	containers []corev1.Container
+}
+
+// RightsizingService 基于历史用量的工作负载资源规格建议服务
+type RightsizingService struct {
+	prometheusSvc *PrometheusService
+
+	// clientFor 获取集群客户端，profiles 查询容器用量画像，测试时可替换
+	clientFor func(clusterID uint) (kubernetes.Interface, error)
+	profiles  func(ctx context.Context, config *models.MonitoringConfig, selector string, window time.Duration) ([]models.ContainerUsageProfile, error)
+}
+
+// NewRightsizingService 创建规格建议服务
+func NewRightsizingService(clusterService *ClusterService, prometheusSvc *PrometheusService) *RightsizingService {
+	return &RightsizingService{
+		prometheusSvc: prometheusSvc,
+		clientFor: func(clusterID uint) (kubernetes.Interface, error) {
+			cluster, err := clusterService.GetCluster(clusterID)
+			if err != nil {
+				return nil, err
+			}
+			client, err := NewK8sClientForCluster(cluster)
+			if err != nil {
+				return nil, err
+			}
+			return client.GetClientset(), nil
+		},
+		profiles: prometheusSvc.QueryContainerUsageProfiles,
+	}
+}
+
+// WorkloadRecommendation 计算单个工作负载的规格建议，kind 支持 deployments、statefulsets、daemonsets
+func (s *RightsizingService) WorkloadRecommendation(ctx context.Context, clusterID uint, config *models.MonitoringConfig, kind, namespace, name string, window time.Duration) (*models.WorkloadRightsizing, error) {
+	if err := validateRightsizingWindow(window); err != nil {
+		return nil, err
+	}
+	client, err := s.clientFor(clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("获取集群客户端失败: %w", err)
+	}
+	workload, err := getRightsizingWorkload(ctx, client, kind, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	pattern := rightsizingPodPattern(workload.kind, workload.name)
+	profiles, err := s.profiles(ctx, config, rightsizingPodSelector(namespace, pattern), window)
+	if err != nil {
+		return nil, fmt.Errorf("查询历史用量失败: %w", err)
+	}
+	result := RecommendWorkload(workload.kind, namespace, name, workload.replicas, workload.containers, profiles)
+	result.Window = formatRightsizingWindow(window)
+	return result, nil
+}
+
+// NamespaceRecommendations 计算命名空间内所有 Deployment / StatefulSet / DaemonSet 的规格建议，按可回收 CPU 降序
+func (s *RightsizingService) NamespaceRecommendations(ctx context.Context, clusterID uint, config *models.MonitoringConfig, namespace string, window time.Duration) (*models.RightsizingReport, error) {
+	if err := validateRightsizingWindow(window); err != nil {
+		return nil, err
+	}
+	client, err := s.clientFor(clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("获取集群客户端失败: %w", err)
+	}
+	workloads, err := listRightsizingWorkloads(ctx, client, namespace)
+	if err != nil {
+		return nil, err
+	}
+	profiles, err := s.profiles(ctx, config, rightsizingPodSelector(namespace, ""), window)
+	if err != nil {
+		return nil, fmt.Errorf("查询历史用量失败: %w", err)
+	}
+
+	report := &models.RightsizingReport{
+		Namespace: namespace,
+		Window:    formatRightsizingWindow(window),
+		Workloads: make([]models.WorkloadRightsizing, 0, len(workloads)),
+	}
+	for _, w := range workloads {
+		re := regexp.MustCompile("^(?:" + rightsizingPodPattern(w.kind, w.name) + ")$")
+		var matched []models.ContainerUsageProfile
+		for _, p := range profiles {
+			if re.MatchString(p.Pod) {
+				matched = append(matched, p)
+			}
+		}
+		result := RecommendWorkload(w.kind, namespace, w.name, w.replicas, w.containers, matched)
+		result.Window = report.Window
+		report.Workloads = append(report.Workloads, *result)
+		report.ReclaimCPU += result.ReclaimCPU
+		report.ReclaimMemory += result.ReclaimMemory
+	}
+	report.ReclaimCPU = roundTo(report.ReclaimCPU, 3)
+
+	sort.SliceStable(report.Workloads, func(i, j int) bool {
+		return report.Workloads[i].ReclaimCPU > report.Workloads[j].ReclaimCPU
+	})
+	return report, nil
+}
+
+// RecommendWorkload 根据容器规格与用量画像生成工作负载的规格建议与 patch
+func RecommendWorkload(kind, namespace, name string, replicas int32, containers []corev1.Container, profiles []models.ContainerUsageProfile) *models.WorkloadRightsizing {
+	byContainer := make(map[string][]models.ContainerUsageProfile)
+	for _, p := range profiles {
+		byContainer[p.Container] = append(byContainer[p.Container], p)
+	}
+
+	result := &models.WorkloadRightsizing{
+		Kind:       kind,
+		Namespace:  namespace,
+		Name:       name,
+		Replicas:   replicas,
+		Status:     models.RightsizingNoData,
+		Containers: make([]models.ContainerRightsizing, 0, len(containers)),
+	}
+	for _, container := range containers {
+		rec := RecommendContainer(container.Name, containerResourceValues(container), byContainer[container.Name])
+		if rightsizingStatusPriority[rec.Status] > rightsizingStatusPriority[result.Status] {
+			result.Status = rec.Status
+		}
+		if rec.Status != models.RightsizingNoData {
+			result.ReclaimCPU += (rec.Current.CPURequest - rec.Recommended.CPURequest) * float64(replicas)
+			result.ReclaimMemory += (rec.Current.MemoryRequest - rec.Recommended.MemoryRequest) * float64(replicas)
+		}
+		result.Containers = append(result.Containers, rec)
+	}
+	result.ReclaimCPU = roundTo(result.ReclaimCPU, 3)
+
+	if patch := rightsizingPatch(result.Containers); patch != "" {
+		result.Patch = patch
+		result.Command = fmt.Sprintf("kubectl -n %s patch %s %s --type strategic -p '%s'", namespace, strings.ToLower(kind), name, patch)
+	}
+	return result
+}
+
+// RecommendContainer 汇总容器在各 Pod 上的用量画像（取最大值），给出建议规格与分类
+func RecommendContainer(name string, current models.ContainerResourceValues, profiles []models.ContainerUsageProfile) models.ContainerRightsizing {
+	rec := models.ContainerRightsizing{
+		Name:        name,
+		Pods:        len(profiles),
+		Current:     current,
+		Recommended: current,
+		Status:      models.RightsizingNoData,
+		Reasons:     []string{},
+	}
+	if len(profiles) == 0 {
+		rec.Reasons = append(rec.Reasons, "统计窗口内没有用量数据")
+		return rec
+	}
+	for _, p := range profiles {
+		rec.CPUP95 = math.Max(rec.CPUP95, p.CPUP95)
+		rec.CPUP99 = math.Max(rec.CPUP99, p.CPUP99)
+		rec.MemoryP95 = math.Max(rec.MemoryP95, p.MemoryP95)
+		rec.MemoryP99 = math.Max(rec.MemoryP99, p.MemoryP99)
+		rec.MemoryMax = math.Max(rec.MemoryMax, p.MemoryMax)
+		rec.CPUThrottling = math.Max(rec.CPUThrottling, p.CPUThrottling)
+		rec.OOMKills += p.OOMKills
+	}
+
+	// 建议值：request 覆盖 P95，limit 覆盖 P99 与峰值；原本未设置 limit 的不主动添加
+	recommended := models.ContainerResourceValues{
+		CPURequest:    roundUp(math.Max(rec.CPUP95*rightsizingRequestHeadroom, rightsizingMinCPU), rightsizingCPUStep),
+		MemoryRequest: roundUp(math.Max(rec.MemoryP95*rightsizingRequestHeadroom, rightsizingMinMemory), rightsizingMemoryStep),
+	}
+	if current.CPULimit > 0 {
+		limit := rec.CPUP99 * rightsizingLimitHeadroom
+		if rec.CPUThrottling >= rightsizingThrottlePercent {
+			// 严重限流时实际用量被 limit 截断，P99 不能反映真实需求
+			limit = math.Max(limit, current.CPULimit*rightsizingOOMLimitFactor)
+		}
+		recommended.CPULimit = roundUp(math.Max(limit, recommended.CPURequest), rightsizingCPUStep)
+	}
+	if current.MemoryLimit > 0 {
+		limit := math.Max(rec.MemoryMax, rec.MemoryP99) * rightsizingLimitHeadroom
+		if rec.OOMKills > 0 {
+			// 发生 OOM 时内存用量同样被 limit 截断
+			limit = math.Max(limit, current.MemoryLimit*rightsizingOOMLimitFactor)
+		}
+		recommended.MemoryLimit = roundUp(math.Max(limit, recommended.MemoryRequest), rightsizingMemoryStep)
+	}
+	rec.Recommended = recommended
+
+	// 分类：OOM 风险 > 资源不足 > 资源过剩
+	var oom, under, over []string
+	if rec.OOMKills > 0 {
+		oom = append(oom, fmt.Sprintf("统计窗口内发生 %.0f 次 OOM Kill", rec.OOMKills))
+	}
+	if current.MemoryLimit > 0 && rec.MemoryP99 >= current.MemoryLimit*rightsizingMemoryNearLimit {
+		oom = append(oom, fmt.Sprintf("内存 P99 已达 limit 的 %.0f%%", rec.MemoryP99/current.MemoryLimit*100))
+	}
+	if rec.CPUThrottling >= rightsizingThrottlePercent {
+		under = append(under, fmt.Sprintf("CPU 限流周期占比 %.1f%%", rec.CPUThrottling))
+	}
+	under = append(under, requestShortage("CPU", current.CPURequest, rec.CPUP95)...)
+	under = append(under, requestShortage("内存", current.MemoryRequest, rec.MemoryP95)...)
+	if current.CPURequest > 0 && rec.CPUP95 < current.CPURequest*rightsizingOverRatio {
+		over = append(over, fmt.Sprintf("CPU P95 仅为 request 的 %.0f%%", rec.CPUP95/current.CPURequest*100))
+	}
+	if current.MemoryRequest > 0 && rec.MemoryP95 < current.MemoryRequest*rightsizingOverRatio {
+		over = append(over, fmt.Sprintf("内存 P95 仅为 request 的 %.0f%%", rec.MemoryP95/current.MemoryRequest*100))
+	}
+
+	switch {
+	case len(oom) > 0:
+		rec.Status = models.RightsizingOOMProne
+	case len(under) > 0:
+		rec.Status = models.RightsizingUnderProvisioned
+	case len(over) > 0:
+		rec.Status = models.RightsizingOverProvisioned
+	default:
+		rec.Status = models.RightsizingOptimal
+	}
+	rec.Reasons = append(append(append(rec.Reasons, oom...), under...), over...)
+	return rec
+}
+
+// requestShortage 未设置 request 或 P95 超过 request 时返回原因
+func requestShortage(resourceName string, request, p95 float64) []string {
+	if request <= 0 {
+		return []string{fmt.Sprintf("未设置 %s request", resourceName)}
+	}
+	if p95 > request {
+		return []string{fmt.Sprintf("%s P95 超过 request 的 %.0f%%", resourceName, p95/request*100-100)}
+	}
+	return nil
+}
+
+// rightsizingPatch 生成只包含需要调整的容器资源的 strategic merge patch
+func rightsizingPatch(containers []models.ContainerRightsizing) string {
+	var patches []map[string]interface{}
+	for _, c := range containers {
+		if c.Status == models.RightsizingNoData || c.Recommended == c.Current {
+			continue
+		}
+		resources := map[string]interface{}{
+			"requests": map[string]string{
+				"cpu":    formatCPUQuantity(c.Recommended.CPURequest),
+				"memory": formatMemoryQuantity(c.Recommended.MemoryRequest),
+			},
+		}
+		limits := map[string]string{}
+		if c.Recommended.CPULimit > 0 {
+			limits["cpu"] = formatCPUQuantity(c.Recommended.CPULimit)
+		}
+		if c.Recommended.MemoryLimit > 0 {
+			limits["memory"] = formatMemoryQuantity(c.Recommended.MemoryLimit)
+		}
+		if len(limits) > 0 {
+			resources["limits"] = limits
+		}
+		patches = append(patches, map[string]interface{}{"name": c.Name, "resources": resources})
+	}
+	if len(patches) == 0 {
+		return ""
+	}
+	patch := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{"containers": patches},
+			},
+		},
+	}
+	data, _ := json.Marshal(patch)
+	return string(data)
+}
+
+func containerResourceValues(c corev1.Container) models.ContainerResourceValues {
+	values := models.ContainerResourceValues{}
+	if q, ok := c.Resources.Requests[corev1.ResourceCPU]; ok {
+		values.CPURequest = quantityValue(q, corev1.ResourceCPU)
+	}
+	if q, ok := c.Resources.Limits[corev1.ResourceCPU]; ok {
+		values.CPULimit = quantityValue(q, corev1.ResourceCPU)
+	}
+	if q, ok := c.Resources.Requests[corev1.ResourceMemory]; ok {
+		values.MemoryRequest = quantityValue(q, corev1.ResourceMemory)
+	}
+	if q, ok := c.Resources.Limits[corev1.ResourceMemory]; ok {
+		values.MemoryLimit = quantityValue(q, corev1.ResourceMemory)
+	}
+	return values
+}
+
+// getRightsizingWorkload 读取工作负载的副本数与容器规格
+func getRightsizingWorkload(ctx context.Context, client kubernetes.Interface, kind, namespace, name string) (*rightsizingWorkload, error) {
+	switch strings.ToLower(kind) {
+	case "deployments", "deployment":
+		d, err := client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("获取 Deployment 失败: %w", err)
+		}
+		return &rightsizingWorkload{kind: "Deployment", namespace: namespace, name: name, replicas: replicasOrOne(d.Spec.Replicas), containers: d.Spec.Template.Spec.Containers}, nil
+	case "statefulsets", "statefulset":
+		sts, err := client.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("获取 StatefulSet 失败: %w", err)
+		}
+		return &rightsizingWorkload{kind: "StatefulSet", namespace: namespace, name: name, replicas: replicasOrOne(sts.Spec.Replicas), containers: sts.Spec.Template.Spec.Containers}, nil
+	case "daemonsets", "daemonset":
+		ds, err := client.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("获取 DaemonSet 失败: %w", err)
+		}
+		return &rightsizingWorkload{kind: "DaemonSet", namespace: namespace, name: name, replicas: ds.Status.DesiredNumberScheduled, containers: ds.Spec.Template.Spec.Containers}, nil
+	}
+	return nil, fmt.Errorf("不支持的工作负载类型: %s", kind)
+}
+
+// listRightsizingWorkloads 列出命名空间内的 Deployment、StatefulSet 与 DaemonSet
+func listRightsizingWorkloads(ctx context.Context, client kubernetes.Interface, namespace string) ([]rightsizingWorkload, error) {
+	var workloads []rightsizingWorkload
+	deployments, err := client.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取 Deployment 列表失败: %w", err)
+	}
+	for _, d := range deployments.Items {
+		workloads = append(workloads, rightsizingWorkload{kind: "Deployment", namespace: namespace, name: d.Name, replicas: replicasOrOne(d.Spec.Replicas), containers: d.Spec.Template.Spec.Containers})
+	}
+	statefulSets, err := client.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取 StatefulSet 列表失败: %w", err)
+	}
+	for _, sts := range statefulSets.Items {
+		workloads = append(workloads, rightsizingWorkload{kind: "StatefulSet", namespace: namespace, name: sts.Name, replicas: replicasOrOne(sts.Spec.Replicas), containers: sts.Spec.Template.Spec.Containers})
+	}
+	daemonSets, err := client.AppsV1().DaemonSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取 DaemonSet 列表失败: %w", err)
+	}
+	for _, ds := range daemonSets.Items {
+		workloads = append(workloads, rightsizingWorkload{kind: "DaemonSet", namespace: namespace, name: ds.Name, replicas: ds.Status.DesiredNumberScheduled, containers: ds.Spec.Template.Spec.Containers})
+	}
+	return workloads, nil
+}
+
+// rightsizingPodPattern 按工作负载类型生成 Pod 名称正则，避免前缀相同的其他工作负载被误匹配：
+// Deployment 为 name-<rs hash>-<5 位后缀>，StatefulSet 为 name-<序号>，DaemonSet 为 name-<5 位后缀>
+func rightsizingPodPattern(kind, name string) string {
+	quoted := regexp.QuoteMeta(name)
+	switch kind {
+	case "Deployment":
+		return quoted + "-[a-z0-9]{1,10}-[a-z0-9]{5}"
+	case "StatefulSet":
+		return quoted + "-[0-9]+"
+	default:
+		return quoted + "-[a-z0-9]{5}"
+	}
+}
+
+func replicasOrOne(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
+}
+
+func validateRightsizingWindow(window time.Duration) error {
+	if window < rightsizingMinWindow || window > rightsizingMaxWindow {
+		return fmt.Errorf("统计窗口需在 1h 到 30d 之间")
+	}
+	return nil
+}
+
+// formatRightsizingWindow 整天数的窗口显示为 7d 形式
+func formatRightsizingWindow(window time.Duration) string {
+	if window%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", window/(24*time.Hour))
+	}
+	return window.String()
+}
+
+func roundUp(v, step float64) float64 {
+	return math.Ceil(v/step-1e-9) * step
+}
+
+func formatCPUQuantity(cores float64) string {
+	return resource.NewMilliQuantity(int64(math.Round(cores*1000)), resource.DecimalSI).String()
+}
+
+func formatMemoryQuantity(bytes float64) string {
+	return resource.NewQuantity(int64(math.Round(bytes)), resource.BinarySI).String()
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// RightsizingServiceTestSuite 定义资源规格建议服务测试套件
+type RightsizingServiceTestSuite struct {
+	suite.Suite
+	svc          *RightsizingService
+	profiles     []models.ContainerUsageProfile
+	lastSelector string
+}
+
+func rightsizingContainer(name, cpuReq, cpuLimit, memReq, memLimit string) corev1.Container {
+	c := corev1.Container{Name: name, Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{}, Limits: corev1.ResourceList{}}}
+	for res, value := range map[corev1.ResourceName][2]string{corev1.ResourceCPU: {cpuReq, cpuLimit}, corev1.ResourceMemory: {memReq, memLimit}} {
+		if value[0] != "" {
+			c.Resources.Requests[res] = resource.MustParse(value[0])
+		}
+		if value[1] != "" {
+			c.Resources.Limits[res] = resource.MustParse(value[1])
+		}
+	}
+	return c
+}
+
+// SetupTest 每个测试前的设置
+func (s *RightsizingServiceTestSuite) SetupTest() {
+	replicas := int32(3)
+	client := fake.NewSimpleClientset(
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "prod"},
+			Spec: appsv1.DeploymentSpec{Replicas: &replicas, Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{
+				rightsizingContainer("app", "1", "2", "1Gi", "2Gi"),
+			}}}},
+		},
+		&appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "api-db", Namespace: "prod"},
+			Spec: appsv1.StatefulSetSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{
+				rightsizingContainer("db", "500m", "", "512Mi", "512Mi"),
+			}}}},
+		},
+	)
+
+	s.profiles = []models.ContainerUsageProfile{
+		{Pod: "api-7d9f8c6b5-abcde", Container: "app", CPUP95: 0.2, CPUP99: 0.3, MemoryP95: 300 << 20, MemoryP99: 320 << 20, MemoryMax: 400 << 20},
+		{Pod: "api-7d9f8c6b5-fghij", Container: "app", CPUP95: 0.25, CPUP99: 0.35, MemoryP95: 280 << 20, MemoryP99: 300 << 20, MemoryMax: 350 << 20},
+		{Pod: "api-db-0", Container: "db", CPUP95: 0.45, CPUP99: 0.5, MemoryP95: 480 << 20, MemoryP99: 510 << 20, MemoryMax: 512 << 20, OOMKills: 2},
+	}
+	s.svc = &RightsizingService{
+		clientFor: func(uint) (kubernetes.Interface, error) { return client, nil },
+		profiles: func(_ context.Context, _ *models.MonitoringConfig, selector string, _ time.Duration) ([]models.ContainerUsageProfile, error) {
+			s.lastSelector = selector
+			return s.profiles, nil
+		},
+	}
+}
+
+// TestRecommendContainer 测试容器建议值与分类
+func (s *RightsizingServiceTestSuite) TestRecommendContainer() {
+	current := models.ContainerResourceValues{CPURequest: 1, CPULimit: 2, MemoryRequest: 1 << 30, MemoryLimit: 2 << 30}
+	rec := RecommendContainer("app", current, []models.ContainerUsageProfile{
+		{CPUP95: 0.2, CPUP99: 0.3, MemoryP95: 300 << 20, MemoryP99: 320 << 20, MemoryMax: 400 << 20},
+		{CPUP95: 0.25, CPUP99: 0.35, MemoryP95: 280 << 20, MemoryP99: 300 << 20, MemoryMax: 350 << 20},
+	})
+	assert.Equal(s.T(), models.RightsizingOverProvisioned, rec.Status)
+	assert.Equal(s.T(), 2, rec.Pods)
+	assert.InDelta(s.T(), 0.29, rec.Recommended.CPURequest, 1e-9) // 0.25 * 1.15 = 0.2875，向上取整到 5m
+	assert.InDelta(s.T(), 0.455, rec.Recommended.CPULimit, 1e-9)  // 0.35 * 1.3
+	assert.Equal(s.T(), float64(345<<20), rec.Recommended.MemoryRequest)
+	assert.Equal(s.T(), float64(520<<20), rec.Recommended.MemoryLimit) // 峰值 400Mi * 1.3
+	assert.Len(s.T(), rec.Reasons, 2)
+
+	// 发生 OOM：limit 至少放大 1.5 倍
+	rec = RecommendContainer("db", models.ContainerResourceValues{CPURequest: 0.5, MemoryRequest: 512 << 20, MemoryLimit: 512 << 20},
+		[]models.ContainerUsageProfile{{CPUP95: 0.45, CPUP99: 0.5, MemoryP95: 480 << 20, MemoryP99: 510 << 20, MemoryMax: 512 << 20, OOMKills: 2}})
+	assert.Equal(s.T(), models.RightsizingOOMProne, rec.Status)
+	assert.Equal(s.T(), float64(0), rec.Recommended.CPULimit, "原本未设置的 limit 不主动添加")
+	assert.InDelta(s.T(), float64(768<<20), rec.Recommended.MemoryLimit, 1<<20)
+
+	// 严重限流
+	rec = RecommendContainer("web", models.ContainerResourceValues{CPURequest: 0.5, CPULimit: 0.5, MemoryRequest: 256 << 20},
+		[]models.ContainerUsageProfile{{CPUP95: 0.45, CPUP99: 0.5, MemoryP95: 200 << 20, CPUThrottling: 40}})
+	assert.Equal(s.T(), models.RightsizingUnderProvisioned, rec.Status)
+	assert.InDelta(s.T(), 0.75, rec.Recommended.CPULimit, 1e-9)
+
+	rec = RecommendContainer("idle", current, nil)
+	assert.Equal(s.T(), models.RightsizingNoData, rec.Status)
+	assert.Equal(s.T(), current, rec.Recommended)
+}
+
+// TestWorkloadRecommendation 测试工作负载建议、回收量与 patch
+func (s *RightsizingServiceTestSuite) TestWorkloadRecommendation() {
+	s.profiles = s.profiles[:2]
+	result, err := s.svc.WorkloadRecommendation(context.Background(), 1, &models.MonitoringConfig{}, "deployments", "prod", "api", RightsizingDefaultWindow)
+	s.Require().NoError(err)
+	assert.Equal(s.T(), `namespace="prod",pod=~"api-[a-z0-9]{1,10}-[a-z0-9]{5}"`, s.lastSelector)
+	assert.Equal(s.T(), "Deployment", result.Kind)
+	assert.Equal(s.T(), "7d", result.Window)
+	assert.Equal(s.T(), models.RightsizingOverProvisioned, result.Status)
+	assert.InDelta(s.T(), (1-0.29)*3, result.ReclaimCPU, 1e-9)
+	assert.Equal(s.T(), float64((1024-345)<<20)*3, result.ReclaimMemory)
+	assert.JSONEq(s.T(), `{"spec":{"template":{"spec":{"containers":[{"name":"app","resources":{
+		"requests":{"cpu":"290m","memory":"345Mi"},"limits":{"cpu":"455m","memory":"520Mi"}}}]}}}}`, result.Patch)
+	assert.Contains(s.T(), result.Command, "kubectl -n prod patch deployment api --type strategic -p '")
+
+	_, err = s.svc.WorkloadRecommendation(context.Background(), 1, &models.MonitoringConfig{}, "rollouts", "prod", "api", RightsizingDefaultWindow)
+	assert.Error(s.T(), err)
+	_, err = s.svc.WorkloadRecommendation(context.Background(), 1, &models.MonitoringConfig{}, "deployments", "prod", "api", 90*24*time.Hour)
+	assert.Error(s.T(), err)
+}
+
+// TestNamespaceRecommendations 测试命名空间汇总：Pod 按工作负载类型归属，不被前缀相同的工作负载误匹配
+func (s *RightsizingServiceTestSuite) TestNamespaceRecommendations() {
+	report, err := s.svc.NamespaceRecommendations(context.Background(), 1, &models.MonitoringConfig{}, "prod", 24*time.Hour)
+	s.Require().NoError(err)
+	assert.Equal(s.T(), `namespace="prod"`, s.lastSelector)
+	assert.Equal(s.T(), "1d", report.Window)
+	s.Require().Len(report.Workloads, 2)
+
+	assert.Equal(s.T(), "api", report.Workloads[0].Name)
+	assert.Equal(s.T(), 2, report.Workloads[0].Containers[0].Pods)
+	assert.Equal(s.T(), "api-db", report.Workloads[1].Name)
+	assert.Equal(s.T(), models.RightsizingOOMProne, report.Workloads[1].Status)
+	assert.Equal(s.T(), 1, report.Workloads[1].Containers[0].Pods)
+	assert.InDelta(s.T(), report.Workloads[0].ReclaimCPU+report.Workloads[1].ReclaimCPU, report.ReclaimCPU, 1e-9)
+}
+
+// TestRightsizingPodPattern 测试 Pod 名称正则
+func (s *RightsizingServiceTestSuite) TestRightsizingPodPattern() {
+	match := func(kind, name, pod string) bool {
+		return regexp.MustCompile("^(?:" + rightsizingPodPattern(kind, name) + ")$").MatchString(pod)
+	}
+	assert.True(s.T(), match("Deployment", "api", "api-7d9f8c6b5-abcde"))
+	assert.False(s.T(), match("Deployment", "api", "api-web-7d9f8c6b5-abcde"))
+	assert.True(s.T(), match("StatefulSet", "db", "db-12"))
+	assert.False(s.T(), match("StatefulSet", "db", "db-backup-0"))
+	assert.True(s.T(), match("DaemonSet", "agent", "agent-x7k2p"))
+	assert.False(s.T(), match("DaemonSet", "a.b", "axb-x7k2p"))
+}
+
+// TestRightsizingServiceTestSuite 运行资源规格建议服务测试套件
+func TestRightsizingServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(RightsizingServiceTestSuite))
+}
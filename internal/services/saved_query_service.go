@@ -0,0 +1,109 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// SavedQueryService 查询控制台保存查询服务：用户可见自己的查询与他人共享的查询，只有创建者可以修改和删除
+type SavedQueryService struct {
+	db *gorm.DB
+}
+
+// NewSavedQueryService 创建保存查询服务
+func NewSavedQueryService(db *gorm.DB) *SavedQueryService {
+	return &SavedQueryService{db: db}
+}
+
+// List 获取用户可见的保存查询；clusterID 不为 0 时只返回该集群及不限集群的查询
+func (s *SavedQueryService) List(userID, clusterID uint, keyword string) ([]models.SavedQuery, error) {
+	query := s.db.Model(&models.SavedQuery{}).Where("owner_id = ? OR shared = ?", userID, true)
+	if clusterID != 0 {
+		query = query.Where("cluster_id IN ?", []uint{0, clusterID})
+	}
+	if keyword = strings.TrimSpace(keyword); keyword != "" {
+		like := "%" + keyword + "%"
+		query = query.Where("name LIKE ? OR description LIKE ? OR query LIKE ?", like, like, like)
+	}
+
+	var queries []models.SavedQuery
+	if err := query.Order("updated_at DESC").Find(&queries).Error; err != nil {
+		return nil, fmt.Errorf("查询保存的查询失败: %w", err)
+	}
+	return queries, nil
+}
+
+// Save 创建（id 为 0）或更新保存查询
+func (s *SavedQueryService) Save(userID uint, username string, id uint, req *models.SavedQueryRequest) (*models.SavedQuery, error) {
+	if err := validateSavedQuery(req); err != nil {
+		return nil, err
+	}
+
+	saved := &models.SavedQuery{OwnerID: userID, OwnerName: username}
+	if id != 0 {
+		existing, err := s.getOwned(userID, id)
+		if err != nil {
+			return nil, err
+		}
+		saved = existing
+	}
+	saved.ClusterID = req.ClusterID
+	saved.Name = strings.TrimSpace(req.Name)
+	saved.Description = req.Description
+	saved.Query = strings.TrimSpace(req.Query)
+	saved.Shared = req.Shared
+
+	if err := s.db.Save(saved).Error; err != nil {
+		return nil, fmt.Errorf("保存查询失败: %w", err)
+	}
+	return saved, nil
+}
+
+// Delete 删除保存查询
+func (s *SavedQueryService) Delete(userID, id uint) error {
+	saved, err := s.getOwned(userID, id)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Delete(saved).Error; err != nil {
+		return fmt.Errorf("删除保存查询失败: %w", err)
+	}
+	return nil
+}
+
+// getOwned 获取当前用户创建的保存查询，共享查询也只有创建者可以修改
+func (s *SavedQueryService) getOwned(userID, id uint) (*models.SavedQuery, error) {
+	var saved models.SavedQuery
+	if err := s.db.First(&saved, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("保存的查询不存在: %d", id)
+		}
+		return nil, fmt.Errorf("查询保存的查询失败: %w", err)
+	}
+	if saved.OwnerID != userID {
+		return nil, fmt.Errorf("只有创建者可以修改或删除该查询")
+	}
+	return &saved, nil
+}
+
+func validateSavedQuery(req *models.SavedQueryRequest) error {
+	if strings.TrimSpace(req.Name) == "" {
+		return fmt.Errorf("名称不能为空")
+	}
+	query := strings.TrimSpace(req.Query)
+	if query == "" {
+		return fmt.Errorf("查询语句不能为空")
+	}
+	if len(query) > consoleMaxQueryLength {
+		return fmt.Errorf("查询语句过长（最多 %d 个字符）", consoleMaxQueryLength)
+	}
+	// 只做词法检查，语法错误留给执行时由 Prometheus 返回
+	if _, err := InjectPromQLSelector(query, `__check__="1"`); err != nil {
+		return fmt.Errorf("解析查询语句失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+
+	"sigs.k8s.io/yaml"
+)
+
+// SecretProvider 外部密钥源，按路径读取一组键值
+type SecretProvider interface {
+	// Read 读取路径下的全部键值
+	Read(ctx context.Context, path string) (map[string]string, error)
+}
+
+// NewSecretProvider 根据密钥源配置创建对应的 Provider，fileRootDir 为服务端配置的文件密钥源根目录
+func NewSecretProvider(source *models.SecretSource, fileRootDir string) (SecretProvider, error) {
+	switch source.Type {
+	case models.SecretSourceTypeVault:
+		return NewVaultKVProvider(source), nil
+	case models.SecretSourceTypeFile:
+		return NewFileSecretProvider(fileRootDir, source.BaseDir), nil
+	default:
+		return nil, fmt.Errorf("不支持的密钥源类型: %s", source.Type)
+	}
+}
+
+// VaultKVProvider HashiCorp Vault KV v2 密钥源
+type VaultKVProvider struct {
+	address    string
+	mountPath  string
+	namespace  string
+	token      string
+	httpClient *http.Client
+}
+
+// NewVaultKVProvider 创建 Vault KV v2 密钥源
+func NewVaultKVProvider(source *models.SecretSource) *VaultKVProvider {
+	mountPath := strings.Trim(source.MountPath, "/")
+	if mountPath == "" {
+		mountPath = "secret"
+	}
+	return &VaultKVProvider{
+		address:   strings.TrimRight(source.Address, "/"),
+		mountPath: mountPath,
+		namespace: source.VaultNamespace,
+		token:     source.Token,
+		httpClient: &http.Client{
+			Timeout: 15 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: source.Insecure},
+			},
+		},
+	}
+}
+
+// Read 读取 KV v2 路径的最新版本：GET /v1/<mount>/data/<path>
+func (p *VaultKVProvider) Read(ctx context.Context, path string) (map[string]string, error) {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil, fmt.Errorf("vault 路径不能为空")
+	}
+
+	reqURL, err := url.JoinPath(p.address, "v1", p.mountPath, "data", path)
+	if err != nil {
+		return nil, fmt.Errorf("无效的 Vault 地址: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建 Vault 请求失败: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", p.token)
+	if p.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.namespace)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求 Vault 失败: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("vault 路径不存在: %s/%s", p.mountPath, path)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("vault 响应异常, 状态码: %d, 内容: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("解析 Vault 响应失败: %w", err)
+	}
+	if result.Data.Data == nil {
+		// 最新版本已被删除
+		return nil, fmt.Errorf("vault 路径无可用数据: %s/%s", p.mountPath, path)
+	}
+	return stringifySecretValues(result.Data.Data)
+}
+
+// FileSecretProvider 本地文件密钥源，路径对应目录下的 JSON/YAML 文件，用于测试和离线环境。
+// 只能读取服务端配置的根目录（external_secret.file_root_dir）下的文件
+type FileSecretProvider struct {
+	rootDir string
+	baseDir string
+}
+
+// NewFileSecretProvider 创建文件密钥源，baseDir 相对 rootDir 解析
+func NewFileSecretProvider(rootDir, baseDir string) *FileSecretProvider {
+	return &FileSecretProvider{rootDir: rootDir, baseDir: baseDir}
+}
+
+// ResolveFileSecretDir 将密钥源目录解析为根目录下的绝对路径，根目录未配置或目录在根目录之外时返回错误
+func ResolveFileSecretDir(rootDir, baseDir string) (string, error) {
+	if rootDir == "" {
+		return "", fmt.Errorf("服务端未配置文件密钥源根目录（external_secret.file_root_dir），不允许使用文件密钥源")
+	}
+	root, err := filepath.Abs(rootDir)
+	if err != nil {
+		return "", fmt.Errorf("无效的文件密钥源根目录: %w", err)
+	}
+	dir := baseDir
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(root, dir)
+	}
+	dir = filepath.Clean(dir)
+	if !pathWithin(root, dir) {
+		return "", fmt.Errorf("目录 %s 不在文件密钥源根目录 %s 下", baseDir, root)
+	}
+	return dir, nil
+}
+
+// pathWithin 判断 path 是否为 root 本身或位于 root 下
+func pathWithin(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// Read 读取 <rootDir>/<baseDir>/<path>（可省略 .json/.yaml/.yml 扩展名），符号链接解析后仍须位于根目录下
+func (p *FileSecretProvider) Read(_ context.Context, path string) (map[string]string, error) {
+	dir, err := ResolveFileSecretDir(p.rootDir, p.baseDir)
+	if err != nil {
+		return nil, err
+	}
+	clean := filepath.Clean("/" + path)
+	if clean == "/" {
+		return nil, fmt.Errorf("文件路径不能为空")
+	}
+	base := filepath.Join(dir, clean)
+	root, err := filepath.EvalSymlinks(p.rootDir)
+	if err != nil {
+		return nil, fmt.Errorf("文件密钥源根目录不可用: %w", err)
+	}
+	if root, err = filepath.Abs(root); err != nil {
+		return nil, fmt.Errorf("文件密钥源根目录不可用: %w", err)
+	}
+
+	var content []byte
+	err = os.ErrNotExist
+	for _, candidate := range []string{base, base + ".json", base + ".yaml", base + ".yml"} {
+		resolved, evalErr := filepath.EvalSymlinks(candidate)
+		if evalErr != nil {
+			continue
+		}
+		if !pathWithin(root, resolved) {
+			return nil, fmt.Errorf("密钥文件 %s 指向根目录之外", path)
+		}
+		if content, err = os.ReadFile(resolved); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取密钥文件失败: %s", path)
+	}
+
+	var data map[string]interface{}
+	if err := yaml.Unmarshal(content, &data); err != nil {
+		return nil, fmt.Errorf("解析密钥文件失败: %w", err)
+	}
+	return stringifySecretValues(data)
+}
+
+// stringifySecretValues 将外部返回的值统一转换为字符串，嵌套结构序列化为 JSON
+func stringifySecretValues(data map[string]interface{}) (map[string]string, error) {
+	result := make(map[string]string, len(data))
+	for k, v := range data {
+		switch val := v.(type) {
+		case string:
+			result[k] = val
+		case nil:
+			result[k] = ""
+		default:
+			b, err := json.Marshal(val)
+			if err != nil {
+				return nil, fmt.Errorf("键 %s 的值无法序列化: %w", k, err)
+			}
+			result[k] = string(b)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// SecretProviderTestSuite 定义外部密钥源测试套件
+type SecretProviderTestSuite struct {
+	suite.Suite
+	baseDir string
+}
+
+// SetupTest 每个测试前的设置
+func (s *SecretProviderTestSuite) SetupTest() {
+	s.baseDir = s.T().TempDir()
+	s.Require().NoError(os.MkdirAll(filepath.Join(s.baseDir, "app"), 0o755))
+	s.Require().NoError(os.WriteFile(filepath.Join(s.baseDir, "app", "db.yaml"),
+		[]byte("username: admin\npassword: s3cret\nport: 5432\n"), 0o600))
+}
+
+// TestFileProviderRead 测试文件源读取与扩展名补全
+func (s *SecretProviderTestSuite) TestFileProviderRead() {
+	provider := NewFileSecretProvider(s.baseDir, "")
+
+	data, err := provider.Read(context.Background(), "app/db")
+	s.Require().NoError(err)
+	assert.Equal(s.T(), map[string]string{"username": "admin", "password": "s3cret", "port": "5432"}, data)
+
+	data, err = NewFileSecretProvider(s.baseDir, "app").Read(context.Background(), "db")
+	s.Require().NoError(err)
+	assert.Equal(s.T(), "admin", data["username"])
+
+	// 路径不能逃逸根目录
+	_, err = provider.Read(context.Background(), "../../etc/passwd")
+	assert.Error(s.T(), err)
+}
+
+// TestFileProviderRootDir 测试文件源只能读取服务端配置的根目录
+func (s *SecretProviderTestSuite) TestFileProviderRootDir() {
+	_, err := NewFileSecretProvider("", "/").Read(context.Background(), "etc/passwd")
+	assert.ErrorContains(s.T(), err, "未配置文件密钥源根目录")
+
+	for _, baseDir := range []string{"/", "/etc", "..", "app/../.."} {
+		_, err = ResolveFileSecretDir(s.baseDir, baseDir)
+		assert.Error(s.T(), err, baseDir)
+		_, err = NewFileSecretProvider(s.baseDir, baseDir).Read(context.Background(), "etc/passwd")
+		assert.Error(s.T(), err, baseDir)
+	}
+
+	dir, err := ResolveFileSecretDir(s.baseDir, filepath.Join(s.baseDir, "app"))
+	s.Require().NoError(err)
+	assert.Equal(s.T(), filepath.Join(s.baseDir, "app"), dir)
+	dir, err = ResolveFileSecretDir(s.baseDir, "app")
+	s.Require().NoError(err)
+	assert.Equal(s.T(), filepath.Join(s.baseDir, "app"), dir)
+
+	// 指向根目录之外的符号链接
+	outside := s.T().TempDir()
+	s.Require().NoError(os.WriteFile(filepath.Join(outside, "config.yaml"), []byte("jwt: secret\n"), 0o600))
+	s.Require().NoError(os.Symlink(filepath.Join(outside, "config.yaml"), filepath.Join(s.baseDir, "app", "leak.yaml")))
+	_, err = NewFileSecretProvider(s.baseDir, "app").Read(context.Background(), "leak")
+	assert.ErrorContains(s.T(), err, "指向根目录之外")
+}
+
+// TestCheckSecretSourceTokenReuse 测试修改 Vault 连接目标时需要重新填写 Token
+func (s *SecretProviderTestSuite) TestCheckSecretSourceTokenReuse() {
+	existing := &models.SecretSource{Type: models.SecretSourceTypeVault, Address: "https://vault.internal:8200", VaultNamespace: "ops", Token: "s.stored"}
+	request := func(mutate func(req *models.SecretSourceRequest)) *models.SecretSourceRequest {
+		req := &models.SecretSourceRequest{Name: "vault", Type: models.SecretSourceTypeVault, Address: existing.Address, VaultNamespace: existing.VaultNamespace, MountPath: "kv"}
+		mutate(req)
+		return req
+	}
+
+	assert.NoError(s.T(), checkSecretSourceTokenReuse(existing, request(func(req *models.SecretSourceRequest) { req.Description = "prod" })))
+	for name, mutate := range map[string]func(req *models.SecretSourceRequest){
+		"address":   func(req *models.SecretSourceRequest) { req.Address = "https://attacker.example.com" },
+		"namespace": func(req *models.SecretSourceRequest) { req.VaultNamespace = "other" },
+		"insecure":  func(req *models.SecretSourceRequest) { req.Insecure = true },
+	} {
+		assert.Error(s.T(), checkSecretSourceTokenReuse(existing, request(mutate)), name)
+	}
+	assert.NoError(s.T(), checkSecretSourceTokenReuse(existing, request(func(req *models.SecretSourceRequest) {
+		req.Address = "https://vault-new.internal:8200"
+		req.Token = "s.new"
+	})), "重新填写 Token 时允许修改地址")
+}
+
+// TestDiffSecretData 测试漂移计算
+func (s *SecretProviderTestSuite) TestDiffSecretData() {
+	secret := &corev1.Secret{Data: map[string][]byte{
+		"username": []byte("admin"),
+		"password": []byte("old"),
+		"legacy":   []byte("x"),
+	}}
+	drift := diffSecretData(secret, map[string]string{"username": "admin", "password": "new", "port": "5432"})
+
+	assert.False(s.T(), drift.InSync)
+	assert.Equal(s.T(), []string{"password"}, drift.Changed)
+	assert.Equal(s.T(), []string{"port"}, drift.Missing)
+	assert.Equal(s.T(), []string{"legacy"}, drift.Extra)
+
+	drift = diffSecretData(nil, map[string]string{"username": "admin"})
+	assert.False(s.T(), drift.SecretFound)
+	assert.Equal(s.T(), []string{"username"}, drift.Missing)
+}
+
+// TestSecretProviderSuite 运行测试套件
+func TestSecretProviderSuite(t *testing.T) {
+	suite.Run(t, new(SecretProviderTestSuite))
+}
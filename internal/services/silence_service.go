@@ -0,0 +1,663 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+	"github.com/clay-wangzhi/KubePolaris/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// recurringSilenceInterval 周期静默调度周期
+	recurringSilenceInterval = time.Minute
+	// recurringSilenceMaxDuration 周期静默单个窗口的最长时长
+	recurringSilenceMaxDuration = 7 * 24 * 60
+	// silenceMaxDurationMinutes 模板与批量静默的最长时长（30 天）
+	silenceMaxDurationMinutes = 30 * 24 * 60
+	// orphanedSilenceLookback 孤立静默检查默认回溯的告警历史时长
+	orphanedSilenceLookback = 7 * 24 * time.Hour
+)
+
+// SilenceService 静默管理：静默模板、周期静默、按分组批量静默、延长静默与孤立静默检查
+type SilenceService struct {
+	db                    *gorm.DB
+	alertManagerConfigSvc *AlertManagerConfigService
+	alertManagerSvc       *AlertManagerService
+	now                   func() time.Time
+}
+
+// NewSilenceService 创建静默管理服务
+func NewSilenceService(db *gorm.DB, alertManagerConfigSvc *AlertManagerConfigService, alertManagerSvc *AlertManagerService) *SilenceService {
+	return &SilenceService{
+		db:                    db,
+		alertManagerConfigSvc: alertManagerConfigSvc,
+		alertManagerSvc:       alertManagerSvc,
+		now:                   time.Now,
+	}
+}
+
+// Start 启动周期静默调度器，ctx 取消后退出
+func (s *SilenceService) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(recurringSilenceInterval)
+		defer ticker.Stop()
+		for {
+			s.scheduleRecurring(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	logger.Info("周期静默调度器已启动")
+}
+
+// amConfig 获取集群 Alertmanager 配置，未启用时返回错误
+func (s *SilenceService) amConfig(clusterID uint) (*models.AlertManagerConfig, error) {
+	config, err := s.alertManagerConfigSvc.GetAlertManagerConfig(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	if !config.Enabled {
+		return nil, fmt.Errorf("alertmanager 未启用")
+	}
+	return config, nil
+}
+
+// ========== 模板 ==========
+
+// ListTemplates 获取集群可用的静默模板（含共用模板）
+func (s *SilenceService) ListTemplates(clusterID uint) ([]models.SilenceTemplate, error) {
+	var templates []models.SilenceTemplate
+	if err := s.db.Where("cluster_id IN ?", []uint{0, clusterID}).Order("name ASC").Find(&templates).Error; err != nil {
+		return nil, fmt.Errorf("查询静默模板失败: %w", err)
+	}
+	for i := range templates {
+		decodeSilenceMatchers(templates[i].Matchers, &templates[i].MatcherList)
+	}
+	return templates, nil
+}
+
+func (s *SilenceService) getTemplate(clusterID, id uint) (*models.SilenceTemplate, error) {
+	var tpl models.SilenceTemplate
+	if err := s.db.Where("cluster_id IN ?", []uint{0, clusterID}).First(&tpl, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("静默模板不存在: %d", id)
+		}
+		return nil, fmt.Errorf("查询静默模板失败: %w", err)
+	}
+	decodeSilenceMatchers(tpl.Matchers, &tpl.MatcherList)
+	return &tpl, nil
+}
+
+// SaveTemplate 创建（id 为 0）或更新静默模板
+func (s *SilenceService) SaveTemplate(clusterID, id uint, req *models.SilenceTemplateRequest, username string) (*models.SilenceTemplate, error) {
+	if err := ValidateSilenceMatchers(req.Matchers); err != nil {
+		return nil, err
+	}
+	if req.DurationMinutes <= 0 || req.DurationMinutes > silenceMaxDurationMinutes {
+		return nil, fmt.Errorf("静默时长需在 1 到 %d 分钟之间", silenceMaxDurationMinutes)
+	}
+
+	tpl := &models.SilenceTemplate{CreatedBy: username}
+	if id != 0 {
+		existing, err := s.getTemplate(clusterID, id)
+		if err != nil {
+			return nil, err
+		}
+		tpl = existing
+	}
+	tpl.ClusterID = clusterID
+	if req.Shared {
+		tpl.ClusterID = 0
+	}
+	tpl.Name = req.Name
+	tpl.Description = req.Description
+	tpl.DurationMinutes = req.DurationMinutes
+	tpl.Comment = req.Comment
+	tpl.MatcherList = req.Matchers
+	data, _ := json.Marshal(req.Matchers)
+	tpl.Matchers = string(data)
+
+	if err := s.db.Save(tpl).Error; err != nil {
+		return nil, fmt.Errorf("保存静默模板失败: %w", err)
+	}
+	return tpl, nil
+}
+
+// DeleteTemplate 删除静默模板
+func (s *SilenceService) DeleteTemplate(clusterID, id uint) error {
+	tpl, err := s.getTemplate(clusterID, id)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Delete(tpl).Error; err != nil {
+		return fmt.Errorf("删除静默模板失败: %w", err)
+	}
+	return nil
+}
+
+// ApplyTemplate 按模板在集群 Alertmanager 中创建静默
+func (s *SilenceService) ApplyTemplate(ctx context.Context, clusterID, id uint, req *models.ApplySilenceTemplateRequest, username string) (*models.Silence, error) {
+	tpl, err := s.getTemplate(clusterID, id)
+	if err != nil {
+		return nil, err
+	}
+	config, err := s.amConfig(clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	startsAt := s.now()
+	if req.StartsAt != nil && req.StartsAt.After(startsAt) {
+		startsAt = *req.StartsAt
+	}
+	duration := tpl.DurationMinutes
+	if req.DurationMinutes > 0 {
+		duration = req.DurationMinutes
+	}
+	comment := tpl.Comment
+	if req.Comment != "" {
+		comment = req.Comment
+	}
+	if comment == "" {
+		comment = "静默模板: " + tpl.Name
+	}
+	return s.alertManagerSvc.CreateSilence(ctx, config, &models.CreateSilenceRequest{
+		Matchers:  tpl.MatcherList,
+		StartsAt:  startsAt,
+		EndsAt:    startsAt.Add(time.Duration(duration) * time.Minute),
+		CreatedBy: username,
+		Comment:   comment,
+	})
+}
+
+// ========== 周期静默 ==========
+
+// ListRecurring 获取集群的周期静默
+func (s *SilenceService) ListRecurring(clusterID uint) ([]models.RecurringSilence, error) {
+	var rules []models.RecurringSilence
+	if err := s.db.Where("cluster_id = ?", clusterID).Order("id ASC").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("查询周期静默失败: %w", err)
+	}
+	for i := range rules {
+		s.decodeRecurring(&rules[i])
+	}
+	return rules, nil
+}
+
+// GetRecurring 获取周期静默
+func (s *SilenceService) GetRecurring(clusterID, id uint) (*models.RecurringSilence, error) {
+	var rule models.RecurringSilence
+	if err := s.db.Where("cluster_id = ?", clusterID).First(&rule, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("周期静默不存在: %d", id)
+		}
+		return nil, fmt.Errorf("查询周期静默失败: %w", err)
+	}
+	s.decodeRecurring(&rule)
+	return &rule, nil
+}
+
+// SaveRecurring 创建（id 为 0）或更新周期静默；修改窗口后会在下一轮调度中按新窗口创建静默
+func (s *SilenceService) SaveRecurring(clusterID, id uint, req *models.RecurringSilenceRequest, username string) (*models.RecurringSilence, error) {
+	rule := &models.RecurringSilence{ClusterID: clusterID, CreatedBy: username}
+	if id != 0 {
+		existing, err := s.GetRecurring(clusterID, id)
+		if err != nil {
+			return nil, err
+		}
+		rule = existing
+	}
+	rule.Name = req.Name
+	rule.MatcherList = req.Matchers
+	rule.Comment = req.Comment
+	rule.WeekdayList = req.Weekdays
+	rule.StartTime = req.StartTime
+	rule.DurationMinutes = req.DurationMinutes
+	rule.Timezone = req.Timezone
+	rule.LeadMinutes = req.LeadMinutes
+	rule.Enabled = req.Enabled
+	if err := ValidateRecurringSilence(rule); err != nil {
+		return nil, err
+	}
+	matchers, _ := json.Marshal(rule.MatcherList)
+	rule.Matchers = string(matchers)
+	weekdays, _ := json.Marshal(normalizeWeekdays(rule.WeekdayList))
+	rule.Weekdays = string(weekdays)
+	rule.LastError = ""
+
+	if err := s.db.Save(rule).Error; err != nil {
+		return nil, fmt.Errorf("保存周期静默失败: %w", err)
+	}
+	s.decodeRecurring(rule)
+	return rule, nil
+}
+
+// DeleteRecurring 删除周期静默，并使其已创建但尚未结束的静默过期
+func (s *SilenceService) DeleteRecurring(ctx context.Context, clusterID, id uint) error {
+	rule, err := s.GetRecurring(clusterID, id)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Delete(rule).Error; err != nil {
+		return fmt.Errorf("删除周期静默失败: %w", err)
+	}
+	if rule.LastSilenceID != "" && rule.LastWindowStart != nil &&
+		rule.LastWindowStart.Add(time.Duration(rule.DurationMinutes)*time.Minute).After(s.now()) {
+		if config, err := s.amConfig(clusterID); err == nil {
+			if err := s.alertManagerSvc.DeleteSilence(ctx, config, rule.LastSilenceID); err != nil {
+				logger.Warn("删除周期静默关联的静默失败", "silence_id", rule.LastSilenceID, "error", err)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateRecurringSilence 校验周期静默定义
+func ValidateRecurringSilence(rule *models.RecurringSilence) error {
+	if err := ValidateSilenceMatchers(rule.MatcherList); err != nil {
+		return err
+	}
+	if len(rule.WeekdayList) == 0 {
+		return fmt.Errorf("至少选择一天")
+	}
+	for _, d := range rule.WeekdayList {
+		if d < 0 || d > 6 {
+			return fmt.Errorf("无效的星期: %d（0 表示周日）", d)
+		}
+	}
+	if _, _, err := parseClockTime(rule.StartTime); err != nil {
+		return err
+	}
+	if rule.DurationMinutes <= 0 || rule.DurationMinutes > recurringSilenceMaxDuration {
+		return fmt.Errorf("窗口时长需在 1 到 %d 分钟之间", recurringSilenceMaxDuration)
+	}
+	if rule.LeadMinutes < 0 || rule.LeadMinutes > 24*60 {
+		return fmt.Errorf("提前创建时间需在 0 到 1440 分钟之间")
+	}
+	if rule.Timezone != "" {
+		if _, err := time.LoadLocation(rule.Timezone); err != nil {
+			return fmt.Errorf("无效的时区: %s", rule.Timezone)
+		}
+	}
+	return nil
+}
+
+func (s *SilenceService) decodeRecurring(rule *models.RecurringSilence) {
+	decodeSilenceMatchers(rule.Matchers, &rule.MatcherList)
+	if rule.Weekdays != "" {
+		_ = json.Unmarshal([]byte(rule.Weekdays), &rule.WeekdayList)
+	}
+	if rule.Enabled {
+		if start, end, ok := nextRecurringWindow(rule, s.now()); ok {
+			rule.NextWindowStart, rule.NextWindowEnd = &start, &end
+		}
+	}
+}
+
+// scheduleRecurring 为进入提前量范围且尚未创建静默的窗口创建静默
+func (s *SilenceService) scheduleRecurring(ctx context.Context) {
+	var rules []models.RecurringSilence
+	if err := s.db.Where("enabled = ?", true).Find(&rules).Error; err != nil {
+		logger.Error("查询周期静默失败", "error", err)
+		return
+	}
+	now := s.now()
+	for i := range rules {
+		rule := &rules[i]
+		s.decodeRecurring(rule)
+		start, end, due := recurringWindowDue(rule, now)
+		if !due {
+			continue
+		}
+
+		updates := map[string]interface{}{}
+		config, err := s.amConfig(rule.ClusterID)
+		var silence *models.Silence
+		if err == nil {
+			startsAt := start
+			if startsAt.Before(now) {
+				startsAt = now
+			}
+			comment := fmt.Sprintf("[周期静默 #%d %s] %s", rule.ID, rule.Name, rule.Comment)
+			silence, err = s.alertManagerSvc.CreateSilence(ctx, config, &models.CreateSilenceRequest{
+				Matchers:  rule.MatcherList,
+				StartsAt:  startsAt,
+				EndsAt:    end,
+				CreatedBy: rule.CreatedBy,
+				Comment:   strings.TrimSpace(comment),
+			})
+		}
+		if err != nil {
+			// 失败时不记录窗口，下一轮继续尝试
+			logger.Warn("创建周期静默失败", "rule_id", rule.ID, "error", err)
+			updates["last_error"] = truncateAlertText(err.Error(), 1000)
+		} else {
+			logger.Info("已创建周期静默", "rule_id", rule.ID, "silence_id", silence.ID, "window_start", start)
+			updates["last_window_start"] = start
+			updates["last_silence_id"] = silence.ID
+			updates["last_error"] = ""
+		}
+		s.db.Model(&models.RecurringSilence{}).Where("id = ?", rule.ID).Updates(updates)
+	}
+}
+
+// recurringWindowDue 判断当前是否需要为下一个窗口创建静默
+func recurringWindowDue(rule *models.RecurringSilence, now time.Time) (start, end time.Time, due bool) {
+	start, end, ok := nextRecurringWindow(rule, now)
+	if !ok {
+		return start, end, false
+	}
+	if rule.LastWindowStart != nil && rule.LastWindowStart.Unix() == start.Unix() {
+		return start, end, false
+	}
+	lead := time.Duration(rule.LeadMinutes) * time.Minute
+	return start, end, !now.Before(start.Add(-lead))
+}
+
+// nextRecurringWindow 返回尚未结束的最早窗口（可能已经开始）
+func nextRecurringWindow(rule *models.RecurringSilence, now time.Time) (start, end time.Time, ok bool) {
+	hour, minute, err := parseClockTime(rule.StartTime)
+	if err != nil || len(rule.WeekdayList) == 0 || rule.DurationMinutes <= 0 {
+		return start, end, false
+	}
+	loc := time.Local
+	if rule.Timezone != "" {
+		if l, err := time.LoadLocation(rule.Timezone); err == nil {
+			loc = l
+		}
+	}
+	days := make(map[time.Weekday]bool, len(rule.WeekdayList))
+	for _, d := range rule.WeekdayList {
+		days[time.Weekday(d)] = true
+	}
+	duration := time.Duration(rule.DurationMinutes) * time.Minute
+
+	local := now.In(loc)
+	// 从 7 天前开始查找，覆盖跨天的长窗口
+	for offset := -7; offset <= 7; offset++ {
+		day := time.Date(local.Year(), local.Month(), local.Day()+offset, hour, minute, 0, 0, loc)
+		if !days[day.Weekday()] {
+			continue
+		}
+		if day.Add(duration).After(now) {
+			return day, day.Add(duration), true
+		}
+	}
+	return start, end, false
+}
+
+func parseClockTime(value string) (hour, minute int, err error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, 0, fmt.Errorf("无效的开始时间: %s（格式 HH:MM）", value)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+func normalizeWeekdays(days []int) []int {
+	seen := make(map[int]bool, len(days))
+	result := make([]int, 0, len(days))
+	for _, d := range days {
+		if !seen[d] {
+			seen[d] = true
+			result = append(result, d)
+		}
+	}
+	sort.Ints(result)
+	return result
+}
+
+// ========== 批量与延长 ==========
+
+// BulkSilenceGroup 按告警分组批量静默：group 方式按分组标签创建一条静默，alerts 方式为每条告警各创建一条
+func (s *SilenceService) BulkSilenceGroup(ctx context.Context, clusterID uint, req *models.BulkSilenceRequest, username string) (*models.BulkSilenceResult, error) {
+	if req.DurationMinutes <= 0 || req.DurationMinutes > silenceMaxDurationMinutes {
+		return nil, fmt.Errorf("静默时长需在 1 到 %d 分钟之间", silenceMaxDurationMinutes)
+	}
+	if req.Mode == "" {
+		req.Mode = models.BulkSilenceByGroup
+	}
+	if req.Mode != models.BulkSilenceByGroup && req.Mode != models.BulkSilenceByAlert {
+		return nil, fmt.Errorf("不支持的静默方式: %s", req.Mode)
+	}
+	config, err := s.amConfig(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := s.alertManagerSvc.GetAlertGroups(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	var group *models.AlertGroup
+	for i := range groups {
+		if groups[i].Receiver == req.Receiver && sameLabels(groups[i].Labels, req.GroupLabels) {
+			group = &groups[i]
+			break
+		}
+	}
+	if group == nil {
+		return nil, fmt.Errorf("告警分组不存在或已恢复")
+	}
+
+	matcherSets := bulkSilenceMatchers(group, req.Mode)
+	if len(matcherSets) == 0 {
+		return nil, fmt.Errorf("分组没有分组标签，请使用按告警静默")
+	}
+
+	now := s.now()
+	result := &models.BulkSilenceResult{Created: []models.Silence{}}
+	for _, matchers := range matcherSets {
+		silence, err := s.alertManagerSvc.CreateSilence(ctx, config, &models.CreateSilenceRequest{
+			Matchers:  matchers,
+			StartsAt:  now,
+			EndsAt:    now.Add(time.Duration(req.DurationMinutes) * time.Minute),
+			CreatedBy: username,
+			Comment:   req.Comment,
+		})
+		if err != nil {
+			result.Errors = append(result.Errors, err.Error())
+			continue
+		}
+		result.Created = append(result.Created, *silence)
+	}
+	return result, nil
+}
+
+// bulkSilenceMatchers 生成批量静默的匹配器集合，已被静默的告警跳过
+func bulkSilenceMatchers(group *models.AlertGroup, mode string) [][]models.Matcher {
+	if mode == models.BulkSilenceByGroup {
+		if len(group.Labels) == 0 {
+			return nil
+		}
+		return [][]models.Matcher{equalMatchers(group.Labels)}
+	}
+	var sets [][]models.Matcher
+	seen := make(map[string]bool)
+	for _, alert := range group.Alerts {
+		if len(alert.Status.SilencedBy) > 0 || seen[alert.Fingerprint] {
+			continue
+		}
+		seen[alert.Fingerprint] = true
+		sets = append(sets, equalMatchers(alert.Labels))
+	}
+	return sets
+}
+
+func equalMatchers(labels map[string]string) []models.Matcher {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	matchers := make([]models.Matcher, 0, len(names))
+	for _, name := range names {
+		matchers = append(matchers, models.Matcher{Name: name, Value: labels[name], IsEqual: true})
+	}
+	return matchers
+}
+
+// ExtendSilence 在静默当前结束时间（已过当前时间则从现在起）基础上延长
+func (s *SilenceService) ExtendSilence(ctx context.Context, clusterID uint, silenceID string, req *models.ExtendSilenceRequest, username string) (*models.Silence, error) {
+	if req.Minutes <= 0 || req.Minutes > silenceMaxDurationMinutes {
+		return nil, fmt.Errorf("延长时长需在 1 到 %d 分钟之间", silenceMaxDurationMinutes)
+	}
+	config, err := s.amConfig(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	silence, err := s.alertManagerSvc.GetSilence(ctx, config, silenceID)
+	if err != nil {
+		return nil, err
+	}
+	if silence.Status.State == "expired" {
+		return nil, fmt.Errorf("静默已过期，请重新创建")
+	}
+
+	endsAt := silence.EndsAt
+	if now := s.now(); endsAt.Before(now) {
+		endsAt = now
+	}
+	comment := silence.Comment
+	if req.Comment != "" {
+		comment = req.Comment
+	}
+	createdBy := silence.CreatedBy
+	if createdBy == "" {
+		createdBy = username
+	}
+	extended, err := s.alertManagerSvc.CreateSilence(ctx, config, &models.CreateSilenceRequest{
+		ID:        silence.ID,
+		Matchers:  silence.Matchers,
+		StartsAt:  silence.StartsAt,
+		EndsAt:    endsAt.Add(time.Duration(req.Minutes) * time.Minute),
+		CreatedBy: createdBy,
+		Comment:   comment,
+	})
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("延长静默", "silence_id", silenceID, "operator", username, "ends_at", extended.EndsAt)
+	return extended, nil
+}
+
+// ========== 孤立静默 ==========
+
+// OrphanedSilences 列出未过期、但不匹配当前任何告警以及回溯期内告警历史的静默
+func (s *SilenceService) OrphanedSilences(ctx context.Context, clusterID uint, lookback time.Duration) ([]models.OrphanedSilence, error) {
+	if lookback <= 0 {
+		lookback = orphanedSilenceLookback
+	}
+	config, err := s.amConfig(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	silences, err := s.alertManagerSvc.GetSilences(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	alerts, err := s.alertManagerSvc.GetAlerts(ctx, config, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	labelSets := make([]map[string]string, 0, len(alerts))
+	for _, a := range alerts {
+		labelSets = append(labelSets, a.Labels)
+	}
+	var records []models.AlertRecord
+	if err := s.db.Select("labels").Where("cluster_id = ? AND last_seen_at >= ?", clusterID, s.now().Add(-lookback)).
+		Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("查询告警历史失败: %w", err)
+	}
+	for i := range records {
+		decodeAlertRecord(&records[i])
+		if len(records[i].LabelMap) > 0 {
+			labelSets = append(labelSets, records[i].LabelMap)
+		}
+	}
+	return findOrphanedSilences(silences, labelSets, s.now()), nil
+}
+
+// findOrphanedSilences 返回未过期且不匹配任何标签集的静默，按过期时间升序
+func findOrphanedSilences(silences []models.Silence, labelSets []map[string]string, now time.Time) []models.OrphanedSilence {
+	result := []models.OrphanedSilence{}
+	for _, silence := range silences {
+		if silence.Status.State == "expired" {
+			continue
+		}
+		matched := false
+		for _, labels := range labelSets {
+			if silenceMatches(silence.Matchers, labels) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			result = append(result, models.OrphanedSilence{Silence: silence, ExpiresIn: int64(silence.EndsAt.Sub(now).Seconds())})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].EndsAt.Before(result[j].EndsAt) })
+	return result
+}
+
+// silenceMatches 按 Alertmanager 语义判断匹配器是否全部匹配标签集：正则全匹配，缺失的标签视为空字符串
+func silenceMatches(matchers []models.Matcher, labels map[string]string) bool {
+	if len(matchers) == 0 {
+		return false
+	}
+	for _, m := range matchers {
+		value := labels[m.Name]
+		var ok bool
+		if m.IsRegex {
+			re, err := regexp.Compile("^(?:" + m.Value + ")$")
+			if err != nil {
+				return false
+			}
+			ok = re.MatchString(value)
+		} else {
+			ok = value == m.Value
+		}
+		if ok != m.IsEqual {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateSilenceMatchers 校验匹配器：名称不能为空、正则可编译，且至少一个匹配器不匹配空字符串（Alertmanager 的要求）
+func ValidateSilenceMatchers(matchers []models.Matcher) error {
+	if len(matchers) == 0 {
+		return fmt.Errorf("至少需要一个匹配器")
+	}
+	for _, m := range matchers {
+		if strings.TrimSpace(m.Name) == "" {
+			return fmt.Errorf("匹配器名称不能为空")
+		}
+		if m.IsRegex {
+			if _, err := regexp.Compile("^(?:" + m.Value + ")$"); err != nil {
+				return fmt.Errorf("匹配器 %s 的正则无效: %v", m.Name, err)
+			}
+		}
+	}
+	if silenceMatches(matchers, map[string]string{}) {
+		return fmt.Errorf("匹配器不能全部匹配空值，否则会静默所有告警")
+	}
+	return nil
+}
+
+func decodeSilenceMatchers(data string, matchers *[]models.Matcher) {
+	if data != "" {
+		_ = json.Unmarshal([]byte(data), matchers)
+	}
+}
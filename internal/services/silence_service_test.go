@@ -0,0 +1,161 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// SilenceServiceTestSuite 定义静默管理测试套件
+type SilenceServiceTestSuite struct {
+	suite.Suite
+	loc *time.Location
+}
+
+// SetupTest 每个测试前的设置
+func (s *SilenceServiceTestSuite) SetupTest() {
+	s.loc = time.UTC
+}
+
+func (s *SilenceServiceTestSuite) rule() *models.RecurringSilence {
+	return &models.RecurringSilence{
+		ID:              1,
+		MatcherList:     []models.Matcher{{Name: "job", Value: "batch", IsEqual: true}},
+		WeekdayList:     []int{6}, // 每周六
+		StartTime:       "02:00",
+		DurationMinutes: 180,
+		LeadMinutes:     60,
+		Timezone:        "UTC",
+		Enabled:         true,
+	}
+}
+
+// TestNextRecurringWindow 测试下一个窗口的计算：窗口进行中时返回当前窗口
+func (s *SilenceServiceTestSuite) TestNextRecurringWindow() {
+	rule := s.rule()
+
+	// 2024-01-03 为周三，下一个窗口是周六 02:00
+	start, end, ok := nextRecurringWindow(rule, time.Date(2024, 1, 3, 10, 0, 0, 0, s.loc))
+	s.Require().True(ok)
+	assert.True(s.T(), start.Equal(time.Date(2024, 1, 6, 2, 0, 0, 0, s.loc)))
+	assert.True(s.T(), end.Equal(time.Date(2024, 1, 6, 5, 0, 0, 0, s.loc)))
+
+	// 窗口进行中
+	start, _, ok = nextRecurringWindow(rule, time.Date(2024, 1, 6, 3, 0, 0, 0, s.loc))
+	s.Require().True(ok)
+	assert.True(s.T(), start.Equal(time.Date(2024, 1, 6, 2, 0, 0, 0, s.loc)))
+
+	// 窗口结束后顺延到下周
+	start, _, ok = nextRecurringWindow(rule, time.Date(2024, 1, 6, 5, 0, 0, 0, s.loc))
+	s.Require().True(ok)
+	assert.True(s.T(), start.Equal(time.Date(2024, 1, 13, 2, 0, 0, 0, s.loc)))
+}
+
+// TestRecurringWindowDue 测试提前量与已创建窗口的判断
+func (s *SilenceServiceTestSuite) TestRecurringWindowDue() {
+	rule := s.rule()
+
+	_, _, due := recurringWindowDue(rule, time.Date(2024, 1, 6, 0, 59, 0, 0, s.loc))
+	assert.False(s.T(), due)
+
+	start, _, due := recurringWindowDue(rule, time.Date(2024, 1, 6, 1, 0, 0, 0, s.loc))
+	assert.True(s.T(), due)
+
+	rule.LastWindowStart = &start
+	_, _, due = recurringWindowDue(rule, time.Date(2024, 1, 6, 2, 30, 0, 0, s.loc))
+	assert.False(s.T(), due)
+
+	// 服务停机错过提前量时，窗口进行中仍会补建
+	rule.LastWindowStart = nil
+	_, _, due = recurringWindowDue(rule, time.Date(2024, 1, 6, 4, 0, 0, 0, s.loc))
+	assert.True(s.T(), due)
+}
+
+// TestValidateRecurring 测试周期静默校验
+func (s *SilenceServiceTestSuite) TestValidateRecurring() {
+	assert.NoError(s.T(), ValidateRecurringSilence(s.rule()))
+
+	rule := s.rule()
+	rule.WeekdayList = []int{7}
+	assert.Error(s.T(), ValidateRecurringSilence(rule))
+
+	rule = s.rule()
+	rule.StartTime = "25:00"
+	assert.Error(s.T(), ValidateRecurringSilence(rule))
+
+	rule = s.rule()
+	rule.DurationMinutes = recurringSilenceMaxDuration + 1
+	assert.Error(s.T(), ValidateRecurringSilence(rule))
+
+	rule = s.rule()
+	rule.Timezone = "Mars/Base"
+	assert.Error(s.T(), ValidateRecurringSilence(rule))
+}
+
+// TestSilenceMatchers 测试匹配器语义与校验
+func (s *SilenceServiceTestSuite) TestSilenceMatchers() {
+	labels := map[string]string{"alertname": "HighCPU", "namespace": "payments"}
+	assert.True(s.T(), silenceMatches([]models.Matcher{{Name: "alertname", Value: "HighCPU", IsEqual: true}}, labels))
+	assert.True(s.T(), silenceMatches([]models.Matcher{{Name: "alertname", Value: "High.*", IsRegex: true, IsEqual: true}}, labels))
+	assert.False(s.T(), silenceMatches([]models.Matcher{{Name: "alertname", Value: "High", IsRegex: true, IsEqual: true}}, labels), "正则需全匹配")
+	assert.False(s.T(), silenceMatches([]models.Matcher{{Name: "namespace", Value: "payments", IsEqual: false}}, labels))
+	assert.False(s.T(), silenceMatches([]models.Matcher{
+		{Name: "alertname", Value: "HighCPU", IsEqual: true},
+		{Name: "pod", Value: "api-0", IsEqual: true},
+	}, labels))
+
+	assert.NoError(s.T(), ValidateSilenceMatchers([]models.Matcher{{Name: "alertname", Value: "HighCPU", IsEqual: true}}))
+	assert.Error(s.T(), ValidateSilenceMatchers(nil))
+	assert.Error(s.T(), ValidateSilenceMatchers([]models.Matcher{{Name: "alertname", Value: ".*", IsRegex: true, IsEqual: true}}))
+	assert.Error(s.T(), ValidateSilenceMatchers([]models.Matcher{{Name: "alertname", Value: "x", IsEqual: false}}))
+	assert.Error(s.T(), ValidateSilenceMatchers([]models.Matcher{{Name: "alertname", Value: "(", IsRegex: true, IsEqual: true}}))
+}
+
+// TestOrphanedSilences 测试孤立静默：跳过已过期静默，按过期时间排序
+func (s *SilenceServiceTestSuite) TestOrphanedSilences() {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	silences := []models.Silence{
+		{ID: "used", Matchers: []models.Matcher{{Name: "alertname", Value: "HighCPU", IsEqual: true}}, EndsAt: now.Add(time.Hour), Status: models.SilenceStatus{State: "active"}},
+		{ID: "late", Matchers: []models.Matcher{{Name: "alertname", Value: "Gone", IsEqual: true}}, EndsAt: now.Add(2 * time.Hour), Status: models.SilenceStatus{State: "active"}},
+		{ID: "soon", Matchers: []models.Matcher{{Name: "alertname", Value: "Gone", IsEqual: true}}, EndsAt: now.Add(time.Hour), Status: models.SilenceStatus{State: "pending"}},
+		{ID: "expired", Matchers: []models.Matcher{{Name: "alertname", Value: "Gone", IsEqual: true}}, EndsAt: now.Add(-time.Hour), Status: models.SilenceStatus{State: "expired"}},
+	}
+	orphaned := findOrphanedSilences(silences, []map[string]string{{"alertname": "HighCPU"}}, now)
+	s.Require().Len(orphaned, 2)
+	assert.Equal(s.T(), "soon", orphaned[0].ID)
+	assert.Equal(s.T(), int64(3600), orphaned[0].ExpiresIn)
+	assert.Equal(s.T(), "late", orphaned[1].ID)
+}
+
+// TestBulkSilenceMatchers 测试按分组与按告警生成匹配器
+func (s *SilenceServiceTestSuite) TestBulkSilenceMatchers() {
+	group := &models.AlertGroup{
+		Labels: map[string]string{"alertname": "HighCPU", "namespace": "payments"},
+		Alerts: []models.Alert{
+			{Fingerprint: "a", Labels: map[string]string{"alertname": "HighCPU", "pod": "api-0"}},
+			{Fingerprint: "b", Labels: map[string]string{"alertname": "HighCPU", "pod": "api-1"}, Status: models.AlertStatus{SilencedBy: []string{"s1"}}},
+			{Fingerprint: "c", Labels: map[string]string{"alertname": "HighCPU", "pod": "api-2"}},
+		},
+	}
+	sets := bulkSilenceMatchers(group, models.BulkSilenceByGroup)
+	s.Require().Len(sets, 1)
+	assert.Equal(s.T(), []models.Matcher{
+		{Name: "alertname", Value: "HighCPU", IsEqual: true},
+		{Name: "namespace", Value: "payments", IsEqual: true},
+	}, sets[0])
+
+	sets = bulkSilenceMatchers(group, models.BulkSilenceByAlert)
+	s.Require().Len(sets, 2, "已静默的告警跳过")
+	assert.Equal(s.T(), "api-2", sets[1][1].Value)
+
+	assert.Empty(s.T(), bulkSilenceMatchers(&models.AlertGroup{}, models.BulkSilenceByGroup))
+}
+
+// TestSilenceServiceTestSuite 运行静默管理测试套件
+func TestSilenceServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(SilenceServiceTestSuite))
+}
@@ -0,0 +1,536 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+
+	"gorm.io/gorm"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// SLO 参数
+const (
+	SLODefaultWindow = "30d"
+	sloMinWindow     = 24 * time.Hour
+	sloMaxWindow     = 90 * 24 * time.Hour
+	sloWindowParam   = "window"
+	sloHistoryPoints = 120 // 未指定步长时错误预算趋势的采样点数
+
+	// sloRecordPrefix 生成的记录规则名称前缀，完整名称如 slo:sli_error:ratio_rate5m
+	sloRecordPrefix = "slo:sli_error:ratio_rate"
+)
+
+// sloTargetKinds SLO 可以绑定的对象类型
+var sloTargetKinds = map[string]bool{
+	"Deployment": true, "StatefulSet": true, "DaemonSet": true, "Rollout": true, "Ingress": true,
+}
+
+// sloBurnRateAlerts 多窗口多消耗速率告警（参考 Google SRE Workbook）：
+// 在 long 区间内消耗了统计窗口 budgetPercent% 的错误预算，且 short 区间仍在以同样速率消耗时告警
+var sloBurnRateAlerts = []struct {
+	severity      string
+	long, short   string
+	budgetPercent float64
+}{
+	{"critical", "1h", "5m", 2},
+	{"critical", "6h", "30m", 5},
+	{"warning", "1d", "2h", 10},
+	{"warning", "3d", "6h", 10},
+}
+
+// sloBurnRateWindows 状态中展示的消耗速率区间
+var sloBurnRateWindows = []string{"5m", "30m", "1h", "2h", "6h", "1d", "3d"}
+
+// sloRuleNameInvalidChars 生成 PrometheusRule 名称时替换的字符
+var sloRuleNameInvalidChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// sloTemplates 内置 SLI 模板（基于 ingress-nginx 控制器指标）
+var sloTemplates = []models.SLOTemplate{
+	{
+		ID: "ingress-nginx-availability", Name: "Ingress 可用性",
+		Description: "非 5xx 响应占全部请求的比例",
+		Params: []models.PrometheusRuleTemplateParam{
+			{Name: "namespace", Label: "命名空间"},
+			{Name: "ingress", Label: "Ingress 名称"},
+		},
+		GoodQuery:  `sum(rate(nginx_ingress_controller_requests{exported_namespace="${namespace}",ingress="${ingress}",status!~"5.."}[${window}]))`,
+		TotalQuery: `sum(rate(nginx_ingress_controller_requests{exported_namespace="${namespace}",ingress="${ingress}"}[${window}]))`,
+	},
+	{
+		ID: "ingress-nginx-latency", Name: "Ingress 延迟",
+		Description: "响应时间不超过阈值的请求占比，阈值需与 ingress-nginx 直方图的 bucket 边界一致（默认 0.005 ~ 10 秒）",
+		Params: []models.PrometheusRuleTemplateParam{
+			{Name: "namespace", Label: "命名空间"},
+			{Name: "ingress", Label: "Ingress 名称"},
+			{Name: "le", Label: "延迟阈值", Default: "0.25", Unit: "s"},
+		},
+		GoodQuery:  `sum(rate(nginx_ingress_controller_request_duration_seconds_bucket{exported_namespace="${namespace}",ingress="${ingress}",le="${le}"}[${window}]))`,
+		TotalQuery: `sum(rate(nginx_ingress_controller_request_duration_seconds_count{exported_namespace="${namespace}",ingress="${ingress}"}[${window}]))`,
+	},
+}
+
+// SLOService SLO 定义与错误预算计算服务
+type SLOService struct {
+	db            *gorm.DB
+	prometheusSvc *PrometheusService
+	ruleSvc       *PrometheusRuleService
+
+	// configFor 获取集群监控配置，now 获取当前时间，测试时可替换
+	configFor func(clusterID uint) (*models.MonitoringConfig, error)
+	now       func() time.Time
+}
+
+// NewSLOService 创建 SLO 服务
+func NewSLOService(db *gorm.DB, monitoringConfigSvc *MonitoringConfigService, prometheusSvc *PrometheusService, ruleSvc *PrometheusRuleService) *SLOService {
+	return &SLOService{
+		db:            db,
+		prometheusSvc: prometheusSvc,
+		ruleSvc:       ruleSvc,
+		configFor:     monitoringConfigSvc.GetMonitoringConfig,
+		now:           time.Now,
+	}
+}
+
+// SLOTemplates 获取内置 SLI 模板
+func SLOTemplates() []models.SLOTemplate {
+	return sloTemplates
+}
+
+// List 获取集群的 SLO
+func (s *SLOService) List(clusterID uint, query *models.SLOListQuery) ([]models.SLO, error) {
+	db := s.db.Model(&models.SLO{}).Where("cluster_id = ?", clusterID)
+	if query.TargetKind != "" {
+		db = db.Where("target_kind = ?", query.TargetKind)
+	}
+	if query.Namespace != "" {
+		db = db.Where("namespace = ?", query.Namespace)
+	}
+	if query.TargetName != "" {
+		db = db.Where("target_name = ?", query.TargetName)
+	}
+	var slos []models.SLO
+	if err := db.Order("namespace, name").Find(&slos).Error; err != nil {
+		return nil, fmt.Errorf("查询 SLO 失败: %w", err)
+	}
+	return slos, nil
+}
+
+// Get 获取集群中的 SLO
+func (s *SLOService) Get(clusterID, id uint) (*models.SLO, error) {
+	var slo models.SLO
+	if err := s.db.Where("cluster_id = ?", clusterID).First(&slo, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("SLO 不存在: %d", id)
+		}
+		return nil, fmt.Errorf("查询 SLO 失败: %w", err)
+	}
+	return &slo, nil
+}
+
+// Save 创建（existing 为 nil）或更新 SLO
+func (s *SLOService) Save(clusterID uint, existing *models.SLO, req *models.SLORequest, userID uint, username string) (*models.SLO, error) {
+	slo := existing
+	if slo == nil {
+		slo = &models.SLO{ClusterID: clusterID, CreatedBy: userID, CreatedByName: username}
+	}
+	if err := applySLORequest(slo, req); err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := s.db.Model(&models.SLO{}).Where("cluster_id = ? AND name = ? AND id <> ?", clusterID, slo.Name, slo.ID).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("查询 SLO 失败: %w", err)
+	}
+	if count > 0 {
+		return nil, fmt.Errorf("SLO 名称已存在: %s", slo.Name)
+	}
+	if err := s.db.Save(slo).Error; err != nil {
+		return nil, fmt.Errorf("保存 SLO 失败: %w", err)
+	}
+	return slo, nil
+}
+
+// Delete 删除 SLO，已生成的 PrometheusRule 一并删除
+func (s *SLOService) Delete(ctx context.Context, cluster *models.Cluster, slo *models.SLO) error {
+	if slo.RuleName != "" {
+		if err := s.ruleSvc.DeleteRule(ctx, cluster, slo.RuleNamespace, slo.RuleName); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	if err := s.db.Delete(slo).Error; err != nil {
+		return fmt.Errorf("删除 SLO 失败: %w", err)
+	}
+	return nil
+}
+
+// SyncRule 生成或更新 SLO 对应的 PrometheusRule（记录规则与多窗口消耗速率告警），并记录在 SLO 上
+func (s *SLOService) SyncRule(ctx context.Context, cluster *models.Cluster, slo *models.SLO) (*models.PrometheusRule, error) {
+	rule, err := BuildSLORule(slo)
+	if err != nil {
+		return nil, err
+	}
+	if errs := ValidatePrometheusRule(rule); len(errs) > 0 {
+		return nil, fmt.Errorf("生成的规则校验失败: %s %s", errs[0].Path, errs[0].Message)
+	}
+
+	existing, err := s.ruleSvc.GetRule(ctx, cluster, rule.Namespace, rule.Name)
+	switch {
+	case err == nil:
+		rule.ResourceVersion = existing.ResourceVersion
+		rule, err = s.ruleSvc.UpdateRule(ctx, cluster, rule)
+	case apierrors.IsNotFound(err):
+		rule, err = s.ruleSvc.CreateRule(ctx, cluster, rule)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	slo.RuleNamespace, slo.RuleName = rule.Namespace, rule.Name
+	if err := s.db.Model(slo).Updates(map[string]interface{}{"rule_namespace": slo.RuleNamespace, "rule_name": slo.RuleName}).Error; err != nil {
+		return nil, fmt.Errorf("保存 SLO 失败: %w", err)
+	}
+	return rule, nil
+}
+
+// Status 计算 SLO 当前的达标率、错误预算与各区间消耗速率
+func (s *SLOService) Status(ctx context.Context, slo *models.SLO) *models.SLOStatus {
+	budget := sloErrorBudget(slo.Objective)
+	status := &models.SLOStatus{
+		SLO:         slo,
+		ErrorBudget: roundTo(budget*100, 4),
+		BurnRates:   make([]models.SLOBurnRate, 0, len(sloBurnRateWindows)),
+		Alerts:      []models.SLOBurnRateAlert{},
+	}
+	config, err := s.configFor(slo.ClusterID)
+	if err != nil || config.Type == "disabled" {
+		status.Error = "集群未配置监控"
+		return status
+	}
+
+	now := s.now()
+	errorRatio := func(window string) (*float64, error) {
+		query, err := sloErrorRatioQuery(slo, window, s.prometheusSvc.buildClusterSelector(config.Labels, ""))
+		if err != nil {
+			return nil, err
+		}
+		resp, err := s.prometheusSvc.QueryInstant(ctx, config, query, now)
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range resp.Data.Result {
+			if len(result.Value) < 2 {
+				continue
+			}
+			v, err := strconv.ParseFloat(fmt.Sprintf("%v", result.Value[1]), 64)
+			if err == nil && !math.IsNaN(v) && !math.IsInf(v, 0) {
+				return &v, nil
+			}
+		}
+		return nil, nil
+	}
+
+	ratio, err := errorRatio(slo.Window)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	if ratio != nil {
+		sli := roundTo((1-*ratio)*100, 4)
+		consumed := roundTo(*ratio/budget*100, 2)
+		remaining := roundTo(100-consumed, 2)
+		status.SLI, status.BudgetConsumed, status.BudgetRemaining = &sli, &consumed, &remaining
+	}
+
+	rates := make(map[string]*float64, len(sloBurnRateWindows))
+	for _, window := range sloBurnRateWindows {
+		ratio, err := errorRatio(window)
+		if err != nil {
+			status.Error = err.Error()
+			return status
+		}
+		var rate *float64
+		if ratio != nil {
+			v := roundTo(*ratio/budget, 3)
+			rate = &v
+		}
+		rates[window] = rate
+		status.BurnRates = append(status.BurnRates, models.SLOBurnRate{Window: window, Rate: rate})
+	}
+	windowDuration, _ := ParsePromDuration(slo.Window)
+	for _, alert := range sloBurnRateAlerts {
+		threshold, ok := sloBurnRateThreshold(windowDuration, alert.long, alert.budgetPercent)
+		if !ok {
+			continue
+		}
+		long, short := rates[alert.long], rates[alert.short]
+		status.Alerts = append(status.Alerts, models.SLOBurnRateAlert{
+			Severity:    alert.severity,
+			LongWindow:  alert.long,
+			ShortWindow: alert.short,
+			Threshold:   threshold,
+			Firing:      long != nil && short != nil && *long > threshold && *short > threshold,
+		})
+	}
+	return status
+}
+
+// BudgetHistory 查询最近 timeRange（如 7d）内的错误预算、达标率与 1h 消耗速率趋势，step 为空时按约 120 个点计算
+func (s *SLOService) BudgetHistory(ctx context.Context, slo *models.SLO, timeRange, step string) (*models.SLOBudgetHistory, error) {
+	start, end, err := s.prometheusSvc.parseTimeRange(timeRange)
+	if err != nil {
+		return nil, err
+	}
+	if step == "" {
+		seconds := (end - start) / sloHistoryPoints
+		if seconds < 60 {
+			seconds = 60
+		}
+		step = fmt.Sprintf("%ds", seconds)
+	}
+	config, err := s.configFor(slo.ClusterID)
+	if err != nil {
+		return nil, fmt.Errorf("获取监控配置失败: %w", err)
+	}
+	if config.Type == "disabled" {
+		return nil, fmt.Errorf("集群未配置监控")
+	}
+	selector := s.prometheusSvc.buildClusterSelector(config.Labels, "")
+	rangeQuery := func(window string) ([]models.DataPoint, error) {
+		query, err := sloErrorRatioQuery(slo, window, selector)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := s.prometheusSvc.QueryPrometheus(ctx, config, &models.MetricsQuery{Query: query, Start: start, End: end, Step: step})
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.Data.Result) == 0 {
+			return nil, nil
+		}
+		return metricDataPoints(resp.Data.Result[0].Values), nil
+	}
+
+	budget := sloErrorBudget(slo.Objective)
+	history := &models.SLOBudgetHistory{BudgetRemaining: []models.DataPoint{}, SLI: []models.DataPoint{}, BurnRate: []models.DataPoint{}}
+	points, err := rangeQuery(slo.Window)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range points {
+		history.BudgetRemaining = append(history.BudgetRemaining, models.DataPoint{Timestamp: p.Timestamp, Value: roundTo(100-p.Value/budget*100, 2)})
+		history.SLI = append(history.SLI, models.DataPoint{Timestamp: p.Timestamp, Value: roundTo((1-p.Value)*100, 4)})
+	}
+	if points, err = rangeQuery("1h"); err != nil {
+		return nil, err
+	}
+	for _, p := range points {
+		history.BurnRate = append(history.BurnRate, models.DataPoint{Timestamp: p.Timestamp, Value: roundTo(p.Value/budget, 3)})
+	}
+	return history, nil
+}
+
+// BuildSLORule 生成 SLO 的 PrometheusRule：各区间错误率的记录规则，以及多窗口消耗速率告警
+func BuildSLORule(slo *models.SLO) (*models.PrometheusRule, error) {
+	windowDuration, err := ParsePromDuration(slo.Window)
+	if err != nil {
+		return nil, err
+	}
+	budget := sloErrorBudget(slo.Objective)
+	sloID := strconv.FormatUint(uint64(slo.ID), 10)
+	labels := map[string]string{"slo": slo.Name, "slo_id": sloID}
+
+	windows := append([]string{}, sloBurnRateWindows...)
+	if !containsString(windows, slo.Window) {
+		windows = append(windows, slo.Window)
+	}
+	records := models.PrometheusRuleGroup{Name: fmt.Sprintf("slo-%s-sli", sloID)}
+	for _, window := range windows {
+		expr, err := sloErrorRatioQuery(slo, window, "")
+		if err != nil {
+			return nil, err
+		}
+		records.Rules = append(records.Rules, models.PrometheusAlertRule{Record: sloRecordPrefix + window, Expr: expr, Labels: labels})
+	}
+
+	alerts := models.PrometheusRuleGroup{Name: fmt.Sprintf("slo-%s-alerts", sloID)}
+	for _, alert := range sloBurnRateAlerts {
+		threshold, ok := sloBurnRateThreshold(windowDuration, alert.long, alert.budgetPercent)
+		if !ok {
+			continue
+		}
+		matcher := fmt.Sprintf(`{slo_id="%s"}`, sloID)
+		alerts.Rules = append(alerts.Rules, models.PrometheusAlertRule{
+			Alert: "SLOErrorBudgetBurn",
+			Expr: fmt.Sprintf("%s%s%s > (%g * %g) and %s%s%s > (%g * %g)",
+				sloRecordPrefix, alert.long, matcher, threshold, budget,
+				sloRecordPrefix, alert.short, matcher, threshold, budget),
+			Labels: map[string]string{"severity": alert.severity, "slo": slo.Name, "long_window": alert.long, "short_window": alert.short},
+			Annotations: map[string]string{
+				"summary": fmt.Sprintf("SLO %s 错误预算消耗过快", slo.Name),
+				"description": fmt.Sprintf("%s %s/%s 的错误预算在 %s 与 %s 内的消耗速率均超过 %g 倍，按此速率 %s 窗口内的预算将提前耗尽",
+					slo.TargetKind, slo.Namespace, slo.TargetName, alert.long, alert.short, threshold, slo.Window),
+			},
+		})
+	}
+
+	groups := []models.PrometheusRuleGroup{records}
+	if len(alerts.Rules) > 0 {
+		groups = append(groups, alerts)
+	}
+	name := "slo-" + sloID
+	if slug := strings.Trim(sloRuleNameInvalidChars.ReplaceAllString(strings.ToLower(slo.Name), "-"), "-"); slug != "" {
+		name = "slo-" + slug + "-" + sloID
+	}
+	return &models.PrometheusRule{
+		Name:      name,
+		Namespace: slo.Namespace,
+		Labels:    map[string]string{prometheusRuleManagedByLabel: "kubepolaris", "kubepolaris.io/slo-id": sloID},
+		Groups:    groups,
+	}, nil
+}
+
+// sloErrorBudget 允许的错误比例，舍入以消除浮点误差（99.9 → 0.001），便于生成可读的告警表达式
+func sloErrorBudget(objective float64) float64 {
+	return roundTo(1-objective/100, 10)
+}
+
+// sloBurnRateThreshold 消耗速率阈值：long 区间内消耗统计窗口 budgetPercent% 的预算对应的速率，区间超过统计窗口时不适用
+func sloBurnRateThreshold(window time.Duration, long string, budgetPercent float64) (float64, bool) {
+	longDuration, err := ParsePromDuration(long)
+	if err != nil || longDuration >= window {
+		return 0, false
+	}
+	return roundTo(budgetPercent/100*float64(window)/float64(longDuration), 2), true
+}
+
+// sloErrorRatioQuery 生成 window 区间内错误率的 PromQL：1 - good / total，selector 不为空时注入到每个向量选择器
+func sloErrorRatioQuery(slo *models.SLO, window, selector string) (string, error) {
+	render := func(query string) (string, error) {
+		query = strings.ReplaceAll(query, "${"+sloWindowParam+"}", window)
+		return InjectPromQLSelector(query, selector)
+	}
+	good, err := render(slo.GoodQuery)
+	if err != nil {
+		return "", fmt.Errorf("解析 good 表达式失败: %w", err)
+	}
+	total, err := render(slo.TotalQuery)
+	if err != nil {
+		return "", fmt.Errorf("解析 total 表达式失败: %w", err)
+	}
+	return fmt.Sprintf("1 - ((%s) / (%s))", good, total), nil
+}
+
+// applySLORequest 校验请求并填充 SLO，使用模板时渲染 good / total 表达式
+func applySLORequest(slo *models.SLO, req *models.SLORequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return fmt.Errorf("名称不能为空")
+	}
+	if !sloTargetKinds[req.TargetKind] {
+		return fmt.Errorf("不支持的对象类型: %s", req.TargetKind)
+	}
+	if req.Objective <= 0 || req.Objective >= 100 {
+		return fmt.Errorf("目标必须在 0 到 100 之间")
+	}
+	window := req.Window
+	if window == "" {
+		window = SLODefaultWindow
+	}
+	windowDuration, err := ParsePromDuration(window)
+	if err != nil {
+		return err
+	}
+	if windowDuration < sloMinWindow || windowDuration > sloMaxWindow {
+		return fmt.Errorf("统计窗口必须在 1d 到 90d 之间")
+	}
+
+	good, total := strings.TrimSpace(req.GoodQuery), strings.TrimSpace(req.TotalQuery)
+	params := ""
+	if req.TemplateID != "" {
+		values := map[string]string{"namespace": req.Namespace}
+		if req.TargetKind == "Ingress" {
+			values["ingress"] = req.TargetName
+		}
+		for k, v := range req.TemplateParams {
+			values[k] = v
+		}
+		if good, total, err = renderSLOTemplate(req.TemplateID, values); err != nil {
+			return err
+		}
+		data, _ := json.Marshal(req.TemplateParams)
+		params = string(data)
+	}
+	for label, query := range map[string]string{"good": good, "total": total} {
+		if query == "" {
+			return fmt.Errorf("%s 表达式不能为空", label)
+		}
+		if len(query) > consoleMaxQueryLength {
+			return fmt.Errorf("%s 表达式过长（最多 %d 个字符）", label, consoleMaxQueryLength)
+		}
+		if !strings.Contains(query, "${"+sloWindowParam+"}") {
+			return fmt.Errorf("%s 表达式需要使用 ${window} 作为区间，如 rate(http_requests_total[${window}])", label)
+		}
+		if _, err := InjectPromQLSelector(strings.ReplaceAll(query, "${"+sloWindowParam+"}", "5m"), `__check__="1"`); err != nil {
+			return fmt.Errorf("解析 %s 表达式失败: %w", label, err)
+		}
+	}
+
+	slo.Name = name
+	slo.Description = req.Description
+	slo.TargetKind = req.TargetKind
+	slo.Namespace = req.Namespace
+	slo.TargetName = req.TargetName
+	slo.TemplateID = req.TemplateID
+	slo.TemplateParams = params
+	slo.GoodQuery = good
+	slo.TotalQuery = total
+	slo.Objective = req.Objective
+	slo.Window = window
+	return nil
+}
+
+// renderSLOTemplate 使用参数渲染 SLI 模板，${window} 保留到计算时替换
+func renderSLOTemplate(id string, params map[string]string) (string, string, error) {
+	var tmpl *models.SLOTemplate
+	for i := range sloTemplates {
+		if sloTemplates[i].ID == id {
+			tmpl = &sloTemplates[i]
+			break
+		}
+	}
+	if tmpl == nil {
+		return "", "", fmt.Errorf("SLI 模板不存在: %s", id)
+	}
+
+	values := map[string]string{sloWindowParam: "${" + sloWindowParam + "}"}
+	for _, param := range tmpl.Params {
+		v := params[param.Name]
+		if v == "" {
+			v = param.Default
+		}
+		if v == "" {
+			return "", "", fmt.Errorf("缺少模板参数: %s", param.Label)
+		}
+		if strings.ContainsAny(v, `"\`+"\n") {
+			return "", "", fmt.Errorf("模板参数 %s 包含非法字符", param.Label)
+		}
+		values[param.Name] = v
+	}
+	if _, err := strconv.ParseFloat(values["le"], 64); values["le"] != "" && err != nil {
+		return "", "", fmt.Errorf("延迟阈值必须是数字: %s", values["le"])
+	}
+	render := func(s string) string {
+		return promTemplateParam.ReplaceAllStringFunc(s, func(m string) string {
+			return values[promTemplateParam.FindStringSubmatch(m)[1]]
+		})
+	}
+	return render(tmpl.GoodQuery), render(tmpl.TotalQuery), nil
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// SLOServiceTestSuite 定义 SLO 测试套件
+type SLOServiceTestSuite struct {
+	suite.Suite
+	svc *SLOService
+}
+
+// SetupTest 每个测试前的设置
+func (s *SLOServiceTestSuite) SetupTest() {
+	s.svc = NewSLOService(nil, nil, NewPrometheusService(), nil)
+	s.svc.now = func() time.Time { return time.Date(2024, 3, 15, 10, 0, 0, 0, time.UTC) }
+}
+
+func customSLORequest() *models.SLORequest {
+	return &models.SLORequest{
+		Name: "api-availability", TargetKind: "Deployment", Namespace: "prod", TargetName: "api",
+		GoodQuery:  `sum(rate(http_requests_total{job="api",code!~"5.."}[${window}]))`,
+		TotalQuery: `sum(rate(http_requests_total{job="api"}[${window}]))`,
+		Objective:  99.9,
+	}
+}
+
+// TestApplySLOTemplate 测试 ingress-nginx 模板渲染与默认值
+func (s *SLOServiceTestSuite) TestApplySLOTemplate() {
+	slo := &models.SLO{}
+	err := applySLORequest(slo, &models.SLORequest{
+		Name: "web-latency", TargetKind: "Ingress", Namespace: "prod", TargetName: "web",
+		TemplateID: "ingress-nginx-latency", TemplateParams: map[string]string{"le": "0.5"}, Objective: 99,
+	})
+	s.Require().NoError(err)
+	assert.Equal(s.T(), SLODefaultWindow, slo.Window)
+	assert.Equal(s.T(), `sum(rate(nginx_ingress_controller_request_duration_seconds_bucket{exported_namespace="prod",ingress="web",le="0.5"}[${window}]))`, slo.GoodQuery)
+	assert.Contains(s.T(), slo.TotalQuery, `_count{exported_namespace="prod",ingress="web"}[${window}]`)
+	assert.Equal(s.T(), `{"le":"0.5"}`, slo.TemplateParams)
+
+	err = applySLORequest(&models.SLO{}, &models.SLORequest{
+		Name: "api", TargetKind: "Deployment", Namespace: "prod", TargetName: "api",
+		TemplateID: "ingress-nginx-availability", Objective: 99,
+	})
+	assert.ErrorContains(s.T(), err, "Ingress 名称", "绑定工作负载时需要显式指定 Ingress")
+}
+
+// TestApplySLOValidation 测试请求校验
+func (s *SLOServiceTestSuite) TestApplySLOValidation() {
+	cases := map[string]func(req *models.SLORequest){
+		"不支持的对象类型":       func(req *models.SLORequest) { req.TargetKind = "Pod" },
+		"统计窗口必须在":        func(req *models.SLORequest) { req.Window = "2h" },
+		"需要使用 ${window}": func(req *models.SLORequest) { req.TotalQuery = `sum(rate(http_requests_total[5m]))` },
+		"解析 good 表达式失败":  func(req *models.SLORequest) { req.GoodQuery = `sum(rate(x{a="b}[${window}]))` },
+		"SLI 模板不存在":      func(req *models.SLORequest) { req.TemplateID = "unknown" },
+		"包含非法字符": func(req *models.SLORequest) {
+			req.TemplateID = "ingress-nginx-availability"
+			req.TemplateParams = map[string]string{"ingress": `web"}`}
+		},
+		"延迟阈值必须是数字": func(req *models.SLORequest) {
+			req.TemplateID = "ingress-nginx-latency"
+			req.TemplateParams = map[string]string{"ingress": "web", "le": "300ms"}
+		},
+	}
+	for message, mutate := range cases {
+		req := customSLORequest()
+		mutate(req)
+		assert.ErrorContains(s.T(), applySLORequest(&models.SLO{}, req), message)
+	}
+	s.Require().NoError(applySLORequest(&models.SLO{}, customSLORequest()))
+}
+
+// TestBuildSLORule 测试生成的记录规则与多窗口消耗速率告警
+func (s *SLOServiceTestSuite) TestBuildSLORule() {
+	slo := &models.SLO{ID: 7}
+	s.Require().NoError(applySLORequest(slo, customSLORequest()))
+	rule, err := BuildSLORule(slo)
+	s.Require().NoError(err)
+	assert.Empty(s.T(), ValidatePrometheusRule(rule))
+	assert.Equal(s.T(), "slo-api-availability-7", rule.Name)
+	assert.Equal(s.T(), "prod", rule.Namespace)
+	s.Require().Len(rule.Groups, 2)
+
+	records := rule.Groups[0].Rules
+	s.Require().Len(records, 8)
+	assert.Equal(s.T(), "slo:sli_error:ratio_rate30d", records[7].Record)
+	assert.Equal(s.T(), `1 - ((sum(rate(http_requests_total{job="api",code!~"5.."}[30d]))) / (sum(rate(http_requests_total{job="api"}[30d]))))`, records[7].Expr)
+	assert.Equal(s.T(), "7", records[0].Labels["slo_id"])
+
+	alerts := rule.Groups[1].Rules
+	s.Require().Len(alerts, 4)
+	assert.Equal(s.T(), `slo:sli_error:ratio_rate1h{slo_id="7"} > (14.4 * 0.001) and slo:sli_error:ratio_rate5m{slo_id="7"} > (14.4 * 0.001)`, alerts[0].Expr)
+	assert.Contains(s.T(), alerts[1].Expr, "(6 * 0.001)")
+	assert.Contains(s.T(), alerts[2].Expr, "(3 * 0.001)")
+	assert.Contains(s.T(), alerts[3].Expr, "(1 * 0.001)")
+	assert.Equal(s.T(), "warning", alerts[3].Labels["severity"])
+
+	// 统计窗口为 1d 时，不超过窗口的长区间才参与告警
+	slo.Window = "1d"
+	rule, err = BuildSLORule(slo)
+	s.Require().NoError(err)
+	assert.Len(s.T(), rule.Groups[0].Rules, 7)
+	assert.Len(s.T(), rule.Groups[1].Rules, 2)
+}
+
+// TestStatus 测试错误预算与消耗速率计算
+func (s *SLOServiceTestSuite) TestStatus() {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query().Get("query")
+		queries = append(queries, q)
+		value := "0.0001"
+		switch {
+		case strings.Contains(q, "[30d]"):
+			value = "0.0005"
+		case strings.Contains(q, "[1h]"):
+			value = "0.02"
+		case strings.Contains(q, "[5m]"):
+			value = "0.03"
+		case strings.Contains(q, "[3d]"):
+			value = "NaN"
+		}
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1710496800,"` + value + `"]}]}}`))
+	}))
+	defer server.Close()
+	s.svc.configFor = func(uint) (*models.MonitoringConfig, error) {
+		return &models.MonitoringConfig{Type: "prometheus", Endpoint: server.URL, Labels: map[string]string{"cluster": "prod"}}, nil
+	}
+
+	slo := &models.SLO{ID: 1, ClusterID: 1}
+	s.Require().NoError(applySLORequest(slo, customSLORequest()))
+	status := s.svc.Status(context.Background(), slo)
+	s.Require().Empty(status.Error)
+	assert.Equal(s.T(), 0.1, status.ErrorBudget)
+	assert.Equal(s.T(), 99.95, *status.SLI)
+	assert.Equal(s.T(), 50.0, *status.BudgetConsumed)
+	assert.Equal(s.T(), 50.0, *status.BudgetRemaining)
+
+	s.Require().Len(status.BurnRates, 7)
+	assert.Equal(s.T(), "5m", status.BurnRates[0].Window)
+	assert.Equal(s.T(), 30.0, *status.BurnRates[0].Rate)
+	assert.Equal(s.T(), 20.0, *status.BurnRates[2].Rate)
+	assert.Nil(s.T(), status.BurnRates[6].Rate, "区间内无请求")
+
+	s.Require().Len(status.Alerts, 4)
+	assert.True(s.T(), status.Alerts[0].Firing)
+	assert.Equal(s.T(), 14.4, status.Alerts[0].Threshold)
+	assert.False(s.T(), status.Alerts[1].Firing)
+	assert.False(s.T(), status.Alerts[3].Firing)
+
+	for _, q := range queries {
+		assert.Contains(s.T(), q, `cluster="prod"`)
+	}
+}
+
+// TestSLOServiceTestSuite 运行 SLO 测试套件
+func TestSLOServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(SLOServiceTestSuite))
+}
@@ -0,0 +1,476 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// deprecatedAPIs 内置的 API 废弃与移除版本，参考 Kubernetes Deprecated API Migration Guide
+var deprecatedAPIs = []models.DeprecatedAPI{
+	// 1.16
+	{Group: "extensions", Version: "v1beta1", Kind: "Deployment", Resource: "deployments", DeprecatedIn: "1.9", RemovedIn: "1.16", Replacement: "apps/v1"},
+	{Group: "extensions", Version: "v1beta1", Kind: "DaemonSet", Resource: "daemonsets", DeprecatedIn: "1.9", RemovedIn: "1.16", Replacement: "apps/v1"},
+	{Group: "extensions", Version: "v1beta1", Kind: "ReplicaSet", Resource: "replicasets", DeprecatedIn: "1.9", RemovedIn: "1.16", Replacement: "apps/v1"},
+	{Group: "extensions", Version: "v1beta1", Kind: "NetworkPolicy", Resource: "networkpolicies", DeprecatedIn: "1.9", RemovedIn: "1.16", Replacement: "networking.k8s.io/v1"},
+	{Group: "extensions", Version: "v1beta1", Kind: "PodSecurityPolicy", Resource: "podsecuritypolicies", DeprecatedIn: "1.11", RemovedIn: "1.16", Replacement: "policy/v1beta1"},
+	{Group: "apps", Version: "v1beta1", Kind: "Deployment", Resource: "deployments", DeprecatedIn: "1.9", RemovedIn: "1.16", Replacement: "apps/v1"},
+	{Group: "apps", Version: "v1beta1", Kind: "StatefulSet", Resource: "statefulsets", DeprecatedIn: "1.9", RemovedIn: "1.16", Replacement: "apps/v1"},
+	{Group: "apps", Version: "v1beta2", Kind: "Deployment", Resource: "deployments", DeprecatedIn: "1.9", RemovedIn: "1.16", Replacement: "apps/v1"},
+	{Group: "apps", Version: "v1beta2", Kind: "StatefulSet", Resource: "statefulsets", DeprecatedIn: "1.9", RemovedIn: "1.16", Replacement: "apps/v1"},
+	{Group: "apps", Version: "v1beta2", Kind: "DaemonSet", Resource: "daemonsets", DeprecatedIn: "1.9", RemovedIn: "1.16", Replacement: "apps/v1"},
+	{Group: "apps", Version: "v1beta2", Kind: "ReplicaSet", Resource: "replicasets", DeprecatedIn: "1.9", RemovedIn: "1.16", Replacement: "apps/v1"},
+	// 1.22
+	{Group: "admissionregistration.k8s.io", Version: "v1beta1", Kind: "MutatingWebhookConfiguration", Resource: "mutatingwebhookconfigurations", DeprecatedIn: "1.16", RemovedIn: "1.22", Replacement: "admissionregistration.k8s.io/v1"},
+	{Group: "admissionregistration.k8s.io", Version: "v1beta1", Kind: "ValidatingWebhookConfiguration", Resource: "validatingwebhookconfigurations", DeprecatedIn: "1.16", RemovedIn: "1.22", Replacement: "admissionregistration.k8s.io/v1"},
+	{Group: "apiextensions.k8s.io", Version: "v1beta1", Kind: "CustomResourceDefinition", Resource: "customresourcedefinitions", DeprecatedIn: "1.16", RemovedIn: "1.22", Replacement: "apiextensions.k8s.io/v1"},
+	{Group: "apiregistration.k8s.io", Version: "v1beta1", Kind: "APIService", Resource: "apiservices", DeprecatedIn: "1.19", RemovedIn: "1.22", Replacement: "apiregistration.k8s.io/v1"},
+	{Group: "authentication.k8s.io", Version: "v1beta1", Kind: "TokenReview", Resource: "tokenreviews", DeprecatedIn: "1.19", RemovedIn: "1.22", Replacement: "authentication.k8s.io/v1"},
+	{Group: "authorization.k8s.io", Version: "v1beta1", Kind: "SubjectAccessReview", Resource: "subjectaccessreviews", DeprecatedIn: "1.19", RemovedIn: "1.22", Replacement: "authorization.k8s.io/v1"},
+	{Group: "authorization.k8s.io", Version: "v1beta1", Kind: "LocalSubjectAccessReview", Resource: "localsubjectaccessreviews", DeprecatedIn: "1.19", RemovedIn: "1.22", Replacement: "authorization.k8s.io/v1"},
+	{Group: "authorization.k8s.io", Version: "v1beta1", Kind: "SelfSubjectAccessReview", Resource: "selfsubjectaccessreviews", DeprecatedIn: "1.19", RemovedIn: "1.22", Replacement: "authorization.k8s.io/v1"},
+	{Group: "certificates.k8s.io", Version: "v1beta1", Kind: "CertificateSigningRequest", Resource: "certificatesigningrequests", DeprecatedIn: "1.19", RemovedIn: "1.22", Replacement: "certificates.k8s.io/v1"},
+	{Group: "coordination.k8s.io", Version: "v1beta1", Kind: "Lease", Resource: "leases", DeprecatedIn: "1.19", RemovedIn: "1.22", Replacement: "coordination.k8s.io/v1"},
+	{Group: "extensions", Version: "v1beta1", Kind: "Ingress", Resource: "ingresses", DeprecatedIn: "1.14", RemovedIn: "1.22", Replacement: "networking.k8s.io/v1"},
+	{Group: "networking.k8s.io", Version: "v1beta1", Kind: "Ingress", Resource: "ingresses", DeprecatedIn: "1.19", RemovedIn: "1.22", Replacement: "networking.k8s.io/v1"},
+	{Group: "networking.k8s.io", Version: "v1beta1", Kind: "IngressClass", Resource: "ingressclasses", DeprecatedIn: "1.19", RemovedIn: "1.22", Replacement: "networking.k8s.io/v1"},
+	{Group: "rbac.authorization.k8s.io", Version: "v1beta1", Kind: "ClusterRole", Resource: "clusterroles", DeprecatedIn: "1.17", RemovedIn: "1.22", Replacement: "rbac.authorization.k8s.io/v1"},
+	{Group: "rbac.authorization.k8s.io", Version: "v1beta1", Kind: "ClusterRoleBinding", Resource: "clusterrolebindings", DeprecatedIn: "1.17", RemovedIn: "1.22", Replacement: "rbac.authorization.k8s.io/v1"},
+	{Group: "rbac.authorization.k8s.io", Version: "v1beta1", Kind: "Role", Resource: "roles", DeprecatedIn: "1.17", RemovedIn: "1.22", Replacement: "rbac.authorization.k8s.io/v1"},
+	{Group: "rbac.authorization.k8s.io", Version: "v1beta1", Kind: "RoleBinding", Resource: "rolebindings", DeprecatedIn: "1.17", RemovedIn: "1.22", Replacement: "rbac.authorization.k8s.io/v1"},
+	{Group: "scheduling.k8s.io", Version: "v1beta1", Kind: "PriorityClass", Resource: "priorityclasses", DeprecatedIn: "1.14", RemovedIn: "1.22", Replacement: "scheduling.k8s.io/v1"},
+	{Group: "storage.k8s.io", Version: "v1beta1", Kind: "CSIDriver", Resource: "csidrivers", DeprecatedIn: "1.19", RemovedIn: "1.22", Replacement: "storage.k8s.io/v1"},
+	{Group: "storage.k8s.io", Version: "v1beta1", Kind: "CSINode", Resource: "csinodes", DeprecatedIn: "1.17", RemovedIn: "1.22", Replacement: "storage.k8s.io/v1"},
+	{Group: "storage.k8s.io", Version: "v1beta1", Kind: "StorageClass", Resource: "storageclasses", DeprecatedIn: "1.19", RemovedIn: "1.22", Replacement: "storage.k8s.io/v1"},
+	{Group: "storage.k8s.io", Version: "v1beta1", Kind: "VolumeAttachment", Resource: "volumeattachments", DeprecatedIn: "1.19", RemovedIn: "1.22", Replacement: "storage.k8s.io/v1"},
+	// 1.25
+	{Group: "batch", Version: "v1beta1", Kind: "CronJob", Resource: "cronjobs", DeprecatedIn: "1.21", RemovedIn: "1.25", Replacement: "batch/v1"},
+	{Group: "discovery.k8s.io", Version: "v1beta1", Kind: "EndpointSlice", Resource: "endpointslices", DeprecatedIn: "1.21", RemovedIn: "1.25", Replacement: "discovery.k8s.io/v1"},
+	{Group: "events.k8s.io", Version: "v1beta1", Kind: "Event", Resource: "events", DeprecatedIn: "1.19", RemovedIn: "1.25", Replacement: "events.k8s.io/v1"},
+	{Group: "autoscaling", Version: "v2beta1", Kind: "HorizontalPodAutoscaler", Resource: "horizontalpodautoscalers", DeprecatedIn: "1.22", RemovedIn: "1.25", Replacement: "autoscaling/v2"},
+	{Group: "policy", Version: "v1beta1", Kind: "PodDisruptionBudget", Resource: "poddisruptionbudgets", DeprecatedIn: "1.21", RemovedIn: "1.25", Replacement: "policy/v1"},
+	{Group: "policy", Version: "v1beta1", Kind: "PodSecurityPolicy", Resource: "podsecuritypolicies", DeprecatedIn: "1.21", RemovedIn: "1.25"},
+	{Group: "node.k8s.io", Version: "v1beta1", Kind: "RuntimeClass", Resource: "runtimeclasses", DeprecatedIn: "1.20", RemovedIn: "1.25", Replacement: "node.k8s.io/v1"},
+	// 1.26
+	{Group: "flowcontrol.apiserver.k8s.io", Version: "v1beta1", Kind: "FlowSchema", Resource: "flowschemas", DeprecatedIn: "1.23", RemovedIn: "1.26", Replacement: "flowcontrol.apiserver.k8s.io/v1"},
+	{Group: "flowcontrol.apiserver.k8s.io", Version: "v1beta1", Kind: "PriorityLevelConfiguration", Resource: "prioritylevelconfigurations", DeprecatedIn: "1.23", RemovedIn: "1.26", Replacement: "flowcontrol.apiserver.k8s.io/v1"},
+	{Group: "autoscaling", Version: "v2beta2", Kind: "HorizontalPodAutoscaler", Resource: "horizontalpodautoscalers", DeprecatedIn: "1.23", RemovedIn: "1.26", Replacement: "autoscaling/v2"},
+	// 1.27
+	{Group: "storage.k8s.io", Version: "v1beta1", Kind: "CSIStorageCapacity", Resource: "csistoragecapacities", DeprecatedIn: "1.24", RemovedIn: "1.27", Replacement: "storage.k8s.io/v1"},
+	// 1.29
+	{Group: "flowcontrol.apiserver.k8s.io", Version: "v1beta2", Kind: "FlowSchema", Resource: "flowschemas", DeprecatedIn: "1.26", RemovedIn: "1.29", Replacement: "flowcontrol.apiserver.k8s.io/v1"},
+	{Group: "flowcontrol.apiserver.k8s.io", Version: "v1beta2", Kind: "PriorityLevelConfiguration", Resource: "prioritylevelconfigurations", DeprecatedIn: "1.26", RemovedIn: "1.29", Replacement: "flowcontrol.apiserver.k8s.io/v1"},
+	// 1.32
+	{Group: "flowcontrol.apiserver.k8s.io", Version: "v1beta3", Kind: "FlowSchema", Resource: "flowschemas", DeprecatedIn: "1.29", RemovedIn: "1.32", Replacement: "flowcontrol.apiserver.k8s.io/v1"},
+	{Group: "flowcontrol.apiserver.k8s.io", Version: "v1beta3", Kind: "PriorityLevelConfiguration", Resource: "prioritylevelconfigurations", DeprecatedIn: "1.29", RemovedIn: "1.32", Replacement: "flowcontrol.apiserver.k8s.io/v1"},
+}
+
+// deprecatedAPIUnscannedResources 不扫描存量对象的资源：Review 类资源不持久化，事件数量大且生命周期短，只能通过请求指标发现
+var deprecatedAPIUnscannedResources = []string{
+	"tokenreviews", "subjectaccessreviews", "localsubjectaccessreviews", "selfsubjectaccessreviews", "events",
+}
+
+const (
+	// lastAppliedConfigAnnotation kubectl apply 记录的上次应用配置
+	lastAppliedConfigAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+	upgradeReadinessListPageSize = 500
+)
+
+var kubeVersionPattern = regexp.MustCompile(`^v?(\d+)\.(\d+)`)
+
+// kubeMinorVersion Kubernetes 主次版本号
+type kubeMinorVersion struct {
+	major, minor int
+}
+
+// parseKubeMinorVersion 解析 1.29、v1.29.3、v1.28.3-eks-xxx 等版本号
+func parseKubeMinorVersion(version string) (kubeMinorVersion, error) {
+	m := kubeVersionPattern.FindStringSubmatch(strings.TrimSpace(version))
+	if m == nil {
+		return kubeMinorVersion{}, fmt.Errorf("无效的 Kubernetes 版本: %s", version)
+	}
+	major, _ := strconv.Atoi(m[1])
+	minor, _ := strconv.Atoi(m[2])
+	return kubeMinorVersion{major: major, minor: minor}, nil
+}
+
+func (v kubeMinorVersion) less(o kubeMinorVersion) bool {
+	if v.major != o.major {
+		return v.major < o.major
+	}
+	return v.minor < o.minor
+}
+
+func (v kubeMinorVersion) String() string {
+	return fmt.Sprintf("%d.%d", v.major, v.minor)
+}
+
+// deprecatedAPIStatus 计算 API 在目标版本中的状态，尚未废弃时返回空
+func deprecatedAPIStatus(api *models.DeprecatedAPI, target kubeMinorVersion) string {
+	if removed, err := parseKubeMinorVersion(api.RemovedIn); err == nil && !target.less(removed) {
+		return models.DeprecatedAPIStatusRemoved
+	}
+	if deprecated, err := parseKubeMinorVersion(api.DeprecatedIn); err == nil && !target.less(deprecated) {
+		return models.DeprecatedAPIStatusDeprecated
+	}
+	return ""
+}
+
+// deprecatedAPIVersion 返回 API 的 apiVersion，核心组为 v1 形式
+func deprecatedAPIVersion(group, version string) string {
+	if group == "" {
+		return version
+	}
+	return group + "/" + version
+}
+
+// UpgradeReadinessService 集群升级前的废弃 API 检查服务
+type UpgradeReadinessService struct {
+	prometheusSvc *PrometheusService
+
+	// configFor 获取集群监控配置，dynamicFor 获取集群动态客户端，versionFor 获取集群当前版本，now 获取当前时间，测试时可替换
+	configFor  func(clusterID uint) (*models.MonitoringConfig, error)
+	dynamicFor func(cluster *models.Cluster) (dynamic.Interface, error)
+	versionFor func(cluster *models.Cluster) (string, error)
+	now        func() time.Time
+}
+
+// NewUpgradeReadinessService 创建升级检查服务
+func NewUpgradeReadinessService(monitoringConfigSvc *MonitoringConfigService, prometheusSvc *PrometheusService) *UpgradeReadinessService {
+	return &UpgradeReadinessService{
+		prometheusSvc: prometheusSvc,
+		configFor:     monitoringConfigSvc.GetMonitoringConfig,
+		dynamicFor: func(cluster *models.Cluster) (dynamic.Interface, error) {
+			client, err := NewK8sClientForCluster(cluster)
+			if err != nil {
+				return nil, err
+			}
+			return dynamic.NewForConfig(client.GetRestConfig())
+		},
+		versionFor: func(cluster *models.Cluster) (string, error) {
+			client, err := NewK8sClientForCluster(cluster)
+			if err != nil {
+				return "", err
+			}
+			info, err := client.GetClientset().Discovery().ServerVersion()
+			if err != nil {
+				return "", err
+			}
+			return info.GitVersion, nil
+		},
+		now: time.Now,
+	}
+}
+
+// DeprecatedAPIs 获取内置的 API 废弃与移除版本
+func DeprecatedAPIs() []models.DeprecatedAPI {
+	return deprecatedAPIs
+}
+
+// Check 检查集群升级到目标版本前仍在使用的废弃 API：存量对象的 last-applied 注解与 managedFields，以及 API Server 的废弃 API 请求指标
+func (s *UpgradeReadinessService) Check(ctx context.Context, cluster *models.Cluster, query *models.UpgradeReadinessQuery) (*models.UpgradeReadinessReport, error) {
+	currentVersion, err := s.versionFor(cluster)
+	if err != nil || currentVersion == "" {
+		// 无法访问集群时使用记录的版本
+		currentVersion = cluster.Version
+	}
+	current, err := parseKubeMinorVersion(currentVersion)
+	if err != nil {
+		return nil, fmt.Errorf("无法获取集群当前版本: %s", currentVersion)
+	}
+	target := kubeMinorVersion{major: current.major, minor: current.minor + 1}
+	if query.TargetVersion != "" {
+		if target, err = parseKubeMinorVersion(query.TargetVersion); err != nil {
+			return nil, err
+		}
+		if target.less(current) {
+			return nil, fmt.Errorf("目标版本 %s 低于集群当前版本 %s", target, current)
+		}
+	}
+
+	report := &models.UpgradeReadinessReport{
+		ClusterID:      cluster.ID,
+		CurrentVersion: currentVersion,
+		TargetVersion:  target.String(),
+		Objects:        []models.DeprecatedAPIObject{},
+		Requests:       []models.DeprecatedAPIRequest{},
+		GeneratedAt:    s.now().Unix(),
+	}
+
+	client, err := s.dynamicFor(cluster)
+	if err != nil {
+		return nil, fmt.Errorf("创建K8s客户端失败: %w", err)
+	}
+	report.Objects, report.ScanErrors = scanDeprecatedAPIObjects(ctx, client, target)
+
+	if requests, err := s.deprecatedAPIRequests(ctx, cluster.ID, target); err != nil {
+		report.MetricsError = err.Error()
+	} else {
+		report.MetricsAvailable = true
+		report.Requests = requests
+	}
+
+	for _, o := range report.Objects {
+		if o.Status == models.DeprecatedAPIStatusRemoved {
+			report.Blocking++
+		} else {
+			report.Warnings++
+		}
+	}
+	for _, r := range report.Requests {
+		if r.Status == models.DeprecatedAPIStatusRemoved {
+			report.Blocking++
+		} else {
+			report.Warnings++
+		}
+	}
+	report.Ready = report.Blocking == 0
+	return report, nil
+}
+
+// scanDeprecatedAPIObjects 按资源列出存量对象，检查 last-applied 注解与 managedFields 记录的 apiVersion。
+// 同一资源的多个废弃版本只列出一次：优先使用替代版本，集群尚未提供替代版本时回退到废弃版本
+func scanDeprecatedAPIObjects(ctx context.Context, client dynamic.Interface, target kubeMinorVersion) ([]models.DeprecatedAPIObject, []string) {
+	type resourceAPIs struct {
+		candidates []schema.GroupVersionResource
+		apis       map[string]*models.DeprecatedAPI // apiVersion/kind -> API
+	}
+	var order []string
+	resources := make(map[string]*resourceAPIs)
+	for i := range deprecatedAPIs {
+		api := &deprecatedAPIs[i]
+		if containsString(deprecatedAPIUnscannedResources, api.Resource) || deprecatedAPIStatus(api, target) == "" {
+			continue
+		}
+		// 替代版本所在的组决定资源归属，如 extensions/v1beta1 Ingress 与 networking.k8s.io/v1 Ingress 为同一资源
+		group := api.Group
+		replacement, err := schema.ParseGroupVersion(api.Replacement)
+		if api.Replacement != "" && err == nil {
+			group = replacement.Group
+		}
+		key := group + "/" + api.Resource
+		r := resources[key]
+		if r == nil {
+			r = &resourceAPIs{apis: make(map[string]*models.DeprecatedAPI)}
+			resources[key] = r
+			order = append(order, key)
+		}
+		if api.Replacement != "" && err == nil {
+			r.candidates = appendGVR(r.candidates, replacement.WithResource(api.Resource))
+		}
+		r.apis[deprecatedAPIVersion(api.Group, api.Version)+"/"+api.Kind] = api
+	}
+	for _, key := range order {
+		r := resources[key]
+		// 替代版本优先，其次按表中顺序尝试废弃版本
+		for _, api := range deprecatedAPIs {
+			if r.apis[deprecatedAPIVersion(api.Group, api.Version)+"/"+api.Kind] != nil {
+				r.candidates = appendGVR(r.candidates, schema.GroupVersionResource{Group: api.Group, Version: api.Version, Resource: api.Resource})
+			}
+		}
+	}
+
+	objects := []models.DeprecatedAPIObject{}
+	var scanErrors []string
+	for _, key := range order {
+		r := resources[key]
+		items, err := listDeprecatedAPIResource(ctx, client, r.candidates)
+		if err != nil {
+			scanErrors = append(scanErrors, fmt.Sprintf("%s: %v", key, err))
+			continue
+		}
+		for i := range items {
+			objects = append(objects, deprecatedAPIObjectUsages(&items[i], r.apis, target)...)
+		}
+	}
+
+	statusRank := map[string]int{models.DeprecatedAPIStatusRemoved: 0, models.DeprecatedAPIStatusDeprecated: 1}
+	sort.SliceStable(objects, func(i, j int) bool {
+		a, b := objects[i], objects[j]
+		if a.Status != b.Status {
+			return statusRank[a.Status] < statusRank[b.Status]
+		}
+		if a.APIVersion+a.Kind != b.APIVersion+b.Kind {
+			return a.APIVersion+"/"+a.Kind < b.APIVersion+"/"+b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return objects, scanErrors
+}
+
+func appendGVR(list []schema.GroupVersionResource, gvr schema.GroupVersionResource) []schema.GroupVersionResource {
+	for _, existing := range list {
+		if existing == gvr {
+			return list
+		}
+	}
+	return append(list, gvr)
+}
+
+// listDeprecatedAPIResource 分页列出资源，依次尝试候选版本直到集群提供该版本；均未提供时返回空
+func listDeprecatedAPIResource(ctx context.Context, client dynamic.Interface, candidates []schema.GroupVersionResource) ([]unstructured.Unstructured, error) {
+	for _, gvr := range candidates {
+		items, err := listAllDynamic(ctx, client.Resource(gvr))
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		return items, err
+	}
+	return nil, nil
+}
+
+// listAllDynamic 分页列出所有命名空间的对象
+func listAllDynamic(ctx context.Context, resource dynamic.NamespaceableResourceInterface) ([]unstructured.Unstructured, error) {
+	var items []unstructured.Unstructured
+	opts := metav1.ListOptions{Limit: upgradeReadinessListPageSize}
+	for {
+		list, err := resource.List(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, list.Items...)
+		if opts.Continue = list.GetContinue(); opts.Continue == "" {
+			return items, nil
+		}
+	}
+}
+
+// deprecatedAPIObjectUsages 检查对象的 last-applied 注解与 managedFields 是否使用了废弃的 apiVersion
+func deprecatedAPIObjectUsages(obj *unstructured.Unstructured, apis map[string]*models.DeprecatedAPI, target kubeMinorVersion) []models.DeprecatedAPIObject {
+	var usages []models.DeprecatedAPIObject
+	add := func(apiVersion, source, manager string) {
+		api := apis[apiVersion+"/"+obj.GetKind()]
+		if api == nil {
+			return
+		}
+		for _, u := range usages {
+			if u.APIVersion == apiVersion && u.Source == source && u.Manager == manager {
+				return
+			}
+		}
+		usages = append(usages, models.DeprecatedAPIObject{
+			APIVersion:   apiVersion,
+			Kind:         obj.GetKind(),
+			Namespace:    obj.GetNamespace(),
+			Name:         obj.GetName(),
+			Source:       source,
+			Manager:      manager,
+			Status:       deprecatedAPIStatus(api, target),
+			DeprecatedIn: api.DeprecatedIn,
+			RemovedIn:    api.RemovedIn,
+			Replacement:  api.Replacement,
+		})
+	}
+
+	if lastApplied := obj.GetAnnotations()[lastAppliedConfigAnnotation]; lastApplied != "" {
+		var applied struct {
+			APIVersion string `json:"apiVersion"`
+		}
+		if json.Unmarshal([]byte(lastApplied), &applied) == nil {
+			add(applied.APIVersion, models.DeprecatedAPISourceLastApplied, "")
+		}
+	}
+	for _, field := range obj.GetManagedFields() {
+		add(field.APIVersion, models.DeprecatedAPISourceManagedFields, field.Manager)
+	}
+	return usages
+}
+
+// deprecatedAPIRequests 查询 apiserver_requested_deprecated_apis 指标中仍被请求的废弃 API 及近 24 小时请求数
+func (s *UpgradeReadinessService) deprecatedAPIRequests(ctx context.Context, clusterID uint, target kubeMinorVersion) ([]models.DeprecatedAPIRequest, error) {
+	config, err := s.configFor(clusterID)
+	if err != nil || config.Type == "disabled" || config.Endpoint == "" {
+		return nil, fmt.Errorf("集群未配置监控")
+	}
+	selector := s.prometheusSvc.buildClusterSelector(config.Labels, "")
+	now := s.now()
+
+	query, err := InjectPromQLSelector("max by (group, version, resource, subresource, removed_release) (apiserver_requested_deprecated_apis)", selector)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.prometheusSvc.QueryInstant(ctx, config, query, now)
+	if err != nil {
+		return nil, fmt.Errorf("查询废弃 API 指标失败: %w", err)
+	}
+
+	requests := []models.DeprecatedAPIRequest{}
+	index := make(map[string]int)
+	for _, result := range resp.Data.Result {
+		r := models.DeprecatedAPIRequest{
+			Group:       result.Metric["group"],
+			Version:     result.Metric["version"],
+			Resource:    result.Metric["resource"],
+			Subresource: result.Metric["subresource"],
+			RemovedIn:   result.Metric["removed_release"],
+		}
+		api := &models.DeprecatedAPI{RemovedIn: r.RemovedIn}
+		for i := range deprecatedAPIs {
+			if deprecatedAPIs[i].Group == r.Group && deprecatedAPIs[i].Version == r.Version && deprecatedAPIs[i].Resource == r.Resource {
+				api = &deprecatedAPIs[i]
+				break
+			}
+		}
+		if r.RemovedIn == "" {
+			r.RemovedIn = api.RemovedIn
+		}
+		r.Replacement = api.Replacement
+		// 指标只包含已废弃的 API，不在内置表中时按移除版本判断，目标版本尚未移除即视为已废弃
+		if r.Status = deprecatedAPIStatus(&models.DeprecatedAPI{DeprecatedIn: "0.0", RemovedIn: r.RemovedIn}, target); r.Status == "" {
+			continue
+		}
+		index[deprecatedAPIRequestKey(result.Metric)] = len(requests)
+		requests = append(requests, r)
+	}
+	if len(requests) == 0 {
+		return requests, nil
+	}
+
+	// 请求数只用于排序与展示，查询失败不影响结果
+	countQuery, err := InjectPromQLSelector("sum by (group, version, resource, subresource) (increase(apiserver_request_total[24h]))"+
+		" and on (group, version, resource, subresource) apiserver_requested_deprecated_apis", selector)
+	if err == nil {
+		if counts, err := s.prometheusSvc.QueryInstant(ctx, config, countQuery, now); err == nil {
+			for _, result := range counts.Data.Result {
+				i, ok := index[deprecatedAPIRequestKey(result.Metric)]
+				if !ok || len(result.Value) < 2 {
+					continue
+				}
+				if v, err := strconv.ParseFloat(fmt.Sprintf("%v", result.Value[1]), 64); err == nil && !math.IsNaN(v) {
+					requests[i].Requests = math.Round(v)
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(requests, func(i, j int) bool {
+		a, b := requests[i], requests[j]
+		if a.Status != b.Status {
+			return a.Status == models.DeprecatedAPIStatusRemoved
+		}
+		return a.Requests > b.Requests
+	})
+	return requests, nil
+}
+
+func deprecatedAPIRequestKey(metric map[string]string) string {
+	return strings.Join([]string{metric["group"], metric["version"], metric["resource"], metric["subresource"]}, "/")
+}
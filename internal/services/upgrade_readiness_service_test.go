@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// UpgradeReadinessServiceTestSuite 定义升级前废弃 API 检查测试套件
+type UpgradeReadinessServiceTestSuite struct {
+	suite.Suite
+	svc     *UpgradeReadinessService
+	cluster *models.Cluster
+}
+
+func deprecatedAPITestObject(apiVersion, kind, namespace, name, lastApplied string, managed ...metav1.ManagedFieldsEntry) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	if lastApplied != "" {
+		obj.SetAnnotations(map[string]string{lastAppliedConfigAnnotation: lastApplied})
+	}
+	obj.SetManagedFields(managed)
+	return obj
+}
+
+// SetupTest 每个测试前的设置
+func (s *UpgradeReadinessServiceTestSuite) SetupTest() {
+	listKinds := make(map[schema.GroupVersionResource]string)
+	for _, api := range deprecatedAPIs {
+		listKinds[schema.GroupVersionResource{Group: api.Group, Version: api.Version, Resource: api.Resource}] = api.Kind + "List"
+		if gv, err := schema.ParseGroupVersion(api.Replacement); api.Replacement != "" && err == nil {
+			listKinds[gv.WithResource(api.Resource)] = api.Kind + "List"
+		}
+	}
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds,
+		deprecatedAPITestObject("networking.k8s.io/v1", "Ingress", "shop", "web", `{"apiVersion":"extensions/v1beta1","kind":"Ingress"}`,
+			metav1.ManagedFieldsEntry{Manager: "kubectl-client-side-apply", APIVersion: "extensions/v1beta1"},
+			metav1.ManagedFieldsEntry{Manager: "nginx-ingress-controller", APIVersion: "networking.k8s.io/v1"},
+		),
+		deprecatedAPITestObject("batch/v1", "CronJob", "batch", "report", "",
+			metav1.ManagedFieldsEntry{Manager: "argocd-controller", APIVersion: "batch/v1beta1"},
+		),
+		deprecatedAPITestObject("apps/v1", "Deployment", "shop", "api", `{"apiVersion":"apps/v1","kind":"Deployment"}`),
+		deprecatedAPITestObject("flowcontrol.apiserver.k8s.io/v1", "FlowSchema", "", "custom", "",
+			metav1.ManagedFieldsEntry{Manager: "helm", APIVersion: "flowcontrol.apiserver.k8s.io/v1beta2"},
+		),
+		deprecatedAPITestObject("autoscaling/v2beta1", "HorizontalPodAutoscaler", "shop", "web", "",
+			metav1.ManagedFieldsEntry{Manager: "helm", APIVersion: "autoscaling/v2beta2"},
+		),
+	)
+	// 模拟集群尚未提供 autoscaling/v2，回退到 autoscaling/v2beta1 列出
+	client.PrependReactor("list", "horizontalpodautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetResource().Version == "v2" {
+			return true, nil, apierrors.NewNotFound(action.GetResource().GroupResource(), "")
+		}
+		return false, nil, nil
+	})
+
+	s.svc = NewUpgradeReadinessService(nil, NewPrometheusService())
+	s.svc.dynamicFor = func(*models.Cluster) (dynamic.Interface, error) { return client, nil }
+	s.svc.versionFor = func(*models.Cluster) (string, error) { return "v1.24.3-eks-1", nil }
+	s.svc.configFor = func(uint) (*models.MonitoringConfig, error) { return &models.MonitoringConfig{Type: "disabled"}, nil }
+	s.svc.now = func() time.Time { return time.Unix(1710000000, 0) }
+	s.cluster = &models.Cluster{ID: 1, Name: "prod", Version: "v1.23.0"}
+}
+
+func deprecatedAPIObjectKeys(objects []models.DeprecatedAPIObject) []string {
+	keys := make([]string, 0, len(objects))
+	for _, o := range objects {
+		keys = append(keys, strings.Join([]string{o.Status, o.APIVersion, o.Kind, o.Namespace, o.Name, o.Source, o.Manager}, "|"))
+	}
+	return keys
+}
+
+// TestVersion 测试版本解析与废弃状态
+func (s *UpgradeReadinessServiceTestSuite) TestVersion() {
+	v, err := parseKubeMinorVersion("v1.28.3-gke.100")
+	s.Require().NoError(err)
+	assert.Equal(s.T(), "1.28", v.String())
+	_, err = parseKubeMinorVersion("latest")
+	assert.Error(s.T(), err)
+
+	api := &models.DeprecatedAPI{DeprecatedIn: "1.21", RemovedIn: "1.25"}
+	assert.Equal(s.T(), "", deprecatedAPIStatus(api, kubeMinorVersion{1, 20}))
+	assert.Equal(s.T(), models.DeprecatedAPIStatusDeprecated, deprecatedAPIStatus(api, kubeMinorVersion{1, 24}))
+	assert.Equal(s.T(), models.DeprecatedAPIStatusRemoved, deprecatedAPIStatus(api, kubeMinorVersion{1, 25}))
+	assert.Equal(s.T(), models.DeprecatedAPIStatusRemoved, deprecatedAPIStatus(api, kubeMinorVersion{2, 0}))
+}
+
+// TestObjects 测试通过 last-applied 注解与 managedFields 发现废弃 API 的使用
+func (s *UpgradeReadinessServiceTestSuite) TestObjects() {
+	report, err := s.svc.Check(context.Background(), s.cluster, &models.UpgradeReadinessQuery{})
+	s.Require().NoError(err)
+	assert.Equal(s.T(), "v1.24.3-eks-1", report.CurrentVersion)
+	assert.Equal(s.T(), "1.25", report.TargetVersion, "默认检查下一个次版本")
+	assert.Equal(s.T(), []string{
+		"removed|batch/v1beta1|CronJob|batch|report|managed_fields|argocd-controller",
+		"removed|extensions/v1beta1|Ingress|shop|web|last_applied|",
+		"removed|extensions/v1beta1|Ingress|shop|web|managed_fields|kubectl-client-side-apply",
+		"deprecated|autoscaling/v2beta2|HorizontalPodAutoscaler|shop|web|managed_fields|helm",
+	}, deprecatedAPIObjectKeys(report.Objects), "FlowSchema v1beta2 在 1.26 才废弃")
+	assert.Equal(s.T(), "networking.k8s.io/v1", report.Objects[1].Replacement)
+	assert.Empty(s.T(), report.ScanErrors)
+	assert.False(s.T(), report.Ready)
+	assert.Equal(s.T(), 3, report.Blocking)
+	assert.Equal(s.T(), 1, report.Warnings)
+	assert.False(s.T(), report.MetricsAvailable)
+	assert.Equal(s.T(), "集群未配置监控", report.MetricsError)
+
+	report, err = s.svc.Check(context.Background(), s.cluster, &models.UpgradeReadinessQuery{TargetVersion: "v1.26"})
+	s.Require().NoError(err)
+	assert.Contains(s.T(), deprecatedAPIObjectKeys(report.Objects),
+		"deprecated|flowcontrol.apiserver.k8s.io/v1beta2|FlowSchema||custom|managed_fields|helm")
+	assert.Equal(s.T(), 4, report.Blocking, "HorizontalPodAutoscaler v2beta2 在 1.26 移除")
+	assert.Equal(s.T(), 1, report.Warnings)
+
+	report, err = s.svc.Check(context.Background(), s.cluster, &models.UpgradeReadinessQuery{TargetVersion: "1.29"})
+	s.Require().NoError(err)
+	assert.Equal(s.T(), 5, report.Blocking)
+
+	_, err = s.svc.Check(context.Background(), s.cluster, &models.UpgradeReadinessQuery{TargetVersion: "1.23"})
+	assert.EqualError(s.T(), err, "目标版本 1.23 低于集群当前版本 1.24")
+
+	s.svc.versionFor = func(*models.Cluster) (string, error) { return "", context.DeadlineExceeded }
+	report, err = s.svc.Check(context.Background(), s.cluster, &models.UpgradeReadinessQuery{})
+	s.Require().NoError(err)
+	assert.Equal(s.T(), "1.24", report.TargetVersion, "无法访问集群时使用记录的版本")
+}
+
+// TestRequests 测试 API Server 废弃 API 请求指标
+func (s *UpgradeReadinessServiceTestSuite) TestRequests() {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query().Get("query")
+		queries = append(queries, q)
+		if strings.Contains(q, "apiserver_request_total") {
+			_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[
+				{"metric":{"group":"batch","version":"v1beta1","resource":"cronjobs","subresource":""},"value":[1710000000,"120.4"]},
+				{"metric":{"group":"flowcontrol.apiserver.k8s.io","version":"v1beta3","resource":"flowschemas","subresource":""},"value":[1710000000,"3000"]}
+			]}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[
+			{"metric":{"group":"flowcontrol.apiserver.k8s.io","version":"v1beta3","resource":"flowschemas","removed_release":"1.32"},"value":[1710000000,"1"]},
+			{"metric":{"group":"batch","version":"v1beta1","resource":"cronjobs","removed_release":"1.25"},"value":[1710000000,"1"]},
+			{"metric":{"group":"example.com","version":"v1alpha1","resource":"widgets","removed_release":"1.40"},"value":[1710000000,"1"]}
+		]}}`))
+	}))
+	defer server.Close()
+	s.svc.configFor = func(uint) (*models.MonitoringConfig, error) {
+		return &models.MonitoringConfig{Type: "prometheus", Endpoint: server.URL, Labels: map[string]string{"cluster": "prod"}}, nil
+	}
+
+	report, err := s.svc.Check(context.Background(), s.cluster, &models.UpgradeReadinessQuery{})
+	s.Require().NoError(err)
+	s.Require().True(report.MetricsAvailable)
+	s.Require().Len(queries, 2)
+	assert.Contains(s.T(), queries[0], `apiserver_requested_deprecated_apis{cluster="prod"}`)
+	assert.Contains(s.T(), queries[1], `apiserver_request_total{cluster="prod"}[24h]`)
+
+	assert.Equal(s.T(), []models.DeprecatedAPIRequest{
+		{Group: "batch", Version: "v1beta1", Resource: "cronjobs", RemovedIn: "1.25", Requests: 120, Status: models.DeprecatedAPIStatusRemoved, Replacement: "batch/v1"},
+		{Group: "flowcontrol.apiserver.k8s.io", Version: "v1beta3", Resource: "flowschemas", RemovedIn: "1.32", Requests: 3000, Status: models.DeprecatedAPIStatusDeprecated, Replacement: "flowcontrol.apiserver.k8s.io/v1"},
+		{Group: "example.com", Version: "v1alpha1", Resource: "widgets", RemovedIn: "1.40", Status: models.DeprecatedAPIStatusDeprecated},
+	}, report.Requests)
+	assert.Equal(s.T(), 4, report.Blocking)
+	assert.Equal(s.T(), 3, report.Warnings)
+}
+
+// TestUpgradeReadinessServiceSuite 运行升级前废弃 API 检查测试套件
+func TestUpgradeReadinessServiceSuite(t *testing.T) {
+	suite.Run(t, new(UpgradeReadinessServiceTestSuite))
+}
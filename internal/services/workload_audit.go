@@ -0,0 +1,414 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+
+	rolloutsv1alpha1 "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// workloadAuditChecks 检查项名称、级别与修复建议，按展示顺序排列
+var workloadAuditChecks = []struct {
+	check, name, severity, solution string
+}{
+	{models.WorkloadAuditPrivileged, "特权容器", "critical", "移除 securityContext.privileged，按需只授予必要的 capabilities"},
+	{models.WorkloadAuditRunAsRoot, "以 root 用户运行", "warning", "设置 securityContext.runAsNonRoot: true 并指定非 0 的 runAsUser"},
+	{models.WorkloadAuditHostPath, "挂载 hostPath", "warning", "改用 PVC、ConfigMap 等卷类型，确需宿主机路径时设置为只读"},
+	{models.WorkloadAuditImageTag, "镜像使用 latest 或未指定标签", "warning", "使用固定版本标签或镜像摘要，保证发布可追溯、可回滚"},
+	{models.WorkloadAuditLivenessProbe, "未配置存活探针", "warning", "配置 livenessProbe，使进程假死时能被自动重启"},
+	{models.WorkloadAuditReadinessProbe, "未配置就绪探针", "warning", "配置 readinessProbe，避免流量转发到未就绪的 Pod"},
+	{models.WorkloadAuditResourceRequests, "未设置资源 Request", "warning", "为容器设置 CPU 与内存 Request，保证调度与资源保障"},
+	{models.WorkloadAuditResourceLimits, "未设置资源 Limit", "info", "为容器设置 CPU 与内存 Limit，防止单个容器耗尽节点资源"},
+	{models.WorkloadAuditPDB, "多副本工作负载缺少 PDB", "warning", "创建 PodDisruptionBudget，避免节点维护时副本被同时驱逐"},
+	{models.WorkloadAuditSingleReplica, "生产环境单副本", "warning", "将副本数调整为至少 2 个，并配合反亲和性分散到不同节点"},
+}
+
+// workloadAuditDeductions 每个违反的检查项在工作负载评分中的扣分（同一检查项多个容器只扣一次）
+var workloadAuditDeductions = map[string]int{"critical": 30, "warning": 10, "info": 3}
+
+// workloadAuditSystemNamespaces 默认不检查的系统命名空间
+var workloadAuditSystemNamespaces = []string{"kube-system", "kube-public", "kube-node-lease"}
+
+// workloadAuditInput 待检查的集群对象
+type workloadAuditInput struct {
+	Namespaces   []*corev1.Namespace
+	Deployments  []*appsv1.Deployment
+	StatefulSets []*appsv1.StatefulSet
+	DaemonSets   []*appsv1.DaemonSet
+	CronJobs     []*batchv1.CronJob
+	Rollouts     []*rolloutsv1alpha1.Rollout
+	PDBs         []*policyv1.PodDisruptionBudget
+}
+
+// auditedWorkload 工作负载中与检查相关的字段
+type auditedWorkload struct {
+	kind     string
+	meta     metav1.ObjectMeta
+	replicas int32
+	template *corev1.PodTemplateSpec
+	longRun  bool // 常驻服务：需要探针；CronJob 不需要
+	scalable bool // 按副本数部署：需要 PDB 与多副本；DaemonSet、CronJob 不需要
+}
+
+// loadWorkloadAuditInput 从诊断数据来源获取待检查的对象
+func loadWorkloadAuditInput(ctx context.Context, env *DiagnosisEnv) (*workloadAuditInput, error) {
+	in := &workloadAuditInput{}
+	var err error
+	if in.Namespaces, err = env.Namespaces(ctx); err != nil {
+		return nil, err
+	}
+	if in.Deployments, err = env.Deployments(ctx); err != nil {
+		return nil, err
+	}
+	if in.StatefulSets, err = env.StatefulSets(ctx); err != nil {
+		return nil, err
+	}
+	if in.DaemonSets, err = env.DaemonSets(ctx); err != nil {
+		return nil, err
+	}
+	if in.CronJobs, err = env.CronJobs(ctx); err != nil {
+		return nil, err
+	}
+	if in.Rollouts, err = env.Rollouts(ctx); err != nil {
+		return nil, err
+	}
+	if in.PDBs, err = env.PodDisruptionBudgets(ctx); err != nil {
+		return nil, err
+	}
+	return in, nil
+}
+
+// workloads 汇总各类工作负载
+func (in *workloadAuditInput) workloads() []auditedWorkload {
+	replicas := func(r *int32) int32 {
+		if r == nil {
+			return 1
+		}
+		return *r
+	}
+	var result []auditedWorkload
+	for _, d := range in.Deployments {
+		result = append(result, auditedWorkload{kind: "Deployment", meta: d.ObjectMeta, replicas: replicas(d.Spec.Replicas), template: &d.Spec.Template, longRun: true, scalable: true})
+	}
+	for _, s := range in.StatefulSets {
+		result = append(result, auditedWorkload{kind: "StatefulSet", meta: s.ObjectMeta, replicas: replicas(s.Spec.Replicas), template: &s.Spec.Template, longRun: true, scalable: true})
+	}
+	for _, d := range in.DaemonSets {
+		result = append(result, auditedWorkload{kind: "DaemonSet", meta: d.ObjectMeta, replicas: d.Status.DesiredNumberScheduled, template: &d.Spec.Template, longRun: true})
+	}
+	for _, c := range in.CronJobs {
+		result = append(result, auditedWorkload{kind: "CronJob", meta: c.ObjectMeta, template: &c.Spec.JobTemplate.Spec.Template})
+	}
+	for _, r := range in.Rollouts {
+		result = append(result, auditedWorkload{kind: "Rollout", meta: r.ObjectMeta, replicas: replicas(r.Spec.Replicas), template: &r.Spec.Template, longRun: true, scalable: true})
+	}
+	return result
+}
+
+// auditWorkloads 检查工作负载配置是否符合最佳实践，按工作负载、命名空间与集群评分
+func auditWorkloads(in *workloadAuditInput, query *models.WorkloadAuditQuery, now time.Time) *models.WorkloadAuditReport {
+	production := make(map[string]bool, len(in.Namespaces))
+	for _, ns := range in.Namespaces {
+		production[ns.Name] = isProductionNamespace(ns)
+	}
+	pdbs := make(map[string][]labels.Selector)
+	for _, pdb := range in.PDBs {
+		if selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector); err == nil && !selector.Empty() {
+			pdbs[pdb.Namespace] = append(pdbs[pdb.Namespace], selector)
+		}
+	}
+
+	report := &models.WorkloadAuditReport{
+		Namespaces:  []models.WorkloadAuditNamespace{},
+		Checks:      []models.WorkloadAuditCheckSummary{},
+		Items:       []models.WorkloadAuditItem{},
+		GeneratedAt: now.Unix(),
+	}
+	namespaces := make(map[string]*models.WorkloadAuditNamespace)
+	violations := make(map[string]int)
+	totalScore := 0
+	for _, w := range in.workloads() {
+		if query.Namespace != "" && w.meta.Namespace != query.Namespace {
+			continue
+		}
+		if query.Namespace == "" && !query.IncludeSystem && containsString(workloadAuditSystemNamespaces, w.meta.Namespace) {
+			continue
+		}
+
+		findings := auditWorkload(&w, production[w.meta.Namespace], pdbs[w.meta.Namespace])
+		score := 100
+		seen := make(map[string]bool)
+		for _, f := range findings {
+			if !seen[f.Check] {
+				seen[f.Check] = true
+				violations[f.Check]++
+				score -= workloadAuditDeductions[f.Severity]
+			}
+		}
+		if score < 0 {
+			score = 0
+		}
+
+		ns := namespaces[w.meta.Namespace]
+		if ns == nil {
+			ns = &models.WorkloadAuditNamespace{Namespace: w.meta.Namespace, Production: production[w.meta.Namespace]}
+			namespaces[w.meta.Namespace] = ns
+		}
+		ns.Workloads++
+		ns.Score += score
+		report.Workloads++
+		totalScore += score
+		for _, f := range findings {
+			switch f.Severity {
+			case "critical":
+				ns.Critical++
+				report.Critical++
+			case "warning":
+				ns.Warning++
+				report.Warning++
+			default:
+				ns.Info++
+				report.Info++
+			}
+		}
+
+		// 展示过滤只影响返回的问题列表，不影响评分
+		shown := []models.WorkloadAuditFinding{}
+		for _, f := range findings {
+			if (query.Severity == "" || f.Severity == query.Severity) && (query.Check == "" || f.Check == query.Check) {
+				shown = append(shown, f)
+			}
+		}
+		if len(shown) > 0 {
+			report.Items = append(report.Items, models.WorkloadAuditItem{
+				Kind: w.kind, Namespace: w.meta.Namespace, Name: w.meta.Name, Replicas: w.replicas, Score: score, Findings: shown,
+			})
+		}
+	}
+
+	report.Score = 100
+	if report.Workloads > 0 {
+		report.Score = totalScore / report.Workloads
+	}
+	for _, ns := range namespaces {
+		ns.Score /= ns.Workloads
+		report.Namespaces = append(report.Namespaces, *ns)
+	}
+	sort.Slice(report.Namespaces, func(i, j int) bool {
+		a, b := report.Namespaces[i], report.Namespaces[j]
+		if a.Score != b.Score {
+			return a.Score < b.Score
+		}
+		return a.Namespace < b.Namespace
+	})
+	sort.SliceStable(report.Items, func(i, j int) bool {
+		a, b := report.Items[i], report.Items[j]
+		if a.Score != b.Score {
+			return a.Score < b.Score
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	for _, c := range workloadAuditChecks {
+		if violations[c.check] > 0 {
+			report.Checks = append(report.Checks, models.WorkloadAuditCheckSummary{Check: c.check, Name: c.name, Severity: c.severity, Workloads: violations[c.check]})
+		}
+	}
+	sort.SliceStable(report.Checks, func(i, j int) bool { return report.Checks[i].Workloads > report.Checks[j].Workloads })
+	return report
+}
+
+// auditWorkload 检查单个工作负载，忽略注解中列出的检查项
+func auditWorkload(w *auditedWorkload, production bool, pdbs []labels.Selector) []models.WorkloadAuditFinding {
+	ignored := make(map[string]bool)
+	for _, check := range strings.Split(w.meta.Annotations[models.WorkloadAuditIgnoreAnnotation], ",") {
+		if check = strings.TrimSpace(check); check != "" {
+			ignored[check] = true
+		}
+	}
+	findings := []models.WorkloadAuditFinding{}
+	add := func(check, container, format string, args ...interface{}) {
+		if ignored["*"] || ignored[check] {
+			return
+		}
+		for _, c := range workloadAuditChecks {
+			if c.check == check {
+				findings = append(findings, models.WorkloadAuditFinding{
+					Check: check, Severity: c.severity, Container: container, Message: fmt.Sprintf(format, args...), Solution: c.solution,
+				})
+				return
+			}
+		}
+	}
+
+	spec := &w.template.Spec
+	for _, c := range spec.InitContainers {
+		auditContainerSecurity(&c, spec.SecurityContext, add)
+	}
+	for _, c := range spec.Containers {
+		auditContainerSecurity(&c, spec.SecurityContext, add)
+		if w.longRun {
+			if c.LivenessProbe == nil {
+				add(models.WorkloadAuditLivenessProbe, c.Name, "容器 %s 未配置 livenessProbe", c.Name)
+			}
+			if c.ReadinessProbe == nil {
+				add(models.WorkloadAuditReadinessProbe, c.Name, "容器 %s 未配置 readinessProbe", c.Name)
+			}
+		}
+		if missing := missingContainerResources(c.Resources.Requests); len(missing) > 0 {
+			add(models.WorkloadAuditResourceRequests, c.Name, "容器 %s 未设置 %s Request", c.Name, strings.Join(missing, "、"))
+		}
+		if missing := missingContainerResources(c.Resources.Limits); len(missing) > 0 {
+			add(models.WorkloadAuditResourceLimits, c.Name, "容器 %s 未设置 %s Limit", c.Name, strings.Join(missing, "、"))
+		}
+	}
+	for _, v := range spec.Volumes {
+		if v.HostPath != nil {
+			add(models.WorkloadAuditHostPath, "", "卷 %s 挂载宿主机路径 %s", v.Name, v.HostPath.Path)
+		}
+	}
+
+	if w.scalable {
+		if w.replicas > 1 && !workloadCoveredByPDB(w.template.Labels, pdbs) {
+			add(models.WorkloadAuditPDB, "", "%d 个副本，没有 PodDisruptionBudget 覆盖", w.replicas)
+		}
+		if w.replicas == 1 && production {
+			add(models.WorkloadAuditSingleReplica, "", "生产命名空间 %s 中只有 1 个副本", w.meta.Namespace)
+		}
+	}
+	return findings
+}
+
+// auditContainerSecurity 检查镜像标签、特权与 root 用户，容器级 securityContext 优先于 Pod 级
+func auditContainerSecurity(c *corev1.Container, podSC *corev1.PodSecurityContext, add func(check, container, format string, args ...interface{})) {
+	if !imageHasFixedTag(c.Image) {
+		add(models.WorkloadAuditImageTag, c.Name, "容器 %s 镜像 %s 未固定版本", c.Name, c.Image)
+	}
+
+	var runAsNonRoot *bool
+	var runAsUser *int64
+	if podSC != nil {
+		runAsNonRoot, runAsUser = podSC.RunAsNonRoot, podSC.RunAsUser
+	}
+	if sc := c.SecurityContext; sc != nil {
+		if sc.Privileged != nil && *sc.Privileged {
+			add(models.WorkloadAuditPrivileged, c.Name, "容器 %s 以特权模式运行", c.Name)
+		}
+		if sc.RunAsNonRoot != nil {
+			runAsNonRoot = sc.RunAsNonRoot
+		}
+		if sc.RunAsUser != nil {
+			runAsUser = sc.RunAsUser
+		}
+	}
+	switch {
+	case runAsUser != nil && *runAsUser == 0:
+		add(models.WorkloadAuditRunAsRoot, c.Name, "容器 %s 指定以 root（UID 0）运行", c.Name)
+	case runAsUser == nil && (runAsNonRoot == nil || !*runAsNonRoot):
+		add(models.WorkloadAuditRunAsRoot, c.Name, "容器 %s 未设置 runAsNonRoot 或 runAsUser，可能以 root 运行", c.Name)
+	}
+}
+
+// imageHasFixedTag 镜像是否指定了摘要或非 latest 的标签
+func imageHasFixedTag(image string) bool {
+	if strings.Contains(image, "@") {
+		return true
+	}
+	name := image[strings.LastIndex(image, "/")+1:]
+	i := strings.LastIndex(name, ":")
+	return i >= 0 && name[i+1:] != "" && name[i+1:] != "latest"
+}
+
+// missingContainerResources 返回未设置的 CPU / 内存资源
+func missingContainerResources(list corev1.ResourceList) []string {
+	var missing []string
+	if _, ok := list[corev1.ResourceCPU]; !ok {
+		missing = append(missing, "CPU")
+	}
+	if _, ok := list[corev1.ResourceMemory]; !ok {
+		missing = append(missing, "内存")
+	}
+	return missing
+}
+
+// workloadCoveredByPDB Pod 模板标签是否被命名空间内的某个 PDB 选中
+func workloadCoveredByPDB(podLabels map[string]string, pdbs []labels.Selector) bool {
+	for _, selector := range pdbs {
+		if selector.Matches(labels.Set(podLabels)) {
+			return true
+		}
+	}
+	return false
+}
+
+// isProductionNamespace 命名空间标签 env / environment 为 prod、production，或名称为 prod、以 prod- 开头、以 -prod 结尾时视为生产环境
+func isProductionNamespace(ns *corev1.Namespace) bool {
+	for _, key := range []string{"env", "environment"} {
+		switch strings.ToLower(ns.Labels[key]) {
+		case "prod", "production":
+			return true
+		}
+	}
+	name := strings.ToLower(ns.Name)
+	return name == "prod" || name == "production" || strings.HasPrefix(name, "prod-") || strings.HasSuffix(name, "-prod")
+}
+
+// workloadAuditRisks 把检查报告转换为健康诊断风险项：每个存在严重或警告问题的工作负载一项，级别取最高
+func workloadAuditRisks(report *models.WorkloadAuditReport, maxReported int) []models.RiskItem {
+	risks := []models.RiskItem{}
+	for _, item := range report.Items {
+		severity := ""
+		var problems []string
+		for _, f := range item.Findings {
+			switch f.Severity {
+			case "critical":
+				severity = "critical"
+			case "warning":
+				if severity == "" {
+					severity = "warning"
+				}
+			default:
+				continue
+			}
+			name := f.Check
+			for _, c := range workloadAuditChecks {
+				if c.check == f.Check {
+					name = c.name
+				}
+			}
+			if !containsString(problems, name) {
+				problems = append(problems, name)
+			}
+		}
+		if severity == "" {
+			continue
+		}
+		if len(risks) >= maxReported {
+			break
+		}
+		risks = append(risks, models.RiskItem{
+			ID:          fmt.Sprintf("best-practice-%s-%s-%s", strings.ToLower(item.Kind), item.Namespace, item.Name),
+			Category:    "best_practice",
+			Severity:    severity,
+			Title:       fmt.Sprintf("%s 配置不符合最佳实践", item.Kind),
+			Description: fmt.Sprintf("%s %s/%s（评分 %d）: %s", item.Kind, item.Namespace, item.Name, item.Score, strings.Join(problems, "、")),
+			Resource:    item.Name,
+			Namespace:   item.Namespace,
+			Solution:    "在运维中心的工作负载检查报告中查看具体问题与修复建议",
+		})
+	}
+	return risks
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/clay-wangzhi/KubePolaris/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// WorkloadAuditTestSuite 定义工作负载最佳实践检查测试套件
+type WorkloadAuditTestSuite struct {
+	suite.Suite
+	now time.Time
+	in  *workloadAuditInput
+}
+
+func boolPtr(v bool) *bool { return &v }
+
+// compliantPodTemplate 返回满足全部检查项的 Pod 模板
+func compliantPodTemplate(app string) corev1.PodTemplateSpec {
+	resources := corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m"), corev1.ResourceMemory: resource.MustParse("128Mi")}
+	return corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": app}},
+		Spec: corev1.PodSpec{
+			SecurityContext: &corev1.PodSecurityContext{RunAsNonRoot: boolPtr(true)},
+			Containers: []corev1.Container{{
+				Name:           app,
+				Image:          "registry.example.com:5000/team/" + app + ":1.2.0",
+				LivenessProbe:  &corev1.Probe{},
+				ReadinessProbe: &corev1.Probe{},
+				Resources:      corev1.ResourceRequirements{Requests: resources, Limits: resources},
+			}},
+		},
+	}
+}
+
+func (s *WorkloadAuditTestSuite) SetupTest() {
+	s.now = time.Unix(1710000000, 0)
+
+	good := compliantPodTemplate("good")
+
+	bad := compliantPodTemplate("bad")
+	bad.Spec.Containers[0].Image = "nginx"
+	bad.Spec.Containers[0].LivenessProbe = nil
+	bad.Spec.Containers[0].SecurityContext = &corev1.SecurityContext{Privileged: boolPtr(true), RunAsUser: int64Ptr(0)}
+	bad.Spec.Volumes = []corev1.Volume{{Name: "docker", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/var/run/docker.sock"}}}}
+
+	ignored := compliantPodTemplate("ignored")
+	ignored.Spec.Containers[0].Image = "busybox:latest"
+	ignored.Spec.Containers[0].Resources = corev1.ResourceRequirements{}
+
+	agent := compliantPodTemplate("agent")
+	agent.Spec.Volumes = []corev1.Volume{{Name: "logs", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/var/log"}}}}
+
+	job := compliantPodTemplate("job")
+	job.Spec.Containers[0].LivenessProbe = nil
+	job.Spec.Containers[0].ReadinessProbe = nil
+	job.Spec.InitContainers = []corev1.Container{{Name: "init", Image: "alpine@sha256:abc"}}
+
+	s.in = &workloadAuditInput{
+		Namespaces: []*corev1.Namespace{
+			{ObjectMeta: metav1.ObjectMeta{Name: "shop-prod"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "payment", Labels: map[string]string{"env": "Production"}}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "dev"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}},
+		},
+		Deployments: []*appsv1.Deployment{
+			{ObjectMeta: metav1.ObjectMeta{Name: "good", Namespace: "shop-prod"}, Spec: appsv1.DeploymentSpec{Replicas: int32Ptr(3), Template: good}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "single", Namespace: "payment"}, Spec: appsv1.DeploymentSpec{Replicas: int32Ptr(1), Template: compliantPodTemplate("single")}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "bad", Namespace: "dev"}, Spec: appsv1.DeploymentSpec{Replicas: int32Ptr(2), Template: bad}},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "ignored", Namespace: "dev", Annotations: map[string]string{models.WorkloadAuditIgnoreAnnotation: "image_tag, resource_requests"}},
+				Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(1), Template: ignored},
+			},
+			{ObjectMeta: metav1.ObjectMeta{Name: "coredns", Namespace: "kube-system"}, Spec: appsv1.DeploymentSpec{Replicas: int32Ptr(2), Template: bad}},
+		},
+		DaemonSets: []*appsv1.DaemonSet{
+			{ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "dev"}, Spec: appsv1.DaemonSetSpec{Template: agent}, Status: appsv1.DaemonSetStatus{DesiredNumberScheduled: 3}},
+		},
+		CronJobs: []*batchv1.CronJob{
+			{ObjectMeta: metav1.ObjectMeta{Name: "report", Namespace: "dev"}, Spec: batchv1.CronJobSpec{JobTemplate: batchv1.JobTemplateSpec{Spec: batchv1.JobSpec{Template: job}}}},
+		},
+		PDBs: []*policyv1.PodDisruptionBudget{
+			{ObjectMeta: metav1.ObjectMeta{Name: "good", Namespace: "shop-prod"}, Spec: policyv1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "good"}}}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "bad", Namespace: "shop-prod"}, Spec: policyv1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "bad"}}}},
+		},
+	}
+}
+
+func findingChecks(item models.WorkloadAuditItem) []string {
+	checks := make([]string, 0, len(item.Findings))
+	for _, f := range item.Findings {
+		checks = append(checks, f.Check)
+	}
+	return checks
+}
+
+func findAuditItem(report *models.WorkloadAuditReport, name string) *models.WorkloadAuditItem {
+	for i := range report.Items {
+		if report.Items[i].Name == name {
+			return &report.Items[i]
+		}
+	}
+	return nil
+}
+
+// TestChecks 测试各检查项与忽略注解
+func (s *WorkloadAuditTestSuite) TestChecks() {
+	report := auditWorkloads(s.in, &models.WorkloadAuditQuery{}, s.now)
+
+	assert.Nil(s.T(), findAuditItem(report, "good"), "符合全部检查项且被 PDB 覆盖")
+	assert.Nil(s.T(), findAuditItem(report, "coredns"), "默认不检查系统命名空间")
+
+	bad := findAuditItem(report, "bad")
+	s.Require().NotNil(bad)
+	assert.Equal(s.T(), []string{
+		models.WorkloadAuditImageTag, models.WorkloadAuditPrivileged, models.WorkloadAuditRunAsRoot,
+		models.WorkloadAuditLivenessProbe, models.WorkloadAuditHostPath, models.WorkloadAuditPDB,
+	}, findingChecks(*bad), "其他命名空间的 PDB 不覆盖")
+	assert.Equal(s.T(), 100-30-10*5, bad.Score)
+
+	single := findAuditItem(report, "single")
+	s.Require().NotNil(single)
+	assert.Equal(s.T(), []string{models.WorkloadAuditSingleReplica}, findingChecks(*single), "通过 env 标签识别生产命名空间")
+
+	ignored := findAuditItem(report, "ignored")
+	s.Require().NotNil(ignored)
+	assert.Equal(s.T(), []string{models.WorkloadAuditResourceLimits}, findingChecks(*ignored))
+	assert.Equal(s.T(), 97, ignored.Score)
+
+	agent := findAuditItem(report, "agent")
+	s.Require().NotNil(agent)
+	assert.Equal(s.T(), []string{models.WorkloadAuditHostPath}, findingChecks(*agent), "DaemonSet 不检查 PDB")
+
+	assert.Nil(s.T(), findAuditItem(report, "report"), "CronJob 不检查探针，init 容器只检查安全配置")
+}
+
+// TestScores 测试命名空间与集群评分及排序
+func (s *WorkloadAuditTestSuite) TestScores() {
+	report := auditWorkloads(s.in, &models.WorkloadAuditQuery{}, s.now)
+
+	assert.Equal(s.T(), 6, report.Workloads)
+	assert.Equal(s.T(), (100+90+20+97+90+100)/6, report.Score)
+	assert.Equal(s.T(), []models.WorkloadAuditNamespace{
+		{Namespace: "dev", Score: (20 + 97 + 90 + 100) / 4, Workloads: 4, Critical: 1, Warning: 6, Info: 1},
+		{Namespace: "payment", Production: true, Score: 90, Workloads: 1, Warning: 1},
+		{Namespace: "shop-prod", Production: true, Score: 100, Workloads: 1},
+	}, report.Namespaces)
+	assert.Equal(s.T(), []string{"bad", "agent", "single", "ignored"}, []string{
+		report.Items[0].Name, report.Items[1].Name, report.Items[2].Name, report.Items[3].Name,
+	})
+	s.Require().NotEmpty(report.Checks)
+	assert.Equal(s.T(), models.WorkloadAuditCheckSummary{Check: models.WorkloadAuditHostPath, Name: "挂载 hostPath", Severity: "warning", Workloads: 2}, report.Checks[0])
+
+	system := auditWorkloads(s.in, &models.WorkloadAuditQuery{IncludeSystem: true}, s.now)
+	assert.Equal(s.T(), 7, system.Workloads)
+	scoped := auditWorkloads(s.in, &models.WorkloadAuditQuery{Namespace: "kube-system"}, s.now)
+	assert.Equal(s.T(), 1, scoped.Workloads, "指定命名空间时检查系统命名空间")
+	assert.Equal(s.T(), 20, scoped.Score)
+}
+
+// TestFilters 测试展示过滤不影响评分
+func (s *WorkloadAuditTestSuite) TestFilters() {
+	report := auditWorkloads(s.in, &models.WorkloadAuditQuery{Severity: "critical"}, s.now)
+	s.Require().Len(report.Items, 1)
+	assert.Equal(s.T(), []string{models.WorkloadAuditPrivileged}, findingChecks(report.Items[0]))
+	assert.Equal(s.T(), 20, report.Items[0].Score)
+
+	report = auditWorkloads(s.in, &models.WorkloadAuditQuery{Check: models.WorkloadAuditHostPath, Namespace: "dev"}, s.now)
+	assert.Equal(s.T(), []string{"bad", "agent"}, []string{report.Items[0].Name, report.Items[1].Name})
+	assert.Equal(s.T(), 4, report.Workloads)
+}
+
+// TestRisks 测试转换为健康诊断风险项
+func (s *WorkloadAuditTestSuite) TestRisks() {
+	report := auditWorkloads(s.in, &models.WorkloadAuditQuery{}, s.now)
+	risks := workloadAuditRisks(report, 10)
+	assert.Equal(s.T(), []string{
+		"best-practice-deployment-dev-bad", "best-practice-daemonset-dev-agent", "best-practice-deployment-payment-single",
+	}, riskIDs(risks), "只有 info 级别问题的工作负载不报告")
+	assert.Equal(s.T(), "critical", risks[0].Severity)
+	assert.Equal(s.T(), "warning", risks[1].Severity)
+	assert.Len(s.T(), workloadAuditRisks(report, 1), 1)
+}
+
+// TestImageTag 测试镜像标签识别
+func (s *WorkloadAuditTestSuite) TestImageTag() {
+	for image, fixed := range map[string]bool{
+		"nginx":                         false,
+		"nginx:latest":                  false,
+		"registry:5000/nginx":           false,
+		"registry:5000/nginx:1.25":      true,
+		"nginx@sha256:0123":             true,
+		"ghcr.io/org/app:v1.0.0-alpine": true,
+	} {
+		assert.Equal(s.T(), fixed, imageHasFixedTag(image), image)
+	}
+}
+
+// TestDiagnosisRule 测试通过诊断数据来源执行检查
+func (s *WorkloadAuditTestSuite) TestDiagnosisRule() {
+	client := fake.NewSimpleClientset(s.in.Namespaces[0], s.in.Deployments[0], s.in.Deployments[2], s.in.PDBs[0])
+	env := &DiagnosisEnv{ClusterID: 1, Client: client, Now: s.now}
+	in, err := loadWorkloadAuditInput(context.Background(), env)
+	s.Require().NoError(err)
+	assert.Len(s.T(), in.Deployments, 2)
+
+	rule, ok := DefaultDiagnosisRules.Get("workload-best-practice")
+	s.Require().True(ok)
+	result, err := rule.Evaluate(context.Background(), env, diagnosisRuleParams(rule.Meta(), nil))
+	s.Require().NoError(err)
+	assert.Equal(s.T(), []string{"best-practice-deployment-dev-bad"}, riskIDs(result.Risks))
+	assert.Equal(s.T(), 40, result.Deduction)
+}
+
+// TestWorkloadAuditSuite 运行工作负载最佳实践检查测试套件
+func TestWorkloadAuditSuite(t *testing.T) {
+	suite.Run(t, new(WorkloadAuditTestSuite))
+}